@@ -3,9 +3,39 @@ package main
 import (
 	"3dshelf/internal/config"
 	"3dshelf/internal/handlers"
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"3dshelf/internal/server"
+	"3dshelf/internal/version"
+	"3dshelf/pkg/bambu"
 	"3dshelf/pkg/database"
+	"3dshelf/pkg/demo"
+	"3dshelf/pkg/distribute"
+	"3dshelf/pkg/extractor"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/importer"
+	"3dshelf/pkg/ipfs"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/linkcheck"
+	"3dshelf/pkg/maintenance"
+	"3dshelf/pkg/octoprint"
+	"3dshelf/pkg/preview"
+	"3dshelf/pkg/replication"
+	"3dshelf/pkg/requestlog"
+	"3dshelf/pkg/retention"
+	"3dshelf/pkg/scanner"
+	"3dshelf/pkg/signing"
+	"3dshelf/pkg/slicer"
+	"3dshelf/pkg/snapshot"
+	"3dshelf/pkg/telemetry"
+	"3dshelf/pkg/thumbnail"
+	"3dshelf/pkg/updates"
+	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -23,10 +53,38 @@ func main() {
 		log.Fatal("Configuration validation failed:", err)
 	}
 
+	build := version.Get()
+	log.Printf("3DShelf %s (commit %s)", build.Version, build.Commit)
 	log.Printf("Configuration validated successfully:")
 	log.Printf("  - Scan path: %s", cfg.ScanPath)
 	log.Printf("  - Database: %s", cfg.DatabasePath)
 	log.Printf("  - Port: %s", cfg.Port)
+	log.Printf("  - Slow query threshold: %v", cfg.SlowQueryThreshold)
+	log.Printf("  - Mode: %s", cfg.Mode)
+
+	// Everything created in the library gets the configured owner and modes
+	if err := fileperm.Set(cfg.FilePolicy()); err != nil {
+		log.Fatal("Invalid file permissions:", err)
+	}
+	if err := fileperm.Preflight(cfg.ScanPath); err != nil {
+		log.Fatal("Permission check failed, see FILE_UID, FILE_GID, DIR_MODE and FILE_MODE:", err)
+	}
+	if policy := cfg.FilePolicy(); policy.UID != fileperm.KeepOwner || policy.GID != fileperm.KeepOwner {
+		log.Printf("  - New files: owner %d:%d, modes %#o/%#o", policy.UID, policy.GID, policy.DirMode, policy.FileMode)
+	}
+
+	// Upload temp files go where configured, so on the library's filesystem
+	// staging them is a rename. TMPDIR applies to the whole process, so other
+	// temp files, such as those of quotes and slicing, go there too.
+	if cfg.UploadTempDir != "" {
+		if err := os.Setenv("TMPDIR", cfg.UploadTempDir); err != nil {
+			log.Fatal("Failed to set upload temp directory:", err)
+		}
+		log.Printf("  - Upload temp directory: %s", cfg.UploadTempDir)
+	}
+	if err := handlers.SetMultipartParts(cfg.UploadMaxFiles); err != nil {
+		log.Fatal("Failed to set multipart limits:", err)
+	}
 
 	// Set Gin mode
 	gin.SetMode(cfg.GinMode)
@@ -36,24 +94,327 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// Instrument database queries for the slow query log and metrics
+	queryStats, err := database.Instrument(database.GetDB(), cfg.SlowQueryThreshold)
+	if err != nil {
+		log.Fatal("Failed to instrument database:", err)
+	}
+
+	// Signer for expiring download URLs
+	signer := signing.New([]byte(cfg.DownloadSigningSecret))
+	if cfg.DownloadSigningSecret == "" {
+		log.Printf("  - DOWNLOAD_SIGNING_SECRET not set, signed download URLs will not survive restarts")
+		if signer, err = signing.NewRandom(); err != nil {
+			log.Fatal("Failed to create download URL signer:", err)
+		}
+	}
+
+	// Feature flags come from config unless saved through the admin API
+	featureFlags := features.New()
+	if err := featureFlags.Set(features.ParseList(cfg.FeatureFlags)); err != nil {
+		log.Fatal("Invalid FEATURE_FLAGS:", err)
+	}
+	savedFlags := make(map[features.Flag]bool)
+	if _, err := database.LoadSetting(database.GetDB(), features.SettingKey, &savedFlags); err != nil {
+		log.Fatal("Failed to load feature flags:", err)
+	}
+	if err := featureFlags.Set(savedFlags); err != nil {
+		log.Printf("Warning: Ignoring saved feature flags: %v", err)
+	}
+
+	// Maintenance mode survives restarts so it stays on across a migration
+	maintenanceMode := maintenance.New()
+	var savedMaintenance maintenance.State
+	if _, err := database.LoadSetting(database.GetDB(), maintenance.SettingKey, &savedMaintenance); err != nil {
+		log.Fatal("Failed to load maintenance mode:", err)
+	}
+	if _, err := maintenanceMode.Set(savedMaintenance); err != nil {
+		log.Printf("Warning: Ignoring saved maintenance mode: %v", err)
+	}
+	if maintenanceMode.Enabled() {
+		log.Printf("  - Maintenance mode is on, changes are refused until it is disabled")
+	}
+
 	// Create handlers
 	projectsHandler := handlers.NewProjectsHandler(cfg.ScanPath)
+	projectsHandler.SetWriteSidecars(cfg.WriteSidecars)
+	projectsHandler.SetPublicURL(cfg.PublicURL)
+	projectsHandler.SetConflictPolicy(handlers.ConflictResolution(cfg.UploadConflictPolicy))
+	projectsHandler.SetDirNaming(cfg.DirNaming())
+	projectsHandler.SetMaxUploadFiles(cfg.UploadMaxFiles)
+
+	// Project detection rules come from config unless saved through the admin API
+	detection := scanner.DetectionRules{
+		MinFiles:              cfg.ProjectMinFiles,
+		READMEOnlyWithSidecar: cfg.ProjectREADMEOnlyWithSidecar,
+		ImageOnlyWithSidecar:  cfg.ProjectImageOnlyWithSidecar,
+	}
+	for _, fileType := range cfg.ProjectFileTypes {
+		detection.FileTypes = append(detection.FileTypes, models.FileType(fileType))
+	}
+	if _, err := database.LoadSetting(database.GetDB(), scanner.DetectionSettingKey, &detection); err != nil {
+		log.Fatal("Failed to load project detection settings:", err)
+	}
+	if err := detection.Validate(); err != nil {
+		log.Fatal("Invalid project detection settings:", err)
+	}
+	projectsHandler.Scanner().SetDetectionRules(detection)
+
+	flatMode, err := scanner.ParseFlatMode(cfg.FlatFileMode)
+	if err != nil {
+		log.Fatal("Invalid FLAT_FILE_MODE:", err)
+	}
+	projectsHandler.Scanner().SetFlatMode(flatMode)
+
+	extractors := extractor.New(cfg.ExtractorTimeout)
+	if err := extractors.RegisterList(cfg.Extractors); err != nil {
+		log.Fatal("Invalid EXTRACTORS:", err)
+	}
+	projectsHandler.Scanner().SetExtractors(extractors)
+
+	texts := extractor.NewText(cfg.ExtractorTimeout)
+	if err := texts.RegisterList(cfg.TextExtractors); err != nil {
+		log.Fatal("Invalid TEXT_EXTRACTORS:", err)
+	}
+	projectsHandler.Scanner().SetTextExtractors(texts)
+
+	images := imaging.New(imaging.Options{
+		StripMetadata: cfg.ImageStripMetadata,
+		WebPQuality:   cfg.ImageWebPQuality,
+		WebPEncoder:   cfg.ImageWebPEncoder,
+	})
+	if err := images.CheckEncoder(); err != nil {
+		log.Fatal("Invalid IMAGE_WEBP_ENCODER:", err)
+	}
+	projectsHandler.SetImageNormalizer(images)
+	projectsHandler.SetSnapshots(snapshot.New(cfg.SnapshotDir()))
+
+	scanRunsHandler := handlers.NewScanRunsHandler()
+	deviceTokensHandler := handlers.NewDeviceTokensHandler()
+	thumbnailCache := thumbnail.New(cfg.ThumbnailDir())
+	thumbnailsHandler := handlers.NewThumbnailsHandler(thumbnailCache)
+	projectsHandler.Scanner().SetThumbnails(thumbnailCache)
+	metricsHandler := handlers.NewMetricsHandler(queryStats)
+	filesHandler := handlers.NewFilesHandler(signer)
+	previewCache := preview.New(cfg.PreviewDir(), cfg.PreviewMaxTriangles)
+	filesHandler.SetPreviews(previewCache)
+	// Recent requests are listed by the admin API; none are kept when the size is 0
+	var requestLog *requestlog.Log
+	if cfg.RequestLogSize > 0 {
+		requestLog = requestlog.New(cfg.RequestLogSize)
+	}
+
+	adminHandler := handlers.NewAdminHandler(projectsHandler.Scanner())
+	adminHandler.SetFeatures(featureFlags)
+	adminHandler.SetMaintenance(maintenanceMode)
+	demoSeeder := demo.New(database.GetDB(), projectsHandler.Scanner(), cfg.ScanPath)
+	adminHandler.SetDemo(demoSeeder)
+	usageReporter := telemetry.New(database.GetDB(), featureFlags)
+	usageReporter.Mode = cfg.Mode
+	integrations := map[string]bool{
+		"octoprint":   cfg.OctoPrintURL != "",
+		"thingiverse": cfg.ThingiverseToken != "",
+		"slicer":      cfg.SlicerCommand != "",
+		"bambu":       cfg.BambuHost != "",
+		"ipfs":        cfg.IPFSAPIURL != "",
+	}
+	for name, configured := range integrations {
+		if configured {
+			usageReporter.Integrations = append(usageReporter.Integrations, name)
+		}
+	}
+	adminHandler.SetTelemetry(usageReporter)
+	adminHandler.SetRequestLog(requestLog)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(featureFlags)
+	for name, configured := range integrations {
+		capabilitiesHandler.SetIntegration(name, configured)
+	}
+	infoHandler := handlers.NewInfoHandler(featureFlags, handlers.Limits{
+		MaxUploadBytes: handlers.MaxUploadSize,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	})
+	infoHandler.SetMaintenance(maintenanceMode)
+	var updateChecker *updates.Checker
+	if cfg.UpdateCheck {
+		updateChecker = updates.New(cfg.UpdateCheckRepository, version.Version)
+		infoHandler.SetUpdates(updateChecker)
+	}
+	searchHandler := handlers.NewSearchHandler()
+	sectionsHandler := handlers.NewSectionsHandler()
+	syncHandler := handlers.NewSyncHandler()
+	collectionsHandler := handlers.NewCollectionsHandler()
+	printsHandler := handlers.NewPrintsHandler()
+	printsHandler.SetImageNormalizer(images)
+	printsHandler.SetMaxUploadFiles(cfg.UploadMaxFiles)
+	if cfg.OctoPrintURL != "" {
+		printsHandler.SetOctoPrint(octoprint.New(cfg.OctoPrintURL, cfg.OctoPrintAPIKey))
+	}
+	var bambuClient *bambu.Client
+	if cfg.BambuHost != "" {
+		bambuClient = bambu.New(cfg.BambuHost, cfg.BambuSerial, cfg.BambuAccessCode)
+	}
+	bambuHandler := handlers.NewBambuHandler(bambuClient, cfg.BambuName)
+	partsHandler := handlers.NewPartsHandler()
+	filamentsHandler := handlers.NewFilamentsHandler()
+	calibrationsHandler := handlers.NewCalibrationsHandler()
+	printersHandler := handlers.NewPrintersHandler()
+	ordersHandler := handlers.NewOrdersHandler()
+	quotesHandler := handlers.NewQuotesHandler()
+	quotesHandler.SetMaxUploadFiles(cfg.UploadMaxFiles)
+	storefrontHandler := handlers.NewStorefrontHandler()
+	storefrontHandler.SetCacheTTL(cfg.StorefrontCacheTTL)
+
+	// Remote collection imports; each source needs its own credentials
+	var importSources []importer.Source
+	if cfg.ThingiverseToken != "" {
+		importSources = append(importSources, importer.NewThingiverse(cfg.ThingiverseToken))
+	}
+	jobQueue := jobs.New(database.GetDB())
+	projectsHandler.SetJobQueue(jobQueue)
+	linkChecker := linkcheck.New(database.GetDB(), jobQueue)
+	linkChecker.Webhooks = cfg.LinkCheckWebhooks
+	adminHandler.SetLinkChecker(linkChecker)
+	collectionImporter := importer.New(database.GetDB(), jobQueue, projectsHandler.Scanner(), cfg.ScanPath, importSources...)
+	collectionImporter.SetImageNormalizer(images)
+	collectionImporter.SetDirNaming(cfg.DirNaming())
+	importsHandler := handlers.NewImportsHandler(collectionImporter)
+
+	// Slicing models runs the configured slicer command as a background job
+	var modelSlicer *slicer.Slicer
+	if cfg.SlicerCommand != "" {
+		command, err := slicer.ParseCommand(cfg.SlicerCommand)
+		if err != nil {
+			log.Fatal("Invalid SLICER_COMMAND:", err)
+		}
+		modelSlicer = slicer.New(database.GetDB(), jobQueue, command, cfg.SlicerProfilesDir)
+		modelSlicer.Timeout = cfg.SlicerTimeout
+	}
+	slicerHandler := handlers.NewSlicerHandler(modelSlicer)
+
+	// Public projects are published to IPFS when a node is configured, and
+	// packed into torrents either way
+	var ipfsNode *ipfs.Client
+	if cfg.IPFSAPIURL != "" {
+		ipfsNode = ipfs.New(cfg.IPFSAPIURL)
+	}
+	distributionsHandler := handlers.NewDistributionsHandler(distribute.New(database.GetDB(), jobQueue, ipfsNode, cfg.TorrentTrackers))
+	distributionsHandler.SetFeatures(featureFlags)
+
+	// Mirroring another instance pulls its changes as a background job
+	var replicator *replication.Replicator
+	if cfg.ReplicationSourceURL != "" {
+		replicator = replication.New(database.GetDB(), jobQueue, projectsHandler.Scanner(),
+			replication.NewClient(cfg.ReplicationSourceURL), cfg.ScanPath, cfg.ReplicationDir)
+		replicator.SetDirNaming(cfg.DirNaming())
+	}
+	replicationHandler := handlers.NewReplicationHandler(replicator)
+	adminHandler.SetRetention(retention.New(database.GetDB(), jobQueue))
+	jobsHandler := handlers.NewJobsHandler(jobQueue)
+
+	// Job types are registered above; start the workers unless this process only
+	// serves the API, then queue imports interrupted before the queue existed
+	if cfg.Mode != config.ModeAPI {
+		if err := jobQueue.Start(cfg.JobWorkers); err != nil {
+			log.Fatal("Failed to start job queue:", err)
+		}
+	}
+	if err := collectionImporter.ResumeUnfinished(); err != nil {
+		log.Printf("Warning: Failed to resume import jobs: %v", err)
+	}
+
+	// Worker processes only run jobs; API processes enqueue them
+	if cfg.Mode == config.ModeWorker {
+		log.Printf("Running %d job workers as %s", cfg.JobWorkers, jobQueue.ID())
+		server.WaitForSignal()
+		log.Printf("Shutting down job workers")
+		jobQueue.Stop()
+		return
+	}
+
+	// Only API processes check for updates, as only they report them
+	if updateChecker != nil {
+		log.Printf("  - Checking %s releases for updates every %v", cfg.UpdateCheckRepository, cfg.UpdateCheckInterval)
+		go updateChecker.Run(context.Background(), cfg.UpdateCheckInterval)
+	}
+
+	// Only API processes check links on schedule; POST /api/reports/links/check runs one regardless
+	if cfg.LinkCheck {
+		log.Printf("  - Checking project links every %v", cfg.LinkCheckInterval)
+		go linkChecker.Run(context.Background(), cfg.LinkCheckInterval)
+	}
+
+	// Only API processes queue scheduled replication; workers run it
+	if replicator != nil && cfg.ReplicationInterval > 0 {
+		log.Printf("  - Replicating from %s every %v", replicator.Source(), cfg.ReplicationInterval)
+		go replicator.Schedule(context.Background(), cfg.ReplicationInterval)
+	}
+
+	// API processes keep the library in sync with the scan path while the watcher flag is on
+	libraryWatcher := scanner.NewWatcher(projectsHandler.Scanner(), cfg.WatchDebounce, func() bool {
+		return featureFlags.Enabled(features.Watcher)
+	})
+	if featureFlags.Enabled(features.Watcher) {
+		log.Printf("  - Watching %s for changes", cfg.ScanPath)
+	}
+	go libraryWatcher.Run(context.Background())
+
+	// Thumbnails and model previews are generated by API processes, which
+	// also clear out the ones of files no longer in the library
+	go thumbnailCache.Run(context.Background(), cfg.ThumbnailGCInterval, thumbnailsHandler.LiveHashes)
+	go previewCache.Run(context.Background(), cfg.ThumbnailGCInterval, filesHandler.LiveModelHashes)
+
+	// The Bambu printer's reports are followed by API processes, which serve its status
+	if bambuClient != nil {
+		log.Printf("  - Following Bambu printer %s at %s", cfg.BambuSerial, cfg.BambuHost)
+		go bambuHandler.Run(context.Background())
+	}
+
+	// Usage reports are only sent once opted in; GET /api/admin/telemetry previews them regardless
+	if cfg.Telemetry {
+		usageReporter.URL = cfg.TelemetryURL
+		log.Printf("  - Sending anonymous usage reports to %s every %v", cfg.TelemetryURL, cfg.TelemetryInterval)
+		go usageReporter.Run(context.Background(), cfg.TelemetryInterval)
+	}
+
+	// Demo mode seeds the sample library whenever it isn't, so restarting a demo
+	// instance brings it back after it was cleared
+	if cfg.DemoMode {
+		if manifest, err := demoSeeder.Load(); err != nil {
+			log.Printf("Warning: Failed to load demo data: %v", err)
+		} else if manifest == nil {
+			if manifest, err = demoSeeder.Seed(); err != nil {
+				log.Printf("Warning: Failed to seed demo data: %v", err)
+			} else {
+				log.Printf("  - Demo mode: seeded %d sample projects", len(manifest.ProjectIDs))
+			}
+		}
+	}
 
 	// Setup router
 	router := gin.Default()
 
-	// Set larger limit for file uploads (1GB)
-	router.MaxMultipartMemory = 1024 << 20
+	// Uploads past the memory limit are spooled to temp files, best kept on
+	// the library's filesystem
+	router.MaxMultipartMemory = cfg.MultipartMaxMemory
+
+	// Security headers for every response, including CORS preflights
+	router.Use(middleware.SecurityHeaders(cfg.TLSCertFile != ""))
 
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Units", "If-Match", middleware.APIVersionHeader, middleware.IdempotencyKeyHeader, handlers.UploadIDHeader}
 	// IMPORTANT: Expose Content-Disposition header for file downloads
-	corsConfig.ExposeHeaders = []string{"Content-Disposition"}
+	corsConfig.ExposeHeaders = []string{"Content-Disposition", "X-Checksum-SHA256", "Digest", "ETag", "X-Bundle-GCode-Count", middleware.IdempotentReplayedHeader, "Retry-After", middleware.APIVersionHeader, "Deprecation", "Sunset", "Link"}
 	router.Use(cors.New(corsConfig))
 
+	// Attach route information to request contexts for query instrumentation
+	router.Use(middleware.RouteContext())
+
+	// Recent requests are kept for the admin API, counting bodies refused by the limits below
+	router.Use(middleware.RequestLog(requestLog))
+
 	// Add debugging middleware for file uploads
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		if param.StatusCode >= 400 {
@@ -70,31 +431,326 @@ func main() {
 		return ""
 	}))
 
+	// Bound request bodies and how long clients may take to send them; only
+	// uploads get the full upload size and the server's read timeout
+	uploadLimit := middleware.RouteLimit{MaxBodyBytes: handlers.MaxUploadSize}
+	router.Use(middleware.LimitRequests(
+		middleware.RouteLimit{MaxBodyBytes: cfg.MaxBodyBytes, ReadTimeout: cfg.BodyReadTimeout},
+		map[string]middleware.RouteLimit{
+			"POST /api/projects/upload":    uploadLimit,
+			"POST /api/projects/:id/files": uploadLimit,
+			"POST /api/prints/:id/media":   uploadLimit,
+			"POST /api/upload/:token":      uploadLimit,
+			"POST /api/quotes":             uploadLimit,
+			"POST /api/imports/archive":    uploadLimit,
+		},
+	))
+
+	// Refuse changes during maintenance; the toggle and POST routes that only
+	// read are still served
+	router.Use(middleware.ReadOnly(maintenanceMode, map[string]bool{
+		"PUT /api/admin/maintenance":                   true,
+		"POST /api/projects/:id/files/check-conflicts": true,
+		"POST /api/projects/:id/bundle":                true,
+		"POST /api/files/:id/sign":                     true,
+		"POST /api/files/lookup":                       true,
+		"POST /api/projects/shopping-list":             true,
+		"POST /api/printers/plan":                      true,
+		"POST /api/quotes":                             true,
+	}))
+
 	// Health check endpoint
 	router.GET("/api/health", projectsHandler.HealthCheck)
+	router.GET("/api/capabilities", capabilitiesHandler.GetCapabilities)
+	router.GET("/api/info", infoHandler.GetInfo)
+
+	// Metrics endpoint
+	router.GET("/api/metrics", metricsHandler.GetMetrics)
+
+	// Public storefront catalogue, served without authentication for sellers
+	// to embed in their websites
+	catalogue := router.Group("/public/catalogue", middleware.RequireFeature(featureFlags, features.Storefront))
+	{
+		catalogue.GET("", storefrontHandler.GetCatalogue)
+		catalogue.GET("/projects/:slug", storefrontHandler.GetCatalogueProject)
+		catalogue.GET("/projects/:slug/images/:fileId", storefrontHandler.GetCatalogueImage)
+	}
+
+	// Creating requests sent with an Idempotency-Key replay their response on retry
+	idempotent := middleware.Idempotency(database.GetDB())
 
 	// API routes
 	api := router.Group("/api")
 	{
 		// Project routes
 		projects := api.Group("/projects")
+		projects.Use(middleware.ProjectSlugs(database.GetDB()))
 		{
 			projects.GET("", projectsHandler.GetProjects)
-			projects.POST("", projectsHandler.CreateProject)
+			projects.POST("", idempotent, projectsHandler.CreateProject)
+			projects.POST("/upload", idempotent, projectsHandler.CreateProjectFromUpload)
 			projects.POST("/scan", projectsHandler.ScanProjects)
 			projects.GET("/search", projectsHandler.SearchProjects)
+			projects.POST("/shopping-list", projectsHandler.BuildShoppingList)
+			projects.GET("/compact", projectsHandler.GetCompactProjects)
+			projects.GET("/stats", projectsHandler.GetLibraryStats)
 			projects.GET("/:id", projectsHandler.GetProject)
 			projects.PUT("/:id", projectsHandler.UpdateProject)
 			projects.DELETE("/:id", projectsHandler.DeleteProject)
 			projects.PUT("/:id/sync", projectsHandler.SyncProject)
 			projects.GET("/:id/files", projectsHandler.GetProjectFiles)
 			projects.POST("/:id/files/check-conflicts", projectsHandler.CheckUploadConflicts)
-			projects.POST("/:id/files", projectsHandler.UploadProjectFiles)
+			projects.POST("/:id/files", idempotent, projectsHandler.UploadProjectFiles)
+			projects.POST("/:id/files/normalize", projectsHandler.NormalizeFiles)
+			projects.GET("/:id/upload-sessions/:token", projectsHandler.GetUploadSession)
+			projects.POST("/:id/upload-tokens", projectsHandler.CreateUploadToken)
+			projects.GET("/:id/upload-tokens/:token/qr", projectsHandler.GetUploadTokenQR)
+			projects.DELETE("/:id/upload-tokens/:token", projectsHandler.RevokeUploadToken)
+			projects.GET("/:id/files/:fileId", projectsHandler.GetProjectFile)
 			projects.DELETE("/:id/files/:fileId", projectsHandler.DeleteProjectFile)
+			projects.PATCH("/:id/files/:fileId", projectsHandler.RenameProjectFile)
 			projects.GET("/:id/files/:fileId/download", projectsHandler.DownloadProjectFile)
+			projects.GET("/:id/files/:fileId/thumbnail", thumbnailsHandler.GetFileThumbnail)
+			projects.PATCH("/:id/files/:fileId/profile", projectsHandler.UpdateFileProfile)
+			projects.GET("/:id/files/:fileId/plates", projectsHandler.GetFilePlates)
 			projects.GET("/:id/download", projectsHandler.DownloadProject)
+			projects.GET("/:id/export", projectsHandler.DownloadProject)
+			projects.POST("/:id/snapshot-source", projectsHandler.SnapshotSource)
+			projects.GET("/:id/snapshot-source", projectsHandler.GetSourceSnapshot)
+			projects.GET("/:id/snapshot-source/images/:name", projectsHandler.GetSourceSnapshotImage)
+			projects.POST("/:id/bundle", projectsHandler.CreateBundle)
 			projects.GET("/:id/readme", projectsHandler.GetProjectREADME)
+			projects.PUT("/:id/readme", projectsHandler.UpdateProjectREADME)
 			projects.GET("/:id/stats", projectsHandler.GetProjectStats)
+			projects.GET("/:id/summary", projectsHandler.GetProjectSummary)
+			projects.PUT("/:id/cover", projectsHandler.SetProjectCover)
+			projects.DELETE("/:id/cover", projectsHandler.ClearProjectCover)
+			projects.GET("/:id/images", projectsHandler.GetProjectImages)
+			projects.GET("/:id/distributions", distributionsHandler.GetProjectDistributions)
+			projects.POST("/:id/distributions", distributionsHandler.CreateDistribution)
+			projects.GET("/:id/bom", projectsHandler.GetProjectBOM)
+			projects.POST("/:id/bom", projectsHandler.AddBOMItem)
+			projects.GET("/:id/bom/check", projectsHandler.CheckBuildable)
+			projects.PUT("/:id/bom/:itemId", projectsHandler.UpdateBOMItem)
+			projects.DELETE("/:id/bom/:itemId", projectsHandler.DeleteBOMItem)
+			projects.GET("/:id/assemblies", projectsHandler.GetProjectAssemblies)
+			projects.POST("/:id/assemblies", projectsHandler.CreateAssembly)
+			projects.PUT("/:id/assemblies/:assemblyId", projectsHandler.UpdateAssembly)
+			projects.DELETE("/:id/assemblies/:assemblyId", projectsHandler.DeleteAssembly)
+			projects.POST("/:id/assemblies/:assemblyId/bom", projectsHandler.AddAssemblyToBOM)
+			projects.GET("/:id/label", projectsHandler.GetProjectLabel)
+		}
+
+		// Upload progress routes
+		api.GET("/uploads/:sessionId/progress", projectsHandler.GetUploadProgress)
+
+		// Phone upload page opened from an upload token's QR code
+		api.GET("/upload/:token", projectsHandler.GetUploadPage)
+		api.POST("/upload/:token", projectsHandler.UploadWithToken)
+
+		// Search routes
+		search := api.Group("/search")
+		{
+			search.GET("/suggest", middleware.RequireFeature(featureFlags, features.FullTextSearch), searchHandler.SuggestSearch)
+		}
+
+		// Offline sync routes
+		syncRoutes := api.Group("/sync")
+		{
+			syncRoutes.GET("/snapshot", syncHandler.GetSnapshot)
+			syncRoutes.GET("/delta", syncHandler.GetDelta)
+		}
+
+		// Slicer routes
+		api.GET("/slicer/profiles", middleware.RequireFeature(featureFlags, features.Integrations), slicerHandler.GetProfiles)
+
+		// Remote collection import routes
+		imports := api.Group("/imports", middleware.RequireFeature(featureFlags, features.Integrations))
+		{
+			imports.POST("", idempotent, importsHandler.CreateImport)
+			imports.POST("/archive", idempotent, importsHandler.CreateArchiveImport)
+			imports.GET("", importsHandler.GetImports)
+			imports.GET("/:id", importsHandler.GetImport)
+			imports.POST("/:id/resume", importsHandler.ResumeImport)
+		}
+
+		// Collection routes
+		collections := api.Group("/collections")
+		{
+			collections.GET("", collectionsHandler.GetCollections)
+			collections.GET("/:id", collectionsHandler.GetCollection)
+		}
+
+		// Print history routes
+		prints := api.Group("/prints")
+		{
+			prints.GET("", printsHandler.GetPrints)
+			prints.POST("", printsHandler.RecordPrint)
+			prints.GET("/export", printsHandler.ExportPrints)
+			prints.GET("/failures", printsHandler.GetFailureAnalysis)
+			prints.PUT("/:id/failure", printsHandler.TagPrintFailure)
+			prints.POST("/:id/media", printsHandler.UploadPrintMedia)
+			prints.POST("/:id/media/octoprint", middleware.RequireFeature(featureFlags, features.Integrations), printsHandler.ImportOctoPrintTimelapse)
+			prints.GET("/:id/media/:mediaId", printsHandler.DownloadPrintMedia)
+			prints.DELETE("/:id/media/:mediaId", printsHandler.DeletePrintMedia)
+		}
+
+		// Printed part inventory routes
+		parts := api.Group("/parts")
+		{
+			parts.GET("", partsHandler.GetParts)
+			parts.POST("", partsHandler.CreatePart)
+			parts.GET("/:id", partsHandler.GetPart)
+			parts.PUT("/:id", partsHandler.UpdatePart)
+			parts.DELETE("/:id", partsHandler.DeletePart)
+			parts.POST("/:id/adjust", partsHandler.AdjustStock)
+		}
+
+		// Filament spool routes
+		filaments := api.Group("/filaments")
+		{
+			filaments.GET("", filamentsHandler.GetFilaments)
+			filaments.POST("", filamentsHandler.CreateFilament)
+			filaments.GET("/:id", filamentsHandler.GetFilament)
+			filaments.PUT("/:id", filamentsHandler.UpdateFilament)
+			filaments.DELETE("/:id", filamentsHandler.DeleteFilament)
+			filaments.GET("/:id/label", filamentsHandler.GetFilamentLabel)
+		}
+
+		// Printer device tokens, scoped to the G-code of some projects
+		printers := api.Group("/printers")
+		{
+			printers.GET("", printersHandler.GetPrinters)
+			printers.POST("", printersHandler.CreatePrinter)
+			printers.PUT("/:id", printersHandler.UpdatePrinter)
+			printers.DELETE("/:id", printersHandler.DeletePrinter)
+			printers.GET("/:id/compatibility", printersHandler.GetPrinterCompatibility)
+			printers.POST("/plan", printersHandler.PlanBatch)
+			printers.GET("/tokens", deviceTokensHandler.GetDeviceTokens)
+			printers.POST("/tokens", deviceTokensHandler.CreateDeviceToken)
+			printers.DELETE("/tokens/:id", deviceTokensHandler.RevokeDeviceToken)
+			printers.GET("/bambu", middleware.RequireFeature(featureFlags, features.Integrations), bambuHandler.GetStatus)
+			printers.POST("/bambu/print", middleware.RequireFeature(featureFlags, features.Integrations), bambuHandler.SendFile)
+		}
+
+		// Routes printers call with their device token
+		device := api.Group("/device", middleware.RequireDeviceToken(database.GetDB()))
+		{
+			device.GET("/files", deviceTokensHandler.GetDeviceFiles)
+			device.GET("/files/:id/download", deviceTokensHandler.DownloadDeviceFile)
+			device.POST("/webhooks/octoprint", middleware.RequireFeature(featureFlags, features.Integrations), printsHandler.OctoPrintWebhook)
+			device.POST("/webhooks/moonraker", middleware.RequireFeature(featureFlags, features.Integrations), printsHandler.MoonrakerWebhook)
+		}
+
+		// Customer order routes
+		orders := api.Group("/orders")
+		{
+			orders.GET("", ordersHandler.GetOrders)
+			orders.POST("", ordersHandler.CreateOrder)
+			orders.GET("/dashboard", ordersHandler.GetOrderDashboard)
+			orders.GET("/:id", ordersHandler.GetOrder)
+			orders.PUT("/:id", ordersHandler.UpdateOrder)
+			orders.DELETE("/:id", ordersHandler.DeleteOrder)
+		}
+
+		// Published project distribution routes
+		distributions := api.Group("/distributions")
+		{
+			distributions.GET("/:id/torrent", distributionsHandler.GetDistributionTorrent)
+			distributions.DELETE("/:id", distributionsHandler.DeleteDistribution)
+		}
+
+		// Customer price quote routes
+		api.POST("/quotes", quotesHandler.CreateQuote)
+
+		// Printer calibration routes
+		calibrations := api.Group("/calibrations")
+		{
+			calibrations.GET("", calibrationsHandler.GetCalibrations)
+			calibrations.POST("", calibrationsHandler.RecordCalibration)
+			calibrations.GET("/latest", calibrationsHandler.GetKnownGoodSettings)
+			calibrations.DELETE("/:id", calibrationsHandler.DeleteCalibration)
+		}
+
+		// Library section routes
+		api.GET("/sections", sectionsHandler.GetSections)
+
+		// Thumbnails of project images, addressed by content hash
+		api.GET("/thumbnails/:hash/:size", thumbnailsHandler.GetThumbnail)
+
+		// File routes
+		files := api.Group("/files")
+		{
+			files.POST("/lookup", filesHandler.LookupFiles)
+			files.POST("/:id/sign", filesHandler.SignFileDownload)
+			files.GET("/:id/similar", filesHandler.GetSimilarFiles)
+			files.GET("/:id/scale", filesHandler.GetFileScale)
+			files.GET("/:id/preview", filesHandler.GetFilePreview)
+			files.GET("/:id/render.png", filesHandler.GetFileRender)
+			files.POST("/:id/convert", projectsHandler.ConvertFile)
+			files.POST("/:id/slice", middleware.RequireFeature(featureFlags, features.Integrations), slicerHandler.SliceFile)
+			files.GET("/:id/download", middleware.RequireSignedURL(signer), filesHandler.DownloadSignedFile)
+		}
+
+		// Background job routes
+		jobRoutes := api.Group("/jobs")
+		{
+			jobRoutes.GET("", jobsHandler.GetJobs)
+			jobRoutes.GET("/dead", jobsHandler.GetDeadJobs)
+			jobRoutes.GET("/:id", jobsHandler.GetJob)
+			jobRoutes.POST("/:id/retry", jobsHandler.RetryJob)
+			jobRoutes.POST("/:id/cancel", jobsHandler.CancelJob)
+			jobRoutes.DELETE("/:id", jobsHandler.DeleteJob)
+		}
+
+		// Scan history routes
+		scanRuns := api.Group("/scan-runs")
+		{
+			scanRuns.GET("", scanRunsHandler.GetScanRuns)
+			scanRuns.GET("/:id", scanRunsHandler.GetScanRun)
+		}
+
+		// Background scan routes, started from POST /api/projects/scan
+		scanJobs := api.Group("/scan/jobs")
+		{
+			scanJobs.GET("/:id", projectsHandler.GetScanJob)
+			scanJobs.POST("/:id/cancel", projectsHandler.CancelScanJob)
+		}
+
+		// Library reports
+		reports := api.Group("/reports")
+		{
+			reports.GET("/unused", adminHandler.GetUnusedModelsReport)
+			reports.GET("/links", adminHandler.GetLinkIssuesReport)
+			reports.POST("/links/check", adminHandler.CheckLinks)
+		}
+
+		// Library maintenance routes
+		admin := api.Group("/admin")
+		{
+			admin.GET("/settings", adminHandler.GetSettings)
+			admin.PUT("/settings", adminHandler.UpdateSettings)
+			admin.GET("/maintenance", adminHandler.GetMaintenance)
+			admin.PUT("/maintenance", adminHandler.UpdateMaintenance)
+			admin.GET("/seed", adminHandler.GetDemo)
+			admin.POST("/seed", adminHandler.SeedDemo)
+			admin.DELETE("/seed", adminHandler.ClearDemo)
+			admin.GET("/telemetry", adminHandler.GetTelemetry)
+			admin.GET("/requests", adminHandler.GetRequests)
+			admin.POST("/dedupe", adminHandler.DedupeLibrary)
+			admin.GET("/retention", adminHandler.GetRetention)
+			admin.POST("/retention", adminHandler.ApplyRetention)
+			admin.GET("/replication", replicationHandler.GetReplication)
+			admin.POST("/replication/run", replicationHandler.RunReplication)
+			admin.GET("/metadata.csv", projectsHandler.ExportMetadataCSV)
+			admin.PUT("/metadata.csv", projectsHandler.ImportMetadataCSV)
+			admin.GET("/metadata.json", projectsHandler.ExportMetadataJSON)
+			admin.PUT("/metadata.json", projectsHandler.ImportMetadataJSON)
+			admin.GET("/other-files", adminHandler.GetOtherFilesReport)
+			admin.POST("/other-files/delete", adminHandler.DeleteOtherFiles)
+			admin.POST("/sections", sectionsHandler.CreateSection)
+			admin.PUT("/sections/:id", sectionsHandler.UpdateSection)
+			admin.DELETE("/sections/:id", sectionsHandler.DeleteSection)
 		}
 	}
 
@@ -103,7 +759,13 @@ func main() {
 	log.Printf("Scanning path: %s", cfg.ScanPath)
 	log.Printf("Database path: %s", cfg.DatabasePath)
 
-	if err := router.Run(":" + cfg.Port); err != nil {
+	// Versioned /api/v1 routes are served by the routes above, which stay
+	// reachable unversioned, with deprecation headers, for older clients
+	srv := server.New(cfg, middleware.APIVersions(router, cfg.LegacyAPISunsetTime()))
+	if err := server.Run(cfg, srv); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
+
+	// Interrupted jobs are picked up again on the next start
+	jobQueue.Stop()
 }
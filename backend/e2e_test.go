@@ -15,8 +15,8 @@ import (
 
 	"3dshelf/internal/config"
 	"3dshelf/internal/handlers"
-	"3dshelf/internal/models"
 	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
@@ -37,14 +37,14 @@ func setupE2EEnvironment(t *testing.T) *E2ETestSuite {
 	// Create temporary directory for filesystem operations
 	tmpDir := t.TempDir()
 
-	// Setup database
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	// Setup database, in a file the job workers running scans share
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "e2e.db")), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
 	// Run migrations
-	err = db.AutoMigrate(&models.Project{}, &models.ProjectFile{})
+	err = database.Migrate(db)
 	if err != nil {
 		t.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -56,8 +56,14 @@ func setupE2EEnvironment(t *testing.T) *E2ETestSuite {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
-	// Create handler with actual scan path
+	// Create handler with actual scan path, running scans on job workers
 	handler := handlers.NewProjectsHandler(tmpDir)
+	queue := jobs.New(db)
+	handler.SetJobQueue(queue)
+	if err := queue.Start(1); err != nil {
+		t.Fatalf("Failed to start job queue: %v", err)
+	}
+	t.Cleanup(queue.Stop)
 
 	// Setup routes exactly like in the main application
 	api := router.Group("/api")
@@ -71,6 +77,7 @@ func setupE2EEnvironment(t *testing.T) *E2ETestSuite {
 		api.GET("/projects/:id/files", handler.GetProjectFiles)
 		api.GET("/projects/:id/readme", handler.GetProjectREADME)
 		api.GET("/projects/:id/stats", handler.GetProjectStats)
+		api.GET("/scan/jobs/:id", handler.GetScanJob)
 	}
 
 	return &E2ETestSuite{
@@ -109,6 +116,19 @@ func (suite *E2ETestSuite) makeRequest(method, path string) *httptest.ResponseRe
 	return w
 }
 
+// scan starts a scan and waits for its job to finish, returning the
+// finished job's progress
+func (suite *E2ETestSuite) scan(t *testing.T) *httptest.ResponseRecorder {
+	w := suite.makeRequest("POST", "/api/projects/scan")
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected the scan to start, got status %d", w.Code)
+		return w
+	}
+	jobID, _ := parseResponse(t, w)["job_id"].(float64)
+	suite.Handler.Scanner().Wait(uint(jobID))
+	return suite.makeRequest("GET", "/api/scan/jobs/"+strconv.Itoa(int(jobID)))
+}
+
 // parseResponse parses JSON response into a map
 func parseResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
 	var response map[string]interface{}
@@ -190,18 +210,18 @@ func TestE2ECompleteWorkflow(t *testing.T) {
 
 	// Step 4: Trigger filesystem scan
 	t.Log("Step 4: Triggering filesystem scan")
-	w = suite.makeRequest("POST", "/api/projects/scan")
+	w = suite.scan(t)
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected successful scan, got status %d", w.Code)
 	}
 
 	response = parseResponse(t, w)
-	message, _ := response["message"].(string)
-	if !strings.Contains(message, "Scan completed successfully") {
-		t.Errorf("Expected success message, got: %s", message)
+	status, _ = response["status"].(string)
+	if status != "completed" {
+		t.Errorf("Expected the scan completed, got: %s", status)
 	}
 
-	scannedCount, _ := response["project_count"].(float64)
+	scannedCount, _ := response["projects_found"].(float64)
 	if int(scannedCount) != 2 {
 		t.Errorf("Expected 2 projects to be detected, got %d", int(scannedCount))
 	}
@@ -237,15 +257,15 @@ func TestE2ECompleteWorkflow(t *testing.T) {
 		if name == "ComplexModel" {
 			complexProjectID = project["id"].(float64)
 			// Verify complex project has correct file count
-			files := project["files"].([]interface{})
-			if len(files) != 8 {
-				t.Errorf("Expected 8 files in ComplexModel, got %d", len(files))
+			fileCount := project["file_count"].(float64)
+			if int(fileCount) != 8 {
+				t.Errorf("Expected 8 files in ComplexModel, got %d", int(fileCount))
 			}
 		} else if name == "SimpleGadget" {
 			// Verify simple project has correct file count
-			files := project["files"].([]interface{})
-			if len(files) != 2 {
-				t.Errorf("Expected 2 files in SimpleGadget, got %d", len(files))
+			fileCount := project["file_count"].(float64)
+			if int(fileCount) != 2 {
+				t.Errorf("Expected 2 files in SimpleGadget, got %d", int(fileCount))
 			}
 		}
 	}
@@ -411,7 +431,7 @@ func TestE2ECompleteWorkflow(t *testing.T) {
 	}
 
 	response = parseResponse(t, w)
-	message, _ = response["message"].(string)
+	message, _ := response["message"].(string)
 	if !strings.Contains(message, "synced successfully") {
 		t.Errorf("Expected sync success message, got: %s", message)
 	}
@@ -450,7 +470,7 @@ func TestE2EFilesystemChanges(t *testing.T) {
 
 	// Step 2: Initial scan
 	t.Log("Step 2: Performing initial scan")
-	w := suite.makeRequest("POST", "/api/projects/scan")
+	w := suite.scan(t)
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected successful initial scan, got %d", w.Code)
 	}
@@ -504,7 +524,7 @@ func TestE2EFilesystemChanges(t *testing.T) {
 
 	// Step 4: Rescan to detect changes
 	t.Log("Step 4: Rescanning to detect changes")
-	w = suite.makeRequest("POST", "/api/projects/scan")
+	w = suite.scan(t)
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected successful rescan, got %d", w.Code)
 	}
@@ -554,7 +574,7 @@ func TestE2EFilesystemChanges(t *testing.T) {
 	}
 
 	// Rescan again
-	w = suite.makeRequest("POST", "/api/projects/scan")
+	w = suite.scan(t)
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected successful rescan after removal, got %d", w.Code)
 	}
@@ -654,7 +674,7 @@ func TestE2EPerformance(t *testing.T) {
 
 	// Time the scanning operation
 	scanStart := time.Now()
-	w := suite.makeRequest("POST", "/api/projects/scan")
+	w := suite.scan(t)
 	scanDuration := time.Since(scanStart)
 
 	if w.Code != http.StatusOK {
@@ -753,7 +773,7 @@ func BenchmarkE2ECompleteWorkflow(b *testing.B) {
 			})
 
 			// Scan
-			suite.makeRequest("POST", "/api/projects/scan")
+			suite.scan(&testing.T{})
 
 			// List projects
 			suite.makeRequest("GET", "/api/projects")
@@ -1,20 +1,213 @@
 package config
 
 import (
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/naming"
+	"3dshelf/pkg/preview"
+	"3dshelf/pkg/requestlog"
+	"3dshelf/pkg/updates"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Process modes; see Config.Mode
+const (
+	ModeAll    = "all"
+	ModeAPI    = "api"
+	ModeWorker = "worker"
+)
+
+// maxRequestLogSize bounds the request log, which is held in memory
+const maxRequestLogSize = 100000
+
 // Config holds the application configuration
 type Config struct {
 	ScanPath     string
 	DatabasePath string
 	Port         string
 	GinMode      string
+
+	// SlowQueryThreshold is the duration above which database queries are logged
+	SlowQueryThreshold time.Duration
+
+	// RequestLogSize is how many recent requests the admin API can list; 0 keeps none
+	RequestLogSize int
+
+	// LegacyAPISunset is the date, as YYYY-MM-DD, unversioned /api routes are
+	// announced to stop working on; empty announces none
+	LegacyAPISunset string
+
+	// HTTP server tuning
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	MaxHeaderBytes    int
+	TLSCertFile       string
+	TLSKeyFile        string
+	EnableH2C         bool
+
+	// MaxBodyBytes and BodyReadTimeout limit requests other than uploads, which
+	// are bounded by the upload size and ReadTimeout instead
+	MaxBodyBytes    int64
+	BodyReadTimeout time.Duration
+
+	// DownloadSigningSecret signs expiring download URLs; random per process when empty
+	DownloadSigningSecret string
+
+	// WriteSidecars keeps a .3dshelf.json metadata file in each project directory
+	WriteSidecars bool
+
+	// FileUID and FileGID own the directories and files the server creates in
+	// the library, -1 keeping the server's own; DirMode and FileMode are their permissions
+	FileUID  int
+	FileGID  int
+	DirMode  os.FileMode
+	FileMode os.FileMode
+
+	// UploadConflictPolicy resolves upload conflicts the client left unresolved: skip, rename or overwrite
+	UploadConflictPolicy string
+
+	// MultipartMaxMemory is how much of an upload is held in memory; the
+	// rest is spooled to UploadTempDir, or the system's temp directory when
+	// empty. UploadMaxFiles bounds the files of one upload request.
+	MultipartMaxMemory int64
+	UploadTempDir      string
+	UploadMaxFiles     int
+
+	// DirNameTransliterate spells the directory names of created projects in
+	// ASCII, and DirNameMaxBytes bounds them; project names are kept as given
+	DirNameTransliterate bool
+	DirNameMaxBytes      int
+
+	// ThumbnailCacheDir keeps generated thumbnails, in a thumbnails directory
+	// next to the database when empty; ThumbnailGCInterval is how often thumbnails of images gone
+	// from the library are removed
+	ThumbnailCacheDir   string
+	ThumbnailGCInterval time.Duration
+
+	// PreviewMaxTriangles is how many triangles 3D previews of STL models
+	// keep at most, decimating larger models; 0 serves models whole.
+	// PreviewCacheDir keeps decimated previews, in a previews directory next
+	// to the database when empty; they are cleared out with thumbnails.
+	PreviewMaxTriangles int
+	PreviewCacheDir     string
+
+	// SourceSnapshotDir keeps the snapshots of project source pages, in a
+	// snapshots directory next to the database when empty
+	SourceSnapshotDir string
+
+	// ImageStripMetadata strips EXIF, XMP and text metadata from uploaded and
+	// imported images, turning them upright first
+	ImageStripMetadata bool
+	// ImageWebPQuality converts uploaded and imported images to WebP at this
+	// quality (1-100) with ImageWebPEncoder; 0 keeps their format
+	ImageWebPQuality int
+	ImageWebPEncoder string
+
+	// Project detection defaults; rules saved through the admin settings API take precedence
+	ProjectFileTypes             []string
+	ProjectMinFiles              int
+	ProjectREADMEOnlyWithSidecar bool
+	ProjectImageOnlyWithSidecar  bool
+
+	// FlatFileMode turns loose model files at the scan root into projects: off, single or prefix
+	FlatFileMode string
+
+	// WatchDebounce is how long the scan path has to be quiet before the
+	// watcher syncs what changed; the watcher feature flag switches it on
+	WatchDebounce time.Duration
+
+	// ThingiverseToken enables importing Thingiverse collections
+	ThingiverseToken string
+
+	// OctoPrintURL and OctoPrintAPIKey enable pulling print time-lapses from OctoPrint
+	OctoPrintURL    string
+	OctoPrintAPIKey string
+
+	// IPFSAPIURL is the RPC API of the IPFS node public projects are published
+	// to; TorrentTrackers announce the torrents they are packed into
+	IPFSAPIURL      string
+	TorrentTrackers []string
+
+	// ReplicationSourceURL is another instance this one mirrors into
+	// ReplicationDir under the scan path, every ReplicationInterval or only on
+	// demand when it is 0
+	ReplicationSourceURL string
+	ReplicationInterval  time.Duration
+	ReplicationDir       string
+
+	// BambuHost, BambuSerial and BambuAccessCode enable sending files to a
+	// Bambu Lab printer in LAN mode and recording its prints; BambuName names
+	// it in print history
+	BambuHost       string
+	BambuSerial     string
+	BambuAccessCode string
+	BambuName       string
+
+	// Extractors run external commands to read metadata from files, as
+	// "extension=command args..." items; ExtractorTimeout bounds each run
+	Extractors       []string
+	ExtractorTimeout time.Duration
+
+	// TextExtractors run commands printing the text of documents and images,
+	// such as pdftotext or tesseract, to index it for search; they share
+	// ExtractorTimeout
+	TextExtractors []string
+
+	// SlicerCommand enables slicing models into G-code, as a preset name or a
+	// command template; SlicerProfilesDir holds the profiles it can slice with
+	// and SlicerTimeout bounds each run
+	SlicerCommand     string
+	SlicerProfilesDir string
+	SlicerTimeout     time.Duration
+
+	// Mode selects what this process runs: "all" serves the API and runs
+	// background jobs, "api" only serves the API and "worker" only runs jobs,
+	// sharing the queue with the other processes through the database
+	Mode string
+
+	// JobWorkers is how many background jobs run at once
+	JobWorkers int
+
+	// PublicURL is the base URL of the web UI, linked from printed label QR codes
+	PublicURL string
+
+	// StorefrontCacheTTL is how long public catalogue responses are served
+	// from memory, and how long clients and CDNs may keep them
+	StorefrontCacheTTL time.Duration
+
+	// FeatureFlags switches experimental subsystems: "name" enables a flag, "-name"
+	// disables it. Flags saved through the admin settings API take precedence.
+	FeatureFlags []string
+
+	// DemoMode seeds the sample library at startup unless it is already seeded
+	DemoMode bool
+
+	// UpdateCheck polls UpdateCheckRepository's GitHub releases every
+	// UpdateCheckInterval and reports newer versions in server info
+	UpdateCheck           bool
+	UpdateCheckInterval   time.Duration
+	UpdateCheckRepository string
+
+	// LinkCheck verifies project source URLs and the files READMEs link to
+	// every LinkCheckInterval, posting new broken links to LinkCheckWebhooks
+	LinkCheck         bool
+	LinkCheckInterval time.Duration
+	LinkCheckWebhooks []string
+
+	// Telemetry sends an anonymous usage report to TelemetryURL every TelemetryInterval
+	Telemetry         bool
+	TelemetryURL      string
+	TelemetryInterval time.Duration
 }
 
 // Load loads configuration from environment variables and .env file
@@ -27,6 +220,110 @@ func Load() (*Config, error) {
 		DatabasePath: getEnv("DATABASE_PATH", "./printvault.db"),
 		Port:         getEnv("PORT", "8080"),
 		GinMode:      getEnv("GIN_MODE", "debug"),
+
+		SlowQueryThreshold: getEnvAsDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		RequestLogSize:     getEnvAsInt("REQUEST_LOG_SIZE", requestlog.DefaultSize),
+		LegacyAPISunset:    getEnv("LEGACY_API_SUNSET", ""),
+
+		// Long read/write timeouts leave room for 1GB uploads and G-code
+		// downloads to slow printer hosts; header timeouts guard against slowloris
+		ReadHeaderTimeout: getEnvAsDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		ReadTimeout:       getEnvAsDuration("HTTP_READ_TIMEOUT", 30*time.Minute),
+		WriteTimeout:      getEnvAsDuration("HTTP_WRITE_TIMEOUT", 30*time.Minute),
+		IdleTimeout:       getEnvAsDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:   getEnvAsDuration("HTTP_SHUTDOWN_TIMEOUT", 30*time.Second),
+		MaxHeaderBytes:    getEnvAsInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		MaxBodyBytes:      int64(getEnvAsInt("HTTP_MAX_BODY_BYTES", 10<<20)),
+		BodyReadTimeout:   getEnvAsDuration("HTTP_BODY_READ_TIMEOUT", time.Minute),
+		TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+		EnableH2C:         getEnvAsBool("HTTP_ENABLE_H2C", false),
+
+		DownloadSigningSecret: getEnv("DOWNLOAD_SIGNING_SECRET", ""),
+
+		WriteSidecars: getEnvAsBool("WRITE_SIDECARS", false),
+
+		FileUID:  getEnvAsInt("FILE_UID", fileperm.KeepOwner),
+		FileGID:  getEnvAsInt("FILE_GID", fileperm.KeepOwner),
+		DirMode:  getEnvAsFileMode("DIR_MODE", 0755),
+		FileMode: getEnvAsFileMode("FILE_MODE", 0644),
+
+		UploadConflictPolicy: getEnv("UPLOAD_CONFLICT_POLICY", "skip"),
+
+		MultipartMaxMemory: int64(getEnvAsInt("MULTIPART_MAX_MEMORY", 1<<30)),
+		UploadTempDir:      getEnv("UPLOAD_TEMP_DIR", ""),
+		UploadMaxFiles:     getEnvAsInt("UPLOAD_MAX_FILES", 1000),
+
+		DirNameTransliterate: getEnvAsBool("DIR_NAME_TRANSLITERATE", false),
+		DirNameMaxBytes:      getEnvAsInt("DIR_NAME_MAX_BYTES", naming.Default().MaxBytes),
+
+		ThumbnailCacheDir:   getEnv("THUMBNAIL_CACHE_DIR", ""),
+		ThumbnailGCInterval: getEnvAsDuration("THUMBNAIL_GC_INTERVAL", 6*time.Hour),
+
+		PreviewMaxTriangles: getEnvAsInt("PREVIEW_MAX_TRIANGLES", preview.DefaultMaxTriangles),
+		PreviewCacheDir:     getEnv("PREVIEW_CACHE_DIR", ""),
+
+		SourceSnapshotDir: getEnv("SOURCE_SNAPSHOT_DIR", ""),
+
+		ImageStripMetadata: getEnvAsBool("IMAGE_STRIP_METADATA", true),
+		ImageWebPQuality:   getEnvAsInt("IMAGE_WEBP_QUALITY", 0),
+		ImageWebPEncoder:   getEnv("IMAGE_WEBP_ENCODER", "cwebp"),
+
+		ProjectFileTypes:             getEnvAsList("PROJECT_FILE_TYPES", []string{"stl", "3mf", "gcode"}),
+		ProjectMinFiles:              getEnvAsInt("PROJECT_MIN_FILES", 1),
+		ProjectREADMEOnlyWithSidecar: getEnvAsBool("PROJECT_README_ONLY_WITH_SIDECAR", false),
+		ProjectImageOnlyWithSidecar:  getEnvAsBool("PROJECT_IMAGE_ONLY_WITH_SIDECAR", false),
+
+		FlatFileMode: getEnv("FLAT_FILE_MODE", "off"),
+
+		WatchDebounce: getEnvAsDuration("WATCH_DEBOUNCE", 2*time.Second),
+
+		ThingiverseToken: getEnv("THINGIVERSE_TOKEN", ""),
+
+		OctoPrintURL:    getEnv("OCTOPRINT_URL", ""),
+		OctoPrintAPIKey: getEnv("OCTOPRINT_API_KEY", ""),
+
+		IPFSAPIURL:      getEnv("IPFS_API_URL", ""),
+		TorrentTrackers: getEnvAsList("TORRENT_TRACKERS", nil),
+
+		ReplicationSourceURL: getEnv("REPLICATION_SOURCE_URL", ""),
+		ReplicationInterval:  getEnvAsDuration("REPLICATION_INTERVAL", 0),
+		ReplicationDir:       getEnv("REPLICATION_DIR", "mirror"),
+
+		BambuHost:       getEnv("BAMBU_HOST", ""),
+		BambuSerial:     getEnv("BAMBU_SERIAL", ""),
+		BambuAccessCode: getEnv("BAMBU_ACCESS_CODE", ""),
+		BambuName:       getEnv("BAMBU_NAME", "Bambu Lab"),
+
+		Extractors:       getEnvAsList("EXTRACTORS", nil),
+		ExtractorTimeout: getEnvAsDuration("EXTRACTOR_TIMEOUT", 30*time.Second),
+		TextExtractors:   getEnvAsList("TEXT_EXTRACTORS", nil),
+
+		SlicerCommand:     getEnv("SLICER_COMMAND", ""),
+		SlicerProfilesDir: getEnv("SLICER_PROFILES_DIR", ""),
+		SlicerTimeout:     getEnvAsDuration("SLICER_TIMEOUT", 30*time.Minute),
+
+		Mode:       getEnv("MODE", ModeAll),
+		JobWorkers: getEnvAsInt("JOB_WORKERS", 2),
+
+		PublicURL:          getEnv("PUBLIC_URL", ""),
+		StorefrontCacheTTL: getEnvAsDuration("STOREFRONT_CACHE_TTL", 5*time.Minute),
+
+		FeatureFlags: getEnvAsList("FEATURE_FLAGS", nil),
+
+		DemoMode: getEnvAsBool("DEMO_MODE", false),
+
+		UpdateCheck:           getEnvAsBool("UPDATE_CHECK", false),
+		UpdateCheckInterval:   getEnvAsDuration("UPDATE_CHECK_INTERVAL", 24*time.Hour),
+		UpdateCheckRepository: getEnv("UPDATE_CHECK_REPOSITORY", updates.DefaultRepository),
+
+		LinkCheck:         getEnvAsBool("LINK_CHECK", false),
+		LinkCheckInterval: getEnvAsDuration("LINK_CHECK_INTERVAL", 24*time.Hour),
+		LinkCheckWebhooks: getEnvAsList("LINK_CHECK_WEBHOOKS", nil),
+
+		Telemetry:         getEnvAsBool("TELEMETRY", false),
+		TelemetryURL:      getEnv("TELEMETRY_URL", ""),
+		TelemetryInterval: getEnvAsDuration("TELEMETRY_INTERVAL", 24*time.Hour),
 	}
 
 	return config, nil
@@ -50,6 +347,93 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool gets an environment variable as boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsDuration gets an environment variable as a duration (e.g. "250ms") or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsFileMode gets an environment variable as an octal permission mode (e.g. "0775") or returns a default value
+func getEnvAsFileMode(key string, defaultValue os.FileMode) os.FileMode {
+	if value := os.Getenv(key); value != "" {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+			return os.FileMode(mode)
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list or returns a default value
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FilePolicy is the ownership and permissions of what the server creates in the library
+func (c *Config) FilePolicy() fileperm.Policy {
+	return fileperm.Policy{UID: c.FileUID, GID: c.FileGID, DirMode: c.DirMode, FileMode: c.FileMode}
+}
+
+// DirNaming is how the names of created projects become directory names
+func (c *Config) DirNaming() naming.Policy {
+	return naming.Policy{Transliterate: c.DirNameTransliterate, MaxBytes: c.DirNameMaxBytes}
+}
+
+// ThumbnailDir is where generated thumbnails are kept
+func (c *Config) ThumbnailDir() string {
+	if c.ThumbnailCacheDir != "" {
+		return c.ThumbnailCacheDir
+	}
+	return filepath.Join(filepath.Dir(c.DatabasePath), "thumbnails")
+}
+
+// PreviewDir is where decimated 3D previews are kept
+func (c *Config) PreviewDir() string {
+	if c.PreviewCacheDir != "" {
+		return c.PreviewCacheDir
+	}
+	return filepath.Join(filepath.Dir(c.DatabasePath), "previews")
+}
+
+// SnapshotDir is where snapshots of project source pages are kept
+func (c *Config) SnapshotDir() string {
+	if c.SourceSnapshotDir != "" {
+		return c.SourceSnapshotDir
+	}
+	return filepath.Join(filepath.Dir(c.DatabasePath), "snapshots")
+}
+
+// LegacyAPISunsetTime is when unversioned /api routes are announced to stop
+// working, or zero when none is announced
+func (c *Config) LegacyAPISunsetTime() time.Time {
+	sunset, _ := time.Parse(time.DateOnly, c.LegacyAPISunset)
+	return sunset
+}
+
 // Validate checks if the configuration is valid and ready to use
 func (c *Config) Validate() error {
 	// Check if scan path exists, create if possible
@@ -77,5 +461,180 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("port %d is not valid (must be between 1 and 65535)", portInt)
 	}
 
+	// TLS needs both a certificate and a key
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	// A Bambu printer is reached by its address, its serial number and access code
+	if c.BambuHost != "" || c.BambuSerial != "" || c.BambuAccessCode != "" {
+		if c.BambuHost == "" || c.BambuSerial == "" || c.BambuAccessCode == "" {
+			return fmt.Errorf("BAMBU_HOST, BAMBU_SERIAL and BAMBU_ACCESS_CODE must be set together")
+		}
+	}
+
+	switch c.Mode {
+	case ModeAll, ModeAPI, ModeWorker:
+	default:
+		return fmt.Errorf("mode %q is not valid (must be %s, %s or %s)", c.Mode, ModeAll, ModeAPI, ModeWorker)
+	}
+	if c.JobWorkers < 1 {
+		return fmt.Errorf("job workers %d is not valid (must be positive)", c.JobWorkers)
+	}
+
+	switch c.UploadConflictPolicy {
+	case "skip", "rename", "overwrite":
+	default:
+		return fmt.Errorf("upload conflict policy %q is not valid (must be skip, rename or overwrite)", c.UploadConflictPolicy)
+	}
+	if err := c.DirNaming().Validate(); err != nil {
+		return err
+	}
+
+	if c.RequestLogSize < 0 || c.RequestLogSize > maxRequestLogSize {
+		return fmt.Errorf("request log size %d is not valid (must be between 0 and %d)", c.RequestLogSize, maxRequestLogSize)
+	}
+	if c.LegacyAPISunset != "" {
+		if _, err := time.Parse(time.DateOnly, c.LegacyAPISunset); err != nil {
+			return fmt.Errorf("legacy API sunset %q is not valid (must be a YYYY-MM-DD date)", c.LegacyAPISunset)
+		}
+	}
+
+	if c.MultipartMaxMemory < 1 {
+		return fmt.Errorf("multipart max memory %d is not valid (must be positive)", c.MultipartMaxMemory)
+	}
+	if c.UploadMaxFiles < 1 {
+		return fmt.Errorf("upload max files %d is not valid (must be positive)", c.UploadMaxFiles)
+	}
+	if c.UploadTempDir != "" {
+		if err := os.MkdirAll(c.UploadTempDir, 0755); err != nil {
+			return fmt.Errorf("upload temp directory '%s' cannot be created: %v", c.UploadTempDir, err)
+		}
+		testFile := filepath.Join(c.UploadTempDir, ".write_test")
+		if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
+			return fmt.Errorf("upload temp directory '%s' is not writable: %v", c.UploadTempDir, err)
+		}
+		os.Remove(testFile)
+	}
+
+	if c.ThumbnailCacheDir != "" {
+		if err := os.MkdirAll(c.ThumbnailCacheDir, 0755); err != nil {
+			return fmt.Errorf("thumbnail cache directory '%s' cannot be created: %v", c.ThumbnailCacheDir, err)
+		}
+	}
+	if c.ThumbnailGCInterval < time.Minute {
+		return fmt.Errorf("thumbnail GC interval %v is not valid (must be at least 1m)", c.ThumbnailGCInterval)
+	}
+	if c.PreviewMaxTriangles < 0 {
+		return fmt.Errorf("preview max triangles %d is not valid (must be 0 or more)", c.PreviewMaxTriangles)
+	}
+	if c.PreviewCacheDir != "" {
+		if err := os.MkdirAll(c.PreviewCacheDir, 0755); err != nil {
+			return fmt.Errorf("preview cache directory '%s' cannot be created: %v", c.PreviewCacheDir, err)
+		}
+	}
+	if c.SourceSnapshotDir != "" {
+		if err := os.MkdirAll(c.SourceSnapshotDir, 0755); err != nil {
+			return fmt.Errorf("source snapshot directory '%s' cannot be created: %v", c.SourceSnapshotDir, err)
+		}
+	}
+	if c.WatchDebounce <= 0 {
+		return fmt.Errorf("watch debounce %v is not valid (must be positive)", c.WatchDebounce)
+	}
+
+	if c.ImageWebPQuality < 0 || c.ImageWebPQuality > 100 {
+		return fmt.Errorf("image WebP quality %d is not valid (must be between 0 and 100)", c.ImageWebPQuality)
+	}
+
+	if err := c.FilePolicy().Validate(); err != nil {
+		return err
+	}
+
+	// Slicing needs somewhere to pick profiles from
+	if c.SlicerCommand != "" {
+		if c.SlicerProfilesDir == "" {
+			return fmt.Errorf("SLICER_PROFILES_DIR must be set with SLICER_COMMAND")
+		}
+		if info, err := os.Stat(c.SlicerProfilesDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("slicer profiles directory '%s' is not a directory", c.SlicerProfilesDir)
+		}
+		if c.SlicerTimeout <= 0 {
+			return fmt.Errorf("slicer timeout %v is not valid (must be positive)", c.SlicerTimeout)
+		}
+	}
+
+	if c.MaxHeaderBytes < 1 {
+		return fmt.Errorf("max header bytes %d is not valid (must be positive)", c.MaxHeaderBytes)
+	}
+	if c.MaxBodyBytes < 1 {
+		return fmt.Errorf("max body bytes %d is not valid (must be positive)", c.MaxBodyBytes)
+	}
+	if c.BodyReadTimeout <= 0 {
+		return fmt.Errorf("body read timeout %v is not valid (must be positive)", c.BodyReadTimeout)
+	}
+	if c.StorefrontCacheTTL < 0 {
+		return fmt.Errorf("storefront cache TTL %v is not valid (must not be negative)", c.StorefrontCacheTTL)
+	}
+
+	if c.UpdateCheck {
+		// GitHub allows 60 unauthenticated requests an hour per address
+		if c.UpdateCheckInterval < time.Hour {
+			return fmt.Errorf("update check interval %v is not valid (must be at least 1h)", c.UpdateCheckInterval)
+		}
+		if owner, name, ok := strings.Cut(c.UpdateCheckRepository, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("update check repository %q is not valid (must be owner/name)", c.UpdateCheckRepository)
+		}
+	}
+
+	if c.LinkCheck && c.LinkCheckInterval < time.Hour {
+		return fmt.Errorf("link check interval %v is not valid (must be at least 1h)", c.LinkCheckInterval)
+	}
+	for _, webhook := range c.LinkCheckWebhooks {
+		if u, err := url.Parse(webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("link check webhook %q is not valid (must be an http or https URL)", webhook)
+		}
+	}
+
+	if c.IPFSAPIURL != "" {
+		if u, err := url.Parse(c.IPFSAPIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("IPFS_API_URL %q is not valid (must be an http or https URL)", c.IPFSAPIURL)
+		}
+	}
+	for _, tracker := range c.TorrentTrackers {
+		if u, err := url.Parse(tracker); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "udp") || u.Host == "" {
+			return fmt.Errorf("torrent tracker %q is not valid (must be an http, https or udp URL)", tracker)
+		}
+	}
+
+	if c.ReplicationSourceURL != "" {
+		if u, err := url.Parse(c.ReplicationSourceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("REPLICATION_SOURCE_URL is not valid (must be an http or https URL)")
+		}
+		if c.ReplicationInterval != 0 && c.ReplicationInterval < time.Minute {
+			return fmt.Errorf("replication interval %v is not valid (must be 0 or at least 1m)", c.ReplicationInterval)
+		}
+		// Hidden directories are skipped by scans, and the mirror must stay in the library
+		dir := filepath.Clean(c.ReplicationDir)
+		if dir == "." || filepath.IsAbs(dir) || strings.HasPrefix(dir, "..") || strings.HasPrefix(c.ReplicationDir, ".") {
+			return fmt.Errorf("replication directory '%s' is not valid (must be a visible directory under the scan path)", c.ReplicationDir)
+		}
+	}
+
+	if c.Telemetry {
+		if u, err := url.Parse(c.TelemetryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("TELEMETRY_URL %q is not valid (must be an http or https URL)", c.TelemetryURL)
+		}
+		if c.TelemetryInterval < time.Hour {
+			return fmt.Errorf("telemetry interval %v is not valid (must be at least 1h)", c.TelemetryInterval)
+		}
+	}
+
+	if len(c.ProjectFileTypes) == 0 {
+		return fmt.Errorf("PROJECT_FILE_TYPES must list at least one file type")
+	}
+	if c.ProjectMinFiles < 1 {
+		return fmt.Errorf("project min files %d is not valid (must be at least 1)", c.ProjectMinFiles)
+	}
+
 	return nil
 }
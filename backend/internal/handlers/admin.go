@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/dedupe"
+	"3dshelf/pkg/demo"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/linkcheck"
+	"3dshelf/pkg/maintenance"
+	"3dshelf/pkg/pricing"
+	"3dshelf/pkg/requestlog"
+	"3dshelf/pkg/retention"
+	"3dshelf/pkg/scanner"
+	"3dshelf/pkg/telemetry"
+	"3dshelf/pkg/units"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles library maintenance operations and runtime settings
+type AdminHandler struct {
+	scanner *scanner.Scanner
+
+	// features are switched through the settings API; nil when not configured
+	features *features.Flags
+
+	// maintenance is switched through the maintenance API; nil when not configured
+	maintenance *maintenance.Mode
+
+	// demo seeds and clears the sample library; nil when not configured
+	demo *demo.Seeder
+
+	// telemetry previews the usage report; nil when not configured
+	telemetry *telemetry.Reporter
+
+	// requestLog holds the requests recently handled; nil when not configured
+	requestLog *requestlog.Log
+
+	// links checks for broken links on demand; nil when not configured
+	links *linkcheck.Checker
+
+	// retention deletes G-code by reviewed rules; nil when not configured
+	retention *retention.Retainer
+}
+
+// NewAdminHandler creates a new AdminHandler managing the given scanner's settings
+func NewAdminHandler(scanner *scanner.Scanner) *AdminHandler {
+	return &AdminHandler{
+		scanner: scanner,
+	}
+}
+
+// SetFeatures lets the settings API switch the instance's feature flags
+func (h *AdminHandler) SetFeatures(flags *features.Flags) {
+	h.features = flags
+}
+
+// Settings are the runtime settings exposed through the admin API
+type Settings struct {
+	Detection scanner.DetectionRules `json:"detection"`
+
+	// Units is the instance unit preference; clients may override it per request
+	Units units.Preferences `json:"units"`
+
+	// Pricing are the costs and margin quotes are priced with
+	Pricing pricing.Rules `json:"pricing"`
+
+	// Storefront is how the public catalogue presents the library
+	Storefront StorefrontTheme `json:"storefront"`
+
+	// Features switches experimental subsystems on or off
+	Features map[features.Flag]bool `json:"features,omitempty"`
+}
+
+// GetSettings returns the current runtime settings
+func (h *AdminHandler) GetSettings(c *gin.Context) {
+	prefs, err := loadUnits(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+	theme, _, err := loadStorefront(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+	rules, err := loadPricing(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+
+	settings := Settings{Detection: h.scanner.DetectionRules(), Units: prefs, Pricing: rules, Storefront: theme}
+	if h.features != nil {
+		settings.Features = h.features.All()
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings validates, persists and applies runtime settings. Fields
+// omitted from the body keep their current values.
+func (h *AdminHandler) UpdateSettings(c *gin.Context) {
+	prefs, err := loadUnits(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+	theme, _, err := loadStorefront(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+	rules, err := loadPricing(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load settings"})
+		return
+	}
+
+	settings := Settings{Detection: h.scanner.DetectionRules(), Units: prefs, Pricing: rules, Storefront: theme}
+	if h.features != nil {
+		settings.Features = h.features.All()
+	}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := settings.Detection.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := settings.Units.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := settings.Pricing.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := settings.Storefront.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.features == nil && settings.Features != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Feature flags are not supported"})
+		return
+	}
+	if err := features.Validate(settings.Features); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.SaveSetting(requestDB(c), scanner.DetectionSettingKey, settings.Detection); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	if err := database.SaveSetting(requestDB(c), units.SettingKey, settings.Units); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	if err := database.SaveSetting(requestDB(c), pricing.SettingKey, settings.Pricing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	if err := database.SaveSetting(requestDB(c), StorefrontSettingKey, settings.Storefront); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		return
+	}
+	if h.features != nil {
+		if err := database.SaveSetting(requestDB(c), features.SettingKey, settings.Features); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+			return
+		}
+		h.features.Set(settings.Features)
+	}
+	h.scanner.SetDetectionRules(settings.Detection)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Settings updated successfully",
+		"settings": settings,
+	})
+}
+
+// DedupeRequest is the body accepted by DedupeLibrary
+type DedupeRequest struct {
+	Policy dedupe.Policy `json:"policy"`
+	Action dedupe.Action `json:"action"`
+
+	// DryRun defaults to true; applying requires the token from a prior dry run
+	DryRun *bool  `json:"dry_run"`
+	Token  string `json:"token"`
+}
+
+// DedupeLibrary reports duplicate files by hash and, once a dry run has been
+// reviewed, consolidates them into one canonical copy
+func (h *AdminHandler) DedupeLibrary(c *gin.Context) {
+	var req DedupeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	dryRun := req.DryRun == nil || *req.DryRun
+	if !dryRun && req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A dry run is required before applying deduplication"})
+		return
+	}
+
+	opts := dedupe.Options{Policy: req.Policy, Action: req.Action}
+	if err := opts.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Applying rewrites files across projects, so no other library operation may run meanwhile
+	ctx := context.Background()
+	if !dryRun {
+		lockCtx, unlock, ok := lockLibrary(c)
+		if !ok {
+			return
+		}
+		defer unlock()
+		ctx = lockCtx
+	}
+
+	plan, err := dedupe.BuildPlan(requestDB(c), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build deduplication plan"})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"plan":    plan,
+		})
+		return
+	}
+
+	if req.Token != plan.Token {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Library changed since the dry run; review a new plan before applying",
+			"plan":  plan,
+		})
+		return
+	}
+
+	result := dedupe.Execute(ctx, requestDB(c), plan)
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": false,
+		"plan":    plan,
+		"result":  result,
+	})
+}
+
+// lockLibrary takes the advisory lock guarding library-wide operations on every
+// instance, writing the error response and returning false when it is held.
+// The returned context is cancelled if the lock is lost while held; a client
+// going away doesn't stop the operation.
+func lockLibrary(c *gin.Context) (context.Context, func(), bool) {
+	ctx, unlock, err := database.Lock(context.Background(), database.GetDB(), database.LibraryLock)
+	switch {
+	case errors.Is(err, database.ErrLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another library operation is running, try again later"})
+		return nil, nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock library"})
+		return nil, nil, false
+	}
+	return ctx, unlock, true
+}
+
+// libraryProjectLockWait is how long library-wide operations wait for an
+// upload, rename or other change to a project they are about to touch
+const libraryProjectLockWait = 30 * time.Second
+
+// lockLibraryProject takes a project's lock for a library-wide operation
+// running under ctx, waiting for other changes to the project to finish
+func lockLibraryProject(ctx context.Context, projectID uint) (func(), error) {
+	_, unlock, err := database.LockWait(ctx, database.GetDB(), database.ProjectLock(projectID), libraryProjectLockWait)
+	return unlock, err
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAdminRouter creates a router exposing the admin routes
+func setupAdminRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminHandler := NewAdminHandler(nil)
+
+	router.POST("/api/admin/dedupe", adminHandler.DedupeLibrary)
+
+	return router
+}
+
+// postDedupe sends a dedupe request and decodes the response
+func postDedupe(t *testing.T, router *gin.Engine, body gin.H) (int, map[string]interface{}) {
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/admin/dedupe", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return w.Code, response
+}
+
+// TestDedupeLibrary tests the dry-run-then-apply deduplication flow
+func TestDedupeLibrary(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupAdminRouter()
+	tmpDir := t.TempDir()
+
+	var paths []string
+	for _, name := range []string{"First", "Second"} {
+		project := models.Project{Name: name, Path: filepath.Join(tmpDir, name)}
+		if err := os.MkdirAll(project.Path, 0755); err != nil {
+			t.Fatalf("Failed to create project directory: %v", err)
+		}
+		db.Create(&project)
+
+		path := filepath.Join(project.Path, "model.stl")
+		if err := os.WriteFile(path, []byte("solid shared"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		db.Create(&models.ProjectFile{
+			ProjectID: project.ID,
+			Filename:  "model.stl",
+			Filepath:  path,
+			FileType:  models.FileTypeSTL,
+			Size:      12,
+			Hash:      "sharedhash",
+		})
+		paths = append(paths, path)
+	}
+
+	t.Run("Apply without dry run", func(t *testing.T) {
+		code, _ := postDedupe(t, router, gin.H{"dry_run": false})
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		code, _ := postDedupe(t, router, gin.H{"policy": "keep_oldest"})
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	var token string
+	t.Run("Dry run", func(t *testing.T) {
+		code, response := postDedupe(t, router, gin.H{})
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+
+		plan := response["plan"].(map[string]interface{})
+		if plan["duplicate_count"].(float64) != 1 {
+			t.Errorf("Expected 1 duplicate, got %v", plan["duplicate_count"])
+		}
+		token = plan["token"].(string)
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil || info.Size() != 12 {
+				t.Errorf("Expected dry run to leave %s untouched", path)
+			}
+		}
+	})
+
+	t.Run("Stale token", func(t *testing.T) {
+		code, _ := postDedupe(t, router, gin.H{"dry_run": false, "token": "stale"})
+		if code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, code)
+		}
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		code, response := postDedupe(t, router, gin.H{"dry_run": false, "action": "delete", "token": token})
+		if code != http.StatusConflict {
+			t.Fatalf("Expected token from a link plan to be rejected for delete, got %d", code)
+		}
+
+		code, response = postDedupe(t, router, gin.H{"dry_run": false, "token": token})
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+
+		result := response["result"].(map[string]interface{})
+		if result["applied"].(float64) != 1 {
+			t.Errorf("Expected 1 applied, got %v", result["applied"])
+		}
+
+		var linked int64
+		db.Model(&models.ProjectFile{}).Where("duplicate_of IS NOT NULL").Count(&linked)
+		if linked != 1 {
+			t.Errorf("Expected 1 linked file record, got %d", linked)
+		}
+	})
+}
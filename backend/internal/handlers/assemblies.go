@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+	"archive/zip"
+	"encoding/json"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// assembliesExport is the ZIP entry bundles carry the assemblies in
+const assembliesExport = ".3dshelf-assemblies.json"
+
+// maxPartQuantity bounds quantities read from filenames, so numbers that
+// aren't counts aren't taken for one
+const maxPartQuantity = 100
+
+// partQuantityPattern matches count markers in model filenames, like leg_x4,
+// leg (4x), 4x_leg, leg_qty4 or leg-4pcs; a marker must stand apart from the
+// name, so M3x8 isn't a count
+var partQuantityPattern = regexp.MustCompile(`(?i)(?:^|[_\-. (])(?:x(\d+)|(\d+)x|qty(\d+)|(\d+)pcs?)(?:$|[_\-. )])`)
+
+// AssemblyRequest is the body accepted by CreateAssembly and UpdateAssembly
+type AssemblyRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Notes string `json:"notes"`
+	Parts []struct {
+		FileID   uint `json:"file_id"`
+		Quantity int  `json:"quantity"`
+	} `json:"parts"`
+}
+
+// AssemblyBOMRequest is the body accepted by AddAssemblyToBOM
+type AssemblyBOMRequest struct {
+	// Sets is how many complete assemblies the bill of materials is for; 1 when omitted
+	Sets int `json:"sets"`
+}
+
+// GetProjectAssemblies returns a project's assemblies and the ones suggested
+// for its model files that aren't in one yet
+func (h *ProjectsHandler) GetProjectAssemblies(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var assemblies []models.Assembly
+	if err := db.Where("project_id = ?", project.ID).Order("name ASC, id ASC").Find(&assemblies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assemblies"})
+		return
+	}
+	attachAssemblyFiles(assemblies, project.Files)
+
+	c.JSON(http.StatusOK, gin.H{
+		"assemblies": assemblies,
+		"suggested":  detectAssemblies(project, assemblies),
+		"count":      len(assemblies),
+	})
+}
+
+// CreateAssembly groups model files of a project into an assembly
+func (h *ProjectsHandler) CreateAssembly(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	assembly := models.Assembly{ProjectID: project.ID}
+	if !bindAssembly(c, project, &assembly) {
+		return
+	}
+
+	if err := db.Create(&assembly).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create assembly"})
+		return
+	}
+	attachAssemblyFiles([]models.Assembly{assembly}, project.Files)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Assembly created successfully",
+		"assembly": assembly,
+	})
+}
+
+// UpdateAssembly replaces an assembly's name, notes and parts
+func (h *ProjectsHandler) UpdateAssembly(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var assembly models.Assembly
+	if err := db.Where("id = ? AND project_id = ?", c.Param("assemblyId"), project.ID).First(&assembly).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assembly not found"})
+		return
+	}
+	if !bindAssembly(c, project, &assembly) {
+		return
+	}
+
+	if err := db.Save(&assembly).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assembly"})
+		return
+	}
+	attachAssemblyFiles([]models.Assembly{assembly}, project.Files)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Assembly updated successfully",
+		"assembly": assembly,
+	})
+}
+
+// DeleteAssembly removes an assembly; its files are kept
+func (h *ProjectsHandler) DeleteAssembly(c *gin.Context) {
+	result := requestDB(c).Where("id = ? AND project_id = ?", c.Param("assemblyId"), c.Param("id")).Delete(&models.Assembly{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete assembly"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assembly not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Assembly deleted successfully"})
+}
+
+// AddAssemblyToBOM sets the project's printed bill of materials lines for the
+// parts of an assembly, adding the lines that are missing. Parts whose file is
+// gone are reported and skipped.
+func (h *ProjectsHandler) AddAssemblyToBOM(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var assembly models.Assembly
+	if err := db.Where("id = ? AND project_id = ?", c.Param("assemblyId"), project.ID).First(&assembly).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assembly not found"})
+		return
+	}
+
+	req := AssemblyBOMRequest{Sets: 1}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	if req.Sets < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sets must be at least 1"})
+		return
+	}
+
+	attachAssemblyFiles([]models.Assembly{assembly}, project.Files)
+	missing := []string{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, part := range assembly.Parts {
+			if part.FileID == nil {
+				missing = append(missing, part.Filename)
+				continue
+			}
+
+			item := models.BOMItem{ProjectID: project.ID, Kind: models.BOMPrinted, FileID: part.FileID}
+			if err := tx.Where(&item).Limit(1).Find(&item).Error; err != nil {
+				return err
+			}
+			if item.Name == "" {
+				item.Name = modelStem(part.Filename)
+			}
+			item.Quantity = part.Quantity * req.Sets
+			if err := tx.Save(&item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bill of materials"})
+		return
+	}
+
+	var items []models.BOMItem
+	if err := db.Where("project_id = ?", project.ID).Order("kind DESC, id ASC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bill of materials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bill of materials updated successfully",
+		"items":   items,
+		"missing": missing,
+	})
+}
+
+// bindAssembly reads an assembly request into assembly, resolving each part
+// to the filename of a model file in the project. It writes the error response
+// and returns false when the request is invalid.
+func bindAssembly(c *gin.Context, project models.Project, assembly *models.Assembly) bool {
+	var req AssemblyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+
+	filesByID := make(map[uint]models.ProjectFile, len(project.Files))
+	for _, file := range project.Files {
+		filesByID[file.ID] = file
+	}
+
+	assembly.Name = req.Name
+	assembly.Notes = req.Notes
+	assembly.Parts = make([]models.AssemblyPart, 0, len(req.Parts))
+	for _, part := range req.Parts {
+		file, ok := filesByID[part.FileID]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found", "file_id": part.FileID})
+			return false
+		}
+		if !isAssemblyPart(file) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only model files can be assembly parts", "file_id": part.FileID})
+			return false
+		}
+		assembly.Parts = append(assembly.Parts, models.AssemblyPart{Filename: file.Filename, Quantity: part.Quantity})
+	}
+
+	if err := assembly.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// attachAssemblyFiles sets the ID of the file currently at each part's filename
+func attachAssemblyFiles(assemblies []models.Assembly, files []models.ProjectFile) {
+	idsByFilename := make(map[string]uint, len(files))
+	for _, file := range files {
+		idsByFilename[file.Filename] = file.ID
+	}
+	for i := range assemblies {
+		for j, part := range assemblies[i].Parts {
+			if id, ok := idsByFilename[part.Filename]; ok {
+				assemblies[i].Parts[j].FileID = &id
+			}
+		}
+	}
+}
+
+// detectAssemblies suggests an assembly for each folder of the project with
+// two or more model files not yet in an assembly, counting parts from
+// quantity markers in their names
+func detectAssemblies(project models.Project, assemblies []models.Assembly) []models.Assembly {
+	assigned := make(map[string]bool)
+	for _, assembly := range assemblies {
+		for _, part := range assembly.Parts {
+			assigned[part.Filename] = true
+		}
+	}
+
+	byFolder := make(map[string][]models.ProjectFile)
+	for _, file := range project.Files {
+		if isAssemblyPart(file) && !assigned[file.Filename] {
+			folder := path.Dir(file.Filename)
+			byFolder[folder] = append(byFolder[folder], file)
+		}
+	}
+
+	folders := make([]string, 0, len(byFolder))
+	for folder, files := range byFolder {
+		if len(files) >= 2 {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Strings(folders)
+
+	suggested := []models.Assembly{}
+	for _, folder := range folders {
+		files := byFolder[folder]
+		sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+		assembly := models.Assembly{ProjectID: project.ID, Name: project.Name}
+		if folder != "." {
+			assembly.Name = path.Base(folder)
+		}
+		for _, file := range files {
+			id := file.ID
+			assembly.Parts = append(assembly.Parts, models.AssemblyPart{
+				Filename: file.Filename,
+				Quantity: partQuantity(file.Filename),
+				FileID:   &id,
+			})
+		}
+		suggested = append(suggested, assembly)
+	}
+	return suggested
+}
+
+// partQuantity reads how many of a part are needed from its filename, 1 when
+// it doesn't say
+func partQuantity(filename string) int {
+	match := partQuantityPattern.FindStringSubmatch(modelStem(filename))
+	if match == nil {
+		return 1
+	}
+	for _, group := range match[1:] {
+		if n, err := strconv.Atoi(group); err == nil && n >= 1 && n <= maxPartQuantity {
+			return n
+		}
+	}
+	return 1
+}
+
+// isAssemblyPart reports whether a file is a model that can be part of an assembly
+func isAssemblyPart(file models.ProjectFile) bool {
+	switch file.FileType {
+	case models.FileTypeSTL, models.FileType3MF, models.FileTypeCAD:
+		return true
+	}
+	return false
+}
+
+// modelStem is a file's base name without its extension
+func modelStem(filename string) string {
+	base := strings.TrimSuffix(path.Base(filename), mesh.CompressedExt)
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// zipAssemblies adds the assemblies' parts and quantities to a bundle ZIP
+func zipAssemblies(zipWriter *zip.Writer, assemblies []models.Assembly) error {
+	if len(assemblies) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(assemblies, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := zipWriter.Create(assembliesExport)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
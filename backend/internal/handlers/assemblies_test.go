@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPartQuantity tests reading part counts from model filenames
+func TestPartQuantity(t *testing.T) {
+	testCases := map[string]int{
+		"leg_x4.stl":       4,
+		"leg (4x).stl":     4,
+		"parts/2x_top.3mf": 2,
+		"clip-qty12.stl":   12,
+		"washer_6pcs.stl":  6,
+		"M3x8_bolt.stl":    1,
+		"benchy_0.2mm.stl": 1,
+		"spacer_x1000.stl": 1,
+		"top.stl":          1,
+	}
+	for filename, expected := range testCases {
+		if got := partQuantity(filename); got != expected {
+			t.Errorf("partQuantity(%q) = %d, expected %d", filename, got, expected)
+		}
+	}
+}
+
+// TestAssemblies tests suggesting, saving and using assemblies of model files
+func TestAssemblies(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/assemblies", handler.GetProjectAssemblies)
+	router.POST("/api/projects/:id/assemblies", handler.CreateAssembly)
+	router.PUT("/api/projects/:id/assemblies/:assemblyId", handler.UpdateAssembly)
+	router.DELETE("/api/projects/:id/assemblies/:assemblyId", handler.DeleteAssembly)
+	router.POST("/api/projects/:id/assemblies/:assemblyId/bom", handler.AddAssemblyToBOM)
+	router.POST("/api/projects/:id/bundle", handler.CreateBundle)
+
+	project := models.Project{Name: "Table", Path: tmpDir}
+	db.Create(&project)
+	files := map[string]models.ProjectFile{}
+	for _, name := range []string{"leg_x4.stl", "top.stl", "leg_x4.gcode", "stand/base.stl", "README.md"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("; G-code or model\n"), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)}
+		db.Create(&file)
+		files[name] = file
+	}
+	base := fmt.Sprintf("/api/projects/%d/assemblies", project.ID)
+
+	t.Run("Suggested", func(t *testing.T) {
+		w := sendJSON(router, "GET", base, "")
+		var response struct {
+			Assemblies []models.Assembly `json:"assemblies"`
+			Suggested  []models.Assembly `json:"suggested"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || len(response.Assemblies) != 0 || len(response.Suggested) != 1 {
+			t.Fatalf("Expected one suggestion for the root folder, got %d: %s", w.Code, w.Body.String())
+		}
+		suggestion := response.Suggested[0]
+		if suggestion.Name != "Table" || len(suggestion.Parts) != 2 || suggestion.Parts[0].Filename != "leg_x4.stl" || suggestion.Parts[0].Quantity != 4 {
+			t.Errorf("Unexpected suggestion: %+v", suggestion)
+		}
+	})
+
+	var created models.Assembly
+	t.Run("Create", func(t *testing.T) {
+		body := fmt.Sprintf(`{"name": "Table", "parts": [{"file_id": %d, "quantity": 4}, {"file_id": %d, "quantity": 1}]}`, files["leg_x4.stl"].ID, files["top.stl"].ID)
+		w := sendJSON(router, "POST", base, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Assembly models.Assembly `json:"assembly"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		created = response.Assembly
+		if len(created.Parts) != 2 || created.Parts[0].FileID == nil || *created.Parts[0].FileID != files["leg_x4.stl"].ID {
+			t.Errorf("Expected parts with their file IDs, got %+v", created.Parts)
+		}
+
+		w = sendJSON(router, "GET", base, "")
+		var listed struct {
+			Suggested []models.Assembly `json:"suggested"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &listed)
+		if len(listed.Suggested) != 0 {
+			t.Errorf("Expected no suggestions once the parts are assembled, got %+v", listed.Suggested)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		leg := files["leg_x4.stl"].ID
+		for _, body := range []string{
+			`{"name": "Empty", "parts": []}`,
+			fmt.Sprintf(`{"name": "Zero", "parts": [{"file_id": %d, "quantity": 0}]}`, leg),
+			fmt.Sprintf(`{"name": "Twice", "parts": [{"file_id": %d, "quantity": 1}, {"file_id": %d, "quantity": 2}]}`, leg, leg),
+			fmt.Sprintf(`{"name": "G-code", "parts": [{"file_id": %d, "quantity": 1}]}`, files["leg_x4.gcode"].ID),
+			`{"name": "Missing", "parts": [{"file_id": 999, "quantity": 1}]}`,
+		} {
+			if w := sendJSON(router, "POST", base, body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+			}
+		}
+	})
+
+	t.Run("BOM", func(t *testing.T) {
+		w := sendJSON(router, "POST", fmt.Sprintf("%s/%d/bom", base, created.ID), `{"sets": 2}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		// A second run updates the same lines
+		sendJSON(router, "POST", fmt.Sprintf("%s/%d/bom", base, created.ID), `{"sets": 2}`)
+
+		var items []models.BOMItem
+		db.Where("project_id = ?", project.ID).Order("id ASC").Find(&items)
+		if len(items) != 2 || items[0].Name != "leg_x4" || items[0].Quantity != 8 || items[1].Quantity != 2 {
+			t.Errorf("Expected 8 legs and 2 tops, got %+v", items)
+		}
+	})
+
+	t.Run("Bundle", func(t *testing.T) {
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/bundle", project.ID), fmt.Sprintf(`{"assembly_id": %d}`, created.ID))
+		if w.Code != http.StatusOK || w.Header().Get("X-Bundle-GCode-Count") != "1" {
+			t.Fatalf("Expected the legs' G-code, got %d: %s", w.Code, w.Body.String())
+		}
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to read bundle ZIP: %v", err)
+		}
+		var manifest []models.Assembly
+		for _, entry := range archive.File {
+			if entry.Name == assembliesExport {
+				r, _ := entry.Open()
+				data, _ := io.ReadAll(r)
+				json.Unmarshal(data, &manifest)
+			}
+		}
+		if len(manifest) != 1 || manifest[0].Parts[0].Quantity != 4 {
+			t.Errorf("Expected the assembly's quantities in the bundle, got %+v", manifest)
+		}
+
+		if w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/bundle", project.ID), `{"assembly_id": 999}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for an unknown assembly, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		body := fmt.Sprintf(`{"name": "Side table", "parts": [{"file_id": %d, "quantity": 3}]}`, files["leg_x4.stl"].ID)
+		w := sendJSON(router, "PUT", fmt.Sprintf("%s/%d", base, created.ID), body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if w := sendJSON(router, "DELETE", fmt.Sprintf("%s/%d", base, created.ID), ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := sendJSON(router, "DELETE", fmt.Sprintf("%s/%d", base, created.ID), ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a deleted assembly, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
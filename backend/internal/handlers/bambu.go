@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/bambu"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/gcode"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bambuRetryInterval is how long the printer is left before reconnecting
+// after losing its reports, as when it is switched off
+const bambuRetryInterval = 30 * time.Second
+
+// BambuHandler sends files to a Bambu Lab printer and records the prints it
+// reports into print history
+type BambuHandler struct {
+	client *bambu.Client
+
+	// printer names the printer in print history
+	printer string
+
+	mu        sync.Mutex
+	status    *bambu.Status
+	lastError string
+}
+
+// NewBambuHandler creates a new BambuHandler; a nil client reports the
+// printer as not configured
+func NewBambuHandler(client *bambu.Client, printer string) *BambuHandler {
+	return &BambuHandler{client: client, printer: printer}
+}
+
+// BambuPrintRequest is the body accepted by SendFile
+type BambuPrintRequest struct {
+	FileID uint `json:"file_id" binding:"required"`
+
+	// Plate is the plate of a sliced 3MF to print, the first when omitted
+	Plate int `json:"plate"`
+
+	UseAMS      bool  `json:"use_ams"`
+	AMSMapping  []int `json:"ams_mapping"`
+	Timelapse   bool  `json:"timelapse"`
+	BedLeveling *bool `json:"bed_leveling"`
+
+	// Start starts printing once uploaded, unless false
+	Start *bool `json:"start"`
+}
+
+// GetStatus reports what the printer last said it is doing
+func (h *BambuHandler) GetStatus(c *gin.Context) {
+	if h.client == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bambu printer is not configured"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	response := gin.H{
+		"printer":   h.printer,
+		"serial":    h.client.Serial,
+		"connected": h.status != nil,
+		"status":    h.status,
+	}
+	if h.lastError != "" {
+		response["error"] = h.lastError
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SendFile uploads a G-code file or sliced 3MF to the printer's storage and
+// starts printing it
+func (h *BambuHandler) SendFile(c *gin.Context) {
+	if h.client == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bambu printer is not configured"})
+		return
+	}
+
+	var req BambuPrintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Plate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plate must be positive"})
+		return
+	}
+
+	var file models.ProjectFile
+	if err := requestDB(c).First(&file, req.FileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeGCode && !gcode.IsPlateFile(file.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only G-code and sliced 3MF files can be printed"})
+		return
+	}
+
+	content, err := os.Open(file.Filepath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+	defer content.Close()
+
+	name := path.Base(file.Filename)
+	if err := h.client.Upload(c.Request.Context(), name, content); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to upload to the printer", "details": err.Error()})
+		return
+	}
+	if req.Start != nil && !*req.Start {
+		c.JSON(http.StatusOK, gin.H{"message": "File uploaded to the printer", "file": name})
+		return
+	}
+
+	opts := bambu.PrintOptions{
+		Plate:       req.Plate,
+		UseAMS:      req.UseAMS,
+		AMSMapping:  req.AMSMapping,
+		Timelapse:   req.Timelapse,
+		BedLeveling: req.BedLeveling == nil || *req.BedLeveling,
+	}
+	if err := h.client.StartPrint(c.Request.Context(), name, opts); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start the print", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Print started", "file": name})
+}
+
+// Run follows the printer's reports until ctx is done, recording prints as
+// they start and end and reconnecting whenever the printer drops off
+func (h *BambuHandler) Run(ctx context.Context) {
+	if h.client == nil {
+		return
+	}
+	for {
+		var last *bambu.Status
+		err := h.client.Watch(ctx, func(status bambu.Status) {
+			h.recordStatus(last, status)
+			last = &status
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		h.mu.Lock()
+		h.status = nil
+		h.lastError = err.Error()
+		h.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(bambuRetryInterval):
+		}
+	}
+}
+
+// recordStatus keeps the printer's status and records the print it started
+// or ended since its previous report. The first report after connecting only
+// records a print under way, as one it reports ended may have been recorded
+// before.
+func (h *BambuHandler) recordStatus(previous *bambu.Status, status bambu.Status) {
+	h.mu.Lock()
+	h.status = &status
+	h.lastError = ""
+	h.mu.Unlock()
+
+	wasActive := previous != nil && previous.Active()
+	event := printEvent{
+		Path:       status.File(),
+		ExternalID: "bambu:" + h.client.Serial + ":" + status.Job(),
+		At:         time.Now(),
+		Progress:   float64(status.Percent) / 100,
+		Plate:      status.Plate(),
+	}
+	switch {
+	case status.Active() && !wasActive:
+		event.Outcome = models.PrintInProgress
+	case !wasActive:
+		return
+	case status.State == bambu.StateFinished:
+		event.Outcome = models.PrintSucceeded
+	case status.Cancelled():
+		event.Outcome = models.PrintCancelled
+	case status.State == bambu.StateFailed:
+		event.Outcome = models.PrintFailed
+	default:
+		return
+	}
+
+	_, _, err := savePrintEvent(database.GetDB(), h.printer, nil, nil, event)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Prints of files outside the library aren't recorded
+		return
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to record Bambu print of %s: %v\n", event.Path, err)
+	}
+}
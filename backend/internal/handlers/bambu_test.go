@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"archive/zip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/bambu"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestBambuRecordStatus tests recording the prints a Bambu printer reports
+func TestBambuRecordStatus(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+
+	platePath := filepath.Join(tmpDir, "gears.gcode.3mf")
+	out, _ := os.Create(platePath)
+	archive := zip.NewWriter(out)
+	w, _ := archive.Create("Metadata/slice_info.config")
+	w.Write([]byte(`<config>
+  <plate><metadata key="index" value="1"/><metadata key="weight" value="10"/><filament type="PLA" used_g="10"/></plate>
+  <plate><metadata key="index" value="2"/><metadata key="weight" value="40"/><filament type="PETG" used_g="40"/></plate>
+</config>`))
+	archive.Close()
+	out.Close()
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "gears.gcode.3mf", Filepath: platePath, FileType: models.FileType3MF})
+
+	handler := NewBambuHandler(bambu.New("192.168.1.50", "01S00C123456789", "12345678"), "X1C")
+	printing := bambu.Status{State: bambu.StateRunning, GCodeFile: "/data/Metadata/plate_2.gcode", SubtaskName: "gears", SubtaskID: "41"}
+
+	// A print already finished when connecting isn't recorded again
+	finished := printing
+	finished.State = bambu.StateFinished
+	handler.recordStatus(nil, finished)
+	var count int64
+	db.Model(&models.PrintJob{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("Expected no print recorded for the first report, got %d", count)
+	}
+
+	handler.recordStatus(&finished, printing)
+	var job models.PrintJob
+	if err := db.First(&job).Error; err != nil || job.Outcome != models.PrintInProgress || job.Material != "PETG" || job.Printer != "X1C" {
+		t.Fatalf("Expected a PETG print in progress on X1C, got %+v (%v)", job, err)
+	}
+
+	// A repeated report while printing changes nothing
+	handler.recordStatus(&printing, printing)
+	db.Model(&models.PrintJob{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected one print recorded, got %d", count)
+	}
+
+	cancelled := printing
+	cancelled.State = bambu.StateFailed
+	cancelled.PrintError = 50348044
+	cancelled.Percent = 25
+	handler.recordStatus(&printing, cancelled)
+	db.First(&job, job.ID)
+	if job.Outcome != models.PrintCancelled || job.FinishedAt == nil || job.FilamentGrams != 10 {
+		t.Errorf("Expected a cancelled print using a quarter of 40g, got %+v", job)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.status == nil || handler.status.State != bambu.StateFailed {
+		t.Errorf("Expected the last status kept, got %+v", handler.status)
+	}
+}
+
+// TestBambuSendFileValidation tests the requests refused before reaching the printer
+func TestBambuSendFileValidation(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	gin.SetMode(gin.TestMode)
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "gear.stl", Filepath: filepath.Join(tmpDir, "gear.stl"), FileType: models.FileTypeSTL})
+
+	unconfigured := gin.New()
+	unconfigured.POST("/api/printers/bambu/print", NewBambuHandler(nil, "X1C").SendFile)
+	if w := sendJSON(unconfigured, "POST", "/api/printers/bambu/print", `{"file_id": 1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without a printer, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	router := gin.New()
+	router.POST("/api/printers/bambu/print", NewBambuHandler(bambu.New("192.168.1.50", "01S00C123456789", "12345678"), "X1C").SendFile)
+	testCases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"Missing file", `{}`, http.StatusBadRequest},
+		{"Unknown file", `{"file_id": 99}`, http.StatusNotFound},
+		{"Model file", `{"file_id": 1}`, http.StatusBadRequest},
+		{"Negative plate", `{"file_id": 1, "plate": -1}`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "POST", "/api/printers/bambu/print", tc.body); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.expected, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/farm"
+	"3dshelf/pkg/pairing"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxBatchParts bounds the distinct parts and maxBatchQuantity the copies
+	// of each one batch plan may hold
+	maxBatchParts    = 200
+	maxBatchQuantity = 1000
+)
+
+// BatchPartRequest is a model to print and how many copies of it
+type BatchPartRequest struct {
+	FileID   uint `json:"file_id"`
+	Quantity int  `json:"quantity"`
+
+	// PrintTimeSeconds overrides the estimate read from G-code sliced from the model
+	PrintTimeSeconds *int64 `json:"print_time_seconds"`
+}
+
+// BatchPlanRequest is the parts to print and the printers to spread them over
+type BatchPlanRequest struct {
+	Parts []BatchPartRequest `json:"parts"`
+
+	// PrinterIDs are the registered printers to plan on; all of them when empty
+	PrinterIDs []uint `json:"printer_ids"`
+
+	// SpacingMM is the gap kept between parts on a plate; 5mm by default
+	SpacingMM *float64 `json:"spacing_mm"`
+}
+
+// BatchPart is a requested part as planned: its bounding box and per-copy
+// print time, and where the time came from
+type BatchPart struct {
+	FileID    uint      `json:"file_id"`
+	ProjectID uint      `json:"project_id"`
+	Filename  string    `json:"filename"`
+	Quantity  int       `json:"quantity"`
+	Size      []float64 `json:"size"`
+
+	// PrintTimeSeconds is unknown (0) when no G-code sliced from the model has
+	// an estimate and the request gave none; such parts are planned as instant
+	PrintTimeSeconds int64 `json:"print_time_seconds"`
+
+	// TimeSource is "gcode" for an estimate read from the G-code with ID
+	// GCodeFileID, "request" for a given one, empty when unknown
+	TimeSource  string `json:"time_source,omitempty"`
+	GCodeFileID *uint  `json:"gcode_file_id,omitempty"`
+}
+
+// BatchPlan is a suggested batch plan with the parts it plans
+type BatchPlan struct {
+	Parts []BatchPart `json:"parts"`
+	farm.Plan
+}
+
+// PlanBatch suggests which requested parts to print on which printers,
+// grouped into plates by bounding box footprint, and estimates the makespan
+// from the slicer's print times, as a first step toward print farm
+// scheduling. Parts are STL models; their print time is the quickest
+// estimate among G-code sliced from them, unless the request gives one.
+func (h *PrintersHandler) PlanBatch(c *gin.Context) {
+	db := requestDB(c)
+
+	var req BatchPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Parts) == 0 || len(req.Parts) > maxBatchParts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parts must list between 1 and 200 models"})
+		return
+	}
+	spacing := farm.DefaultSpacing
+	if req.SpacingMM != nil {
+		if *req.SpacingMM < 0 || *req.SpacingMM > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "spacing_mm must be between 0 and 100"})
+			return
+		}
+		spacing = *req.SpacingMM
+	}
+
+	var printers []models.Printer
+	query := db.Order("id ASC")
+	if len(req.PrinterIDs) > 0 {
+		query = query.Where("id IN ?", req.PrinterIDs)
+	}
+	if err := query.Find(&printers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch printers"})
+		return
+	}
+	if len(req.PrinterIDs) > 0 && len(printers) != len(slices.Compact(slices.Sorted(slices.Values(req.PrinterIDs)))) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Printer not found"})
+		return
+	}
+	if len(printers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No printers are registered"})
+		return
+	}
+
+	plan := BatchPlan{Parts: []BatchPart{}}
+	parts := make([]farm.Part, 0, len(req.Parts))
+	seen := make(map[uint]bool)
+	for _, requested := range req.Parts {
+		if requested.Quantity < 1 || requested.Quantity > maxBatchQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 1000", "file_id": requested.FileID})
+			return
+		}
+		if requested.PrintTimeSeconds != nil && *requested.PrintTimeSeconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "print_time_seconds must not be negative", "file_id": requested.FileID})
+			return
+		}
+		if seen[requested.FileID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each file may be listed once", "file_id": requested.FileID})
+			return
+		}
+		seen[requested.FileID] = true
+
+		var file models.ProjectFile
+		if err := db.First(&file, requested.FileID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found", "file_id": requested.FileID})
+			return
+		}
+		if file.FileType != models.FileTypeSTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL models can be planned", "file_id": file.ID})
+			return
+		}
+		geometry, err := modelGeometry(db, file)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Model could not be read", "file_id": file.ID, "details": err.Error()})
+			return
+		}
+
+		part := BatchPart{
+			FileID:    file.ID,
+			ProjectID: file.ProjectID,
+			Filename:  file.Filename,
+			Quantity:  requested.Quantity,
+			Size:      geometry.Size,
+		}
+		if requested.PrintTimeSeconds != nil {
+			part.PrintTimeSeconds, part.TimeSource = *requested.PrintTimeSeconds, "request"
+		} else if gcodeID, seconds, err := slicedPrintTime(db, file); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project files"})
+			return
+		} else if seconds > 0 {
+			part.PrintTimeSeconds, part.TimeSource, part.GCodeFileID = seconds, "gcode", &gcodeID
+		}
+
+		plan.Parts = append(plan.Parts, part)
+		parts = append(parts, farm.Part{
+			ID:               file.ID,
+			Size:             [3]float64(geometry.Size),
+			PrintTimeSeconds: part.PrintTimeSeconds,
+			Quantity:         part.Quantity,
+		})
+	}
+
+	farmPrinters := make([]farm.Printer, len(printers))
+	for i, printer := range printers {
+		farmPrinters[i] = farm.Printer{
+			ID:    printer.ID,
+			Name:  printer.Name,
+			Build: [3]float64{printer.BuildXMM, printer.BuildYMM, printer.BuildZMM},
+		}
+	}
+	plan.Plan = farm.Schedule(parts, farmPrinters, spacing)
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// slicedPrintTime returns the quickest print time estimate among the G-code
+// sliced from a model, and that G-code's ID; 0 when none has one
+func slicedPrintTime(db *gorm.DB, model models.ProjectFile) (uint, int64, error) {
+	var files []models.ProjectFile
+	if err := db.Where("project_id = ?", model.ProjectID).Find(&files).Error; err != nil {
+		return 0, 0, err
+	}
+	pairing.Pair(files)
+
+	byID := make(map[uint]models.ProjectFile, len(files))
+	var variants []uint
+	for _, file := range files {
+		byID[file.ID] = file
+		if file.ID == model.ID {
+			variants = file.SlicedVariants
+		}
+	}
+
+	var bestID uint
+	var best int64
+	for _, id := range variants {
+		seconds := readEstimates(byID[id]).PrintTimeSeconds
+		if seconds > 0 && (best == 0 || seconds < best) {
+			bestID, best = id, seconds
+		}
+	}
+	return bestID, best, nil
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPlanBatch tests parts are planned across printers with their sliced print times
+func TestPlanBatch(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/printers/plan", NewPrintersHandler().PlanBatch)
+
+	db.Create(&models.Printer{Name: "Mini", BuildXMM: 180, BuildYMM: 180, BuildZMM: 180})
+	db.Create(&models.Printer{Name: "MK4", BuildXMM: 250, BuildYMM: 210, BuildZMM: 220})
+	project := models.Project{Name: "Farm", Path: filepath.Join(tmpDir, "farm")}
+	db.Create(&project)
+
+	for _, m := range []struct {
+		name string
+		mesh *mesh.Mesh
+	}{
+		{"bracket.stl", testBox(60, 60, 20, mesh.Vec3{})},
+		{"panel.stl", testBox(200, 240, 5, mesh.Vec3{})},
+	} {
+		path := filepath.Join(tmpDir, m.name)
+		out, _ := os.Create(path)
+		mesh.WriteBinarySTL(out, m.mesh)
+		out.Close()
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: m.name, Filepath: path, Hash: "hash-" + m.name, FileType: models.FileTypeSTL})
+	}
+	gcodePath := filepath.Join(tmpDir, "bracket_0.2mm.gcode")
+	os.WriteFile(gcodePath, []byte("G28\n; estimated printing time (normal mode) = 1h 0m 0s\n"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "bracket_0.2mm.gcode", Filepath: gcodePath, FileType: models.FileTypeGCode})
+
+	w := sendJSON(router, "POST", "/api/printers/plan",
+		`{"parts": [{"file_id": 1, "quantity": 4}, {"file_id": 2, "quantity": 1, "print_time_seconds": 10800}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var plan BatchPlan
+	json.Unmarshal(w.Body.Bytes(), &plan)
+
+	if len(plan.Parts) != 2 || plan.Parts[0].PrintTimeSeconds != 3600 || plan.Parts[0].TimeSource != "gcode" ||
+		plan.Parts[0].GCodeFileID == nil || *plan.Parts[0].GCodeFileID != 3 || plan.Parts[1].TimeSource != "request" {
+		t.Errorf("Expected print times from the G-code and the request, got %+v", plan.Parts)
+	}
+	if len(plan.Printers) != 2 || len(plan.Printers[1].Plates) != 1 || plan.Printers[1].Plates[0].Items[0].PartID != 2 {
+		t.Errorf("Expected the panel on the MK4, got %+v", plan.Printers)
+	}
+	if plan.MakespanSeconds != 4*3600 || len(plan.Unplaced) != 0 {
+		t.Errorf("Expected a 4 hour makespan with everything placed, got %d, %+v", plan.MakespanSeconds, plan.Unplaced)
+	}
+
+	// On the Mini alone the panel can't be placed
+	w = sendJSON(router, "POST", "/api/printers/plan", `{"parts": [{"file_id": 2, "quantity": 1}], "printer_ids": [1]}`)
+	var mini BatchPlan
+	json.Unmarshal(w.Body.Bytes(), &mini)
+	if w.Code != http.StatusOK || len(mini.Unplaced) != 1 || mini.Parts[0].TimeSource != "" {
+		t.Errorf("Expected the panel unplaced without a print time, got %d: %s", w.Code, w.Body.String())
+	}
+
+	testCases := []struct {
+		body     string
+		expected int
+	}{
+		{`{"parts": []}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 1, "quantity": 0}]}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 1, "quantity": 1}, {"file_id": 1, "quantity": 2}]}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 3, "quantity": 1}]}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 99, "quantity": 1}]}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 1, "quantity": 1}], "printer_ids": [1, 9]}`, http.StatusBadRequest},
+		{`{"parts": [{"file_id": 1, "quantity": 1}], "spacing_mm": -1}`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "POST", "/api/printers/plan", tc.body); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.expected, w.Code)
+		}
+	}
+}
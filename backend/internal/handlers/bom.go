@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BOMAvailability compares a printed BOM item with the printed part inventory
+type BOMAvailability struct {
+	models.BOMItem
+	OnHand    int `json:"on_hand"`
+	Shortfall int `json:"shortfall"`
+}
+
+// BuildCheck answers whether a project's kit can be assembled from stock
+type BuildCheck struct {
+	Buildable bool `json:"buildable"`
+
+	// Kits is how many complete sets of printed parts are in stock
+	Kits int `json:"kits"`
+
+	Printed []BOMAvailability `json:"printed"`
+
+	// Hardware is not tracked in inventory and has to be checked by hand
+	Hardware []models.BOMItem `json:"hardware"`
+}
+
+// GetProjectBOM returns a project's bill of materials
+func (h *ProjectsHandler) GetProjectBOM(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var items []models.BOMItem
+	if err := db.Where("project_id = ?", project.ID).Order("kind DESC, id ASC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bill of materials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// AddBOMItem adds a printed part or piece of hardware to a project's bill of materials
+func (h *ProjectsHandler) AddBOMItem(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var item models.BOMItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	item.ID = 0
+	item.ProjectID = project.ID
+
+	if !resolveBOMItem(c, db, &item) {
+		return
+	}
+
+	if err := db.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Item added successfully",
+		"item":    item,
+	})
+}
+
+// UpdateBOMItem replaces a line of a project's bill of materials
+func (h *ProjectsHandler) UpdateBOMItem(c *gin.Context) {
+	db := requestDB(c)
+
+	var existing models.BOMItem
+	if err := db.Where("id = ? AND project_id = ?", c.Param("itemId"), c.Param("id")).First(&existing).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	var item models.BOMItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	item.ID = existing.ID
+	item.ProjectID = existing.ProjectID
+	item.CreatedAt = existing.CreatedAt
+
+	if !resolveBOMItem(c, db, &item) {
+		return
+	}
+
+	if err := db.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Item updated successfully",
+		"item":    item,
+	})
+}
+
+// DeleteBOMItem removes a line from a project's bill of materials
+func (h *ProjectsHandler) DeleteBOMItem(c *gin.Context) {
+	result := requestDB(c).Where("id = ? AND project_id = ?", c.Param("itemId"), c.Param("id")).Delete(&models.BOMItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete item"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// CheckBuildable compares a project's printed BOM items with the printed part
+// inventory to answer "can I build this now?"
+func (h *ProjectsHandler) CheckBuildable(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var items []models.BOMItem
+	if err := db.Where("project_id = ?", project.ID).Order("id ASC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bill of materials"})
+		return
+	}
+
+	check := BuildCheck{
+		Printed:  []BOMAvailability{},
+		Hardware: []models.BOMItem{},
+	}
+	for _, item := range items {
+		if item.Kind == models.BOMHardware {
+			check.Hardware = append(check.Hardware, item)
+			continue
+		}
+
+		var onHand int
+		if err := db.Model(&models.PrintedPart{}).Where("file_id = ?", *item.FileID).
+			Select("COALESCE(SUM(quantity), 0)").Scan(&onHand).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check inventory"})
+			return
+		}
+
+		availability := BOMAvailability{BOMItem: item, OnHand: onHand}
+		if onHand < item.Quantity {
+			availability.Shortfall = item.Quantity - onHand
+		}
+
+		kits := onHand / item.Quantity
+		if len(check.Printed) == 0 || kits < check.Kits {
+			check.Kits = kits
+		}
+		check.Printed = append(check.Printed, availability)
+	}
+	check.Buildable = len(items) > 0 && (len(check.Printed) == 0 || check.Kits > 0)
+
+	c.JSON(http.StatusOK, check)
+}
+
+// resolveBOMItem validates an item and checks its file exists, naming printed
+// items after their file by default. It writes the error response and returns
+// false when the item is invalid.
+func resolveBOMItem(c *gin.Context, db *gorm.DB, item *models.BOMItem) bool {
+	if err := item.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if item.FileID != nil {
+		var file models.ProjectFile
+		if err := db.First(&file, *item.FileID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found"})
+			return false
+		}
+		if item.Name == "" {
+			item.Name = strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename))
+		}
+	}
+	return true
+}
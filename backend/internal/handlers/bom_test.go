@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupBOMRouter creates a router with the bill of materials routes
+func setupBOMRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(t.TempDir())
+	router.GET("/api/projects/:id/bom", handler.GetProjectBOM)
+	router.POST("/api/projects/:id/bom", handler.AddBOMItem)
+	router.GET("/api/projects/:id/bom/check", handler.CheckBuildable)
+	router.PUT("/api/projects/:id/bom/:itemId", handler.UpdateBOMItem)
+	router.DELETE("/api/projects/:id/bom/:itemId", handler.DeleteBOMItem)
+	return router
+}
+
+// TestProjectBOM tests declaring a kit and checking it against inventory
+func TestProjectBOM(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupBOMRouter(t)
+
+	kit := models.Project{Name: "Desk Organizer", Path: "/test/organizer"}
+	db.Create(&kit)
+	library := models.Project{Name: "Hinge Library", Path: "/test/hinges"}
+	db.Create(&library)
+
+	tray := models.ProjectFile{ProjectID: kit.ID, Filename: "tray.stl", Filepath: "/test/organizer/tray.stl", FileType: models.FileTypeSTL}
+	db.Create(&tray)
+	hinge := models.ProjectFile{ProjectID: library.ID, Filename: "hinge.stl", Filepath: "/test/hinges/hinge.stl", FileType: models.FileTypeSTL}
+	db.Create(&hinge)
+
+	items := []string{
+		`{"kind": "printed", "file_id": 1, "quantity": 2}`,
+		`{"kind": "printed", "file_id": 2, "name": "Lid hinge", "quantity": 4}`,
+		`{"kind": "hardware", "name": "M3x8 screw", "quantity": 8}`,
+	}
+	for _, body := range items {
+		if w := sendJSON(router, "POST", "/api/projects/1/bom", body); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+
+	check := func() BuildCheck {
+		w := sendJSON(router, "GET", "/api/projects/1/bom/check", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var result BuildCheck
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+
+	t.Run("Missing stock", func(t *testing.T) {
+		db.Create(&models.PrintedPart{Name: "tray", FileID: &tray.ID, Quantity: 5})
+		db.Create(&models.PrintedPart{Name: "hinge", FileID: &hinge.ID, Quantity: 1})
+		db.Create(&models.PrintedPart{Name: "hinge (blue)", FileID: &hinge.ID, Quantity: 2})
+
+		result := check()
+		if result.Buildable || result.Kits != 0 {
+			t.Errorf("Expected kit not to be buildable, got %+v", result)
+		}
+		if len(result.Printed) != 2 || result.Printed[0].Name != "tray" || result.Printed[1].OnHand != 3 || result.Printed[1].Shortfall != 1 {
+			t.Errorf("Unexpected availability: %+v", result.Printed)
+		}
+		if len(result.Hardware) != 1 || result.Hardware[0].Name != "M3x8 screw" {
+			t.Errorf("Expected hardware to be listed, got %+v", result.Hardware)
+		}
+	})
+
+	t.Run("Enough stock", func(t *testing.T) {
+		db.Model(&models.PrintedPart{}).Where("name = ?", "hinge").Update("quantity", 7)
+
+		if result := check(); !result.Buildable || result.Kits != 2 {
+			t.Errorf("Expected 2 buildable kits, got %+v", result)
+		}
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		if w := sendJSON(router, "PUT", "/api/projects/1/bom/1", `{"kind": "printed", "file_id": 1, "quantity": 6}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if result := check(); result.Buildable {
+			t.Errorf("Expected 6 trays to exceed stock, got %+v", result)
+		}
+
+		if w := sendJSON(router, "DELETE", "/api/projects/2/bom/1", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for another project's item, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := sendJSON(router, "DELETE", "/api/projects/1/bom/1", ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		w := sendJSON(router, "GET", "/api/projects/1/bom", "")
+		var response struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Count != 2 {
+			t.Errorf("Expected 2 items left, got %d", response.Count)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		testCases := map[string]string{
+			"Printed without file":  `{"kind": "printed", "quantity": 1}`,
+			"Unknown file":          `{"kind": "printed", "file_id": 99, "quantity": 1}`,
+			"Hardware without name": `{"kind": "hardware", "quantity": 1}`,
+			"Zero quantity":         `{"kind": "hardware", "name": "Nut", "quantity": 0}`,
+			"Unknown kind":          `{"kind": "sticker", "name": "Logo", "quantity": 1}`,
+		}
+		for name, body := range testCases {
+			if w := sendJSON(router, "POST", "/api/projects/1/bom", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+			}
+		}
+		if w := sendJSON(router, "POST", "/api/projects/99/bom", items[2]); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+// TestCheckBuildableEmptyBOM tests that projects without a BOM are not buildable
+func TestCheckBuildableEmptyBOM(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupBOMRouter(t)
+	db.Create(&models.Project{Name: "Empty", Path: "/test/empty"})
+
+	w := sendJSON(router, "GET", "/api/projects/1/bom/check", "")
+	var result BuildCheck
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result.Buildable {
+		t.Error("Expected project without a BOM not to be buildable")
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/pairing"
+	"archive/zip"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BundleRequest selects which G-code goes into a print-ready bundle. Empty
+// filters match everything.
+type BundleRequest struct {
+	Printer        string  `json:"printer"`
+	NozzleDiameter float64 `json:"nozzle_diameter"`
+	Material       string  `json:"material"`
+
+	// AssemblyID limits the bundle to G-code sliced from the parts of one assembly
+	AssemblyID *uint `json:"assembly_id"`
+
+	// IncludeREADME and IncludeImages default to true
+	IncludeREADME *bool `json:"include_readme"`
+	IncludeImages *bool `json:"include_images"`
+}
+
+// matches reports whether sliced G-code fits the requested profile
+func (r BundleRequest) matches(meta gcode.Metadata) bool {
+	if r.Printer != "" && !strings.Contains(strings.ToLower(meta.Printer), strings.ToLower(r.Printer)) {
+		return false
+	}
+	if r.NozzleDiameter > 0 && math.Abs(meta.NozzleDiameter-r.NozzleDiameter) > 0.001 {
+		return false
+	}
+	if r.Material != "" && !strings.EqualFold(meta.Material, r.Material) {
+		return false
+	}
+	return true
+}
+
+// CreateBundle streams a ZIP with the G-code matching a printer/material
+// profile plus the README and images, ready to hand to a print farm
+func (h *ProjectsHandler) CreateBundle(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var req BundleRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	var project models.Project
+	if err := requestDB(c).Preload("Files").First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	// The bundle lists how many of each part to print, for one assembly or all of them
+	assemblies := requestDB(c).Where("project_id = ?", project.ID).Order("name ASC, id ASC")
+	if req.AssemblyID != nil {
+		assemblies = assemblies.Where("id = ?", *req.AssemblyID)
+	}
+	var bundled []models.Assembly
+	if err := assemblies.Find(&bundled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assemblies"})
+		return
+	}
+	if req.AssemblyID != nil && len(bundled) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assembly not found"})
+		return
+	}
+	var partIDs map[uint]bool
+	if req.AssemblyID != nil {
+		partIDs = assemblyPartIDs(bundled[0], project.Files)
+		pairing.Pair(project.Files)
+	}
+
+	includeREADME := req.IncludeREADME == nil || *req.IncludeREADME
+	includeImages := req.IncludeImages == nil || *req.IncludeImages
+
+	var gcodeFiles, extras []models.ProjectFile
+	for _, file := range project.Files {
+		switch {
+		case file.FileType == models.FileTypeGCode:
+			meta, err := gcode.ReadMetadata(file.Filepath)
+			if err != nil {
+				fmt.Printf("Warning: Failed to read G-code metadata from %s: %v\n", file.Filepath, err)
+				continue
+			}
+			if req.matches(meta) && (partIDs == nil || slicedFromAny(file, partIDs)) {
+				gcodeFiles = append(gcodeFiles, file)
+			}
+		case file.FileType == models.FileTypeREADME && includeREADME:
+			extras = append(extras, file)
+		case models.IsImageFile(file.Filename) && includeImages:
+			extras = append(extras, file)
+		}
+	}
+
+	if len(gcodeFiles) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No G-code files match the requested profile"})
+		return
+	}
+
+	zipFilename := fmt.Sprintf("%s-bundle.zip", strings.ReplaceAll(project.Name, " ", "_"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", zipFilename))
+	c.Header("X-Bundle-GCode-Count", strconv.Itoa(len(gcodeFiles)))
+
+	zipWriter := zip.NewWriter(c.Writer)
+	defer zipWriter.Close()
+
+	err := zipProjectFiles(zipWriter, append(gcodeFiles, extras...))
+	if err == nil {
+		err = zipAssemblies(zipWriter, bundled)
+	}
+	if err != nil {
+		// Headers are already written, so the error can only be logged
+		fmt.Printf("Error creating bundle for project %s: %v\n", project.Name, err)
+	}
+}
+
+// assemblyPartIDs returns the IDs of the files the assembly's parts are
+func assemblyPartIDs(assembly models.Assembly, files []models.ProjectFile) map[uint]bool {
+	filenames := make(map[string]bool, len(assembly.Parts))
+	for _, part := range assembly.Parts {
+		filenames[part.Filename] = true
+	}
+	ids := make(map[uint]bool, len(assembly.Parts))
+	for _, file := range files {
+		if filenames[file.Filename] {
+			ids[file.ID] = true
+		}
+	}
+	return ids
+}
+
+// slicedFromAny reports whether paired G-code was sliced from one of the files
+func slicedFromAny(file models.ProjectFile, ids map[uint]bool) bool {
+	for _, source := range file.SourceModels {
+		if ids[source] {
+			return true
+		}
+	}
+	return false
+}
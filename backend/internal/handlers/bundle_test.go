@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateBundle tests assembling print-ready bundles filtered by G-code metadata
+func TestCreateBundle(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.POST("/api/projects/:id/bundle", handler.CreateBundle)
+
+	project := models.Project{Name: "Benchy Boat", Path: tmpDir}
+	db.Create(&project)
+
+	files := map[string]string{
+		"benchy_0.4_PLA.gcode":  "; nozzle_diameter = 0.4\n; filament_type = PLA\n; printer_model = MK4\nG28\n",
+		"benchy_0.6_PETG.gcode": "; nozzle_diameter = 0.6\n; filament_type = PETG\n; printer_model = MK4\nG28\n",
+		"benchy.stl":            "solid benchy",
+		"README.md":             "# Benchy",
+		"photo.jpg":             "JPEG",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)})
+	}
+
+	bundle := func(body string) (*httptest.ResponseRecorder, []string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/projects/1/bundle", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			return w, nil
+		}
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to read bundle ZIP: %v", err)
+		}
+		var names []string
+		for _, entry := range archive.File {
+			names = append(names, entry.Name)
+		}
+		sort.Strings(names)
+		return w, names
+	}
+
+	t.Run("Profile filter", func(t *testing.T) {
+		w, names := bundle(`{"nozzle_diameter": 0.4, "material": "pla", "printer": "mk4"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		expected := []string{"README.md", "benchy_0.4_PLA.gcode", "photo.jpg"}
+		if len(names) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, names)
+		}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Errorf("Expected %v, got %v", expected, names)
+				break
+			}
+		}
+		if w.Header().Get("X-Bundle-GCode-Count") != "1" {
+			t.Errorf("Expected 1 G-code file, got %s", w.Header().Get("X-Bundle-GCode-Count"))
+		}
+	})
+
+	t.Run("No filters and no extras", func(t *testing.T) {
+		_, names := bundle(`{"include_readme": false, "include_images": false}`)
+		if len(names) != 2 {
+			t.Errorf("Expected both G-code files only, got %v", names)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		w, _ := bundle(`{"material": "TPU"}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Invalid body", func(t *testing.T) {
+		w, _ := bundle(`{"nozzle_diameter": "wide"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
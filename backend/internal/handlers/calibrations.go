@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/units"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCalibrationLimit = 100
+	maxCalibrationLimit     = 1000
+)
+
+// CalibratedSetting is the latest measured value of one setting and where it came from
+type CalibratedSetting struct {
+	Value         float64   `json:"value"`
+	CalibrationID uint      `json:"calibration_id"`
+	CalibratedAt  time.Time `json:"calibrated_at"`
+}
+
+// KnownGoodSettings is the last known good value of each calibrated setting
+// for a printer and filament, null when never measured
+type KnownGoodSettings struct {
+	Printer    string `json:"printer"`
+	FilamentID *uint  `json:"filament_id"`
+	Material   string `json:"material"`
+
+	Flow            *CalibratedSetting `json:"flow"`
+	PressureAdvance *CalibratedSetting `json:"pressure_advance"`
+	NozzleTempC     *CalibratedSetting `json:"nozzle_temp_c"`
+
+	Display units.Display `json:"display,omitempty"`
+}
+
+// CalibrationsHandler handles printer calibration HTTP requests
+type CalibrationsHandler struct{}
+
+// NewCalibrationsHandler creates a new CalibrationsHandler
+func NewCalibrationsHandler() *CalibrationsHandler {
+	return &CalibrationsHandler{}
+}
+
+// RecordCalibration stores calibration results for a printer and filament
+func (h *CalibrationsHandler) RecordCalibration(c *gin.Context) {
+	var calibration models.Calibration
+	if err := c.ShouldBindJSON(&calibration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	calibration.ID = 0
+
+	db := requestDB(c)
+	if calibration.FilamentID != nil {
+		var filament models.Filament
+		if err := db.First(&filament, *calibration.FilamentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Filament not found"})
+			return
+		}
+		if calibration.Material == "" {
+			calibration.Material = filament.Material
+		}
+	}
+
+	if err := calibration.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Create(&calibration).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record calibration"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Calibration recorded successfully",
+		"calibration": calibration,
+	})
+}
+
+// GetCalibrations returns calibration history, most recent first, optionally
+// for one ?printer=, ?filament_id= or ?material=
+func (h *CalibrationsHandler) GetCalibrations(c *gin.Context) {
+	limit := defaultCalibrationLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxCalibrationLimit)
+	}
+
+	query := requestDB(c)
+	if printer := c.Query("printer"); printer != "" {
+		query = query.Where("printer = ? COLLATE NOCASE", printer)
+	}
+	if raw := c.Query("filament_id"); raw != "" {
+		filamentID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filament_id"})
+			return
+		}
+		query = query.Where("filament_id = ?", filamentID)
+	}
+	if material := c.Query("material"); material != "" {
+		query = query.Where("material = ? COLLATE NOCASE", material)
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	var calibrations []models.Calibration
+	if err := query.Order("calibrated_at DESC, id DESC").Limit(limit).Find(&calibrations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibrations"})
+		return
+	}
+	for i := range calibrations {
+		calibrations[i].Display = calibrationDisplay(prefs, calibrations[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"calibrations": calibrations,
+		"count":        len(calibrations),
+	})
+}
+
+// DeleteCalibration removes a calibration result
+func (h *CalibrationsHandler) DeleteCalibration(c *gin.Context) {
+	result := requestDB(c).Delete(&models.Calibration{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calibration"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calibration not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calibration deleted successfully"})
+}
+
+// GetKnownGoodSettings returns the last known good settings for ?printer= with
+// ?filament_id= or ?material=. Results for the spool take precedence over
+// results recorded for its material as a whole.
+func (h *CalibrationsHandler) GetKnownGoodSettings(c *gin.Context) {
+	db := requestDB(c)
+
+	settings := KnownGoodSettings{Printer: c.Query("printer"), Material: c.Query("material")}
+	if settings.Printer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Printer is required"})
+		return
+	}
+
+	if raw := c.Query("filament_id"); raw != "" {
+		var filament models.Filament
+		if err := db.First(&filament, raw).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Filament not found"})
+			return
+		}
+		settings.FilamentID = &filament.ID
+		if settings.Material == "" {
+			settings.Material = filament.Material
+		}
+	}
+	if settings.FilamentID == nil && settings.Material == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filament_id or material is required"})
+		return
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	calibrations, err := matchingCalibrations(db, settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calibrations"})
+		return
+	}
+	if len(calibrations) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No calibrations found"})
+		return
+	}
+
+	for _, calibration := range calibrations {
+		if settings.Flow == nil && calibration.Flow != nil {
+			settings.Flow = calibratedSetting(calibration, *calibration.Flow)
+		}
+		if settings.PressureAdvance == nil && calibration.PressureAdvance != nil {
+			settings.PressureAdvance = calibratedSetting(calibration, *calibration.PressureAdvance)
+		}
+		if settings.NozzleTempC == nil && calibration.NozzleTempC != nil {
+			settings.NozzleTempC = calibratedSetting(calibration, float64(*calibration.NozzleTempC))
+		}
+	}
+	if settings.NozzleTempC != nil {
+		settings.Display = units.Display{"nozzle_temp_c": prefs.TemperatureOf(settings.NozzleTempC.Value)}
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// matchingCalibrations loads the printer's calibrations for the spool and its
+// material, spool results first and most recent first within each
+func matchingCalibrations(db *gorm.DB, settings KnownGoodSettings) ([]models.Calibration, error) {
+	query := db.Where("printer = ? COLLATE NOCASE", settings.Printer)
+	materialWide := db.Where("filament_id IS NULL AND material = ? COLLATE NOCASE", settings.Material)
+	switch {
+	case settings.FilamentID != nil && settings.Material != "":
+		query = query.Where(db.Where("filament_id = ?", *settings.FilamentID).Or(materialWide))
+	case settings.FilamentID != nil:
+		query = query.Where("filament_id = ?", *settings.FilamentID)
+	default:
+		query = query.Where(materialWide)
+	}
+
+	var calibrations []models.Calibration
+	err := query.Order("filament_id IS NULL ASC, calibrated_at DESC, id DESC").Find(&calibrations).Error
+	return calibrations, err
+}
+
+func calibratedSetting(calibration models.Calibration, value float64) *CalibratedSetting {
+	return &CalibratedSetting{Value: value, CalibrationID: calibration.ID, CalibratedAt: calibration.CalibratedAt}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupCalibrationsRouter creates a router with the calibration routes
+func setupCalibrationsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewCalibrationsHandler()
+	router.GET("/api/calibrations", handler.GetCalibrations)
+	router.POST("/api/calibrations", handler.RecordCalibration)
+	router.GET("/api/calibrations/latest", handler.GetKnownGoodSettings)
+	router.DELETE("/api/calibrations/:id", handler.DeleteCalibration)
+	return router
+}
+
+// TestRecordCalibration tests recording and listing calibration results
+func TestRecordCalibration(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupCalibrationsRouter()
+
+	db.Create(&models.Filament{Name: "Galaxy Black", Material: "PETG", DiameterMM: 1.75})
+
+	w := sendJSON(router, "POST", "/api/calibrations", `{"printer": " MK4 ", "filament_id": 1, "flow": 0.97, "calibrated_at": "2026-03-01T10:00:00Z"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Calibration models.Calibration `json:"calibration"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Calibration.Printer != "MK4" || created.Calibration.Material != "PETG" {
+		t.Errorf("Expected the printer trimmed and the material taken from the spool, got %+v", created.Calibration)
+	}
+
+	sendJSON(router, "POST", "/api/calibrations", `{"printer": "Ender 3", "material": "PLA", "nozzle_temp_c": 205}`)
+
+	testCases := map[string]string{
+		"Missing printer":   `{"material": "PLA", "flow": 1}`,
+		"Missing filament":  `{"printer": "MK4", "flow": 1}`,
+		"Nothing measured":  `{"printer": "MK4", "material": "PLA"}`,
+		"Flow out of range": `{"printer": "MK4", "material": "PLA", "flow": 3}`,
+		"Unknown spool":     `{"printer": "MK4", "filament_id": 9, "flow": 1}`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/calibrations", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w = sendJSON(router, "GET", "/api/calibrations?printer=mk4", "")
+	var history struct {
+		Calibrations []models.Calibration `json:"calibrations"`
+		Count        int                  `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if history.Count != 1 || history.Calibrations[0].Printer != "MK4" {
+		t.Errorf("Expected the printer filter to apply, got %s", w.Body.String())
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/calibrations/1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "DELETE", "/api/calibrations/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted calibration, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestGetKnownGoodSettings tests combining the latest measurement of each setting
+func TestGetKnownGoodSettings(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupCalibrationsRouter()
+
+	db.Create(&models.Filament{Name: "Galaxy Black", Material: "PETG", DiameterMM: 1.75})
+	for _, body := range []string{
+		`{"printer": "MK4", "material": "PETG", "flow": 0.95, "pressure_advance": 0.05, "nozzle_temp_c": 240, "calibrated_at": "2026-01-01T10:00:00Z"}`,
+		`{"printer": "MK4", "filament_id": 1, "flow": 0.97, "calibrated_at": "2026-02-01T10:00:00Z"}`,
+		`{"printer": "MK4", "filament_id": 1, "flow": 0.98, "calibrated_at": "2026-01-15T10:00:00Z"}`,
+		`{"printer": "MK4", "material": "PETG", "nozzle_temp_c": 245, "calibrated_at": "2026-03-01T10:00:00Z"}`,
+		`{"printer": "Ender 3", "filament_id": 1, "pressure_advance": 0.4}`,
+	} {
+		if w := sendJSON(router, "POST", "/api/calibrations", body); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to record calibration: %s", w.Body.String())
+		}
+	}
+
+	w := sendJSON(router, "GET", "/api/calibrations/latest?printer=mk4&filament_id=1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var settings KnownGoodSettings
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to parse settings: %v", err)
+	}
+	if settings.Material != "PETG" || settings.Flow == nil || settings.Flow.Value != 0.97 || settings.Flow.CalibrationID != 2 {
+		t.Errorf("Expected the spool's latest flow, got %+v", settings.Flow)
+	}
+	if settings.NozzleTempC == nil || settings.NozzleTempC.Value != 245 {
+		t.Errorf("Expected the latest material-wide temperature, got %+v", settings.NozzleTempC)
+	}
+	if settings.PressureAdvance == nil || settings.PressureAdvance.Value != 0.05 {
+		t.Errorf("Expected pressure advance from this printer only, got %+v", settings.PressureAdvance)
+	}
+
+	w = sendJSON(router, "GET", "/api/calibrations/latest?printer=MK4&material=petg", "")
+	json.Unmarshal(w.Body.Bytes(), &settings)
+	if settings.Flow == nil || settings.Flow.Value != 0.95 {
+		t.Errorf("Expected only material-wide results without a spool, got %+v", settings.Flow)
+	}
+
+	testCases := map[string]struct {
+		path string
+		code int
+	}{
+		"Missing printer":  {"/api/calibrations/latest?material=PETG", http.StatusBadRequest},
+		"Missing filament": {"/api/calibrations/latest?printer=MK4", http.StatusBadRequest},
+		"Unknown spool":    {"/api/calibrations/latest?printer=MK4&filament_id=9", http.StatusNotFound},
+		"Never calibrated": {"/api/calibrations/latest?printer=Voron&material=PETG", http.StatusNotFound},
+	}
+	for name, tc := range testCases {
+		if w := sendJSON(router, "GET", tc.path, ""); w.Code != tc.code {
+			t.Errorf("%s: expected status %d, got %d", name, tc.code, w.Code)
+		}
+	}
+}
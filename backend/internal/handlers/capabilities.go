@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"3dshelf/pkg/features"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Capabilities tells the frontend which subsystems this backend supports
+type Capabilities struct {
+	Features map[features.Flag]bool `json:"features"`
+
+	// Integrations reports each third-party service as usable when it is
+	// configured and the integrations flag is on
+	Integrations map[string]bool `json:"integrations"`
+}
+
+// CapabilitiesHandler handles the capabilities HTTP request
+type CapabilitiesHandler struct {
+	features     *features.Flags
+	integrations map[string]bool
+}
+
+// NewCapabilitiesHandler creates a new CapabilitiesHandler reporting the given flags
+func NewCapabilitiesHandler(flags *features.Flags) *CapabilitiesHandler {
+	return &CapabilitiesHandler{
+		features:     flags,
+		integrations: make(map[string]bool),
+	}
+}
+
+// SetIntegration records whether a third-party service is configured
+func (h *CapabilitiesHandler) SetIntegration(name string, configured bool) {
+	h.integrations[name] = configured
+}
+
+// GetCapabilities returns the enabled feature flags and usable integrations
+func (h *CapabilitiesHandler) GetCapabilities(c *gin.Context) {
+	capabilities := Capabilities{
+		Features:     h.features.All(),
+		Integrations: make(map[string]bool, len(h.integrations)),
+	}
+	for name, configured := range h.integrations {
+		capabilities.Integrations[name] = configured && capabilities.Features[features.Integrations]
+	}
+
+	c.JSON(http.StatusOK, capabilities)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetCapabilities tests reporting and switching feature flags
+func TestGetCapabilities(t *testing.T) {
+	db := setupTestDB(t)
+	flags := features.New()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := NewAdminHandler(scanner.New(db, t.TempDir()))
+	admin.SetFeatures(flags)
+	router.GET("/api/admin/settings", admin.GetSettings)
+	router.PUT("/api/admin/settings", admin.UpdateSettings)
+	capabilities := NewCapabilitiesHandler(flags)
+	capabilities.SetIntegration("octoprint", true)
+	capabilities.SetIntegration("thingiverse", false)
+	router.GET("/api/capabilities", capabilities.GetCapabilities)
+
+	get := func() Capabilities {
+		w := sendJSON(router, "GET", "/api/capabilities", "")
+		var response Capabilities
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	response := get()
+	if !response.Features[features.FullTextSearch] || response.Features[features.Watcher] {
+		t.Errorf("Expected default flags, got %v", response.Features)
+	}
+	if !response.Integrations["octoprint"] || response.Integrations["thingiverse"] {
+		t.Errorf("Expected only configured integrations to be usable, got %v", response.Integrations)
+	}
+
+	w := sendJSON(router, "PUT", "/api/admin/settings", `{"features": {"watcher": true, "integrations": false}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	response = get()
+	if !response.Features[features.Watcher] || !response.Features[features.FullTextSearch] || response.Integrations["octoprint"] {
+		t.Errorf("Expected the flags to be switched, got %+v", response)
+	}
+
+	saved := make(map[features.Flag]bool)
+	if found, err := database.LoadSetting(db, features.SettingKey, &saved); err != nil || !found || !saved[features.Watcher] {
+		t.Errorf("Expected the flags to be persisted, got %v (found=%v err=%v)", saved, found, err)
+	}
+
+	if w := sendJSON(router, "PUT", "/api/admin/settings", `{"features": {"teleporter": true}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown flag, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// collectionProjectCount counts each collection's projects without loading them
+const collectionProjectCount = "(SELECT COUNT(*) FROM projects WHERE projects.collection_id = collections.id AND projects.deleted_at IS NULL) AS project_count"
+
+// CollectionsHandler handles collection HTTP requests
+type CollectionsHandler struct{}
+
+// NewCollectionsHandler creates a new CollectionsHandler
+func NewCollectionsHandler() *CollectionsHandler {
+	return &CollectionsHandler{}
+}
+
+// GetCollections returns all collections with their project counts
+func (h *CollectionsHandler) GetCollections(c *gin.Context) {
+	var collections []models.Collection
+	if err := requestDB(c).Select("collections.*, " + collectionProjectCount).
+		Order("name ASC").Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": collections,
+		"count":       len(collections),
+	})
+}
+
+// GetCollection returns a collection with its projects
+func (h *CollectionsHandler) GetCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := requestDB(c).Select("collections.*, "+collectionProjectCount).
+		Preload("Projects").First(&collection, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compactCacheControl lets clients reuse the list for a minute and keep
+// showing it while they revalidate, which If-None-Match makes cheap
+const compactCacheControl = "private, max-age=60, stale-while-revalidate=600"
+
+// CompactProject is a project as listed by the compact endpoint, with only
+// what a browsing screen needs
+type CompactProject struct {
+	ID   uint     `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+
+	// FileCounts counts the project's files by type, leaving out types it has none of
+	FileCounts map[models.FileType]int `json:"file_counts"`
+
+	// CoverURL serves the project's cover, of the kind CoverSource says;
+	// omitted when it has none
+	CoverURL    string      `json:"cover_url,omitempty"`
+	CoverSource CoverSource `json:"cover_source,omitempty"`
+}
+
+// GetCompactProjects lists projects with minimal fields for clients on slow
+// or metered connections. It pages with ?limit= and ?offset=, compresses the
+// response for clients accepting gzip and answers If-None-Match with 304
+// while the list is unchanged.
+func (h *ProjectsHandler) GetCompactProjects(c *gin.Context) {
+	limit, err := parseNonNegative(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offset, err := parseNonNegative(c, "offset")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := requestDB(c).Model(&models.Project{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	query := requestDB(c).Select("id", "name", "tags", "cover_filename").Order("id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	compact := make([]CompactProject, len(projects))
+	index := make(map[uint]int, len(projects))
+	byID := make(map[uint]models.Project, len(projects))
+	ids := make([]uint, len(projects))
+	for i, project := range projects {
+		compact[i] = CompactProject{ID: project.ID, Name: project.Name, Tags: project.Tags, FileCounts: make(map[models.FileType]int)}
+		if compact[i].Tags == nil {
+			compact[i].Tags = []string{}
+		}
+		index[project.ID] = i
+		byID[project.ID] = project
+		ids[i] = project.ID
+	}
+
+	if len(ids) > 0 {
+		var files []models.ProjectFile
+		if err := requestDB(c).Select("id", "project_id", "filename", "filepath", "file_type", "size", "hash").Where("project_id IN ?", ids).Find(&files).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project files"})
+			return
+		}
+		// Sorted like the project summary, so both pick the same cover
+		sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+		projectFiles := make(map[uint][]models.ProjectFile)
+		for _, file := range files {
+			compact[index[file.ProjectID]].FileCounts[file.FileType]++
+			projectFiles[file.ProjectID] = append(projectFiles[file.ProjectID], file)
+		}
+		for projectID, files := range projectFiles {
+			if cover := pickCover(byID[projectID], files); cover != nil {
+				compact[index[projectID]].CoverURL = cover.URL
+				compact[index[projectID]].CoverSource = cover.Source
+			}
+		}
+	}
+
+	body, err := json.Marshal(gin.H{
+		"projects": compact,
+		"count":    len(compact),
+		"total":    total,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode projects"})
+		return
+	}
+
+	// Weak, as the gzip and plain responses carry the same tag
+	etag := fmt.Sprintf(`W/"%x"`, sha256.Sum256(body))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", compactCacheControl)
+	c.Header("Vary", "Accept-Encoding")
+	if matchesETag(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write(body)
+		writer.Close()
+		c.Header("Content-Encoding", "gzip")
+		body = compressed.Bytes()
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// parseNonNegative reads an optional non-negative integer query parameter
+func parseNonNegative(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// matchesETag reports whether an If-None-Match header lists etag, comparing weakly
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) == "gzip" {
+			return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+		}
+	}
+	return false
+}
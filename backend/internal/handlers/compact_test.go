@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type compactResponse struct {
+	Projects []CompactProject `json:"projects"`
+	Count    int              `json:"count"`
+	Total    int64            `json:"total"`
+}
+
+// TestGetCompactProjects tests the lightweight project list and its caching headers
+func TestGetCompactProjects(t *testing.T) {
+	db := setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(t.TempDir())
+	router.GET("/api/projects/compact", handler.GetCompactProjects)
+
+	gears := models.Project{Name: "Gears", Path: "/library/gears", Description: "A long description", Tags: []string{"mechanical"}}
+	clips := models.Project{Name: "Clips", Path: "/library/clips"}
+	db.Create(&gears)
+	db.Create(&clips)
+	for _, name := range []string{"gear.stl", "gear_large.stl", "gears.gcode", "photo.png", "cover.jpg"} {
+		db.Create(&models.ProjectFile{
+			ProjectID: gears.ID,
+			Filename:  name,
+			Filepath:  "/library/gears/" + name,
+			FileType:  models.GetFileTypeFromExtension(name),
+		})
+	}
+
+	get := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/compact"+query, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("MinimalFields", func(t *testing.T) {
+		w := get("", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var raw struct {
+			Projects []map[string]interface{} `json:"projects"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &raw)
+		if len(raw.Projects) != 2 {
+			t.Fatalf("Expected 2 projects, got %d", len(raw.Projects))
+		}
+		for _, field := range []string{"description", "path", "files", "created_at"} {
+			if _, ok := raw.Projects[0][field]; ok {
+				t.Errorf("Expected no %q in compact projects", field)
+			}
+		}
+
+		var response compactResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		first, second := response.Projects[0], response.Projects[1]
+		if first.Name != "Gears" || len(first.Tags) != 1 || first.FileCounts[models.FileTypeSTL] != 2 || first.FileCounts[models.FileTypeGCode] != 1 {
+			t.Errorf("Unexpected compact project: %+v", first)
+		}
+		var cover models.ProjectFile
+		db.Where("filename = ?", "cover.jpg").First(&cover)
+		if want := fmt.Sprintf("/api/projects/%d/files/%d/download", gears.ID, cover.ID); first.CoverURL != want {
+			t.Errorf("Expected cover URL %s, got %s", want, first.CoverURL)
+		}
+		if second.CoverURL != "" || second.Tags == nil || len(second.FileCounts) != 0 {
+			t.Errorf("Expected project without files to have no cover and empty tags and counts, got %+v", second)
+		}
+		if w.Header().Get("Cache-Control") != compactCacheControl || w.Header().Get("ETag") == "" {
+			t.Errorf("Expected caching headers, got %v", w.Header())
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		w := get("?limit=1&offset=1", nil)
+		var response compactResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Count != 1 || response.Total != 2 || response.Projects[0].Name != "Clips" {
+			t.Errorf("Unexpected page: %+v", response)
+		}
+
+		if w := get("?limit=-1", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for negative limit, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("NotModified", func(t *testing.T) {
+		etag := get("", nil).Header().Get("ETag")
+
+		w := get("", map[string]string{"If-None-Match": etag})
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("Expected empty %d, got %d with %d bytes", http.StatusNotModified, w.Code, w.Body.Len())
+		}
+
+		db.Model(&clips).Update("name", "Cable Clips")
+		if w := get("", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d after a change, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Gzip", func(t *testing.T) {
+		w := get("", map[string]string{"Accept-Encoding": "br, gzip"})
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("Expected gzip encoding, got %q", w.Header().Get("Content-Encoding"))
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Failed to open gzip body: %v", err)
+		}
+		body, _ := io.ReadAll(reader)
+		var response compactResponse
+		if err := json.Unmarshal(body, &response); err != nil || response.Count != 2 {
+			t.Errorf("Unexpected decompressed response: %s", body)
+		}
+
+		if w := get("", map[string]string{"Accept-Encoding": "gzip;q=0"}); w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("Expected no encoding when gzip is refused")
+		}
+	})
+}
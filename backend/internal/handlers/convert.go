@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/mesh"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ConvertFileRequest is the body accepted by ConvertFile
+type ConvertFileRequest struct {
+	// Format is the STL encoding to convert to, "binary" or "ascii"; empty keeps the file's
+	Format mesh.Encoding `json:"format"`
+
+	// Compress stores the file zstd-compressed, as <name>.stl.zst, or
+	// decompresses it when false; unset keeps the file as it is stored
+	Compress *bool `json:"compress"`
+}
+
+// ConvertFile converts an STL file between the binary and ASCII encodings
+// and compresses or decompresses it, in place. Binary files are a fraction of
+// the size of ASCII ones and compression is lossless; compressed files are
+// decompressed transparently when downloaded and read. The file keeps its ID,
+// and print profiles and assemblies follow it to its new name.
+func (h *ProjectsHandler) ConvertFile(c *gin.Context) {
+	db := requestDB(c)
+
+	var req ConvertFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	switch req.Format {
+	case "", mesh.EncodingBinary, mesh.EncodingASCII:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be \"binary\" or \"ascii\""})
+		return
+	}
+
+	var file models.ProjectFile
+	if err := db.Preload("Project").First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeSTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL files can be converted"})
+		return
+	}
+	if rejectFlatProject(c, &file.Project) {
+		return
+	}
+
+	unlock, ok := h.lockProject(c, file.ProjectID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	encoding, err := mesh.STLFileEncoding(file.Filepath)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "File is not a readable STL", "details": err.Error()})
+		return
+	}
+	compressed := mesh.IsCompressed(file.Filepath)
+	target, compress := encoding, compressed
+	if req.Format != "" {
+		target = req.Format
+	}
+	if req.Compress != nil {
+		compress = *req.Compress
+	}
+	if target == encoding && compress == compressed {
+		c.JSON(http.StatusOK, gin.H{"message": "File is already stored that way", "file": file})
+		return
+	}
+
+	filename := file.Filename
+	if compressed {
+		filename = strings.TrimSuffix(filename, path.Ext(filename))
+	}
+	if compress {
+		filename += mesh.CompressedExt
+	}
+	dest := filepath.Join(filepath.Dir(file.Filepath), path.Base(filename))
+	if filename != file.Filename {
+		if _, err := os.Lstat(dest); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A file named " + filename + " already exists"})
+			return
+		}
+	}
+
+	if err := convertSTL(file, dest, target != encoding, target, compress); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert file", "details": err.Error()})
+		return
+	}
+
+	previous := file
+	size, hash, err := fsutil.HashFile(dest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert file", "details": err.Error()})
+		return
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&file).Updates(map[string]interface{}{
+			"filename": filename, "filepath": dest, "size": size, "hash": hash,
+		}).Error; err != nil {
+			return err
+		}
+		if filename == previous.Filename {
+			return nil
+		}
+		renames := []FileRename{{FileID: file.ID, From: previous.Filename, To: filename}}
+		if err := tx.Model(&models.FileProfile{}).Where("project_id = ? AND filename = ?", file.ProjectID, previous.Filename).
+			Update("filename", filename).Error; err != nil {
+			return err
+		}
+		return renameAssemblyParts(tx, file.ProjectID, renames)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update file", "details": err.Error()})
+		return
+	}
+	// The old name is only removed once the record points at the new one
+	if dest != previous.Filepath {
+		if err := os.Remove(previous.Filepath); err != nil {
+			fmt.Printf("Warning: Failed to remove converted file %s: %v\n", previous.Filepath, err)
+		}
+	}
+
+	db.First(&file, file.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "File converted",
+		"file":          file,
+		"previous_size": previous.Size,
+		"format":        target,
+		"compressed":    compress,
+	})
+}
+
+// convertSTL writes a file's model to dest, re-encoded as target when
+// reencode is set and compressed when compress is. It is written under a
+// hidden name the scanner skips and moved into place once complete.
+func convertSTL(file models.ProjectFile, dest string, reencode bool, target mesh.Encoding, compress bool) error {
+	dir := filepath.Dir(dest)
+	temp, err := os.CreateTemp(dir, ".convert-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	temp.Close()
+
+	content, size, err := mesh.Open(file.Filepath)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	var source io.Reader = content
+
+	// Compression records the content size up front, so a re-encoded model is written out first
+	if reencode {
+		model, err := mesh.ReadSTL(content, size)
+		if err != nil {
+			return err
+		}
+		encoded, err := os.CreateTemp(dir, ".convert-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(encoded.Name())
+		defer encoded.Close()
+
+		if target == mesh.EncodingASCII {
+			err = mesh.WriteASCIISTL(encoded, model, modelStem(file.Filename))
+		} else {
+			err = mesh.WriteBinarySTL(encoded, model)
+		}
+		if err != nil {
+			return err
+		}
+		if size, err = encoded.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+		if _, err := encoded.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		source = encoded
+	}
+
+	out, err := os.Create(temp.Name())
+	if err != nil {
+		return err
+	}
+	if compress {
+		err = mesh.Compress(out, source, size)
+	} else {
+		_, err = io.Copy(out, source)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := fileperm.File(temp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), dest)
+}
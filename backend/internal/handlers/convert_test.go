@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestConvertFile tests converting an STL between encodings and compressing it
+func TestConvertFile(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.POST("/api/files/:id/convert", handler.ConvertFile)
+	router.GET("/api/projects/:id/files/:fileId/download", handler.DownloadProjectFile)
+
+	model := &mesh.Mesh{Triangles: []mesh.Triangle{
+		{{0, 0, 0}, {10, 0, 0}, {0, 10, 0}},
+		{{0, 0, 0}, {0, 10, 0}, {0, 0, 10.5}},
+	}}
+	var ascii bytes.Buffer
+	mesh.WriteASCIISTL(&ascii, model, "wedge")
+
+	project := models.Project{Name: "Wedge", Path: tmpDir}
+	db.Create(&project)
+	path := filepath.Join(tmpDir, "wedge.stl")
+	os.WriteFile(path, ascii.Bytes(), 0644)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "wedge.stl", Filepath: path, FileType: models.FileTypeSTL, Size: int64(ascii.Len())}
+	db.Create(&file)
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "wedge.stl", Notes: "no supports"})
+	readme := models.ProjectFile{ProjectID: project.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME}
+	db.Create(&readme)
+
+	convert := func(id uint, body string) (*httptest.ResponseRecorder, models.ProjectFile) {
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/files/%d/convert", id), body)
+		var result struct {
+			File models.ProjectFile `json:"file"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return w, result.File
+	}
+
+	w, converted := convert(file.ID, `{"format": "binary", "compress": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if converted.ID != file.ID || converted.Filename != "wedge.stl.zst" || converted.Hash == "" || converted.Size >= file.Size {
+		t.Errorf("Expected a smaller compressed file under the same ID, got %+v", converted)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the ASCII file to be removed, got %v", err)
+	}
+	var profile models.FileProfile
+	if err := db.Where("project_id = ? AND filename = ?", project.ID, "wedge.stl.zst").First(&profile).Error; err != nil {
+		t.Errorf("Expected the print profile to follow the file: %v", err)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 1 {
+		t.Errorf("Expected only the converted file left, got %d entries", len(entries))
+	}
+
+	// Downloads are decompressed unless the stored bytes are asked for
+	download := fmt.Sprintf("/api/projects/%d/files/%d/download", project.ID, file.ID)
+	w = sendJSON(router, "GET", download, "")
+	if w.Code != http.StatusOK || w.Body.Len() != 84+2*50 || w.Header().Get("X-Checksum-SHA256") != "" {
+		t.Errorf("Expected the decompressed binary STL without checksums, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), `filename="wedge.stl"`) {
+		t.Errorf("Expected the download named without the suffix, got %s", w.Header().Get("Content-Disposition"))
+	}
+	w = sendJSON(router, "GET", download+"?raw=true", "")
+	if int64(w.Body.Len()) != converted.Size || w.Header().Get("X-Checksum-SHA256") != converted.Hash {
+		t.Errorf("Expected the stored bytes with their checksum, got %d bytes", w.Body.Len())
+	}
+
+	w, converted = convert(file.ID, `{"format": "ascii", "compress": false}`)
+	if w.Code != http.StatusOK || converted.Filename != "wedge.stl" {
+		t.Fatalf("Expected the file back as wedge.stl, got %d: %s", w.Code, w.Body.String())
+	}
+	roundTrip, err := mesh.ReadSTLFile(converted.Filepath)
+	if err != nil || len(roundTrip.Triangles) != 2 || roundTrip.Triangles[1] != model.Triangles[1] {
+		t.Errorf("Expected the vertices to survive the round trip, got %v %v", roundTrip, err)
+	}
+
+	if w, _ := convert(file.ID, `{"format": "ascii"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "already") {
+		t.Errorf("Expected nothing to convert, got %d: %s", w.Code, w.Body.String())
+	}
+	for name, tc := range map[string]struct {
+		id     uint
+		body   string
+		status int
+	}{
+		"Unknown format": {file.ID, `{"format": "obj"}`, http.StatusBadRequest},
+		"Not an STL":     {readme.ID, `{"compress": true}`, http.StatusBadRequest},
+		"Missing file":   {999, `{"compress": true}`, http.StatusNotFound},
+	} {
+		if w, _ := convert(tc.id, tc.body); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/thumbnail"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoverSource says what a project's cover is made from
+type CoverSource string
+
+const (
+	// CoverSourceImage is an image file of the project
+	CoverSourceImage CoverSource = "image"
+	// CoverSourceEmbedded is the thumbnail a slicer embedded in a G-code or 3MF file
+	CoverSourceEmbedded CoverSource = "embedded"
+	// CoverSourceModel is an STL model, rendered by the server or by clients
+	// from its preview mesh
+	CoverSourceModel CoverSource = "model"
+)
+
+// ProjectCover is the visual a project is shown with
+type ProjectCover struct {
+	FileID   uint        `json:"file_id"`
+	Filename string      `json:"filename"`
+	Source   CoverSource `json:"source"`
+
+	// Chosen is set when the cover was chosen rather than picked automatically
+	Chosen bool `json:"chosen"`
+
+	// URL serves the image, the embedded thumbnail at its largest size or,
+	// for models, the preview mesh
+	URL string `json:"url"`
+
+	// ThumbnailURL is a thumbnail of the cover; models have a PNG render
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// ProjectImage is an image file of a project, as shown in its gallery
+type ProjectImage struct {
+	FileID   uint   `json:"file_id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+
+	// ThumbnailURL is a cacheable thumbnail of the image
+	ThumbnailURL string `json:"thumbnail_url"`
+
+	// Cover is set on the image the project is shown with
+	Cover bool `json:"cover"`
+}
+
+// SetProjectCoverRequest chooses the file a project is shown with
+type SetProjectCoverRequest struct {
+	FileID uint `json:"file_id" binding:"required"`
+}
+
+// pickCover picks the cover of a project from its files, sorted by filename:
+// the chosen file while it can still be a cover, else the image
+// pickCoverImage prefers, else the first G-code or 3MF file embedding a
+// thumbnail, else the largest STL model. Projects with none of these have no
+// cover.
+func pickCover(project models.Project, files []models.ProjectFile) *ProjectCover {
+	if project.CoverFilename != "" {
+		for _, file := range files {
+			if file.Filename != project.CoverFilename {
+				continue
+			}
+			if cover := coverOf(project.ID, file); cover != nil {
+				cover.Chosen = true
+				return cover
+			}
+		}
+	}
+
+	var images []models.ProjectFile
+	var largestModel *models.ProjectFile
+	for i, file := range files {
+		switch {
+		case models.IsImageFile(file.Filename):
+			images = append(images, file)
+		case file.FileType == models.FileTypeSTL:
+			if largestModel == nil || file.Size > largestModel.Size {
+				largestModel = &files[i]
+			}
+		}
+	}
+	if image := pickCoverImage(images); image != nil {
+		return coverOf(project.ID, *image)
+	}
+
+	// Embedded thumbnails are looked for only when the project has no images,
+	// as it takes reading the files
+	for _, file := range files {
+		if !gcode.EmbedsThumbnails(file.Filename) {
+			continue
+		}
+		if cover := coverOf(project.ID, file); cover != nil && cover.Source == CoverSourceEmbedded {
+			return cover
+		}
+	}
+
+	if largestModel != nil {
+		return coverOf(project.ID, *largestModel)
+	}
+	return nil
+}
+
+// coverOf describes a file as a project cover, or returns nil when it can't
+// be one
+func coverOf(projectID uint, file models.ProjectFile) *ProjectCover {
+	cover := &ProjectCover{FileID: file.ID, Filename: file.Filename}
+	switch {
+	case models.IsImageFile(file.Filename):
+		cover.Source = CoverSourceImage
+		cover.URL = fmt.Sprintf("/api/projects/%d/files/%d/download", projectID, file.ID)
+		cover.ThumbnailURL = thumbnail.URL(file.Hash, thumbnail.DefaultSize)
+	case gcode.EmbedsThumbnails(file.Filename) && thumbnail.ValidHash(file.Hash) && gcode.HasThumbnail(file.Filepath):
+		cover.Source = CoverSourceEmbedded
+		cover.URL = thumbnail.URL(file.Hash, thumbnail.Sizes[len(thumbnail.Sizes)-1])
+		cover.ThumbnailURL = thumbnail.URL(file.Hash, thumbnail.DefaultSize)
+	case file.FileType == models.FileTypeSTL:
+		cover.Source = CoverSourceModel
+		cover.URL = fmt.Sprintf("/api/files/%d/preview", file.ID)
+		cover.ThumbnailURL = fmt.Sprintf("/api/files/%d/render.png?size=%d", file.ID, thumbnail.DefaultSize)
+	default:
+		return nil
+	}
+	return cover
+}
+
+// SetProjectCover chooses the file a project is shown with, overriding the
+// automatic pick. Images, G-code and 3MF files embedding a thumbnail, and STL
+// models can be covers.
+func (h *ProjectsHandler) SetProjectCover(c *gin.Context) {
+	var req SetProjectCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if err := requestDB(c).First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	var file models.ProjectFile
+	if err := requestDB(c).Where("id = ? AND project_id = ?", req.FileID, project.ID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	cover := coverOf(project.ID, file)
+	if cover == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File cannot be a cover", "details": "choose an image, an STL model, or a G-code or 3MF file with an embedded thumbnail"})
+		return
+	}
+
+	if err := requestDB(c).Model(&project).Update("cover_filename", file.Filename).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cover", "details": err.Error()})
+		return
+	}
+	cover.Chosen = true
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cover updated", "cover": cover})
+}
+
+// ClearProjectCover goes back to picking a project's cover automatically
+func (h *ProjectsHandler) ClearProjectCover(c *gin.Context) {
+	var project models.Project
+	if err := requestDB(c).Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	if err := requestDB(c).Model(&project).Update("cover_filename", "").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cover", "details": err.Error()})
+		return
+	}
+
+	sort.Slice(project.Files, func(i, j int) bool { return project.Files[i].Filename < project.Files[j].Filename })
+	c.JSON(http.StatusOK, gin.H{"message": "Cover reset", "cover": pickCover(project, project.Files)})
+}
+
+// GetProjectImages lists a project's images by filename, with the project's
+// cover, so clients can show a gallery and let users choose the cover
+func (h *ProjectsHandler) GetProjectImages(c *gin.Context) {
+	var project models.Project
+	if err := requestDB(c).Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	sort.Slice(project.Files, func(i, j int) bool { return project.Files[i].Filename < project.Files[j].Filename })
+	cover := pickCover(project, project.Files)
+
+	images := []ProjectImage{}
+	for _, file := range project.Files {
+		if file.FileType != models.FileTypeImage {
+			continue
+		}
+		image := ProjectImage{
+			FileID:       file.ID,
+			Filename:     file.Filename,
+			Size:         file.Size,
+			URL:          fmt.Sprintf("/api/projects/%d/files/%d/download", project.ID, file.ID),
+			ThumbnailURL: thumbnail.URL(file.Hash, thumbnail.DefaultSize),
+			Cover:        cover != nil && cover.FileID == file.ID,
+		}
+		if image.ThumbnailURL == "" {
+			image.ThumbnailURL = fmt.Sprintf("/api/projects/%d/files/%d/thumbnail", project.ID, file.ID)
+		}
+		images = append(images, image)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"images": images, "count": len(images), "cover": cover})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestProjectCover tests the automatic cover fallbacks and choosing a cover
+func TestProjectCover(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/summary", handler.GetProjectSummary)
+	router.PUT("/api/projects/:id/cover", handler.SetProjectCover)
+	router.DELETE("/api/projects/:id/cover", handler.ClearProjectCover)
+	router.GET("/api/projects/:id/images", handler.GetProjectImages)
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	addFile := func(name string, content []byte) models.ProjectFile {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, content, 0644)
+		file := models.ProjectFile{
+			ProjectID: project.ID,
+			Filename:  name,
+			Filepath:  path,
+			Size:      int64(len(content)),
+			FileType:  models.GetFileTypeFromExtension(name),
+			Hash:      fmt.Sprintf("%064x", len(name)),
+		}
+		db.Create(&file)
+		return file
+	}
+	cover := func() *ProjectCover {
+		t.Helper()
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/summary", project.ID), "")
+		var summary ProjectSummary
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to parse summary: %v", err)
+		}
+		return summary.Cover
+	}
+
+	readme := addFile("README.md", []byte("# Benchy"))
+	if got := cover(); got != nil {
+		t.Fatalf("Expected no cover without images or models, got %+v", got)
+	}
+
+	hull := addFile("hull.stl", []byte("solid hull with more bytes"))
+	addFile("chimney.stl", []byte("solid chimney"))
+	if got := cover(); got == nil || got.Source != CoverSourceModel || got.FileID != hull.ID || got.URL != fmt.Sprintf("/api/files/%d/preview", hull.ID) {
+		t.Fatalf("Expected the largest model as cover, got %+v", got)
+	}
+
+	var embedded bytes.Buffer
+	png.Encode(&embedded, image.NewGray(image.Rect(0, 0, 16, 16)))
+	gcode := addFile("benchy.gcode", []byte("; thumbnail begin 16x16 0\n; "+base64.StdEncoding.EncodeToString(embedded.Bytes())+"\n; thumbnail end\nG28\n"))
+	addFile("plain.gcode", []byte("G28\n"))
+	if got := cover(); got == nil || got.Source != CoverSourceEmbedded || got.FileID != gcode.ID || got.ThumbnailURL == "" {
+		t.Fatalf("Expected the embedded thumbnail as cover, got %+v", got)
+	}
+
+	photo := addFile("photo.png", []byte("PNG"))
+	if got := cover(); got == nil || got.Source != CoverSourceImage || got.FileID != photo.ID || got.Chosen {
+		t.Fatalf("Expected the image as cover, got %+v", got)
+	}
+
+	t.Run("Choose", func(t *testing.T) {
+		w := sendJSON(router, "PUT", fmt.Sprintf("/api/projects/%d/cover", project.ID), fmt.Sprintf(`{"file_id": %d}`, hull.ID))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := cover(); got == nil || got.FileID != hull.ID || !got.Chosen {
+			t.Errorf("Expected the chosen model as cover, got %+v", got)
+		}
+
+		// A chosen file that is gone falls back to the automatic pick
+		db.Delete(&hull)
+		if got := cover(); got == nil || got.FileID != photo.ID || got.Chosen {
+			t.Errorf("Expected the image once the chosen model is gone, got %+v", got)
+		}
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		backdrop := addFile("backdrop.webp", []byte("RIFF"))
+		sendJSON(router, "PUT", fmt.Sprintf("/api/projects/%d/cover", project.ID), fmt.Sprintf(`{"file_id": %d}`, photo.ID))
+
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/images", project.ID), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Images []ProjectImage `json:"images"`
+			Count  int            `json:"count"`
+			Cover  *ProjectCover  `json:"cover"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Count != 2 || response.Images[0].FileID != backdrop.ID || response.Images[1].FileID != photo.ID {
+			t.Fatalf("Expected both images by filename, got %+v", response.Images)
+		}
+		if response.Images[0].Cover || !response.Images[1].Cover || response.Cover == nil || !response.Cover.Chosen {
+			t.Errorf("Expected the chosen image flagged as cover, got %+v", response.Images)
+		}
+		if response.Images[1].ThumbnailURL == "" || response.Images[1].URL != fmt.Sprintf("/api/projects/%d/files/%d/download", project.ID, photo.ID) {
+			t.Errorf("Expected the image and thumbnail URLs, got %+v", response.Images[1])
+		}
+
+		if w := sendJSON(router, "GET", "/api/projects/999/images", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a missing project, got %d", http.StatusNotFound, w.Code)
+		}
+		db.Delete(&backdrop)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			path, body string
+			status     int
+		}{
+			"Not a cover":     {fmt.Sprintf("/api/projects/%d/cover", project.ID), fmt.Sprintf(`{"file_id": %d}`, readme.ID), http.StatusBadRequest},
+			"Missing file":    {fmt.Sprintf("/api/projects/%d/cover", project.ID), `{"file_id": 999}`, http.StatusNotFound},
+			"Missing project": {"/api/projects/999/cover", fmt.Sprintf(`{"file_id": %d}`, photo.ID), http.StatusNotFound},
+			"No file":         {fmt.Sprintf("/api/projects/%d/cover", project.ID), `{}`, http.StatusBadRequest},
+		} {
+			if w := sendJSON(router, "PUT", tc.path, tc.body); w.Code != tc.status {
+				t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+			}
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		sendJSON(router, "PUT", fmt.Sprintf("/api/projects/%d/cover", project.ID), fmt.Sprintf(`{"file_id": %d}`, gcode.ID))
+		w := sendJSON(router, "DELETE", fmt.Sprintf("/api/projects/%d/cover", project.ID), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Cover ProjectCover `json:"cover"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Cover.FileID != photo.ID || response.Cover.Chosen {
+			t.Errorf("Expected the automatic cover back, got %+v", response.Cover)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestDB returns the database bound to the request context, so queries
+// carry the route information used by slow query logging
+func requestDB(c *gin.Context) *gorm.DB {
+	return database.GetDB().WithContext(c.Request.Context())
+}
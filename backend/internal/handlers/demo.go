@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"3dshelf/pkg/demo"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetDemo lets the admin API seed and clear the sample library
+func (h *AdminHandler) SetDemo(seeder *demo.Seeder) {
+	h.demo = seeder
+}
+
+// GetDemo reports whether the sample library is seeded and what it holds
+func (h *AdminHandler) GetDemo(c *gin.Context) {
+	if h.demo == nil {
+		c.JSON(http.StatusOK, gin.H{"seeded": false})
+		return
+	}
+
+	manifest, err := h.demo.Load()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load demo data"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"seeded": manifest != nil, "demo": manifest})
+}
+
+// SeedDemo writes the sample projects into the library and records spools
+// and print history for them
+func (h *AdminHandler) SeedDemo(c *gin.Context) {
+	if h.demo == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Demo data is not supported"})
+		return
+	}
+
+	manifest, err := h.demo.Seed()
+	switch {
+	case errors.Is(err, demo.ErrSeeded), errors.Is(err, demo.ErrPathExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to seed demo data", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Demo data seeded",
+		"demo":    manifest,
+	})
+}
+
+// ClearDemo removes the sample projects, their files and everything recorded
+// against them, leaving the rest of the library alone
+func (h *AdminHandler) ClearDemo(c *gin.Context) {
+	if h.demo == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Demo data is not supported"})
+		return
+	}
+
+	manifest, err := h.demo.Clear()
+	switch {
+	case errors.Is(err, demo.ErrNotSeeded):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Demo data is not seeded"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove demo data", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Demo data removed",
+		"demo":    manifest,
+	})
+}
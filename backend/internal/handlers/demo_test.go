@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/demo"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestDemoData tests seeding and clearing the sample library through the admin API
+func TestDemoData(t *testing.T) {
+	// The scanner locks the library while importing, so the database is shared through a file
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	database.DB = db
+	scanPath := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminHandler := NewAdminHandler(nil)
+	adminHandler.SetDemo(demo.New(db, scanner.New(db, scanPath), scanPath))
+	router.GET("/api/admin/seed", adminHandler.GetDemo)
+	router.POST("/api/admin/seed", adminHandler.SeedDemo)
+	router.DELETE("/api/admin/seed", adminHandler.ClearDemo)
+
+	if w := sendJSON(router, "DELETE", "/api/admin/seed", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before seeding, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w := sendJSON(router, "POST", "/api/admin/seed", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&models.Project{}).Count(&count)
+	if count == 0 {
+		t.Error("Expected sample projects to be created")
+	}
+
+	var status struct {
+		Seeded bool          `json:"seeded"`
+		Demo   demo.Manifest `json:"demo"`
+	}
+	json.Unmarshal(sendJSON(router, "GET", "/api/admin/seed", "").Body.Bytes(), &status)
+	if !status.Seeded || int64(len(status.Demo.ProjectIDs)) != count {
+		t.Errorf("Expected the status to list the %d sample projects, got %+v", count, status)
+	}
+
+	if w := sendJSON(router, "POST", "/api/admin/seed", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d when already seeded, got %d", http.StatusConflict, w.Code)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/admin/seed", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	db.Model(&models.Project{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the sample projects to be removed, got %d", count)
+	}
+}
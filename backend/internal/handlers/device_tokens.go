@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deviceTokenPrefixLength is how much of a token listings show
+const deviceTokenPrefixLength = 8
+
+// DeviceTokenRequest issues a token for a printer
+type DeviceTokenRequest struct {
+	Name       string `json:"name"`
+	ProjectIDs []uint `json:"project_ids"`
+}
+
+// DeviceTokenResponse is an issued token; Token is only ever returned here
+type DeviceTokenResponse struct {
+	models.DeviceToken
+	Token string `json:"token"`
+}
+
+// DeviceFile is a G-code file a device may download, with where to get it
+type DeviceFile struct {
+	models.ProjectFile
+	ProjectName string `json:"project_name"`
+	DownloadURL string `json:"download_url"`
+}
+
+// DeviceTokensHandler issues and revokes printer device tokens and serves
+// the files they give access to
+type DeviceTokensHandler struct{}
+
+// NewDeviceTokensHandler creates a new DeviceTokensHandler
+func NewDeviceTokensHandler() *DeviceTokensHandler {
+	return &DeviceTokensHandler{}
+}
+
+// GetDeviceTokens lists the issued device tokens, newest first, without the tokens themselves
+func (h *DeviceTokensHandler) GetDeviceTokens(c *gin.Context) {
+	var tokens []models.DeviceToken
+	if err := requestDB(c).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch device tokens"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "count": len(tokens)})
+}
+
+// CreateDeviceToken issues a token that can only fetch the G-code of the given projects
+func (h *DeviceTokensHandler) CreateDeviceToken(c *gin.Context) {
+	var req DeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device name is required"})
+		return
+	}
+	if len(req.ProjectIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one project is required"})
+		return
+	}
+
+	projectIDs := slices.Compact(slices.Sorted(slices.Values(req.ProjectIDs)))
+	var found int64
+	if err := requestDB(c).Model(&models.Project{}).Where("id IN ?", projectIDs).Count(&found).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check projects"})
+		return
+	}
+	if int(found) != len(projectIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		return
+	}
+
+	token, err := newDeviceToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device token"})
+		return
+	}
+	deviceToken := models.DeviceToken{
+		Name:       req.Name,
+		TokenHash:  models.HashDeviceToken(token),
+		Prefix:     token[:deviceTokenPrefixLength],
+		ProjectIDs: projectIDs,
+	}
+	if err := requestDB(c).Create(&deviceToken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, DeviceTokenResponse{DeviceToken: deviceToken, Token: token})
+}
+
+// RevokeDeviceToken stops a token working; it stays listed as revoked
+func (h *DeviceTokensHandler) RevokeDeviceToken(c *gin.Context) {
+	var deviceToken models.DeviceToken
+	if err := requestDB(c).First(&deviceToken, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device token not found"})
+		return
+	}
+
+	if deviceToken.RevokedAt == nil {
+		now := time.Now()
+		deviceToken.RevokedAt = &now
+		if err := requestDB(c).Model(&deviceToken).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke device token"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, deviceToken)
+}
+
+// GetDeviceFiles lists the G-code files the request's device token may download
+func (h *DeviceTokensHandler) GetDeviceFiles(c *gin.Context) {
+	deviceToken := c.MustGet(middleware.DeviceTokenKey).(*models.DeviceToken)
+
+	var files []models.ProjectFile
+	if err := requestDB(c).Preload("Project").Where("project_id IN ? AND file_type = ?", deviceToken.ProjectIDs, models.FileTypeGCode).
+		Order("project_id ASC, filename ASC").Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch files"})
+		return
+	}
+
+	deviceFiles := make([]DeviceFile, 0, len(files))
+	for _, file := range files {
+		deviceFiles = append(deviceFiles, DeviceFile{
+			ProjectFile: file,
+			ProjectName: file.Project.Name,
+			DownloadURL: fmt.Sprintf("/api/device/files/%d/download", file.ID),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"files": deviceFiles, "count": len(deviceFiles)})
+}
+
+// DownloadDeviceFile serves a G-code file of a project the device token was issued for
+func (h *DeviceTokensHandler) DownloadDeviceFile(c *gin.Context) {
+	deviceToken := c.MustGet(middleware.DeviceTokenKey).(*models.DeviceToken)
+
+	var file models.ProjectFile
+	err := requestDB(c).First(&file, c.Param("id")).Error
+	// Files outside the token's scope are reported missing, not forbidden, so
+	// a token can't be used to find out what else is in the library
+	if err != nil || file.FileType != models.FileTypeGCode || !deviceToken.Allows(file.ProjectID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if _, err := os.Stat(file.Filepath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+
+	serveFile(c, &file)
+}
+
+// newDeviceToken returns a random 256-bit token
+func newDeviceToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDeviceTokens tests issuing a printer token scoped to projects, fetching
+// G-code with it and revoking it
+func TestDeviceTokens(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewDeviceTokensHandler()
+	router.GET("/api/printers/tokens", handler.GetDeviceTokens)
+	router.POST("/api/printers/tokens", handler.CreateDeviceToken)
+	router.DELETE("/api/printers/tokens/:id", handler.RevokeDeviceToken)
+	device := router.Group("/api/device", middleware.RequireDeviceToken(db))
+	device.GET("/files", handler.GetDeviceFiles)
+	device.GET("/files/:id/download", handler.DownloadDeviceFile)
+
+	allowed := models.Project{Name: "Gears", Path: filepath.Join(tmpDir, "Gears")}
+	other := models.Project{Name: "Vase", Path: filepath.Join(tmpDir, "Vase")}
+	db.Create(&allowed)
+	db.Create(&other)
+	newFile := func(project models.Project, name string) models.ProjectFile {
+		path := filepath.Join(tmpDir, project.Name+"_"+name)
+		os.WriteFile(path, []byte("G28\n"), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)}
+		db.Create(&file)
+		return file
+	}
+	gcode := newFile(allowed, "gear.gcode")
+	model := newFile(allowed, "gear.stl")
+	otherGCode := newFile(other, "vase.gcode")
+
+	for _, body := range []string{`{"project_ids": [1]}`, `{"name": "MK4"}`, fmt.Sprintf(`{"name": "MK4", "project_ids": [%d, 99]}`, allowed.ID)} {
+		if w := sendJSON(router, "POST", "/api/printers/tokens", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	w := sendJSON(router, "POST", "/api/printers/tokens", fmt.Sprintf(`{"name": "MK4", "project_ids": [%d, %d]}`, allowed.ID, allowed.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var issued DeviceTokenResponse
+	json.Unmarshal(w.Body.Bytes(), &issued)
+	if len(issued.Token) != 64 || len(issued.ProjectIDs) != 1 || issued.Prefix != issued.Token[:8] {
+		t.Fatalf("Unexpected issued token: %+v", issued)
+	}
+
+	deviceRequest := func(path string, header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	bearer := "Bearer " + issued.Token
+
+	w = deviceRequest("/api/device/files", "Authorization", bearer)
+	var listing struct {
+		Files []DeviceFile `json:"files"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listing)
+	if w.Code != http.StatusOK || len(listing.Files) != 1 || listing.Files[0].ID != gcode.ID || listing.Files[0].ProjectName != "Gears" {
+		t.Fatalf("Expected only the project's G-code, got %d: %s", w.Code, w.Body.String())
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{"Scoped G-code", listing.Files[0].DownloadURL, "Authorization", bearer, http.StatusOK},
+		{"API key header", listing.Files[0].DownloadURL, middleware.DeviceTokenHeader, issued.Token, http.StatusOK},
+		{"Model file", fmt.Sprintf("/api/device/files/%d/download", model.ID), "Authorization", bearer, http.StatusNotFound},
+		{"Other project", fmt.Sprintf("/api/device/files/%d/download", otherGCode.ID), "Authorization", bearer, http.StatusNotFound},
+		{"No token", listing.Files[0].DownloadURL, "", "", http.StatusUnauthorized},
+		{"Wrong token", listing.Files[0].DownloadURL, "Authorization", "Bearer nope", http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		if w := deviceRequest(tc.path, tc.header, tc.value); w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expectedCode, w.Code)
+		}
+	}
+
+	if w := sendJSON(router, "DELETE", fmt.Sprintf("/api/printers/tokens/%d", issued.ID), ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d revoking, got %d", http.StatusOK, w.Code)
+	}
+	if w := deviceRequest("/api/device/files", "Authorization", bearer); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked token to be refused, got %d", w.Code)
+	}
+
+	w = sendJSON(router, "GET", "/api/printers/tokens", "")
+	var tokens struct {
+		Tokens []map[string]any `json:"tokens"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	if len(tokens.Tokens) != 1 || tokens.Tokens[0]["revoked_at"] == nil || tokens.Tokens[0]["last_used_at"] == nil || tokens.Tokens[0]["token"] != nil {
+		t.Errorf("Expected the revoked, used token listed without its secret, got %+v", tokens.Tokens)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/distribute"
+	"3dshelf/pkg/features"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DistributionsHandler handles publishing public projects to IPFS and as torrents
+type DistributionsHandler struct {
+	publisher *distribute.Publisher
+
+	// features gates IPFS behind the integrations flag; nil allows it
+	features *features.Flags
+}
+
+// NewDistributionsHandler creates a new DistributionsHandler
+func NewDistributionsHandler(publisher *distribute.Publisher) *DistributionsHandler {
+	return &DistributionsHandler{
+		publisher: publisher,
+	}
+}
+
+// SetFeatures sets the feature flags IPFS publishing is gated by
+func (h *DistributionsHandler) SetFeatures(flags *features.Flags) {
+	h.features = flags
+}
+
+// CreateDistributionRequest is the body accepted by CreateDistribution
+type CreateDistributionRequest struct {
+	Kind models.DistributionKind `json:"kind" binding:"required"`
+}
+
+// CreateDistribution starts a background job publishing a public project to
+// IPFS or packing it into a torrent; the content ID is recorded when it completes
+func (h *DistributionsHandler) CreateDistribution(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req CreateDistributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// An IPFS node is an integration like any other; torrents are created locally
+	if req.Kind == models.DistributionIPFS && h.features != nil && !h.features.Enabled(features.Integrations) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feature not enabled"})
+		return
+	}
+
+	job, err := h.publisher.Start(uint(id), req.Kind)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	case errors.Is(err, distribute.ErrUnknownKind):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be ipfs or torrent"})
+		return
+	case errors.Is(err, distribute.ErrIPFSNotConfigured):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IPFS is not configured"})
+		return
+	case errors.Is(err, distribute.ErrNotPublic):
+		c.JSON(http.StatusConflict, gin.H{"error": "Only public projects can be distributed"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start distribution", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Distribution started",
+		"job":     job,
+	})
+}
+
+// GetProjectDistributions lists a project's recorded distributions, most recent first
+func (h *DistributionsHandler) GetProjectDistributions(c *gin.Context) {
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	distributions := []models.Distribution{}
+	if err := db.Where("project_id = ?", project.ID).Order("created_at DESC, id DESC").Find(&distributions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch distributions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"distributions": distributions,
+		"count":         len(distributions),
+	})
+}
+
+// GetDistributionTorrent downloads the .torrent file of a torrent distribution
+func (h *DistributionsHandler) GetDistributionTorrent(c *gin.Context) {
+	var distribution models.Distribution
+	if err := requestDB(c).First(&distribution, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Distribution not found"})
+		return
+	}
+	if distribution.Kind != models.DistributionTorrent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only torrent distributions have a torrent file"})
+		return
+	}
+
+	var project models.Project
+	requestDB(c).Select("slug").First(&project, distribution.ProjectID)
+	name := project.Slug
+	if name == "" {
+		name = distribution.ContentID
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.torrent\"", name))
+	c.Data(http.StatusOK, "application/x-bittorrent", distribution.Torrent)
+}
+
+// DeleteDistribution forgets a distribution. Content already shared stays
+// available from the peers holding it.
+func (h *DistributionsHandler) DeleteDistribution(c *gin.Context) {
+	result := requestDB(c).Delete(&models.Distribution{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete distribution"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Distribution not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Distribution deleted successfully"})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/distribute"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDistributionsHandler tests starting, listing and downloading project distributions
+func TestDistributionsHandler(t *testing.T) {
+	db := setupTestDB(t)
+	publisher := distribute.New(db, jobs.New(db), nil, nil)
+	handler := NewDistributionsHandler(publisher)
+	flags := features.New()
+	flags.Set(map[features.Flag]bool{features.Integrations: false})
+	handler.SetFeatures(flags)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/projects/:id/distributions", handler.GetProjectDistributions)
+	router.POST("/api/projects/:id/distributions", handler.CreateDistribution)
+	router.GET("/api/distributions/:id/torrent", handler.GetDistributionTorrent)
+	router.DELETE("/api/distributions/:id", handler.DeleteDistribution)
+
+	dir := t.TempDir()
+	project := models.Project{Name: "Benchy", Path: dir, Public: true}
+	private := models.Project{Name: "Prototype", Path: t.TempDir()}
+	db.Create(&project)
+	db.Create(&private)
+	os.WriteFile(filepath.Join(dir, "benchy.stl"), []byte("solid benchy"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "benchy.stl", Filepath: filepath.Join(dir, "benchy.stl"), Size: 12, FileType: models.FileTypeSTL})
+
+	w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/distributions", project.ID), `{"kind": "torrent"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var started struct {
+		Job models.Job `json:"job"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &started)
+	if started.Job.Type != distribute.JobType {
+		t.Errorf("Expected a %s job, got %+v", distribute.JobType, started.Job)
+	}
+
+	cases := []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{fmt.Sprintf("/api/projects/%d/distributions", project.ID), `{"kind": "zip"}`, http.StatusBadRequest},
+		{fmt.Sprintf("/api/projects/%d/distributions", project.ID), `{}`, http.StatusBadRequest},
+		// IPFS is an integration, switched off here
+		{fmt.Sprintf("/api/projects/%d/distributions", project.ID), `{"kind": "ipfs"}`, http.StatusNotFound},
+		{fmt.Sprintf("/api/projects/%d/distributions", private.ID), `{"kind": "torrent"}`, http.StatusConflict},
+		{"/api/projects/999/distributions", `{"kind": "torrent"}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if w := sendJSON(router, "POST", tc.path, tc.body); w.Code != tc.status {
+			t.Errorf("Expected status %d for %s %s, got %d", tc.status, tc.path, tc.body, w.Code)
+		}
+	}
+	flags.Set(map[features.Flag]bool{features.Integrations: true})
+	if w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/distributions", project.ID), `{"kind": "ipfs"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without an IPFS node, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// The job's work, run in place
+	distribution, err := publisher.Publish(context.Background(), project.ID, models.DistributionTorrent)
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/distributions", project.ID), "")
+	var listing struct {
+		Distributions []models.Distribution `json:"distributions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listing)
+	if w.Code != http.StatusOK || len(listing.Distributions) != 1 || listing.Distributions[0].ContentID != distribution.ContentID {
+		t.Errorf("Expected the torrent distribution, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/distributions/%d/torrent", distribution.ID), "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-bittorrent" || w.Body.String() != string(distribution.Torrent) {
+		t.Errorf("Expected the torrent file, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Content-Disposition") != `attachment; filename="`+project.Slug+`.torrent"` {
+		t.Errorf("Unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+	}
+
+	if w := sendJSON(router, "DELETE", fmt.Sprintf("/api/distributions/%d", distribution.ID), ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "GET", fmt.Sprintf("/api/distributions/%d/torrent", distribution.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after deleting, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/label"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FilamentsHandler handles filament spool HTTP requests
+type FilamentsHandler struct{}
+
+// NewFilamentsHandler creates a new FilamentsHandler
+func NewFilamentsHandler() *FilamentsHandler {
+	return &FilamentsHandler{}
+}
+
+// GetFilaments returns all filament spools, optionally of one ?material=
+func (h *FilamentsHandler) GetFilaments(c *gin.Context) {
+	query := requestDB(c).Order("name ASC")
+	if material := c.Query("material"); material != "" {
+		query = query.Where("material = ? COLLATE NOCASE", material)
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	var filaments []models.Filament
+	if err := query.Find(&filaments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch filaments"})
+		return
+	}
+	for i := range filaments {
+		filaments[i].Display = filamentDisplay(prefs, filaments[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"filaments": filaments,
+		"count":     len(filaments),
+	})
+}
+
+// GetFilament returns a single filament spool
+func (h *FilamentsHandler) GetFilament(c *gin.Context) {
+	var filament models.Filament
+	if err := requestDB(c).First(&filament, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filament not found"})
+		return
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+	filament.Display = filamentDisplay(prefs, filament)
+
+	c.JSON(http.StatusOK, filament)
+}
+
+// CreateFilament adds a filament spool
+func (h *FilamentsHandler) CreateFilament(c *gin.Context) {
+	var filament models.Filament
+	if err := c.ShouldBindJSON(&filament); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	filament.ID = 0
+
+	if err := filament.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := requestDB(c).Create(&filament).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create filament"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Filament created successfully",
+		"filament": filament,
+	})
+}
+
+// UpdateFilament replaces a filament spool's details
+func (h *FilamentsHandler) UpdateFilament(c *gin.Context) {
+	db := requestDB(c)
+
+	var existing models.Filament
+	if err := db.First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filament not found"})
+		return
+	}
+
+	var filament models.Filament
+	if err := c.ShouldBindJSON(&filament); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	filament.ID = existing.ID
+	filament.CreatedAt = existing.CreatedAt
+
+	if err := filament.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(&filament).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update filament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Filament updated successfully",
+		"filament": filament,
+	})
+}
+
+// DeleteFilament removes a filament spool
+func (h *FilamentsHandler) DeleteFilament(c *gin.Context) {
+	result := requestDB(c).Delete(&models.Filament{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete filament"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filament not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Filament deleted successfully"})
+}
+
+// GetFilamentLabel renders a printable spool label with the material, print
+// temperatures and a QR code linking back to the spool
+func (h *FilamentsHandler) GetFilamentLabel(c *gin.Context) {
+	var filament models.Filament
+	if err := requestDB(c).First(&filament, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filament not found"})
+		return
+	}
+
+	content := label.Label{
+		Title: filament.Name,
+		QR:    fmt.Sprintf("%s/api/filaments/%d", requestBaseURL(c), filament.ID),
+	}
+
+	var material []string
+	for _, value := range []string{filament.Brand, filament.Material, filament.Color} {
+		if value != "" {
+			material = append(material, value)
+		}
+	}
+	material = append(material, fmt.Sprintf("%gmm", filament.DiameterMM))
+	content.Lines = append(content.Lines, strings.Join(material, " · "))
+
+	var temps []string
+	if filament.NozzleTempC > 0 {
+		temps = append(temps, fmt.Sprintf("Nozzle %d°C", filament.NozzleTempC))
+	}
+	if filament.BedTempC > 0 {
+		temps = append(temps, fmt.Sprintf("Bed %d°C", filament.BedTempC))
+	}
+	if len(temps) > 0 {
+		content.Lines = append(content.Lines, strings.Join(temps, " · "))
+	}
+	if filament.WeightGrams > 0 {
+		content.Lines = append(content.Lines, fmt.Sprintf("%g g spool", filament.WeightGrams))
+	}
+	content.Lines = append(content.Lines, fmt.Sprintf("#%d", filament.ID))
+
+	writeLabel(c, content, fmt.Sprintf("filament-%d-label", filament.ID))
+}
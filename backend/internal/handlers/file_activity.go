@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/pairing"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUnusedMonths = 6
+	defaultUnusedLimit  = 100
+	maxUnusedLimit      = 1000
+)
+
+// UnusedModelReport describes a model nobody printed or downloaded lately
+type UnusedModelReport struct {
+	ID          uint            `json:"id"`
+	ProjectID   uint            `json:"project_id"`
+	ProjectName string          `json:"project_name"`
+	Filename    string          `json:"filename"`
+	FileType    models.FileType `json:"file_type"`
+	Size        int64           `json:"size"`
+
+	LastDownloadedAt *time.Time `json:"last_downloaded_at"`
+	LastPrintedAt    *time.Time `json:"last_printed_at"`
+
+	// LastUsedAt is the latest download or print of the model or of the
+	// G-code sliced from it; nil when it was never used
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// recordDownload notes that a file was downloaded now
+func recordDownload(db *gorm.DB, file models.ProjectFile) {
+	now := time.Now()
+	recordFileActivity(db, file, func(activity *models.FileActivity) {
+		activity.LastDownloadedAt = &now
+	})
+}
+
+// recordPrint notes that a file was printed at the given time, unless a
+// later print is already recorded
+func recordPrint(db *gorm.DB, file models.ProjectFile, at time.Time) {
+	recordFileActivity(db, file, func(activity *models.FileActivity) {
+		if activity.LastPrintedAt == nil || at.After(*activity.LastPrintedAt) {
+			activity.LastPrintedAt = &at
+		}
+	})
+}
+
+// recordFileActivity updates the activity of a file. Failures are logged
+// rather than returned, so they never fail the download or print recorded.
+func recordFileActivity(db *gorm.DB, file models.ProjectFile, update func(*models.FileActivity)) {
+	activity := models.FileActivity{ProjectID: file.ProjectID, Filename: file.Filename}
+	if err := db.Where(&activity).FirstOrInit(&activity).Error; err != nil {
+		fmt.Printf("Warning: Failed to fetch activity of %s: %v\n", file.Filename, err)
+		return
+	}
+	update(&activity)
+	if err := db.Save(&activity).Error; err != nil {
+		fmt.Printf("Warning: Failed to record activity of %s: %v\n", file.Filename, err)
+	}
+}
+
+// loadFileActivity returns the activity of a project's files by filename
+func loadFileActivity(db *gorm.DB, projectIDs []uint) (map[uint]map[string]models.FileActivity, error) {
+	var activities []models.FileActivity
+	if err := db.Where("project_id IN ?", projectIDs).Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	byProject := make(map[uint]map[string]models.FileActivity)
+	for _, activity := range activities {
+		if byProject[activity.ProjectID] == nil {
+			byProject[activity.ProjectID] = make(map[string]models.FileActivity)
+		}
+		byProject[activity.ProjectID][activity.Filename] = activity
+	}
+	return byProject, nil
+}
+
+// GetUnusedModelsReport lists the STL and 3MF models not printed or downloaded
+// in the last ?months=6, largest first. A model counts as used when it or a
+// G-code file sliced from it was. Projects added within the period are left
+// out, as their models haven't had the time to be used.
+func (h *AdminHandler) GetUnusedModelsReport(c *gin.Context) {
+	months := defaultUnusedMonths
+	if raw := c.Query("months"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid months"})
+			return
+		}
+		months = parsed
+	}
+
+	limit := defaultUnusedLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxUnusedLimit)
+	}
+
+	db := requestDB(c)
+	since := time.Now().AddDate(0, -months, 0)
+
+	var projects []models.Project
+	err := db.Where("created_at < ?", since).
+		Preload("Files", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, project_id, filename, filepath, file_type, size").Order("filename")
+		}).
+		Find(&projects).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+	projectIDs := make([]uint, len(projects))
+	for i, project := range projects {
+		projectIDs[i] = project.ID
+	}
+	activities, err := loadFileActivity(db, projectIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch file activity"})
+		return
+	}
+
+	// Prints recorded before files had activity still count while their file IDs last
+	var printedIDs []uint
+	if err := db.Model(&models.PrintJob{}).Where("file_id IS NOT NULL AND started_at >= ?", since).Distinct().Pluck("file_id", &printedIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+	printed := make(map[uint]bool, len(printedIDs))
+	for _, id := range printedIDs {
+		printed[id] = true
+	}
+
+	unused := []UnusedModelReport{}
+	var reclaimable int64
+	for _, project := range projects {
+		pairing.Pair(project.Files)
+		byID := make(map[uint]models.ProjectFile, len(project.Files))
+		for _, file := range project.Files {
+			byID[file.ID] = file
+		}
+
+		for _, file := range project.Files {
+			if file.FileType != models.FileTypeSTL && file.FileType != models.FileType3MF {
+				continue
+			}
+			activity := activities[project.ID][file.Filename]
+			lastUsed, recentlyPrinted := activity.LastUsedAt(), printed[file.ID]
+			for _, id := range file.SlicedVariants {
+				variant := activities[project.ID][byID[id].Filename]
+				if used := variant.LastUsedAt(); used != nil && (lastUsed == nil || used.After(*lastUsed)) {
+					lastUsed = used
+				}
+				recentlyPrinted = recentlyPrinted || printed[id]
+			}
+			if recentlyPrinted || (lastUsed != nil && !lastUsed.Before(since)) {
+				continue
+			}
+
+			unused = append(unused, UnusedModelReport{
+				ID:               file.ID,
+				ProjectID:        project.ID,
+				ProjectName:      project.Name,
+				Filename:         file.Filename,
+				FileType:         file.FileType,
+				Size:             file.Size,
+				LastDownloadedAt: activity.LastDownloadedAt,
+				LastPrintedAt:    activity.LastPrintedAt,
+				LastUsedAt:       lastUsed,
+			})
+			reclaimable += file.Size
+		}
+	}
+
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Size > unused[j].Size })
+	total := len(unused)
+	if len(unused) > limit {
+		unused = unused[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"models":            unused,
+		"count":             len(unused),
+		"total":             total,
+		"reclaimable_bytes": reclaimable,
+		"months":            months,
+		"since":             since,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUnusedModelsReport tests recording downloads and prints and listing the models neither touched lately
+func TestUnusedModelsReport(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	projectsHandler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/files/:fileId", projectsHandler.GetProjectFile)
+	router.GET("/api/projects/:id/files/:fileId/download", projectsHandler.DownloadProjectFile)
+	router.POST("/api/prints", NewPrintsHandler().RecordPrint)
+	router.GET("/api/reports/unused", NewAdminHandler(nil).GetUnusedModelsReport)
+
+	yearAgo := time.Now().AddDate(-1, 0, 0)
+	old := models.Project{Name: "Hoard", Path: filepath.Join(tmpDir, "hoard"), CreatedAt: yearAgo}
+	recent := models.Project{Name: "Fresh", Path: filepath.Join(tmpDir, "fresh")}
+	db.Create(&old)
+	db.Create(&recent)
+	addFile := func(project models.Project, name string, size int) models.ProjectFile {
+		os.MkdirAll(project.Path, 0755)
+		path := filepath.Join(project.Path, name)
+		os.WriteFile(path, make([]byte, size), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name), Size: int64(size)}
+		db.Create(&file)
+		return file
+	}
+
+	forgotten := addFile(old, "forgotten.stl", 300)
+	stale := addFile(old, "stale.3mf", 200)
+	downloaded := addFile(old, "downloaded.stl", 400)
+	addFile(old, "benchy.stl", 500)
+	sliced := addFile(old, "benchy_0.2mm_PLA.gcode", 50)
+	addFile(old, "README.md", 10)
+	addFile(recent, "new.stl", 100)
+
+	// Activity older than the period doesn't make a model used
+	db.Create(&models.FileActivity{ProjectID: old.ID, Filename: stale.Filename, LastDownloadedAt: &yearAgo})
+
+	if w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/files/%d/download", old.ID, downloaded.ID), ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected download status %d, got %d", http.StatusOK, w.Code)
+	}
+	// Raw downloads are replication's, not use
+	sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/files/%d/download?raw=true", old.ID, forgotten.ID), "")
+	if w := sendJSON(router, "POST", "/api/prints", fmt.Sprintf(`{"project_id": %d, "file_id": %d, "outcome": "success"}`, old.ID, sliced.ID)); w.Code != http.StatusCreated {
+		t.Fatalf("Expected print status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	t.Run("File detail", func(t *testing.T) {
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/files/%d", old.ID, downloaded.ID), "")
+		var detail FileDetail
+		json.Unmarshal(w.Body.Bytes(), &detail)
+		if detail.LastDownloadedAt == nil || time.Since(*detail.LastDownloadedAt) > time.Minute || detail.LastPrintedAt != nil {
+			t.Errorf("Expected the download recorded, got %v and %v", detail.LastDownloadedAt, detail.LastPrintedAt)
+		}
+
+		w = sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/files/%d", old.ID, sliced.ID), "")
+		json.Unmarshal(w.Body.Bytes(), &detail)
+		if detail.LastPrintedAt == nil {
+			t.Error("Expected the print recorded")
+		}
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/reports/unused?months=6", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Models           []UnusedModelReport `json:"models"`
+			Count            int                 `json:"count"`
+			Total            int                 `json:"total"`
+			ReclaimableBytes int64               `json:"reclaimable_bytes"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		// The printed G-code counts for the model it was sliced from, and
+		// models of projects added within the period are left out
+		if response.Count != 2 || response.Models[0].ID != forgotten.ID || response.Models[1].ID != stale.ID {
+			t.Fatalf("Expected the forgotten and stale models, largest first, got %+v", response.Models)
+		}
+		if response.ReclaimableBytes != 500 {
+			t.Errorf("Expected 500 reclaimable bytes, got %d", response.ReclaimableBytes)
+		}
+		if last := response.Models[1].LastUsedAt; last == nil || !last.Equal(yearAgo) {
+			t.Errorf("Expected the stale model's last use, got %v", last)
+		}
+
+		w = sendJSON(router, "GET", "/api/reports/unused?limit=1", "")
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Count != 1 || response.Total != 2 || response.ReclaimableBytes != 500 {
+			t.Errorf("Expected one of two models listed with the total reclaimable, got %d of %d (%d bytes)", response.Count, response.Total, response.ReclaimableBytes)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, query := range []string{"?months=0", "?months=soon", "?limit=-1"} {
+			if w := sendJSON(router, "GET", "/api/reports/unused"+query, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
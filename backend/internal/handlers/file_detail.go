@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileDetail is a project file with what is read from its content
+type FileDetail struct {
+	models.ProjectFile
+
+	// Filaments map each tool of a G-code file or sliced 3MF to the filament
+	// it was sliced for, so AMS slots can be checked before sending a job
+	Filaments []gcode.Filament `json:"filaments,omitempty"`
+
+	// Text is what a text extractor read from the file for search
+	Text string `json:"text,omitempty"`
+
+	// LastDownloadedAt and LastPrintedAt are when the file was last used;
+	// left out when it never was
+	LastDownloadedAt *time.Time `json:"last_downloaded_at,omitempty"`
+	LastPrintedAt    *time.Time `json:"last_printed_at,omitempty"`
+}
+
+// GetProjectFile returns one file of a project with its print profile, when
+// it was last downloaded and printed and, for G-code and sliced 3MFs, its
+// filaments
+func (h *ProjectsHandler) GetProjectFile(c *gin.Context) {
+	db := requestDB(c)
+
+	var file models.ProjectFile
+	if err := db.Where("id = ? AND project_id = ?", c.Param("fileId"), c.Param("id")).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+	files := []models.ProjectFile{file}
+	if err := attachFileProfiles(db, file.ProjectID, files, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profiles"})
+		return
+	}
+
+	detail := FileDetail{ProjectFile: files[0], Text: file.Text}
+	var activity models.FileActivity
+	if err := db.Where("project_id = ? AND filename = ?", file.ProjectID, file.Filename).Limit(1).Find(&activity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch file activity"})
+		return
+	}
+	detail.LastDownloadedAt, detail.LastPrintedAt = activity.LastDownloadedAt, activity.LastPrintedAt
+	switch {
+	case file.FileType == models.FileTypeGCode:
+		filaments, err := gcode.ReadFilaments(file.Filepath)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read filaments from %s: %v\n", file.Filepath, err)
+		}
+		detail.Filaments = filaments
+	case gcode.IsPlateFile(file.Filename):
+		plates, err := gcode.ReadPlates(file.Filepath)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read plates from %s: %v\n", file.Filepath, err)
+		}
+		detail.Filaments = plateFilaments(plates)
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// plateFilaments adds up the filaments of all plates by tool
+func plateFilaments(plates []gcode.Plate) []gcode.Filament {
+	byTool := make(map[int]*gcode.Filament)
+	var filaments []gcode.Filament
+	for _, plate := range plates {
+		for _, filament := range plate.Filaments {
+			if total, ok := byTool[filament.Tool]; ok {
+				total.Grams += filament.Grams
+				total.MM += filament.MM
+				continue
+			}
+			copied := filament
+			byTool[filament.Tool] = &copied
+		}
+	}
+	for _, filament := range byTool {
+		filaments = append(filaments, *filament)
+	}
+	sort.Slice(filaments, func(i, j int) bool { return filaments[i].Tool < filaments[j].Tool })
+	return filaments
+}
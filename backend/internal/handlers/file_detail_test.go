@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetProjectFile tests the file detail with its profile and filaments
+func TestGetProjectFile(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/files/:fileId", handler.GetProjectFile)
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	other := models.Project{Name: "Other", Path: filepath.Join(tmpDir, "other")}
+	db.Create(&other)
+
+	files := map[string]string{
+		"gears.gcode": "; filament_type = PLA;PETG\n; filament_colour = #FFFFFF;#FF0000\n; filament used [g] = 5,2\nG28\n",
+		"gear.stl":    "solid gear",
+	}
+	for _, name := range []string{"gears.gcode", "gear.stl"} {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(files[name]), 0644)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)})
+	}
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "gear.stl", Notes: "4 perimeters"})
+
+	get := func(path string) (*httptest.ResponseRecorder, FileDetail) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		var detail FileDetail
+		json.Unmarshal(w.Body.Bytes(), &detail)
+		return w, detail
+	}
+
+	w, detail := get("/api/projects/1/files/1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if detail.Filename != "gears.gcode" || len(detail.Filaments) != 2 || detail.Filaments[1].Material != "PETG" ||
+		detail.Filaments[1].Color != "#FF0000" || detail.Filaments[1].Grams != 2 {
+		t.Errorf("Expected the G-code's two filaments, got %+v", detail)
+	}
+
+	w, detail = get("/api/projects/1/files/2")
+	if w.Code != http.StatusOK || detail.Profile == nil || detail.Profile.Notes != "4 perimeters" || detail.Filaments != nil {
+		t.Errorf("Expected the model's profile and no filaments, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := get("/api/projects/2/files/1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for another project's file, got %d", http.StatusNotFound, w.Code)
+	}
+}
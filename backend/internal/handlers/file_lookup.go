@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFileLookupKeys bounds the IDs and hashes one lookup may ask for
+const maxFileLookupKeys = 1000
+
+// FileLookupRequest lists the files to look up by ID and by content hash
+type FileLookupRequest struct {
+	IDs    []uint   `json:"ids"`
+	Hashes []string `json:"hashes"`
+}
+
+// FileLookupResult is the metadata of the files found and what matched nothing
+type FileLookupResult struct {
+	// Files holds each file found once, in ID order; a hash may match several
+	Files []models.ProjectFile `json:"files"`
+
+	MissingIDs    []uint   `json:"missing_ids"`
+	MissingHashes []string `json:"missing_hashes"`
+}
+
+// LookupFiles returns the metadata of many files in one call, found by ID or
+// SHA-256 content hash, for sync clients and deduplication tools
+func (h *FilesHandler) LookupFiles(c *gin.Context) {
+	var req FileLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.IDs) == 0 && len(req.Hashes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids or hashes are required"})
+		return
+	}
+	if len(req.IDs)+len(req.Hashes) > maxFileLookupKeys {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Too many files in one lookup", "max_keys": maxFileLookupKeys})
+		return
+	}
+	for i, hash := range req.Hashes {
+		// An empty hash would match every unhashed file
+		if req.Hashes[i] = strings.ToLower(strings.TrimSpace(hash)); req.Hashes[i] == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Hashes must not be empty"})
+			return
+		}
+	}
+
+	query := requestDB(c).Where("1 = 0")
+	if len(req.IDs) > 0 {
+		query = query.Or("id IN ?", req.IDs)
+	}
+	if len(req.Hashes) > 0 {
+		query = query.Or("hash IN ?", req.Hashes)
+	}
+
+	result := FileLookupResult{Files: []models.ProjectFile{}, MissingIDs: []uint{}, MissingHashes: []string{}}
+	if err := query.Order("id ASC").Find(&result.Files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up files"})
+		return
+	}
+
+	foundIDs := make(map[uint]bool, len(result.Files))
+	foundHashes := make(map[string]bool, len(result.Files))
+	for _, file := range result.Files {
+		foundIDs[file.ID] = true
+		foundHashes[file.Hash] = true
+	}
+	for _, id := range req.IDs {
+		if !foundIDs[id] {
+			result.MissingIDs = append(result.MissingIDs, id)
+		}
+	}
+	for _, hash := range req.Hashes {
+		if !foundHashes[hash] {
+			result.MissingHashes = append(result.MissingHashes, hash)
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
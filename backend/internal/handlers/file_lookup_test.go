@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestLookupFiles tests looking up many files by ID and hash in one call
+func TestLookupFiles(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/files/lookup", NewFilesHandler(nil).LookupFiles)
+
+	hashA := strings.Repeat("a", 64)
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	first := models.ProjectFile{ProjectID: project.ID, Filename: "gear.stl", Filepath: tmpDir + "/gear.stl", FileType: models.FileTypeSTL, Hash: hashA}
+	duplicate := models.ProjectFile{ProjectID: project.ID, Filename: "gear copy.stl", Filepath: tmpDir + "/gear copy.stl", FileType: models.FileTypeSTL, Hash: hashA}
+	other := models.ProjectFile{ProjectID: project.ID, Filename: "base.stl", Filepath: tmpDir + "/base.stl", FileType: models.FileTypeSTL}
+	db.Create(&first)
+	db.Create(&duplicate)
+	db.Create(&other)
+
+	body := fmt.Sprintf(`{"ids": [%d, %d, 999], "hashes": ["%s", "%s"]}`, first.ID, other.ID, strings.ToUpper(hashA), strings.Repeat("b", 64))
+	w := sendJSON(router, "POST", "/api/files/lookup", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var result FileLookupResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Files) != 3 {
+		t.Errorf("Expected each matching file once, got %+v", result.Files)
+	}
+	if len(result.MissingIDs) != 1 || result.MissingIDs[0] != 999 {
+		t.Errorf("Expected ID 999 missing, got %v", result.MissingIDs)
+	}
+	if len(result.MissingHashes) != 1 || result.MissingHashes[0] != strings.Repeat("b", 64) {
+		t.Errorf("Expected the unknown hash missing, got %v", result.MissingHashes)
+	}
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"Empty", `{}`, http.StatusBadRequest},
+		{"Empty hash", `{"hashes": [""]}`, http.StatusBadRequest},
+		{"Malformed", `{"ids": "1"}`, http.StatusBadRequest},
+		{"Too many", `{"ids": [` + strings.Repeat("1,", maxFileLookupKeys) + `1]}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "POST", "/api/files/lookup", tc.body); w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expectedCode, w.Code)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/units"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// fileProfilesExport is the ZIP entry project downloads carry the file profiles in
+const fileProfilesExport = ".3dshelf-profiles.json"
+
+// UpdateFileProfile merges print recommendations into a model file's profile.
+// Only fields present in the body change, null clears a value, and a profile
+// left empty is removed.
+func (h *ProjectsHandler) UpdateFileProfile(c *gin.Context) {
+	db := requestDB(c)
+
+	var file models.ProjectFile
+	if err := db.Where("id = ? AND project_id = ?", c.Param("fileId"), c.Param("id")).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	switch file.FileType {
+	case models.FileTypeSTL, models.FileType3MF, models.FileTypeCAD:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Print profiles can only be attached to model files"})
+		return
+	}
+
+	unlock, ok := h.lockProject(c, file.ProjectID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	profile := models.FileProfile{ProjectID: file.ProjectID, Filename: file.Filename}
+	if err := db.Where(&profile).Limit(1).Find(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profile"})
+		return
+	}
+
+	// The body sets updated_at only when it carries the version the client read
+	updatedAt := profile.UpdatedAt
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !checkVersion(c, "Print profile", updatedAt, &profile.UpdatedAt) {
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var err error
+	switch {
+	case profile.IsEmpty() && profile.ID != 0:
+		err = db.Delete(&profile).Error
+	case !profile.IsEmpty():
+		err = db.Save(&profile).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update print profile"})
+		return
+	}
+
+	// Sidecars summarize the notes of a project's files
+	var project models.Project
+	if err := db.First(&project, file.ProjectID).Error; err == nil {
+		if err := h.scanner.RefreshSidecar(&project); err != nil {
+			fmt.Printf("Warning: Failed to write sidecar for project %d: %v\n", project.ID, err)
+		}
+	}
+
+	if !profile.IsEmpty() {
+		file.Profile = &profile
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Print profile updated successfully",
+		"file":    file,
+	})
+}
+
+// attachFileProfiles sets the profile of each file of a project that has one,
+// displayed in prefs
+func attachFileProfiles(db *gorm.DB, projectID uint, files []models.ProjectFile, prefs units.Preferences) error {
+	profiles, err := loadFileProfiles(db, projectID)
+	if err != nil {
+		return err
+	}
+	for i := range files {
+		if profile, ok := profiles[files[i].Filename]; ok {
+			profile.Display = fileProfileDisplay(prefs, profile)
+			files[i].Profile = &profile
+		}
+	}
+	return nil
+}
+
+// loadFileProfiles returns a project's file profiles by filename
+func loadFileProfiles(db *gorm.DB, projectID uint) (map[string]models.FileProfile, error) {
+	var profiles []models.FileProfile
+	if err := db.Where("project_id = ?", projectID).Order("filename ASC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+
+	byFilename := make(map[string]models.FileProfile, len(profiles))
+	for _, profile := range profiles {
+		byFilename[profile.Filename] = profile
+	}
+	return byFilename, nil
+}
+
+// zipFileProfiles adds the profiles, keyed by filename, to a project ZIP
+func zipFileProfiles(zipWriter *zip.Writer, profiles map[string]models.FileProfile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := zipWriter.Create(fileProfilesExport)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
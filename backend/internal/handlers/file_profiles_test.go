@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupFileProfilesRouter creates a router with the file profile, listing and download routes
+func setupFileProfilesRouter(tmpDir string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id", handler.GetProject)
+	router.GET("/api/projects/:id/files", handler.GetProjectFiles)
+	router.DELETE("/api/projects/:id/files/:fileId", handler.DeleteProjectFile)
+	router.PATCH("/api/projects/:id/files/:fileId/profile", handler.UpdateFileProfile)
+	router.GET("/api/projects/:id/download", handler.DownloadProject)
+	return router
+}
+
+// TestUpdateFileProfile tests attaching print recommendations to a model file
+func TestUpdateFileProfile(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupFileProfilesRouter(tmpDir)
+
+	projectDir := filepath.Join(tmpDir, "Bracket")
+	os.MkdirAll(projectDir, 0755)
+	os.WriteFile(filepath.Join(projectDir, "bracket.stl"), []byte("solid bracket"), 0644)
+	os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("# Bracket"), 0644)
+
+	scanner.New(db, tmpDir).Run(models.ScanTriggerManual)
+
+	var model models.ProjectFile
+	db.Where("filename = ?", "bracket.stl").First(&model)
+	profilePath := "/api/projects/1/files/" + strconv.Itoa(int(model.ID)) + "/profile"
+
+	w := sendJSON(router, "PATCH", profilePath, `{"layer_height": 0.2, "infill_percent": 40, "supports": "build_plate", "orientation": " Flat side down "}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Later patches only change the fields they mention
+	w = sendJSON(router, "PATCH", profilePath, `{"infill_percent": null, "notes": "Use 4 perimeters"}`)
+	var response struct {
+		File models.ProjectFile `json:"file"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	profile := response.File.Profile
+	if profile == nil || *profile.LayerHeight != 0.2 || profile.InfillPercent != nil || profile.Supports != models.SupportsBuildPlate ||
+		profile.Orientation != "Flat side down" || profile.Notes != "Use 4 perimeters" {
+		t.Fatalf("Expected the patch to merge into the profile, got %+v", profile)
+	}
+
+	testCases := map[string]string{
+		"Layer height too large": `{"layer_height": 5}`,
+		"Infill over 100":        `{"infill_percent": 120}`,
+		"Unknown supports":       `{"supports": "tree"}`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "PATCH", profilePath, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	var readme models.ProjectFile
+	db.Where("filename = ?", "README.md").First(&readme)
+	if w := sendJSON(router, "PATCH", "/api/projects/1/files/"+strconv.Itoa(int(readme.ID))+"/profile", `{"notes": "x"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a README, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := sendJSON(router, "PATCH", "/api/projects/1/files/999/profile", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown file, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// A rescan recreates the file records but keeps the profile
+	scanner.New(db, tmpDir).Run(models.ScanTriggerManual)
+	w = sendJSON(router, "GET", "/api/projects/1/files", "")
+	var listing struct {
+		Files []models.ProjectFile `json:"files"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listing)
+	found := false
+	for _, file := range listing.Files {
+		if file.Filename == "bracket.stl" {
+			found = file.Profile != nil && file.Profile.Notes == "Use 4 perimeters"
+			model = file
+		}
+	}
+	if !found {
+		t.Errorf("Expected the file listing to show the profile after a rescan, got %s", w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", "/api/projects/1/download", "")
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read ZIP: %v", err)
+	}
+	var exported map[string]models.FileProfile
+	for _, entry := range archive.File {
+		if entry.Name == fileProfilesExport {
+			reader, _ := entry.Open()
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			json.Unmarshal(data, &exported)
+		}
+	}
+	if exported["bracket.stl"].Supports != models.SupportsBuildPlate {
+		t.Errorf("Expected the export to include the profile, got %v", exported)
+	}
+
+	// Clearing every field removes the profile
+	profilePath = "/api/projects/1/files/" + strconv.Itoa(int(model.ID)) + "/profile"
+	sendJSON(router, "PATCH", profilePath, `{"layer_height": null, "supports": "", "orientation": "", "notes": ""}`)
+	var count int64
+	db.Model(&models.FileProfile{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected an empty profile to be removed, got %d profiles", count)
+	}
+}
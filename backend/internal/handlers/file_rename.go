@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RenameFileRequest is the body accepted by RenameProjectFile
+type RenameFileRequest struct {
+	// Filename is the new path relative to the project directory; a path in
+	// another folder moves the file there
+	Filename string `json:"filename" binding:"required"`
+
+	// UpdatedAt is when the client read the file, checked when there is no If-Match
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// RenameProjectFile renames a file or moves it to another folder of its
+// project, on disk and in the database together. Its print profile,
+// activity, assembly parts and cover choice follow the new name. A name
+// taken by another file is a conflict, as on upload, and the file keeps its
+// type, so the extension can only change case. Files others were
+// deduplicated against keep their names.
+func (h *ProjectsHandler) RenameProjectFile(c *gin.Context) {
+	db := requestDB(c)
+
+	var req RenameFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	newName := strings.TrimSpace(req.Filename)
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
+
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	var file models.ProjectFile
+	if err := db.Where("id = ? AND project_id = ?", c.Param("fileId"), project.ID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if !checkVersion(c, "File", file.UpdatedAt, req.UpdatedAt) {
+		return
+	}
+
+	if err := validateUploadPath(newName, path.Base(newName)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename", "details": err.Error()})
+		return
+	}
+	if fileType := models.GetFileTypeFromExtension(newName); fileType != file.FileType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename", "details": fmt.Sprintf("%s would no longer be a %s file", newName, file.FileType)})
+		return
+	}
+	if newName == file.Filename {
+		c.JSON(http.StatusOK, gin.H{"message": "File unchanged", "file": file})
+		return
+	}
+	if rejectCanonicalCopy(c, db, file.ID) {
+		return
+	}
+
+	// Names are compared case-insensitively, as when normalizing, so that
+	// case-only renames work and names can't collide on filesystems that ignore case
+	var existing models.ProjectFile
+	err := db.Where("project_id = ? AND id <> ? AND LOWER(filename) = LOWER(?)", project.ID, file.ID, newName).First(&existing).Error
+	switch {
+	case err == nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "A file with this name already exists", "existing_file": existing})
+		return
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing files"})
+		return
+	}
+	dest := filepath.Join(project.Path, filepath.FromSlash(newName))
+	if info, err := os.Lstat(dest); err == nil {
+		if source, err := os.Lstat(file.Filepath); err != nil || !os.SameFile(info, source) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A file with this name already exists"})
+			return
+		}
+	}
+
+	if err := fileperm.MkdirAll(filepath.Dir(dest)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create folder", "details": err.Error()})
+		return
+	}
+	rename := FileRename{FileID: file.ID, From: file.Filename, To: newName}
+	if err := renameProjectFiles(db, project, []FileRename{rename}, map[uint]models.ProjectFile{file.ID: file}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename file", "details": err.Error()})
+		return
+	}
+
+	// Scans must descend as deep as the new folder, or they would drop the file
+	if depth := uploadDepth(newName); depth > project.ScanSettings.MaxDepth {
+		project.ScanSettings.MaxDepth = depth
+		if err := db.Model(&project).Select("scan_settings").Updates(&project).Error; err != nil {
+			fmt.Printf("Warning: Failed to extend the scan depth of project %d: %v\n", project.ID, err)
+		}
+	}
+	if err := db.Model(&project).Update("last_scanned", time.Now()).Error; err != nil {
+		fmt.Printf("Warning: Failed to update project last_scanned timestamp: %v\n", err)
+	}
+
+	if err := db.First(&file, file.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch renamed file"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "File renamed successfully",
+		"from":    rename.From,
+		"file":    file,
+	})
+}
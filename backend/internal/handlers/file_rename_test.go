@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRenameProjectFile tests renaming and moving a file within its project
+func TestRenameProjectFile(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.PATCH("/api/projects/:id/files/:fileId", handler.RenameProjectFile)
+
+	project := models.Project{Name: "Table", Path: tmpDir, CoverFilename: "leg.stl"}
+	db.Create(&project)
+	files := map[string]models.ProjectFile{}
+	for _, name := range []string{"leg.stl", "top.stl", "README.md"} {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)}
+		db.Create(&file)
+		files[name] = file
+	}
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "leg.stl", Notes: "4 perimeters"})
+	now := time.Now()
+	db.Create(&models.FileActivity{ProjectID: project.ID, Filename: "leg.stl", LastDownloadedAt: &now})
+	db.Create(&models.Assembly{ProjectID: project.ID, Name: "Table", Parts: []models.AssemblyPart{{Filename: "leg.stl", Quantity: 4}}})
+
+	rename := func(name, body string) *httptest.ResponseRecorder {
+		return sendJSON(router, "PATCH", fmt.Sprintf("/api/projects/%d/files/%d", project.ID, files[name].ID), body)
+	}
+
+	w := rename("leg.stl", `{"filename": "parts/Leg.STL"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		From string             `json:"from"`
+		File models.ProjectFile `json:"file"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	dest := filepath.Join(tmpDir, "parts", "Leg.STL")
+	if response.From != "leg.stl" || response.File.Filename != "parts/Leg.STL" || response.File.Filepath != dest {
+		t.Errorf("Expected the file moved into parts, got %+v", response)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("Expected the file moved on disk: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "leg.stl")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old name gone, got %v", err)
+	}
+
+	// Records kept by filename follow the file
+	var profile models.FileProfile
+	var activity models.FileActivity
+	var assembly models.Assembly
+	db.First(&profile)
+	db.First(&activity)
+	db.First(&assembly)
+	db.First(&project, project.ID)
+	if profile.Filename != "parts/Leg.STL" || activity.Filename != "parts/Leg.STL" || assembly.Parts[0].Filename != "parts/Leg.STL" {
+		t.Errorf("Expected the profile, activity and assembly renamed, got %q %q %q", profile.Filename, activity.Filename, assembly.Parts[0].Filename)
+	}
+	if project.CoverFilename != "parts/Leg.STL" || project.ScanSettings.MaxDepth < 1 {
+		t.Errorf("Expected the cover kept and scans reaching the folder, got %q (depth %d)", project.CoverFilename, project.ScanSettings.MaxDepth)
+	}
+
+	t.Run("Conflicts", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"Taken by a file":    `{"filename": "parts/leg.stl"}`,
+			"Taken on disk only": `{"filename": "stray.stl"}`,
+			"Stale updated_at":   `{"filename": "lid.stl", "updated_at": "2020-01-02T15:04:05Z"}`,
+		} {
+			os.WriteFile(filepath.Join(tmpDir, "stray.stl"), []byte("stray"), 0644)
+			if w := rename("top.stl", body); w.Code != http.StatusConflict {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusConflict, w.Code)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "top.stl")); err != nil {
+			t.Errorf("Expected the file left in place: %v", err)
+		}
+	})
+
+	t.Run("Canonical copy", func(t *testing.T) {
+		top := files["top.stl"]
+		duplicate := models.ProjectFile{ProjectID: project.ID + 1, Filename: "top.stl", Filepath: filepath.Join(t.TempDir(), "top.stl"), FileType: models.FileTypeSTL, DuplicateOf: &top.ID}
+		db.Create(&duplicate)
+		defer db.Delete(&duplicate)
+
+		if w := rename("top.stl", `{"filename": "lid.stl"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "top.stl")); err != nil {
+			t.Errorf("Expected the canonical copy left in place: %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"Missing filename": `{}`,
+			"Outside project":  `{"filename": "../top.stl"}`,
+			"Absolute":         `{"filename": "/tmp/top.stl"}`,
+			"Hidden":           `{"filename": ".top.stl"}`,
+			"Other type":       `{"filename": "top.gcode"}`,
+		} {
+			if w := rename("top.stl", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+			}
+		}
+		if w := sendJSON(router, "PATCH", fmt.Sprintf("/api/projects/%d/files/999", project.ID), `{"filename": "x.stl"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a missing file, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
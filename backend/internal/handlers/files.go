@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/preview"
+	"3dshelf/pkg/signing"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSignedURLTTL = time.Hour
+	maxSignedURLTTL     = 7 * 24 * time.Hour
+)
+
+// FilesHandler handles file-level HTTP requests that are not scoped to a project route
+type FilesHandler struct {
+	signer   *signing.Signer
+	previews *preview.Cache
+}
+
+// SignFileRequest represents the request body for signing a download URL
+type SignFileRequest struct {
+	ExpiresIn int `json:"expires_in"` // seconds
+}
+
+// NewFilesHandler creates a new FilesHandler
+func NewFilesHandler(signer *signing.Signer) *FilesHandler {
+	return &FilesHandler{
+		signer: signer,
+	}
+}
+
+// SetPreviews decimates the 3D previews of large models through previews;
+// without it models are previewed whole
+func (h *FilesHandler) SetPreviews(previews *preview.Cache) {
+	h.previews = previews
+}
+
+// SignFileDownload creates an expiring signed download URL for a file
+func (h *FilesHandler) SignFileDownload(c *gin.Context) {
+	var req SignFileRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	ttl := defaultSignedURLTTL
+	if req.ExpiresIn != 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+		if ttl <= 0 || ttl > maxSignedURLTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be between 1 second and 7 days"})
+			return
+		}
+	}
+
+	var file models.ProjectFile
+	if err := requestDB(c).First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	expires := time.Now().Add(ttl)
+	path := fmt.Sprintf("/api/files/%d/download", file.ID)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("sha256", file.Hash)
+	query.Set("signature", h.signer.Sign(path, expires, file.Hash))
+	signedURL := path + "?" + query.Encode()
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":          signedURL,
+		"absolute_url": requestBaseURL(c) + signedURL,
+		"expires_at":   time.Unix(expires.Unix(), 0),
+		"sha256":       file.Hash,
+		"size":         file.Size,
+		"filename":     file.Filename,
+	})
+}
+
+// DownloadSignedFile serves a file through a URL verified by RequireSignedURL
+func (h *FilesHandler) DownloadSignedFile(c *gin.Context) {
+	var file models.ProjectFile
+	if err := requestDB(c).First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	// The URL promises specific content; refuse to serve something else
+	if c.GetString(middleware.SignedHashKey) != file.Hash {
+		c.JSON(http.StatusConflict, gin.H{"error": "File changed since the URL was signed"})
+		return
+	}
+
+	if _, err := os.Stat(file.Filepath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+
+	serveFile(c, &file)
+}
+
+// serveFile streams a project file as an attachment with checksum headers.
+// Compressed models are decompressed and named without their suffix, unless
+// ?raw=true asks for the stored bytes, which the checksums are of. Raw
+// downloads are made by replication rather than people, so only the others
+// count as the file's last download.
+func serveFile(c *gin.Context, file *models.ProjectFile) {
+	raw := c.Query("raw") == "true"
+	filename := filepath.Base(file.Filename)
+	decompress := mesh.IsCompressed(file.Filepath) && !raw
+	if decompress {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	if !raw {
+		recordDownload(requestDB(c), *file)
+	}
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("Content-Type", "application/octet-stream")
+
+	if file.Hash != "" && !decompress {
+		c.Header("X-Checksum-SHA256", file.Hash)
+		c.Header("ETag", `"`+file.Hash+`"`)
+		if digest, err := hex.DecodeString(file.Hash); err == nil {
+			c.Header("Digest", "sha-256="+base64.StdEncoding.EncodeToString(digest))
+		}
+	}
+
+	if !decompress {
+		c.File(file.Filepath)
+		return
+	}
+	content, size, err := mesh.Open(file.Filepath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decompress file", "details": err.Error()})
+		return
+	}
+	defer content.Close()
+	c.DataFromReader(http.StatusOK, size, "application/octet-stream", content, nil)
+}
+
+// requestBaseURL reconstructs the scheme and host the client used to reach the server
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"3dshelf/pkg/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupFilesRouter creates a router exposing signed download routes
+func setupFilesRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	signer := signing.New([]byte("test-secret"))
+	handler := NewFilesHandler(signer)
+
+	api := router.Group("/api")
+	{
+		api.POST("/files/:id/sign", handler.SignFileDownload)
+		api.GET("/files/:id/download", middleware.RequireSignedURL(signer), handler.DownloadSignedFile)
+	}
+
+	return router
+}
+
+// signFile requests a signed URL and returns the parsed response
+func signFile(t *testing.T, router *gin.Engine, fileID uint, body string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/files/"+strconv.Itoa(int(fileID))+"/sign", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+// TestSignedDownload tests signing a URL and downloading through it
+func TestSignedDownload(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupFilesRouter()
+
+	filePath := filepath.Join(tmpDir, "benchy.gcode")
+	if err := os.WriteFile(filePath, []byte("G28\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	project := models.Project{Name: "Signed", Path: tmpDir}
+	db.Create(&project)
+	file := models.ProjectFile{
+		ProjectID: project.ID,
+		Filename:  "benchy.gcode",
+		Filepath:  filePath,
+		FileType:  models.FileTypeGCode,
+		Size:      4,
+		Hash:      "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+	db.Create(&file)
+
+	status, response := signFile(t, router, file.ID, `{"expires_in": 600}`)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	if response["sha256"] != file.Hash {
+		t.Errorf("Expected hash %s in response, got %v", file.Hash, response["sha256"])
+	}
+
+	signedURL := response["url"].(string)
+
+	t.Run("Download with signature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", signedURL, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("X-Checksum-SHA256") != file.Hash {
+			t.Errorf("Expected checksum header, got %q", w.Header().Get("X-Checksum-SHA256"))
+		}
+		if w.Header().Get("Digest") == "" {
+			t.Error("Expected Digest header")
+		}
+		if w.Body.String() != "G28\n" {
+			t.Errorf("Unexpected body %q", w.Body.String())
+		}
+	})
+
+	t.Run("Tampered signature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", signedURL+"0", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("File changed after signing", func(t *testing.T) {
+		db.Model(&file).Update("hash", "changed")
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", signedURL, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+}
+
+// TestSignFileDownloadErrors tests validation of sign requests
+func TestSignFileDownloadErrors(t *testing.T) {
+	db := setupTestDB(t)
+	createTestData(t, db)
+	router := setupFilesRouter()
+
+	if status, _ := signFile(t, router, 999, ""); status != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown file, got %d", http.StatusNotFound, status)
+	}
+
+	if status, _ := signFile(t, router, 1, `{"expires_in": 99999999}`); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for excessive expiry, got %d", http.StatusBadRequest, status)
+	}
+
+	if status, response := signFile(t, router, 1, ""); status != http.StatusOK || response["url"] == "" {
+		t.Errorf("Expected default expiry to succeed, got %d %v", status, response)
+	}
+}
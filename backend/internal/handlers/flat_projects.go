@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+	"archive/zip"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rejectFlatProject responds with 409 and returns true when the project is
+// made of loose root files and so has no directory to write into
+func rejectFlatProject(c *gin.Context, project *models.Project) bool {
+	if !project.IsFlat() {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "Not supported for flat-file projects"})
+	return true
+}
+
+// zipProjectFiles writes the given files into a ZIP archive by filename
+func zipProjectFiles(zipWriter *zip.Writer, files []models.ProjectFile) error {
+	for _, file := range files {
+		name := file.Filename
+		if mesh.IsCompressed(file.Filepath) {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		entry, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+
+		source, _, err := mesh.Open(file.Filepath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, source)
+		source.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestFlatProjects tests handler behaviour for projects made of loose root files
+func TestFlatProjects(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/download", handler.DownloadProject)
+	router.POST("/api/projects/:id/files/check-conflicts", handler.CheckUploadConflicts)
+	router.PUT("/api/projects/:id/readme", handler.UpdateProjectREADME)
+	router.DELETE("/api/projects/:id", handler.DeleteProject)
+
+	project := models.Project{Name: "benchy", Path: filepath.Join(tmpDir, "benchy") + "#flat", Layout: models.LayoutFlat}
+	db.Create(&project)
+
+	var paths []string
+	for _, name := range []string{"benchy_hull.stl", "benchy_cabin.stl"} {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte("solid "+name), 0644)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.FileTypeSTL})
+		paths = append(paths, path)
+	}
+	unrelated := filepath.Join(tmpDir, "gear.stl")
+	os.WriteFile(unrelated, []byte("solid gear"), 0644)
+
+	t.Run("Download zips recorded files", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/1/download", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to read ZIP: %v", err)
+		}
+		if len(archive.File) != 2 {
+			t.Errorf("Expected 2 files in ZIP, got %d", len(archive.File))
+		}
+	})
+
+	t.Run("Writes are rejected", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest("POST", "/api/projects/1/files/check-conflicts", bytes.NewReader([]byte(`{"filenames": ["a.stl"]}`))),
+			httptest.NewRequest("PUT", "/api/projects/1/readme", bytes.NewReader([]byte(`{"content": "# benchy"}`))),
+		} {
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusConflict {
+				t.Errorf("Expected status %d for %s %s, got %d", http.StatusConflict, req.Method, req.URL.Path, w.Code)
+			}
+		}
+	})
+
+	t.Run("Delete removes only the project's files", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/projects/1", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("Expected %s to be deleted", path)
+			}
+		}
+		if _, err := os.Stat(unrelated); err != nil {
+			t.Error("Expected unrelated root files to be kept")
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/importer"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultImportJobLimit = 50
+	maxImportJobLimit     = 500
+)
+
+// ImportsHandler handles remote collection and export archive import HTTP requests
+type ImportsHandler struct {
+	importer *importer.Importer
+}
+
+// NewImportsHandler creates a new ImportsHandler running jobs on the given importer
+func NewImportsHandler(importer *importer.Importer) *ImportsHandler {
+	return &ImportsHandler{
+		importer: importer,
+	}
+}
+
+// CreateImportRequest is the body accepted by CreateImport
+type CreateImportRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// CreateImport starts a background import of a remote collection URL
+func (h *ImportsHandler) CreateImport(c *gin.Context) {
+	var req CreateImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	job, err := h.importer.Start(req.URL)
+	if errors.Is(err, importer.ErrUnsupportedURL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No configured importer supports this URL"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Import started",
+		"job":     job,
+	})
+}
+
+// CreateArchiveImport starts a background import of an uploaded export
+// archive of another library tool, given as the multipart "file" field. The
+// optional "format" field names the tool; it is detected otherwise.
+func (h *ImportsHandler) CreateArchiveImport(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded archive"})
+		return
+	}
+	defer file.Close()
+
+	job, err := h.importer.StartArchive(file, header.Filename, c.PostForm("format"))
+	if errors.Is(err, importer.ErrUnsupportedArchive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive is not a supported export", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Import started",
+		"job":     job,
+	})
+}
+
+// GetImports returns import jobs, most recent first
+func (h *ImportsHandler) GetImports(c *gin.Context) {
+	limit := defaultImportJobLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxImportJobLimit)
+	}
+
+	var jobs []models.ImportJob
+	if err := requestDB(c).Order("created_at DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetImport returns an import job with per-item progress
+func (h *ImportsHandler) GetImport(c *gin.Context) {
+	var job models.ImportJob
+	if err := requestDB(c).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeImport restarts an interrupted import job, retrying failed items
+func (h *ImportsHandler) ResumeImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	job, err := h.importer.Resume(uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	case errors.Is(err, importer.ErrJobActive), errors.Is(err, importer.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resume import"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Import resumed",
+		"job":     job,
+	})
+}
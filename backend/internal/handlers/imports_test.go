@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/importer"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestImportsHandler tests validating and inspecting collection imports
+func TestImportsHandler(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	imp := importer.New(db, jobs.New(db), scanner.New(db, tmpDir), tmpDir, importer.NewThingiverse("token"))
+	handler := NewImportsHandler(imp)
+	router.POST("/api/imports", handler.CreateImport)
+	router.GET("/api/imports", handler.GetImports)
+	router.GET("/api/imports/:id", handler.GetImport)
+	router.POST("/api/imports/:id/resume", handler.ResumeImport)
+	router.POST("/api/imports/archive", handler.CreateArchiveImport)
+
+	collection := models.Collection{Name: "Tools", SourceURL: "https://www.thingiverse.com/maker/collections/1"}
+	db.Create(&collection)
+	job := models.ImportJob{
+		SourceURL:    collection.SourceURL,
+		Provider:     "thingiverse",
+		Status:       models.ImportJobCompleted,
+		CollectionID: &collection.ID,
+		Total:        2,
+		Imported:     2,
+	}
+	db.Create(&job)
+	db.Create(&models.ImportItem{JobID: job.ID, RemoteID: "1", Name: "Holder", Status: models.ImportItemImported})
+	db.Create(&models.ImportItem{JobID: job.ID, RemoteID: "2", Name: "Stand", Status: models.ImportItemImported})
+
+	t.Run("Unsupported URL", func(t *testing.T) {
+		for _, body := range []string{`{"url": "https://www.printables.com/@maker/collections/1"}`, `{}`} {
+			if w := sendJSON(router, "POST", "/api/imports", body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+			}
+		}
+	})
+
+	t.Run("List and get", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/imports", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		w = sendJSON(router, "GET", "/api/imports/1", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var result models.ImportJob
+		json.Unmarshal(w.Body.Bytes(), &result)
+		if len(result.Items) != 2 || result.Items[0].Name != "Holder" {
+			t.Errorf("Expected items with progress, got %+v", result.Items)
+		}
+
+		if w := sendJSON(router, "GET", "/api/imports/999", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		if w := sendJSON(router, "POST", "/api/imports/1/resume", ""); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a finished job, got %d", http.StatusConflict, w.Code)
+		}
+		if w := sendJSON(router, "POST", "/api/imports/999/resume", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		upload := func(entry, format string) *httptest.ResponseRecorder {
+			var archive bytes.Buffer
+			zw := zip.NewWriter(&archive)
+			f, _ := zw.Create(entry)
+			f.Write([]byte(`[{"id": 1, "name": "Holder"}]`))
+			zw.Close()
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile("file", "export.zip")
+			part.Write(archive.Bytes())
+			writer.WriteField("format", format)
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/api/imports/archive", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := upload("models.json", "")
+		var started struct {
+			Job models.ImportJob `json:"job"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &started)
+		if w.Code != http.StatusAccepted || started.Job.Provider != "printables" || started.Job.SourceURL != "export.zip" {
+			t.Errorf("Expected a printables import, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if w := upload("models.json", "manyfold"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for the wrong format, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := upload("model.stl", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown export, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := sendJSON(router, "POST", "/api/imports/archive", "{}"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d without a file, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// TestCollectionsHandler tests listing collections and their projects
+func TestCollectionsHandler(t *testing.T) {
+	db := setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewCollectionsHandler()
+	router.GET("/api/collections", handler.GetCollections)
+	router.GET("/api/collections/:id", handler.GetCollection)
+
+	collection := models.Collection{Name: "Tools", SourceURL: "https://www.thingiverse.com/maker/collections/1"}
+	db.Create(&collection)
+	db.Create(&models.Project{Name: "Holder", Path: "/test/Tools/Holder_1", CollectionID: &collection.ID})
+	db.Create(&models.Project{Name: "Stand", Path: "/test/Tools/Stand_2", CollectionID: &collection.ID})
+	db.Create(&models.Project{Name: "Loose", Path: "/test/Loose"})
+
+	w := sendJSON(router, "GET", "/api/collections", "")
+	var list struct {
+		Collections []models.Collection `json:"collections"`
+		Count       int                 `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if list.Count != 1 || list.Collections[0].ProjectCount != 2 {
+		t.Errorf("Expected one collection with 2 projects, got %+v", list)
+	}
+
+	w = sendJSON(router, "GET", "/api/collections/1", "")
+	var detail models.Collection
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if len(detail.Projects) != 2 {
+		t.Errorf("Expected 2 projects in collection, got %d", len(detail.Projects))
+	}
+
+	if w := sendJSON(router, "GET", "/api/collections/999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
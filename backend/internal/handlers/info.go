@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/internal/version"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/maintenance"
+	"3dshelf/pkg/updates"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileTypeInfo is a file type the server recognizes and the extensions that map to it
+type FileTypeInfo struct {
+	Type       models.FileType `json:"type"`
+	Extensions []string        `json:"extensions"`
+}
+
+// StorageInfo describes where the library is kept
+type StorageInfo struct {
+	Backend  string `json:"backend"`
+	Database string `json:"database"`
+}
+
+// Limits are the request limits clients must stay within
+type Limits struct {
+	MaxUploadBytes int64 `json:"max_upload_bytes"`
+	MaxHeaderBytes int   `json:"max_header_bytes"`
+}
+
+// ServerInfo lets clients and the CLI negotiate behavior across server versions
+type ServerInfo struct {
+	version.Info
+	Features  map[features.Flag]bool `json:"features"`
+	FileTypes []FileTypeInfo         `json:"file_types"`
+	Storage   StorageInfo            `json:"storage"`
+	Limits    Limits                 `json:"limits"`
+
+	// APIVersions are the versions served under /api/<version>, the last one current
+	APIVersions []string `json:"api_versions"`
+
+	// Maintenance tells clients to show a read-only banner while enabled
+	Maintenance maintenance.State `json:"maintenance"`
+
+	// Update is what the opt-in update check found; omitted when it is off
+	Update *updates.Status `json:"update,omitempty"`
+}
+
+// InfoHandler handles the server information HTTP request
+type InfoHandler struct {
+	features *features.Flags
+	limits   Limits
+
+	// maintenance is reported when set
+	maintenance *maintenance.Mode
+
+	// updates is reported when set
+	updates *updates.Checker
+}
+
+// NewInfoHandler creates a new InfoHandler reporting the given flags and limits
+func NewInfoHandler(flags *features.Flags, limits Limits) *InfoHandler {
+	return &InfoHandler{
+		features: flags,
+		limits:   limits,
+	}
+}
+
+// SetMaintenance reports the given maintenance mode in server info
+func (h *InfoHandler) SetMaintenance(mode *maintenance.Mode) {
+	h.maintenance = mode
+}
+
+// SetUpdates reports what the given update checker found in server info
+func (h *InfoHandler) SetUpdates(checker *updates.Checker) {
+	h.updates = checker
+}
+
+// GetInfo returns the server version, enabled features, supported file types,
+// storage backend, request limits, API versions, maintenance state and available updates
+func (h *InfoHandler) GetInfo(c *gin.Context) {
+	info := ServerInfo{
+		Info:        version.Get(),
+		Features:    h.features.All(),
+		Storage:     StorageInfo{Backend: "filesystem", Database: "sqlite"},
+		Limits:      h.limits,
+		APIVersions: version.APIVersions,
+		Maintenance: h.maintenance.Get(),
+		Update:      h.updates.Status(),
+	}
+	for _, fileType := range models.FileTypeExtensions {
+		info.FileTypes = append(info.FileTypes, FileTypeInfo{Type: fileType.Type, Extensions: fileType.Extensions})
+	}
+	info.FileTypes = append(info.FileTypes, FileTypeInfo{Type: models.FileTypeREADME, Extensions: []string{}})
+
+	c.JSON(http.StatusOK, info)
+}
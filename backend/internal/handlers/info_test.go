@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/internal/version"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/updates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetInfo tests the server information used for client negotiation
+func TestGetInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	flags := features.New()
+	flags.Set(map[features.Flag]bool{features.Watcher: true})
+	router.GET("/api/info", NewInfoHandler(flags, Limits{MaxUploadBytes: MaxUploadSize, MaxHeaderBytes: 4096}).GetInfo)
+
+	w := sendJSON(router, "GET", "/api/info", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var info ServerInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to parse info: %v", err)
+	}
+
+	if info.Version != version.Version || info.GoVersion == "" {
+		t.Errorf("Expected build information, got %+v", info.Info)
+	}
+	if !info.Features[features.Watcher] {
+		t.Errorf("Expected the enabled features, got %v", info.Features)
+	}
+	if info.Limits.MaxUploadBytes != 1<<30 || info.Limits.MaxHeaderBytes != 4096 {
+		t.Errorf("Unexpected limits: %+v", info.Limits)
+	}
+	if info.Storage.Backend != "filesystem" || info.Storage.Database != "sqlite" {
+		t.Errorf("Unexpected storage: %+v", info.Storage)
+	}
+	if len(info.APIVersions) == 0 || info.APIVersions[len(info.APIVersions)-1] != version.CurrentAPI() {
+		t.Errorf("Expected the API versions, current last, got %v", info.APIVersions)
+	}
+
+	types := make(map[models.FileType][]string)
+	for _, fileType := range info.FileTypes {
+		types[fileType.Type] = fileType.Extensions
+	}
+	if len(types[models.FileTypeGCode]) != 2 || types[models.FileTypeSTL][0] != ".stl" {
+		t.Errorf("Expected the supported file types, got %+v", info.FileTypes)
+	}
+	if _, ok := types[models.FileTypeREADME]; !ok {
+		t.Error("Expected README files to be listed")
+	}
+}
+
+// TestGetInfoUpdate tests that the update check is only reported when enabled
+func TestGetInfoUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/info", NewInfoHandler(features.New(), Limits{}).GetInfo)
+	var raw map[string]json.RawMessage
+	json.Unmarshal(sendJSON(router, "GET", "/api/info", "").Body.Bytes(), &raw)
+	if _, ok := raw["update"]; ok {
+		t.Error("Expected no update status while the check is off")
+	}
+
+	handler := NewInfoHandler(features.New(), Limits{})
+	handler.SetUpdates(updates.New(updates.DefaultRepository, "v1.0.0"))
+	router = gin.New()
+	router.GET("/api/info", handler.GetInfo)
+	var info ServerInfo
+	json.Unmarshal(sendJSON(router, "GET", "/api/info", "").Body.Bytes(), &info)
+	if info.Update == nil || info.Update.Current != "v1.0.0" || info.Update.Available {
+		t.Errorf("Expected the unchecked update status, got %+v", info.Update)
+	}
+}
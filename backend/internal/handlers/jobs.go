@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 500
+)
+
+// JobsHandler handles background job queue HTTP requests
+type JobsHandler struct {
+	queue *jobs.Queue
+}
+
+// NewJobsHandler creates a new JobsHandler managing the given queue
+func NewJobsHandler(queue *jobs.Queue) *JobsHandler {
+	return &JobsHandler{
+		queue: queue,
+	}
+}
+
+// GetJobs returns queued jobs, most recent first, optionally filtered by ?status= and ?type=
+func (h *JobsHandler) GetJobs(c *gin.Context) {
+	h.listJobs(c, models.JobStatus(c.Query("status")))
+}
+
+// GetDeadJobs returns the dead letters: jobs that failed on every attempt
+func (h *JobsHandler) GetDeadJobs(c *gin.Context) {
+	h.listJobs(c, models.JobDead)
+}
+
+func (h *JobsHandler) listJobs(c *gin.Context, status models.JobStatus) {
+	limit := defaultJobLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxJobLimit)
+	}
+
+	query := requestDB(c).Order("created_at DESC, id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType := c.Query("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var queued []models.Job
+	if err := query.Find(&queued).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  queued,
+		"count": len(queued),
+	})
+}
+
+// GetJob returns a single job
+func (h *JobsHandler) GetJob(c *gin.Context) {
+	var job models.Job
+	if err := requestDB(c).First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob queues a dead or cancelled job again
+func (h *JobsHandler) RetryJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.queue.Retry(id)
+	if h.writeJobError(c, err, "Failed to retry job") {
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Job queued for retry",
+		"job":     job,
+	})
+}
+
+// CancelJob stops a pending job from running
+func (h *JobsHandler) CancelJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.queue.Cancel(id)
+	if h.writeJobError(c, err, "Failed to cancel job") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Job cancelled successfully",
+		"job":     job,
+	})
+}
+
+// DeleteJob removes a finished job
+func (h *JobsHandler) DeleteJob(c *gin.Context) {
+	id, ok := jobID(c)
+	if !ok {
+		return
+	}
+
+	if h.writeJobError(c, h.queue.Delete(id), "Failed to delete job") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job deleted successfully"})
+}
+
+// jobID parses the :id parameter, writing a 404 and reporting false when it is invalid
+func jobID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeJobError writes the response for a failed queue operation and reports
+// whether there was an error
+func (h *JobsHandler) writeJobError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	case errors.Is(err, jobs.ErrNotRetryable), errors.Is(err, jobs.ErrNotCancellable),
+		errors.Is(err, jobs.ErrNotFinished), errors.Is(err, jobs.ErrUnknownType):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestJobsHandler tests listing and managing queued jobs
+func TestJobsHandler(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Without started workers jobs stay where the test puts them
+	queue := jobs.New(db)
+	queue.Register("export", jobs.DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error { return nil })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewJobsHandler(queue)
+	router.GET("/api/jobs", handler.GetJobs)
+	router.GET("/api/jobs/dead", handler.GetDeadJobs)
+	router.GET("/api/jobs/:id", handler.GetJob)
+	router.POST("/api/jobs/:id/retry", handler.RetryJob)
+	router.POST("/api/jobs/:id/cancel", handler.CancelJob)
+	router.DELETE("/api/jobs/:id", handler.DeleteJob)
+
+	pending, _ := queue.Enqueue("export", map[string]uint{"project_id": 1})
+	dead := models.Job{Type: "export", Status: models.JobDead, Attempts: 3, MaxAttempts: 3, Error: "disk full", RunAt: time.Now()}
+	db.Create(&dead)
+
+	var list struct {
+		Jobs  []models.Job `json:"jobs"`
+		Count int          `json:"count"`
+	}
+	w := sendJSON(router, "GET", "/api/jobs?type=export", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK || list.Count != 2 {
+		t.Fatalf("Expected 2 jobs, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", "/api/jobs/dead", "")
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if list.Count != 1 || list.Jobs[0].ID != dead.ID || list.Jobs[0].Error != "disk full" {
+		t.Errorf("Expected the dead job, got %s", w.Body.String())
+	}
+
+	if w = sendJSON(router, "GET", "/api/jobs?limit=0", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", w.Code)
+	}
+	if w = sendJSON(router, "GET", fmt.Sprintf("/api/jobs/%d", pending.ID), ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if w = sendJSON(router, "GET", "/api/jobs/999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+
+	// Pending jobs cannot be retried or deleted, only cancelled
+	if w = sendJSON(router, "POST", fmt.Sprintf("/api/jobs/%d/retry", pending.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 retrying a pending job, got %d", w.Code)
+	}
+	if w = sendJSON(router, "DELETE", fmt.Sprintf("/api/jobs/%d", pending.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deleting a pending job, got %d", w.Code)
+	}
+	if w = sendJSON(router, "POST", fmt.Sprintf("/api/jobs/%d/cancel", pending.ID), ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 cancelling a pending job, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = sendJSON(router, "POST", fmt.Sprintf("/api/jobs/%d/cancel", dead.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 cancelling a dead job, got %d", w.Code)
+	}
+
+	w = sendJSON(router, "POST", fmt.Sprintf("/api/jobs/%d/retry", dead.ID), "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202 retrying a dead job, got %d: %s", w.Code, w.Body.String())
+	}
+	var retried models.Job
+	db.First(&retried, dead.ID)
+	if retried.Status != models.JobPending || retried.Attempts != 0 || retried.Error != "" {
+		t.Errorf("Expected the dead job to be pending again, got %+v", retried)
+	}
+	if w = sendJSON(router, "POST", "/api/jobs/999/retry", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 retrying a missing job, got %d", w.Code)
+	}
+
+	if w = sendJSON(router, "DELETE", fmt.Sprintf("/api/jobs/%d", pending.ID), ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 deleting a cancelled job, got %d", w.Code)
+	}
+	if w = sendJSON(router, "GET", fmt.Sprintf("/api/jobs/%d", pending.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected deleted job to be gone, got %d", w.Code)
+	}
+}
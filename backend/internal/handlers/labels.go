@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/label"
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetPublicURL sets the base URL of the web UI that project label QR codes link to
+func (h *ProjectsHandler) SetPublicURL(publicURL string) {
+	h.publicURL = strings.TrimSuffix(publicURL, "/")
+}
+
+// GetProjectLabel renders a printable label for a project's storage box, with
+// a QR code linking back to the project
+func (h *ProjectsHandler) GetProjectLabel(c *gin.Context) {
+	var project models.Project
+	if err := requestDB(c).Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	// Without a public URL the QR code points at the API the label was requested from
+	link := fmt.Sprintf("%s/api/projects/%d", requestBaseURL(c), project.ID)
+	if h.publicURL != "" {
+		link = fmt.Sprintf("%s/projects/%d", h.publicURL, project.ID)
+	}
+
+	summary := buildProjectSummary(project)
+	content := label.Label{Title: project.Name, QR: link}
+	if project.Designer != "" {
+		content.Lines = append(content.Lines, "by "+project.Designer)
+	}
+	if len(summary.PrintProfiles) > 0 {
+		content.Lines = append(content.Lines, printProfileLines(summary.PrintProfiles[0])...)
+	}
+	if len(summary.Tags) > 0 {
+		content.Lines = append(content.Lines, "#"+strings.Join(summary.Tags, " #"))
+	}
+	content.Lines = append(content.Lines, fmt.Sprintf("#%d · %d files", project.ID, summary.TotalFiles))
+
+	writeLabel(c, content, fmt.Sprintf("project-%d-label", project.ID))
+}
+
+// printProfileLines describes the slicer settings worth printing on a label
+func printProfileLines(profile PrintProfile) []string {
+	var lines []string
+	if profile.Printer != "" {
+		lines = append(lines, profile.Printer)
+	}
+
+	var settings []string
+	if profile.Material != "" {
+		settings = append(settings, profile.Material)
+	}
+	if profile.NozzleDiameter > 0 {
+		settings = append(settings, fmt.Sprintf("%gmm nozzle", profile.NozzleDiameter))
+	}
+	if profile.LayerHeight > 0 {
+		settings = append(settings, fmt.Sprintf("%gmm layers", profile.LayerHeight))
+	}
+	if len(settings) > 0 {
+		lines = append(lines, strings.Join(settings, " · "))
+	}
+	return lines
+}
+
+// writeLabel renders content with the ?size=, ?dpi= and ?format= query
+// parameters and writes it as a PNG or PDF named filename
+func writeLabel(c *gin.Context, content label.Label, filename string) {
+	size, err := label.ParseSize(c.Query("size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dpi := label.DefaultDPI
+	if raw := c.Query("dpi"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < label.MinDPI || parsed > label.MaxDPI {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid dpi (must be between %d and %d)", label.MinDPI, label.MaxDPI)})
+			return
+		}
+		dpi = parsed
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported label format: %s", format)})
+		return
+	}
+
+	img, err := label.Render(content, size, dpi)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render label"})
+		return
+	}
+
+	var out bytes.Buffer
+	contentType := "image/png"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = label.WritePDF(&out, img, size)
+	} else {
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render label"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s.%s\"", filename, format))
+	c.Data(http.StatusOK, contentType, out.Bytes())
+}
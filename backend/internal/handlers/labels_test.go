@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupLabelsRouter creates a router with the project and filament routes
+func setupLabelsRouter(publicURL string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	projects := NewProjectsHandler("/test")
+	projects.SetPublicURL(publicURL)
+	router.GET("/api/projects/:id/label", projects.GetProjectLabel)
+
+	filaments := NewFilamentsHandler()
+	router.GET("/api/filaments", filaments.GetFilaments)
+	router.POST("/api/filaments", filaments.CreateFilament)
+	router.GET("/api/filaments/:id", filaments.GetFilament)
+	router.PUT("/api/filaments/:id", filaments.UpdateFilament)
+	router.DELETE("/api/filaments/:id", filaments.DeleteFilament)
+	router.GET("/api/filaments/:id/label", filaments.GetFilamentLabel)
+	return router
+}
+
+// TestGetProjectLabel tests rendering project labels as PNG and PDF
+func TestGetProjectLabel(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupLabelsRouter("https://shelf.example.com/")
+
+	project := models.Project{Name: "Drawer Kit", Path: "/test/drawer", Designer: "Jane", Tags: []string{"storage"}}
+	db.Create(&project)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/projects/1/label?size=62x29&dpi=300", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected a PNG, got %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "project-1-label.png") {
+		t.Errorf("Unexpected Content-Disposition: %s", w.Header().Get("Content-Disposition"))
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to decode label: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 732 || size.Y != 343 {
+		t.Errorf("Expected 732x343 pixels, got %v", size)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/projects/1/label?format=pdf&size=dk-11202", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("Expected a PDF, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+		t.Error("Expected the body to be a PDF document")
+	}
+
+	testCases := map[string]int{
+		"/api/projects/99/label":           http.StatusNotFound,
+		"/api/projects/1/label?size=tiny":  http.StatusBadRequest,
+		"/api/projects/1/label?size=5x5":   http.StatusBadRequest,
+		"/api/projects/1/label?dpi=1200":   http.StatusBadRequest,
+		"/api/projects/1/label?dpi=high":   http.StatusBadRequest,
+		"/api/projects/1/label?format=svg": http.StatusBadRequest,
+	}
+	for path, expected := range testCases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d", path, expected, w.Code)
+		}
+	}
+}
+
+// TestPrintProfileLines tests the slicer settings printed on project labels
+func TestPrintProfileLines(t *testing.T) {
+	profile := PrintProfile{}
+	profile.Printer = "Prusa MK4"
+	profile.Material = "PETG"
+	profile.NozzleDiameter = 0.4
+	profile.LayerHeight = 0.2
+
+	lines := printProfileLines(profile)
+	if len(lines) != 2 || lines[0] != "Prusa MK4" || lines[1] != "PETG · 0.4mm nozzle · 0.2mm layers" {
+		t.Errorf("Unexpected lines: %q", lines)
+	}
+
+	if lines := printProfileLines(PrintProfile{}); len(lines) != 0 {
+		t.Errorf("Expected no lines without metadata, got %q", lines)
+	}
+}
+
+// TestFilaments tests managing filament spools and rendering their labels
+func TestFilaments(t *testing.T) {
+	setupTestDB(t)
+	router := setupLabelsRouter("")
+
+	w := sendJSON(router, "POST", "/api/filaments", `{"brand": "Prusament", "material": "PETG", "color": "Galaxy Black", "nozzle_temp_c": 250, "bed_temp_c": 85, "weight_grams": 1000, "remaining_grams": 640}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"name":"Prusament PETG Galaxy Black"`) || !strings.Contains(w.Body.String(), `"diameter_mm":1.75`) {
+		t.Errorf("Expected the name and diameter defaults, got %s", w.Body.String())
+	}
+
+	testCases := map[string]string{
+		"No name":            `{}`,
+		"Negative diameter":  `{"name": "PLA", "diameter_mm": -1}`,
+		"Negative weight":    `{"name": "PLA", "weight_grams": -1}`,
+		"Remaining too much": `{"name": "PLA", "weight_grams": 1000, "remaining_grams": 1200}`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/filaments", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	sendJSON(router, "POST", "/api/filaments", `{"name": "Basic PLA", "material": "pla"}`)
+	w = sendJSON(router, "GET", "/api/filaments?material=PLA", "")
+	if !strings.Contains(w.Body.String(), `"count":1`) || !strings.Contains(w.Body.String(), "Basic PLA") {
+		t.Errorf("Expected the material filter to match case-insensitively, got %s", w.Body.String())
+	}
+
+	w = sendJSON(router, "PUT", "/api/filaments/1", `{"name": "PETG Black", "material": "PETG", "remaining_grams": 200}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"remaining_grams":200`) {
+		t.Errorf("Expected the spool to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", "/api/filaments/1/label?format=pdf&size=dymo-11354", "")
+	if w.Code != http.StatusOK || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("Expected a PDF label, got %d", w.Code)
+	}
+	if w := sendJSON(router, "GET", "/api/filaments/9/label", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown spool, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/filaments/1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "GET", "/api/filaments/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after delete, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/linkcheck"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LinkIssueReport is a broken link with the name of its project
+type LinkIssueReport struct {
+	models.LinkIssue
+	ProjectName string `json:"project_name"`
+}
+
+// SetLinkChecker lets the reports API run link checks on demand
+func (h *AdminHandler) SetLinkChecker(checker *linkcheck.Checker) {
+	h.links = checker
+}
+
+// GetLinkIssuesReport lists the broken links found by the last link check,
+// by project, optionally only of a ?kind or ?project_id
+func (h *AdminHandler) GetLinkIssuesReport(c *gin.Context) {
+	query := requestDB(c).Model(&models.LinkIssue{}).
+		Select("link_issues.*, projects.name AS project_name").
+		Joins("JOIN projects ON projects.id = link_issues.project_id AND projects.deleted_at IS NULL").
+		Order("projects.name ASC, link_issues.kind ASC, link_issues.target ASC")
+
+	if kind := models.LinkIssueKind(c.Query("kind")); kind != "" {
+		if kind != models.LinkIssueSource && kind != models.LinkIssueMissingFile {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind", "details": fmt.Sprintf("kind must be %s or %s", models.LinkIssueSource, models.LinkIssueMissingFile)})
+			return
+		}
+		query = query.Where("link_issues.kind = ?", kind)
+	}
+	if raw := c.Query("project_id"); raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project_id"})
+			return
+		}
+		query = query.Where("link_issues.project_id = ?", projectID)
+	}
+
+	issues := []LinkIssueReport{}
+	if err := query.Scan(&issues).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch link issues"})
+		return
+	}
+	projects := make(map[uint]bool)
+	for _, issue := range issues {
+		projects[issue.ProjectID] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"issues":     issues,
+		"count":      len(issues),
+		"projects":   len(projects),
+		"last_check": h.links.Status(),
+	})
+}
+
+// CheckLinks runs a link check now rather than waiting for the next
+// scheduled one, flagging projects and notifying webhooks as scheduled checks do
+func (h *AdminHandler) CheckLinks(c *gin.Context) {
+	if h.links == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Link checks are not configured"})
+		return
+	}
+
+	result, err := h.links.Check(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Link check failed", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Link check completed",
+		"projects": result.Projects,
+		"issues":   len(result.Issues),
+		"new":      result.New,
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/linkcheck"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestLinkIssuesReport tests running a link check and listing the broken links it found
+func TestLinkIssuesReport(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewAdminHandler(nil)
+	router.GET("/api/reports/links", handler.GetLinkIssuesReport)
+	router.POST("/api/reports/links/check", handler.CheckLinks)
+
+	if w := sendJSON(router, "POST", "/api/reports/links/check", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without a checker, got %d", http.StatusBadRequest, w.Code)
+	}
+	handler.SetLinkChecker(linkcheck.New(db, jobs.New(db)))
+
+	project := models.Project{Name: "Benchy", Path: filepath.Join(tmpDir, "benchy")}
+	db.Create(&project)
+	os.MkdirAll(project.Path, 0755)
+	os.WriteFile(filepath.Join(project.Path, "README.md"), []byte("![Printed](photos/printed.jpg)"), 0644)
+
+	w := sendJSON(router, "POST", "/api/reports/links/check", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", "/api/reports/links", "")
+	var response struct {
+		Issues    []LinkIssueReport `json:"issues"`
+		Count     int               `json:"count"`
+		LastCheck *linkcheck.Status `json:"last_check"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 1 || response.Issues[0].ProjectName != "Benchy" || response.Issues[0].Target != "photos/printed.jpg" {
+		t.Fatalf("Expected the missing photo, got %+v", response.Issues)
+	}
+	if response.LastCheck == nil || response.LastCheck.CheckedAt == nil {
+		t.Errorf("Expected the last check reported, got %+v", response.LastCheck)
+	}
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/reports/links?kind=%s", models.LinkIssueSource), "")
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 0 {
+		t.Errorf("Expected no source issues, got %+v", response.Issues)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, query := range []string{"?kind=typo", "?project_id=one"} {
+			if w := sendJSON(router, "GET", "/api/reports/links"+query, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
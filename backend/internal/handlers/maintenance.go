@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/maintenance"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetMaintenance lets the admin API switch read-only maintenance mode
+func (h *AdminHandler) SetMaintenance(mode *maintenance.Mode) {
+	h.maintenance = mode
+}
+
+// GetMaintenance returns the current maintenance state
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenance.Get())
+}
+
+// UpdateMaintenance switches read-only maintenance mode on or off. The state
+// is persisted so maintenance survives a restart during a migration.
+func (h *AdminHandler) UpdateMaintenance(c *gin.Context) {
+	if h.maintenance == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Maintenance mode is not supported"})
+		return
+	}
+
+	var state maintenance.State
+	if err := c.ShouldBindJSON(&state); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := state.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := h.maintenance.Get()
+	applied, err := h.maintenance.Set(state)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.SaveSetting(requestDB(c), maintenance.SettingKey, applied); err != nil {
+		h.maintenance.Set(previous)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save maintenance mode"})
+		return
+	}
+
+	message := "Maintenance mode disabled"
+	if applied.Enabled {
+		message = "Maintenance mode enabled, changes are refused until it is disabled"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"maintenance": applied,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/middleware"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/maintenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMaintenanceMode tests switching read-only mode through the admin API
+func TestMaintenanceMode(t *testing.T) {
+	db := setupTestDB(t)
+	mode := maintenance.New()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ReadOnly(mode, map[string]bool{"PUT /api/admin/maintenance": true}))
+	adminHandler := NewAdminHandler(nil)
+	adminHandler.SetMaintenance(mode)
+	infoHandler := NewInfoHandler(features.New(), Limits{})
+	infoHandler.SetMaintenance(mode)
+	router.GET("/api/admin/maintenance", adminHandler.GetMaintenance)
+	router.PUT("/api/admin/maintenance", adminHandler.UpdateMaintenance)
+	router.GET("/api/info", infoHandler.GetInfo)
+	router.POST("/api/parts", NewPartsHandler().CreatePart)
+
+	w := sendJSON(router, "PUT", "/api/admin/maintenance", `{"enabled": true, "message": "Backing up", "retry_after_seconds": 600}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var saved maintenance.State
+	if found, _ := database.LoadSetting(db, maintenance.SettingKey, &saved); !found || !saved.Enabled || saved.Since == nil {
+		t.Errorf("Expected maintenance mode to be persisted, got %+v", saved)
+	}
+
+	var info ServerInfo
+	json.Unmarshal(sendJSON(router, "GET", "/api/info", "").Body.Bytes(), &info)
+	if !info.Maintenance.Enabled || info.Maintenance.Message != "Backing up" {
+		t.Errorf("Expected the banner in server info, got %+v", info.Maintenance)
+	}
+
+	w = sendJSON(router, "POST", "/api/parts", `{"name": "Hinge"}`)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "600" {
+		t.Errorf("Expected changes to be refused with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	if w := sendJSON(router, "PUT", "/api/admin/maintenance", `{"enabled": true, "retry_after_seconds": -5}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a negative estimate, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := sendJSON(router, "PUT", "/api/admin/maintenance", `{"enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var state maintenance.State
+	json.Unmarshal(sendJSON(router, "GET", "/api/admin/maintenance", "").Body.Bytes(), &state)
+	if state.Enabled || mode.Enabled() {
+		t.Errorf("Expected maintenance mode to be off, got %+v", state)
+	}
+	if w := sendJSON(router, "POST", "/api/parts", `{"name": "Hinge"}`); w.Code == http.StatusServiceUnavailable {
+		t.Error("Expected changes to be accepted once maintenance ends")
+	}
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/frontmatter"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metadataColumns are the columns of the metadata spreadsheet, in export order.
+// id identifies the project and is never changed.
+var metadataColumns = []string{"id", "name", "tags", "license", "designer", "rating"}
+
+// utf8BOM starts exported spreadsheets so Excel reads them as UTF-8
+const utf8BOM = "\ufeff"
+
+// MetadataRow is the editable metadata of a project. On import, fields left
+// out are left unchanged.
+type MetadataRow struct {
+	ID       uint      `json:"id"`
+	Name     *string   `json:"name,omitempty"`
+	Tags     *[]string `json:"tags,omitempty"`
+	License  *string   `json:"license,omitempty"`
+	Designer *string   `json:"designer,omitempty"`
+	Rating   *int      `json:"rating,omitempty"`
+}
+
+// MetadataRowStatus is the outcome of one imported row
+type MetadataRowStatus string
+
+const (
+	// MetadataUpdated is a row whose changes were applied, or would be in a dry run
+	MetadataUpdated MetadataRowStatus = "updated"
+	// MetadataUnchanged is a row matching the project's metadata
+	MetadataUnchanged MetadataRowStatus = "unchanged"
+	// MetadataFailed is a row rejected by validation or that could not be applied
+	MetadataFailed MetadataRowStatus = "failed"
+)
+
+// MetadataRowResult is the outcome of one imported row
+type MetadataRowResult struct {
+	// Row is the spreadsheet line, counting the header as 1, or the position
+	// in a JSON import starting at 1
+	Row    int               `json:"row"`
+	ID     uint              `json:"id,omitempty"`
+	Status MetadataRowStatus `json:"status"`
+
+	// Changed lists the columns that differed from the project's metadata
+	Changed []string `json:"changed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// MetadataImportRequest is the body of a JSON metadata import
+type MetadataImportRequest struct {
+	Projects []MetadataRow `json:"projects" binding:"required"`
+}
+
+// metadataEdit is a parsed row, or why it couldn't be parsed
+type metadataEdit struct {
+	row int
+	MetadataRow
+	err error
+}
+
+// ExportMetadataCSV returns the metadata of every project as a spreadsheet,
+// tags separated by commas like in README front matter
+func (h *ProjectsHandler) ExportMetadataCSV(c *gin.Context) {
+	rows, ok := loadMetadataRows(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+	w := csv.NewWriter(&buf)
+	w.Write(metadataColumns)
+	for _, row := range rows {
+		w.Write([]string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			*row.Name,
+			strings.Join(*row.Tags, ", "),
+			*row.License,
+			*row.Designer,
+			strconv.Itoa(*row.Rating),
+		})
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", `attachment; filename="3dshelf-metadata.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ExportMetadataJSON returns the metadata of every project
+func (h *ProjectsHandler) ExportMetadataJSON(c *gin.Context) {
+	rows, ok := loadMetadataRows(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projects": rows, "count": len(rows)})
+}
+
+// ImportMetadataCSV applies an edited metadata spreadsheet. The header names
+// the columns present, which must include id; the others may be left out to
+// keep them unchanged. Every row is validated and reported on its own, so a
+// bad row doesn't keep the others from being applied. ?dry_run=true only
+// reports what would change.
+func (h *ProjectsHandler) ImportMetadataCSV(c *gin.Context) {
+	edits, err := parseMetadataCSV(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV", "details": err.Error()})
+		return
+	}
+	h.importMetadata(c, edits)
+}
+
+// ImportMetadataJSON applies edited project metadata like ImportMetadataCSV,
+// with fields left out of a project kept unchanged
+func (h *ProjectsHandler) ImportMetadataJSON(c *gin.Context) {
+	var req MetadataImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	edits := make([]metadataEdit, len(req.Projects))
+	for i, row := range req.Projects {
+		edits[i] = metadataEdit{row: i + 1, MetadataRow: row}
+		if row.ID == 0 {
+			edits[i].err = errors.New("id is required")
+		}
+	}
+	h.importMetadata(c, edits)
+}
+
+// loadMetadataRows returns the metadata of every project by name, writing the
+// error response on failure
+func loadMetadataRows(c *gin.Context) ([]MetadataRow, bool) {
+	var projects []models.Project
+	if err := requestDB(c).Select("id", "name", "tags", "license", "designer", "rating").Order("name ASC, id ASC").Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return nil, false
+	}
+
+	rows := make([]MetadataRow, len(projects))
+	for i, project := range projects {
+		tags := project.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		rows[i] = MetadataRow{
+			ID:       project.ID,
+			Name:     &project.Name,
+			Tags:     &tags,
+			License:  &project.License,
+			Designer: &project.Designer,
+			Rating:   &project.Rating,
+		}
+	}
+	return rows, true
+}
+
+// parseMetadataCSV reads the rows of a metadata spreadsheet. Malformed CSV
+// and headers fail the whole import; bad cells only fail their row.
+func parseMetadataCSV(body io.Reader) ([]metadataEdit, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, utf8BOM)))
+		if !slices.Contains(metadataColumns, name) {
+			return nil, fmt.Errorf("unknown column %q (expected %s)", name, strings.Join(metadataColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("missing id column")
+	}
+
+	var edits []metadataEdit
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return edits, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+
+		// Spreadsheets keep rows that were emptied rather than deleted
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		edit := metadataEdit{row: line}
+		if len(record) != len(header) {
+			edit.err = fmt.Errorf("expected %d columns, got %d", len(header), len(record))
+		} else {
+			edit.MetadataRow, edit.err = parseMetadataRecord(record, columns)
+		}
+		edits = append(edits, edit)
+	}
+}
+
+// parseMetadataRecord reads the cells of one spreadsheet row
+func parseMetadataRecord(record []string, columns map[string]int) (MetadataRow, error) {
+	var row MetadataRow
+	cell := func(name string) (string, bool) {
+		i, ok := columns[name]
+		if !ok {
+			return "", false
+		}
+		return strings.TrimSpace(record[i]), true
+	}
+
+	raw, _ := cell("id")
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return row, fmt.Errorf("invalid id %q", raw)
+	}
+	row.ID = uint(id)
+
+	if name, ok := cell("name"); ok {
+		row.Name = &name
+	}
+	if raw, ok := cell("tags"); ok {
+		tags := []string{}
+		for _, tag := range strings.Split(raw, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		row.Tags = &tags
+	}
+	if license, ok := cell("license"); ok {
+		row.License = &license
+	}
+	if designer, ok := cell("designer"); ok {
+		row.Designer = &designer
+	}
+	if raw, ok := cell("rating"); ok {
+		rating := 0
+		if raw != "" {
+			if rating, err = strconv.Atoi(raw); err != nil {
+				return row, fmt.Errorf("invalid rating %q", raw)
+			}
+		}
+		row.Rating = &rating
+	}
+	return row, nil
+}
+
+// importMetadata validates and applies parsed rows, responding with the
+// outcome of each
+func (h *ProjectsHandler) importMetadata(c *gin.Context, edits []metadataEdit) {
+	dryRun := c.Query("dry_run") == "true"
+
+	ids := make([]uint, 0, len(edits))
+	for _, edit := range edits {
+		if edit.err == nil {
+			ids = append(ids, edit.ID)
+		}
+	}
+	var projects []models.Project
+	if err := requestDB(c).Where("id IN ?", ids).Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+	byID := make(map[uint]models.Project, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+	}
+
+	results := make([]MetadataRowResult, len(edits))
+	counts := map[MetadataRowStatus]int{MetadataUpdated: 0, MetadataUnchanged: 0, MetadataFailed: 0}
+	seen := make(map[uint]int, len(edits))
+	for i, edit := range edits {
+		result := MetadataRowResult{Row: edit.row, ID: edit.ID}
+		project, found := byID[edit.ID]
+		first, duplicate := seen[edit.ID]
+		switch {
+		case edit.err != nil:
+			result.Error = edit.err.Error()
+		case !found:
+			result.Error = "project not found"
+		case duplicate:
+			result.Error = fmt.Sprintf("project already edited on row %d", first)
+		default:
+			seen[edit.ID] = edit.row
+			if err := validateMetadataRow(edit.MetadataRow); err != nil {
+				result.Error = err.Error()
+				break
+			}
+			result.Changed = metadataChanges(project, edit.MetadataRow)
+			if len(result.Changed) > 0 && !dryRun {
+				if err := h.applyMetadataRow(c, project, edit.MetadataRow); err != nil {
+					result.Error = err.Error()
+				}
+			}
+		}
+
+		switch {
+		case result.Error != "":
+			result.Status = MetadataFailed
+			result.Changed = nil
+		case len(result.Changed) == 0:
+			result.Status = MetadataUnchanged
+		default:
+			result.Status = MetadataUpdated
+		}
+		counts[result.Status]++
+		results[i] = result
+	}
+
+	message := "Metadata imported"
+	if dryRun {
+		message = "Metadata checked, nothing was changed"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "dry_run": dryRun, "counts": counts, "rows": results})
+}
+
+// validateMetadataRow checks the values a row sets
+func validateMetadataRow(row MetadataRow) error {
+	if row.Name != nil && strings.TrimSpace(*row.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	if row.Rating != nil {
+		return models.ValidateRating(*row.Rating)
+	}
+	return nil
+}
+
+// metadataChanges lists the columns a row changes on a project
+func metadataChanges(project models.Project, row MetadataRow) []string {
+	var changed []string
+	if row.Name != nil && strings.TrimSpace(*row.Name) != project.Name {
+		changed = append(changed, "name")
+	}
+	if row.Tags != nil && !slices.Equal(*row.Tags, project.Tags) {
+		changed = append(changed, "tags")
+	}
+	if row.License != nil && strings.TrimSpace(*row.License) != project.License {
+		changed = append(changed, "license")
+	}
+	if row.Designer != nil && strings.TrimSpace(*row.Designer) != project.Designer {
+		changed = append(changed, "designer")
+	}
+	if row.Rating != nil && *row.Rating != project.Rating {
+		changed = append(changed, "rating")
+	}
+	return changed
+}
+
+// applyMetadataRow saves a row's changes to a project. A README with front
+// matter is rewritten too, as scans read the metadata back from it. Renamed
+// projects keep their directory; PUT /api/projects/:id moves it.
+func (h *ProjectsHandler) applyMetadataRow(c *gin.Context, project models.Project, row MetadataRow) error {
+	unlock, err := h.locks.lock(project.ID)
+	if err != nil {
+		return errors.New("project is being modified by another operation, try again later")
+	}
+	defer unlock()
+
+	if row.Name != nil && strings.TrimSpace(*row.Name) != project.Name {
+		project.Name = strings.TrimSpace(*row.Name)
+		if project.Slug, err = models.UniqueSlug(requestDB(c), project.Name, project.ID); err != nil {
+			return errors.New("failed to update slug")
+		}
+	}
+	if row.Tags != nil {
+		project.Tags = *row.Tags
+	}
+	if row.License != nil {
+		project.License = strings.TrimSpace(*row.License)
+	}
+	if row.Designer != nil {
+		project.Designer = strings.TrimSpace(*row.Designer)
+	}
+	if row.Rating != nil {
+		project.Rating = *row.Rating
+	}
+
+	if !project.IsFlat() {
+		if err := h.rewriteFrontMatter(c, &project); err != nil {
+			fmt.Printf("Warning: Failed to rewrite README front matter for project %d: %v\n", project.ID, err)
+			return errors.New("failed to write README")
+		}
+	}
+
+	project.UpdatedAt = time.Now()
+	err = requestDB(c).Model(&project).
+		Select("name", "slug", "tags", "license", "designer", "rating", "updated_at").
+		Updates(&project).Error
+	if err != nil {
+		return errors.New("failed to update project")
+	}
+
+	if err := h.scanner.RefreshSidecar(&project); err != nil {
+		fmt.Printf("Warning: Failed to write sidecar for project %d: %v\n", project.ID, err)
+	}
+	return nil
+}
+
+// rewriteFrontMatter writes the project's metadata to the front matter of its
+// README, keeping the body and keys not managed. READMEs without front matter
+// are left as they are.
+func (h *ProjectsHandler) rewriteFrontMatter(c *gin.Context, project *models.Project) error {
+	readmePath := filepath.Join(project.Path, "README.md")
+	content, err := os.ReadFile(readmePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, _, found := frontmatter.Split(content); !found {
+		return nil
+	}
+
+	current, body, err := frontmatter.Parse(content)
+	if err != nil {
+		return err
+	}
+	meta := projectMetadata(project)
+	meta.Extra = current.Extra
+	updated, err := frontmatter.Render(meta, body)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(updated, content) {
+		return nil
+	}
+	if err := writeFileAtomic(readmePath, updated); err != nil {
+		return err
+	}
+	if err := h.recordREADME(c, project, readmePath, updated); err != nil {
+		fmt.Printf("Warning: Failed to record README file for project %d: %v\n", project.ID, err)
+	}
+	return nil
+}
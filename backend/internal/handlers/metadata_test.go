@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMetadataCSV tests exporting project metadata as a spreadsheet and importing edited rows
+func TestMetadataCSV(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/admin/metadata.csv", handler.ExportMetadataCSV)
+	router.PUT("/api/admin/metadata.csv", handler.ImportMetadataCSV)
+	router.GET("/api/admin/metadata.json", handler.ExportMetadataJSON)
+	router.PUT("/api/admin/metadata.json", handler.ImportMetadataJSON)
+
+	benchy := models.Project{Name: "Benchy", Path: filepath.Join(tmpDir, "Benchy"), Tags: []string{"boat", "test"}, License: "CC-BY", Rating: 4}
+	db.Create(&benchy)
+	os.MkdirAll(benchy.Path, 0755)
+	os.WriteFile(filepath.Join(benchy.Path, "README.md"), []byte("---\ntags: [boat, test]\nlicense: CC-BY\nprinted: true\n---\n\n# Benchy\n"), 0644)
+	vase := models.Project{Name: "Vase", Path: filepath.Join(tmpDir, "Vase"), Designer: "Jo"}
+	db.Create(&vase)
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	type importResponse struct {
+		Counts map[MetadataRowStatus]int `json:"counts"`
+		Rows   []MetadataRowResult       `json:"rows"`
+	}
+
+	t.Run("Export", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/admin/metadata.csv", "")
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("Expected a CSV, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), utf8BOM))).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse export: %v", err)
+		}
+		expected := [][]string{
+			metadataColumns,
+			{fmt.Sprint(benchy.ID), "Benchy", "boat, test", "CC-BY", "", "4"},
+			{fmt.Sprint(vase.ID), "Vase", "", "", "Jo", "0"},
+		}
+		if !reflect.DeepEqual(records, expected) {
+			t.Errorf("Expected %v, got %v", expected, records)
+		}
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		body := utf8BOM + "ID,Name,Tags,License,Rating\n" +
+			fmt.Sprintf("%d,Benchy,\"boat, calibration\",CC-BY-SA,5\n", benchy.ID) +
+			fmt.Sprintf("%d,Vase,,,0\n", vase.ID) +
+			",,,,\n" +
+			fmt.Sprintf("%d,Vase,,,9\n", vase.ID) +
+			"999,Ghost,,,1\n" +
+			"x,Typo,,,1\n"
+		w := put("/api/admin/metadata.csv", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response importResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Counts[MetadataUpdated] != 1 || response.Counts[MetadataUnchanged] != 1 || response.Counts[MetadataFailed] != 3 {
+			t.Fatalf("Expected one updated, one unchanged and three failed rows, got %+v", response.Rows)
+		}
+		updated := response.Rows[0]
+		if updated.Row != 2 || !reflect.DeepEqual(updated.Changed, []string{"tags", "license", "rating"}) {
+			t.Errorf("Expected the first row's changes, got %+v", updated)
+		}
+		// The emptied row is skipped, the rest are reported by line
+		for i, line := range []int{5, 6, 7} {
+			if row := response.Rows[2+i]; row.Row != line || row.Status != MetadataFailed || row.Error == "" {
+				t.Errorf("Expected line %d to fail, got %+v", line, row)
+			}
+		}
+
+		db.First(&benchy, benchy.ID)
+		if !reflect.DeepEqual(benchy.Tags, []string{"boat", "calibration"}) || benchy.License != "CC-BY-SA" || benchy.Rating != 5 {
+			t.Errorf("Expected the project updated, got %v %q %d", benchy.Tags, benchy.License, benchy.Rating)
+		}
+		// Scans read the metadata back from the README, which keeps its other keys and body
+		content, _ := os.ReadFile(filepath.Join(benchy.Path, "README.md"))
+		if !strings.Contains(string(content), "calibration") || !strings.Contains(string(content), "CC-BY-SA") ||
+			!strings.Contains(string(content), "printed: true") || !strings.Contains(string(content), "# Benchy") {
+			t.Errorf("Expected the README front matter rewritten, got %s", content)
+		}
+		db.First(&vase, vase.ID)
+		if vase.Designer != "Jo" {
+			t.Errorf("Expected the column left out kept, got %q", vase.Designer)
+		}
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		w := put("/api/admin/metadata.csv?dry_run=true", fmt.Sprintf("id,name\n%d,Flower vase\n", vase.ID))
+		var response importResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Counts[MetadataUpdated] != 1 {
+			t.Fatalf("Expected the rename reported, got %+v", response.Rows)
+		}
+		db.First(&vase, vase.ID)
+		if vase.Name != "Vase" {
+			t.Errorf("Expected nothing changed by a dry run, got %q", vase.Name)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		w := put("/api/admin/metadata.json", fmt.Sprintf(`{"projects": [{"id": %d, "name": "Flower vase", "rating": 3}]}`, vase.ID))
+		var response importResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Counts[MetadataUpdated] != 1 {
+			t.Fatalf("Expected the project updated, got %+v", response.Rows)
+		}
+		db.First(&vase, vase.ID)
+		if vase.Name != "Flower vase" || vase.Slug != "flower-vase" || vase.Rating != 3 || vase.Designer != "Jo" {
+			t.Errorf("Expected the name, slug and rating changed, got %q %q %d %q", vase.Name, vase.Slug, vase.Rating, vase.Designer)
+		}
+
+		w = sendJSON(router, "GET", "/api/admin/metadata.json", "")
+		var export struct {
+			Projects []MetadataRow `json:"projects"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &export)
+		if len(export.Projects) != 2 || *export.Projects[1].Name != "Flower vase" {
+			t.Errorf("Expected both projects by name, got %+v", export.Projects)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"Empty":          "",
+			"No id column":   "name,tags\nBenchy,boat\n",
+			"Unknown column": "id,stars\n1,5\n",
+			"Bad quoting":    "id,name\n1,\"Benchy\n",
+		} {
+			if w := put("/api/admin/metadata.csv", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
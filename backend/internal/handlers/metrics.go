@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"3dshelf/pkg/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler exposes runtime metrics for self-hosted diagnostics
+type MetricsHandler struct {
+	queryStats *database.QueryStats
+}
+
+// NewMetricsHandler creates a new MetricsHandler
+func NewMetricsHandler(queryStats *database.QueryStats) *MetricsHandler {
+	return &MetricsHandler{
+		queryStats: queryStats,
+	}
+}
+
+// GetMetrics returns aggregate runtime metrics
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	metrics := gin.H{}
+
+	if h.queryStats != nil {
+		metrics["database"] = h.queryStats.Snapshot()
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"3dshelf/internal/middleware"
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetMetrics tests that database query stats are exposed per route
+func TestGetMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	createTestData(t, db)
+
+	queryStats, err := database.Instrument(db, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("Failed to instrument database: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RouteContext())
+	projectsHandler := NewProjectsHandler(t.TempDir())
+	metricsHandler := NewMetricsHandler(queryStats)
+	router.GET("/api/projects", projectsHandler.GetProjects)
+	router.GET("/api/metrics", metricsHandler.GetMetrics)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/projects", nil)
+	router.ServeHTTP(w, req)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/metrics", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Database database.QuerySnapshot `json:"database"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	query, exists := response.Database.Operations["query"]
+	if !exists || query.Count == 0 {
+		t.Fatalf("Expected query operations to be recorded, got %+v", response.Database.Operations)
+	}
+
+	if len(response.Database.RecentSlow) == 0 || response.Database.RecentSlow[0].Route != "GET /api/projects" {
+		t.Errorf("Expected slow queries attributed to 'GET /api/projects', got %+v", response.Database.RecentSlow)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxUploadFiles is how many files one upload request may carry unless configured
+const DefaultMaxUploadFiles = 1000
+
+// multipartPartsPerFile and multipartExtraParts size the multipart part limit
+// for a file count: folder uploads send a path with each file, and forms carry
+// a few fields besides
+const (
+	multipartPartsPerFile = 2
+	multipartExtraParts   = 64
+)
+
+// SetMultipartParts lets multipart forms hold maxFiles files. Go refuses
+// forms of more than 1000 parts unless GODEBUG says otherwise, which would cut
+// folder uploads off at 500 files whatever the limit.
+func SetMultipartParts(maxFiles int) error {
+	setting := fmt.Sprintf("multipartmaxparts=%d", maxFiles*multipartPartsPerFile+multipartExtraParts)
+
+	var settings []string
+	for _, s := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		// A setting given by the administrator is kept
+		if strings.HasPrefix(s, "multipartmaxparts=") {
+			return nil
+		}
+		if s != "" {
+			settings = append(settings, s)
+		}
+	}
+	return os.Setenv("GODEBUG", strings.Join(append(settings, setting), ","))
+}
+
+// rejectTooManyFiles answers 413 when an upload carries more than maxFiles
+// files, reporting whether it did; 0 allows any number
+func rejectTooManyFiles(c *gin.Context, files []*multipart.FileHeader, maxFiles int) bool {
+	if maxFiles <= 0 || len(files) <= maxFiles {
+		return false
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "Too many files in one upload",
+		"max_files": maxFiles,
+		"received":  len(files),
+	})
+	return true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSetMultipartParts tests raising Go's multipart part limit for the file limit
+func TestSetMultipartParts(t *testing.T) {
+	t.Setenv("GODEBUG", "http2client=0")
+	if err := SetMultipartParts(500); err != nil {
+		t.Fatalf("Failed to set multipart parts: %v", err)
+	}
+	if got := os.Getenv("GODEBUG"); got != "http2client=0,multipartmaxparts=1064" {
+		t.Errorf("Expected the part limit added to GODEBUG, got %q", got)
+	}
+
+	t.Setenv("GODEBUG", "multipartmaxparts=50")
+	SetMultipartParts(500)
+	if got := os.Getenv("GODEBUG"); got != "multipartmaxparts=50" {
+		t.Errorf("Expected a configured part limit to be kept, got %q", got)
+	}
+}
+
+// TestUploadMaxFiles tests that uploads with too many files are refused whole
+func TestUploadMaxFiles(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	handler.SetMaxUploadFiles(2)
+	router.POST("/api/projects/upload", handler.CreateProjectFromUpload)
+
+	files := map[string][]byte{"a.stl": []byte("solid"), "b.stl": []byte("solid"), "c.stl": []byte("solid")}
+	w := uploadNewProject(router, files, nil, map[string]string{"name": "Parts"})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Model(&models.Project{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no project created, got %d", count)
+	}
+
+	delete(files, "c.stl")
+	if w := uploadNewProject(router, files, nil, map[string]string{"name": "Parts"}); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d at the limit, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/pairing"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// ndjsonContentType is asked for in Accept to stream a listing as one
+	// JSON record per line
+	ndjsonContentType = "application/x-ndjson"
+
+	// ndjsonBatchSize is how many records are read from the database at a
+	// time while streaming
+	ndjsonBatchSize = 500
+)
+
+// wantsNDJSON reports whether the client prefers newline-delimited JSON to a
+// JSON document
+func wantsNDJSON(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, ndjsonContentType) == ndjsonContentType
+}
+
+// streamNDJSON writes the records query finds as newline-delimited JSON,
+// reading them from the database ndjsonBatchSize at a time so listings of
+// any size are never held in memory whole. prepare, when set, fills in each
+// batch before it is written. Once records are sent the status can't change,
+// so a failure partway ends the stream with an {"error": ...} line.
+func streamNDJSON[T any](c *gin.Context, query *gorm.DB, prepare func([]T) error) {
+	encoder := json.NewEncoder(c.Writer)
+
+	var batch []T
+	err := query.FindInBatches(&batch, ndjsonBatchSize, func(tx *gorm.DB, _ int) error {
+		if prepare != nil {
+			if err := prepare(batch); err != nil {
+				return err
+			}
+		}
+		c.Header("Content-Type", ndjsonContentType)
+		for _, record := range batch {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+
+	switch {
+	case err != nil && !c.Writer.Written():
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list records", "details": err.Error()})
+	case err != nil:
+		encoder.Encode(gin.H{"error": "Listing ended early", "details": err.Error()})
+	case !c.Writer.Written():
+		c.Header("Content-Type", ndjsonContentType)
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// streamProjectFiles streams a project's files as newline-delimited JSON.
+// Pairing G-code with its models needs every file of the project, so it is
+// worked out first from just their names.
+func (h *ProjectsHandler) streamProjectFiles(c *gin.Context, projectID string, fileIDs []uint) {
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	var names []models.ProjectFile
+	if err := requestDB(c).Select("id", "project_id", "filename", "filepath", "file_type").
+		Where("project_id = ?", projectID).Find(&names).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project files"})
+		return
+	}
+	pairing.Pair(names)
+	paired := make(map[uint]models.ProjectFile, len(names))
+	for _, file := range names {
+		paired[file.ID] = file
+	}
+
+	var profiles map[string]models.FileProfile
+	if len(names) > 0 {
+		var err error
+		if profiles, err = loadFileProfiles(requestDB(c), names[0].ProjectID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profiles"})
+			return
+		}
+	}
+
+	query := requestDB(c).Where("project_id = ?", projectID)
+	if fileIDs != nil {
+		query = query.Where("id IN ?", fileIDs)
+	}
+	streamNDJSON(c, query, func(files []models.ProjectFile) error {
+		for i := range files {
+			files[i].SourceModels = paired[files[i].ID].SourceModels
+			files[i].SlicedVariants = paired[files[i].ID].SlicedVariants
+			if profile, ok := profiles[files[i].Filename]; ok {
+				profile.Display = fileProfileDisplay(prefs, profile)
+				files[i].Profile = &profile
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getNDJSON requests path accepting newline-delimited JSON and decodes each line
+func getNDJSON[T any](t *testing.T, router *gin.Engine, path string) (*httptest.ResponseRecorder, []T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", ndjsonContentType)
+	router.ServeHTTP(w, req)
+
+	var records []T
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to parse line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return w, records
+}
+
+// TestGetProjectsNDJSON tests streaming projects one per line
+func TestGetProjectsNDJSON(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects", handler.GetProjects)
+
+	w, records := getNDJSON[models.Project](t, router, "/api/projects")
+	if w.Code != http.StatusOK || len(records) != 0 || w.Header().Get("Content-Type") != ndjsonContentType {
+		t.Fatalf("Expected an empty stream, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	// More projects than one batch holds
+	projects := make([]models.Project, ndjsonBatchSize+20)
+	for i := range projects {
+		projects[i] = models.Project{Name: fmt.Sprintf("Project %d", i), Path: fmt.Sprintf("%s/p%d", tmpDir, i), Slug: fmt.Sprintf("project-%d", i)}
+	}
+	db.CreateInBatches(projects, 100)
+	db.Create(&models.ProjectFile{ProjectID: projects[0].ID, Filename: "gear.stl", Filepath: "gear.stl", FileType: models.FileTypeSTL, Size: 42})
+
+	w, records = getNDJSON[models.Project](t, router, "/api/projects?fields=summary")
+	if w.Code != http.StatusOK || len(records) != len(projects) {
+		t.Fatalf("Expected %d projects, got %d (status %d)", len(projects), len(records), w.Code)
+	}
+	if records[0].Name != "Project 0" || records[0].FileCount != 1 || records[0].TotalSize != 42 {
+		t.Errorf("Expected file aggregates on streamed projects, got %+v", records[0])
+	}
+
+	// Plain JSON is still the default
+	w = sendJSON(router, "GET", "/api/projects", "")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected a JSON document by default, got %q", w.Header().Get("Content-Type"))
+	}
+}
+
+// TestGetProjectFilesNDJSON tests streaming files with their G-code pairings
+func TestGetProjectFilesNDJSON(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/files", handler.GetProjectFiles)
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "gear.stl", Filepath: tmpDir + "/gear.stl", FileType: models.FileTypeSTL})
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "gear_0.2mm_PLA.gcode", Filepath: tmpDir + "/gear_0.2mm_PLA.gcode", FileType: models.FileTypeGCode})
+
+	w, files := getNDJSON[models.ProjectFile](t, router, fmt.Sprintf("/api/projects/%d/files", project.ID))
+	if w.Code != http.StatusOK || len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d (status %d): %s", len(files), w.Code, w.Body.String())
+	}
+	if len(files[0].SlicedVariants) != 1 || len(files[1].SourceModels) != 1 || files[1].SourceModels[0] != files[0].ID {
+		t.Errorf("Expected the G-code paired with its model, got %+v", files)
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// versionSuffixPattern matches version and copy markers at the end of a file
+// stem, like bracket_v2, bracket-v1.3, bracket_rev4, bracket_final or bracket (1)
+var versionSuffixPattern = regexp.MustCompile(`(?i)(?:[ _\-.]+(?:v\d+(?:\.\d+)*|rev\d+|final|copy)|\s*\(\d+\))$`)
+
+// whitespacePattern matches runs of whitespace replaced by ReplaceSpaces
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// NormalizeFilesRequest is the body accepted by NormalizeFiles. Only file base
+// names change; folders keep their names.
+type NormalizeFilesRequest struct {
+	Lowercase bool `json:"lowercase"`
+
+	// ReplaceSpaces replaces each run of whitespace: "_" or "-", or "" to keep it
+	ReplaceSpaces string `json:"replace_spaces"`
+
+	// StripVersions removes version and copy suffixes from names
+	StripVersions bool `json:"strip_versions"`
+
+	// Prefix is added to names that don't start with it
+	Prefix string `json:"prefix"`
+
+	// FileIDs limits the rename to some files; every file when empty
+	FileIDs []uint `json:"file_ids"`
+
+	// DryRun previews the renames without changing anything
+	DryRun bool `json:"dry_run"`
+}
+
+// FileRename is one file renamed by NormalizeFiles
+type FileRename struct {
+	FileID uint   `json:"file_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+
+	// Conflict explains why the file can't take its new name
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// validate checks the request has a rule to apply and usable values
+func (r *NormalizeFilesRequest) validate() error {
+	r.Prefix = strings.TrimSpace(r.Prefix)
+	switch r.ReplaceSpaces {
+	case "", "_", "-":
+	default:
+		return fmt.Errorf("replace_spaces must be \"_\" or \"-\"")
+	}
+	if strings.ContainsAny(r.Prefix, `/\`) || strings.HasPrefix(r.Prefix, ".") {
+		return fmt.Errorf("prefix must be a plain name")
+	}
+	if !r.Lowercase && r.ReplaceSpaces == "" && !r.StripVersions && r.Prefix == "" {
+		return fmt.Errorf("no rename rules given")
+	}
+	return nil
+}
+
+// apply returns the normalized form of a file base name
+func (r *NormalizeFilesRequest) apply(name string) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	if r.StripVersions {
+		for {
+			stripped := versionSuffixPattern.ReplaceAllString(stem, "")
+			if stripped == stem || stripped == "" {
+				break
+			}
+			stem = stripped
+		}
+	}
+	if r.ReplaceSpaces != "" {
+		stem = whitespacePattern.ReplaceAllString(strings.TrimSpace(stem), r.ReplaceSpaces)
+	}
+
+	prefix := r.Prefix
+	if r.Lowercase {
+		stem, ext, prefix = strings.ToLower(stem), strings.ToLower(ext), strings.ToLower(prefix)
+	}
+	if prefix != "" && !strings.HasPrefix(stem, prefix) {
+		stem = prefix + stem
+	}
+	return stem + ext
+}
+
+// NormalizeFiles renames a project's files by rules such as lowercasing,
+// replacing spaces, stripping version suffixes and adding a prefix. The
+// renames are previewed with dry_run; otherwise every file is renamed on disk
+// and in the database, or none is when any new name is taken or a file is the
+// canonical copy of deduplicated files.
+func (h *ProjectsHandler) NormalizeFiles(c *gin.Context) {
+	db := requestDB(c)
+
+	var req NormalizeFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if err := db.Preload("Files").First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
+
+	if !req.DryRun {
+		unlock, ok := h.lockProject(c, project.ID)
+		if !ok {
+			return
+		}
+		defer unlock()
+	}
+
+	renames, files := planRenames(project, &req)
+	if err := markCanonicalCopies(db, renames); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check deduplicated files"})
+		return
+	}
+	conflicts := 0
+	for _, rename := range renames {
+		if rename.Conflict != "" {
+			conflicts++
+		}
+	}
+
+	switch {
+	case req.DryRun:
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":   true,
+			"renames":   renames,
+			"count":     len(renames),
+			"conflicts": conflicts,
+		})
+		return
+	case conflicts > 0:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Some files can't take their new names, nothing was renamed",
+			"renames":   renames,
+			"conflicts": conflicts,
+		})
+		return
+	}
+
+	if err := renameProjectFiles(db, project, renames, files); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename files", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Renamed %d file(s)", len(renames)),
+		"renames": renames,
+		"count":   len(renames),
+	})
+}
+
+// planRenames works out the new name of each selected file whose name the
+// rules change, marking names that are taken on disk or by another file of
+// the batch. It returns the renames and the files they apply to, by ID.
+func planRenames(project models.Project, req *NormalizeFilesRequest) ([]FileRename, map[uint]models.ProjectFile) {
+	selected := make(map[uint]bool, len(req.FileIDs))
+	for _, id := range req.FileIDs {
+		selected[id] = true
+	}
+
+	renames := []FileRename{}
+	files := make(map[uint]models.ProjectFile)
+	var sources []os.FileInfo
+	for _, file := range project.Files {
+		if file.FileType == models.FileTypeREADME || (len(selected) > 0 && !selected[file.ID]) {
+			continue
+		}
+		name := req.apply(path.Base(file.Filename))
+		if name == path.Base(file.Filename) {
+			continue
+		}
+
+		renames = append(renames, FileRename{FileID: file.ID, From: file.Filename, To: path.Join(path.Dir(file.Filename), name)})
+		files[file.ID] = file
+		if info, err := os.Lstat(file.Filepath); err == nil {
+			sources = append(sources, info)
+		}
+	}
+
+	// Names are compared case-insensitively, as the library may be on a
+	// filesystem that does
+	targets := make(map[string]int, len(renames))
+	for _, rename := range renames {
+		targets[strings.ToLower(rename.To)]++
+	}
+	for i, rename := range renames {
+		if targets[strings.ToLower(rename.To)] > 1 {
+			renames[i].Conflict = "Another file gets the same name"
+			continue
+		}
+		// A name taken by a file that is itself being renamed is free by the time it's used
+		info, err := os.Lstat(filepath.Join(project.Path, filepath.FromSlash(rename.To)))
+		if err != nil {
+			continue
+		}
+		free := false
+		for _, source := range sources {
+			free = free || os.SameFile(info, source)
+		}
+		if !free {
+			renames[i].Conflict = "A file with this name already exists"
+		}
+	}
+	return renames, files
+}
+
+// markCanonicalCopies marks the renames of files others were deduplicated
+// against as conflicts, as duplicates that are symlinks would break
+func markCanonicalCopies(db *gorm.DB, renames []FileRename) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(renames))
+	for _, rename := range renames {
+		ids = append(ids, rename.FileID)
+	}
+	var canonicalIDs []uint
+	if err := db.Model(&models.ProjectFile{}).Where("duplicate_of IN ?", ids).Distinct().Pluck("duplicate_of", &canonicalIDs).Error; err != nil {
+		return err
+	}
+	for i := range renames {
+		if renames[i].Conflict == "" && slices.Contains(canonicalIDs, renames[i].FileID) {
+			renames[i].Conflict = "Deduplicated files link to this file"
+		}
+	}
+	return nil
+}
+
+// renameProjectFiles renames files on disk, through temporary names so swaps
+// and case-only renames work, then updates their records and the print
+// profiles, assemblies and cover that refer to them by name. Renames are
+// undone when any step fails.
+func renameProjectFiles(db *gorm.DB, project models.Project, renames []FileRename, files map[uint]models.ProjectFile) error {
+	token, err := newSessionToken()
+	if err != nil {
+		return err
+	}
+
+	type move struct{ from, to string }
+	var done []move
+	undo := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			os.Rename(done[i].to, done[i].from)
+		}
+	}
+
+	// Temporary names are hidden, like staging directories, so scans skip them
+	tempNames := make([]string, len(renames))
+	temps := make([]string, len(renames))
+	for i, rename := range renames {
+		source := files[rename.FileID].Filepath
+		tempNames[i] = fmt.Sprintf(".normalize-%s-%d", token, i)
+		temps[i] = filepath.Join(filepath.Dir(source), tempNames[i])
+		if err := os.Rename(source, temps[i]); err != nil {
+			undo()
+			return fmt.Errorf("failed to rename %s: %v", rename.From, err)
+		}
+		done = append(done, move{source, temps[i]})
+	}
+	for i, rename := range renames {
+		dest := filepath.Join(project.Path, filepath.FromSlash(rename.To))
+		if err := os.Rename(temps[i], dest); err != nil {
+			undo()
+			return fmt.Errorf("failed to rename %s: %v", rename.From, err)
+		}
+		done = append(done, move{temps[i], dest})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, rename := range renames {
+			dest := filepath.Join(project.Path, filepath.FromSlash(rename.To))
+			if err := tx.Model(&models.ProjectFile{}).Where("id = ?", rename.FileID).
+				Updates(map[string]interface{}{"filename": rename.To, "filepath": dest}).Error; err != nil {
+				return err
+			}
+			// Profiles and activity are unique by filename, so they pass through temporary names too
+			for _, keyed := range models.FilenameKeyed {
+				if err := tx.Model(keyed).Where("project_id = ? AND filename = ?", project.ID, rename.From).
+					Update("filename", tempNames[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for i, rename := range renames {
+			for _, keyed := range models.FilenameKeyed {
+				if err := tx.Model(keyed).Where("project_id = ? AND filename = ?", project.ID, tempNames[i]).
+					Update("filename", rename.To).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := renameCover(tx, project, renames); err != nil {
+			return err
+		}
+		return renameAssemblyParts(tx, project.ID, renames)
+	})
+	if err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+// renameAssemblyParts points assembly parts at the new names of renamed files
+func renameAssemblyParts(tx *gorm.DB, projectID uint, renames []FileRename) error {
+	newNames := make(map[string]string, len(renames))
+	for _, rename := range renames {
+		newNames[rename.From] = rename.To
+	}
+
+	var assemblies []models.Assembly
+	if err := tx.Where("project_id = ?", projectID).Find(&assemblies).Error; err != nil {
+		return err
+	}
+	for _, assembly := range assemblies {
+		changed := false
+		for i, part := range assembly.Parts {
+			if to, ok := newNames[part.Filename]; ok {
+				assembly.Parts[i].Filename = to
+				changed = true
+			}
+		}
+		if changed {
+			if err := tx.Save(&assembly).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renameCover keeps a renamed file the project's cover
+func renameCover(tx *gorm.DB, project models.Project, renames []FileRename) error {
+	for _, rename := range renames {
+		if project.CoverFilename != "" && rename.From == project.CoverFilename {
+			return tx.Model(&models.Project{}).Where("id = ?", project.ID).Update("cover_filename", rename.To).Error
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestNormalizeName tests the rename rules on single names
+func TestNormalizeName(t *testing.T) {
+	testCases := []struct {
+		req      NormalizeFilesRequest
+		name     string
+		expected string
+	}{
+		{NormalizeFilesRequest{Lowercase: true}, "Bracket.STL", "bracket.stl"},
+		{NormalizeFilesRequest{ReplaceSpaces: "_"}, "Arm  Mount left.stl", "Arm_Mount_left.stl"},
+		{NormalizeFilesRequest{StripVersions: true}, "bracket_v2.stl", "bracket.stl"},
+		{NormalizeFilesRequest{StripVersions: true}, "bracket-v1.3_final (1).stl", "bracket.stl"},
+		{NormalizeFilesRequest{StripVersions: true}, "v2.stl", "v2.stl"},
+		{NormalizeFilesRequest{StripVersions: true}, "m3_rev4.gcode", "m3.gcode"},
+		{NormalizeFilesRequest{Prefix: "Table_", Lowercase: true}, "Leg.stl", "table_leg.stl"},
+		{NormalizeFilesRequest{Prefix: "table_"}, "table_top.stl", "table_top.stl"},
+	}
+	for _, tc := range testCases {
+		if got := tc.req.apply(tc.name); got != tc.expected {
+			t.Errorf("apply(%q) with %+v = %q, expected %q", tc.name, tc.req, got, tc.expected)
+		}
+	}
+}
+
+// TestNormalizeFiles tests previewing and applying renames across a project
+func TestNormalizeFiles(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.POST("/api/projects/:id/files/normalize", handler.NormalizeFiles)
+
+	project := models.Project{Name: "Table", Path: tmpDir}
+	db.Create(&project)
+	files := map[string]models.ProjectFile{}
+	for _, name := range []string{"Table Leg_v2.stl", "parts/Top Plate.STL", "README.md", "clip.stl", "Clip_final.stl"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(name), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name)}
+		db.Create(&file)
+		files[name] = file
+	}
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "Table Leg_v2.stl", Notes: "4 perimeters"})
+	db.Create(&models.Assembly{ProjectID: project.ID, Name: "Table", Parts: []models.AssemblyPart{{Filename: "parts/Top Plate.STL", Quantity: 1}}})
+
+	normalize := func(body string) (int, map[string]FileRename, int) {
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/files/normalize", project.ID), body)
+		var response struct {
+			Renames   []FileRename `json:"renames"`
+			Conflicts int          `json:"conflicts"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		byFrom := make(map[string]FileRename)
+		for _, rename := range response.Renames {
+			byFrom[rename.From] = rename
+		}
+		return w.Code, byFrom, response.Conflicts
+	}
+	rules := `"lowercase": true, "replace_spaces": "_", "strip_versions": true`
+
+	t.Run("Conflicts", func(t *testing.T) {
+		code, renames, conflicts := normalize(`{` + rules + `, "dry_run": true}`)
+		if code != http.StatusOK || len(renames) != 3 || conflicts != 1 {
+			t.Fatalf("Expected 3 renames with 1 conflict, got %d %+v", code, renames)
+		}
+		if renames["Clip_final.stl"].Conflict == "" || renames["parts/Top Plate.STL"].To != "parts/top_plate.stl" {
+			t.Errorf("Unexpected plan: %+v", renames)
+		}
+
+		if code, _, _ := normalize(`{` + rules + `}`); code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, code)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "Table Leg_v2.stl")); err != nil {
+			t.Errorf("Expected nothing renamed after a conflict: %v", err)
+		}
+	})
+
+	t.Run("Canonical copy", func(t *testing.T) {
+		top := files["parts/Top Plate.STL"]
+		duplicate := models.ProjectFile{ProjectID: project.ID + 1, Filename: "top.stl", Filepath: filepath.Join(t.TempDir(), "top.stl"), FileType: models.FileTypeSTL, DuplicateOf: &top.ID}
+		db.Create(&duplicate)
+		defer db.Delete(&duplicate)
+
+		code, renames, conflicts := normalize(fmt.Sprintf(`{%s, "dry_run": true, "file_ids": [%d]}`, rules, top.ID))
+		if code != http.StatusOK || conflicts != 1 || renames[top.Filename].Conflict == "" {
+			t.Errorf("Expected the canonical copy marked as a conflict, got %d %+v", code, renames)
+		}
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		body := fmt.Sprintf(`{%s, "file_ids": [%d, %d]}`, rules, files["Table Leg_v2.stl"].ID, files["parts/Top Plate.STL"].ID)
+		code, renames, _ := normalize(body)
+		if code != http.StatusOK || len(renames) != 2 {
+			t.Fatalf("Expected 2 renames, got %d %+v", code, renames)
+		}
+
+		if content, err := os.ReadFile(filepath.Join(tmpDir, "table_leg.stl")); err != nil || string(content) != "Table Leg_v2.stl" {
+			t.Errorf("Expected the leg renamed on disk, got %q, %v", content, err)
+		}
+		var leg models.ProjectFile
+		db.First(&leg, files["Table Leg_v2.stl"].ID)
+		if leg.Filename != "table_leg.stl" || leg.Filepath != filepath.Join(tmpDir, "table_leg.stl") {
+			t.Errorf("Expected the record renamed, got %+v", leg)
+		}
+
+		var profile models.FileProfile
+		if err := db.Where("project_id = ? AND filename = ?", project.ID, "table_leg.stl").First(&profile).Error; err != nil {
+			t.Errorf("Expected the print profile to follow the rename: %v", err)
+		}
+		var assembly models.Assembly
+		db.Where("project_id = ?", project.ID).First(&assembly)
+		if assembly.Parts[0].Filename != "parts/top_plate.stl" {
+			t.Errorf("Expected the assembly part to follow the rename, got %+v", assembly.Parts)
+		}
+
+		entries, _ := os.ReadDir(tmpDir)
+		for _, entry := range entries {
+			if entry.Name()[0] == '.' {
+				t.Errorf("Expected no temporary files left, found %s", entry.Name())
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"replace_spaces": "+"}`, `{"prefix": "../x"}`} {
+			if code, _, _ := normalize(body); code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultDueSoonDays is how far ahead the dashboard looks for orders due soon
+const defaultDueSoonDays = 7
+
+// OrderProgress is how much of an order has been printed, from the successful
+// print jobs recorded for it. Each print counts as one copy of the first item
+// it matches that still needs copies: the item's model when it names one,
+// otherwise any model of the item's project.
+type OrderProgress struct {
+	Requested int `json:"requested"`
+	Printed   int `json:"printed"`
+
+	// ItemsPrinted is the copies printed of each item, in the order's item order
+	ItemsPrinted []int  `json:"items_printed"`
+	PrintIDs     []uint `json:"print_ids"`
+}
+
+// OrderView is an order with its fulfillment progress
+type OrderView struct {
+	models.Order
+	Progress OrderProgress `json:"progress"`
+
+	// Overdue is set on open orders whose due date has passed
+	Overdue bool `json:"overdue"`
+}
+
+// OrdersHandler handles customer order HTTP requests
+type OrdersHandler struct{}
+
+// NewOrdersHandler creates a new OrdersHandler
+func NewOrdersHandler() *OrdersHandler {
+	return &OrdersHandler{}
+}
+
+// GetOrders returns orders, most recent first, optionally of one ?status=
+func (h *OrdersHandler) GetOrders(c *gin.Context) {
+	db := requestDB(c)
+
+	query := db.Order("id DESC")
+	if status := models.OrderStatus(c.Query("status")); status != "" {
+		if !slices.Contains(models.OrderStatuses, status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.Order
+	if err := query.Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+	views, err := orderViews(db, orders, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": views,
+		"count":  len(views),
+	})
+}
+
+// GetOrder returns a single order with its progress
+func (h *OrdersHandler) GetOrder(c *gin.Context) {
+	db := requestDB(c)
+
+	var order models.Order
+	if err := db.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	views, err := orderViews(db, []models.Order{order}, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, views[0])
+}
+
+// CreateOrder records a customer order for parts of the library
+func (h *OrdersHandler) CreateOrder(c *gin.Context) {
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	order.ID = 0
+
+	if !saveOrder(c, &order) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Order created successfully",
+		"order":   order,
+	})
+}
+
+// UpdateOrder replaces an order's customer, items, due date, status, price and notes
+func (h *OrdersHandler) UpdateOrder(c *gin.Context) {
+	var existing models.Order
+	if err := requestDB(c).First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	order.ID = existing.ID
+	order.CreatedAt = existing.CreatedAt
+
+	if !saveOrder(c, &order) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order updated successfully",
+		"order":   order,
+	})
+}
+
+// DeleteOrder removes an order; its print jobs stay in the print history
+func (h *OrdersHandler) DeleteOrder(c *gin.Context) {
+	var deleted int64
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Order{}, c.Param("id"))
+		deleted = result.RowsAffected
+		if result.Error != nil || deleted == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.PrintJob{}).Where("order_id = ?", c.Param("id")).Update("order_id", nil).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
+}
+
+// GetOrderDashboard summarizes fulfillment: orders by status, the open ones
+// by due date with their progress, how many are overdue or due within
+// ?days= (7 by default), and the value of open and shipped orders
+func (h *OrdersHandler) GetOrderDashboard(c *gin.Context) {
+	db := requestDB(c)
+
+	days := defaultDueSoonDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+		days = parsed
+	}
+
+	var orders []models.Order
+	if err := db.Order("id ASC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	byStatus := make(map[models.OrderStatus]int, len(models.OrderStatuses))
+	for _, status := range models.OrderStatuses {
+		byStatus[status] = 0
+	}
+	var open []models.Order
+	var openValue, shippedValue float64
+	for _, order := range orders {
+		byStatus[order.Status]++
+		switch {
+		case order.Status.Open():
+			open = append(open, order)
+			openValue += order.Price
+		case order.Status == models.OrderShipped:
+			shippedValue += order.Price
+		}
+	}
+
+	now := time.Now()
+	views, err := orderViews(db, open, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+	// Soonest due first; orders without a due date last
+	sort.SliceStable(views, func(i, j int) bool {
+		a, b := views[i].DueDate, views[j].DueDate
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+
+	overdue, dueSoon := 0, 0
+	horizon := now.AddDate(0, 0, days)
+	for _, view := range views {
+		switch {
+		case view.Overdue:
+			overdue++
+		case view.DueDate != nil && !view.DueDate.After(horizon):
+			dueSoon++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"by_status":     byStatus,
+		"open":          views,
+		"overdue":       overdue,
+		"due_soon":      dueSoon,
+		"due_soon_days": days,
+		"open_value":    openValue,
+		"shipped_value": shippedValue,
+	})
+}
+
+// saveOrder validates and stores an order, writing the error response and
+// returning false when it is invalid or names a missing project or model
+func saveOrder(c *gin.Context, order *models.Order) bool {
+	if err := order.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	db := requestDB(c)
+	for _, item := range order.Items {
+		var err error
+		if item.FileID != nil {
+			err = db.Where("id = ? AND project_id = ?", *item.FileID, item.ProjectID).First(&models.ProjectFile{}).Error
+		} else {
+			err = db.First(&models.Project{}, item.ProjectID).Error
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Project or file not found", "project_id": item.ProjectID, "file_id": item.FileID})
+			return false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save order"})
+			return false
+		}
+	}
+
+	if err := db.Save(order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save order"})
+		return false
+	}
+	return true
+}
+
+// orderViews adds the fulfillment progress to orders, from the successful
+// print jobs recorded for them
+func orderViews(db *gorm.DB, orders []models.Order, now time.Time) ([]OrderView, error) {
+	views := make([]OrderView, len(orders))
+	if len(orders) == 0 {
+		return views, nil
+	}
+	ids := make([]uint, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+
+	var jobs []models.PrintJob
+	if err := db.Where("order_id IN ? AND outcome = ?", ids, models.PrintSucceeded).
+		Order("started_at ASC, id ASC").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	byOrder := make(map[uint][]models.PrintJob)
+	for _, job := range jobs {
+		byOrder[*job.OrderID] = append(byOrder[*job.OrderID], job)
+	}
+
+	for i, order := range orders {
+		progress := OrderProgress{ItemsPrinted: make([]int, len(order.Items)), PrintIDs: []uint{}}
+		for _, item := range order.Items {
+			progress.Requested += item.Quantity
+		}
+		for _, job := range byOrder[order.ID] {
+			progress.PrintIDs = append(progress.PrintIDs, job.ID)
+			for j, item := range order.Items {
+				matches := job.ProjectID == item.ProjectID &&
+					(item.FileID == nil || (job.FileID != nil && *job.FileID == *item.FileID))
+				if matches && progress.ItemsPrinted[j] < item.Quantity {
+					progress.ItemsPrinted[j]++
+					progress.Printed++
+					break
+				}
+			}
+		}
+
+		views[i] = OrderView{
+			Order:    order,
+			Progress: progress,
+			Overdue:  order.Status.Open() && order.DueDate != nil && order.DueDate.Before(now),
+		}
+	}
+	return views, nil
+}
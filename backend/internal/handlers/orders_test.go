@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOrders tests tracking orders and their progress from recorded prints
+func TestOrders(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewOrdersHandler()
+	router.GET("/api/orders", handler.GetOrders)
+	router.POST("/api/orders", handler.CreateOrder)
+	router.GET("/api/orders/dashboard", handler.GetOrderDashboard)
+	router.GET("/api/orders/:id", handler.GetOrder)
+	router.PUT("/api/orders/:id", handler.UpdateOrder)
+	router.DELETE("/api/orders/:id", handler.DeleteOrder)
+	router.POST("/api/prints", NewPrintsHandler().RecordPrint)
+
+	dragon := models.Project{Name: "Dragon", Path: filepath.Join(tmpDir, "dragon")}
+	vase := models.Project{Name: "Vase", Path: filepath.Join(tmpDir, "vase")}
+	db.Create(&dragon)
+	db.Create(&vase)
+	head := models.ProjectFile{ProjectID: dragon.ID, Filename: "head.stl", Filepath: filepath.Join(dragon.Path, "head.stl"), FileType: models.FileTypeSTL}
+	db.Create(&head)
+
+	overdue := time.Now().AddDate(0, 0, -2).UTC().Format(time.RFC3339)
+	soon := time.Now().AddDate(0, 0, 3).UTC().Format(time.RFC3339)
+
+	body := fmt.Sprintf(`{"customer": " Ada ", "reference": "ETSY-1", "price": 45, "due_date": %q,
+		"items": [{"project_id": %d, "file_id": %d, "quantity": 2}, {"project_id": %d, "quantity": 1, "description": "blue"}]}`,
+		soon, dragon.ID, head.ID, vase.ID)
+	w := sendJSON(router, "POST", "/api/orders", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Order models.Order `json:"order"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Order.Customer != "Ada" || created.Order.Status != models.OrderPending {
+		t.Errorf("Expected a pending order for Ada, got %+v", created.Order)
+	}
+
+	body = fmt.Sprintf(`{"customer": "Bob", "price": 10, "due_date": %q, "status": "printing", "items": [{"project_id": %d, "quantity": 1}]}`, overdue, vase.ID)
+	if w := sendJSON(router, "POST", "/api/orders", body); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	body = fmt.Sprintf(`{"customer": "Cy", "price": 20, "status": "shipped", "items": [{"project_id": %d, "quantity": 1}]}`, vase.ID)
+	if w := sendJSON(router, "POST", "/api/orders", body); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	for name, body := range map[string]string{
+		"no customer":     fmt.Sprintf(`{"items": [{"project_id": %d, "quantity": 1}]}`, vase.ID),
+		"no items":        `{"customer": "Dee"}`,
+		"bad status":      fmt.Sprintf(`{"customer": "Dee", "status": "lost", "items": [{"project_id": %d, "quantity": 1}]}`, vase.ID),
+		"zero quantity":   fmt.Sprintf(`{"customer": "Dee", "items": [{"project_id": %d, "quantity": 0}]}`, vase.ID),
+		"missing project": `{"customer": "Dee", "items": [{"project_id": 999, "quantity": 1}]}`,
+		"foreign file":    fmt.Sprintf(`{"customer": "Dee", "items": [{"project_id": %d, "file_id": %d, "quantity": 1}]}`, vase.ID, head.ID),
+	} {
+		if w := sendJSON(router, "POST", "/api/orders", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, name, w.Code)
+		}
+	}
+
+	// Two heads printed, one failed, and a print for another order of the same project
+	for _, body := range []string{
+		fmt.Sprintf(`{"project_id": %d, "file_id": %d, "order_id": %d}`, dragon.ID, head.ID, created.Order.ID),
+		fmt.Sprintf(`{"project_id": %d, "file_id": %d, "order_id": %d, "outcome": "failed"}`, dragon.ID, head.ID, created.Order.ID),
+		fmt.Sprintf(`{"project_id": %d, "file_id": %d, "order_id": %d}`, dragon.ID, head.ID, created.Order.ID),
+		fmt.Sprintf(`{"project_id": %d, "file_id": %d, "order_id": %d}`, dragon.ID, head.ID, created.Order.ID),
+		fmt.Sprintf(`{"project_id": %d, "order_id": 2}`, vase.ID),
+	} {
+		if w := sendJSON(router, "POST", "/api/prints", body); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	if w := sendJSON(router, "POST", "/api/prints", fmt.Sprintf(`{"project_id": %d, "order_id": 999}`, vase.ID)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a missing order, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/orders/%d", created.Order.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var view OrderView
+	json.Unmarshal(w.Body.Bytes(), &view)
+	// The third head is more than ordered and counts toward nothing
+	if view.Progress.Requested != 3 || view.Progress.Printed != 2 || fmt.Sprint(view.Progress.ItemsPrinted) != "[2 0]" {
+		t.Errorf("Expected 2 of 3 printed as [2 0], got %+v", view.Progress)
+	}
+	if len(view.Progress.PrintIDs) != 3 || view.Overdue {
+		t.Errorf("Expected 3 successful prints on a timely order, got %+v", view)
+	}
+
+	w = sendJSON(router, "GET", "/api/orders/dashboard", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var dashboard struct {
+		ByStatus     map[string]int `json:"by_status"`
+		Open         []OrderView    `json:"open"`
+		Overdue      int            `json:"overdue"`
+		DueSoon      int            `json:"due_soon"`
+		OpenValue    float64        `json:"open_value"`
+		ShippedValue float64        `json:"shipped_value"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &dashboard)
+	if dashboard.ByStatus["pending"] != 1 || dashboard.ByStatus["printing"] != 1 || dashboard.ByStatus["shipped"] != 1 || dashboard.ByStatus["ready"] != 0 {
+		t.Errorf("Unexpected counts by status: %v", dashboard.ByStatus)
+	}
+	if len(dashboard.Open) != 2 || dashboard.Open[0].Customer != "Bob" || !dashboard.Open[0].Overdue {
+		t.Fatalf("Expected the overdue order first of 2 open, got %+v", dashboard.Open)
+	}
+	if dashboard.Open[0].Progress.Printed != 1 {
+		t.Errorf("Expected the overdue order's print counted, got %+v", dashboard.Open[0].Progress)
+	}
+	if dashboard.Overdue != 1 || dashboard.DueSoon != 1 || dashboard.OpenValue != 55 || dashboard.ShippedValue != 20 {
+		t.Errorf("Unexpected dashboard totals: %+v", dashboard)
+	}
+	if w := sendJSON(router, "GET", "/api/orders/dashboard?days=1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	} else {
+		var narrow struct {
+			DueSoon int `json:"due_soon"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &narrow)
+		if narrow.DueSoon != 0 {
+			t.Errorf("Expected nothing due within a day, got %d", narrow.DueSoon)
+		}
+	}
+
+	body = fmt.Sprintf(`{"customer": "Bob", "status": "shipped", "items": [{"project_id": %d, "quantity": 1}]}`, vase.ID)
+	if w := sendJSON(router, "PUT", "/api/orders/2", body); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = sendJSON(router, "GET", "/api/orders?status=shipped", "")
+	var listed struct {
+		Count int `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if listed.Count != 2 {
+		t.Errorf("Expected 2 shipped orders, got %d", listed.Count)
+	}
+	if w := sendJSON(router, "GET", "/api/orders?status=lost", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/orders/2", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var unlinked int64
+	db.Model(&models.PrintJob{}).Where("order_id = ?", 2).Count(&unlinked)
+	if unlinked != 0 {
+		t.Errorf("Expected the deleted order's prints unlinked, got %d", unlinked)
+	}
+	if w := sendJSON(router, "DELETE", "/api/orders/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOtherFilesMinSize = 1 << 20 // 1MB
+	defaultOtherFilesLimit   = 100
+	maxOtherFilesLimit       = 1000
+)
+
+// OtherFileReport describes an "other" file that may be reclaimable
+type OtherFileReport struct {
+	ID          uint      `json:"id"`
+	ProjectID   uint      `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Filename    string    `json:"filename"`
+	Filepath    string    `json:"filepath"`
+	Size        int64     `json:"size"`
+	ModifiedAt  time.Time `json:"modified_at"`
+	AgeDays     int       `json:"age_days"`
+}
+
+// BulkDeleteRequest lists the files to delete
+type BulkDeleteRequest struct {
+	FileIDs []uint `json:"file_ids" binding:"required,min=1"`
+}
+
+// GetOtherFilesReport lists large files of type "other", largest first
+func (h *AdminHandler) GetOtherFilesReport(c *gin.Context) {
+	minSize := int64(defaultOtherFilesMinSize)
+	if raw := c.Query("min_size"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_size"})
+			return
+		}
+		minSize = parsed
+	}
+
+	minAgeDays := 0
+	if raw := c.Query("min_age_days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_age_days"})
+			return
+		}
+		minAgeDays = parsed
+	}
+
+	limit := defaultOtherFilesLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxOtherFilesLimit)
+	}
+
+	var rows []struct {
+		models.ProjectFile
+		ProjectName string
+	}
+	err := requestDB(c).Table("project_files").
+		Select("project_files.*, projects.name AS project_name").
+		Joins("JOIN projects ON projects.id = project_files.project_id AND projects.deleted_at IS NULL").
+		Where("project_files.file_type = ? AND project_files.size >= ?", models.FileTypeOther, minSize).
+		Order("project_files.size DESC").
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch files"})
+		return
+	}
+
+	now := time.Now()
+	files := []OtherFileReport{}
+	var totalSize int64
+	for _, row := range rows {
+		// Prefer the on-disk modification time; fall back to the last recorded update
+		modifiedAt := row.UpdatedAt
+		if info, err := os.Stat(row.Filepath); err == nil {
+			modifiedAt = info.ModTime()
+		}
+
+		ageDays := int(now.Sub(modifiedAt).Hours() / 24)
+		if ageDays < minAgeDays {
+			continue
+		}
+
+		files = append(files, OtherFileReport{
+			ID:          row.ID,
+			ProjectID:   row.ProjectID,
+			ProjectName: row.ProjectName,
+			Filename:    row.Filename,
+			Filepath:    row.Filepath,
+			Size:        row.Size,
+			ModifiedAt:  modifiedAt,
+			AgeDays:     ageDays,
+		})
+		totalSize += row.Size
+
+		if len(files) == limit {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"files":      files,
+		"count":      len(files),
+		"total_size": totalSize,
+	})
+}
+
+// DeleteOtherFiles removes the given "other" files from disk and the database.
+// Files of any other type, or that deduplicated copies still reference, are refused.
+func (h *AdminHandler) DeleteOtherFiles(c *gin.Context) {
+	var req BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, unlock, ok := lockLibrary(c)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	var files []models.ProjectFile
+	if err := requestDB(c).Where("id IN ?", req.FileIDs).Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch files"})
+		return
+	}
+
+	found := make(map[uint]models.ProjectFile, len(files))
+	for _, file := range files {
+		found[file.ID] = file
+	}
+
+	deleted := []uint{}
+	errors := []string{}
+	var reclaimed int64
+	touchedProjects := make(map[uint]bool)
+
+	for _, id := range req.FileIDs {
+		if ctx.Err() != nil {
+			errors = append(errors, fmt.Sprintf("stopped: %v", context.Cause(ctx)))
+			break
+		}
+		file, ok := found[id]
+		if !ok {
+			errors = append(errors, fmt.Sprintf("%d: file not found", id))
+			continue
+		}
+		if file.FileType != models.FileTypeOther {
+			errors = append(errors, fmt.Sprintf("%d: only files of type other can be bulk deleted", id))
+			continue
+		}
+
+		var references int64
+		requestDB(c).Model(&models.ProjectFile{}).Where("duplicate_of = ?", file.ID).Count(&references)
+		if references > 0 {
+			errors = append(errors, fmt.Sprintf("%d: file is the canonical copy for %d duplicates", id, references))
+			continue
+		}
+
+		if err := deleteOtherFile(ctx, requestDB(c), file); err != nil {
+			errors = append(errors, fmt.Sprintf("%d: %v", id, err))
+			continue
+		}
+
+		deleted = append(deleted, id)
+		reclaimed += file.Size
+		touchedProjects[file.ProjectID] = true
+	}
+
+	for projectID := range touchedProjects {
+		if err := requestDB(c).Model(&models.Project{}).Where("id = ?", projectID).Update("last_scanned", time.Now()).Error; err != nil {
+			fmt.Printf("Warning: Failed to update project last_scanned timestamp: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":         deleted,
+		"deleted_count":   len(deleted),
+		"reclaimed_bytes": reclaimed,
+		"errors":          errors,
+	})
+}
+
+// deleteOtherFile removes a file from disk and the database while holding its
+// project's lock, so uploads and renames of the project wait
+func deleteOtherFile(ctx context.Context, db *gorm.DB, file models.ProjectFile) error {
+	unlock, err := lockLibraryProject(ctx, file.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to lock project: %v", err)
+	}
+	defer unlock()
+
+	if err := os.Remove(file.Filepath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file from filesystem: %v", err)
+	}
+	if err := db.Delete(&file).Error; err != nil {
+		return fmt.Errorf("failed to delete file from database: %v", err)
+	}
+	return nil
+}
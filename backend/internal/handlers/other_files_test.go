@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOtherFilesReport tests listing and bulk deleting large "other" files
+func TestOtherFilesReport(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminHandler := NewAdminHandler(nil)
+	router.GET("/api/admin/other-files", adminHandler.GetOtherFilesReport)
+	router.POST("/api/admin/other-files/delete", adminHandler.DeleteOtherFiles)
+
+	project := models.Project{Name: "Cluttered", Path: tmpDir}
+	db.Create(&project)
+
+	newFile := func(name string, fileType models.FileType, size int, age time.Duration) models.ProjectFile {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		modified := time.Now().Add(-age)
+		os.Chtimes(path, modified, modified)
+
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: fileType, Size: int64(size)}
+		db.Create(&file)
+		return file
+	}
+
+	oldZip := newFile("old-backup.zip", models.FileTypeOther, 4096, 90*24*time.Hour)
+	newTmp := newFile("slice.tmp", models.FileTypeOther, 2048, time.Hour)
+	newFile("tiny.txt", models.FileTypeOther, 10, 90*24*time.Hour)
+	model := newFile("model.stl", models.FileTypeSTL, 8192, 90*24*time.Hour)
+
+	t.Run("Report by size", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/admin/other-files?min_size=1024", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Files     []OtherFileReport `json:"files"`
+			Count     int               `json:"count"`
+			TotalSize int64             `json:"total_size"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		if response.Count != 2 {
+			t.Fatalf("Expected 2 files, got %d", response.Count)
+		}
+		if response.Files[0].ID != oldZip.ID || response.Files[1].ID != newTmp.ID {
+			t.Errorf("Expected files ordered by size descending")
+		}
+		if response.Files[0].AgeDays < 89 {
+			t.Errorf("Expected age from file modification time, got %d days", response.Files[0].AgeDays)
+		}
+		if response.TotalSize != 6144 {
+			t.Errorf("Expected total size 6144, got %d", response.TotalSize)
+		}
+	})
+
+	t.Run("Report by age", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/admin/other-files?min_size=1024&min_age_days=30", nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["count"].(float64) != 1 {
+			t.Errorf("Expected 1 old file, got %v", response["count"])
+		}
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"min_size=abc", "min_age_days=-1", "limit=0"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/admin/other-files?"+query, nil)
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+
+	t.Run("Bulk delete", func(t *testing.T) {
+		body, _ := json.Marshal(gin.H{"file_ids": []uint{oldZip.ID, model.ID, 9999}})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/admin/other-files/delete", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["deleted_count"].(float64) != 1 {
+			t.Errorf("Expected 1 deleted file, got %v", response["deleted_count"])
+		}
+		if response["reclaimed_bytes"].(float64) != 4096 {
+			t.Errorf("Expected 4096 reclaimed bytes, got %v", response["reclaimed_bytes"])
+		}
+		if len(response["errors"].([]interface{})) != 2 {
+			t.Errorf("Expected 2 errors for the STL and missing file, got %v", response["errors"])
+		}
+
+		if _, err := os.Stat(oldZip.Filepath); !os.IsNotExist(err) {
+			t.Error("Expected old zip to be removed from disk")
+		}
+		if _, err := os.Stat(model.Filepath); err != nil {
+			t.Error("Expected STL file to be kept")
+		}
+	})
+
+	t.Run("Bulk delete requires ids", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/admin/other-files/delete", bytes.NewReader([]byte(`{"file_ids": []}`)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// TestPairSlicedFiles tests linking G-code files to their source models in file listings
+func TestPairSlicedFiles(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+
+	plate := filepath.Join(tmpDir, "plate_1.gcode")
+	os.WriteFile(plate, []byte("; generated by PrusaSlicer\n; printing object hull.stl id:0 copy 0\nG1 X1\n; printing object Mast.STL id:1 copy 0\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.gcode"), []byte("G28\n"), 0644)
+
+	project := models.Project{Name: "Boat", Path: tmpDir}
+	db.Create(&project)
+	files := []models.ProjectFile{
+		{Filename: "hull.stl", FileType: models.FileTypeSTL},
+		{Filename: "hull_v2.stl", FileType: models.FileTypeSTL},
+		{Filename: "parts/mast.stl", FileType: models.FileTypeSTL},
+		{Filename: "hull_v2_0.2mm_PLA_MK4.gcode", FileType: models.FileTypeGCode},
+		{Filename: "Hull.gcode", FileType: models.FileTypeGCode},
+		{Filename: "plate_1.gcode", FileType: models.FileTypeGCode},
+		{Filename: "notes.gcode", FileType: models.FileTypeGCode},
+	}
+	for i := range files {
+		files[i].ProjectID = project.ID
+		files[i].Filepath = filepath.Join(tmpDir, files[i].Filename)
+	}
+	db.Create(&files)
+
+	w := sendJSON(router, "GET", "/api/projects/1/files", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Files []models.ProjectFile `json:"files"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	byName := make(map[string]models.ProjectFile)
+	for _, file := range response.Files {
+		byName[file.Filename] = file
+	}
+
+	expected := map[string][]uint{
+		"hull_v2_0.2mm_PLA_MK4.gcode": {2},
+		"Hull.gcode":                  {1},
+		"plate_1.gcode":               {1, 3},
+		"notes.gcode":                 nil,
+	}
+	for name, sources := range expected {
+		if got := byName[name].SourceModels; !slices.Equal(got, sources) {
+			t.Errorf("%s: expected source models %v, got %v", name, sources, got)
+		}
+	}
+	if got := byName["hull.stl"].SlicedVariants; !slices.Equal(got, []uint{5, 6}) {
+		t.Errorf("Expected hull.stl to list its sliced variants, got %v", got)
+	}
+	if got := byName["parts/mast.stl"].SlicedVariants; !slices.Equal(got, []uint{6}) {
+		t.Errorf("Expected mast.stl to be paired through slicer comments, got %v", got)
+	}
+
+	w = sendJSON(router, "GET", "/api/projects/1", "")
+	var detail models.Project
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if len(detail.Files) != len(files) || !slices.Equal(detail.Files[1].SlicedVariants, []uint{4}) {
+		t.Errorf("Expected the project detail to pair files, got %+v", detail.Files)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PartsHandler handles printed part inventory HTTP requests
+type PartsHandler struct{}
+
+// NewPartsHandler creates a new PartsHandler
+func NewPartsHandler() *PartsHandler {
+	return &PartsHandler{}
+}
+
+// GetParts returns the inventory, optionally for one project or only low-stock parts
+func (h *PartsHandler) GetParts(c *gin.Context) {
+	query := requestDB(c).Order("name ASC")
+	if raw := c.Query("project_id"); raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project_id"})
+			return
+		}
+		query = query.Where("project_id = ?", projectID)
+	}
+	if c.Query("low_stock") == "true" {
+		query = query.Where("low_stock_threshold > 0 AND quantity <= low_stock_threshold")
+	}
+
+	var parts []models.PrintedPart
+	if err := query.Find(&parts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"parts": parts,
+		"count": len(parts),
+	})
+}
+
+// GetPart returns a single printed part
+func (h *PartsHandler) GetPart(c *gin.Context) {
+	var part models.PrintedPart
+	if err := requestDB(c).First(&part, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Part not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, part)
+}
+
+// CreatePart adds a printed part to the inventory
+func (h *PartsHandler) CreatePart(c *gin.Context) {
+	var part models.PrintedPart
+	if err := c.ShouldBindJSON(&part); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	part.ID = 0
+
+	if !h.resolvePartLinks(c, &part) {
+		return
+	}
+
+	if err := requestDB(c).Create(&part).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create part"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Part created successfully",
+		"part":    part,
+	})
+}
+
+// UpdatePart replaces a printed part's details and stock level
+func (h *PartsHandler) UpdatePart(c *gin.Context) {
+	db := requestDB(c)
+
+	var existing models.PrintedPart
+	if err := db.First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Part not found"})
+		return
+	}
+
+	var part models.PrintedPart
+	if err := c.ShouldBindJSON(&part); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	part.ID = existing.ID
+	part.CreatedAt = existing.CreatedAt
+
+	if !h.resolvePartLinks(c, &part) {
+		return
+	}
+
+	if err := db.Save(&part).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update part"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Part updated successfully",
+		"part":    part,
+	})
+}
+
+// DeletePart removes a printed part from the inventory
+func (h *PartsHandler) DeletePart(c *gin.Context) {
+	result := requestDB(c).Delete(&models.PrintedPart{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete part"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Part not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Part deleted successfully"})
+}
+
+// AdjustStockRequest changes a part's quantity on hand
+type AdjustStockRequest struct {
+	// Delta is added to the quantity: negative when parts are used or sold, positive when printed
+	Delta int `json:"delta" binding:"required"`
+}
+
+// AdjustStock atomically changes a part's quantity, refusing to go below zero
+func (h *PartsHandler) AdjustStock(c *gin.Context) {
+	var req AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	db := requestDB(c)
+
+	var part models.PrintedPart
+	if err := db.First(&part, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Part not found"})
+		return
+	}
+
+	result := db.Model(&models.PrintedPart{}).
+		Where("id = ? AND quantity + ? >= 0", part.ID, req.Delta).
+		Update("quantity", gorm.Expr("quantity + ?", req.Delta))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+
+	if err := db.First(&part, part.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock", "part": part})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stock adjusted successfully",
+		"part":    part,
+	})
+}
+
+// resolvePartLinks validates the part and its project/file links, defaulting the
+// project and name from the linked file. It writes the error response and returns
+// false when the part is invalid.
+func (h *PartsHandler) resolvePartLinks(c *gin.Context, part *models.PrintedPart) bool {
+	db := requestDB(c)
+
+	if part.FileID != nil {
+		var file models.ProjectFile
+		if err := db.First(&file, *part.FileID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found"})
+			return false
+		}
+		if part.ProjectID != nil && *part.ProjectID != file.ProjectID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found in project"})
+			return false
+		}
+		part.ProjectID = &file.ProjectID
+		if strings.TrimSpace(part.Name) == "" {
+			part.Name = strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename))
+		}
+	}
+
+	if part.ProjectID != nil {
+		var project models.Project
+		if err := db.First(&project, *part.ProjectID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+			return false
+		}
+		if strings.TrimSpace(part.Name) == "" {
+			part.Name = project.Name
+		}
+	}
+
+	if err := part.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
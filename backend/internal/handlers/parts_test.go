@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPartsRouter creates a router with the inventory routes
+func setupPartsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPartsHandler()
+	router.GET("/api/parts", handler.GetParts)
+	router.POST("/api/parts", handler.CreatePart)
+	router.GET("/api/parts/:id", handler.GetPart)
+	router.PUT("/api/parts/:id", handler.UpdatePart)
+	router.DELETE("/api/parts/:id", handler.DeletePart)
+	router.POST("/api/parts/:id/adjust", handler.AdjustStock)
+	return router
+}
+
+// partResponse decodes the part wrapped in a handler response
+func partResponse(t *testing.T, body []byte) models.PrintedPart {
+	var response struct {
+		Part models.PrintedPart `json:"part"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return response.Part
+}
+
+// TestCreatePart tests adding parts linked to projects and files
+func TestCreatePart(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPartsRouter()
+
+	project := models.Project{Name: "Drawer Kit", Path: "/test/drawer"}
+	db.Create(&project)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "handle.stl", Filepath: "/test/drawer/handle.stl", FileType: models.FileTypeSTL}
+	db.Create(&file)
+	other := models.Project{Name: "Other", Path: "/test/other"}
+	db.Create(&other)
+
+	w := sendJSON(router, "POST", "/api/parts", `{"file_id": 1, "quantity": 8, "location": "Garage", "bin": "A3", "low_stock_threshold": 2}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	part := partResponse(t, w.Body.Bytes())
+	if part.Name != "handle" || part.ProjectID == nil || *part.ProjectID != project.ID || part.LowStock {
+		t.Errorf("Expected name and project from the file, got %+v", part)
+	}
+
+	testCases := map[string]string{
+		"No name":            `{"quantity": 1}`,
+		"Negative quantity":  `{"name": "Clip", "quantity": -1}`,
+		"Negative threshold": `{"name": "Clip", "low_stock_threshold": -1}`,
+		"Unknown file":       `{"file_id": 99}`,
+		"Unknown project":    `{"name": "Clip", "project_id": 99}`,
+		"File from another":  `{"project_id": 2, "file_id": 1}`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/parts", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestAdjustStock tests using and restocking parts with low-stock alerts
+func TestAdjustStock(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPartsRouter()
+
+	db.Create(&models.PrintedPart{Name: "Hinge", Quantity: 5, LowStockThreshold: 2})
+	db.Create(&models.PrintedPart{Name: "Knob", Quantity: 1})
+
+	w := sendJSON(router, "POST", "/api/parts/1/adjust", `{"delta": -3}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if part := partResponse(t, w.Body.Bytes()); part.Quantity != 2 || !part.LowStock {
+		t.Errorf("Expected 2 left and a low-stock alert, got %+v", part)
+	}
+
+	w = sendJSON(router, "POST", "/api/parts/1/adjust", `{"delta": -3}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d when overdrawing, got %d", http.StatusConflict, w.Code)
+	}
+	if part := partResponse(t, w.Body.Bytes()); part.Quantity != 2 {
+		t.Errorf("Expected quantity to be unchanged, got %d", part.Quantity)
+	}
+
+	var response struct {
+		Parts []models.PrintedPart `json:"parts"`
+		Count int                  `json:"count"`
+	}
+	w = sendJSON(router, "GET", "/api/parts?low_stock=true", "")
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 1 || response.Parts[0].Name != "Hinge" {
+		t.Errorf("Expected only Hinge to be low on stock, got %+v", response.Parts)
+	}
+
+	if w := sendJSON(router, "POST", "/api/parts/1/adjust", `{"delta": 10}`); partResponse(t, w.Body.Bytes()).LowStock {
+		t.Error("Expected low-stock alert to clear after restocking")
+	}
+	if w := sendJSON(router, "POST", "/api/parts/1/adjust", `{"delta": 0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for zero delta, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := sendJSON(router, "POST", "/api/parts/99/adjust", `{"delta": 1}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestUpdateAndDeletePart tests editing and removing inventory entries
+func TestUpdateAndDeletePart(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPartsRouter()
+
+	db.Create(&models.PrintedPart{Name: "Hinge", Quantity: 5})
+
+	w := sendJSON(router, "PUT", "/api/parts/1", `{"name": "Hinge v2", "quantity": 4, "location": "Shelf", "bin": "B1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if part := partResponse(t, w.Body.Bytes()); part.Name != "Hinge v2" || part.Bin != "B1" {
+		t.Errorf("Unexpected part after update: %+v", part)
+	}
+
+	if w := sendJSON(router, "GET", "/api/parts/1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "DELETE", "/api/parts/1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "GET", "/api/parts/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
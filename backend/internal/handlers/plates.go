@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FilePlates lists what a sliced file prints on each plate
+type FilePlates struct {
+	FileID   uint          `json:"file_id"`
+	Filename string        `json:"filename"`
+	Plates   []gcode.Plate `json:"plates"`
+}
+
+// GetFilePlates lists the plates of a G-code file or sliced 3MF with their
+// objects and estimates, so what is on each plate is known without opening
+// the slicer. A G-code file is one plate.
+func (h *ProjectsHandler) GetFilePlates(c *gin.Context) {
+	var file models.ProjectFile
+	if err := requestDB(c).Where("id = ? AND project_id = ?", c.Param("fileId"), c.Param("id")).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	result := FilePlates{FileID: file.ID, Filename: file.Filename, Plates: []gcode.Plate{}}
+	switch {
+	case file.FileType == models.FileTypeGCode:
+		meta, err := gcode.ReadMetadata(file.Filepath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read G-code", "details": err.Error()})
+			return
+		}
+		objects, err := gcode.ReadObjects(file.Filepath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read G-code", "details": err.Error()})
+			return
+		}
+		result.Plates = append(result.Plates, gcode.Plate{Index: 1, Metadata: meta, Objects: objects})
+	case gcode.IsPlateFile(file.Filename):
+		plates, err := gcode.ReadPlates(file.Filepath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read plates", "details": err.Error()})
+			return
+		}
+		result.Plates = append(result.Plates, plates...)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plates can only be listed for G-code and sliced 3MF files"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
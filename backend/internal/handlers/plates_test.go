@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetFilePlates tests listing the plates of G-code files and sliced 3MFs
+func TestGetFilePlates(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/files/:fileId/plates", handler.GetFilePlates)
+	router.GET("/api/projects/:id/summary", handler.GetProjectSummary)
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+
+	platePath := filepath.Join(tmpDir, "gears.gcode.3mf")
+	out, _ := os.Create(platePath)
+	archive := zip.NewWriter(out)
+	w, _ := archive.Create("Metadata/slice_info.config")
+	w.Write([]byte(`<config>
+  <plate><metadata key="index" value="1"/><metadata key="prediction" value="600"/><object name="gear.stl"/></plate>
+  <plate><metadata key="index" value="2"/><metadata key="prediction" value="300"/><object name="axle.stl"/></plate>
+</config>`))
+	archive.Close()
+	out.Close()
+
+	files := map[string]string{
+		"gears.gcode": "; filament_type = PLA\nEXCLUDE_OBJECT_DEFINE NAME=gear.stl_id_0_copy_0\n" +
+			"EXCLUDE_OBJECT_DEFINE NAME=gear.stl_id_0_copy_1\nG28\n",
+		"gear.stl": "solid gear",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	for _, name := range []string{"gears.gcode", "gears.gcode.3mf", "gear.stl"} {
+		db.Create(&models.ProjectFile{
+			ProjectID: project.ID,
+			Filename:  name,
+			Filepath:  filepath.Join(tmpDir, name),
+			FileType:  models.GetFileTypeFromExtension(name),
+		})
+	}
+
+	getPlates := func(fileID int) (*httptest.ResponseRecorder, FilePlates) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/projects/1/files/%d/plates", fileID), nil)
+		router.ServeHTTP(w, req)
+		var result FilePlates
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return w, result
+	}
+
+	t.Run("G-code", func(t *testing.T) {
+		w, result := getPlates(1)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if len(result.Plates) != 1 || len(result.Plates[0].Objects) != 2 || result.Plates[0].Material != "PLA" {
+			t.Errorf("Expected one PLA plate with 2 objects, got %+v", result.Plates)
+		}
+	})
+
+	t.Run("Sliced 3MF", func(t *testing.T) {
+		w, result := getPlates(2)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if len(result.Plates) != 2 || result.Plates[1].Objects[0] != "axle.stl" || result.Plates[1].PrintTimeSeconds != 300 {
+			t.Errorf("Expected plates 1 and 2, got %+v", result.Plates)
+		}
+	})
+
+	t.Run("Model file", func(t *testing.T) {
+		if w, _ := getPlates(3); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Summary totals", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/1/summary", nil)
+		router.ServeHTTP(w, req)
+
+		var summary ProjectSummary
+		json.Unmarshal(w.Body.Bytes(), &summary)
+		var sliced *PrintProfile
+		for i, profile := range summary.PrintProfiles {
+			if profile.Filename == "gears.gcode.3mf" {
+				sliced = &summary.PrintProfiles[i]
+			}
+		}
+		if sliced == nil || sliced.PrintTimeSeconds != 900 || len(sliced.Plates) != 2 {
+			t.Errorf("Expected a 900s profile over 2 plates for the sliced 3MF, got %+v", sliced)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionTag is the entity tag of a record's current version: its updated_at
+// in quotes, so clients can also build it from the JSON they read
+func versionTag(updatedAt time.Time) string {
+	return `"` + updatedAt.Format(time.RFC3339Nano) + `"`
+}
+
+// checkVersion enforces the client's precondition for changing a record last
+// updated at updatedAt: an If-Match header, or otherwise the updated_at the
+// client read, sent in the body. It writes a 409 with the current updated_at
+// and reports false when the record changed since it was read. Requests
+// without a precondition always pass.
+func checkVersion(c *gin.Context, record string, updatedAt time.Time, readAt *time.Time) bool {
+	matches := true
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		matches = matchesVersion(ifMatch, updatedAt)
+	} else if readAt != nil {
+		matches = readAt.Equal(updatedAt)
+	}
+	if !matches {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      record + " was modified since it was read",
+			"updated_at": updatedAt,
+		})
+	}
+	return matches
+}
+
+// matchesVersion reports whether an If-Match header lists the version of a
+// record last updated at updatedAt
+func matchesVersion(ifMatch string, updatedAt time.Time) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		readAt, err := time.Parse(time.RFC3339Nano, strings.Trim(tag, `"`))
+		if err == nil && readAt.Equal(updatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProjectVersion enforces the client's precondition against the project
+// as stored now, rather than as loaded before the project lock was taken;
+// callers must hold the lock
+func checkProjectVersion(c *gin.Context, project *models.Project, readAt *time.Time) bool {
+	var current models.Project
+	if err := requestDB(c).Select("id", "updated_at").First(&current, project.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return false
+	}
+	return checkVersion(c, "Project", current.UpdatedAt, readAt)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUpdateProjectPreconditions tests rejecting updates of projects changed since the client read them
+func TestUpdateProjectPreconditions(t *testing.T) {
+	db := setupTestDB(t)
+	tempDir := t.TempDir()
+	router := setupRouter(tempDir)
+	handler := NewProjectsHandler(tempDir)
+	router.DELETE("/api/projects/:id/files/:fileId", handler.DeleteProjectFile)
+
+	project := models.Project{Name: "Benchy", Path: filepath.Join(tempDir, "Benchy")}
+	db.Create(&project)
+	os.MkdirAll(project.Path, 0755)
+	os.WriteFile(filepath.Join(project.Path, "benchy.stl"), []byte("solid benchy"), 0644)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.stl", Filepath: filepath.Join(project.Path, "benchy.stl"), FileType: models.FileTypeSTL}
+	db.Create(&file)
+
+	send := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	projectPath := fmt.Sprintf("/api/projects/%d", project.ID)
+
+	w := send("GET", projectPath, "", "")
+	etag := w.Header().Get("ETag")
+	var read models.Project
+	json.Unmarshal(w.Body.Bytes(), &read)
+	if etag != versionTag(read.UpdatedAt) {
+		t.Fatalf("Expected ETag %s, got %q", versionTag(read.UpdatedAt), etag)
+	}
+
+	// Another client changes the project
+	time.Sleep(time.Millisecond)
+	if w := send("PUT", projectPath, etag, `{"name": "Benchy", "description": "First"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected the first update to apply, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("PUT", projectPath, etag, `{"name": "Benchy", "description": "Second"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a stale If-Match, got %d", w.Code)
+	}
+
+	body, _ := json.Marshal(map[string]any{"name": "Benchy", "description": "Second", "updated_at": read.UpdatedAt})
+	w = send("PUT", projectPath, "", string(body))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 for a stale updated_at, got %d", w.Code)
+	}
+	var conflict struct {
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+
+	body, _ = json.Marshal(map[string]any{"name": "Benchy", "description": "Second", "updated_at": conflict.UpdatedAt})
+	if w := send("PUT", projectPath, "", string(body)); w.Code != http.StatusOK {
+		t.Errorf("Expected the current updated_at to apply, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("PUT", projectPath, "*", `{"name": "Benchy", "description": "Third"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected If-Match * to apply, got %d", w.Code)
+	}
+
+	filePath := fmt.Sprintf("%s/files/%d", projectPath, file.ID)
+	if w := send("DELETE", filePath, `"2001-01-01T00:00:00Z"`, ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deleting a changed file, got %d", w.Code)
+	}
+	if w := send("DELETE", filePath, versionTag(file.UpdatedAt), ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the file delete to apply, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestMatchesVersion tests parsing If-Match headers
+func TestMatchesVersion(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 14, 12, 0, 0, 123456789, time.UTC)
+
+	testCases := []struct {
+		ifMatch  string
+		expected bool
+	}{
+		{ifMatch: versionTag(updatedAt), expected: true},
+		{ifMatch: `"2026-10-14T14:00:00.123456789+02:00"`, expected: true},
+		{ifMatch: `"abc", ` + versionTag(updatedAt), expected: true},
+		{ifMatch: "*", expected: true},
+		{ifMatch: `"2026-10-14T12:00:00Z"`, expected: false},
+		{ifMatch: `W/"abc"`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if got := matchesVersion(tc.ifMatch, updatedAt); got != tc.expected {
+			t.Errorf("matchesVersion(%q) = %v, expected %v", tc.ifMatch, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/preview"
+	"3dshelf/pkg/thumbnail"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFilePreview serves an STL model for the 3D preview. Models with more
+// triangles than the preview budget are decimated on first request and the
+// low-poly binary STL is cached by the model's content hash; others are
+// served whole. X-Preview-Decimated says which the client got.
+func (h *FilesHandler) GetFilePreview(c *gin.Context) {
+	var file models.ProjectFile
+	if err := requestDB(c).First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeSTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL files can be previewed"})
+		return
+	}
+	if _, err := os.Stat(file.Filepath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+
+	var path string
+	if h.previews != nil {
+		var err error
+		if path, err = h.previews.Get(file.Filepath, file.Hash); err != nil {
+			fmt.Printf("Warning: Failed to generate preview of %s: %v\n", file.Filepath, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to generate preview", "details": err.Error()})
+			return
+		}
+	}
+
+	c.Header("X-Preview-Decimated", strconv.FormatBool(path != ""))
+	if path == "" {
+		serveFile(c, &file)
+		return
+	}
+	c.Header("ETag", fmt.Sprintf(`"%s-%d"`, file.Hash, h.previews.MaxTriangles()))
+	c.Header("Content-Type", "model/stl")
+	c.File(path)
+}
+
+// GetFileRender serves a shaded PNG render of an STL model from a standard
+// angle (?view=iso, front, back, left, right, top or bottom, iso by default)
+// at ?size= pixels, 512 by default, for clients that can't render the 3D
+// preview. Renders are cached by the model's content hash.
+func (h *FilesHandler) GetFileRender(c *gin.Context) {
+	view := mesh.View(c.DefaultQuery("view", string(mesh.ViewIso)))
+	if !mesh.ValidView(view) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view", "views": mesh.Views})
+		return
+	}
+	size := thumbnail.Sizes[len(thumbnail.Sizes)-1]
+	if value := c.Query("size"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || !thumbnail.ValidSize(size) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid render size", "sizes": thumbnail.Sizes})
+			return
+		}
+	}
+
+	var file models.ProjectFile
+	if err := requestDB(c).First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeSTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL files can be rendered"})
+		return
+	}
+	if _, err := os.Stat(file.Filepath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+
+	// Models without a content hash are rendered on every request
+	if h.previews == nil || !thumbnail.ValidHash(file.Hash) {
+		var rendered bytes.Buffer
+		if err := preview.Render(&rendered, file.Filepath, view, size); err != nil {
+			fmt.Printf("Warning: Failed to render %s: %v\n", file.Filepath, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to render model", "details": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", rendered.Bytes())
+		return
+	}
+
+	path, err := h.previews.Render(file.Filepath, file.Hash, view, size)
+	if err != nil {
+		fmt.Printf("Warning: Failed to render %s: %v\n", file.Filepath, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to render model", "details": err.Error()})
+		return
+	}
+	c.Header("ETag", fmt.Sprintf(`"%s-%s-%d"`, file.Hash, view, size))
+	c.File(path)
+}
+
+// LiveModelHashes returns the content hashes of the STL models in the
+// library, whose previews and renders the cache cleanup keeps
+func (h *FilesHandler) LiveModelHashes() (map[string]bool, error) {
+	var hashes []string
+	if err := database.GetDB().Model(&models.ProjectFile{}).Where("file_type = ? AND hash <> ''", models.FileTypeSTL).
+		Pluck("hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		live[hash] = true
+	}
+	return live, nil
+}
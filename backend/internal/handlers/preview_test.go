@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/preview"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetFilePreview tests serving large models decimated and small ones whole
+func TestGetFilePreview(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewFilesHandler(nil)
+	handler.SetPreviews(preview.New(filepath.Join(tmpDir, "previews"), 100))
+	router.GET("/api/files/:id/preview", handler.GetFilePreview)
+
+	// A thousand triangles are well over the budget
+	large := &mesh.Mesh{}
+	for i := range 1000 {
+		x, y := float64(i%40), float64(i/40)
+		large.Triangles = append(large.Triangles, mesh.Triangle{{x, y, 0}, {x + 1, y, 0}, {x, y + 1, 1}})
+	}
+	project := models.Project{Name: "Previews", Path: tmpDir}
+	db.Create(&project)
+	addModel := func(name string, model *mesh.Mesh, hash string) models.ProjectFile {
+		var buf bytes.Buffer
+		mesh.WriteBinarySTL(&buf, model)
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, buf.Bytes(), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.FileTypeSTL, Size: int64(buf.Len()), Hash: hash}
+		db.Create(&file)
+		return file
+	}
+	largeFile := addModel("large.stl", large, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	smallFile := addModel("small.stl", &mesh.Mesh{Triangles: large.Triangles[:10]}, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+	readme := models.ProjectFile{ProjectID: project.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME}
+	db.Create(&readme)
+
+	w := sendJSON(router, "GET", fmt.Sprintf("/api/files/%d/preview", largeFile.ID), "")
+	if w.Code != http.StatusOK || w.Header().Get("X-Preview-Decimated") != "true" {
+		t.Fatalf("Expected a decimated preview, got %d: %v", w.Code, w.Header())
+	}
+	decimated, err := mesh.ReadSTL(w.Body, int64(w.Body.Len()))
+	if err != nil || len(decimated.Triangles) == 0 || len(decimated.Triangles) > 100 {
+		t.Errorf("Expected at most 100 triangles, got %v", err)
+	}
+
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/files/%d/preview", smallFile.ID), "")
+	if w.Code != http.StatusOK || w.Header().Get("X-Preview-Decimated") != "false" || int64(w.Body.Len()) != smallFile.Size {
+		t.Errorf("Expected the small model whole, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+
+	for name, tc := range map[string]struct {
+		id     uint
+		status int
+	}{
+		"Not an STL":   {readme.ID, http.StatusBadRequest},
+		"Missing file": {999, http.StatusNotFound},
+	} {
+		if w := sendJSON(router, "GET", fmt.Sprintf("/api/files/%d/preview", tc.id), ""); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
+
+// TestGetFileRender tests serving PNG renders of STL models
+func TestGetFileRender(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewFilesHandler(nil)
+	handler.SetPreviews(preview.New(filepath.Join(tmpDir, "previews"), 0))
+	router.GET("/api/files/:id/render.png", handler.GetFileRender)
+
+	project := models.Project{Name: "Renders", Path: tmpDir}
+	db.Create(&project)
+	var buf bytes.Buffer
+	mesh.WriteBinarySTL(&buf, &mesh.Mesh{Triangles: []mesh.Triangle{{{0, 0, 0}, {10, 0, 0}, {0, 10, 10}}}})
+	path := filepath.Join(tmpDir, "wedge.stl")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	hashed := models.ProjectFile{ProjectID: project.ID, Filename: "wedge.stl", Filepath: path, FileType: models.FileTypeSTL, Hash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+	unhashed := models.ProjectFile{ProjectID: project.ID, Filename: "copy.stl", Filepath: path, FileType: models.FileTypeSTL}
+	readme := models.ProjectFile{ProjectID: project.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME}
+	db.Create(&hashed)
+	db.Create(&unhashed)
+	db.Create(&readme)
+
+	for name, tc := range map[string]struct {
+		query string
+		id    uint
+		size  int
+	}{
+		"Cached":              {"?view=top&size=128", hashed.ID, 128},
+		"Default view":        {"", hashed.ID, 512},
+		"Without a hash":      {"?size=256", unhashed.ID, 256},
+		"Cached second visit": {"?view=top&size=128", hashed.ID, 128},
+	} {
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/files/%d/render.png%s", tc.id, tc.query), "")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("%s: expected a PNG, got %d %s", name, w.Code, w.Header().Get("Content-Type"))
+			continue
+		}
+		config, err := png.DecodeConfig(w.Body)
+		if err != nil || config.Width != tc.size {
+			t.Errorf("%s: expected a %dpx render, got %d (%v)", name, tc.size, config.Width, err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		query  string
+		id     uint
+		status int
+	}{
+		"Unknown view": {"?view=sideways", hashed.ID, http.StatusBadRequest},
+		"Invalid size": {"?size=100", hashed.ID, http.StatusBadRequest},
+		"Not an STL":   {"", readme.ID, http.StatusBadRequest},
+		"Missing file": {"", 999, http.StatusNotFound},
+	} {
+		if w := sendJSON(router, "GET", fmt.Sprintf("/api/files/%d/render.png%s", tc.id, tc.query), ""); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagFailureRequest replaces the failure tags of a failed print
+type TagFailureRequest struct {
+	FailureReasons  []models.FailureReason `json:"failure_reasons"`
+	FailureSettings map[string]string      `json:"failure_settings"`
+}
+
+// FailureCount is how often a failure reason was tagged
+type FailureCount struct {
+	Reason models.FailureReason `json:"reason"`
+	Count  int                  `json:"count"`
+}
+
+// SettingCount is how often a setting was suspected in failures
+type SettingCount struct {
+	Setting string `json:"setting"`
+	Count   int    `json:"count"`
+}
+
+// FailureGroup summarizes failures of one printer or material
+type FailureGroup struct {
+	// Name is the printer or material; empty for prints that did not record it
+	Name        string         `json:"name"`
+	Prints      int            `json:"prints"`
+	Failed      int            `json:"failed"`
+	FailureRate float64        `json:"failure_rate"`
+	Reasons     []FailureCount `json:"reasons"`
+}
+
+// FailureAnalysis surfaces the most common failure causes to guide troubleshooting
+type FailureAnalysis struct {
+	Prints      int     `json:"prints"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+
+	// Untagged counts failed prints without a failure reason
+	Untagged int `json:"untagged"`
+
+	Reasons    []FailureCount `json:"reasons"`
+	Settings   []SettingCount `json:"settings"`
+	ByPrinter  []FailureGroup `json:"by_printer"`
+	ByMaterial []FailureGroup `json:"by_material"`
+}
+
+// TagPrintFailure sets the failure reasons and suspected settings of a failed print
+func (h *PrintsHandler) TagPrintFailure(c *gin.Context) {
+	db := requestDB(c)
+
+	var job models.PrintJob
+	if err := db.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Print not found"})
+		return
+	}
+
+	var req TagFailureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	job.FailureReasons = req.FailureReasons
+	job.FailureSettings = req.FailureSettings
+
+	if err := job.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Model(&job).Select("failure_reasons", "failure_settings").Updates(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag print"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Print tagged successfully",
+		"print":   job,
+	})
+}
+
+// GetFailureAnalysis reports the most common failure causes overall and per
+// printer and material, with the same filters as the print history plus
+// ?printer= and ?material=
+func (h *PrintsHandler) GetFailureAnalysis(c *gin.Context) {
+	query, err := filterPrints(c, requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if printer := c.Query("printer"); printer != "" {
+		query = query.Where("printer = ? COLLATE NOCASE", printer)
+	}
+	if material := c.Query("material"); material != "" {
+		query = query.Where("material = ? COLLATE NOCASE", material)
+	}
+
+	var jobs []models.PrintJob
+	if err := query.Select("id", "printer", "material", "outcome", "failure_reasons", "failure_settings").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analyzeFailures(jobs))
+}
+
+// analyzeFailures tallies failure reasons and suspected settings over jobs
+func analyzeFailures(jobs []models.PrintJob) FailureAnalysis {
+	reasons := make(map[models.FailureReason]int)
+	settings := make(map[string]int)
+	printers := newFailureGroups()
+	materials := newFailureGroups()
+
+	analysis := FailureAnalysis{Prints: len(jobs)}
+	for _, job := range jobs {
+		printers.add(job.Printer, job)
+		materials.add(job.Material, job)
+
+		if job.Outcome != models.PrintFailed {
+			continue
+		}
+		analysis.Failed++
+		if len(job.FailureReasons) == 0 {
+			analysis.Untagged++
+		}
+		for _, reason := range job.FailureReasons {
+			reasons[reason]++
+		}
+		for setting := range job.FailureSettings {
+			settings[setting]++
+		}
+	}
+
+	analysis.FailureRate = failureRate(analysis.Failed, analysis.Prints)
+	analysis.Reasons = sortedFailureCounts(reasons)
+	analysis.Settings = []SettingCount{}
+	for setting, count := range settings {
+		analysis.Settings = append(analysis.Settings, SettingCount{Setting: setting, Count: count})
+	}
+	sort.Slice(analysis.Settings, func(i, j int) bool {
+		if analysis.Settings[i].Count != analysis.Settings[j].Count {
+			return analysis.Settings[i].Count > analysis.Settings[j].Count
+		}
+		return analysis.Settings[i].Setting < analysis.Settings[j].Setting
+	})
+	analysis.ByPrinter = printers.sorted()
+	analysis.ByMaterial = materials.sorted()
+
+	return analysis
+}
+
+// failureGroups accumulates per-printer or per-material tallies
+type failureGroups struct {
+	groups  map[string]*FailureGroup
+	reasons map[string]map[models.FailureReason]int
+}
+
+func newFailureGroups() *failureGroups {
+	return &failureGroups{
+		groups:  make(map[string]*FailureGroup),
+		reasons: make(map[string]map[models.FailureReason]int),
+	}
+}
+
+func (g *failureGroups) add(name string, job models.PrintJob) {
+	group, ok := g.groups[name]
+	if !ok {
+		group = &FailureGroup{Name: name}
+		g.groups[name] = group
+		g.reasons[name] = make(map[models.FailureReason]int)
+	}
+
+	group.Prints++
+	if job.Outcome == models.PrintFailed {
+		group.Failed++
+		for _, reason := range job.FailureReasons {
+			g.reasons[name][reason]++
+		}
+	}
+}
+
+// sorted returns the groups with the most failures, then the highest failure rate, first
+func (g *failureGroups) sorted() []FailureGroup {
+	groups := make([]FailureGroup, 0, len(g.groups))
+	for name, group := range g.groups {
+		group.FailureRate = failureRate(group.Failed, group.Prints)
+		group.Reasons = sortedFailureCounts(g.reasons[name])
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Failed != groups[j].Failed {
+			return groups[i].Failed > groups[j].Failed
+		}
+		if groups[i].FailureRate != groups[j].FailureRate {
+			return groups[i].FailureRate > groups[j].FailureRate
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// sortedFailureCounts orders reasons from most to least common
+func sortedFailureCounts(counts map[models.FailureReason]int) []FailureCount {
+	sorted := make([]FailureCount, 0, len(counts))
+	for reason, count := range counts {
+		sorted = append(sorted, FailureCount{Reason: reason, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Reason < sorted[j].Reason
+	})
+	return sorted
+}
+
+// failureRate is the share of failed prints, rounded to three decimals
+func failureRate(failed, prints int) float64 {
+	if prints == 0 {
+		return 0
+	}
+	return math.Round(float64(failed)/float64(prints)*1000) / 1000
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPrintFailuresRouter creates a router with the failure tagging and analysis routes
+func setupPrintFailuresRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintsHandler()
+	router.POST("/api/prints", handler.RecordPrint)
+	router.GET("/api/prints/failures", handler.GetFailureAnalysis)
+	router.PUT("/api/prints/:id/failure", handler.TagPrintFailure)
+	return router
+}
+
+// TestTagPrintFailure tests recording and tagging failure reasons
+func TestTagPrintFailure(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintFailuresRouter()
+
+	db.Create(&models.Project{Name: "Benchy", Path: "/test/benchy"})
+
+	w := sendJSON(router, "POST", "/api/prints", `{"project_id": 1, "outcome": "failed", "failure_reasons": ["Warping", "power loss", "warping"], "failure_settings": {" bed_temp ": "55"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct {
+		Print models.PrintJob `json:"print"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	reasons := created.Print.FailureReasons
+	if len(reasons) != 2 || reasons[0] != models.FailureWarping || reasons[1] != models.FailurePowerLoss {
+		t.Errorf("Expected normalized, deduplicated reasons, got %v", reasons)
+	}
+	if created.Print.FailureSettings["bed_temp"] != "55" {
+		t.Errorf("Expected trimmed setting keys, got %v", created.Print.FailureSettings)
+	}
+
+	testCases := map[string]string{
+		"Unknown reason":      `{"project_id": 1, "outcome": "failed", "failure_reasons": ["gremlins"]}`,
+		"Reason on a success": `{"project_id": 1, "failure_reasons": ["clog"]}`,
+		"Empty setting key":   `{"project_id": 1, "outcome": "failed", "failure_settings": {" ": "x"}}`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/prints", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w = sendJSON(router, "PUT", "/api/prints/1/failure", `{"failure_reasons": ["clog"], "failure_settings": {"nozzle_temp": "190"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var job models.PrintJob
+	db.First(&job, 1)
+	if len(job.FailureReasons) != 1 || job.FailureReasons[0] != models.FailureClog || job.FailureSettings["nozzle_temp"] != "190" || len(job.FailureSettings) != 1 {
+		t.Errorf("Expected the tags to be replaced, got %v %v", job.FailureReasons, job.FailureSettings)
+	}
+
+	sendJSON(router, "POST", "/api/prints", `{"project_id": 1}`)
+	if w := sendJSON(router, "PUT", "/api/prints/2/failure", `{"failure_reasons": ["clog"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d when tagging a successful print, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := sendJSON(router, "PUT", "/api/prints/99/failure", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown print, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestGetFailureAnalysis tests surfacing the most common failure causes per printer and material
+func TestGetFailureAnalysis(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintFailuresRouter()
+
+	db.Create(&models.Project{Name: "Benchy", Path: "/test/benchy"})
+	jobs := []models.PrintJob{
+		{Printer: "MK4", Material: "PETG", Outcome: models.PrintFailed, FailureReasons: []models.FailureReason{models.FailureWarping, models.FailureAdhesion}, FailureSettings: map[string]string{"bed_temp": "70"}},
+		{Printer: "MK4", Material: "PETG", Outcome: models.PrintFailed, FailureReasons: []models.FailureReason{models.FailureWarping}, FailureSettings: map[string]string{"bed_temp": "65"}},
+		{Printer: "MK4", Material: "PLA", Outcome: models.PrintSucceeded},
+		{Printer: "Ender 3", Material: "PLA", Outcome: models.PrintFailed, FailureReasons: []models.FailureReason{models.FailureClog}},
+		{Printer: "Ender 3", Material: "PLA", Outcome: models.PrintFailed},
+		{Printer: "Ender 3", Material: "PLA", Outcome: models.PrintSucceeded},
+	}
+	for i := range jobs {
+		jobs[i].ProjectID = 1
+		jobs[i].Validate()
+		db.Create(&jobs[i])
+	}
+
+	w := sendJSON(router, "GET", "/api/prints/failures", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var analysis FailureAnalysis
+	if err := json.Unmarshal(w.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("Failed to parse analysis: %v", err)
+	}
+
+	if analysis.Prints != 6 || analysis.Failed != 4 || analysis.Untagged != 1 || analysis.FailureRate != 0.667 {
+		t.Errorf("Unexpected totals: %+v", analysis)
+	}
+	if len(analysis.Reasons) != 3 || analysis.Reasons[0] != (FailureCount{Reason: models.FailureWarping, Count: 2}) {
+		t.Errorf("Expected warping to be the most common cause, got %+v", analysis.Reasons)
+	}
+	if len(analysis.Settings) != 1 || analysis.Settings[0] != (SettingCount{Setting: "bed_temp", Count: 2}) {
+		t.Errorf("Expected bed_temp to be the suspected setting, got %+v", analysis.Settings)
+	}
+
+	if len(analysis.ByPrinter) != 2 {
+		t.Fatalf("Expected 2 printers, got %+v", analysis.ByPrinter)
+	}
+	ender := analysis.ByPrinter[0]
+	if ender.Name != "Ender 3" || ender.Prints != 3 || ender.Failed != 2 || len(ender.Reasons) != 1 || ender.Reasons[0].Reason != models.FailureClog {
+		t.Errorf("Unexpected Ender 3 group: %+v", ender)
+	}
+	if petg := analysis.ByMaterial[0]; petg.Name != "PETG" || petg.FailureRate != 1 || petg.Reasons[0].Reason != models.FailureWarping {
+		t.Errorf("Unexpected PETG group: %+v", petg)
+	}
+
+	w = sendJSON(router, "GET", "/api/prints/failures?printer=mk4&material=PETG", "")
+	json.Unmarshal(w.Body.Bytes(), &analysis)
+	if analysis.Prints != 2 || analysis.Failed != 2 || len(analysis.ByPrinter) != 1 {
+		t.Errorf("Expected the printer and material filters to apply, got %+v", analysis)
+	}
+
+	if w := sendJSON(router, "GET", "/api/prints/failures?from=soon", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid date, got %d", http.StatusBadRequest, w.Code)
+	}
+}
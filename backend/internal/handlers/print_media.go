@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/octoprint"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OctoPrintImportRequest selects the OctoPrint time-lapse attached to a print
+type OctoPrintImportRequest struct {
+	// Filename is the time-lapse to pull; the most recent one when empty
+	Filename string `json:"filename"`
+}
+
+// SetOctoPrint enables pulling time-lapses from an OctoPrint instance
+func (h *PrintsHandler) SetOctoPrint(client *octoprint.Client) {
+	h.octoprint = client
+}
+
+// SetImageNormalizer strips and converts uploaded photos
+func (h *PrintsHandler) SetImageNormalizer(images *imaging.Normalizer) {
+	h.images = images
+}
+
+// UploadPrintMedia attaches uploaded photos and videos to a print job
+func (h *PrintsHandler) UploadPrintMedia(c *gin.Context) {
+	job, project, ok := loadPrintMediaTarget(c)
+	if !ok {
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
+		return
+	}
+	if rejectTooManyFiles(c, files, h.maxUploadFiles) {
+		return
+	}
+
+	dir := printMediaDir(project, job)
+	if err := fileperm.MkdirAll(dir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create print media directory"})
+		return
+	}
+
+	attached := []models.PrintMedia{}
+	var failed []string
+	for _, fileHeader := range files {
+		kind, ok := models.PrintMediaKindOf(fileHeader.Filename)
+		if !ok {
+			failed = append(failed, fmt.Sprintf("File type not supported: %s", fileHeader.Filename))
+			continue
+		}
+
+		dest := fsutil.AvailablePath(dir, filepath.Base(fileHeader.Filename))
+		if err := c.SaveUploadedFile(fileHeader, dest); err != nil {
+			failed = append(failed, fmt.Sprintf("Failed to save file %s: %v", fileHeader.Filename, err))
+			continue
+		}
+		if kind == models.PrintMediaPhoto {
+			if dest, err = h.images.Normalize(dest); err != nil {
+				os.Remove(dest)
+				failed = append(failed, fmt.Sprintf("Failed to process image %s: %v", fileHeader.Filename, err))
+				continue
+			}
+		}
+		if err := fileperm.File(dest); err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to save file %s: %v", fileHeader.Filename, err))
+			continue
+		}
+
+		media, err := recordPrintMedia(requestDB(c), project, job, dest, kind, models.PrintMediaUploaded)
+		if err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to record file %s: %v", fileHeader.Filename, err))
+			continue
+		}
+		attached = append(attached, *media)
+	}
+
+	status := http.StatusCreated
+	if len(attached) == 0 {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"message": fmt.Sprintf("Attached %d file(s)", len(attached)),
+		"media":   attached,
+		"errors":  failed,
+	})
+}
+
+// ImportOctoPrintTimelapse downloads a rendered time-lapse from OctoPrint and
+// attaches it to a print job
+func (h *PrintsHandler) ImportOctoPrintTimelapse(c *gin.Context) {
+	if h.octoprint == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OctoPrint is not configured"})
+		return
+	}
+
+	var req OctoPrintImportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	job, project, ok := loadPrintMediaTarget(c)
+	if !ok {
+		return
+	}
+
+	timelapse, err := h.octoprint.FindTimelapse(c.Request.Context(), req.Filename)
+	if errors.Is(err, octoprint.ErrTimelapseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Timelapse not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach OctoPrint", "details": err.Error()})
+		return
+	}
+
+	kind, ok := models.PrintMediaKindOf(timelapse.Name)
+	if !ok {
+		kind = models.PrintMediaVideo
+	}
+
+	dir := printMediaDir(project, job)
+	if err := fileperm.MkdirAll(dir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create print media directory"})
+		return
+	}
+	dest := fsutil.AvailablePath(dir, filepath.Base(timelapse.Name))
+
+	out, err := os.Create(dest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save timelapse"})
+		return
+	}
+	err = h.octoprint.Download(c.Request.Context(), timelapse, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if err := fileperm.File(dest); err != nil {
+			os.Remove(dest)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save timelapse"})
+			return
+		}
+	}
+	if err != nil {
+		os.Remove(dest)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to download timelapse", "details": err.Error()})
+		return
+	}
+
+	media, err := recordPrintMedia(requestDB(c), project, job, dest, kind, models.PrintMediaOctoPrint)
+	if err != nil {
+		os.Remove(dest)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record timelapse"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Timelapse attached successfully",
+		"media":   media,
+	})
+}
+
+// DownloadPrintMedia serves a print photo or video
+func (h *PrintsHandler) DownloadPrintMedia(c *gin.Context) {
+	media, project, ok := loadPrintMedia(c)
+	if !ok {
+		return
+	}
+
+	c.File(filepath.Join(project.Path, filepath.FromSlash(media.Filename)))
+}
+
+// DeletePrintMedia removes a photo or video from a print job and from disk
+func (h *PrintsHandler) DeletePrintMedia(c *gin.Context) {
+	media, project, ok := loadPrintMedia(c)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Delete(&media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		return
+	}
+	if err := os.Remove(filepath.Join(project.Path, filepath.FromSlash(media.Filename))); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: Failed to remove print media %s: %v\n", media.Filename, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
+
+// loadPrintMediaTarget loads the print job and the project its media is stored
+// under. It writes the error response and returns false when either is missing
+// or the project has no directory.
+func loadPrintMediaTarget(c *gin.Context) (models.PrintJob, models.Project, bool) {
+	db := requestDB(c)
+
+	var job models.PrintJob
+	if err := db.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Print not found"})
+		return job, models.Project{}, false
+	}
+
+	var project models.Project
+	if err := db.First(&project, job.ProjectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return job, project, false
+	}
+	if rejectFlatProject(c, &project) {
+		return job, project, false
+	}
+	return job, project, true
+}
+
+// loadPrintMedia loads an attachment of the print job in the URL and its project
+func loadPrintMedia(c *gin.Context) (models.PrintMedia, models.Project, bool) {
+	db := requestDB(c)
+
+	var media models.PrintMedia
+	if err := db.Where("id = ? AND print_job_id = ?", c.Param("mediaId"), c.Param("id")).First(&media).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return media, models.Project{}, false
+	}
+
+	var project models.Project
+	if err := db.First(&project, media.ProjectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return media, project, false
+	}
+	return media, project, true
+}
+
+// printMediaDir is where a print job's media is stored inside its project
+func printMediaDir(project models.Project, job models.PrintJob) string {
+	return filepath.Join(project.Path, models.PrintMediaDir, strconv.FormatUint(uint64(job.ID), 10))
+}
+
+// recordPrintMedia stores the attachment saved at path
+func recordPrintMedia(db *gorm.DB, project models.Project, job models.PrintJob, path string, kind models.PrintMediaKind, source models.PrintMediaSource) (*models.PrintMedia, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	filename, err := filepath.Rel(project.Path, path)
+	if err != nil {
+		return nil, err
+	}
+
+	media := models.PrintMedia{
+		PrintJobID: job.ID,
+		ProjectID:  project.ID,
+		Filename:   filepath.ToSlash(filename),
+		Kind:       kind,
+		Source:     source,
+		Size:       info.Size(),
+	}
+	if err := db.Create(&media).Error; err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/octoprint"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPrintMediaRouter creates a router with the print media and summary routes
+func setupPrintMediaRouter(client *octoprint.Client) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintsHandler()
+	handler.SetOctoPrint(client)
+	router.GET("/api/prints", handler.GetPrints)
+	router.POST("/api/prints/:id/media", handler.UploadPrintMedia)
+	router.POST("/api/prints/:id/media/octoprint", handler.ImportOctoPrintTimelapse)
+	router.GET("/api/prints/:id/media/:mediaId", handler.DownloadPrintMedia)
+	router.DELETE("/api/prints/:id/media/:mediaId", handler.DeletePrintMedia)
+	router.GET("/api/projects/:id/summary", NewProjectsHandler("/test").GetProjectSummary)
+	return router
+}
+
+// uploadPrintMedia posts files to a print job's media endpoint
+func uploadPrintMedia(t *testing.T, router *gin.Engine, path string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestUploadPrintMedia tests attaching photos to a print and listing them with the history
+func TestUploadPrintMedia(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupPrintMediaRouter(nil)
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	job := models.PrintJob{ProjectID: project.ID, Outcome: models.PrintFailed}
+	job.Validate()
+	db.Create(&job)
+
+	w := uploadPrintMedia(t, router, "/api/prints/1/media", map[string]string{"result.jpg": "jpeg", "notes.txt": "text"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var response struct {
+		Media  []models.PrintMedia `json:"media"`
+		Errors []string            `json:"errors"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Media) != 1 || response.Media[0].Filename != "prints/1/result.jpg" || response.Media[0].Kind != models.PrintMediaPhoto {
+		t.Errorf("Expected the photo to be attached under the project, got %+v", response.Media)
+	}
+	if len(response.Errors) != 1 || !strings.Contains(response.Errors[0], "notes.txt") {
+		t.Errorf("Expected the text file to be rejected, got %v", response.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "prints", "1", "result.jpg")); err != nil {
+		t.Errorf("Expected the photo to be stored in the project: %v", err)
+	}
+
+	// A second photo with the same name is numbered rather than overwritten
+	uploadPrintMedia(t, router, "/api/prints/1/media", map[string]string{"result.jpg": "jpeg2"})
+	if _, err := os.Stat(filepath.Join(tmpDir, "prints", "1", "result_1.jpg")); err != nil {
+		t.Errorf("Expected the second photo to be numbered: %v", err)
+	}
+
+	w = sendJSON(router, "GET", "/api/prints", "")
+	var history struct {
+		Prints []models.PrintJob `json:"prints"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history.Prints) != 1 || len(history.Prints[0].Media) != 2 {
+		t.Errorf("Expected the print history to list the media, got %s", w.Body.String())
+	}
+
+	w = sendJSON(router, "GET", "/api/prints/1/media/1", "")
+	if w.Code != http.StatusOK || w.Body.String() != "jpeg" {
+		t.Errorf("Expected the photo to be served, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := uploadPrintMedia(t, router, "/api/prints/1/media", map[string]string{"notes.txt": "text"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d when nothing is attached, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := uploadPrintMedia(t, router, "/api/prints/9/media", map[string]string{"a.jpg": "jpeg"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown print, got %d", http.StatusNotFound, w.Code)
+	}
+
+	flat := models.Project{Name: "Loose", Path: filepath.Join(tmpDir, "loose.stl"), Layout: models.LayoutFlat}
+	db.Create(&flat)
+	flatJob := models.PrintJob{ProjectID: flat.ID, Outcome: models.PrintSucceeded}
+	flatJob.Validate()
+	db.Create(&flatJob)
+	if w := uploadPrintMedia(t, router, "/api/prints/2/media", map[string]string{"a.jpg": "jpeg"}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a flat project, got %d", http.StatusConflict, w.Code)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/prints/1/media/2", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "prints", "1", "result_1.jpg")); !os.IsNotExist(err) {
+		t.Error("Expected the deleted photo to be removed from disk")
+	}
+	if w := sendJSON(router, "DELETE", "/api/prints/2/media/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for media of another print, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// exifPhoto returns a JPEG carrying a GPS position in its EXIF data, and the
+// same JPEG without it
+func exifPhoto() (photo, stripped []byte) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	stripped = buf.Bytes()
+	exif := append([]byte{0xFF, 0xE1, 0x00, 0x18}, "Exif\x00\x00GPS 52.37N 4.89E"...)
+	photo = append(append(append([]byte{}, stripped[:2]...), exif...), stripped[2:]...)
+	return photo, stripped
+}
+
+// TestUploadPrintMediaStripsMetadata tests uploaded photos lose their EXIF data when normalizing is enabled
+func TestUploadPrintMediaStripsMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintsHandler()
+	handler.SetImageNormalizer(imaging.New(imaging.Options{StripMetadata: true}))
+	router.POST("/api/prints/:id/media", handler.UploadPrintMedia)
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	job := models.PrintJob{ProjectID: project.ID, Outcome: models.PrintSucceeded}
+	job.Validate()
+	db.Create(&job)
+
+	photo, encoded := exifPhoto()
+	w := uploadPrintMedia(t, router, "/api/prints/1/media", map[string]string{"result.jpg": string(photo)})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	stored, err := os.ReadFile(filepath.Join(tmpDir, "prints", "1", "result.jpg"))
+	if err != nil {
+		t.Fatalf("Expected the photo to be stored: %v", err)
+	}
+	if bytes.Contains(stored, []byte("GPS")) {
+		t.Error("Expected the EXIF data to be stripped")
+	}
+	if !bytes.Equal(stored, encoded) {
+		t.Error("Expected the rest of the photo to be kept")
+	}
+
+	// Files that only claim to be photos are rejected rather than stored as-is
+	w = uploadPrintMedia(t, router, "/api/prints/1/media", map[string]string{"fake.jpg": "jpeg"})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Failed to process image fake.jpg") {
+		t.Errorf("Expected the unreadable photo to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "prints", "1", "fake.jpg")); !os.IsNotExist(err) {
+		t.Error("Expected the rejected photo to be removed")
+	}
+}
+
+// TestPrintMediaGallery tests print results appear in the project summary gallery with a badge
+func TestPrintMediaGallery(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintMediaRouter(nil)
+
+	project := models.Project{Name: "Benchy", Path: "/test/benchy"}
+	db.Create(&project)
+	files := []models.ProjectFile{
+		{ProjectID: project.ID, Filename: "cover.png", Filepath: "/test/benchy/cover.png", FileType: models.FileTypeOther},
+		{ProjectID: project.ID, Filename: "prints/1/first.jpg", Filepath: "/test/benchy/prints/1/first.jpg", FileType: models.FileTypeOther},
+	}
+	db.Create(&files)
+	job := models.PrintJob{ProjectID: project.ID, Outcome: models.PrintSucceeded}
+	job.Validate()
+	db.Create(&job)
+	db.Create(&[]models.PrintMedia{
+		{PrintJobID: job.ID, ProjectID: project.ID, Filename: "prints/1/first.jpg", Kind: models.PrintMediaPhoto, Source: models.PrintMediaUploaded},
+		{PrintJobID: job.ID, ProjectID: project.ID, Filename: "prints/1/timelapse.mp4", Kind: models.PrintMediaVideo, Source: models.PrintMediaOctoPrint},
+	})
+
+	w := sendJSON(router, "GET", "/api/projects/1/summary", "")
+	var summary ProjectSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+
+	if len(summary.Gallery) != 3 {
+		t.Fatalf("Expected 3 gallery items without duplicates, got %+v", summary.Gallery)
+	}
+	if summary.Gallery[0].Filename != "cover.png" || summary.Gallery[0].PrintJobID != nil {
+		t.Errorf("Expected the project image without a badge, got %+v", summary.Gallery[0])
+	}
+	if photo := summary.Gallery[1]; photo.PrintJobID == nil || photo.PrintOutcome != models.PrintSucceeded || photo.URL != "/api/projects/1/files/2/download" {
+		t.Errorf("Expected the scanned print photo to be badged, got %+v", photo)
+	}
+	if video := summary.Gallery[2]; video.Kind != models.PrintMediaVideo || video.URL != "/api/prints/1/media/2" {
+		t.Errorf("Expected the time-lapse served from the print, got %+v", video)
+	}
+}
+
+// TestImportOctoPrintTimelapse tests pulling the latest time-lapse from OctoPrint
+func TestImportOctoPrintTimelapse(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/timelapse":
+			w.Write([]byte(`{"files": [
+				{"name": "old.mp4", "bytes": 3, "date": "2026-01-01 10:00", "url": "/downloads/timelapse/old.mp4"},
+				{"name": "benchy_20260301.mp4", "bytes": 5, "date": "2026-03-01 09:30", "url": "/downloads/timelapse/benchy_20260301.mp4"}
+			]}`))
+		case "/downloads/timelapse/benchy_20260301.mp4":
+			w.Write([]byte("video"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	job := models.PrintJob{ProjectID: project.ID}
+	job.Validate()
+	db.Create(&job)
+
+	if w := sendJSON(setupPrintMediaRouter(nil), "POST", "/api/prints/1/media/octoprint", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without OctoPrint, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	router := setupPrintMediaRouter(octoprint.New(server.URL, "secret"))
+	w := sendJSON(router, "POST", "/api/prints/1/media/octoprint", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"source":"octoprint"`) || !strings.Contains(w.Body.String(), `"kind":"video"`) {
+		t.Errorf("Expected an OctoPrint video, got %s", w.Body.String())
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, "prints", "1", "benchy_20260301.mp4"))
+	if err != nil || string(content) != "video" {
+		t.Errorf("Expected the latest time-lapse to be downloaded, got %q (%v)", content, err)
+	}
+
+	if w := sendJSON(router, "POST", "/api/prints/1/media/octoprint", `{"filename": "missing.mp4"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown time-lapse, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := sendJSON(setupPrintMediaRouter(octoprint.New(server.URL, "wrong")), "POST", "/api/prints/1/media/octoprint", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status %d when OctoPrint rejects the key, got %d", http.StatusBadGateway, w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrintTotals adds up the slicer estimates of a set of G-code files, as if
+// each were printed once
+type PrintTotals struct {
+	GCodeFiles int `json:"gcode_files"`
+
+	// UnestimatedFiles counts G-code without a print time estimate, which the
+	// totals leave out
+	UnestimatedFiles int `json:"unestimated_files"`
+
+	PrintTimeSeconds int64   `json:"print_time_seconds"`
+	FilamentGrams    float64 `json:"filament_grams"`
+	FilamentMM       float64 `json:"filament_mm"`
+}
+
+// readEstimates reads a G-code file's slicer estimates, which are empty when
+// the file can't be read
+func readEstimates(file models.ProjectFile) gcode.Metadata {
+	meta, err := readGCodeMetadata(file)
+	if err != nil {
+		fmt.Printf("Warning: Failed to read G-code metadata from %s: %v\n", file.Filepath, err)
+	}
+	return meta
+}
+
+// readGCodeMetadata returns a G-code file's slicer metadata as read when it
+// was scanned, reading the file for G-code that hasn't been scanned yet
+func readGCodeMetadata(file models.ProjectFile) (gcode.Metadata, error) {
+	if file.GCodeMetadata != nil {
+		return *file.GCodeMetadata, nil
+	}
+	return gcode.ReadMetadata(file.Filepath)
+}
+
+// add adds one G-code file's estimates to the totals
+func (t *PrintTotals) add(meta gcode.Metadata) {
+	t.GCodeFiles++
+	if meta.PrintTimeSeconds == 0 {
+		t.UnestimatedFiles++
+	}
+	t.PrintTimeSeconds += meta.PrintTimeSeconds
+	t.FilamentGrams += meta.FilamentGrams
+	t.FilamentMM += meta.FilamentMM
+}
+
+// projectPrintTotals adds up the estimates of a project's G-code files,
+// returning the metadata of each too
+func projectPrintTotals(files []models.ProjectFile) (PrintTotals, []PrintProfile) {
+	var totals PrintTotals
+	profiles := []PrintProfile{}
+	for _, file := range files {
+		if file.FileType == models.FileTypeGCode {
+			meta := readEstimates(file)
+			totals.add(meta)
+			profiles = append(profiles, PrintProfile{FileID: file.ID, Filename: file.Filename, Metadata: meta})
+		}
+	}
+	return totals, profiles
+}
+
+// LibraryStats is the print time and filament of the whole library, and of
+// the backlog of projects that have never been printed successfully
+type LibraryStats struct {
+	Projects        int         `json:"projects"`
+	Totals          PrintTotals `json:"totals"`
+	BacklogProjects int         `json:"backlog_projects"`
+	Backlog         PrintTotals `json:"backlog"`
+}
+
+// GetLibraryStats returns what printing every project's G-code once would take
+func (h *ProjectsHandler) GetLibraryStats(c *gin.Context) {
+	db := requestDB(c)
+
+	var projectIDs []uint
+	if err := db.Model(&models.Project{}).Pluck("id", &projectIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	var printedIDs []uint
+	if err := db.Model(&models.PrintJob{}).Where("outcome = ?", models.PrintSucceeded).Distinct().Pluck("project_id", &printedIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print history"})
+		return
+	}
+	printed := make(map[uint]bool, len(printedIDs))
+	for _, id := range printedIDs {
+		printed[id] = true
+	}
+
+	var files []models.ProjectFile
+	if err := db.Select("id", "project_id", "filepath", "gcode_metadata").Where("file_type = ?", models.FileTypeGCode).Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch G-code files"})
+		return
+	}
+
+	stats := LibraryStats{Projects: len(projectIDs)}
+	known := make(map[uint]bool, len(projectIDs))
+	for _, id := range projectIDs {
+		known[id] = true
+		if !printed[id] {
+			stats.BacklogProjects++
+		}
+	}
+	for _, file := range files {
+		// Files can outlive their soft-deleted project
+		if !known[file.ProjectID] {
+			continue
+		}
+		meta := readEstimates(file)
+		stats.Totals.add(meta)
+		if !printed[file.ProjectID] {
+			stats.Backlog.add(meta)
+		}
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
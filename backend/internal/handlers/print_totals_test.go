@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPrintTotals tests rolling up G-code estimates per project and across the library
+func TestPrintTotals(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/stats", handler.GetLibraryStats)
+	router.GET("/api/projects/:id/stats", handler.GetProjectStats)
+
+	writeGCode := func(project models.Project, name, content string) {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.FileTypeGCode})
+	}
+
+	benchy := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&benchy)
+	writeGCode(benchy, "hull.gcode", ";TIME:3600\n;Filament used: 2m\n")
+	writeGCode(benchy, "cabin.gcode", "; total filament used [g] = 5\n; estimated printing time (normal mode) = 30m 0s\n")
+	writeGCode(benchy, "empty.gcode", "G28\n")
+
+	printed := models.Project{Name: "Calibration cube", Path: filepath.Join(tmpDir, "cube")}
+	db.Create(&printed)
+	writeGCode(printed, "cube.gcode", ";TIME:600\n")
+	db.Create(&models.PrintJob{ProjectID: printed.ID, Outcome: models.PrintSucceeded})
+
+	t.Run("Project", func(t *testing.T) {
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/stats", benchy.ID), "")
+		var response struct {
+			PrintTotals PrintTotals `json:"print_totals"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		expected := PrintTotals{GCodeFiles: 3, UnestimatedFiles: 1, PrintTimeSeconds: 5400, FilamentGrams: 5, FilamentMM: 2000}
+		if response.PrintTotals != expected {
+			t.Errorf("Expected %+v, got %+v", expected, response.PrintTotals)
+		}
+	})
+
+	t.Run("Scanned", func(t *testing.T) {
+		// Metadata recorded by a scan is used without reading the file
+		scanned := models.Project{Name: "Scanned", Path: filepath.Join(tmpDir, "scanned")}
+		db.Create(&scanned)
+		meta := gcode.Metadata{Slicer: "PrusaSlicer 2.7.1", PrintTimeSeconds: 1200, NozzleTemp: 215}
+		db.Create(&models.ProjectFile{ProjectID: scanned.ID, Filename: "gone.gcode", Filepath: filepath.Join(tmpDir, "gone.gcode"), FileType: models.FileTypeGCode, GCodeMetadata: &meta})
+		defer db.Delete(&scanned)
+
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/stats", scanned.ID), "")
+		var response struct {
+			PrintTotals   PrintTotals    `json:"print_totals"`
+			PrintProfiles []PrintProfile `json:"print_profiles"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.PrintTotals.PrintTimeSeconds != 1200 || len(response.PrintProfiles) != 1 || response.PrintProfiles[0].Metadata != meta {
+			t.Errorf("Expected the scanned metadata, got %+v", response)
+		}
+	})
+
+	t.Run("Library", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/projects/stats", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var stats LibraryStats
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		if stats.Projects != 2 || stats.Totals.GCodeFiles != 4 || stats.Totals.PrintTimeSeconds != 6000 {
+			t.Errorf("Expected the whole library in the totals, got %+v", stats)
+		}
+		if stats.BacklogProjects != 1 || stats.Backlog.GCodeFiles != 3 || stats.Backlog.PrintTimeSeconds != 5400 {
+			t.Errorf("Expected only the unprinted project in the backlog, got %+v", stats)
+		}
+	})
+}
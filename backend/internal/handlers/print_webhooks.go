@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// printEvent is a printer's report of a print starting or ending, whichever
+// plugin sent it
+type printEvent struct {
+	// Outcome is PrintInProgress when the print started
+	Outcome models.PrintOutcome
+
+	// Path is the G-code file printed, relative to the printer's storage
+	Path string
+
+	// ExternalID ties the start and end reports of one print together
+	ExternalID string
+
+	At              time.Time
+	StartedAt       time.Time
+	DurationSeconds int64
+
+	// FilamentMM is how much filament the printer itself measured, if it did,
+	// and Progress how much of the print it finished, from 0 to 1, if it said
+	FilamentMM float64
+	Progress   float64
+
+	// Plate is the plate printed of a sliced 3MF
+	Plate int
+}
+
+// octoPrintWebhook is an event posted by an OctoPrint webhook plugin. Plugins
+// name the event in event or topic, as "PrintDone" or "Print Done", and pass
+// OctoPrint's event payload in payload or extra.
+type octoPrintWebhook struct {
+	Event   string                 `json:"event"`
+	Topic   string                 `json:"topic"`
+	Payload *octoPrintEventPayload `json:"payload"`
+	Extra   *octoPrintEventPayload `json:"extra"`
+}
+
+// octoPrintEventPayload is the part of OctoPrint's print event payload read
+type octoPrintEventPayload struct {
+	Name string  `json:"name"`
+	Path string  `json:"path"`
+	Time float64 `json:"time"`
+}
+
+// octoPrintOutcomes maps OctoPrint print events, lowercased without spaces
+var octoPrintOutcomes = map[string]models.PrintOutcome{
+	"printstarted":   models.PrintInProgress,
+	"printdone":      models.PrintSucceeded,
+	"printfailed":    models.PrintFailed,
+	"printcancelled": models.PrintCancelled,
+}
+
+// moonrakerWebhook is a job history entry posted by a Moonraker webhook
+// agent when a job is added (started) or finished
+type moonrakerWebhook struct {
+	Event  string       `json:"event"`
+	Action string       `json:"action"`
+	Job    moonrakerJob `json:"job"`
+}
+
+// moonrakerJob is the part of a Moonraker job history entry read
+type moonrakerJob struct {
+	JobID         string  `json:"job_id"`
+	Filename      string  `json:"filename"`
+	Status        string  `json:"status"`
+	StartTime     float64 `json:"start_time"`
+	EndTime       float64 `json:"end_time"`
+	PrintDuration float64 `json:"print_duration"`
+	FilamentUsed  float64 `json:"filament_used"`
+}
+
+// moonrakerOutcomes maps Moonraker job statuses
+var moonrakerOutcomes = map[string]models.PrintOutcome{
+	"in_progress":       models.PrintInProgress,
+	"completed":         models.PrintSucceeded,
+	"cancelled":         models.PrintCancelled,
+	"error":             models.PrintFailed,
+	"klippy_shutdown":   models.PrintFailed,
+	"klippy_disconnect": models.PrintFailed,
+	"server_exit":       models.PrintFailed,
+	"interrupted":       models.PrintFailed,
+}
+
+// OctoPrintWebhook records the print OctoPrint reports started or ended
+func (h *PrintsHandler) OctoPrintWebhook(c *gin.Context) {
+	var hook octoPrintWebhook
+	if err := c.ShouldBindJSON(&hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	name := hook.Event
+	if name == "" {
+		name = hook.Topic
+	}
+	outcome, ok := octoPrintOutcomes[strings.ToLower(strings.ReplaceAll(name, " ", ""))]
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored", "event": name})
+		return
+	}
+	payload := hook.Payload
+	if payload == nil {
+		payload = hook.Extra
+	}
+	if payload == nil || (payload.Path == "" && payload.Name == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Event payload with the printed file is required"})
+		return
+	}
+
+	event := printEvent{Outcome: outcome, Path: payload.Path, At: time.Now(), DurationSeconds: int64(payload.Time)}
+	if event.Path == "" {
+		event.Path = payload.Name
+	}
+	// OctoPrint has no job IDs: one printer prints one file at a time
+	event.ExternalID = "octoprint:" + event.Path
+	h.recordPrintEvent(c, event)
+}
+
+// MoonrakerWebhook records the print Moonraker reports started or ended
+func (h *PrintsHandler) MoonrakerWebhook(c *gin.Context) {
+	var hook moonrakerWebhook
+	if err := c.ShouldBindJSON(&hook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if hook.Job.Filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job with the printed file is required"})
+		return
+	}
+
+	outcome, ok := moonrakerOutcomes[hook.Job.Status]
+	switch action := strings.ToLower(hook.Event + hook.Action); {
+	case action == "started" || action == "added":
+		outcome, ok = models.PrintInProgress, true
+	case !ok:
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored", "status": hook.Job.Status})
+		return
+	}
+
+	event := printEvent{
+		Outcome:         outcome,
+		Path:            hook.Job.Filename,
+		ExternalID:      "moonraker:" + hook.Job.JobID,
+		At:              time.Now(),
+		StartedAt:       unixTime(hook.Job.StartTime),
+		DurationSeconds: int64(hook.Job.PrintDuration),
+		FilamentMM:      hook.Job.FilamentUsed,
+	}
+	if hook.Job.JobID == "" {
+		event.ExternalID = "moonraker:" + hook.Job.Filename
+	}
+	if end := unixTime(hook.Job.EndTime); !end.IsZero() {
+		event.At = end
+	}
+	if outcome == models.PrintInProgress && !event.StartedAt.IsZero() {
+		event.At = event.StartedAt
+	}
+	h.recordPrintEvent(c, event)
+}
+
+// unixTime converts Moonraker's fractional Unix timestamps; zero stays zero
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second)))
+}
+
+// printValidationError is a print an event would record that isn't valid
+type printValidationError struct{ error }
+
+// recordPrintEvent records the print the device token's printer reports, for
+// the G-code file in the token's projects it printed. A finished print's
+// filament is taken off the spool in ?filament_id=.
+func (h *PrintsHandler) recordPrintEvent(c *gin.Context, event printEvent) {
+	deviceToken := c.MustGet(middleware.DeviceTokenKey).(*models.DeviceToken)
+	db := requestDB(c)
+
+	var filamentID *uint
+	if raw := c.Query("filament_id"); raw != "" {
+		var filament models.Filament
+		if id, err := strconv.ParseUint(raw, 10, 64); err != nil || db.First(&filament, id).Error != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Filament not found"})
+			return
+		}
+		filamentID = &filament.ID
+	}
+
+	job, duplicate, err := savePrintEvent(db, deviceToken.Name, deviceToken.ProjectIDs, filamentID, event)
+	var invalid printValidationError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Printed file not found in the device's projects", "path": event.Path})
+		return
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record print"})
+		return
+	case duplicate:
+		// A repeated start report keeps the print already recorded
+		c.JSON(http.StatusOK, gin.H{"message": "Print already recorded", "print": job})
+		return
+	}
+
+	status := http.StatusOK
+	if event.Outcome == models.PrintInProgress {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"message": "Print recorded successfully", "print": job})
+}
+
+// savePrintEvent creates the print job of a started print, or finishes the
+// one its start created, for the file printer printed among the projects'
+// files, or all files when projectIDs is nil. A finished print's filament,
+// measured by the printer or else estimated by the slicer, is taken off the
+// spool filamentID. A repeated start reports the job already recorded as a
+// duplicate.
+func savePrintEvent(db *gorm.DB, printer string, projectIDs []uint, filamentID *uint, event printEvent) (models.PrintJob, bool, error) {
+	var job models.PrintJob
+	file, err := findPrintedFile(db, projectIDs, event.Path)
+	if err != nil {
+		return job, false, err
+	}
+	meta := printedFileMetadata(file, event.Plate)
+
+	err = db.Where("external_id = ? AND printer = ? AND outcome = ?", event.ExternalID, printer, models.PrintInProgress).
+		Order("started_at DESC").First(&job).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// The end of a print whose start wasn't reported gets a new record
+		job = models.PrintJob{
+			ProjectID:  file.ProjectID,
+			FileID:     &file.ID,
+			Printer:    printer,
+			Material:   meta.Material,
+			ExternalID: event.ExternalID,
+			StartedAt:  event.StartedAt,
+		}
+		if job.StartedAt.IsZero() {
+			job.StartedAt = event.At.Add(-time.Duration(event.DurationSeconds) * time.Second)
+		}
+	case err != nil:
+		return job, false, err
+	case event.Outcome == models.PrintInProgress:
+		return job, true, nil
+	}
+
+	if filamentID != nil {
+		job.FilamentID = filamentID
+	}
+	job.Outcome = event.Outcome
+	if event.Outcome != models.PrintInProgress {
+		finished := event.At
+		job.FinishedAt = &finished
+		job.DurationSeconds = event.DurationSeconds
+		if job.DurationSeconds == 0 {
+			job.DurationSeconds = int64(finished.Sub(job.StartedAt).Seconds())
+		}
+		job.FilamentGrams = usedFilamentGrams(meta, event)
+	}
+	if err := job.Validate(); err != nil {
+		return job, false, printValidationError{err}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		if job.FinishedAt == nil || job.FilamentID == nil || job.FilamentGrams <= 0 {
+			return nil
+		}
+		return tx.Model(&models.Filament{}).Where("id = ?", *job.FilamentID).
+			UpdateColumn("remaining_grams", gorm.Expr("MAX(remaining_grams - ?, 0)", job.FilamentGrams)).Error
+	})
+	if err == nil {
+		recordPrint(db, *file, job.StartedAt)
+	}
+	return job, false, err
+}
+
+// printedFileMetadata is the slicer metadata of the file printed: of the
+// plate printed, or else the first, for a sliced 3MF
+func printedFileMetadata(file *models.ProjectFile, plate int) gcode.Metadata {
+	if !gcode.IsPlateFile(file.Filename) {
+		meta, err := gcode.ReadMetadata(file.Filepath)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read G-code metadata from %s: %v\n", file.Filepath, err)
+		}
+		return meta
+	}
+
+	plates, err := gcode.ReadPlates(file.Filepath)
+	if err != nil {
+		fmt.Printf("Warning: Failed to read plates from %s: %v\n", file.Filepath, err)
+		return gcode.Metadata{}
+	}
+	for _, p := range plates {
+		if p.Index == plate {
+			return p.Metadata
+		}
+	}
+	if len(plates) > 0 {
+		return plates[0].Metadata
+	}
+	return gcode.Metadata{}
+}
+
+// findPrintedFile finds the G-code file or sliced 3MF a printer printed
+// among the projects' files, or all files when projectIDs is nil, by its path
+// or else its name
+func findPrintedFile(db *gorm.DB, projectIDs []uint, printed string) (*models.ProjectFile, error) {
+	printed = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(printed, "\\", "/")), "/")
+	name := path.Base(printed)
+
+	query := db.Where("file_type IN ?", []models.FileType{models.FileTypeGCode, models.FileType3MF})
+	if projectIDs != nil {
+		query = query.Where("project_id IN ?", projectIDs)
+	}
+	var files []models.ProjectFile
+	if err := query.Where("filename = ? OR filename = ? OR filename LIKE ?", printed, name, "%/"+name).
+		Order("id ASC").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	files = slices.DeleteFunc(files, func(file models.ProjectFile) bool {
+		return file.FileType == models.FileType3MF && !gcode.IsPlateFile(file.Filename)
+	})
+	var byName *models.ProjectFile
+	for i := range files {
+		if files[i].Filename == printed || strings.HasSuffix(printed, "/"+files[i].Filename) {
+			return &files[i], nil
+		}
+		if byName == nil && path.Base(files[i].Filename) == name {
+			byName = &files[i]
+		}
+	}
+	if byName == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return byName, nil
+}
+
+// usedFilamentGrams is what a finished print used: the slicer's estimate,
+// scaled by what the printer measured, or else the progress it reported,
+// when it did, so a print stopped halfway counts half
+func usedFilamentGrams(meta gcode.Metadata, event printEvent) float64 {
+	switch {
+	case event.FilamentMM > 0 && meta.FilamentMM > 0:
+		return meta.FilamentGrams * event.FilamentMM / meta.FilamentMM
+	case event.Progress > 0 && event.Outcome != models.PrintSucceeded:
+		return meta.FilamentGrams * min(event.Progress, 1)
+	case event.Outcome == models.PrintSucceeded:
+		return meta.FilamentGrams
+	default:
+		return 0
+	}
+}
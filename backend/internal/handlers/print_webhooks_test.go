@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/middleware"
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPrintWebhooks routes the webhooks behind a device token for a project
+// with one G-code file, returning the router, token, project and spool
+func setupPrintWebhooks(t *testing.T) (*gin.Engine, string, models.Project, models.Filament) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintsHandler()
+	device := router.Group("/api/device", middleware.RequireDeviceToken(db))
+	device.POST("/webhooks/octoprint", handler.OctoPrintWebhook)
+	device.POST("/webhooks/moonraker", handler.MoonrakerWebhook)
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	path := filepath.Join(tmpDir, "gear.gcode")
+	os.WriteFile(path, []byte("; filament used [mm] = 2000\n; filament used [g] = 20\n; filament_type = PETG\nG28\n"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "plates/gear.gcode", Filepath: path, FileType: models.FileTypeGCode})
+
+	filament := models.Filament{Name: "Black PETG", Material: "PETG", WeightGrams: 1000, RemainingGrams: 100}
+	db.Create(&filament)
+
+	token := "printer-token"
+	db.Create(&models.DeviceToken{Name: "MK4", TokenHash: models.HashDeviceToken(token), ProjectIDs: []uint{project.ID}})
+	return router, token, project, filament
+}
+
+// postWebhook posts a webhook body with the device token
+func postWebhook(router *gin.Engine, token, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.DeviceTokenHeader, token)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestOctoPrintWebhook tests recording an OctoPrint print from start to finish
+func TestOctoPrintWebhook(t *testing.T) {
+	router, token, project, filament := setupPrintWebhooks(t)
+	url := fmt.Sprintf("/api/device/webhooks/octoprint?filament_id=%d", filament.ID)
+
+	w := postWebhook(router, token, url, `{"topic": "Print Started", "extra": {"name": "gear.gcode", "path": "plates/gear.gcode"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var started struct {
+		Print models.PrintJob `json:"print"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &started)
+	if started.Print.Outcome != models.PrintInProgress || started.Print.ProjectID != project.ID || started.Print.Printer != "MK4" || started.Print.Material != "PETG" {
+		t.Errorf("Unexpected started print: %+v", started.Print)
+	}
+
+	w = postWebhook(router, token, url, `{"event": "PrintDone", "payload": {"name": "gear.gcode", "path": "plates/gear.gcode", "time": 3600}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var finished struct {
+		Print models.PrintJob `json:"print"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &finished)
+	if finished.Print.ID != started.Print.ID || finished.Print.Outcome != models.PrintSucceeded || finished.Print.DurationSeconds != 3600 || finished.Print.FilamentGrams != 20 {
+		t.Errorf("Expected the started print finished with the slicer's estimate, got %+v", finished.Print)
+	}
+
+	var spool models.Filament
+	database.GetDB().First(&spool, filament.ID)
+	if spool.RemainingGrams != 80 {
+		t.Errorf("Expected 20g taken off the spool, got %v remaining", spool.RemainingGrams)
+	}
+
+	testCases := []struct {
+		name         string
+		token        string
+		body         string
+		expectedCode int
+	}{
+		{"Ignored event", token, `{"event": "PrintPaused", "payload": {"path": "plates/gear.gcode"}}`, http.StatusOK},
+		{"Unknown file", token, `{"event": "PrintStarted", "payload": {"path": "other.gcode"}}`, http.StatusNotFound},
+		{"No payload", token, `{"event": "PrintStarted"}`, http.StatusBadRequest},
+		{"No token", "", `{"event": "PrintStarted", "payload": {"path": "plates/gear.gcode"}}`, http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		if w := postWebhook(router, tc.token, "/api/device/webhooks/octoprint", tc.body); w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expectedCode, w.Code)
+		}
+	}
+}
+
+// TestMoonrakerWebhook tests that a cancelled Klipper print counts the
+// filament the printer measured
+func TestMoonrakerWebhook(t *testing.T) {
+	router, token, _, filament := setupPrintWebhooks(t)
+	url := fmt.Sprintf("/api/device/webhooks/moonraker?filament_id=%d", filament.ID)
+
+	w := postWebhook(router, token, url, `{"action": "added", "job": {"job_id": "00001A", "filename": "gear.gcode", "status": "in_progress", "start_time": 1700000000}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = postWebhook(router, token, url, `{"action": "finished", "job": {"job_id": "00001A", "filename": "gear.gcode", "status": "cancelled", "start_time": 1700000000, "end_time": 1700001800, "print_duration": 1700, "filament_used": 500}}`)
+	var finished struct {
+		Print models.PrintJob `json:"print"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &finished)
+	if w.Code != http.StatusOK || finished.Print.Outcome != models.PrintCancelled || finished.Print.FilamentGrams != 5 || finished.Print.DurationSeconds != 1700 {
+		t.Fatalf("Expected a cancelled print with a quarter of the filament, got %d: %s", w.Code, w.Body.String())
+	}
+	if finished.Print.StartedAt.Unix() != 1700000000 || finished.Print.FinishedAt.Unix() != 1700001800 {
+		t.Errorf("Expected the printer's timestamps, got %v to %v", finished.Print.StartedAt, finished.Print.FinishedAt)
+	}
+
+	var prints int64
+	database.GetDB().Model(&models.PrintJob{}).Count(&prints)
+	if prints != 1 {
+		t.Errorf("Expected one print recorded, got %d", prints)
+	}
+}
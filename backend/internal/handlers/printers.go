@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ModelFit tells whether a model fits a printer's build volume
+type ModelFit struct {
+	FileID    uint   `json:"file_id"`
+	ProjectID uint   `json:"project_id"`
+	Filename  string `json:"filename"`
+
+	// Size is the model's bounding box along X, Y and Z in millimetres, and
+	// Fits whether it fits the build volume; both are unset for a model that
+	// can't be read, whose Error tells why
+	Size  []float64 `json:"size,omitempty"`
+	Fits  *bool     `json:"fits"`
+	Error string    `json:"error,omitempty"`
+}
+
+// PrintersHandler handles registered printer HTTP requests
+type PrintersHandler struct{}
+
+// NewPrintersHandler creates a new PrintersHandler
+func NewPrintersHandler() *PrintersHandler {
+	return &PrintersHandler{}
+}
+
+// GetPrinters returns the registered printers by name
+func (h *PrintersHandler) GetPrinters(c *gin.Context) {
+	var printers []models.Printer
+	if err := requestDB(c).Order("name ASC").Find(&printers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch printers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"printers": printers,
+		"count":    len(printers),
+	})
+}
+
+// CreatePrinter registers a printer and its build volume
+func (h *PrintersHandler) CreatePrinter(c *gin.Context) {
+	var printer models.Printer
+	if err := c.ShouldBindJSON(&printer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	printer.ID = 0
+
+	if !savePrinter(c, &printer) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Printer created successfully",
+		"printer": printer,
+	})
+}
+
+// UpdatePrinter replaces a printer's name, build volume and notes
+func (h *PrintersHandler) UpdatePrinter(c *gin.Context) {
+	var existing models.Printer
+	if err := requestDB(c).First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Printer not found"})
+		return
+	}
+
+	var printer models.Printer
+	if err := c.ShouldBindJSON(&printer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	printer.ID = existing.ID
+	printer.CreatedAt = existing.CreatedAt
+
+	if !savePrinter(c, &printer) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Printer updated successfully",
+		"printer": printer,
+	})
+}
+
+// DeletePrinter removes a registered printer
+func (h *PrintersHandler) DeletePrinter(c *gin.Context) {
+	result := requestDB(c).Delete(&models.Printer{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete printer"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Printer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Printer deleted successfully"})
+}
+
+// GetPrinterCompatibility checks the STL models of the library, or of one
+// ?project_id=, against a printer's build volume, flagging those that don't fit
+func (h *PrintersHandler) GetPrinterCompatibility(c *gin.Context) {
+	db := requestDB(c)
+
+	var printer models.Printer
+	if err := db.First(&printer, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Printer not found"})
+		return
+	}
+
+	query := db.Where("file_type = ?", models.FileTypeSTL)
+	if raw := c.Query("project_id"); raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project_id"})
+			return
+		}
+		query = query.Where("project_id = ?", projectID)
+	}
+	var files []models.ProjectFile
+	if err := query.Order("id ASC").Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch models"})
+		return
+	}
+
+	fits, err := printerFits(db, printer, files)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check models"})
+		return
+	}
+	tooLarge := 0
+	for _, fit := range fits {
+		if fit.Fits != nil && !*fit.Fits {
+			tooLarge++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"printer":   printer,
+		"files":     fits,
+		"count":     len(fits),
+		"too_large": tooLarge,
+	})
+}
+
+// savePrinter validates and stores a printer, writing the error response and
+// returning false when it is invalid or its name is taken
+func savePrinter(c *gin.Context, printer *models.Printer) bool {
+	if err := printer.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	db := requestDB(c)
+	var taken int64
+	if err := db.Model(&models.Printer{}).Where("name = ? COLLATE NOCASE AND id <> ?", printer.Name, printer.ID).
+		Count(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save printer"})
+		return false
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A printer with this name already exists"})
+		return false
+	}
+
+	if err := db.Save(printer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save printer"})
+		return false
+	}
+	return true
+}
+
+// printerFits checks each STL file against a printer's build volume. Sizes
+// are read from the geometry cache, reading models not in it yet; models that
+// can't be read are reported with their error rather than failing the check.
+func printerFits(db *gorm.DB, printer models.Printer, files []models.ProjectFile) ([]ModelFit, error) {
+	fits := make([]ModelFit, 0, len(files))
+	for _, file := range files {
+		if file.FileType != models.FileTypeSTL {
+			continue
+		}
+		fit := ModelFit{FileID: file.ID, ProjectID: file.ProjectID, Filename: file.Filename}
+
+		geometry, err := modelGeometry(db, file)
+		switch {
+		case err == nil:
+			fits := printer.Fits([3]float64(geometry.Size))
+			fit.Size = geometry.Size
+			fit.Fits = &fits
+		case geometry.Error != "":
+			fit.Error = err.Error()
+		default:
+			return nil, err
+		}
+		fits = append(fits, fit)
+	}
+	return fits, nil
+}
+
+// printableOn reads the ?printable_on= printer filter of file and project
+// listings. It writes the error response and returns false when the printer
+// doesn't exist; without the filter it returns a nil printer.
+func printableOn(c *gin.Context) (*models.Printer, bool) {
+	raw := strings.TrimSpace(c.Query("printable_on"))
+	if raw == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid printable_on"})
+		return nil, false
+	}
+	var printer models.Printer
+	if err := requestDB(c).First(&printer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Printer not found", "printable_on": raw})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch printer"})
+		}
+		return nil, false
+	}
+	return &printer, true
+}
+
+// fittingFileIDs returns the IDs of the files that are STL models fitting the printer
+func fittingFileIDs(db *gorm.DB, printer models.Printer, files []models.ProjectFile) ([]uint, error) {
+	fits, err := printerFits(db, printer, files)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{}
+	for _, fit := range fits {
+		if fit.Fits != nil && *fit.Fits {
+			ids = append(ids, fit.FileID)
+		}
+	}
+	return ids, nil
+}
+
+// printableProjectIDs returns the IDs of the projects with STL models that
+// all fit the printer; models that can't be read are left out of the check
+func printableProjectIDs(db *gorm.DB, printer models.Printer) ([]uint, error) {
+	var files []models.ProjectFile
+	if err := db.Where("file_type = ?", models.FileTypeSTL).Order("id ASC").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	fits, err := printerFits(db, printer, files)
+	if err != nil {
+		return nil, err
+	}
+
+	printable := make(map[uint]bool)
+	for _, fit := range fits {
+		if fit.Fits == nil {
+			continue
+		}
+		if fitsSoFar, seen := printable[fit.ProjectID]; !seen || fitsSoFar {
+			printable[fit.ProjectID] = *fit.Fits
+		}
+	}
+	ids := []uint{}
+	for id, fits := range printable {
+		if fits {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPrinters tests registering printers and checking models against their build volume
+func TestPrinters(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintersHandler()
+	router.GET("/api/printers", handler.GetPrinters)
+	router.POST("/api/printers", handler.CreatePrinter)
+	router.PUT("/api/printers/:id", handler.UpdatePrinter)
+	router.DELETE("/api/printers/:id", handler.DeletePrinter)
+	router.GET("/api/printers/:id/compatibility", handler.GetPrinterCompatibility)
+	projects := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects", projects.GetProjects)
+	router.GET("/api/projects/:id/files", projects.GetProjectFiles)
+
+	for _, body := range []string{
+		`{"name": "Mini", "build_x_mm": 180, "build_y_mm": 180, "build_z_mm": 180}`,
+		`{"name": "MK4", "build_x_mm": 250, "build_y_mm": 210, "build_z_mm": 220}`,
+	} {
+		if w := sendJSON(router, "POST", "/api/printers", body); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	if w := sendJSON(router, "POST", "/api/printers", `{"name": "mini", "build_x_mm": 1, "build_y_mm": 1, "build_z_mm": 1}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a taken name, got %d", http.StatusConflict, w.Code)
+	}
+	if w := sendJSON(router, "POST", "/api/printers", `{"name": "Flat", "build_x_mm": 100, "build_y_mm": 100}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without a height, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	small := models.Project{Name: "Small", Path: filepath.Join(tmpDir, "small")}
+	large := models.Project{Name: "Large", Path: filepath.Join(tmpDir, "large")}
+	db.Create(&small)
+	db.Create(&large)
+	for _, m := range []struct {
+		project *models.Project
+		name    string
+		mesh    *mesh.Mesh
+	}{
+		{&small, "cube.stl", testBox(20, 20, 20, mesh.Vec3{})},
+		{&large, "cube.stl", testBox(20, 20, 20, mesh.Vec3{})},
+		// Fits the MK4 only when turned a quarter turn
+		{&large, "panel.stl", testBox(200, 240, 5, mesh.Vec3{-100, -120, 0})},
+	} {
+		path := filepath.Join(tmpDir, m.project.Name+"_"+m.name)
+		out, _ := os.Create(path)
+		mesh.WriteBinarySTL(out, m.mesh)
+		out.Close()
+		db.Create(&models.ProjectFile{ProjectID: m.project.ID, Filename: m.name, Filepath: path, Hash: "hash-" + m.project.Name + m.name, FileType: models.FileTypeSTL})
+	}
+	db.Create(&models.ProjectFile{ProjectID: large.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME})
+
+	w := sendJSON(router, "GET", "/api/printers/1/compatibility", "")
+	var compatibility struct {
+		Files    []ModelFit `json:"files"`
+		TooLarge int        `json:"too_large"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &compatibility)
+	if w.Code != http.StatusOK || len(compatibility.Files) != 3 || compatibility.TooLarge != 1 ||
+		*compatibility.Files[2].Fits || compatibility.Files[2].Size[1] != 240 {
+		t.Errorf("Expected the panel flagged as too large for the Mini, got %d: %s", w.Code, w.Body.String())
+	}
+
+	listed := func(path string) int {
+		w := sendJSON(router, "GET", path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", path, http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Count int `json:"count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Count
+	}
+	if count := listed("/api/projects?printable_on=1"); count != 1 {
+		t.Errorf("Expected only the small project printable on the Mini, got %d", count)
+	}
+	if count := listed("/api/projects?printable_on=2"); count != 2 {
+		t.Errorf("Expected both projects printable on the MK4, got %d", count)
+	}
+	if count := listed("/api/projects/2/files?printable_on=1"); count != 1 {
+		t.Errorf("Expected one model of the large project to fit the Mini, got %d", count)
+	}
+	if count := listed("/api/projects/2/files"); count != 3 {
+		t.Errorf("Expected every file without the filter, got %d", count)
+	}
+	if w := sendJSON(router, "GET", "/api/projects?printable_on=99", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown printer, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// A larger bed fits everything
+	if w := sendJSON(router, "PUT", "/api/printers/1", `{"name": "Mini+", "build_x_mm": 300, "build_y_mm": 300, "build_z_mm": 300}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if count := listed("/api/projects?printable_on=1"); count != 2 {
+		t.Errorf("Expected both projects printable on the enlarged printer, got %d", count)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/printers/2", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if count := listed("/api/printers"); count != 1 {
+		t.Errorf("Expected 1 printer left, got %d", count)
+	}
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/octoprint"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPrintLimit = 100
+	maxPrintLimit     = 1000
+)
+
+// printExportColumns are the header row of the print history spreadsheet
+var printExportColumns = []string{
+	"id", "started_at", "finished_at", "project_id", "project", "file", "printer", "material",
+	"filament_grams", "duration_seconds", "duration", "cost", "outcome", "notes", "failure_reasons",
+}
+
+// PrintsHandler handles print history HTTP requests
+type PrintsHandler struct {
+	// octoprint is where time-lapses are pulled from; nil when not configured
+	octoprint *octoprint.Client
+
+	// images normalizes uploaded photos; nil leaves them as uploaded
+	images *imaging.Normalizer
+
+	// maxUploadFiles bounds the photos and videos of one upload request; 0 allows any number
+	maxUploadFiles int
+}
+
+// NewPrintsHandler creates a new PrintsHandler
+func NewPrintsHandler() *PrintsHandler {
+	return &PrintsHandler{maxUploadFiles: DefaultMaxUploadFiles}
+}
+
+// SetMaxUploadFiles bounds how many photos and videos one upload request may carry
+func (h *PrintsHandler) SetMaxUploadFiles(maxFiles int) {
+	h.maxUploadFiles = maxFiles
+}
+
+// RecordPrint stores a print job for a project
+func (h *PrintsHandler) RecordPrint(c *gin.Context) {
+	var job models.PrintJob
+	if err := c.ShouldBindJSON(&job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	job.ID = 0
+
+	if err := job.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := requestDB(c)
+
+	var project models.Project
+	if err := db.First(&project, job.ProjectID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		return
+	}
+	var file models.ProjectFile
+	if job.FileID != nil {
+		if err := db.Where("id = ? AND project_id = ?", *job.FileID, project.ID).First(&file).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found in project"})
+			return
+		}
+	}
+	if job.OrderID != nil {
+		if err := db.First(&models.Order{}, *job.OrderID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order not found"})
+			return
+		}
+	}
+
+	if err := db.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record print"})
+		return
+	}
+	if job.FileID != nil {
+		recordPrint(db, file, job.StartedAt)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Print recorded successfully",
+		"print":   job,
+	})
+}
+
+// GetPrints returns recorded print jobs, most recent first
+func (h *PrintsHandler) GetPrints(c *gin.Context) {
+	limit := defaultPrintLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPrintLimit)
+	}
+
+	query, err := filterPrints(c, requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	var jobs []models.PrintJob
+	if err := query.Preload("Media").Order("started_at DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+	for i := range jobs {
+		jobs[i].Display = printDisplay(prefs, jobs[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prints": jobs,
+		"count":  len(jobs),
+	})
+}
+
+// ExportPrints streams the print history over a date range as a CSV spreadsheet
+func (h *PrintsHandler) ExportPrints(c *gin.Context) {
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported export format: %s", format)})
+		return
+	}
+
+	query, err := filterPrints(c, requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var jobs []models.PrintJob
+	if err := query.Preload("Project", func(db *gorm.DB) *gorm.DB {
+		// Keep project names for prints of projects deleted since
+		return db.Unscoped()
+	}).Order("started_at ASC").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+
+	filenames, err := printFilenames(requestDB(c), jobs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"prints-%s.csv\"", time.Now().Format("2006-01-02")))
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(printExportColumns)
+	for _, job := range jobs {
+		finishedAt := ""
+		if job.FinishedAt != nil {
+			finishedAt = job.FinishedAt.Format(time.RFC3339)
+		}
+		file := ""
+		if job.FileID != nil {
+			file = filenames[*job.FileID]
+		}
+
+		writer.Write([]string{
+			strconv.FormatUint(uint64(job.ID), 10),
+			job.StartedAt.Format(time.RFC3339),
+			finishedAt,
+			strconv.FormatUint(uint64(job.ProjectID), 10),
+			spreadsheetSafe(job.Project.Name),
+			spreadsheetSafe(file),
+			spreadsheetSafe(job.Printer),
+			spreadsheetSafe(job.Material),
+			strconv.FormatFloat(job.FilamentGrams, 'f', 2, 64),
+			strconv.FormatInt(job.DurationSeconds, 10),
+			formatPrintDuration(job.DurationSeconds),
+			strconv.FormatFloat(job.Cost, 'f', 2, 64),
+			string(job.Outcome),
+			spreadsheetSafe(job.Notes),
+			joinFailureReasons(job.FailureReasons),
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		fmt.Printf("Error writing print export: %v\n", err)
+	}
+}
+
+// filterPrints applies the ?from=, ?to=, ?project_id= and ?outcome= filters
+func filterPrints(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	if raw := c.Query("from"); raw != "" {
+		from, _, err := parsePrintDate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date")
+		}
+		db = db.Where("started_at >= ?", from)
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, dateOnly, err := parsePrintDate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date")
+		}
+		// A plain date includes the whole day
+		if dateOnly {
+			db = db.Where("started_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			db = db.Where("started_at <= ?", to)
+		}
+	}
+	if raw := c.Query("project_id"); raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project_id")
+		}
+		db = db.Where("project_id = ?", projectID)
+	}
+	if outcome := c.Query("outcome"); outcome != "" {
+		db = db.Where("outcome = ?", outcome)
+	}
+	return db, nil
+}
+
+// parsePrintDate accepts YYYY-MM-DD or RFC 3339 timestamps, reporting which was given
+func parsePrintDate(raw string) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return date, true, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, raw)
+	return timestamp, false, err
+}
+
+// printFilenames looks up the names of the files printed by jobs
+func printFilenames(db *gorm.DB, jobs []models.PrintJob) (map[uint]string, error) {
+	var ids []uint
+	for _, job := range jobs {
+		if job.FileID != nil {
+			ids = append(ids, *job.FileID)
+		}
+	}
+
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	var files []models.ProjectFile
+	if err := db.Select("id", "filename").Find(&files, ids).Error; err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		names[file.ID] = file.Filename
+	}
+	return names, nil
+}
+
+// joinFailureReasons lists failure reasons in one spreadsheet cell
+func joinFailureReasons(reasons []models.FailureReason) string {
+	names := make([]string, len(reasons))
+	for i, reason := range reasons {
+		names[i] = string(reason)
+	}
+	return strings.Join(names, "; ")
+}
+
+// formatPrintDuration renders seconds as H:MM:SS for spreadsheets
+func formatPrintDuration(seconds int64) string {
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// spreadsheetSafe keeps user text from being evaluated as a formula when the export is opened
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
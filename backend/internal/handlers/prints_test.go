@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPrintsRouter creates a router with the print history routes
+func setupPrintsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewPrintsHandler()
+	router.GET("/api/prints", handler.GetPrints)
+	router.POST("/api/prints", handler.RecordPrint)
+	router.GET("/api/prints/export", handler.ExportPrints)
+	return router
+}
+
+// TestRecordPrint tests recording print jobs
+func TestRecordPrint(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintsRouter()
+
+	project := models.Project{Name: "Benchy", Path: "/test/benchy"}
+	db.Create(&project)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.gcode", Filepath: "/test/benchy/benchy.gcode", FileType: models.FileTypeGCode}
+	db.Create(&file)
+	other := models.Project{Name: "Other", Path: "/test/other"}
+	db.Create(&other)
+
+	w := sendJSON(router, "POST", "/api/prints", `{"project_id": 1, "file_id": 1, "printer": " MK4 ", "material": "PLA", "filament_grams": 12.5, "duration_seconds": 4500, "cost": 0.31}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Print models.PrintJob `json:"print"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Print.Outcome != models.PrintSucceeded || response.Print.Printer != "MK4" || response.Print.StartedAt.IsZero() {
+		t.Errorf("Expected defaults to be filled in, got %+v", response.Print)
+	}
+
+	testCases := map[string]string{
+		"Unknown project":    `{"project_id": 999}`,
+		"File from another":  `{"project_id": 2, "file_id": 1}`,
+		"Unknown outcome":    `{"project_id": 1, "outcome": "exploded"}`,
+		"Negative filament":  `{"project_id": 1, "filament_grams": -1}`,
+		"Finished too early": `{"project_id": 1, "started_at": "2026-01-02T10:00:00Z", "finished_at": "2026-01-02T09:00:00Z"}`,
+		"Invalid body":       `not json`,
+	}
+	for name, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/prints", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestExportPrints tests the CSV print history export over a date range
+func TestExportPrints(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintsRouter()
+
+	project := models.Project{Name: "Benchy", Path: "/test/benchy"}
+	db.Create(&project)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.gcode", Filepath: "/test/benchy/benchy.gcode", FileType: models.FileTypeGCode}
+	db.Create(&file)
+
+	day := func(d int, hour int) time.Time {
+		return time.Date(2026, time.March, d, hour, 0, 0, 0, time.Local)
+	}
+	finished := day(2, 11)
+	jobs := []models.PrintJob{
+		{ProjectID: project.ID, FileID: &file.ID, Printer: "MK4", Material: "PLA", FilamentGrams: 12.5, DurationSeconds: 3725, Cost: 0.3, Outcome: models.PrintSucceeded, StartedAt: day(2, 10), FinishedAt: &finished},
+		{ProjectID: project.ID, Printer: "MK4", Material: "PETG", Outcome: models.PrintFailed, Notes: "=HYPERLINK(\"x\")", StartedAt: day(3, 23)},
+		{ProjectID: project.ID, Printer: "Mini", Material: "PLA", Outcome: models.PrintSucceeded, StartedAt: day(10, 9)},
+	}
+	for i := range jobs {
+		db.Create(&jobs[i])
+	}
+
+	// Deleted projects keep their name in the export
+	db.Delete(&project)
+
+	w := sendJSON(router, "GET", "/api/prints/export?format=csv&from=2026-03-01&to=2026-03-03", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV content type, got %s", w.Header().Get("Content-Type"))
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d rows", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(printExportColumns, ",") {
+		t.Errorf("Unexpected header: %v", rows[0])
+	}
+
+	first := rows[1]
+	if first[4] != "Benchy" || first[5] != "benchy.gcode" || first[8] != "12.50" || first[10] != "1:02:05" || first[12] != "success" {
+		t.Errorf("Unexpected first row: %v", first)
+	}
+	if notes := rows[2][13]; notes != "'=HYPERLINK(\"x\")" {
+		t.Errorf("Expected formula to be neutralised, got %q", notes)
+	}
+
+	if w := sendJSON(router, "GET", "/api/prints/export?format=xlsx", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unsupported format, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := sendJSON(router, "GET", "/api/prints/export?from=yesterday", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid date, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestGetPrints tests listing print jobs with filters
+func TestGetPrints(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupPrintsRouter()
+
+	db.Create(&models.PrintJob{ProjectID: 1, Outcome: models.PrintSucceeded, StartedAt: time.Now().Add(-time.Hour)})
+	db.Create(&models.PrintJob{ProjectID: 1, Outcome: models.PrintFailed, StartedAt: time.Now()})
+	db.Create(&models.PrintJob{ProjectID: 2, Outcome: models.PrintFailed, StartedAt: time.Now()})
+
+	w := sendJSON(router, "GET", "/api/prints?project_id=1&outcome=failed", "")
+	var response struct {
+		Prints []models.PrintJob `json:"prints"`
+		Count  int               `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 1 || response.Prints[0].ProjectID != 1 || response.Prints[0].Outcome != models.PrintFailed {
+		t.Errorf("Unexpected filtered prints: %+v", response)
+	}
+
+	if w := sendJSON(router, "GET", "/api/prints?limit=0", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
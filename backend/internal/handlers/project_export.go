@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// exportFileTypes are the file types a project ZIP is limited to; nil
+// includes every file
+type exportFileTypes map[models.FileType]bool
+
+// parseExportFileTypes reads the comma-separated ?types= of a project ZIP
+func parseExportFileTypes(raw string) (exportFileTypes, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	types := make(exportFileTypes)
+	for _, part := range strings.Split(raw, ",") {
+		fileType := models.FileType(strings.ToLower(strings.TrimSpace(part)))
+		switch fileType {
+		case models.FileTypeSTL, models.FileType3MF, models.FileTypeGCode, models.FileTypeCAD, models.FileTypeREADME, models.FileTypeImage, models.FileTypeOther:
+			types[fileType] = true
+		default:
+			return nil, fmt.Errorf("unsupported file type: %s", part)
+		}
+	}
+	return types, nil
+}
+
+// includes reports whether the file at a path relative to the project goes
+// into the ZIP. Files are typed by name, as scans type them.
+func (t exportFileTypes) includes(relPath string) bool {
+	return t == nil || t[models.GetFileTypeFromExtension(filepath.Base(relPath))]
+}
+
+// files returns the project files of the selected types
+func (t exportFileTypes) files(files []models.ProjectFile) []models.ProjectFile {
+	if t == nil {
+		return files
+	}
+	selected := make([]models.ProjectFile, 0, len(files))
+	for _, file := range files {
+		if t[file.FileType] {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
+
+// profiles returns the print profiles of the files included
+func (t exportFileTypes) profiles(profiles map[string]models.FileProfile) map[string]models.FileProfile {
+	if t == nil {
+		return profiles
+	}
+	selected := make(map[string]models.FileProfile, len(profiles))
+	for filename, profile := range profiles {
+		if t.includes(filename) {
+			selected[filename] = profile
+		}
+	}
+	return selected
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestExportProject tests exporting a project as a ZIP, whole or by file type
+func TestExportProject(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/export", handler.DownloadProject)
+
+	projectDir := filepath.Join(tmpDir, "Bracket")
+	os.MkdirAll(filepath.Join(projectDir, "sliced"), 0755)
+	for name, content := range map[string]string{
+		"README.md":            "# Bracket",
+		"bracket.stl":          "solid bracket",
+		"sliced/bracket.gcode": "G28",
+		"photo.jpg":            "JPEG",
+	} {
+		os.WriteFile(filepath.Join(projectDir, filepath.FromSlash(name)), []byte(content), 0644)
+	}
+	scanner.New(db, tmpDir).Run(models.ScanTriggerManual)
+	var project models.Project
+	db.First(&project)
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "bracket.stl", Notes: "No supports"})
+
+	export := func(query string) (int, []string) {
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/export%s", project.ID, query), "")
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to read ZIP: %v", err)
+		}
+		names := make([]string, 0, len(archive.File))
+		for _, entry := range archive.File {
+			names = append(names, entry.Name)
+		}
+		sort.Strings(names)
+		return w.Code, names
+	}
+
+	_, names := export("")
+	expected := []string{fileProfilesExport, "README.md", "bracket.stl", "photo.jpg", "sliced/bracket.gcode"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the whole project %v, got %v", expected, names)
+	}
+
+	// Profiles only travel with the models they belong to
+	if _, names = export("?types=gcode,%20README"); !reflect.DeepEqual(names, []string{"README.md", "sliced/bracket.gcode"}) {
+		t.Errorf("Expected only the G-code and README, got %v", names)
+	}
+	if _, names = export("?types=stl"); !reflect.DeepEqual(names, []string{fileProfilesExport, "bracket.stl"}) {
+		t.Errorf("Expected only the model and its profile, got %v", names)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		if code, _ := export("?types=stl,obj"); code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an unknown type, got %d", http.StatusBadRequest, code)
+		}
+		if w := sendJSON(router, "GET", "/api/projects/999/export", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a missing project, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// projectFieldsSummary limits project responses to the columns needed by list views
+const projectFieldsSummary = "summary"
+
+// summaryColumns are the project columns returned for ?fields=summary
+var summaryColumns = []string{"projects.id", "projects.name", "projects.status", "projects.last_scanned", "projects.created_at", "projects.updated_at"}
+
+// fileAggregateColumns computes per-project file totals without loading the files
+const fileAggregateColumns = "(SELECT COUNT(*) FROM project_files WHERE project_files.project_id = projects.id) AS file_count, " +
+	"(SELECT COALESCE(SUM(project_files.size), 0) FROM project_files WHERE project_files.project_id = projects.id) AS total_size"
+
+// projectQueryOptions describes the field selection requested for project responses
+type projectQueryOptions struct {
+	IncludeFiles bool
+	Summary      bool
+}
+
+// parseProjectQueryOptions reads ?include= and ?fields= from the request
+func parseProjectQueryOptions(c *gin.Context) (projectQueryOptions, error) {
+	var opts projectQueryOptions
+
+	if include := c.Query("include"); include != "" {
+		for _, part := range strings.Split(include, ",") {
+			switch strings.TrimSpace(part) {
+			case "files":
+				opts.IncludeFiles = true
+			default:
+				return opts, fmt.Errorf("unsupported include value: %s", part)
+			}
+		}
+	}
+
+	switch fields := c.Query("fields"); fields {
+	case "", "full":
+	case projectFieldsSummary:
+		opts.Summary = true
+	default:
+		return opts, fmt.Errorf("unsupported fields value: %s", fields)
+	}
+
+	return opts, nil
+}
+
+// apply scopes a project query according to the options
+func (o projectQueryOptions) apply(db *gorm.DB) *gorm.DB {
+	columns := "projects.*"
+	if o.Summary {
+		columns = strings.Join(summaryColumns, ", ")
+	}
+
+	db = db.Select(columns + ", " + fileAggregateColumns)
+	if o.IncludeFiles {
+		db = db.Preload("Files")
+	}
+
+	return db
+}
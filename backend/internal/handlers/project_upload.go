@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/imaging"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxArchiveEntries bounds how many entries a project archive may hold
+const maxArchiveEntries = 10000
+
+// errUploadTooLarge is returned when a project's files expand past MaxUploadSize
+var errUploadTooLarge = errors.New("upload expands to more than 1GB")
+
+// CreateProjectUploadResponse reports a project created from an upload:
+// the project and one result per file, as for uploads into a project
+type CreateProjectUploadResponse struct {
+	UploadResponse
+	Project *models.Project `json:"project,omitempty"`
+}
+
+// projectUploadEntry is a file of the upload to store in the new project
+type projectUploadEntry struct {
+	// name is the path inside the project
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// NameCollision represents how to handle a new project whose name is taken
+type NameCollision string
+
+const (
+	// NameCollisionSuffix creates the project next to the other one, in a
+	// directory numbered like Benchy_2; the default
+	NameCollisionSuffix NameCollision = "suffix"
+	// NameCollisionError rejects the project with 409 Conflict
+	NameCollisionError NameCollision = "error"
+)
+
+// maxProjectDirSuffix bounds the numbered directories tried for a taken name
+const maxProjectDirSuffix = 1000
+
+// projectDirName turns a project name into the name of its directory, by the
+// handler's naming policy
+func (h *ProjectsHandler) projectDirName(name string) string {
+	return h.dirNaming.DirName(name)
+}
+
+// validNameCollision reports whether policy is a known name collision policy,
+// or empty for the default
+func validNameCollision(policy NameCollision) bool {
+	return policy == "" || policy == NameCollisionSuffix || policy == NameCollisionError
+}
+
+// projectNameTaken reports whether another project has the name, or the
+// directory a project of that name would get under parent is in use
+func (h *ProjectsHandler) projectNameTaken(db *gorm.DB, parent, name string) bool {
+	projectPath := filepath.Join(parent, h.projectDirName(name))
+	var existingProject models.Project
+	if err := db.Where("name = ? OR path = ?", name, projectPath).First(&existingProject).Error; err == nil {
+		return true
+	}
+	_, err := os.Lstat(projectPath)
+	return err == nil
+}
+
+// uniqueProjectPath returns the directory under parent for a project named
+// name, numbered like Benchy_2, Benchy_3... when the plain one exists on disk
+// or belongs to another project, deleted ones included. excludeID is the
+// project being renamed, whose own path doesn't count as taken.
+func (h *ProjectsHandler) uniqueProjectPath(db *gorm.DB, parent, name string, excludeID uint) (string, error) {
+	base := h.projectDirName(name)
+	for n := 1; n <= maxProjectDirSuffix; n++ {
+		dirName := base
+		if n > 1 {
+			dirName = fmt.Sprintf("%s_%d", base, n)
+		}
+		candidate := filepath.Join(parent, dirName)
+
+		var count int64
+		if err := db.Unscoped().Model(&models.Project{}).Where("path = ? AND id != ?", candidate, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free directory for %s", base)
+}
+
+// CreateProjectFromUpload creates a project from uploaded files, a folder
+// upload or a single zip archive in one step. The files are written to a
+// hidden staging directory and the project only appears once every file
+// was stored, so a failed upload leaves nothing behind.
+func (h *ProjectsHandler) CreateProjectFromUpload(c *gin.Context) {
+	if c.Request.ContentLength > MaxUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large", "max_size": "1GB", "received": c.Request.ContentLength})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form", "details": err.Error()})
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
+		return
+	}
+	if rejectTooManyFiles(c, files, h.maxUploadFiles) {
+		return
+	}
+
+	// The project is named by the request, else by the archive or the dropped folder
+	var entries []projectUploadEntry
+	var results []UploadFileResult
+	var inferredName string
+	if len(files) == 1 && strings.EqualFold(filepath.Ext(files[0].Filename), ".zip") {
+		archive, err := files[0].Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read archive"})
+			return
+		}
+		defer archive.Close()
+
+		entries, results, err = archiveEntries(archive, files[0].Size)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zip archive", "details": err.Error()})
+			return
+		}
+		inferredName = strings.TrimSuffix(files[0].Filename, filepath.Ext(files[0].Filename))
+	} else {
+		names, invalidPaths, err := uploadPaths(form, files)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload paths", "details": err.Error()})
+			return
+		}
+		inferredName = stripUploadRoot(names, invalidPaths)
+
+		for i, fileHeader := range files {
+			if reason, ok := invalidPaths[i]; ok {
+				results = append(results, failedUpload(names[i], ReasonInvalidPath, reason))
+				continue
+			}
+			fileType := models.GetFileTypeFromExtension(fileHeader.Filename)
+			if fileType == models.FileTypeOther && !strings.Contains(fileHeader.Filename, "README") {
+				results = append(results, failedUpload(names[i], ReasonUnsupportedType,
+					fmt.Sprintf("File type not supported: %s", fileHeader.Filename)))
+				continue
+			}
+			entries = append(entries, projectUploadEntry{name: names[i], open: func() (io.ReadCloser, error) { return fileHeader.Open() }})
+		}
+	}
+
+	projectName := inferredName
+	if values := form.Value["name"]; len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		projectName = values[0]
+	}
+	projectName = strings.TrimSpace(projectName)
+	if projectName == "" || strings.HasPrefix(projectName, ".") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required when it can't be inferred from a folder or zip name"})
+		return
+	}
+	projectPath := filepath.Join(h.scanPath, h.projectDirName(projectName))
+	var nameCollision NameCollision
+	if values := form.Value["name_collision"]; len(values) > 0 {
+		nameCollision = NameCollision(values[0])
+	}
+	if !validNameCollision(nameCollision) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name_collision must be \"suffix\" or \"error\""})
+		return
+	}
+
+	// Leading dot keeps the staging directory out of scans until it is moved into place
+	stagingDir, err := os.MkdirTemp(h.scanPath, stagingDirPrefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload staging area"})
+		return
+	}
+	defer os.RemoveAll(stagingDir)
+	// The staging directory becomes the project directory
+	if err := fileperm.Dir(stagingDir); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload staging area"})
+		return
+	}
+
+	records := make([]models.ProjectFile, 0, len(entries))
+	// recordResults holds the index in results of each record
+	recordResults := make([]int, 0, len(entries))
+	depth := 0
+	budget := int64(MaxUploadSize)
+	written := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if written[entry.name] {
+			results = append(results, failedUpload(entry.name, ReasonInvalidPath, fmt.Sprintf("Duplicate path %s", entry.name)))
+			continue
+		}
+		written[entry.name] = true
+
+		name, size, hash, err := writeProjectUploadFile(stagingDir, entry, budget, h.images)
+		if errors.Is(err, errUploadTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload too large", "details": err.Error(), "max_size": "1GB"})
+			return
+		}
+		if err != nil {
+			results = append(results, failedUpload(entry.name, ReasonStagingFailed, err.Error()))
+			continue
+		}
+		budget -= size
+		written[name] = true
+
+		records = append(records, models.ProjectFile{
+			Filename: name,
+			Filepath: filepath.Join(projectPath, filepath.FromSlash(name)),
+			FileType: models.GetFileTypeFromExtension(path.Base(name)),
+			Size:     size,
+			Hash:     hash,
+		})
+		results = append(results, UploadFileResult{Filename: entry.name, Status: UploadUploaded})
+		recordResults = append(recordResults, len(results)-1)
+		depth = max(depth, uploadDepth(name))
+	}
+
+	response := CreateProjectUploadResponse{UploadResponse: UploadResponse{CommitMode: CommitAtomic, Results: results}}
+
+	// Like an atomic upload, any failure leaves nothing behind
+	if failed := countUploadResults(results)[UploadFailed]; failed > 0 || len(records) == 0 {
+		for _, index := range recordResults {
+			results[index] = failedUpload(results[index].Filename, ReasonAborted, "Not committed because another file failed")
+		}
+		response.Message = "Project not created, no files were committed"
+		if len(results) == 0 {
+			response.Message = "Project not created, the upload has no files"
+		}
+		response.Error = response.Message
+		response.Counts = countUploadResults(results)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	// Scans must not pick the directory up before its project is recorded
+	_, unlock, err := database.LockWait(context.Background(), database.GetDB(), database.LibraryLock, projectLockWait)
+	switch {
+	case errors.Is(err, database.ErrLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another scan or library operation is running, try again later"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock library"})
+		return
+	}
+	defer unlock()
+
+	// A taken name is an error or gets a numbered directory, by the request's policy
+	if nameCollision == NameCollisionError {
+		if h.projectNameTaken(requestDB(c), h.scanPath, projectName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Project with this name or path already exists"})
+			return
+		}
+	} else if projectPath, err = h.uniqueProjectPath(requestDB(c), h.scanPath, projectName, 0); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project with this name or path already exists", "details": err.Error()})
+		return
+	}
+
+	if err := os.Rename(stagingDir, projectPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project directory"})
+		return
+	}
+
+	project := models.Project{
+		Name:        projectName,
+		Path:        projectPath,
+		Status:      models.StatusHealthy,
+		LastScanned: time.Now(),
+		// Scans descend as deep as the uploaded folders so they keep every file
+		ScanSettings: models.ProjectScanSettings{MaxDepth: depth},
+	}
+	// The README of an uploaded project describes it, unless the request does
+	if err := h.scanner.ApplyREADME(&project); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: Failed to read README of %s: %v\n", projectPath, err)
+	}
+	if values := form.Value["description"]; len(values) > 0 && values[0] != "" {
+		project.Description = values[0]
+	}
+
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].ProjectID = project.ID
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		os.RemoveAll(projectPath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
+	if err := h.scanner.RefreshSidecar(&project); err != nil {
+		response.Warnings = append(response.Warnings, "Failed to write project sidecar")
+	}
+
+	for i, index := range recordResults {
+		results[index].File = &records[i]
+	}
+	project.Files = records
+	response.Project = &project
+	response.Counts = countUploadResults(results)
+	response.Message = fmt.Sprintf("Created project %s with %d file(s)", project.Name, len(records))
+	c.JSON(http.StatusCreated, response)
+}
+
+// archiveEntries lists the files of a project's zip archive by their path
+// inside it. A top-level folder shared by every entry is dropped, as the
+// project directory takes its place. Folders, hidden files and archiver
+// metadata are left out; entries with unsafe paths fail.
+func archiveEntries(archive multipart.File, size int64) ([]projectUploadEntry, []UploadFileResult, error) {
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(reader.File) > maxArchiveEntries {
+		return nil, nil, fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+
+	var files []*zip.File
+	var names []string
+	invalid := make(map[int]string)
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || archiveMetadata(file.Name) {
+			continue
+		}
+		if err := validateUploadPath(file.Name, path.Base(file.Name)); err != nil {
+			invalid[len(names)] = err.Error()
+		}
+		files = append(files, file)
+		names = append(names, file.Name)
+	}
+	stripUploadRoot(names, invalid)
+
+	var entries []projectUploadEntry
+	var results []UploadFileResult
+	for i, file := range files {
+		if reason, ok := invalid[i]; ok {
+			results = append(results, failedUpload(names[i], ReasonInvalidPath, reason))
+			continue
+		}
+		entries = append(entries, projectUploadEntry{name: names[i], open: file.Open})
+	}
+	return entries, results, nil
+}
+
+// archiveMetadata reports whether a zip entry is hidden or was added by the
+// archiver, like macOS resource forks
+func archiveMetadata(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == "__MACOSX" || (strings.HasPrefix(part, ".") && part != "." && part != "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeProjectUploadFile copies an entry to its path under root, normalizing
+// images, and returns the name it was stored under with its size and hash.
+// It fails with errUploadTooLarge past limit bytes.
+func writeProjectUploadFile(root string, entry projectUploadEntry, limit int64, images *imaging.Normalizer) (string, int64, string, error) {
+	src, err := entry.open()
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to open file %s: %v", entry.name, err)
+	}
+	defer src.Close()
+
+	destPath := filepath.Join(root, filepath.FromSlash(entry.name))
+	if err := fileperm.MkdirAll(filepath.Dir(destPath)); err != nil {
+		return "", 0, "", fmt.Errorf("failed to create folder for %s: %v", entry.name, err)
+	}
+	dest, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to create file %s: %v", entry.name, err)
+	}
+
+	size, err := io.Copy(dest, io.LimitReader(src, limit+1))
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > limit {
+		err = errUploadTooLarge
+	}
+	if err != nil {
+		os.Remove(destPath)
+		if errors.Is(err, errUploadTooLarge) {
+			return "", 0, "", err
+		}
+		return "", 0, "", fmt.Errorf("failed to copy file %s: %v", entry.name, err)
+	}
+
+	// Images may be rewritten, or replaced by a WebP version, before they are hashed
+	name := entry.name
+	if imaging.Handles(name) {
+		normalized, err := images.Normalize(destPath)
+		if err != nil {
+			os.Remove(normalized)
+			return "", 0, "", err
+		}
+		name = path.Join(path.Dir(name), filepath.Base(normalized))
+		destPath = normalized
+	}
+
+	if err := fileperm.File(destPath); err != nil {
+		os.Remove(destPath)
+		return "", 0, "", fmt.Errorf("failed to set permissions of %s: %v", name, err)
+	}
+	size, hash, err := fsutil.HashFile(destPath)
+	if err != nil {
+		os.Remove(destPath)
+		return "", 0, "", fmt.Errorf("failed to hash file %s: %v", name, err)
+	}
+	return name, size, hash, nil
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/imaging"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateProjectFromUpload tests creating a project from files, a folder or a zip in one request
+func TestCreateProjectFromUpload(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         map[string][]byte
+		paths         []string
+		values        map[string]string
+		expectedName  string
+		expectedFiles []string
+		expectedDepth int
+	}{
+		{
+			name:          "Files with a name",
+			files:         map[string][]byte{"benchy.stl": []byte("solid benchy")},
+			values:        map[string]string{"name": "My Benchy", "description": "A boat"},
+			expectedName:  "My Benchy",
+			expectedFiles: []string{"benchy.stl"},
+		},
+		{
+			name:          "Folder",
+			files:         map[string][]byte{"benchy.stl": []byte("solid benchy"), "hull.stl": []byte("solid hull")},
+			paths:         []string{"Benchy/benchy.stl", "Benchy/parts/hull.stl"},
+			expectedName:  "Benchy",
+			expectedFiles: []string{"benchy.stl", "parts/hull.stl"},
+			expectedDepth: 1,
+		},
+		{
+			name: "Zip with a root folder",
+			files: map[string][]byte{"Gearbox.zip": zipArchive(t, map[string]string{
+				"Gearbox/gear.stl":        "solid gear",
+				"Gearbox/README.md":       "# Gearbox\n\nA small gearbox",
+				"Gearbox/images/gear.png": "png",
+				"Gearbox/.DS_Store":       "junk",
+				"__MACOSX/Gearbox/._gear": "junk",
+			})},
+			expectedName:  "Gearbox",
+			expectedFiles: []string{"gear.stl", "README.md", "images/gear.png"},
+			expectedDepth: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tmpDir := t.TempDir()
+			router := setupRouter(tmpDir)
+
+			w := uploadNewProject(router, tc.files, tc.paths, tc.values)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+
+			var response CreateProjectUploadResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Project == nil || response.Project.Name != tc.expectedName {
+				t.Fatalf("Expected project %s, got %+v", tc.expectedName, response.Project)
+			}
+			if response.Counts[UploadUploaded] != len(tc.expectedFiles) {
+				t.Errorf("Expected %d uploaded files, got %+v", len(tc.expectedFiles), response.Results)
+			}
+
+			var project models.Project
+			if err := db.Preload("Files").First(&project, response.Project.ID).Error; err != nil {
+				t.Fatalf("Expected the project to be stored: %v", err)
+			}
+			if len(project.Files) != len(tc.expectedFiles) {
+				t.Errorf("Expected %d file records, got %d", len(tc.expectedFiles), len(project.Files))
+			}
+			if project.ScanSettings.MaxDepth != tc.expectedDepth {
+				t.Errorf("Expected scan depth %d, got %d", tc.expectedDepth, project.ScanSettings.MaxDepth)
+			}
+			for _, name := range tc.expectedFiles {
+				if _, err := os.Stat(filepath.Join(project.Path, name)); err != nil {
+					t.Errorf("Expected %s in the project directory: %v", name, err)
+				}
+			}
+			if description := tc.values["description"]; description != "" && project.Description != description {
+				t.Errorf("Expected description %q, got %q", description, project.Description)
+			}
+
+			entries, _ := os.ReadDir(tmpDir)
+			if len(entries) != 1 {
+				t.Errorf("Expected only the project directory in the library, got %v", entries)
+			}
+		})
+	}
+}
+
+// TestCreateProjectFromUploadFailures tests that failed uploads create nothing
+func TestCreateProjectFromUploadFailures(t *testing.T) {
+	testCases := []struct {
+		name         string
+		files        map[string][]byte
+		values       map[string]string
+		existing     bool
+		expectedCode int
+	}{
+		{name: "Unsupported file", files: map[string][]byte{"benchy.stl": []byte("solid"), "notes.txt": []byte("notes")}, values: map[string]string{"name": "Benchy"}, expectedCode: http.StatusBadRequest},
+		{name: "No name", files: map[string][]byte{"benchy.stl": []byte("solid")}, expectedCode: http.StatusBadRequest},
+		{name: "Unsafe zip entry", files: map[string][]byte{"Escape.zip": zipArchive(t, map[string]string{"../escape.stl": "solid"})}, expectedCode: http.StatusBadRequest},
+		{name: "Invalid zip", files: map[string][]byte{"Broken.zip": []byte("not a zip")}, expectedCode: http.StatusBadRequest},
+		{name: "Existing project", files: map[string][]byte{"benchy.stl": []byte("solid")}, values: map[string]string{"name": "Benchy", "name_collision": "error"}, existing: true, expectedCode: http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tmpDir := t.TempDir()
+			router := setupRouter(tmpDir)
+			if tc.existing {
+				db.Create(&models.Project{Name: "Benchy", Path: filepath.Join(tmpDir, "Benchy"), Status: models.StatusHealthy})
+			}
+
+			w := uploadNewProject(router, tc.files, nil, tc.values)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+
+			var count int64
+			db.Model(&models.Project{}).Count(&count)
+			if want := map[bool]int64{true: 1, false: 0}[tc.existing]; count != want {
+				t.Errorf("Expected %d projects, got %d", want, count)
+			}
+			entries, _ := os.ReadDir(tmpDir)
+			if len(entries) != 0 {
+				t.Errorf("Expected nothing left in the library, got %v", entries)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(tmpDir), "escape.stl")); !os.IsNotExist(err) {
+				t.Error("Archive entries must not be written outside the project")
+			}
+		})
+	}
+}
+
+// TestCreateProjectFromUploadTakenName tests that an upload named like an
+// existing project gets a numbered directory and slug
+func TestCreateProjectFromUploadTakenName(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+	os.MkdirAll(filepath.Join(tmpDir, "Benchy"), 0755)
+	db.Create(&models.Project{Name: "Benchy", Path: filepath.Join(tmpDir, "Benchy"), Status: models.StatusHealthy})
+
+	w := uploadNewProject(router, map[string][]byte{"benchy.stl": []byte("solid")}, nil, map[string]string{"name": "Benchy"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var response CreateProjectUploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Project == nil || response.Project.Slug != "benchy-2" || response.Project.Path != filepath.Join(tmpDir, "Benchy_2") {
+		t.Errorf("Expected the project in Benchy_2 as benchy-2, got %+v", response.Project)
+	}
+}
+
+// TestCreateProjectFromUploadNormalizesImages tests uploaded project images are
+// stripped and converted to WebP before they are recorded
+func TestCreateProjectFromUploadNormalizesImages(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	// Stands in for cwebp: -quiet -metadata none -q N input -o output
+	encoder := filepath.Join(t.TempDir(), "fake-cwebp")
+	os.WriteFile(encoder, []byte("#!/bin/sh\ncp \"$6\" \"$8\"\n"), 0755)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	handler.SetImageNormalizer(imaging.New(imaging.Options{StripMetadata: true, WebPQuality: 80, WebPEncoder: encoder}))
+	router.POST("/api/projects/upload", handler.CreateProjectFromUpload)
+
+	photo, stripped := exifPhoto()
+	archive := zipArchive(t, map[string]string{"Benchy/benchy.stl": "solid", "Benchy/images/result.jpg": string(photo)})
+	w := uploadNewProject(router, map[string][]byte{"Benchy.zip": archive}, nil, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	imagePath := filepath.Join(tmpDir, "Benchy", "images", "result.webp")
+	content, err := os.ReadFile(imagePath)
+	if err != nil {
+		t.Fatalf("Expected the photo to be converted: %v", err)
+	}
+	if !bytes.Equal(content, stripped) {
+		t.Error("Expected the EXIF data to be stripped before converting")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Benchy", "images", "result.jpg")); !os.IsNotExist(err) {
+		t.Error("Expected the original photo to be replaced")
+	}
+
+	var record models.ProjectFile
+	if err := db.Where("filename = ?", "images/result.webp").First(&record).Error; err != nil {
+		t.Fatalf("Expected the converted photo to be recorded: %v", err)
+	}
+	if record.Filepath != imagePath || record.Hash != fmt.Sprintf("%x", sha256.Sum256(stripped)) {
+		t.Errorf("Expected the record to describe the converted file, got %+v", record)
+	}
+}
+
+// TestCreateProjectFromUploadAppliesFilePolicy tests that uploaded projects get the configured modes
+func TestCreateProjectFromUploadAppliesFilePolicy(t *testing.T) {
+	setupTestDB(t)
+	tmpDir := t.TempDir()
+	if err := fileperm.Set(fileperm.Policy{UID: fileperm.KeepOwner, GID: fileperm.KeepOwner, DirMode: 0770, FileMode: 0660}); err != nil {
+		t.Fatalf("Failed to set file policy: %v", err)
+	}
+	defer fileperm.Set(fileperm.Default())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/projects/upload", NewProjectsHandler(tmpDir).CreateProjectFromUpload)
+
+	archive := zipArchive(t, map[string]string{"Benchy/benchy.stl": "solid", "Benchy/plates/plate_1.3mf": "3mf"})
+	w := uploadNewProject(router, map[string][]byte{"Benchy.zip": archive}, nil, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	for path, mode := range map[string]os.FileMode{
+		"Benchy":                    0770,
+		"Benchy/plates":             0770,
+		"Benchy/benchy.stl":         0660,
+		"Benchy/plates/plate_1.3mf": 0660,
+	} {
+		info, err := os.Stat(filepath.Join(tmpDir, path))
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", path, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("Expected %s to have mode %#o, got %#o", path, mode, info.Mode().Perm())
+		}
+	}
+}
+
+// uploadNewProject posts files, with folder paths when given, to the new project upload endpoint
+func uploadNewProject(router http.Handler, files map[string][]byte, paths []string, values map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if paths != nil {
+		for _, relPath := range paths {
+			part, _ := writer.CreateFormFile("files", filepath.Base(relPath))
+			part.Write(files[filepath.Base(relPath)])
+			writer.WriteField("paths", relPath)
+		}
+	} else {
+		for name, content := range files {
+			part, _ := writer.CreateFormFile("files", name)
+			part.Write(content)
+		}
+	}
+	for key, value := range values {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/projects/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// zipArchive builds a zip archive holding the given entries
+func zipArchive(t *testing.T, entries map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	for name, content := range entries {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		entry.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf.Bytes()
+}
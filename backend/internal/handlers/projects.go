@@ -3,14 +3,23 @@ package handlers
 import (
 	"3dshelf/internal/models"
 	"3dshelf/pkg/database"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/frontmatter"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/naming"
+	"3dshelf/pkg/pairing"
 	"3dshelf/pkg/scanner"
+	"3dshelf/pkg/snapshot"
 	"archive/zip"
-	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,12 +27,36 @@ import (
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
+	"gorm.io/gorm"
 )
 
+// MaxUploadSize is the largest upload request accepted, in bytes
+const MaxUploadSize = 1 << 30 // 1GB
+
 // ProjectsHandler handles project-related HTTP requests
 type ProjectsHandler struct {
 	scanner  *scanner.Scanner
 	scanPath string
+	locks    projectLocks
+	uploads  uploadTracker
+
+	// publicURL is the web UI base URL linked from printed labels
+	publicURL string
+
+	// conflictPolicy resolves upload conflicts the client left unresolved
+	conflictPolicy ConflictResolution
+
+	// dirNaming turns the names of created projects into directory names
+	dirNaming naming.Policy
+
+	// maxUploadFiles bounds the files of one upload request; 0 allows any number
+	maxUploadFiles int
+
+	// images normalizes the images of uploaded projects; nil leaves them as uploaded
+	images *imaging.Normalizer
+
+	// snapshots keeps snapshots of project source pages; nil disables them
+	snapshots *snapshot.Archive
 }
 
 // ConflictResolution represents how to handle a file conflict
@@ -50,34 +83,100 @@ type UploadCheckRequest struct {
 
 // UploadCheckResponse represents the response from upload conflict check
 type UploadCheckResponse struct {
-	Conflicts []FileConflict `json:"conflicts"`
-	Safe      []string       `json:"safe"`
-}
-
-// UploadWithResolutionRequest represents enhanced upload with conflict resolution
-type UploadWithResolutionRequest struct {
-	Resolutions map[string]ConflictResolution `json:"resolutions,omitempty"`
+	Conflicts    []FileConflict `json:"conflicts"`
+	Safe         []string       `json:"safe"`
+	SessionToken string         `json:"session_token"`
+	ExpiresAt    time.Time      `json:"expires_at"`
 }
 
 // CreateProjectRequest represents the request body for creating a new project
 type CreateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+
+	// NameCollision says what happens when the name is taken: "suffix" (the
+	// default) numbers the project's directory, "error" rejects the project
+	NameCollision NameCollision `json:"name_collision"`
 }
 
 // NewProjectsHandler creates a new ProjectsHandler
 func NewProjectsHandler(scanPath string) *ProjectsHandler {
 	return &ProjectsHandler{
-		scanner:  scanner.New(database.GetDB(), scanPath),
-		scanPath: scanPath,
+		scanner:        scanner.New(database.GetDB(), scanPath),
+		scanPath:       scanPath,
+		conflictPolicy: ConflictSkip,
+		dirNaming:      naming.Default(),
+		maxUploadFiles: DefaultMaxUploadFiles,
 	}
 }
 
-// GetProjects returns all projects
+// Scanner returns the scanner used for project scans and syncs
+func (h *ProjectsHandler) Scanner() *scanner.Scanner {
+	return h.scanner
+}
+
+// SetConflictPolicy sets how uploads resolve conflicts the client left unresolved
+func (h *ProjectsHandler) SetConflictPolicy(policy ConflictResolution) {
+	h.conflictPolicy = policy
+}
+
+// SetDirNaming sets how the names of created and renamed projects become directory names
+func (h *ProjectsHandler) SetDirNaming(policy naming.Policy) {
+	h.dirNaming = policy
+}
+
+// SetMaxUploadFiles bounds how many files one upload request may carry
+func (h *ProjectsHandler) SetMaxUploadFiles(maxFiles int) {
+	h.maxUploadFiles = maxFiles
+}
+
+// SetImageNormalizer strips and converts the images of uploaded projects
+func (h *ProjectsHandler) SetImageNormalizer(images *imaging.Normalizer) {
+	h.images = images
+}
+
+// SetWriteSidecars enables or disables writing .3dshelf.json sidecars on scans and edits
+func (h *ProjectsHandler) SetWriteSidecars(enabled bool) {
+	h.scanner.SetWriteSidecars(enabled)
+}
+
+// SetJobQueue runs manual scans as background jobs on queue
+func (h *ProjectsHandler) SetJobQueue(queue *jobs.Queue) {
+	h.scanner.SetQueue(queue)
+}
+
+// GetProjects returns all projects with file aggregates; files are only
+// loaded when requested with ?include=files. Clients accepting
+// application/x-ndjson get one project per line, streamed as it is read.
 func (h *ProjectsHandler) GetProjects(c *gin.Context) {
+	opts, err := parseProjectQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	printer, ok := printableOn(c)
+	if !ok {
+		return
+	}
+	query := opts.apply(requestDB(c))
+	if printer != nil {
+		ids, err := printableProjectIDs(requestDB(c), *printer)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check models"})
+			return
+		}
+		query = query.Where("projects.id IN ?", ids)
+	}
+
+	if wantsNDJSON(c) {
+		streamNDJSON[models.Project](c, query, nil)
+		return
+	}
+
 	var projects []models.Project
 
-	if err := database.GetDB().Preload("Files").Find(&projects).Error; err != nil {
+	if err := query.Find(&projects).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
 		return
 	}
@@ -93,11 +192,22 @@ func (h *ProjectsHandler) GetProject(c *gin.Context) {
 	id := c.Param("id")
 
 	var project models.Project
-	if err := database.GetDB().Preload("Files").First(&project, id).Error; err != nil {
+	opts := projectQueryOptions{IncludeFiles: true}
+	if err := opts.apply(requestDB(c)).First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+	pairing.Pair(project.Files)
+	if err := attachFileProfiles(requestDB(c), project.ID, project.Files, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profiles"})
+		return
+	}
 
+	c.Header("ETag", versionTag(project.UpdatedAt))
 	c.JSON(http.StatusOK, project)
 }
 
@@ -115,21 +225,30 @@ func (h *ProjectsHandler) CreateProject(c *gin.Context) {
 		return
 	}
 
-	// Create a safe project path by sanitizing the name
-	projectName := strings.TrimSpace(req.Name)
-	safeName := strings.ReplaceAll(projectName, " ", "_")
-	safeName = strings.ReplaceAll(safeName, "/", "_")
-	projectPath := filepath.Join(h.scanPath, safeName)
-
-	// Check if a project with this name or path already exists
-	var existingProject models.Project
-	if err := database.GetDB().Where("name = ? OR path = ?", projectName, projectPath).First(&existingProject).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Project with this name or path already exists"})
+	if !validNameCollision(req.NameCollision) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name_collision must be \"suffix\" or \"error\""})
 		return
 	}
 
+	// Create a safe project path by sanitizing the name; a taken name is an
+	// error or gets a numbered directory, by the request's policy
+	projectName := strings.TrimSpace(req.Name)
+	projectPath := filepath.Join(h.scanPath, h.projectDirName(projectName))
+	if req.NameCollision == NameCollisionError {
+		if h.projectNameTaken(requestDB(c), h.scanPath, projectName) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Project with this name or path already exists"})
+			return
+		}
+	} else {
+		var err error
+		if projectPath, err = h.uniqueProjectPath(requestDB(c), h.scanPath, projectName, 0); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Project with this name or path already exists", "details": err.Error()})
+			return
+		}
+	}
+
 	// Create the project directory
-	if err := os.MkdirAll(projectPath, 0755); err != nil {
+	if err := fileperm.MkdirAll(projectPath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project directory"})
 		return
 	}
@@ -143,7 +262,7 @@ func (h *ProjectsHandler) CreateProject(c *gin.Context) {
 		LastScanned: time.Now(),
 	}
 
-	if err := database.GetDB().Create(&project).Error; err != nil {
+	if err := requestDB(c).Create(&project).Error; err != nil {
 		// Clean up the directory if database creation fails
 		os.RemoveAll(projectPath)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
@@ -168,14 +287,17 @@ func (h *ProjectsHandler) CheckUploadConflicts(c *gin.Context) {
 
 	// Verify project exists
 	var project models.Project
-	if err := database.GetDB().First(&project, projectID).Error; err != nil {
+	if err := requestDB(c).First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
 
 	// Get existing files for this project
 	var existingFiles []models.ProjectFile
-	if err := database.GetDB().Where("project_id = ?", projectID).Find(&existingFiles).Error; err != nil {
+	if err := requestDB(c).Where("project_id = ?", projectID).Find(&existingFiles).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing files"})
 		return
 	}
@@ -202,9 +324,18 @@ func (h *ProjectsHandler) CheckUploadConflicts(c *gin.Context) {
 		}
 	}
 
+	// Record what the client saw so the upload can detect concurrent changes
+	session, err := createUploadSession(requestDB(c), project.ID, request.Filenames, conflicts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload session"})
+		return
+	}
+
 	response := UploadCheckResponse{
-		Conflicts: conflicts,
-		Safe:      safe,
+		Conflicts:    conflicts,
+		Safe:         safe,
+		SessionToken: session.Token,
+		ExpiresAt:    session.ExpiresAt,
 	}
 
 	fmt.Printf("CheckUploadConflicts response: %d conflicts, %d safe files\n", len(conflicts), len(safe))
@@ -219,10 +350,13 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 
 	// Verify project exists
 	var project models.Project
-	if err := database.GetDB().First(&project, projectID).Error; err != nil {
+	if err := requestDB(c).First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
 
 	// Debug: Log request headers
 	fmt.Printf("Request Headers: %+v\n", c.Request.Header)
@@ -230,7 +364,7 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 	fmt.Printf("Content-Length: %s\n", c.GetHeader("Content-Length"))
 
 	// Check content length
-	if c.Request.ContentLength > 1024<<20 { // 1GB limit
+	if c.Request.ContentLength > MaxUploadSize {
 		fmt.Printf("File too large: %d bytes (max 1GB)\n", c.Request.ContentLength)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large", "max_size": "1GB", "received": c.Request.ContentLength})
 		return
@@ -240,6 +374,14 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 	fmt.Printf("[UPLOAD] Starting file upload processing - Content-Length: %d bytes (%.2f MB)\n",
 		c.Request.ContentLength, float64(c.Request.ContentLength)/(1024*1024))
 
+	upload, ok := h.trackUpload(c, project.ID)
+	if !ok {
+		return
+	}
+	if upload != nil {
+		defer func() { h.uploads.finish(upload, c.Writer.Status()) }()
+	}
+
 	// Parse multipart form
 	fmt.Printf("Attempting to parse multipart form...\n")
 	form, err := c.MultipartForm()
@@ -264,6 +406,9 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 		return
 	}
 	fmt.Printf("Successfully parsed multipart form with %d file fields\n", len(form.File))
+	if upload != nil {
+		h.uploads.setState(upload, UploadProcessing)
+	}
 
 	files := form.File["files"]
 	fmt.Printf("Found %d files in multipart form\n", len(files))
@@ -272,6 +417,19 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
 		return
 	}
+	if rejectTooManyFiles(c, files, h.maxUploadFiles) {
+		return
+	}
+
+	// Folder uploads name each file by its path inside the dropped folder
+	names, invalidPaths, err := uploadPaths(form, files)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload paths", "details": err.Error()})
+		return
+	}
+	if values := form.Value["strip_root"]; len(values) > 0 && values[0] == "true" {
+		stripUploadRoot(names, invalidPaths)
+	}
 
 	// Debug: Print file information
 	for i, fileHeader := range files {
@@ -279,24 +437,36 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 	}
 
 	// Parse conflict resolutions from form data
-	resolutions := make(map[string]ConflictResolution)
-
 	fmt.Printf("DEBUG: All form values: %+v\n", form.Value)
+	resolutions, err := parseUploadResolutions(form)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Look for individual resolution fields like "resolution_filename"
-	for key, values := range form.Value {
-		if strings.HasPrefix(key, "resolution_") && len(values) > 0 {
-			filename := strings.TrimPrefix(key, "resolution_")
-			fmt.Printf("DEBUG: Found resolution field %s -> filename: %s, value: %s\n", key, filename, values[0])
-			resolutions[filename] = ConflictResolution(values[0])
-		}
+	fmt.Printf("DEBUG: Final resolutions: %+v\n", resolutions)
+
+	// Serialize uploads per project so conflict checks and resolutions can't interleave
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
 	}
+	defer unlock()
 
-	fmt.Printf("DEBUG: Final resolutions map: %+v\n", resolutions)
+	// Resolutions made against an earlier conflict check are validated against its snapshot
+	var session *models.UploadSession
+	if tokens := form.Value["session_token"]; len(tokens) > 0 && tokens[0] != "" {
+		session, err = loadUploadSession(requestDB(c), project.ID, tokens[0])
+		if err != nil {
+			status, message := uploadSessionError(err)
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
 
 	// Get existing files for conflict checking
 	var existingFiles []models.ProjectFile
-	if err := database.GetDB().Where("project_id = ?", projectID).Find(&existingFiles).Error; err != nil {
+	if err := requestDB(c).Where("project_id = ?", projectID).Find(&existingFiles).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing files"})
 		return
 	}
@@ -307,166 +477,292 @@ func (h *ProjectsHandler) UploadProjectFiles(c *gin.Context) {
 		existingFileMap[existingFiles[i].Filename] = &existingFiles[i]
 	}
 
-	var uploadedFiles []models.ProjectFile
-	var skippedFiles []string
-	var errors []string
+	// Files are staged first and only moved into the project once staging succeeds
+	mode := CommitAtomic
+	if modes := form.Value["commit_mode"]; len(modes) > 0 && modes[0] != "" {
+		mode = UploadCommitMode(modes[0])
+		if mode != CommitAtomic && mode != CommitPerFile {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commit mode", "allowed": []UploadCommitMode{CommitAtomic, CommitPerFile}})
+			return
+		}
+	}
+
+	stagingDir, err := newStagingDir(project.Path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload staging area"})
+		return
+	}
+	defer os.RemoveAll(stagingDir)
 
-	// Process each file
-	fmt.Printf("Starting to process %d files\n", len(files))
+	results := make([]UploadFileResult, 0, len(files))
+	var staged []*stagedFile
+	// stagedResults holds the index in results of each staged file
+	var stagedResults []int
+
+	// Stage each file
+	fmt.Printf("Starting to process %d files (commit mode: %s)\n", len(files), mode)
 	for i, fileHeader := range files {
-		fmt.Printf("Processing file %d: %s (size: %d)\n", i+1, fileHeader.Filename, fileHeader.Size)
+		filename := names[i]
+		fmt.Printf("Processing file %d: %s (size: %d)\n", i+1, filename, fileHeader.Size)
+
+		if reason, ok := invalidPaths[i]; ok {
+			results = append(results, failedUpload(filename, ReasonInvalidPath, reason))
+			continue
+		}
 
 		// Validate file type
 		fileType := models.GetFileTypeFromExtension(fileHeader.Filename)
 		fmt.Printf("File type detected: %s\n", fileType)
 		if fileType == models.FileTypeOther && !strings.Contains(fileHeader.Filename, "README") {
 			fmt.Printf("ERROR: File type not supported: %s\n", fileHeader.Filename)
-			errors = append(errors, fmt.Sprintf("File type not supported: %s", fileHeader.Filename))
+			results = append(results, failedUpload(filename, ReasonUnsupportedType,
+				fmt.Sprintf("File type not supported: %s", fileHeader.Filename)))
 			continue
 		}
 
 		// Check for conflicts and handle resolution
-		finalFilename := fileHeader.Filename
-		existingFile, hasConflict := existingFileMap[fileHeader.Filename]
+		finalFilename := filename
+		existingFile, hasConflict := existingFileMap[filename]
+		var replaces *models.ProjectFile
+		status := UploadUploaded
+
+		if session != nil {
+			// Files applied by an earlier request in this session are not applied twice
+			if session.IsCompleted(filename) {
+				results = append(results, UploadFileResult{Filename: filename, Status: UploadSkipped, Reason: ReasonAlreadyApplied})
+				continue
+			}
+
+			if reason := staleReason(session, filename, existingFile); reason != "" {
+				results = append(results, failedUpload(filename, ReasonStale, reason))
+				continue
+			}
+		}
 
-		fmt.Printf("Checking conflicts for: %s, hasConflict: %t\n", fileHeader.Filename, hasConflict)
+		fmt.Printf("Checking conflicts for: %s, hasConflict: %t\n", filename, hasConflict)
 		if hasConflict {
-			fmt.Printf("Found existing file, checking resolutions map: %+v\n", resolutions)
-			resolution, hasResolution := resolutions[fileHeader.Filename]
-			fmt.Printf("Resolution for %s: %s, hasResolution: %t\n", fileHeader.Filename, resolution, hasResolution)
+			resolution, hasResolution := resolutions.resolution(filename)
+			fmt.Printf("Resolution for %s: %s, hasResolution: %t\n", filename, resolution, hasResolution)
 
 			if !hasResolution {
-				// No resolution provided for conflict - default to skip
-				fmt.Printf("SKIPPING file due to no resolution: %s\n", fileHeader.Filename)
-				skippedFiles = append(skippedFiles, fileHeader.Filename)
-				continue
+				// No resolution provided for conflict - apply the configured policy
+				resolution = h.conflictPolicy
 			}
 
 			switch resolution {
 			case ConflictSkip:
-				skippedFiles = append(skippedFiles, fileHeader.Filename)
+				if !hasResolution {
+					fmt.Printf("SKIPPING file due to no resolution: %s\n", filename)
+					results = append(results, UploadFileResult{Filename: filename, Status: UploadSkipped, Reason: ReasonUnresolvedConflict})
+					continue
+				}
+				results = append(results, UploadFileResult{Filename: filename, Status: UploadSkipped, Reason: ReasonSkipRequested})
+				if session != nil {
+					session.MarkCompleted(filename)
+				}
 				continue
 			case ConflictRename:
 				// Add timestamp to filename
-				ext := filepath.Ext(fileHeader.Filename)
-				name := strings.TrimSuffix(fileHeader.Filename, ext)
+				ext := filepath.Ext(filename)
+				name := strings.TrimSuffix(filename, ext)
 				timestamp := time.Now().Format("20060102_150405")
 				finalFilename = fmt.Sprintf("%s_%s%s", name, timestamp, ext)
+				status = UploadRenamed
 			case ConflictOverwrite:
-				// Remove existing file record and file
-				if err := os.Remove(existingFile.Filepath); err != nil {
-					// Log but don't fail - file might not exist on disk
-				}
-				if err := database.GetDB().Delete(&existingFile).Error; err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to remove existing file record %s: %v", fileHeader.Filename, err))
-					continue
-				}
+				// The existing file is replaced when the staged copy is committed
+				replaces = existingFile
+				status = UploadOverwritten
+			default:
+				results = append(results, failedUpload(filename, ReasonInvalidResolution,
+					fmt.Sprintf("Invalid resolution %q for %s", resolution, filename)))
+				continue
 			}
 		}
 
-		// Open uploaded file
-		file, err := fileHeader.Open()
+		file, err := stageUpload(stagingDir, fileHeader, filename, finalFilename, fileType)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to open file %s: %v", fileHeader.Filename, err))
+			results = append(results, failedUpload(filename, ReasonStagingFailed, err.Error()))
 			continue
 		}
+		file.Replaces = replaces
+		staged = append(staged, file)
+		results = append(results, UploadFileResult{Filename: filename, Status: status})
+		stagedResults = append(stagedResults, len(results)-1)
+	}
 
-		// Create destination path with final filename
-		destPath := filepath.Join(project.Path, finalFilename)
+	response := UploadResponse{CommitMode: mode, Results: results}
 
-		// Create destination file
-		dest, err := os.Create(destPath)
-		if err != nil {
-			file.Close()
-			errors = append(errors, fmt.Sprintf("Failed to create file %s: %v", fileHeader.Filename, err))
-			continue
+	// In atomic mode any failure leaves the project untouched
+	if failed := countUploadResults(results)[UploadFailed]; mode == CommitAtomic && failed > 0 {
+		fmt.Printf("Upload aborted - %d error(s) while staging, nothing committed\n", failed)
+		for _, index := range stagedResults {
+			results[index] = failedUpload(results[index].Filename, ReasonAborted, "Not committed because another file failed")
 		}
 
-		// Copy file content and calculate hash
-		hasher := sha256.New()
-		size, err := io.Copy(io.MultiWriter(dest, hasher), file)
-		dest.Close()
-		file.Close()
+		status := http.StatusBadRequest
+		if hasUploadReason(results, ReasonStale) {
+			status = http.StatusConflict
+		}
+		response.Message = "Upload aborted, no files were committed"
+		response.Error = response.Message
+		response.Counts = countUploadResults(results)
+		c.JSON(status, response)
+		return
+	}
 
+	// Commit staged files into the project
+	if mode == CommitAtomic {
+		var committed []*committedFile
+		err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+			for _, file := range staged {
+				result, err := commitStaged(tx, &project, file)
+				if err != nil {
+					return err
+				}
+				committed = append(committed, result)
+			}
+			return nil
+		})
 		if err != nil {
-			os.Remove(destPath)
-			errors = append(errors, fmt.Sprintf("Failed to copy file %s: %v", fileHeader.Filename, err))
-			continue
+			for i := len(committed) - 1; i >= 0; i-- {
+				committed[i].undo()
+			}
+			for _, index := range stagedResults {
+				results[index] = failedUpload(results[index].Filename, ReasonCommitFailed, err.Error())
+			}
+			response.Message = "Failed to commit uploaded files, no files were committed"
+			response.Error = response.Message
+			response.Counts = countUploadResults(results)
+			c.JSON(http.StatusInternalServerError, response)
+			return
 		}
 
-		// Calculate hash
-		hash := fmt.Sprintf("%x", hasher.Sum(nil))
-
-		// Create file record in database
-		projectFile := models.ProjectFile{
-			ProjectID: project.ID,
-			Filename:  finalFilename,
-			Filepath:  destPath,
-			FileType:  fileType,
-			Size:      size,
-			Hash:      hash,
+		for i, result := range committed {
+			results[stagedResults[i]].File = &result.record
+			if session != nil {
+				session.MarkCompleted(staged[i].Source)
+			}
 		}
-
-		if err := database.GetDB().Create(&projectFile).Error; err != nil {
-			os.Remove(destPath)
-			errors = append(errors, fmt.Sprintf("Failed to save file record for %s: %v", fileHeader.Filename, err))
-			continue
+	} else {
+		for i, file := range staged {
+			result, err := commitStaged(requestDB(c), &project, file)
+			if err != nil {
+				results[stagedResults[i]] = failedUpload(file.Source, ReasonCommitFailed, err.Error())
+				continue
+			}
+			results[stagedResults[i]].File = &result.record
+			if session != nil {
+				session.MarkCompleted(file.Source)
+			}
 		}
-
-		uploadedFiles = append(uploadedFiles, projectFile)
 	}
 
-	// Update project last_scanned time
-	if err := database.GetDB().Model(&project).Update("last_scanned", time.Now()).Error; err != nil {
-		// Non-critical error, just log it
-		errors = append(errors, "Failed to update project scan time")
+	if session != nil {
+		if err := requestDB(c).Save(session).Error; err != nil {
+			response.Warnings = append(response.Warnings, "Failed to update upload session")
+		}
+		response.Session = session
 	}
 
-	// Prepare response
-	response := gin.H{
-		"message":        fmt.Sprintf("Uploaded %d file(s)", len(uploadedFiles)),
-		"uploaded_files": uploadedFiles,
-		"uploaded_count": len(uploadedFiles),
+	// Scans must descend as deep as the uploaded folders, or they would drop
+	// the files again and pick the subfolders up as projects of their own
+	depth := project.ScanSettings.MaxDepth
+	for _, result := range results {
+		if result.File != nil {
+			depth = max(depth, uploadDepth(result.File.Filename))
+		}
 	}
-
-	if len(skippedFiles) > 0 {
-		response["skipped_files"] = skippedFiles
-		response["skipped_count"] = len(skippedFiles)
+	if depth > project.ScanSettings.MaxDepth {
+		project.ScanSettings.MaxDepth = depth
+		if err := requestDB(c).Model(&project).Select("scan_settings").Updates(&project).Error; err != nil {
+			response.Warnings = append(response.Warnings, "Failed to extend the project's scan depth to the uploaded folders")
+		}
 	}
 
-	if len(errors) > 0 {
-		response["errors"] = errors
-		response["error_count"] = len(errors)
+	// Update project last_scanned time
+	if err := requestDB(c).Model(&project).Update("last_scanned", time.Now()).Error; err != nil {
+		// Non-critical error, just log it
+		response.Warnings = append(response.Warnings, "Failed to update project scan time")
 	}
 
-	fmt.Printf("Upload summary - Uploaded: %d, Skipped: %d, Errors: %d\n", len(uploadedFiles), len(skippedFiles), len(errors))
+	counts := countUploadResults(results)
+	applied := counts[UploadUploaded] + counts[UploadRenamed] + counts[UploadOverwritten]
+	response.Counts = counts
+	response.Message = fmt.Sprintf("Uploaded %d file(s)", applied)
+
+	fmt.Printf("Upload summary - Uploaded: %d, Skipped: %d, Failed: %d\n", applied, counts[UploadSkipped], counts[UploadFailed])
 
 	// Return 200 if any files were processed (uploaded or skipped), 400 only if nothing was processed
-	if len(uploadedFiles) > 0 || len(skippedFiles) > 0 {
+	if applied > 0 || counts[UploadSkipped] > 0 {
 		c.JSON(http.StatusOK, response)
+	} else if hasUploadReason(results, ReasonStale) {
+		// Everything conflicted with changes made after the check; the client must re-check
+		response.Error = "Files changed since the conflict check, check again before uploading"
+		c.JSON(http.StatusConflict, response)
 	} else {
 		fmt.Printf("ERROR: No files were processed - returning 400\n")
+		response.Error = "No files were uploaded"
 		c.JSON(http.StatusBadRequest, response)
 	}
 }
 
-// ScanProjects triggers a filesystem scan for projects
+// ScanProjects starts a filesystem scan for projects in the background and
+// returns its job at once; GetScanJob reports its progress
 func (h *ProjectsHandler) ScanProjects(c *gin.Context) {
-	if err := h.scanner.ScanForProjects(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to scan projects",
-			"details": err.Error(),
-		})
+	run, err := h.scanner.Start(models.ScanTriggerManual)
+	if errors.Is(err, database.ErrLocked) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another scan or library operation is running, try again later"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start scan", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Scan started",
+		"job_id":   run.ID,
+		"scan_run": run,
+	})
+}
+
+// GetScanJob reports a scan job's progress: its status and the directories
+// visited, projects found and files hashed so far
+func (h *ProjectsHandler) GetScanJob(c *gin.Context) {
+	var run models.ScanRun
+	if err := requestDB(c).First(&run, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scan job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// CancelScanJob cancels a pending or running scan job. Running scans stop
+// within a second or so, keeping what they recorded so far.
+func (h *ProjectsHandler) CancelScanJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scan job not found"})
 		return
 	}
 
-	// Return updated project count
-	var count int64
-	database.GetDB().Model(&models.Project{}).Count(&count)
+	run, err := h.scanner.Cancel(uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scan job not found"})
+		return
+	case errors.Is(err, scanner.ErrScanFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": "Scan job has already finished", "scan_run": run})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel scan job", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Scan completed successfully",
-		"project_count": count,
+		"message":  "Scan job cancelled",
+		"scan_run": run,
 	})
 }
 
@@ -475,7 +771,7 @@ func (h *ProjectsHandler) SyncProject(c *gin.Context) {
 	id := c.Param("id")
 
 	var project models.Project
-	if err := database.GetDB().First(&project, id).Error; err != nil {
+	if err := requestDB(c).First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
@@ -488,15 +784,56 @@ func (h *ProjectsHandler) SyncProject(c *gin.Context) {
 	})
 }
 
-// GetProjectFiles returns files for a specific project
+// GetProjectFiles returns files for a specific project with their print profiles,
+// and G-code files paired to the models they were sliced from; streamed one
+// file per line to clients accepting application/x-ndjson
 func (h *ProjectsHandler) GetProjectFiles(c *gin.Context) {
 	id := c.Param("id")
 
+	// With ?printable_on= only the models fitting the printer are listed
+	var fileIDs []uint
+	printer, ok := printableOn(c)
+	if !ok {
+		return
+	}
+	if printer != nil {
+		var models3D []models.ProjectFile
+		if err := requestDB(c).Where("project_id = ? AND file_type = ?", id, models.FileTypeSTL).Find(&models3D).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project files"})
+			return
+		}
+		var err error
+		if fileIDs, err = fittingFileIDs(requestDB(c), *printer, models3D); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check models"})
+			return
+		}
+	}
+
+	if wantsNDJSON(c) {
+		h.streamProjectFiles(c, id, fileIDs)
+		return
+	}
+
+	query := requestDB(c).Where("project_id = ?", id)
+	if fileIDs != nil {
+		query = query.Where("id IN ?", fileIDs)
+	}
 	var files []models.ProjectFile
-	if err := database.GetDB().Where("project_id = ?", id).Find(&files).Error; err != nil {
+	if err := query.Find(&files).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project files"})
 		return
 	}
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+	pairing.Pair(files)
+	if len(files) > 0 {
+		if err := attachFileProfiles(requestDB(c), files[0].ProjectID, files, prefs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profiles"})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"files": files,
@@ -509,15 +846,17 @@ func (h *ProjectsHandler) GetProjectREADME(c *gin.Context) {
 	id := c.Param("id")
 
 	var project models.Project
-	if err := database.GetDB().First(&project, id).Error; err != nil {
+	if err := requestDB(c).First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
 	if project.Description == "" {
 		c.JSON(http.StatusOK, gin.H{
-			"html": "",
-			"raw":  "",
+			"html":     "",
+			"raw":      "",
+			"metadata": projectMetadata(&project),
+			"template": frontmatter.Template(project.Name),
 		})
 		return
 	}
@@ -533,33 +872,59 @@ func (h *ProjectsHandler) GetProjectREADME(c *gin.Context) {
 	htmlContent := markdown.ToHTML([]byte(project.Description), p, renderer)
 
 	c.JSON(http.StatusOK, gin.H{
-		"html": string(htmlContent),
-		"raw":  project.Description,
+		"html":     string(htmlContent),
+		"raw":      project.Description,
+		"metadata": projectMetadata(&project),
 	})
 }
 
-// DeleteProjectFile deletes a specific file from a project
+// DeleteProjectFile deletes a specific file from a project, with its print
+// profile and activity, and clears it as the cover. Files others were
+// deduplicated against are kept.
 func (h *ProjectsHandler) DeleteProjectFile(c *gin.Context) {
 	projectID := c.Param("id")
 	fileID := c.Param("fileId")
 
 	// Verify project exists
 	var project models.Project
-	if err := database.GetDB().First(&project, projectID).Error; err != nil {
+	if err := requestDB(c).First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
 	// Find and verify the file belongs to this project
 	var file models.ProjectFile
-	if err := database.GetDB().Where("id = ? AND project_id = ?", fileID, projectID).First(&file).Error; err != nil {
+	if err := requestDB(c).Where("id = ? AND project_id = ?", fileID, projectID).First(&file).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
+	if !checkVersion(c, "File", file.UpdatedAt, nil) {
+		return
+	}
+	if rejectCanonicalCopy(c, requestDB(c), file.ID) {
+		return
+	}
 
-	// Delete the physical file from filesystem
+	// The file is moved aside until its records are gone, so a failure on
+	// either side leaves both as they were
 	fullPath := filepath.Join(project.Path, file.Filename)
-	if err := os.Remove(fullPath); err != nil {
+	if project.IsFlat() {
+		fullPath = file.Filepath
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file from filesystem"})
+		return
+	}
+	// Hidden, like staging directories, so scans skip it
+	asidePath := filepath.Join(filepath.Dir(fullPath), ".delete-"+token)
+	if err := os.Rename(fullPath, asidePath); err != nil {
 		// If file doesn't exist on filesystem, log warning but continue with DB deletion
 		if !os.IsNotExist(err) {
 			fmt.Printf("Warning: Failed to delete file from filesystem: %v\n", err)
@@ -567,16 +932,39 @@ func (h *ProjectsHandler) DeleteProjectFile(c *gin.Context) {
 			return
 		}
 		fmt.Printf("Warning: File %s not found on filesystem, proceeding with database cleanup\n", fullPath)
+		asidePath = ""
 	}
 
-	// Delete the database record
-	if err := database.GetDB().Delete(&file).Error; err != nil {
+	// Delete the database records, with those kept by its filename
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&file).Error; err != nil {
+			return err
+		}
+		for _, keyed := range models.FilenameKeyed {
+			if err := tx.Where("project_id = ? AND filename = ?", project.ID, file.Filename).Delete(keyed).Error; err != nil {
+				return err
+			}
+		}
+		if project.CoverFilename != "" && project.CoverFilename == file.Filename {
+			return tx.Model(&project).Update("cover_filename", "").Error
+		}
+		return nil
+	})
+	if err != nil {
+		if asidePath != "" {
+			os.Rename(asidePath, fullPath)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file from database"})
 		return
 	}
+	if asidePath != "" {
+		if err := os.Remove(asidePath); err != nil {
+			fmt.Printf("Warning: Failed to remove deleted file %s: %v\n", asidePath, err)
+		}
+	}
 
 	// Update project's last_scanned timestamp
-	if err := database.GetDB().Model(&project).Update("last_scanned", time.Now()).Error; err != nil {
+	if err := requestDB(c).Model(&project).Update("last_scanned", time.Now()).Error; err != nil {
 		fmt.Printf("Warning: Failed to update project last_scanned timestamp: %v\n", err)
 	}
 
@@ -594,7 +982,7 @@ func (h *ProjectsHandler) GetProjectStats(c *gin.Context) {
 	id := c.Param("id")
 
 	var project models.Project
-	if err := database.GetDB().Preload("Files").First(&project, id).Error; err != nil {
+	if err := requestDB(c).Preload("Files").First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
@@ -612,11 +1000,13 @@ func (h *ProjectsHandler) GetProjectStats(c *gin.Context) {
 		fileTypes[file.FileType]++
 		stats["total_size"] = stats["total_size"].(int64) + file.Size
 	}
+	stats["print_totals"], stats["print_profiles"] = projectPrintTotals(project.Files)
 
 	c.JSON(http.StatusOK, stats)
 }
 
-// SearchProjects searches projects by name or description
+// SearchProjects searches projects by name, description or the text
+// extracted from their files, such as model cards
 func (h *ProjectsHandler) SearchProjects(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
@@ -624,12 +1014,18 @@ func (h *ProjectsHandler) SearchProjects(c *gin.Context) {
 		return
 	}
 
+	opts, err := parseProjectQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var projects []models.Project
 	searchPattern := "%" + query + "%"
 
-	if err := database.GetDB().
-		Preload("Files").
-		Where("name LIKE ? OR description LIKE ?", searchPattern, searchPattern).
+	if err := opts.apply(requestDB(c)).
+		Where("name LIKE ? OR description LIKE ? OR EXISTS (SELECT 1 FROM project_files WHERE project_files.project_id = projects.id AND project_files.text LIKE ?)",
+			searchPattern, searchPattern, searchPattern).
 		Find(&projects).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
 		return
@@ -646,6 +1042,20 @@ func (h *ProjectsHandler) SearchProjects(c *gin.Context) {
 type UpdateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+
+	// ScanSettings replaces the project's scan overrides when present; they apply from the next scan
+	ScanSettings *models.ProjectScanSettings `json:"scan_settings"`
+
+	// Public and ListPrice change the project's storefront listing when present
+	Public    *bool    `json:"public"`
+	ListPrice *float64 `json:"list_price"`
+
+	// Rating changes the project's star rating when present; 0 clears it
+	Rating *int `json:"rating"`
+
+	// UpdatedAt is the updated_at the client read; the update is rejected when
+	// the project changed since. An If-Match header takes precedence.
+	UpdatedAt *time.Time `json:"updated_at"`
 }
 
 // UpdateProject updates a project's name and/or description, and renames the directory if needed
@@ -658,34 +1068,59 @@ func (h *ProjectsHandler) UpdateProject(c *gin.Context) {
 		return
 	}
 
+	if req.ScanSettings != nil {
+		if err := req.ScanSettings.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.ListPrice != nil && *req.ListPrice < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "list_price must not be negative"})
+		return
+	}
+	if req.Rating != nil {
+		if err := models.ValidateRating(*req.Rating); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Get the existing project
 	var project models.Project
-	if err := database.GetDB().First(&project, id).Error; err != nil {
+	if err := requestDB(c).First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !checkProjectVersion(c, &project, req.UpdatedAt) {
+		return
+	}
+
 	// Check if name is changing
 	nameChanged := project.Name != req.Name
 
-	// If name is changing, validate new name and prepare for directory rename
+	// If name is changing, the slug follows it and the directory is renamed,
+	// numbered like a new project's when another project has the name
 	var newPath string
 	if nameChanged {
-		// Sanitize new project name (same logic as CreateProject)
-		safeName := strings.ReplaceAll(req.Name, "/", "_")
-		safeName = strings.ReplaceAll(safeName, " ", "_")
-		newPath = filepath.Join(filepath.Dir(project.Path), safeName)
-
-		// Check if new directory would conflict
-		if _, err := os.Stat(newPath); err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "A project with this name already exists"})
+		slug, err := models.UniqueSlug(requestDB(c), req.Name, project.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
 			return
 		}
-
-		// Check if another project in DB has the same name
-		var existingProject models.Project
-		if err := database.GetDB().Where("name = ? AND id != ?", req.Name, project.ID).First(&existingProject).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "A project with this name already exists"})
+		project.Slug = slug
+	}
+	// Flat projects have no directory, and names differing only by spaces keep theirs
+	moveDir := nameChanged && !project.IsFlat() && h.projectDirName(req.Name) != filepath.Base(project.Path)
+	if moveDir {
+		var err error
+		if newPath, err = h.uniqueProjectPath(requestDB(c), filepath.Dir(project.Path), req.Name, project.ID); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A project with this name already exists", "details": err.Error()})
 			return
 		}
 	}
@@ -693,18 +1128,30 @@ func (h *ProjectsHandler) UpdateProject(c *gin.Context) {
 	// Update project in database first
 	project.Name = req.Name
 	project.Description = req.Description
+	if req.ScanSettings != nil {
+		project.ScanSettings = *req.ScanSettings
+	}
+	if req.Public != nil {
+		project.Public = *req.Public
+	}
+	if req.ListPrice != nil {
+		project.ListPrice = *req.ListPrice
+	}
+	if req.Rating != nil {
+		project.Rating = *req.Rating
+	}
 	project.UpdatedAt = time.Now()
 
-	if err := database.GetDB().Save(&project).Error; err != nil {
+	if err := requestDB(c).Save(&project).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
 		return
 	}
 
 	// If name changed, rename the directory
-	if nameChanged {
+	if moveDir {
 		if err := os.Rename(project.Path, newPath); err != nil {
 			// Rollback database changes
-			database.GetDB().Model(&project).Updates(map[string]interface{}{
+			requestDB(c).Model(&project).Updates(map[string]interface{}{
 				"name":        project.Name, // Original name
 				"description": project.Description,
 			})
@@ -716,7 +1163,7 @@ func (h *ProjectsHandler) UpdateProject(c *gin.Context) {
 		oldPath := project.Path
 		project.Path = newPath
 
-		if err := database.GetDB().Save(&project).Error; err != nil {
+		if err := requestDB(c).Save(&project).Error; err != nil {
 			// Try to rollback directory rename
 			os.Rename(newPath, oldPath)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project path"})
@@ -724,53 +1171,108 @@ func (h *ProjectsHandler) UpdateProject(c *gin.Context) {
 		}
 
 		// Update file paths for all associated files
-		if err := database.GetDB().Model(&models.ProjectFile{}).
+		if err := requestDB(c).Model(&models.ProjectFile{}).
 			Where("project_id = ?", project.ID).
 			Update("filepath", fmt.Sprintf("REPLACE(filepath, '%s', '%s')", oldPath, newPath)).Error; err != nil {
 			fmt.Printf("Warning: Failed to update file paths for project %d: %v\n", project.ID, err)
 		}
 	}
 
+	if err := h.scanner.RefreshSidecar(&project); err != nil {
+		fmt.Printf("Warning: Failed to write sidecar for project %d: %v\n", project.ID, err)
+	}
+
 	// Return updated project with files
-	if err := database.GetDB().Preload("Files").First(&project, id).Error; err != nil {
+	if err := (projectQueryOptions{IncludeFiles: true}).apply(requestDB(c)).First(&project, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated project"})
 		return
 	}
 
+	c.Header("ETag", versionTag(project.UpdatedAt))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Project updated successfully",
 		"project": project,
 	})
 }
 
-// DeleteProject deletes a project completely (directory and database entries)
+// rejectCanonicalCopy reports a conflict, and returns true, when the file is
+// the canonical copy files were deduplicated against. Deduplicated files may
+// be symlinks to it, which renaming or deleting it would break.
+func rejectCanonicalCopy(c *gin.Context, db *gorm.DB, fileID uint) bool {
+	var references int64
+	if err := db.Model(&models.ProjectFile{}).Where("duplicate_of = ?", fileID).Count(&references).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check deduplicated files"})
+		return true
+	}
+	if references > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "File is the canonical copy of deduplicated files", "duplicates": references})
+		return true
+	}
+	return false
+}
+
+// DeleteProject deletes a project completely (directory and database entries),
+// unless files of other projects were deduplicated against its files
 func (h *ProjectsHandler) DeleteProject(c *gin.Context) {
 	id := c.Param("id")
 
 	// Get the project
 	var project models.Project
-	if err := database.GetDB().Preload("Files").First(&project, id).Error; err != nil {
+	if err := requestDB(c).Preload("Files").First(&project, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
-	// Delete all files from database first
-	if err := database.GetDB().Where("project_id = ?", project.ID).Delete(&models.ProjectFile{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project files from database"})
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !checkProjectVersion(c, &project, nil) {
 		return
 	}
 
-	// Delete project from database
-	if err := database.GetDB().Delete(&project).Error; err != nil {
+	// Duplicates elsewhere may be symlinks to the project's files
+	var references int64
+	if err := requestDB(c).Model(&models.ProjectFile{}).
+		Where("project_id <> ? AND duplicate_of IN (?)", project.ID, requestDB(c).Model(&models.ProjectFile{}).Select("id").Where("project_id = ?", project.ID)).
+		Count(&references).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check deduplicated files"})
+		return
+	}
+	if references > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Project holds the canonical copies of files deduplicated in other projects", "duplicates": references})
+		return
+	}
+
+	// Delete the project with its files and other records; the soft delete
+	// lists it in sync deltas and prints keep its name
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := database.DeleteProjectRecords(tx, []uint{project.ID}); err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project from database"})
 		return
 	}
 
-	// Remove directory from filesystem
-	if err := os.RemoveAll(project.Path); err != nil {
+	// Remove directory from filesystem; flat projects only own their files
+	if project.IsFlat() {
+		for _, file := range project.Files {
+			if err := os.Remove(file.Filepath); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("Warning: Failed to remove project file %s: %v\n", file.Filepath, err)
+			}
+		}
+	} else if err := os.RemoveAll(project.Path); err != nil {
 		fmt.Printf("Warning: Failed to remove project directory %s: %v\n", project.Path, err)
 		// Don't return error here as database cleanup was successful
 	}
+	if h.snapshots != nil {
+		if err := h.snapshots.Remove(snapshotKey(project.ID)); err != nil {
+			fmt.Printf("Warning: Failed to remove source snapshot of project %d: %v\n", project.ID, err)
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Project deleted successfully",
@@ -785,14 +1287,14 @@ func (h *ProjectsHandler) DownloadProjectFile(c *gin.Context) {
 
 	// Verify project exists
 	var project models.Project
-	if err := database.GetDB().First(&project, projectID).Error; err != nil {
+	if err := requestDB(c).First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
 	// Find and verify the file belongs to this project
 	var file models.ProjectFile
-	if err := database.GetDB().Where("id = ? AND project_id = ?", fileID, projectID).First(&file).Error; err != nil {
+	if err := requestDB(c).Where("id = ? AND project_id = ?", fileID, projectID).First(&file).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
@@ -803,33 +1305,47 @@ func (h *ProjectsHandler) DownloadProjectFile(c *gin.Context) {
 		return
 	}
 
-	// Set headers for file download
-	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.Filename))
-	c.Header("Content-Type", "application/octet-stream")
-
-	// Stream the file
-	c.File(file.Filepath)
+	// Stream the file with download and checksum headers
+	serveFile(c, &file)
 }
 
-// DownloadProject downloads the entire project as a ZIP file
+// DownloadProject streams the project directory as a ZIP file, with the
+// print profiles of its models; ?types=stl,gcode limits it to some file types
 func (h *ProjectsHandler) DownloadProject(c *gin.Context) {
 	projectID := c.Param("id")
 
+	types, err := parseExportFileTypes(c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid types", "details": err.Error()})
+		return
+	}
+
 	// Verify project exists
 	var project models.Project
-	if err := database.GetDB().Preload("Files").First(&project, projectID).Error; err != nil {
+	if err := requestDB(c).Preload("Files").First(&project, projectID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		return
 	}
 
 	// Check if project directory exists
-	if _, err := os.Stat(project.Path); os.IsNotExist(err) {
+	if _, err := os.Stat(project.Path); os.IsNotExist(err) && !project.IsFlat() {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Project directory not found"})
 		return
 	}
 
+	profiles, err := loadFileProfiles(requestDB(c), project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print profiles"})
+		return
+	}
+	profiles = types.profiles(profiles)
+
+	// Downloading the project counts as downloading each of its files
+	files := types.files(project.Files)
+	for _, file := range files {
+		recordDownload(requestDB(c), file)
+	}
+
 	// Set headers for ZIP download
 	zipFilename := fmt.Sprintf("%s.zip", strings.ReplaceAll(project.Name, " ", "_"))
 	c.Header("Content-Type", "application/zip")
@@ -839,14 +1355,31 @@ func (h *ProjectsHandler) DownloadProject(c *gin.Context) {
 	zipWriter := zip.NewWriter(c.Writer)
 	defer zipWriter.Close()
 
+	// Print profiles live in the database, so they travel with the export
+	if err := zipFileProfiles(zipWriter, profiles); err != nil {
+		fmt.Printf("Error creating ZIP file for project %s: %v\n", project.Name, err)
+		return
+	}
+
+	// Flat projects have no directory; zip their recorded files
+	if project.IsFlat() {
+		if err := zipProjectFiles(zipWriter, files); err != nil {
+			fmt.Printf("Error creating ZIP file for project %s: %v\n", project.Name, err)
+		}
+		return
+	}
+
 	// Walk through project directory and add all files to ZIP
-	err := filepath.Walk(project.Path, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(project.Path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip directories
+		// Skip directories, and in-flight upload staging areas entirely
 		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), stagingDirPrefix) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -855,6 +1388,14 @@ func (h *ProjectsHandler) DownloadProject(c *gin.Context) {
 		if err != nil {
 			return err
 		}
+		if !types.includes(relPath) {
+			return nil
+		}
+
+		// Compressed models are added decompressed, as they are downloaded
+		if mesh.IsCompressed(path) {
+			relPath = strings.TrimSuffix(relPath, filepath.Ext(relPath))
+		}
 
 		// Create ZIP entry
 		zipFile, err := zipWriter.Create(relPath)
@@ -863,7 +1404,7 @@ func (h *ProjectsHandler) DownloadProject(c *gin.Context) {
 		}
 
 		// Open source file
-		sourceFile, err := os.Open(path)
+		sourceFile, _, err := mesh.Open(path)
 		if err != nil {
 			return err
 		}
@@ -885,7 +1426,7 @@ func (h *ProjectsHandler) DownloadProject(c *gin.Context) {
 // HealthCheck returns the health status of the service
 func (h *ProjectsHandler) HealthCheck(c *gin.Context) {
 	// Check database connectivity
-	sqlDB, err := database.GetDB().DB()
+	sqlDB, err := requestDB(c).DB()
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"status": "unhealthy",
@@ -904,11 +1445,11 @@ func (h *ProjectsHandler) HealthCheck(c *gin.Context) {
 
 	// Count projects
 	var projectCount int64
-	database.GetDB().Model(&models.Project{}).Count(&projectCount)
+	requestDB(c).Model(&models.Project{}).Count(&projectCount)
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":        "healthy",
 		"project_count": projectCount,
-		"timestamp":     database.GetDB().NowFunc(),
+		"timestamp":     requestDB(c).NowFunc(),
 	})
 }
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,8 @@ import (
 
 	"3dshelf/internal/models"
 	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/naming"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
@@ -29,7 +32,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Run migrations
-	err = db.AutoMigrate(&models.Project{}, &models.ProjectFile{})
+	err = database.Migrate(db)
 	if err != nil {
 		t.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -113,6 +116,7 @@ func setupRouter(tmpDir string) *gin.Engine {
 		api.GET("/health", handler.HealthCheck)
 		api.GET("/projects", handler.GetProjects)
 		api.POST("/projects", handler.CreateProject)
+		api.POST("/projects/upload", handler.CreateProjectFromUpload)
 		api.POST("/projects/scan", handler.ScanProjects)
 		api.GET("/projects/search", handler.SearchProjects)
 		api.GET("/projects/:id", handler.GetProject)
@@ -122,6 +126,7 @@ func setupRouter(tmpDir string) *gin.Engine {
 		api.GET("/projects/:id/files", handler.GetProjectFiles)
 		api.POST("/projects/:id/files", handler.UploadProjectFiles)
 		api.GET("/projects/:id/readme", handler.GetProjectREADME)
+		api.PUT("/projects/:id/readme", handler.UpdateProjectREADME)
 		api.GET("/projects/:id/stats", handler.GetProjectStats)
 	}
 
@@ -136,7 +141,7 @@ func TestGetProjects(t *testing.T) {
 	router := setupRouter(tmpDir)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/api/projects", nil)
+	req, _ := http.NewRequest("GET", "/api/projects?include=files", nil)
 	router.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
@@ -179,6 +184,75 @@ func TestGetProjects(t *testing.T) {
 	}
 }
 
+// TestGetProjectsFieldSelection tests aggregate-only and summary list responses
+func TestGetProjectsFieldSelection(t *testing.T) {
+	db := setupTestDB(t)
+	createTestData(t, db)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+
+	t.Run("Default returns aggregates without files", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects", nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		first := response["projects"].([]interface{})[0].(map[string]interface{})
+		if _, exists := first["files"]; exists {
+			t.Error("Files should not be included by default")
+		}
+		if first["file_count"].(float64) != 2 {
+			t.Errorf("Expected file_count 2, got %v", first["file_count"])
+		}
+		if first["total_size"].(float64) != 2560 {
+			t.Errorf("Expected total_size 2560, got %v", first["total_size"])
+		}
+		if first["description"] == "" {
+			t.Error("Full fields should include the description")
+		}
+
+		empty := response["projects"].([]interface{})[2].(map[string]interface{})
+		if empty["file_count"].(float64) != 0 || empty["total_size"].(float64) != 0 {
+			t.Errorf("Expected zero aggregates for empty project, got %v", empty)
+		}
+	})
+
+	t.Run("Summary omits heavy columns", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects?fields=summary", nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		first := response["projects"].([]interface{})[0].(map[string]interface{})
+		if first["description"] != "" || first["path"] != "" {
+			t.Errorf("Summary should omit description and path, got %v", first)
+		}
+		if first["name"] != "Test Project 1" || first["file_count"].(float64) != 2 {
+			t.Errorf("Summary should keep name and aggregates, got %v", first)
+		}
+	})
+
+	t.Run("Invalid selection", func(t *testing.T) {
+		for _, query := range []string{"include=notes", "fields=everything"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/projects?"+query, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
+
 // TestGetProject tests the GetProject endpoint
 func TestGetProject(t *testing.T) {
 	db := setupTestDB(t)
@@ -216,10 +290,38 @@ func TestGetProject(t *testing.T) {
 	}
 }
 
+// setupScanRouter routes scans to a handler whose scans run on started job
+// workers, over a database file the workers share
+func setupScanRouter(t *testing.T, scanPath string) (*gorm.DB, *gin.Engine, *ProjectsHandler) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scan.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	database.DB = db
+
+	queue := jobs.New(db)
+	handler := NewProjectsHandler(scanPath)
+	handler.SetJobQueue(queue)
+	if err := queue.Start(1); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+	t.Cleanup(queue.Stop)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/projects/scan", handler.ScanProjects)
+	router.GET("/api/scan/jobs/:id", handler.GetScanJob)
+	router.POST("/api/scan/jobs/:id/cancel", handler.CancelScanJob)
+	return db, router, handler
+}
+
 // TestScanProjects tests the ScanProjects endpoint
 func TestScanProjects(t *testing.T) {
-	db := setupTestDB(t)
 	tmpDir := t.TempDir()
+	db, router, handler := setupScanRouter(t, tmpDir)
 
 	// Create a test project directory
 	projectDir := filepath.Join(tmpDir, "ScanTestProject")
@@ -241,30 +343,32 @@ func TestScanProjects(t *testing.T) {
 		t.Fatalf("Failed to create README file: %v", err)
 	}
 
-	router := setupRouter(tmpDir)
-
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/api/projects/scan", nil)
-	router.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	w := sendJSON(router, "POST", "/api/projects/scan", "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
 	}
 
-	var response map[string]interface{}
-	err = json.Unmarshal(w.Body.Bytes(), &response)
-	if err != nil {
-		t.Errorf("Failed to unmarshal response: %v", err)
+	var response struct {
+		Message string         `json:"message"`
+		JobID   uint           `json:"job_id"`
+		ScanRun models.ScanRun `json:"scan_run"`
 	}
-
-	message, exists := response["message"].(string)
-	if !exists || !strings.Contains(message, "Scan completed successfully") {
-		t.Errorf("Expected success message, got '%s'", message)
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Message != "Scan started" || response.JobID == 0 || response.ScanRun.ID != response.JobID {
+		t.Errorf("Expected the started scan's job, got %s", w.Body.String())
 	}
 
-	projectCount, exists := response["project_count"].(float64)
-	if !exists || int(projectCount) != 1 {
-		t.Errorf("Expected project_count to be 1, got %f", projectCount)
+	handler.Scanner().Wait(response.JobID)
+	w = sendJSON(router, "GET", fmt.Sprintf("/api/scan/jobs/%d", response.JobID), "")
+	var run models.ScanRun
+	json.Unmarshal(w.Body.Bytes(), &run)
+	if w.Code != http.StatusOK || run.Status != models.ScanRunCompleted {
+		t.Fatalf("Expected the scan job completed, got %d: %s", w.Code, w.Body.String())
+	}
+	if run.DirsVisited != 1 || run.ProjectsFound != 1 || run.FilesHashed != 2 {
+		t.Errorf("Expected the scan's progress, got %+v", run)
 	}
 
 	// Verify project was created in database
@@ -277,6 +381,36 @@ func TestScanProjects(t *testing.T) {
 	if len(project.Files) != 2 {
 		t.Errorf("Expected 2 files in scanned project, got %d", len(project.Files))
 	}
+
+	// Finished jobs can no longer be cancelled
+	path := fmt.Sprintf("/api/scan/jobs/%d/cancel", response.JobID)
+	if w := sendJSON(router, "POST", path, ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 cancelling a finished scan, got %d", w.Code)
+	}
+	for _, path := range []string{"/api/scan/jobs/999", "/api/scan/jobs/abc"} {
+		if w := sendJSON(router, "GET", path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, w.Code)
+		}
+		if w := sendJSON(router, "POST", path+"/cancel", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 cancelling %s, got %d", path, w.Code)
+		}
+	}
+}
+
+// TestCancelScanJob tests cancelling a running scan job
+func TestCancelScanJob(t *testing.T) {
+	db, router, _ := setupScanRouter(t, t.TempDir())
+
+	run := models.ScanRun{Trigger: models.ScanTriggerManual, Status: models.ScanRunRunning, StartedAt: time.Now()}
+	db.Create(&run)
+	w := sendJSON(router, "POST", fmt.Sprintf("/api/scan/jobs/%d/cancel", run.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	db.First(&run, run.ID)
+	if !run.CancelRequested || run.Status != models.ScanRunRunning {
+		t.Errorf("Expected the cancellation requested of the running scan, got %+v", run)
+	}
 }
 
 // TestSearchProjects tests the SearchProjects endpoint
@@ -286,12 +420,22 @@ func TestSearchProjects(t *testing.T) {
 	tmpDir := t.TempDir()
 	router := setupRouter(tmpDir)
 
+	// Text read from a model card is searched along with the project
+	db.Create(&models.ProjectFile{ProjectID: 3, Filename: "card.pdf", Filepath: "/test/empty/card.pdf",
+		FileType: models.FileTypeOther, Text: "Print settings: 0.16mm quality profile, 15% gyroid infill"})
+
 	testCases := []struct {
 		name           string
 		query          string
 		expectedCount  int
 		expectedStatus int
 	}{
+		{
+			name:           "Search by file text",
+			query:          "0.16mm",
+			expectedCount:  1,
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "Search by name",
 			query:          "Test Project 1",
@@ -839,7 +983,7 @@ func TestCreateProject(t *testing.T) {
 	}
 }
 
-// TestCreateProjectDuplicate tests creating a project with duplicate name/path
+// TestCreateProjectDuplicate tests creating a project with a name that is taken
 func TestCreateProjectDuplicate(t *testing.T) {
 	db := setupTestDB(t)
 	tmpDir := t.TempDir()
@@ -862,24 +1006,96 @@ func TestCreateProjectDuplicate(t *testing.T) {
 		t.Fatalf("Failed to create project directory: %v", err)
 	}
 
-	// Try to create a project with the same name
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/api/projects", strings.NewReader(`{"name": "Duplicate Test", "description": "Second project"}`))
-	req.Header.Set("Content-Type", "application/json")
-	router.ServeHTTP(w, req)
+	t.Run("Error policy", func(t *testing.T) {
+		w := sendJSON(router, "POST", "/api/projects", `{"name": "Duplicate Test", "name_collision": "error"}`)
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
 
-	if w.Code != http.StatusConflict {
-		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Errorf("Failed to unmarshal response: %v", err)
+		}
+
+		errorMsg, ok := response["error"].(string)
+		if !ok || !strings.Contains(errorMsg, "already exists") {
+			t.Errorf("Expected error about duplicate project, got: %v", response)
+		}
+	})
+
+	t.Run("Suffix policy", func(t *testing.T) {
+		w := sendJSON(router, "POST", "/api/projects", `{"name": "Duplicate Test", "description": "Second project"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var project models.Project
+		json.Unmarshal(w.Body.Bytes(), &project)
+		if project.Slug != "duplicate-test-2" || project.Path != filepath.Join(tmpDir, "Duplicate_Test_2") {
+			t.Errorf("Expected a numbered slug and directory, got %q and %q", project.Slug, project.Path)
+		}
+		if _, err := os.Stat(project.Path); err != nil {
+			t.Errorf("Expected the numbered directory to be created: %v", err)
+		}
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		if w := sendJSON(router, "POST", "/api/projects", `{"name": "Duplicate Test", "name_collision": "merge"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// TestCreateProjectUnicodeName tests that a project keeps its display name
+// while its directory follows the naming policy
+func TestCreateProjectUnicodeName(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	handler.SetDirNaming(naming.Policy{Transliterate: true, MaxBytes: 120})
+	router.POST("/api/projects", handler.CreateProject)
+
+	w := sendJSON(router, "POST", "/api/projects", `{"name": "Café Ørsted: Lamp"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
 	}
 
-	var response map[string]interface{}
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Errorf("Failed to unmarshal response: %v", err)
+	var project models.Project
+	db.First(&project)
+	if project.Name != "Café Ørsted: Lamp" || project.Path != filepath.Join(tmpDir, "Cafe_Orsted__Lamp") {
+		t.Errorf("Expected the name kept and an ASCII directory, got %q in %q", project.Name, project.Path)
 	}
+}
 
-	errorMsg, ok := response["error"].(string)
-	if !ok || !strings.Contains(errorMsg, "already exists") {
-		t.Errorf("Expected error about duplicate project, got: %v", response)
+// TestUpdateProjectSlug tests that renaming a project moves its slug and
+// numbers its directory when another project has the name
+func TestUpdateProjectSlug(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+
+	other := models.Project{Name: "Lamp", Path: filepath.Join(tmpDir, "Lamp")}
+	project := models.Project{Name: "Shade", Path: filepath.Join(tmpDir, "Shade")}
+	for _, p := range []*models.Project{&other, &project} {
+		db.Create(p)
+		os.MkdirAll(p.Path, 0755)
+	}
+
+	w := sendJSON(router, "PUT", "/api/projects/"+strconv.FormatUint(uint64(project.ID), 10), `{"name": "Lamp"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var updated models.Project
+	db.First(&updated, project.ID)
+	if updated.Slug != "lamp-2" || updated.Path != filepath.Join(tmpDir, "Lamp_2") {
+		t.Errorf("Expected slug lamp-2 in Lamp_2, got %q in %q", updated.Slug, updated.Path)
+	}
+	if _, err := os.Stat(updated.Path); err != nil {
+		t.Errorf("Expected the directory to be renamed: %v", err)
 	}
 }
 
@@ -936,13 +1152,18 @@ func TestUploadProjectFiles(t *testing.T) {
 			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 		}
 
-		var response map[string]interface{}
+		var response UploadResponse
 		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 			t.Errorf("Failed to unmarshal response: %v", err)
 		}
 
-		if uploadedCount, ok := response["uploaded_count"].(float64); !ok || uploadedCount != 2 {
-			t.Errorf("Expected 2 uploaded files, got: %v", response["uploaded_count"])
+		if response.Counts[UploadUploaded] != 2 || len(response.Results) != 2 {
+			t.Errorf("Expected 2 uploaded files, got: %+v", response)
+		}
+		for _, result := range response.Results {
+			if result.Status != UploadUploaded || result.File == nil || result.File.Filename != result.Filename {
+				t.Errorf("Expected an uploaded result with its file record, got %+v", result)
+			}
 		}
 
 		// Check files were created in database
@@ -1113,6 +1334,55 @@ func TestUpdateProject(t *testing.T) {
 		}
 	})
 
+	t.Run("Update scan settings", func(t *testing.T) {
+		updateData := map[string]interface{}{
+			"name":        "Updated Project Name",
+			"description": "Updated description",
+			"scan_settings": map[string]interface{}{
+				"exclude":     []string{"*.zip", "archive/*"},
+				"max_depth":   2,
+				"hash_policy": "none",
+			},
+		}
+		jsonData, _ := json.Marshal(updateData)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/projects/"+strconv.Itoa(int(project.ID)), strings.NewReader(string(jsonData)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var updatedProject models.Project
+		db.First(&updatedProject, project.ID)
+		settings := updatedProject.ScanSettings
+		if len(settings.Exclude) != 2 || settings.MaxDepth != 2 || settings.HashPolicy != models.HashNone {
+			t.Errorf("Expected scan settings to be stored, got %+v", settings)
+		}
+	})
+
+	t.Run("Update with invalid scan settings", func(t *testing.T) {
+		for _, settings := range []map[string]interface{}{
+			{"exclude": []string{"[unclosed"}},
+			{"max_depth": -1},
+			{"max_depth": models.MaxScanDepth + 1},
+			{"hash_policy": "md5"},
+		} {
+			jsonData, _ := json.Marshal(map[string]interface{}{"name": "Updated Project Name", "scan_settings": settings})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/api/projects/"+strconv.Itoa(int(project.ID)), strings.NewReader(string(jsonData)))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %v, got %d", http.StatusBadRequest, settings, w.Code)
+			}
+		}
+	})
+
 	t.Run("Update with empty name", func(t *testing.T) {
 		updateData := map[string]interface{}{
 			"name":        "",
@@ -1178,6 +1448,22 @@ func TestDeleteProject(t *testing.T) {
 		t.Fatalf("Failed to create physical test file: %v", err)
 	}
 
+	t.Run("Canonical copies of other projects' files", func(t *testing.T) {
+		duplicate := models.ProjectFile{ProjectID: project.ID + 1, Filename: "test.stl", Filepath: filepath.Join(t.TempDir(), "test.stl"), FileType: "stl", DuplicateOf: &testFile.ID}
+		db.Create(&duplicate)
+		defer db.Delete(&duplicate)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/projects/"+strconv.Itoa(int(project.ID)), nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d. Body: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(testFile.Filepath); err != nil {
+			t.Errorf("Expected the project left in place: %v", err)
+		}
+	})
+
 	t.Run("Delete existing project", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("DELETE", "/api/projects/"+strconv.Itoa(int(project.ID)), nil)
@@ -1228,3 +1514,75 @@ func TestDeleteProject(t *testing.T) {
 		}
 	})
 }
+
+// TestDeleteProjectFile tests deleting a single file with the records kept by its name
+func TestDeleteProjectFile(t *testing.T) {
+	db := setupTestDB(t)
+	tempDir := t.TempDir()
+	router := setupRouter(tempDir)
+	router.DELETE("/api/projects/:id/files/:fileId", NewProjectsHandler(tempDir).DeleteProjectFile)
+
+	project := models.Project{Name: "Bracket", Path: tempDir, CoverFilename: "bracket.stl"}
+	db.Create(&project)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "bracket.stl", Filepath: filepath.Join(tempDir, "bracket.stl"), FileType: models.FileTypeSTL}
+	db.Create(&file)
+	os.WriteFile(file.Filepath, []byte("solid bracket"), 0644)
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "bracket.stl", Notes: "No supports"})
+
+	// Deduplicated files may be symlinks to the file, so it stays while they point at it
+	duplicate := models.ProjectFile{ProjectID: project.ID + 1, Filename: "bracket.stl", Filepath: filepath.Join(t.TempDir(), "bracket.stl"), FileType: models.FileTypeSTL, DuplicateOf: &file.ID}
+	db.Create(&duplicate)
+	if w := sendJSON(router, "DELETE", fmt.Sprintf("/api/projects/%d/files/%d", project.ID, file.ID), ""); w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d for a canonical copy, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(file.Filepath); err != nil {
+		t.Fatalf("Expected the canonical copy left in place: %v", err)
+	}
+	db.Delete(&duplicate)
+
+	w := sendJSON(router, "DELETE", fmt.Sprintf("/api/projects/%d/files/%d", project.ID, file.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	entries, _ := os.ReadDir(tempDir)
+	if len(entries) != 0 {
+		t.Errorf("Expected the file removed from disk, got %v", entries)
+	}
+	var profiles int64
+	db.Model(&models.FileProfile{}).Count(&profiles)
+	db.First(&project, project.ID)
+	if profiles != 0 || project.CoverFilename != "" {
+		t.Errorf("Expected the profile and cover cleared, got %d profiles and cover %q", profiles, project.CoverFilename)
+	}
+
+	if w := sendJSON(router, "DELETE", fmt.Sprintf("/api/projects/%d/files/%d", project.ID, file.ID), ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted file, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestScanProjectsLocked tests scans are refused while another instance runs a library operation
+func TestScanProjectsLocked(t *testing.T) {
+	db, router, handler := setupScanRouter(t, t.TempDir())
+
+	db.Create(&models.Lock{Name: database.LibraryLock, Owner: "node-b-1#1", ExpiresAt: time.Now().Add(time.Minute)})
+	w := sendJSON(router, "POST", "/api/projects/scan", "")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the library is locked, got %d", w.Code)
+	}
+
+	db.Where("name = ?", database.LibraryLock).Delete(&models.Lock{})
+	if w = sendJSON(router, "POST", "/api/projects/scan", ""); w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 once the lock is released, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		JobID uint `json:"job_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	handler.Scanner().Wait(response.JobID)
+	var count int64
+	db.Model(&models.Lock{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the scan to release its lock, got %d locks", count)
+	}
+}
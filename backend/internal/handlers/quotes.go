@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/pricing"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxQuoteItems bounds the files and maxQuoteQuantity the copies of each
+	// one quote may price
+	maxQuoteItems    = 200
+	maxQuoteQuantity = 10000
+
+	// defaultQuoteMaterial is assumed when neither the request nor the G-code names one
+	defaultQuoteMaterial = "PLA"
+)
+
+// QuoteItemRequest is a library file to quote and how many copies of it
+type QuoteItemRequest struct {
+	FileID   uint `json:"file_id"`
+	Quantity int  `json:"quantity"`
+
+	// Material prices the filament; the G-code's material, or PLA, by default
+	Material string `json:"material"`
+}
+
+// QuoteRequest is what a customer asked to have printed
+type QuoteRequest struct {
+	Items    []QuoteItemRequest `json:"items"`
+	Customer string             `json:"customer"`
+	Notes    string             `json:"notes"`
+}
+
+// QuoteResponse is a priced quote for a customer
+type QuoteResponse struct {
+	Customer string    `json:"customer,omitempty"`
+	Notes    string    `json:"notes,omitempty"`
+	QuotedAt time.Time `json:"quoted_at"`
+	pricing.Quote
+}
+
+// QuotesHandler handles customer price quote HTTP requests
+type QuotesHandler struct {
+	// maxUploadFiles bounds the files of one upload request; 0 allows any number
+	maxUploadFiles int
+}
+
+// NewQuotesHandler creates a new QuotesHandler
+func NewQuotesHandler() *QuotesHandler {
+	return &QuotesHandler{maxUploadFiles: DefaultMaxUploadFiles}
+}
+
+// SetMaxUploadFiles bounds how many files one quote request may upload
+func (h *QuotesHandler) SetMaxUploadFiles(maxFiles int) {
+	h.maxUploadFiles = maxFiles
+}
+
+// CreateQuote prices a customer request with the instance pricing rules:
+// material, machine time and labor per copy, plus the setup fee and margin.
+// The JSON body names library files; a multipart form uploads the customer's
+// own under "files", with a "quantity" and "material" for all of them. Slicer
+// estimates are used when there are any: a G-code file's own, or those of the
+// G-code sliced from an STL model; otherwise they are estimated from the
+// model's volume. ?format=pdf returns the quote as a PDF.
+func (h *QuotesHandler) CreateQuote(c *gin.Context) {
+	db := requestDB(c)
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported quote format: %s", format)})
+		return
+	}
+	rules, err := loadPricing(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pricing rules"})
+		return
+	}
+
+	var req QuoteRequest
+	var parts []pricing.Part
+	var ok bool
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		req, parts, ok = h.uploadedParts(c, rules)
+	} else {
+		req, parts, ok = libraryParts(c, db, rules)
+	}
+	if !ok {
+		return
+	}
+
+	quote := QuoteResponse{
+		Customer: strings.TrimSpace(req.Customer),
+		Notes:    strings.TrimSpace(req.Notes),
+		QuotedAt: time.Now(),
+		Quote:    pricing.Price(parts, rules),
+	}
+
+	if format == "pdf" {
+		details := []string{"Date: " + quote.QuotedAt.Format("2006-01-02")}
+		if quote.Customer != "" {
+			details = append([]string{"Customer: " + quote.Customer}, details...)
+		}
+		if quote.Notes != "" {
+			details = append(details, quote.Notes)
+		}
+		var out bytes.Buffer
+		if err := pricing.WritePDF(&out, quote.Quote, "Quote", details); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render quote"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"quote-%s.pdf\"", quote.QuotedAt.Format("20060102")))
+		c.Data(http.StatusOK, "application/pdf", out.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// libraryParts reads a JSON quote request naming library files. It writes the
+// error response and returns false when the request can't be quoted.
+func libraryParts(c *gin.Context, db *gorm.DB, rules pricing.Rules) (QuoteRequest, []pricing.Part, bool) {
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return req, nil, false
+	}
+	if len(req.Items) == 0 || len(req.Items) > maxQuoteItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "items must list between 1 and 200 files"})
+		return req, nil, false
+	}
+
+	parts := make([]pricing.Part, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Quantity < 1 || item.Quantity > maxQuoteQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 10000", "file_id": item.FileID})
+			return req, nil, false
+		}
+		var file models.ProjectFile
+		if err := db.First(&file, item.FileID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File not found", "file_id": item.FileID})
+			return req, nil, false
+		}
+		part, ok := quotePart(c, db, file, item.Quantity, item.Material, rules)
+		if !ok {
+			return req, nil, false
+		}
+		parts = append(parts, part)
+	}
+	return req, parts, true
+}
+
+// uploadedParts reads a multipart quote request of the customer's own files,
+// kept only while they are read. It writes the error response and returns
+// false when the request can't be quoted.
+func (h *QuotesHandler) uploadedParts(c *gin.Context, rules pricing.Rules) (QuoteRequest, []pricing.Part, bool) {
+	req := QuoteRequest{Customer: c.PostForm("customer"), Notes: c.PostForm("notes")}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return req, nil, false
+	}
+	files := form.File["files"]
+	if len(files) == 0 || len(files) > maxQuoteItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "files must hold between 1 and 200 files"})
+		return req, nil, false
+	}
+	if rejectTooManyFiles(c, files, h.maxUploadFiles) {
+		return req, nil, false
+	}
+	quantity := 1
+	if raw := c.PostForm("quantity"); raw != "" {
+		quantity, err = strconv.Atoi(raw)
+		if err != nil || quantity < 1 || quantity > maxQuoteQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be between 1 and 10000"})
+			return req, nil, false
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "3dshelf-quote-")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded files"})
+		return req, nil, false
+	}
+	defer os.RemoveAll(tmpDir)
+
+	parts := make([]pricing.Part, 0, len(files))
+	for i, header := range files {
+		filename := filepath.Base(header.Filename)
+		path := filepath.Join(tmpDir, fmt.Sprintf("%d%s", i, strings.ToLower(filepath.Ext(filename))))
+		if err := c.SaveUploadedFile(header, path); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded files"})
+			return req, nil, false
+		}
+		file := models.ProjectFile{Filename: filename, Filepath: path, FileType: models.GetFileTypeFromExtension(filename)}
+		part, ok := quotePart(c, requestDB(c), file, quantity, c.PostForm("material"), rules)
+		if !ok {
+			return req, nil, false
+		}
+		parts = append(parts, part)
+	}
+	return req, parts, true
+}
+
+// quotePart reads what one copy of a G-code or STL file takes to print.
+// Uploaded files have no ID and are never matched to sliced G-code. It writes
+// the error response and returns false when the file can't be quoted.
+func quotePart(c *gin.Context, db *gorm.DB, file models.ProjectFile, quantity int, material string, rules pricing.Rules) (pricing.Part, bool) {
+	part := pricing.Part{Name: file.Filename, FileID: file.ID, Quantity: quantity, Material: strings.TrimSpace(material)}
+	fail := func(status int, message string, err error) (pricing.Part, bool) {
+		body := gin.H{"error": message, "filename": file.Filename}
+		if file.ID != 0 {
+			body["file_id"] = file.ID
+		}
+		if err != nil {
+			body["details"] = err.Error()
+		}
+		c.JSON(status, body)
+		return part, false
+	}
+
+	var sliced *models.ProjectFile
+	switch file.FileType {
+	case models.FileTypeGCode:
+		sliced = &file
+	case models.FileTypeSTL:
+		if file.ID == 0 {
+			break
+		}
+		gcodeID, _, err := slicedPrintTime(db, file)
+		if err != nil {
+			return fail(http.StatusInternalServerError, "Failed to fetch project files", nil)
+		}
+		if gcodeID != 0 {
+			var gcodeFile models.ProjectFile
+			if err := db.First(&gcodeFile, gcodeID).Error; err != nil {
+				return fail(http.StatusInternalServerError, "Failed to fetch project files", nil)
+			}
+			sliced = &gcodeFile
+		}
+	default:
+		return fail(http.StatusBadRequest, "Only STL models and G-code can be quoted", nil)
+	}
+
+	if sliced != nil {
+		meta := readEstimates(*sliced)
+		switch {
+		case meta.FilamentGrams > 0:
+			if part.Material == "" {
+				part.Material = meta.Material
+			}
+			part.FilamentGrams, part.PrintTimeSeconds, part.Source = meta.FilamentGrams, meta.PrintTimeSeconds, "gcode"
+		case file.FileType == models.FileTypeGCode:
+			return fail(http.StatusUnprocessableEntity, "The G-code has no filament estimate", nil)
+		}
+	}
+	if part.Material == "" {
+		part.Material = defaultQuoteMaterial
+	}
+
+	if part.Source == "" {
+		geometry, err := modelGeometry(db, file)
+		if err != nil {
+			if geometry.Error != "" {
+				return fail(http.StatusUnprocessableEntity, "Model could not be read", err)
+			}
+			return fail(http.StatusInternalServerError, "Failed to read model", nil)
+		}
+		density, _ := models.MaterialDensity(part.Material)
+		// Volumes are in mm³
+		part.FilamentGrams, part.PrintTimeSeconds = rules.Estimate(*geometry.Volume/1000, density)
+		part.Source = "estimate"
+	}
+	return part, true
+}
+
+// loadPricing returns the instance pricing rules, the defaults unless saved through the admin API
+func loadPricing(db *gorm.DB) (pricing.Rules, error) {
+	rules := pricing.Default
+	if _, err := database.LoadSetting(db, pricing.SettingKey, &rules); err != nil {
+		return pricing.Default, err
+	}
+	return rules, rules.Validate()
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/pricing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateQuote tests pricing library files from slicer estimates and model volumes
+func TestCreateQuote(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/quotes", NewQuotesHandler().CreateQuote)
+
+	project := models.Project{Name: "Shop", Path: filepath.Join(tmpDir, "shop")}
+	db.Create(&project)
+	for _, name := range []string{"bracket.stl", "cube.stl"} {
+		path := filepath.Join(tmpDir, name)
+		out, _ := os.Create(path)
+		// 100cm³ of PLA: 43.4g at the default fill ratio
+		mesh.WriteBinarySTL(out, testBox(40, 50, 50, mesh.Vec3{}))
+		out.Close()
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, Hash: "hash-" + name, FileType: models.FileTypeSTL})
+	}
+	gcodePath := filepath.Join(tmpDir, "bracket_0.2mm.gcode")
+	os.WriteFile(gcodePath, []byte("; filament_type = PETG\n; filament used [g] = 40\n; estimated printing time (normal mode) = 2h 0m 0s\n"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "bracket_0.2mm.gcode", Filepath: gcodePath, FileType: models.FileTypeGCode})
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME})
+
+	rules := pricing.Default
+	rules.MaterialCosts = map[string]float64{"PETG": 30}
+	database.SaveSetting(db, pricing.SettingKey, rules)
+
+	w := sendJSON(router, "POST", "/api/quotes", `{"customer": "Ada", "items": [{"file_id": 1, "quantity": 3}, {"file_id": 2, "quantity": 1}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var quote QuoteResponse
+	json.Unmarshal(w.Body.Bytes(), &quote)
+	if quote.Customer != "Ada" || len(quote.Lines) != 2 {
+		t.Fatalf("Expected 2 lines for Ada, got %+v", quote)
+	}
+	// The bracket takes the sliced G-code's estimates and material
+	bracket, cube := quote.Lines[0], quote.Lines[1]
+	if bracket.Source != "gcode" || bracket.Material != "PETG" || bracket.FilamentGrams != 40 || bracket.PrintTimeSeconds != 7200 {
+		t.Errorf("Expected the bracket priced from its G-code, got %+v", bracket)
+	}
+	if bracket.MaterialCost != 1.2 || bracket.Cost != bracket.UnitCost*3 {
+		t.Errorf("Expected PETG at 30/kg for 3 copies, got %+v", bracket)
+	}
+	if cube.Source != "estimate" || cube.Material != "PLA" || cube.FilamentGrams < 43.3 || cube.FilamentGrams > 43.5 {
+		t.Errorf("Expected the cube estimated from its volume, got %+v", cube)
+	}
+	if quote.Total <= quote.Cost || quote.Currency != "USD" {
+		t.Errorf("Expected the margin added to the cost, got %+v", quote.Quote)
+	}
+
+	w = sendJSON(router, "POST", "/api/quotes?format=pdf", `{"items": [{"file_id": 3, "quantity": 1}]}`)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(w.Body.String(), "%PDF") {
+		t.Errorf("Expected a PDF quote, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	testCases := []struct {
+		body     string
+		expected int
+	}{
+		{`{"items": []}`, http.StatusBadRequest},
+		{`{"items": [{"file_id": 1, "quantity": 0}]}`, http.StatusBadRequest},
+		{`{"items": [{"file_id": 99, "quantity": 1}]}`, http.StatusBadRequest},
+		{`{"items": [{"file_id": 4, "quantity": 1}]}`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "POST", "/api/quotes", tc.body); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.expected, w.Code)
+		}
+	}
+	if w := sendJSON(router, "POST", "/api/quotes?format=docx", `{"items": [{"file_id": 1, "quantity": 1}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// A customer's own model, uploaded with the request
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("quantity", "2")
+	form.WriteField("material", "ABS")
+	part, _ := form.CreateFormFile("files", "custom.stl")
+	mesh.WriteBinarySTL(part, testBox(10, 10, 10, mesh.Vec3{}))
+	form.Close()
+	req, _ := http.NewRequest("POST", "/api/quotes", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var uploaded QuoteResponse
+	json.Unmarshal(w.Body.Bytes(), &uploaded)
+	if len(uploaded.Lines) != 1 || uploaded.Lines[0].Name != "custom.stl" || uploaded.Lines[0].Quantity != 2 ||
+		uploaded.Lines[0].Material != "ABS" || uploaded.Lines[0].Source != "estimate" {
+		t.Errorf("Expected the uploaded model estimated in ABS, got %+v", uploaded.Lines)
+	}
+}
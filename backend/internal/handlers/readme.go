@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/frontmatter"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateREADMERequest is the body accepted by UpdateProjectREADME
+type UpdateREADMERequest struct {
+	// Content is the markdown body. When Metadata is omitted it may start with
+	// its own front matter block, which is then used as-is.
+	Content  string                `json:"content"`
+	Metadata *frontmatter.Metadata `json:"metadata"`
+
+	// UpdatedAt is the project updated_at the client read; the edit is
+	// rejected when the project changed since. An If-Match header takes precedence.
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// projectMetadata returns the front matter fields stored on a project
+func projectMetadata(project *models.Project) frontmatter.Metadata {
+	tags := project.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return frontmatter.Metadata{
+		Tags:     tags,
+		License:  project.License,
+		Designer: project.Designer,
+		Source:   project.Source,
+	}
+}
+
+// UpdateProjectREADME rewrites a project's README.md with front matter metadata
+// and refreshes the fields parsed from it
+func (h *ProjectsHandler) UpdateProjectREADME(c *gin.Context) {
+	id := c.Param("id")
+
+	var project models.Project
+	if err := requestDB(c).First(&project, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
+
+	var req UpdateREADMERequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	meta, body, err := frontmatter.Parse([]byte(req.Content))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	readmePath := filepath.Join(project.Path, "README.md")
+	if req.Metadata != nil {
+		// Keep keys we don't manage from the README currently on disk
+		extra := meta.Extra
+		if len(extra) == 0 {
+			if existing, err := os.ReadFile(readmePath); err == nil {
+				if current, _, err := frontmatter.Parse(existing); err == nil {
+					extra = current.Extra
+				}
+			}
+		}
+		meta = *req.Metadata
+		meta.Extra = extra
+	}
+
+	content, err := frontmatter.Render(meta, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid metadata: %v", err)})
+		return
+	}
+
+	unlock, ok := h.lockProject(c, project.ID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !checkProjectVersion(c, &project, req.UpdatedAt) {
+		return
+	}
+
+	if err := writeFileAtomic(readmePath, content); err != nil {
+		fmt.Printf("Warning: Failed to write README for project %d: %v\n", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write README"})
+		return
+	}
+
+	if err := h.scanner.ApplyREADME(&project); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read README"})
+		return
+	}
+	if meta.IsZero() {
+		// Without front matter the README no longer carries metadata
+		project.Tags, project.License, project.Designer, project.Source = meta.Tags, meta.License, meta.Designer, meta.Source
+	}
+	project.LastScanned = time.Now()
+
+	if err := requestDB(c).Save(&project).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	if err := h.recordREADME(c, &project, readmePath, content); err != nil {
+		fmt.Printf("Warning: Failed to record README file for project %d: %v\n", project.ID, err)
+	}
+	if err := h.scanner.RefreshSidecar(&project); err != nil {
+		fmt.Printf("Warning: Failed to write sidecar for project %d: %v\n", project.ID, err)
+	}
+
+	c.Header("ETag", versionTag(project.UpdatedAt))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "README updated successfully",
+		"raw":      project.Description,
+		"metadata": projectMetadata(&project),
+	})
+}
+
+// recordREADME creates or refreshes the README's project file record
+func (h *ProjectsHandler) recordREADME(c *gin.Context, project *models.Project, readmePath string, content []byte) error {
+	var file models.ProjectFile
+	err := requestDB(c).Where(models.ProjectFile{ProjectID: project.ID, Filename: "README.md"}).
+		Attrs(models.ProjectFile{Filepath: readmePath, FileType: models.FileTypeREADME}).
+		FirstOrInit(&file).Error
+	if err != nil {
+		return err
+	}
+
+	file.Size = int64(len(content))
+	file.Hash = fmt.Sprintf("%x", sha256.Sum256(content))
+	return requestDB(c).Save(&file).Error
+}
+
+// writeFileAtomic replaces path with content via a temporary file in the same directory
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fileperm.File(tmp.Name())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// putREADME sends a README update and decodes the response
+func putREADME(t *testing.T, router *gin.Engine, id uint, body gin.H) (int, map[string]interface{}) {
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/api/projects/"+jsonID(id)+"/readme", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+// jsonID formats a numeric ID for a URL path
+func jsonID(id uint) string {
+	encoded, _ := json.Marshal(id)
+	return string(encoded)
+}
+
+// TestUpdateProjectREADME tests writing README front matter and reading it back
+func TestUpdateProjectREADME(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+
+	projectPath := filepath.Join(tmpDir, "Gears")
+	if err := os.MkdirAll(projectPath, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	readmePath := filepath.Join(projectPath, "README.md")
+	os.WriteFile(readmePath, []byte("---\nprinter: mk4\n---\n# Old\n"), 0644)
+
+	project := models.Project{Name: "Gears", Path: projectPath}
+	db.Create(&project)
+
+	t.Run("Structured metadata", func(t *testing.T) {
+		code, response := putREADME(t, router, project.ID, gin.H{
+			"content": "# Gears\nA gearbox.",
+			"metadata": gin.H{
+				"tags":     []string{"gears", "mechanical"},
+				"license":  "CC-BY-4.0",
+				"designer": "Jane Maker",
+			},
+		})
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %v", http.StatusOK, code, response)
+		}
+
+		written, _ := os.ReadFile(readmePath)
+		for _, expected := range []string{"license: CC-BY-4.0", "designer: Jane Maker", "printer: mk4", "# Gears"} {
+			if !strings.Contains(string(written), expected) {
+				t.Errorf("Expected README to contain %q, got:\n%s", expected, written)
+			}
+		}
+
+		var updated models.Project
+		db.First(&updated, project.ID)
+		if updated.License != "CC-BY-4.0" || len(updated.Tags) != 2 {
+			t.Errorf("Expected project metadata to be updated, got license=%q tags=%v", updated.License, updated.Tags)
+		}
+		if strings.Contains(updated.Description, "license:") {
+			t.Errorf("Expected description without front matter, got %q", updated.Description)
+		}
+
+		var file models.ProjectFile
+		if err := db.Where("project_id = ? AND filename = ?", project.ID, "README.md").First(&file).Error; err != nil {
+			t.Fatalf("Expected README file record: %v", err)
+		}
+		if file.Size != int64(len(written)) {
+			t.Errorf("Expected README size %d, got %d", len(written), file.Size)
+		}
+	})
+
+	t.Run("Round trip through GET", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/"+jsonID(project.ID)+"/readme", nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Raw      string `json:"raw"`
+			Metadata struct {
+				Tags     []string `json:"tags"`
+				Designer string   `json:"designer"`
+			} `json:"metadata"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		if response.Metadata.Designer != "Jane Maker" || len(response.Metadata.Tags) != 2 {
+			t.Errorf("Expected metadata in README response, got %+v", response.Metadata)
+		}
+		if !strings.HasPrefix(response.Raw, "# Gears") {
+			t.Errorf("Expected raw body without front matter, got %q", response.Raw)
+		}
+	})
+
+	t.Run("Raw front matter", func(t *testing.T) {
+		code, _ := putREADME(t, router, project.ID, gin.H{
+			"content": "---\ntags: printed\nsource: https://example.com\n---\n# Gears v2\n",
+		})
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+
+		var updated models.Project
+		db.First(&updated, project.ID)
+		if updated.Source != "https://example.com" || updated.License != "" || len(updated.Tags) != 1 {
+			t.Errorf("Expected metadata from raw front matter, got source=%q license=%q tags=%v", updated.Source, updated.License, updated.Tags)
+		}
+	})
+
+	t.Run("Invalid front matter", func(t *testing.T) {
+		code, _ := putREADME(t, router, project.ID, gin.H{"content": "---\ntags: [broken\n---\n"})
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("Nonexistent project", func(t *testing.T) {
+		code, _ := putREADME(t, router, 999, gin.H{"content": "# Missing"})
+		if code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, code)
+		}
+	})
+}
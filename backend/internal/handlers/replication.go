@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"3dshelf/pkg/replication"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationHandler handles requests about mirroring another instance
+type ReplicationHandler struct {
+	replicator *replication.Replicator
+}
+
+// NewReplicationHandler creates a new ReplicationHandler; a nil replicator
+// reports replication as not configured
+func NewReplicationHandler(replicator *replication.Replicator) *ReplicationHandler {
+	return &ReplicationHandler{
+		replicator: replicator,
+	}
+}
+
+// GetReplication reports the source mirrored and how its last run went
+func (h *ReplicationHandler) GetReplication(c *gin.Context) {
+	if h.replicator == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Replication is not configured"})
+		return
+	}
+
+	status, err := h.replicator.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load replication status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// RunReplication starts a background job pulling what changed on the source
+func (h *ReplicationHandler) RunReplication(c *gin.Context) {
+	if h.replicator == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Replication is not configured"})
+		return
+	}
+
+	job, err := h.replicator.Start()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start replication", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Replication started",
+		"job":     job,
+	})
+}
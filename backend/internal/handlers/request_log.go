@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"3dshelf/pkg/requestlog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultRequestLogLimit and maxRequestLogLimit bound GetRequests pages
+	defaultRequestLogLimit = 100
+	maxRequestLogLimit     = 1000
+)
+
+// SetRequestLog lets the admin API list the requests recently handled
+func (h *AdminHandler) SetRequestLog(log *requestlog.Log) {
+	h.requestLog = log
+}
+
+// GetRequests returns the requests recently handled, newest first, filtered by
+// ?method=, ?route= (part of the route pattern or path), ?status= (a code like
+// 413 or a class like 4xx) and ?since= (RFC 3339)
+func (h *AdminHandler) GetRequests(c *gin.Context) {
+	filter := requestlog.Filter{
+		Method: c.Query("method"),
+		Route:  c.Query("route"),
+		Limit:  defaultRequestLogLimit,
+	}
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = min(parsed, maxRequestLogLimit)
+	}
+	if raw := c.Query("status"); raw != "" {
+		var ok bool
+		if filter.MinStatus, filter.MaxStatus, ok = parseStatusFilter(raw); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected a code like 413 or a class like 4xx"})
+			return
+		}
+	}
+	if raw := c.Query("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since, expected an RFC 3339 time"})
+			return
+		}
+		filter.Since = since
+	}
+
+	entries := h.requestLog.Entries(filter)
+	c.JSON(http.StatusOK, gin.H{
+		"requests": entries,
+		"count":    len(entries),
+		"capacity": h.requestLog.Size(),
+	})
+}
+
+// parseStatusFilter reads a status code, or a class like 4xx, as the range it covers
+func parseStatusFilter(raw string) (int, int, bool) {
+	if class, ok := strings.CutSuffix(strings.ToLower(raw), "xx"); ok {
+		n, err := strconv.Atoi(class)
+		if err != nil || n < 1 || n > 5 {
+			return 0, 0, false
+		}
+		return n * 100, n*100 + 99, true
+	}
+	code, err := strconv.Atoi(raw)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, false
+	}
+	return code, code, true
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"3dshelf/pkg/requestlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetRequests tests listing and filtering the recent requests
+func TestGetRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	log := requestlog.New(10)
+	adminHandler := NewAdminHandler(nil)
+	adminHandler.SetRequestLog(log)
+	router.GET("/api/admin/requests", adminHandler.GetRequests)
+
+	now := time.Now().UTC()
+	log.Add(requestlog.Entry{Time: now.Add(-time.Hour), Method: "GET", Route: "/api/projects", Status: 200})
+	log.Add(requestlog.Entry{Time: now, Method: "POST", Route: "/api/projects/:id/files", Status: 413, Error: "Request body too large"})
+	log.Add(requestlog.Entry{Time: now, Method: "POST", Route: "/api/projects/:id/files", Status: 201})
+
+	testCases := []struct {
+		query        string
+		expectedCode int
+		expected     int
+	}{
+		{"", http.StatusOK, 3},
+		{"?status=4xx", http.StatusOK, 1},
+		{"?status=201", http.StatusOK, 1},
+		{"?route=/files&method=POST", http.StatusOK, 2},
+		{"?since=" + now.Add(-time.Minute).Format(time.RFC3339), http.StatusOK, 2},
+		{"?limit=1", http.StatusOK, 1},
+		{"?status=6xx", http.StatusBadRequest, 0},
+		{"?since=yesterday", http.StatusBadRequest, 0},
+		{"?limit=0", http.StatusBadRequest, 0},
+	}
+	for _, tc := range testCases {
+		w := sendJSON(router, "GET", "/api/admin/requests"+tc.query, "")
+		if w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.query, tc.expectedCode, w.Code)
+			continue
+		}
+		var response struct {
+			Requests []requestlog.Entry `json:"requests"`
+			Capacity int                `json:"capacity"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if tc.expectedCode == http.StatusOK && (len(response.Requests) != tc.expected || response.Capacity != 10) {
+			t.Errorf("%s: expected %d requests, got %+v", tc.query, tc.expected, response)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"3dshelf/pkg/retention"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRetention lets the admin API review and apply G-code retention rules
+func (h *AdminHandler) SetRetention(retainer *retention.Retainer) {
+	h.retention = retainer
+}
+
+// RetentionRequest is the body accepted by ApplyRetention
+type RetentionRequest struct {
+	retention.Rules
+
+	// DryRun defaults to true; applying requires the token from a prior dry run
+	DryRun *bool  `json:"dry_run"`
+	Token  string `json:"token"`
+}
+
+// GetRetention returns how the last G-code retention run went, or null
+// before the first one
+func (h *AdminHandler) GetRetention(c *gin.Context) {
+	if h.retention == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "G-code retention is not configured"})
+		return
+	}
+
+	run, err := h.retention.LastRun()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load G-code retention run"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"last_run": run})
+}
+
+// ApplyRetention reports the G-code files the rules would delete and, once
+// a dry run has been reviewed, starts a background job deleting them. The
+// job rebuilds the plan and deletes nothing unless it still matches.
+func (h *AdminHandler) ApplyRetention(c *gin.Context) {
+	if h.retention == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "G-code retention is not configured"})
+		return
+	}
+
+	var req RetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+	if !dryRun && req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A dry run is required before applying G-code retention"})
+		return
+	}
+	if err := req.Rules.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.retention.Plan(req.Rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build G-code retention plan"})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": true,
+			"plan":    plan,
+		})
+		return
+	}
+
+	if req.Token != plan.Token {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Library changed since the dry run; review a new plan before applying",
+			"plan":  plan,
+		})
+		return
+	}
+
+	job, err := h.retention.Start(plan.Rules, plan.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start G-code retention", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "G-code retention started",
+		"dry_run": false,
+		"plan":    plan,
+		"job":     job,
+	})
+}
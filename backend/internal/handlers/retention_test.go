@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestApplyRetention tests reviewing G-code retention rules and starting a run
+func TestApplyRetention(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewAdminHandler(nil)
+	router.GET("/api/admin/retention", handler.GetRetention)
+	router.POST("/api/admin/retention", handler.ApplyRetention)
+
+	if w := sendJSON(router, "POST", "/api/admin/retention", `{"max_age_days": 365}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without retention, got %d", http.StatusBadRequest, w.Code)
+	}
+	handler.SetRetention(retention.New(db, jobs.New(db)))
+
+	project := models.Project{Name: "Bracket", Path: tmpDir}
+	db.Create(&project)
+	path := filepath.Join(tmpDir, "bracket.gcode")
+	os.WriteFile(path, []byte("G28"), 0644)
+	old := time.Now().AddDate(-2, 0, 0)
+	os.Chtimes(path, old, old)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "bracket.gcode", Filepath: path, FileType: models.FileTypeGCode, Size: 3})
+
+	w := sendJSON(router, "POST", "/api/admin/retention", `{"max_age_days": 365}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var review struct {
+		DryRun bool           `json:"dry_run"`
+		Plan   retention.Plan `json:"plan"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &review)
+	if !review.DryRun || review.Plan.Count != 1 || review.Plan.Token == "" {
+		t.Fatalf("Expected a dry run deleting one file, got %+v", review)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected a dry run to leave the file: %v", err)
+	}
+
+	w = sendJSON(router, "POST", "/api/admin/retention", fmt.Sprintf(`{"max_age_days": 365, "keep_latest": true, "dry_run": false, "token": %q}`, review.Plan.Token))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for other rules than reviewed, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = sendJSON(router, "POST", "/api/admin/retention", fmt.Sprintf(`{"max_age_days": 365, "dry_run": false, "token": %q}`, review.Plan.Token))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var started struct {
+		Job models.Job `json:"job"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &started)
+	if started.Job.Type != retention.JobType || started.Job.Status != models.JobPending {
+		t.Errorf("Expected a pending %s job, got %+v", retention.JobType, started.Job)
+	}
+
+	w = sendJSON(router, "GET", "/api/admin/retention", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"Missing age":   `{}`,
+			"Negative age":  `{"max_age_days": -1}`,
+			"Missing token": `{"max_age_days": 365, "dry_run": false}`,
+			"Bad JSON":      `{`,
+		} {
+			if w := sendJSON(router, "POST", "/api/admin/retention", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+}
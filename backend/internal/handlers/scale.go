@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxScaleFactor bounds how far a model may be scaled up or down
+const maxScaleFactor = 1000
+
+// ModelDimensions is a model's bounding box size in millimetres and its
+// enclosed volume in cm³
+type ModelDimensions struct {
+	Size      []float64 `json:"size"`
+	VolumeCM3 float64   `json:"volume_cm3"`
+}
+
+// ScaledModel is what a model becomes when scaled by a factor
+type ScaledModel struct {
+	ModelDimensions
+
+	// WeightGrams is the weight of the model printed solid; infill and
+	// thin walls make prints lighter
+	WeightGrams float64 `json:"weight_grams"`
+}
+
+// ScalePrinterFit tells whether the scaled model fits a registered printer
+type ScalePrinterFit struct {
+	PrinterID uint   `json:"printer_id"`
+	Name      string `json:"name"`
+	Fits      bool   `json:"fits"`
+}
+
+// ScaleResult answers "will this fit, how heavy will it be" for a scaled model
+type ScaleResult struct {
+	FileID   uint            `json:"file_id"`
+	Factor   float64         `json:"factor"`
+	Original ModelDimensions `json:"original"`
+	Scaled   ScaledModel     `json:"scaled"`
+
+	// Material is the material the weight is estimated for; DensityKnown is
+	// unset when its density is assumed to be PLA's
+	Material     string  `json:"material,omitempty"`
+	DensityGCM3  float64 `json:"density_g_cm3"`
+	DensityKnown bool    `json:"density_known"`
+
+	Printers []ScalePrinterFit `json:"printers"`
+}
+
+// GetFileScale computes the uniform scale factor bringing an STL model to a
+// target size along one or more axes (?target_x=200, ?target_y=, ?target_z=,
+// in millimetres; the smallest factor wins so every target is met), or takes
+// a ?factor= directly, and returns the resulting dimensions, volume and
+// weight estimate with whether it fits each registered printer. The weight
+// uses the density of ?material=, of the spool ?filament_id= or ?density=.
+func (h *FilesHandler) GetFileScale(c *gin.Context) {
+	db := requestDB(c)
+
+	var file models.ProjectFile
+	if err := db.First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeSTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL files can be scaled"})
+		return
+	}
+
+	positive := func(name string) (float64, bool, bool) {
+		raw := c.Query(name)
+		if raw == "" {
+			return 0, false, true
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 || math.IsInf(value, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return 0, false, false
+		}
+		return value, true, true
+	}
+
+	var targets [3]float64
+	hasTarget := false
+	for axis, name := range []string{"target_x", "target_y", "target_z"} {
+		value, set, ok := positive(name)
+		if !ok {
+			return
+		}
+		targets[axis] = value
+		hasTarget = hasTarget || set
+	}
+	factor, hasFactor, ok := positive("factor")
+	if !ok {
+		return
+	}
+	if hasTarget == hasFactor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either factor or target_x, target_y or target_z is required"})
+		return
+	}
+
+	result := ScaleResult{FileID: file.ID, Material: c.Query("material"), Printers: []ScalePrinterFit{}}
+	if raw := c.Query("filament_id"); raw != "" {
+		filamentID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filament_id"})
+			return
+		}
+		var filament models.Filament
+		if err := db.First(&filament, filamentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Filament not found"})
+			return
+		}
+		result.Material = filament.Material
+	}
+	result.DensityGCM3, result.DensityKnown = models.MaterialDensity(result.Material)
+	density, hasDensity, ok := positive("density")
+	if !ok {
+		return
+	}
+	if hasDensity {
+		result.DensityGCM3, result.DensityKnown = density, true
+	}
+
+	geometry, err := modelGeometry(db, file)
+	if err != nil {
+		if geometry.Error != "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Model could not be read", "details": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read model"})
+		}
+		return
+	}
+	size := [3]float64(geometry.Size)
+
+	if hasTarget {
+		factor = math.Inf(1)
+		for axis, target := range targets {
+			if target == 0 {
+				continue
+			}
+			if size[axis] == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "The model is flat along a target axis"})
+				return
+			}
+			factor = math.Min(factor, target/size[axis])
+		}
+	}
+	if factor > maxScaleFactor || factor < 1.0/maxScaleFactor {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The scale factor must be between 0.001 and 1000"})
+		return
+	}
+	result.Factor = factor
+
+	// Volumes are in mm³, reported in cm³
+	volume := *geometry.Volume / 1000
+	result.Original = ModelDimensions{Size: geometry.Size, VolumeCM3: volume}
+	scaled := [3]float64{size[0] * factor, size[1] * factor, size[2] * factor}
+	scaledVolume := volume * factor * factor * factor
+	result.Scaled = ScaledModel{
+		ModelDimensions: ModelDimensions{Size: scaled[:], VolumeCM3: scaledVolume},
+		WeightGrams:     scaledVolume * result.DensityGCM3,
+	}
+
+	var printers []models.Printer
+	if err := db.Order("name ASC").Find(&printers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch printers"})
+		return
+	}
+	for _, printer := range printers {
+		result.Printers = append(result.Printers, ScalePrinterFit{
+			PrinterID: printer.ID,
+			Name:      printer.Name,
+			Fits:      printer.Fits(scaled),
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetFileScale tests scale factors, scaled dimensions and weight estimates
+func TestGetFileScale(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/files/:id/scale", NewFilesHandler(nil).GetFileScale)
+
+	project := models.Project{Name: "Vase", Path: filepath.Join(tmpDir, "vase")}
+	db.Create(&project)
+	path := filepath.Join(tmpDir, "block.stl")
+	out, _ := os.Create(path)
+	mesh.WriteBinarySTL(out, testBox(100, 50, 20, mesh.Vec3{5, 5, 0}))
+	out.Close()
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "block.stl", Filepath: path, Hash: "hash-block", FileType: models.FileTypeSTL})
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "README.md", Filepath: filepath.Join(tmpDir, "README.md"), FileType: models.FileTypeREADME})
+	db.Create(&models.Filament{Name: "Galaxy Black", Material: "PETG", DiameterMM: 1.75})
+	db.Create(&models.Printer{Name: "Mini", BuildXMM: 180, BuildYMM: 180, BuildZMM: 180})
+	db.Create(&models.Printer{Name: "XL", BuildXMM: 360, BuildYMM: 360, BuildZMM: 360})
+
+	scale := func(query string) ScaleResult {
+		w := sendJSON(router, "GET", "/api/files/1/scale?"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", query, http.StatusOK, w.Code, w.Body.String())
+		}
+		var result ScaleResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+	result := scale("target_x=200")
+	if !near(result.Factor, 2) || !near(result.Scaled.Size[0], 200) || !near(result.Scaled.Size[2], 40) {
+		t.Errorf("Expected the block doubled, got %+v", result)
+	}
+	// 100 cm³ doubled in size is 800 cm³ of PLA by default
+	if !near(result.Original.VolumeCM3, 100) || !near(result.Scaled.VolumeCM3, 800) ||
+		!near(result.Scaled.WeightGrams, 800*1.24) || result.DensityKnown {
+		t.Errorf("Expected 800 cm³ at the default density, got %+v", result)
+	}
+	if len(result.Printers) != 2 || result.Printers[0].Fits || !result.Printers[1].Fits {
+		t.Errorf("Expected the doubled block to fit only the XL, got %+v", result.Printers)
+	}
+
+	// The smallest factor meets every target
+	if result := scale("target_x=200&target_y=50"); !near(result.Factor, 1) {
+		t.Errorf("Expected factor 1 to keep within both targets, got %v", result.Factor)
+	}
+	if result := scale("factor=0.5&filament_id=1"); !near(result.Scaled.WeightGrams, 12.5*1.27) || result.Material != "PETG" || !result.DensityKnown {
+		t.Errorf("Expected a PETG weight for half size, got %+v", result)
+	}
+	if result := scale("factor=1&material=PLA%2B&density=2"); !near(result.Scaled.WeightGrams, 200) {
+		t.Errorf("Expected the given density to win, got %+v", result)
+	}
+
+	testCases := []struct {
+		path     string
+		expected int
+	}{
+		{"/api/files/99/scale?factor=2", http.StatusNotFound},
+		{"/api/files/2/scale?factor=2", http.StatusBadRequest},
+		{"/api/files/1/scale", http.StatusBadRequest},
+		{"/api/files/1/scale?factor=2&target_x=10", http.StatusBadRequest},
+		{"/api/files/1/scale?target_x=-5", http.StatusBadRequest},
+		{"/api/files/1/scale?factor=5000", http.StatusBadRequest},
+		{"/api/files/1/scale?factor=2&filament_id=9", http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "GET", tc.path, ""); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultScanRunLimit = 50
+	maxScanRunLimit     = 500
+)
+
+// ScanRunsHandler handles scan history HTTP requests
+type ScanRunsHandler struct{}
+
+// NewScanRunsHandler creates a new ScanRunsHandler
+func NewScanRunsHandler() *ScanRunsHandler {
+	return &ScanRunsHandler{}
+}
+
+// GetScanRuns returns recorded scan runs, most recent first
+func (h *ScanRunsHandler) GetScanRuns(c *gin.Context) {
+	limit := defaultScanRunLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxScanRunLimit)
+	}
+
+	query := requestDB(c).Order("started_at DESC").Limit(limit)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.ScanRun
+	if err := query.Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scan runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scan_runs": runs,
+		"count":     len(runs),
+	})
+}
+
+// GetScanRun returns a specific scan run by ID
+func (h *ScanRunsHandler) GetScanRun(c *gin.Context) {
+	id := c.Param("id")
+
+	var run models.ScanRun
+	if err := requestDB(c).First(&run, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scan run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupScanRunsRouter creates a router exposing scan and scan history routes
+func setupScanRunsRouter(t *testing.T, tmpDir string) (*gorm.DB, *gin.Engine, *ProjectsHandler) {
+	db, router, projectsHandler := setupScanRouter(t, tmpDir)
+	scanRunsHandler := NewScanRunsHandler()
+
+	api := router.Group("/api")
+	{
+		api.GET("/scan-runs", scanRunsHandler.GetScanRuns)
+		api.GET("/scan-runs/:id", scanRunsHandler.GetScanRun)
+	}
+
+	return db, router, projectsHandler
+}
+
+// TestScanRuns tests that scans are recorded and exposed via the history endpoints
+func TestScanRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, router, projectsHandler := setupScanRunsRouter(t, tmpDir)
+
+	projectDir := filepath.Join(tmpDir, "HistoryProject")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "model.stl"), []byte("STL content"), 0644); err != nil {
+		t.Fatalf("Failed to create STL file: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/projects/scan", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected scan status %d, got %d", http.StatusAccepted, w.Code)
+		}
+		var response struct {
+			JobID uint `json:"job_id"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		projectsHandler.Scanner().Wait(response.JobID)
+	}
+
+	t.Run("List scan runs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/scan-runs", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			ScanRuns []models.ScanRun `json:"scan_runs"`
+			Count    int              `json:"count"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response.Count != 2 {
+			t.Fatalf("Expected 2 scan runs, got %d", response.Count)
+		}
+
+		// Most recent first: the second scan updates the project created by the first
+		if response.ScanRuns[0].ProjectsUpdated != 1 || response.ScanRuns[1].ProjectsAdded != 1 {
+			t.Errorf("Unexpected scan run ordering or counts: %+v", response.ScanRuns)
+		}
+	})
+
+	t.Run("Limit scan runs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/scan-runs?limit=1", nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if count, ok := response["count"].(float64); !ok || count != 1 {
+			t.Errorf("Expected 1 scan run, got %v", response["count"])
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/scan-runs?limit=abc", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Get scan run", func(t *testing.T) {
+		var run models.ScanRun
+		if err := db.First(&run).Error; err != nil {
+			t.Fatalf("Failed to load scan run: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/scan-runs/"+strconv.Itoa(int(run.ID)), nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Get nonexistent scan run", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/scan-runs/999", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+
+	// suggestCandidateLimit bounds how many index matches are scanned for completions
+	suggestCandidateLimit = 200
+)
+
+// SuggestionType annotates what a search suggestion completes
+type SuggestionType string
+
+const (
+	SuggestionName     SuggestionType = "name"
+	SuggestionTag      SuggestionType = "tag"
+	SuggestionDesigner SuggestionType = "designer"
+)
+
+// Suggestion is one type-ahead completion
+type Suggestion struct {
+	Text  string         `json:"text"`
+	Type  SuggestionType `json:"type"`
+	Count int            `json:"count"`
+
+	// ProjectID is set for name completions that identify a single project
+	ProjectID uint `json:"project_id,omitempty"`
+
+	score int
+}
+
+// SearchHandler handles search HTTP requests
+type SearchHandler struct{}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler() *SearchHandler {
+	return &SearchHandler{}
+}
+
+// SuggestSearch returns ranked name, tag and designer completions for a partial query
+func (h *SearchHandler) SuggestSearch(c *gin.Context) {
+	limit := defaultSuggestLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxSuggestLimit)
+	}
+
+	query := c.Query("q")
+	match := database.PrefixMatchQuery(query)
+	if match == "" {
+		c.JSON(http.StatusOK, gin.H{"suggestions": []Suggestion{}, "count": 0, "query": query})
+		return
+	}
+
+	db := requestDB(c)
+
+	var ids []uint
+	if err := db.Raw("SELECT docid FROM "+database.SearchIndexTable+" WHERE "+database.SearchIndexTable+" MATCH ? LIMIT ?",
+		match, suggestCandidateLimit).Scan(&ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	var projects []models.Project
+	if len(ids) > 0 {
+		if err := db.Select("id", "name", "tags", "designer").Find(&projects, ids).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+			return
+		}
+	}
+
+	suggestions := rankSuggestions(projects, strings.Join(database.SearchWords(query), " "))
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+		"query":       query,
+	})
+}
+
+// rankSuggestions collects the values completing needle, merging duplicates and
+// ranking whole-value prefixes above word prefixes, then by popularity
+func rankSuggestions(projects []models.Project, needle string) []Suggestion {
+	byKey := make(map[string]*Suggestion)
+	var order []string
+
+	add := func(text string, kind SuggestionType, projectID uint) {
+		score := completionScore(text, needle)
+		if score == 0 {
+			return
+		}
+		key := string(kind) + "\x00" + strings.ToLower(text)
+		if existing, ok := byKey[key]; ok {
+			existing.Count++
+			existing.ProjectID = 0
+			return
+		}
+		byKey[key] = &Suggestion{Text: text, Type: kind, Count: 1, ProjectID: projectID, score: score}
+		order = append(order, key)
+	}
+
+	for _, project := range projects {
+		add(project.Name, SuggestionName, project.ID)
+		for _, tag := range project.Tags {
+			add(tag, SuggestionTag, 0)
+		}
+		if project.Designer != "" {
+			add(project.Designer, SuggestionDesigner, 0)
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(order))
+	for _, key := range order {
+		suggestions = append(suggestions, *byKey[key])
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		return strings.ToLower(a.Text) < strings.ToLower(b.Text)
+	})
+
+	return suggestions
+}
+
+// completionScore is 2 when value starts with needle, 1 when one of its words does, and 0 otherwise
+func completionScore(value, needle string) int {
+	normalized := strings.Join(database.SearchWords(value), " ")
+	switch {
+	case strings.HasPrefix(normalized, needle):
+		return 2
+	case strings.Contains(" "+normalized, " "+needle):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSuggestSearch tests type-ahead completions backed by the search index
+func TestSuggestSearch(t *testing.T) {
+	db := setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewSearchHandler()
+	router.GET("/api/search/suggest", handler.SuggestSearch)
+
+	projects := []models.Project{
+		{Name: "Benchy", Path: "/test/benchy", Tags: []string{"benchmark", "boat"}},
+		{Name: "Tiny Benchy", Path: "/test/tiny-benchy", Tags: []string{"benchmark"}, Designer: "Bernd Maker"},
+		{Name: "Gear Box", Path: "/test/gear-box", Tags: []string{"mechanical"}},
+		{Name: "Bed Leveling Test", Path: "/test/bed-leveling"},
+	}
+	for i := range projects {
+		db.Create(&projects[i])
+	}
+
+	// Deleted projects leave the index
+	db.Delete(&projects[3])
+
+	suggest := func(query string) []Suggestion {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/search/suggest?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Suggestions []Suggestion `json:"suggestions"`
+			Count       int          `json:"count"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Count != len(response.Suggestions) {
+			t.Errorf("Count %d does not match %d suggestions", response.Count, len(response.Suggestions))
+		}
+		return response.Suggestions
+	}
+
+	t.Run("Ranked completions", func(t *testing.T) {
+		suggestions := suggest("q=be")
+
+		expected := []struct {
+			text  string
+			kind  SuggestionType
+			count int
+		}{
+			{"benchmark", SuggestionTag, 2},
+			{"Benchy", SuggestionName, 1},
+			{"Bernd Maker", SuggestionDesigner, 1},
+			{"Tiny Benchy", SuggestionName, 1},
+		}
+		if len(suggestions) != len(expected) {
+			t.Fatalf("Expected %d suggestions, got %+v", len(expected), suggestions)
+		}
+		for i, e := range expected {
+			s := suggestions[i]
+			if s.Text != e.text || s.Type != e.kind || s.Count != e.count {
+				t.Errorf("Suggestion %d: expected %s/%s/%d, got %+v", i, e.text, e.kind, e.count, s)
+			}
+		}
+		if suggestions[1].ProjectID != projects[0].ID {
+			t.Errorf("Expected name suggestion to identify project %d, got %d", projects[0].ID, suggestions[1].ProjectID)
+		}
+	})
+
+	t.Run("Index follows updates", func(t *testing.T) {
+		db.Model(&projects[2]).Update("name", "Planetary Gearbox")
+
+		suggestions := suggest("q=planet")
+		if len(suggestions) != 1 || suggestions[0].Text != "Planetary Gearbox" {
+			t.Errorf("Expected renamed project, got %+v", suggestions)
+		}
+		if len(suggest("q=gear+box")) != 0 {
+			t.Error("Expected old name to leave the index")
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		if suggestions := suggest("q=be&limit=2"); len(suggestions) != 2 {
+			t.Errorf("Expected 2 suggestions, got %d", len(suggestions))
+		}
+	})
+
+	t.Run("Query syntax is ignored", func(t *testing.T) {
+		if suggestions := suggest("q=%22%29+OR+*"); len(suggestions) != 0 {
+			t.Errorf("Expected no suggestions, got %+v", suggestions)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/search/suggest?q=be&limit=0", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sectionOrder maps section sorts to project ORDER BY clauses
+var sectionOrder = map[models.SectionSort]string{
+	models.SortNewest:      "projects.created_at DESC",
+	models.SortUpdated:     "projects.updated_at DESC",
+	models.SortName:        "projects.name COLLATE NOCASE ASC",
+	models.SortLastScanned: "projects.last_scanned DESC",
+}
+
+// SectionsHandler handles library section HTTP requests
+type SectionsHandler struct{}
+
+// NewSectionsHandler creates a new SectionsHandler
+func NewSectionsHandler() *SectionsHandler {
+	return &SectionsHandler{}
+}
+
+// SectionPreview is a section with the first projects matching its filter
+type SectionPreview struct {
+	models.Section
+	Projects []models.Project `json:"projects"`
+	Total    int64            `json:"total"`
+}
+
+// GetSections returns every section in position order with its project previews
+func (h *SectionsHandler) GetSections(c *gin.Context) {
+	db := requestDB(c)
+
+	var sections []models.Section
+	if err := db.Order("position ASC, id ASC").Find(&sections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sections"})
+		return
+	}
+
+	now := time.Now()
+	previews := make([]SectionPreview, 0, len(sections))
+	for _, section := range sections {
+		preview := SectionPreview{Section: section, Projects: []models.Project{}}
+
+		if err := applySectionFilter(db.Model(&models.Project{}), section.Filter, now).
+			Count(&preview.Total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sections"})
+			return
+		}
+
+		query := projectQueryOptions{Summary: true}.apply(db)
+		if err := applySectionFilter(query, section.Filter, now).
+			Order(sectionOrder[section.Sort]).
+			Limit(section.PreviewLimit()).
+			Find(&preview.Projects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sections"})
+			return
+		}
+
+		previews = append(previews, preview)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sections": previews,
+		"count":    len(previews),
+	})
+}
+
+// CreateSection defines a new library section
+func (h *SectionsHandler) CreateSection(c *gin.Context) {
+	var section models.Section
+	if err := c.ShouldBindJSON(&section); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	section.ID = 0
+
+	if err := section.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := requestDB(c).Create(&section).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create section"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Section created successfully",
+		"section": section,
+	})
+}
+
+// UpdateSection replaces a section's definition
+func (h *SectionsHandler) UpdateSection(c *gin.Context) {
+	db := requestDB(c)
+
+	var existing models.Section
+	if err := db.First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+		return
+	}
+
+	var section models.Section
+	if err := c.ShouldBindJSON(&section); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	section.ID = existing.ID
+	section.CreatedAt = existing.CreatedAt
+
+	if err := section.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(&section).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update section"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Section updated successfully",
+		"section": section,
+	})
+}
+
+// DeleteSection removes a library section
+func (h *SectionsHandler) DeleteSection(c *gin.Context) {
+	result := requestDB(c).Delete(&models.Section{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete section"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Section deleted successfully"})
+}
+
+// applySectionFilter scopes a project query to the projects matching filter
+func applySectionFilter(db *gorm.DB, filter models.SectionFilter, now time.Time) *gorm.DB {
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		db = db.Where("projects.name LIKE ? OR projects.description LIKE ?", pattern, pattern)
+	}
+	for _, tag := range filter.Tags {
+		db = db.Where("EXISTS (SELECT 1 FROM json_each(projects.tags) WHERE lower(json_each.value) = ?)", strings.ToLower(tag))
+	}
+	if filter.Designer != "" {
+		db = db.Where("projects.designer = ? COLLATE NOCASE", filter.Designer)
+	}
+	if filter.Status != "" {
+		db = db.Where("projects.status = ?", filter.Status)
+	}
+	for _, fileType := range filter.HasFileTypes {
+		db = db.Where("EXISTS (SELECT 1 FROM project_files WHERE project_files.project_id = projects.id AND project_files.file_type = ?)", fileType)
+	}
+	for _, fileType := range filter.MissingFileTypes {
+		db = db.Where("NOT EXISTS (SELECT 1 FROM project_files WHERE project_files.project_id = projects.id AND project_files.file_type = ?)", fileType)
+	}
+	if filter.AddedWithinDays > 0 {
+		db = db.Where("projects.created_at >= ?", now.AddDate(0, 0, -filter.AddedWithinDays))
+	}
+	return db
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupSectionsRouter creates a router with the section routes
+func setupSectionsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewSectionsHandler()
+	router.GET("/api/sections", handler.GetSections)
+	router.POST("/api/admin/sections", handler.CreateSection)
+	router.PUT("/api/admin/sections/:id", handler.UpdateSection)
+	router.DELETE("/api/admin/sections/:id", handler.DeleteSection)
+	return router
+}
+
+// sendJSON performs a request with an optional JSON body
+func sendJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestGetSections tests section previews driven by saved filters
+func TestGetSections(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupSectionsRouter()
+
+	old := time.Now().AddDate(0, 0, -30)
+	projects := []models.Project{
+		{Name: "Calibration Cube", Path: "/test/cube", Tags: []string{"Calibration"}, CreatedAt: old},
+		{Name: "Benchy", Path: "/test/benchy", Tags: []string{"calibration", "boat"}},
+		{Name: "Gear Box", Path: "/test/gear-box"},
+	}
+	for i := range projects {
+		db.Create(&projects[i])
+	}
+	db.Create(&models.ProjectFile{ProjectID: projects[0].ID, Filename: "cube.stl", Filepath: "/test/cube/cube.stl", FileType: models.FileTypeSTL})
+	db.Create(&models.ProjectFile{ProjectID: projects[0].ID, Filename: "cube.gcode", Filepath: "/test/cube/cube.gcode", FileType: models.FileTypeGCode})
+	db.Create(&models.ProjectFile{ProjectID: projects[1].ID, Filename: "benchy.stl", Filepath: "/test/benchy/benchy.stl", FileType: models.FileTypeSTL})
+
+	definitions := []string{
+		`{"name": "Calibration", "position": 3, "filter": {"tags": ["calibration"]}, "sort": "name"}`,
+		`{"name": "Recently added", "position": 1, "filter": {"added_within_days": 7}, "limit": 1}`,
+		`{"name": "Needs slicing", "position": 2, "filter": {"has_file_types": ["stl"], "missing_file_types": ["gcode"]}, "cover": "/covers/slicer.png"}`,
+	}
+	for _, definition := range definitions {
+		if w := sendJSON(router, "POST", "/api/admin/sections", definition); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+
+	w := sendJSON(router, "GET", "/api/sections", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Sections []SectionPreview `json:"sections"`
+		Count    int              `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	expected := []struct {
+		name     string
+		total    int64
+		projects []string
+	}{
+		{"Recently added", 2, []string{"Gear Box"}},
+		{"Needs slicing", 1, []string{"Benchy"}},
+		{"Calibration", 2, []string{"Benchy", "Calibration Cube"}},
+	}
+	if response.Count != len(expected) {
+		t.Fatalf("Expected %d sections, got %d", len(expected), response.Count)
+	}
+	for i, e := range expected {
+		section := response.Sections[i]
+		if section.Name != e.name || section.Total != e.total {
+			t.Errorf("Section %d: expected %s with %d projects, got %s with %d", i, e.name, e.total, section.Name, section.Total)
+		}
+		if len(section.Projects) != len(e.projects) {
+			t.Errorf("Section %s: expected previews %v, got %+v", e.name, e.projects, section.Projects)
+			continue
+		}
+		for j, name := range e.projects {
+			if section.Projects[j].Name != name {
+				t.Errorf("Section %s preview %d: expected %s, got %s", e.name, j, name, section.Projects[j].Name)
+			}
+		}
+	}
+	if response.Sections[1].Cover != "/covers/slicer.png" {
+		t.Errorf("Expected section cover, got %q", response.Sections[1].Cover)
+	}
+	if response.Sections[2].Projects[1].FileCount != 2 {
+		t.Errorf("Expected file_count aggregate in previews, got %d", response.Sections[2].Projects[1].FileCount)
+	}
+}
+
+// TestSectionValidation tests rejecting invalid section definitions
+func TestSectionValidation(t *testing.T) {
+	setupTestDB(t)
+	router := setupSectionsRouter()
+
+	testCases := []string{
+		`{"name": " "}`,
+		`{"name": "Sorted", "sort": "random"}`,
+		`{"name": "Huge", "limit": 500}`,
+		`{"name": "Typed", "filter": {"has_file_types": ["obj"]}}`,
+		`{"name": "Status", "filter": {"status": "unknown"}}`,
+		`{"name": "Past", "filter": {"added_within_days": -1}}`,
+		`not json`,
+	}
+
+	for _, body := range testCases {
+		if w := sendJSON(router, "POST", "/api/admin/sections", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+// TestUpdateAndDeleteSection tests replacing and removing section definitions
+func TestUpdateAndDeleteSection(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupSectionsRouter()
+
+	section := models.Section{Name: "Old", Sort: models.SortNewest}
+	db.Create(&section)
+
+	w := sendJSON(router, "PUT", "/api/admin/sections/1", `{"name": "Renamed", "filter": {"designer": "Jane"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var updated models.Section
+	db.First(&updated, section.ID)
+	if updated.Name != "Renamed" || updated.Filter.Designer != "Jane" || updated.Sort != models.SortNewest {
+		t.Errorf("Unexpected section after update: %+v", updated)
+	}
+
+	if w := sendJSON(router, "PUT", "/api/admin/sections/999", `{"name": "Missing"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := sendJSON(router, "DELETE", "/api/admin/sections/1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendJSON(router, "DELETE", "/api/admin/sections/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/scanner"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAdminSettings tests reading and updating project detection settings
+func TestAdminSettings(t *testing.T) {
+	db := setupTestDB(t)
+	s := scanner.New(db, t.TempDir())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminHandler := NewAdminHandler(s)
+	router.GET("/api/admin/settings", adminHandler.GetSettings)
+	router.PUT("/api/admin/settings", adminHandler.UpdateSettings)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/admin/settings", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Get defaults", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/admin/settings", nil)
+		router.ServeHTTP(w, req)
+
+		var settings Settings
+		json.Unmarshal(w.Body.Bytes(), &settings)
+		if len(settings.Detection.FileTypes) != 3 || settings.Detection.MinFiles != 1 {
+			t.Errorf("Expected default detection rules, got %+v", settings.Detection)
+		}
+	})
+
+	t.Run("Partial update", func(t *testing.T) {
+		w := put(`{"detection": {"min_files": 2, "readme_only_with_sidecar": true}}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		rules := s.DetectionRules()
+		if rules.MinFiles != 2 || !rules.READMEOnlyWithSidecar || len(rules.FileTypes) != 3 {
+			t.Errorf("Expected updated rules with file types kept, got %+v", rules)
+		}
+
+		var saved scanner.DetectionRules
+		if found, err := database.LoadSetting(db, scanner.DetectionSettingKey, &saved); err != nil || !found {
+			t.Fatalf("Expected rules to be persisted, got found=%v err=%v", found, err)
+		}
+		if saved.MinFiles != 2 {
+			t.Errorf("Expected persisted min_files 2, got %d", saved.MinFiles)
+		}
+	})
+
+	t.Run("Invalid rules", func(t *testing.T) {
+		for _, body := range []string{
+			`{"detection": {"file_types": []}}`,
+			`{"detection": {"file_types": ["obj"]}}`,
+			`{"detection": {"min_files": 0}}`,
+			`not json`,
+		} {
+			if w := put(body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+			}
+		}
+
+		if rules := s.DetectionRules(); rules.MinFiles != 2 {
+			t.Errorf("Expected rejected updates to leave rules unchanged, got %+v", rules)
+		}
+	})
+}
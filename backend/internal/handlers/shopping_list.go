@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxShoppingListBuilds bounds how many copies of one project a list plans for
+const maxShoppingListBuilds = 1000
+
+// ShoppingListRequest selects the projects whose hardware to buy
+type ShoppingListRequest struct {
+	ProjectIDs []uint `json:"project_ids"`
+
+	// CollectionIDs add every project of the collections
+	CollectionIDs []uint `json:"collection_ids"`
+
+	// Builds is how many of a project will be built, by project ID; 1 by default
+	Builds map[uint]int `json:"builds"`
+}
+
+// ShoppingListUse is what one project needs of a shopping list item
+type ShoppingListUse struct {
+	ProjectID   uint   `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ShoppingListItem is a piece of hardware to buy, totalled over the projects
+// needing it
+type ShoppingListItem struct {
+	Name     string            `json:"name"`
+	Quantity int               `json:"quantity"`
+	Notes    []string          `json:"notes,omitempty"`
+	Projects []ShoppingListUse `json:"projects"`
+}
+
+// BuildShoppingList adds up the hardware lines of the bills of materials of
+// several projects, such as all the parts of a printer or RC car build, into
+// one list to buy from. Lines are merged by name, ignoring case and spacing.
+func (h *ProjectsHandler) BuildShoppingList(c *gin.Context) {
+	db := requestDB(c)
+
+	var req ShoppingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.ProjectIDs) == 0 && len(req.CollectionIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_ids or collection_ids are required"})
+		return
+	}
+	for _, builds := range req.Builds {
+		if builds < 1 || builds > maxShoppingListBuilds {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "builds must be between 1 and 1000"})
+			return
+		}
+	}
+
+	var projects []models.Project
+	if len(req.ProjectIDs) > 0 {
+		if err := db.Where("id IN ?", req.ProjectIDs).Find(&projects).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+			return
+		}
+		found := make(map[uint]bool, len(projects))
+		for _, project := range projects {
+			found[project.ID] = true
+		}
+		for _, id := range req.ProjectIDs {
+			if !found[id] {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Project not found", "project_id": id})
+				return
+			}
+		}
+	}
+	for _, id := range req.CollectionIDs {
+		var collection models.Collection
+		if err := db.Preload("Projects").First(&collection, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found", "collection_id": id})
+			return
+		}
+		projects = append(projects, collection.Projects...)
+	}
+
+	// A project both selected and in a selected collection is built once
+	names := make(map[uint]string)
+	ids := []uint{}
+	for _, project := range projects {
+		if _, seen := names[project.ID]; !seen {
+			names[project.ID] = project.Name
+			ids = append(ids, project.ID)
+		}
+	}
+
+	var lines []models.BOMItem
+	if err := db.Where("project_id IN ? AND kind = ?", ids, models.BOMHardware).
+		Order("project_id ASC, id ASC").Find(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bills of materials"})
+		return
+	}
+
+	byName := make(map[string]*ShoppingListItem)
+	items := []*ShoppingListItem{}
+	for _, line := range lines {
+		key := strings.ToLower(strings.Join(strings.Fields(line.Name), " "))
+		item, ok := byName[key]
+		if !ok {
+			item = &ShoppingListItem{Name: line.Name}
+			byName[key] = item
+			items = append(items, item)
+		}
+
+		quantity := line.Quantity * max(req.Builds[line.ProjectID], 1)
+		item.Quantity += quantity
+		if note := strings.TrimSpace(line.Notes); note != "" {
+			item.Notes = append(item.Notes, note)
+		}
+		// A project listing the same hardware on several lines needs it once, summed
+		if uses := item.Projects; len(uses) > 0 && uses[len(uses)-1].ProjectID == line.ProjectID {
+			uses[len(uses)-1].Quantity += quantity
+			continue
+		}
+		item.Projects = append(item.Projects, ShoppingListUse{
+			ProjectID:   line.ProjectID,
+			ProjectName: names[line.ProjectID],
+			Quantity:    quantity,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":       items,
+		"count":       len(items),
+		"project_ids": ids,
+	})
+}
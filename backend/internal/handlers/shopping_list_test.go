@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestBuildShoppingList tests hardware is totalled across projects and collections
+func TestBuildShoppingList(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/projects/shopping-list", NewProjectsHandler(t.TempDir()).BuildShoppingList)
+
+	collection := models.Collection{Name: "RC Crawler", SourceURL: "https://example.com/collections/crawler"}
+	db.Create(&collection)
+	chassis := models.Project{Name: "Chassis", Path: "/test/chassis", CollectionID: &collection.ID}
+	wheels := models.Project{Name: "Wheels", Path: "/test/wheels", CollectionID: &collection.ID}
+	printer := models.Project{Name: "Printer", Path: "/test/printer"}
+	for _, project := range []*models.Project{&chassis, &wheels, &printer} {
+		db.Create(project)
+	}
+
+	fileID := uint(1)
+	db.Create(&models.ProjectFile{ProjectID: chassis.ID, Filename: "frame.stl", Filepath: "/test/chassis/frame.stl", FileType: models.FileTypeSTL})
+	for _, item := range []models.BOMItem{
+		{ProjectID: chassis.ID, Kind: models.BOMHardware, Name: "M3x8 screw", Quantity: 4},
+		{ProjectID: chassis.ID, Kind: models.BOMPrinted, FileID: &fileID, Name: "frame", Quantity: 1},
+		{ProjectID: chassis.ID, Kind: models.BOMHardware, Name: "m3x8  Screw", Quantity: 2, Notes: "for the servo"},
+		{ProjectID: wheels.ID, Kind: models.BOMHardware, Name: "608 bearing", Quantity: 2},
+		{ProjectID: wheels.ID, Kind: models.BOMHardware, Name: "M3x8 screw", Quantity: 8},
+		{ProjectID: printer.ID, Kind: models.BOMHardware, Name: "608 bearing", Quantity: 3},
+	} {
+		db.Create(&item)
+	}
+
+	w := sendJSON(router, "POST", "/api/projects/shopping-list",
+		`{"project_ids": [3, 1], "collection_ids": [1], "builds": {"2": 2}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Items      []ShoppingListItem `json:"items"`
+		ProjectIDs []uint             `json:"project_ids"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if len(response.ProjectIDs) != 3 || len(response.Items) != 2 {
+		t.Fatalf("Expected 2 items over 3 projects, got %+v", response)
+	}
+	bearings, screws := response.Items[0], response.Items[1]
+	if bearings.Name != "608 bearing" || bearings.Quantity != 7 || len(bearings.Projects) != 2 {
+		t.Errorf("Expected 7 bearings for two wheel sets and the printer, got %+v", bearings)
+	}
+	if screws.Quantity != 22 || len(screws.Projects) != 2 || screws.Projects[0].ProjectName != "Chassis" ||
+		screws.Projects[0].Quantity != 6 || len(screws.Notes) != 1 {
+		t.Errorf("Expected 22 screws with the chassis lines merged, got %+v", screws)
+	}
+
+	testCases := []struct {
+		body     string
+		expected int
+	}{
+		{`{}`, http.StatusBadRequest},
+		{`{"project_ids": [1], "builds": {"1": 0}}`, http.StatusBadRequest},
+		{`{"project_ids": [1, 99]}`, http.StatusNotFound},
+		{`{"collection_ids": [99]}`, http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "POST", "/api/projects/shopping-list", tc.body); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSimilarLimit    = 10
+	maxSimilarLimit        = 100
+	defaultSimilarMinScore = 0.85
+)
+
+// SimilarFile is a model shaped like the one compared against
+type SimilarFile struct {
+	File        models.ProjectFile `json:"file"`
+	ProjectName string             `json:"project_name"`
+
+	// Score is how alike the shapes are, from 0 to 1
+	Score float64 `json:"score"`
+
+	// Identical is set for copies with the same content
+	Identical bool `json:"identical"`
+}
+
+// GetSimilarFiles lists the STL models across the library shaped most like
+// a model, whatever their position, orientation or scale, to find remixes
+// and duplicates of it. Fingerprints are computed the first time each model
+// content is compared and cached by content hash.
+func (h *FilesHandler) GetSimilarFiles(c *gin.Context) {
+	db := requestDB(c)
+
+	limit := defaultSimilarLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxSimilarLimit)
+	}
+	minScore := defaultSimilarMinScore
+	if raw := c.Query("min_score"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_score must be between 0 and 1"})
+			return
+		}
+		minScore = parsed
+	}
+
+	var file models.ProjectFile
+	if err := db.First(&file, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if file.FileType != models.FileTypeSTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Similar models can only be found for STL files"})
+		return
+	}
+	target, err := geometryFingerprint(db, file)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Model could not be read", "details": err.Error()})
+		return
+	}
+
+	var candidates []models.ProjectFile
+	if err := db.Preload("Project").Where("file_type = ? AND id <> ?", models.FileTypeSTL, file.ID).
+		Order("id ASC").Find(&candidates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch models"})
+		return
+	}
+
+	similar := []SimilarFile{}
+	for _, candidate := range candidates {
+		identical := file.Hash != "" && candidate.Hash == file.Hash
+		score := 1.0
+		if !identical {
+			fingerprint, err := geometryFingerprint(db, candidate)
+			if err != nil {
+				continue
+			}
+			score = mesh.Similarity(target, fingerprint)
+		}
+		if score < minScore {
+			continue
+		}
+		similar = append(similar, SimilarFile{
+			File:        candidate,
+			ProjectName: candidate.Project.Name,
+			Score:       score,
+			Identical:   identical,
+		})
+	}
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].Score > similar[j].Score })
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file_id": file.ID,
+		"similar": similar,
+		"count":   len(similar),
+	})
+}
+
+// geometryFingerprint returns a model's fingerprint; see modelGeometry
+func geometryFingerprint(db *gorm.DB, file models.ProjectFile) (mesh.Fingerprint, error) {
+	geometry, err := modelGeometry(db, file)
+	return geometry.Vector, err
+}
+
+// modelGeometry returns a model's fingerprint, size and volume, computing and caching
+// them by content hash when they aren't yet; unhashed files aren't cached
+func modelGeometry(db *gorm.DB, file models.ProjectFile) (models.GeometryFingerprint, error) {
+	var cached models.GeometryFingerprint
+	if file.Hash != "" {
+		err := db.Where("hash = ? AND version = ?", file.Hash, mesh.FingerprintVersion).First(&cached).Error
+		switch {
+		case err == nil && cached.Error != "":
+			return cached, errors.New(cached.Error)
+		// Rows cached before sizes and volumes were recorded are read again
+		case err == nil && len(cached.Size) == 3 && cached.Volume != nil:
+			return cached, nil
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return cached, err
+		}
+	}
+
+	cached = models.GeometryFingerprint{Hash: file.Hash, Version: mesh.FingerprintVersion}
+	model, err := mesh.ReadSTLFile(file.Filepath)
+	if err == nil {
+		lo, hi := model.Bounds()
+		size := hi.Sub(lo)
+		cached.Triangles = len(model.Triangles)
+		cached.Size = size[:]
+		volume := model.Volume()
+		cached.Volume = &volume
+		cached.Vector, err = mesh.ComputeFingerprint(model)
+	}
+	if err != nil {
+		cached.Error = err.Error()
+	}
+	if file.Hash != "" {
+		if saveErr := db.Save(&cached).Error; saveErr != nil {
+			return cached, saveErr
+		}
+	}
+	return cached, err
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/mesh"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testBox returns a closed box mesh of the given size at offset
+func testBox(x, y, z float64, offset mesh.Vec3) *mesh.Mesh {
+	corner := func(i, j, k float64) mesh.Vec3 { return mesh.Vec3{i*x + offset[0], j*y + offset[1], k*z + offset[2]} }
+	quads := [][4]mesh.Vec3{
+		{corner(0, 0, 0), corner(0, 1, 0), corner(1, 1, 0), corner(1, 0, 0)},
+		{corner(0, 0, 1), corner(1, 0, 1), corner(1, 1, 1), corner(0, 1, 1)},
+		{corner(0, 0, 0), corner(1, 0, 0), corner(1, 0, 1), corner(0, 0, 1)},
+		{corner(0, 1, 0), corner(0, 1, 1), corner(1, 1, 1), corner(1, 1, 0)},
+		{corner(0, 0, 0), corner(0, 0, 1), corner(0, 1, 1), corner(0, 1, 0)},
+		{corner(1, 0, 0), corner(1, 1, 0), corner(1, 1, 1), corner(1, 0, 1)},
+	}
+	m := &mesh.Mesh{}
+	for _, q := range quads {
+		m.Triangles = append(m.Triangles, mesh.Triangle{q[0], q[1], q[2]}, mesh.Triangle{q[0], q[2], q[3]})
+	}
+	return m
+}
+
+// TestGetSimilarFiles tests finding models shaped like another across projects
+func TestGetSimilarFiles(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/files/:id/similar", NewFilesHandler(nil).GetSimilarFiles)
+
+	cubes := models.Project{Name: "Cubes", Path: filepath.Join(tmpDir, "cubes")}
+	remixes := models.Project{Name: "Remixes", Path: filepath.Join(tmpDir, "remixes")}
+	db.Create(&cubes)
+	db.Create(&remixes)
+
+	models3D := []struct {
+		project *models.Project
+		name    string
+		hash    string
+		mesh    *mesh.Mesh
+	}{
+		{&cubes, "cube.stl", "hash-cube", testBox(10, 10, 10, mesh.Vec3{})},
+		{&remixes, "big_cube.stl", "hash-big", testBox(25, 25, 25, mesh.Vec3{100, 0, 5})},
+		{&remixes, "cube_copy.stl", "hash-cube", testBox(10, 10, 10, mesh.Vec3{})},
+		{&remixes, "rod.stl", "hash-rod", testBox(2, 2, 40, mesh.Vec3{})},
+	}
+	for _, m := range models3D {
+		path := filepath.Join(tmpDir, m.name)
+		out, _ := os.Create(path)
+		mesh.WriteBinarySTL(out, m.mesh)
+		out.Close()
+		db.Create(&models.ProjectFile{ProjectID: m.project.ID, Filename: m.name, Filepath: path, Hash: m.hash, FileType: models.FileTypeSTL})
+	}
+	broken := filepath.Join(tmpDir, "broken.stl")
+	os.WriteFile(broken, []byte("not a model"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: remixes.ID, Filename: "broken.stl", Filepath: broken, Hash: "hash-broken", FileType: models.FileTypeSTL})
+	db.Create(&models.ProjectFile{ProjectID: cubes.ID, Filename: "cube.gcode", Filepath: filepath.Join(tmpDir, "cube.gcode"), FileType: models.FileTypeGCode})
+
+	get := func(path string) (*httptest.ResponseRecorder, []SimilarFile) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		var response struct {
+			Similar []SimilarFile `json:"similar"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.Similar
+	}
+
+	w, similar := get("/api/files/1/similar")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(similar) != 2 || similar[0].File.Filename != "cube_copy.stl" || !similar[0].Identical ||
+		similar[1].File.Filename != "big_cube.stl" || similar[1].ProjectName != "Remixes" || similar[1].Score < 0.9 {
+		t.Errorf("Expected the copy and then the scaled cube, got %+v", similar)
+	}
+
+	// Fingerprints are cached by content, broken models with their error
+	var cached []models.GeometryFingerprint
+	db.Order("hash ASC").Find(&cached)
+	if len(cached) != 4 || cached[1].Hash != "hash-broken" || cached[1].Error == "" || cached[2].Triangles != 12 {
+		t.Errorf("Expected 4 cached fingerprints, got %+v", cached)
+	}
+
+	if _, similar := get("/api/files/1/similar?min_score=0&limit=3"); len(similar) != 3 || similar[2].File.Filename != "rod.stl" {
+		t.Errorf("Expected every readable model down to the rod, got %+v", similar)
+	}
+
+	testCases := []struct {
+		path     string
+		expected int
+	}{
+		{"/api/files/99/similar", http.StatusNotFound},
+		{"/api/files/6/similar", http.StatusBadRequest},
+		{"/api/files/5/similar", http.StatusUnprocessableEntity},
+		{"/api/files/1/similar?limit=0", http.StatusBadRequest},
+		{"/api/files/1/similar?min_score=2", http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w, _ := get(tc.path); w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.expected, w.Code)
+		}
+	}
+}
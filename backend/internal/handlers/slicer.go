@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"3dshelf/pkg/slicer"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SlicerHandler handles requests to slice models into G-code
+type SlicerHandler struct {
+	slicer *slicer.Slicer
+}
+
+// NewSlicerHandler creates a new SlicerHandler; a nil slicer reports slicing
+// as not configured
+func NewSlicerHandler(s *slicer.Slicer) *SlicerHandler {
+	return &SlicerHandler{
+		slicer: s,
+	}
+}
+
+// SliceFileRequest is the body accepted by SliceFile
+type SliceFileRequest struct {
+	Profile string `json:"profile" binding:"required"`
+}
+
+// GetProfiles lists the profiles models can be sliced with
+func (h *SlicerHandler) GetProfiles(c *gin.Context) {
+	if h.slicer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slicer is not configured"})
+		return
+	}
+
+	profiles, err := h.slicer.Profiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read slicer profiles", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profiles": profiles,
+		"count":    len(profiles),
+	})
+}
+
+// SliceFile starts a background job slicing a model with a profile; the
+// G-code is added to the model's project when the job completes
+func (h *SlicerHandler) SliceFile(c *gin.Context) {
+	if h.slicer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slicer is not configured"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req SliceFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	job, err := h.slicer.Start(uint(id), req.Profile)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	case errors.Is(err, slicer.ErrUnsupportedFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only STL and 3MF models can be sliced"})
+		return
+	case errors.Is(err, slicer.ErrUnknownProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown slicer profile"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start slicing", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Slicing started",
+		"job":     job,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/slicer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSlicerHandler tests validating slice requests and listing profiles
+func TestSlicerHandler(t *testing.T) {
+	db := setupTestDB(t)
+	profilesDir := t.TempDir()
+	os.WriteFile(filepath.Join(profilesDir, "pla.ini"), []byte("; PLA\n"), 0644)
+
+	command, _ := slicer.ParseCommand("prusaslicer")
+	handler := NewSlicerHandler(slicer.New(db, jobs.New(db), command, profilesDir))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/slicer/profiles", handler.GetProfiles)
+	router.POST("/api/files/:id/slice", handler.SliceFile)
+
+	project := models.Project{Name: "Benchy", Path: t.TempDir()}
+	db.Create(&project)
+	model := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.stl", Filepath: filepath.Join(project.Path, "benchy.stl"), FileType: models.FileTypeSTL}
+	db.Create(&model)
+	gcode := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.gcode", Filepath: filepath.Join(project.Path, "benchy.gcode"), FileType: models.FileTypeGCode}
+	db.Create(&gcode)
+
+	t.Run("Profiles", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/slicer/profiles", "")
+		var response struct {
+			Profiles []string `json:"profiles"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || len(response.Profiles) != 1 || response.Profiles[0] != "pla" {
+			t.Errorf("Expected the pla profile, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Slice", func(t *testing.T) {
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/files/%d/slice", model.ID), `{"profile": "pla"}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var response struct {
+			Job models.Job `json:"job"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Job.Type != slicer.JobType {
+			t.Errorf("Expected a %s job, got %+v", slicer.JobType, response.Job)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cases := []struct {
+			path   string
+			body   string
+			status int
+		}{
+			{fmt.Sprintf("/api/files/%d/slice", model.ID), `{}`, http.StatusBadRequest},
+			{fmt.Sprintf("/api/files/%d/slice", model.ID), `{"profile": "petg"}`, http.StatusBadRequest},
+			{fmt.Sprintf("/api/files/%d/slice", gcode.ID), `{"profile": "pla"}`, http.StatusBadRequest},
+			{"/api/files/999/slice", `{"profile": "pla"}`, http.StatusNotFound},
+			{"/api/files/abc/slice", `{"profile": "pla"}`, http.StatusBadRequest},
+		}
+		for _, tc := range cases {
+			if w := sendJSON(router, "POST", tc.path, tc.body); w.Code != tc.status {
+				t.Errorf("Expected status %d for %s %s, got %d", tc.status, tc.path, tc.body, w.Code)
+			}
+		}
+	})
+
+	t.Run("Not configured", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/api/files/:id/slice", NewSlicerHandler(nil).SliceFile)
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/files/%d/slice", model.ID), `{"profile": "pla"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
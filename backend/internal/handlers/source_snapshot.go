@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/snapshot"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SourceSnapshot is the saved snapshot of a project's source page
+type SourceSnapshot struct {
+	*snapshot.Snapshot
+	Images []SourceSnapshotImage `json:"images"`
+}
+
+// SourceSnapshotImage is an image of a source snapshot and where its saved copy is served
+type SourceSnapshotImage struct {
+	snapshot.Image
+	LocalURL string `json:"local_url"`
+}
+
+// SetSnapshots keeps snapshots of project source pages in archive
+func (h *ProjectsHandler) SetSnapshots(archive *snapshot.Archive) {
+	h.snapshots = archive
+}
+
+// SnapshotSource saves the page the project's source URL points at, with its
+// title, description, license and images, so the project keeps them should
+// the page go away. A new snapshot replaces the previous one.
+func (h *ProjectsHandler) SnapshotSource(c *gin.Context) {
+	if h.snapshots == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source snapshots are not configured"})
+		return
+	}
+
+	var project models.Project
+	if err := requestDB(c).First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if project.Source == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project has no source URL"})
+		return
+	}
+	if !snapshot.ValidURL(project.Source) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Project source is not an http or https URL", "source": project.Source})
+		return
+	}
+
+	saved, err := h.snapshots.Take(c.Request.Context(), snapshotKey(project.ID), project.Source)
+	if err != nil {
+		fmt.Printf("Warning: Failed to snapshot source of project %d: %v\n", project.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to snapshot source", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Source snapshot saved", "snapshot": sourceSnapshot(project.ID, saved)})
+}
+
+// GetSourceSnapshot returns the saved snapshot of a project's source page
+func (h *ProjectsHandler) GetSourceSnapshot(c *gin.Context) {
+	project, saved, ok := h.loadSourceSnapshot(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, sourceSnapshot(project.ID, saved))
+}
+
+// GetSourceSnapshotImage serves a saved image of a project's source page
+func (h *ProjectsHandler) GetSourceSnapshotImage(c *gin.Context) {
+	project, _, ok := h.loadSourceSnapshot(c)
+	if !ok {
+		return
+	}
+	path, err := h.snapshots.ImagePath(snapshotKey(project.ID), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	c.File(path)
+}
+
+// loadSourceSnapshot looks up the project of the request and its source
+// snapshot, responding with an error when either is missing
+func (h *ProjectsHandler) loadSourceSnapshot(c *gin.Context) (models.Project, *snapshot.Snapshot, bool) {
+	var project models.Project
+	if err := requestDB(c).First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return project, nil, false
+	}
+	if h.snapshots == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Source snapshot not found"})
+		return project, nil, false
+	}
+
+	saved, err := h.snapshots.Get(snapshotKey(project.ID))
+	switch {
+	case errors.Is(err, snapshot.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Source snapshot not found"})
+		return project, nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read source snapshot", "details": err.Error()})
+		return project, nil, false
+	}
+	return project, saved, true
+}
+
+// snapshotKey is what a project's source snapshot is saved under
+func snapshotKey(projectID uint) string {
+	return strconv.FormatUint(uint64(projectID), 10)
+}
+
+// sourceSnapshot adds where a snapshot's images are served
+func sourceSnapshot(projectID uint, saved *snapshot.Snapshot) SourceSnapshot {
+	images := make([]SourceSnapshotImage, len(saved.Images))
+	for i, image := range saved.Images {
+		images[i] = SourceSnapshotImage{
+			Image:    image,
+			LocalURL: fmt.Sprintf("/api/projects/%d/snapshot-source/images/%s", projectID, image.Filename),
+		}
+	}
+	return SourceSnapshot{Snapshot: saved, Images: images}
+}
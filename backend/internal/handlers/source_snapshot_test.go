@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSnapshotSource tests saving and serving snapshots of a project's source page
+func TestSnapshotSource(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	source := http.NewServeMux()
+	source.HandleFunc("/thing:763622", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>3DBenchy</title><meta name="description" content="Torture test">`+
+			`<meta name="license" content="CC BY-ND"><meta property="og:image" content="/benchy.png"></head></html>`)
+	})
+	source.HandleFunc("/benchy.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "PNG")
+	})
+	server := httptest.NewServer(source)
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	archive := snapshot.New(filepath.Join(tmpDir, "snapshots"))
+	archive.HTTP = server.Client()
+	handler.SetSnapshots(archive)
+	router.POST("/api/projects/:id/snapshot-source", handler.SnapshotSource)
+	router.GET("/api/projects/:id/snapshot-source", handler.GetSourceSnapshot)
+	router.GET("/api/projects/:id/snapshot-source/images/:name", handler.GetSourceSnapshotImage)
+
+	project := models.Project{Name: "Benchy", Path: filepath.Join(tmpDir, "benchy"), Source: server.URL + "/thing:763622"}
+	db.Create(&project)
+	unsourced := models.Project{Name: "Original", Path: filepath.Join(tmpDir, "original")}
+	db.Create(&unsourced)
+	offline := models.Project{Name: "Gone", Path: filepath.Join(tmpDir, "gone"), Source: server.URL + "/thing:1"}
+	db.Create(&offline)
+
+	path := fmt.Sprintf("/api/projects/%d/snapshot-source", project.ID)
+	if w := sendJSON(router, "GET", path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before a snapshot, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w := sendJSON(router, "POST", path, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Snapshot SourceSnapshot `json:"snapshot"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	saved := response.Snapshot
+	if saved.Snapshot == nil || saved.Title != "3DBenchy" || saved.Description != "Torture test" || saved.License != "CC BY-ND" {
+		t.Fatalf("Expected the page's metadata, got %+v", saved)
+	}
+	if len(saved.Images) != 1 || saved.Images[0].LocalURL != path+"/images/image-1.png" {
+		t.Fatalf("Expected the image served locally, got %+v", saved.Images)
+	}
+
+	if w := sendJSON(router, "GET", path, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the saved snapshot, got %d", w.Code)
+	}
+	if w := sendJSON(router, "GET", saved.Images[0].LocalURL, ""); w.Code != http.StatusOK || w.Body.String() != "PNG" {
+		t.Errorf("Expected the saved image, got %d %q", w.Code, w.Body.String())
+	}
+
+	for name, tc := range map[string]struct {
+		method, path string
+		status       int
+	}{
+		"No source":       {"POST", fmt.Sprintf("/api/projects/%d/snapshot-source", unsourced.ID), http.StatusBadRequest},
+		"Source offline":  {"POST", fmt.Sprintf("/api/projects/%d/snapshot-source", offline.ID), http.StatusBadGateway},
+		"Missing project": {"POST", "/api/projects/999/snapshot-source", http.StatusNotFound},
+		"Missing image":   {"GET", path + "/images/page.html", http.StatusNotFound},
+	} {
+		if w := sendJSON(router, tc.method, tc.path, ""); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/linkcheck"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// StorefrontSettingKey is the settings key the storefront theme is persisted under
+	StorefrontSettingKey = "storefront"
+
+	// DefaultStorefrontCacheTTL is how long catalogue responses are cached unless configured
+	DefaultStorefrontCacheTTL = 5 * time.Minute
+
+	// maxStorefrontEntries bounds the catalogue responses kept in memory, as
+	// each query string is cached apart
+	maxStorefrontEntries = 1000
+
+	defaultStorefrontLimit = 100
+	maxStorefrontLimit     = 500
+)
+
+// accentColorPattern matches a #rrggbb color
+var accentColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// StorefrontTheme is how the public catalogue presents the library, for the
+// pages embedding it to style themselves with
+type StorefrontTheme struct {
+	Title       string `json:"title"`
+	Tagline     string `json:"tagline,omitempty"`
+	LogoURL     string `json:"logo_url,omitempty"`
+	AccentColor string `json:"accent_color,omitempty"`
+
+	// QuoteURL is where customers ask for a quote, with {slug} replaced by
+	// the project's slug, e.g. https://shop.example.com/quote?project={slug}
+	QuoteURL string `json:"quote_url,omitempty"`
+}
+
+// DefaultStorefrontTheme is the theme used until one is saved
+var DefaultStorefrontTheme = StorefrontTheme{Title: "3DShelf"}
+
+// Validate trims and checks the theme's fields
+func (t *StorefrontTheme) Validate() error {
+	t.Title = strings.TrimSpace(t.Title)
+	t.Tagline = strings.TrimSpace(t.Tagline)
+	t.LogoURL = strings.TrimSpace(t.LogoURL)
+	t.AccentColor = strings.TrimSpace(t.AccentColor)
+	t.QuoteURL = strings.TrimSpace(t.QuoteURL)
+
+	if t.Title == "" {
+		return fmt.Errorf("storefront title is required")
+	}
+	if t.AccentColor != "" && !accentColorPattern.MatchString(t.AccentColor) {
+		return fmt.Errorf("storefront accent_color must be a #rrggbb color")
+	}
+	if t.LogoURL != "" && !linkcheck.IsWebURL(t.LogoURL) {
+		return fmt.Errorf("storefront logo_url must be an http or https URL")
+	}
+	if t.QuoteURL != "" && !linkcheck.IsWebURL(strings.ReplaceAll(t.QuoteURL, "{slug}", "slug")) {
+		return fmt.Errorf("storefront quote_url must be an http or https URL")
+	}
+	return nil
+}
+
+// PublicModel is a printable file of a public project, without its location on disk
+type PublicModel struct {
+	Filename string          `json:"filename"`
+	FileType models.FileType `json:"file_type"`
+	Size     int64           `json:"size"`
+}
+
+// PublicProject is what the catalogue shows of a public project
+type PublicProject struct {
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	License     string   `json:"license,omitempty"`
+	Designer    string   `json:"designer,omitempty"`
+
+	// Price is the list price in Currency; unset when the price is quoted
+	Price    *float64 `json:"price,omitempty"`
+	Currency string   `json:"currency"`
+	QuoteURL string   `json:"quote_url,omitempty"`
+
+	CoverURL   string `json:"cover_url,omitempty"`
+	ModelCount int    `json:"model_count"`
+
+	// Images and Models are listed on a single project only
+	Images []string      `json:"images,omitempty"`
+	Models []PublicModel `json:"models,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storefrontEntry is a cached catalogue response
+type storefrontEntry struct {
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// StorefrontHandler serves the public, read-only catalogue of the projects
+// marked public. Responses are cached in memory and by clients for the cache
+// TTL, so changes to projects show up once it passes.
+type StorefrontHandler struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]storefrontEntry
+}
+
+// NewStorefrontHandler creates a new StorefrontHandler
+func NewStorefrontHandler() *StorefrontHandler {
+	return &StorefrontHandler{ttl: DefaultStorefrontCacheTTL, cache: make(map[string]storefrontEntry)}
+}
+
+// SetCacheTTL sets how long catalogue responses are cached; 0 disables caching
+func (h *StorefrontHandler) SetCacheTTL(ttl time.Duration) {
+	h.ttl = ttl
+}
+
+// GetCatalogue returns the storefront theme and the public projects by name,
+// optionally with a ?tag=, paged with ?limit= (default 100) and ?offset=
+func (h *StorefrontHandler) GetCatalogue(c *gin.Context) {
+	limit := defaultStorefrontLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxStorefrontLimit)
+	}
+	offset, err := parseNonNegative(c, "offset")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.serve(c, func(db *gorm.DB) (any, error) {
+		theme, currency, err := loadStorefront(db)
+		if err != nil {
+			return nil, err
+		}
+
+		var projects []models.Project
+		if err := db.Where("public = ?", true).Order("name ASC").Find(&projects).Error; err != nil {
+			return nil, err
+		}
+		if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+			projects = slices.DeleteFunc(projects, func(project models.Project) bool {
+				return !slices.ContainsFunc(project.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+			})
+		}
+		total := len(projects)
+		// Clamped before adding, so huge offsets can't overflow
+		start := min(offset, total)
+		projects = projects[start : start+min(limit, total-start)]
+
+		ids := make([]uint, len(projects))
+		for i, project := range projects {
+			ids[i] = project.ID
+		}
+		var files []models.ProjectFile
+		if err := db.Where("project_id IN ?", ids).Order("filename ASC").Find(&files).Error; err != nil {
+			return nil, err
+		}
+		byProject := make(map[uint][]models.ProjectFile)
+		for _, file := range files {
+			byProject[file.ProjectID] = append(byProject[file.ProjectID], file)
+		}
+
+		listed := make([]PublicProject, len(projects))
+		for i, project := range projects {
+			listed[i] = publicProject(project, byProject[project.ID], theme, currency, false)
+		}
+		return gin.H{
+			"storefront": theme,
+			"projects":   listed,
+			"count":      len(listed),
+			"total":      total,
+		}, nil
+	})
+}
+
+// GetCatalogueProject returns a public project by slug with its images and models
+func (h *StorefrontHandler) GetCatalogueProject(c *gin.Context) {
+	h.serve(c, func(db *gorm.DB) (any, error) {
+		project, err := publicProjectBySlug(db, c.Param("slug"))
+		if err != nil {
+			return nil, err
+		}
+		theme, currency, err := loadStorefront(db)
+		if err != nil {
+			return nil, err
+		}
+		var files []models.ProjectFile
+		if err := db.Where("project_id = ?", project.ID).Order("filename ASC").Find(&files).Error; err != nil {
+			return nil, err
+		}
+		return publicProject(project, files, theme, currency, true), nil
+	})
+}
+
+// GetCatalogueImage serves an image of a public project
+func (h *StorefrontHandler) GetCatalogueImage(c *gin.Context) {
+	db := requestDB(c)
+
+	project, err := publicProjectBySlug(db, c.Param("slug"))
+	if err != nil {
+		respondStorefrontError(c, err)
+		return
+	}
+	var file models.ProjectFile
+	if err := db.Where("id = ? AND project_id = ?", c.Param("fileId"), project.ID).First(&file).Error; err != nil || !models.IsImageFile(file.Filename) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	if file.Hash != "" {
+		etag := `"` + file.Hash + `"`
+		c.Header("ETag", etag)
+		if matchesETag(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.Header("Cache-Control", h.cacheControl())
+	c.File(file.Filepath)
+}
+
+// serve answers with the cached response for the request's URL while it is
+// fresh, otherwise builds, caches and sends a new one. A client holding the
+// response's ETag gets 304.
+func (h *StorefrontHandler) serve(c *gin.Context, build func(db *gorm.DB) (any, error)) {
+	key := c.Request.URL.RequestURI()
+	now := time.Now()
+
+	h.mu.Lock()
+	entry, ok := h.cache[key]
+	h.mu.Unlock()
+
+	if !ok || h.ttl <= 0 || now.After(entry.expires) {
+		response, err := build(requestDB(c))
+		if err != nil {
+			respondStorefrontError(c, err)
+			return
+		}
+		body, err := json.Marshal(response)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode catalogue"})
+			return
+		}
+		entry = storefrontEntry{body: body, etag: fmt.Sprintf(`"%x"`, sha256.Sum256(body)), expires: now.Add(h.ttl)}
+		if h.ttl > 0 {
+			h.store(key, entry, now)
+		}
+	}
+
+	c.Header("ETag", entry.etag)
+	c.Header("Cache-Control", h.cacheControl())
+	if matchesETag(c.GetHeader("If-None-Match"), entry.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", entry.body)
+}
+
+// store caches a response, making room by dropping expired entries, or all
+// of them when none has expired
+func (h *StorefrontHandler) store(key string, entry storefrontEntry, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.cache) >= maxStorefrontEntries {
+		for cached, old := range h.cache {
+			if now.After(old.expires) {
+				delete(h.cache, cached)
+			}
+		}
+		if len(h.cache) >= maxStorefrontEntries {
+			clear(h.cache)
+		}
+	}
+	h.cache[key] = entry
+}
+
+// cacheControl lets browsers and CDNs keep responses for the cache TTL and
+// serve them stale for a day while revalidating
+func (h *StorefrontHandler) cacheControl() string {
+	if h.ttl <= 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d, stale-while-revalidate=86400", int(h.ttl.Seconds()))
+}
+
+// respondStorefrontError answers 404 for projects that don't exist or aren't public
+func respondStorefrontError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catalogue"})
+}
+
+// publicProjectBySlug finds a project marked public by its slug
+func publicProjectBySlug(db *gorm.DB, slug string) (models.Project, error) {
+	var project models.Project
+	err := db.Where("slug = ? AND public = ?", slug, true).First(&project).Error
+	return project, err
+}
+
+// loadStorefront returns the storefront theme, the default unless saved
+// through the admin API, and the currency list prices are in
+func loadStorefront(db *gorm.DB) (StorefrontTheme, string, error) {
+	theme := DefaultStorefrontTheme
+	if _, err := database.LoadSetting(db, StorefrontSettingKey, &theme); err != nil {
+		return theme, "", err
+	}
+	rules, err := loadPricing(db)
+	if err != nil {
+		return theme, "", err
+	}
+	return theme, rules.Currency, nil
+}
+
+// publicProject describes a public project for the catalogue, with its images
+// and models when detailed
+func publicProject(project models.Project, files []models.ProjectFile, theme StorefrontTheme, currency string, detailed bool) PublicProject {
+	public := PublicProject{
+		Slug:        project.Slug,
+		Name:        project.Name,
+		Description: project.Description,
+		Tags:        project.Tags,
+		License:     project.License,
+		Designer:    project.Designer,
+		Currency:    currency,
+		UpdatedAt:   project.UpdatedAt,
+	}
+	if public.Tags == nil {
+		public.Tags = []string{}
+	}
+	if project.ListPrice > 0 {
+		price := project.ListPrice
+		public.Price = &price
+	}
+	if theme.QuoteURL != "" {
+		public.QuoteURL = strings.ReplaceAll(theme.QuoteURL, "{slug}", url.QueryEscape(project.Slug))
+	}
+
+	var images []models.ProjectFile
+	for _, file := range files {
+		switch {
+		case models.IsImageFile(file.Filename):
+			images = append(images, file)
+		case file.FileType == models.FileTypeSTL || file.FileType == models.FileType3MF:
+			public.ModelCount++
+			if detailed {
+				public.Models = append(public.Models, PublicModel{Filename: file.Filename, FileType: file.FileType, Size: file.Size})
+			}
+		}
+	}
+	imageURL := func(file models.ProjectFile) string {
+		return fmt.Sprintf("/public/catalogue/projects/%s/images/%d", url.PathEscape(project.Slug), file.ID)
+	}
+	if cover := pickCoverImage(images); cover != nil {
+		public.CoverURL = imageURL(*cover)
+	}
+	if detailed {
+		for _, image := range images {
+			public.Images = append(public.Images, imageURL(image))
+		}
+	}
+	return public
+}
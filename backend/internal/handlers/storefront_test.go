@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestStorefrontCatalogue tests listing public projects and hiding private ones
+func TestStorefrontCatalogue(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewStorefrontHandler()
+	router.GET("/public/catalogue", handler.GetCatalogue)
+	router.GET("/public/catalogue/projects/:slug", handler.GetCatalogueProject)
+	router.GET("/public/catalogue/projects/:slug/images/:fileId", handler.GetCatalogueImage)
+
+	lamp := models.Project{Name: "Lamp", Path: filepath.Join(tmpDir, "lamp"), Tags: []string{"Home"}, Public: true, ListPrice: 24.5}
+	vase := models.Project{Name: "Vase", Path: filepath.Join(tmpDir, "vase"), Tags: []string{"decor"}, Public: true}
+	secret := models.Project{Name: "Secret", Path: filepath.Join(tmpDir, "secret"), Tags: []string{"home"}}
+	for _, p := range []*models.Project{&lamp, &vase, &secret} {
+		db.Create(p)
+	}
+	photo := filepath.Join(tmpDir, "lamp.png")
+	os.WriteFile(photo, []byte("png"), 0644)
+	db.Create(&models.ProjectFile{ProjectID: lamp.ID, Filename: "lamp.png", Filepath: photo, Hash: "photohash", FileType: models.FileTypeOther})
+	db.Create(&models.ProjectFile{ProjectID: lamp.ID, Filename: "shade.stl", Filepath: filepath.Join(tmpDir, "shade.stl"), Size: 1024, FileType: models.FileTypeSTL})
+	db.Create(&models.ProjectFile{ProjectID: secret.ID, Filename: "secret.png", Filepath: photo, FileType: models.FileTypeOther})
+
+	database.SaveSetting(db, StorefrontSettingKey, StorefrontTheme{Title: "Ada's Prints", QuoteURL: "https://shop.example.com/quote?project={slug}"})
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/public/catalogue", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var catalogue struct {
+		Storefront StorefrontTheme `json:"storefront"`
+		Projects   []PublicProject `json:"projects"`
+		Total      int             `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &catalogue)
+	if catalogue.Storefront.Title != "Ada's Prints" || catalogue.Total != 2 || len(catalogue.Projects) != 2 {
+		t.Fatalf("Expected the 2 public projects under the saved theme, got %+v", catalogue)
+	}
+	listed := catalogue.Projects[0]
+	if listed.Name != "Lamp" || listed.Price == nil || *listed.Price != 24.5 || listed.Currency != "USD" || listed.ModelCount != 1 {
+		t.Errorf("Expected the lamp at its list price, got %+v", listed)
+	}
+	if listed.QuoteURL != "https://shop.example.com/quote?project="+lamp.Slug || listed.CoverURL == "" || listed.Models != nil {
+		t.Errorf("Expected the lamp's quote link and cover without its models, got %+v", listed)
+	}
+	if catalogue.Projects[1].Price != nil {
+		t.Errorf("Expected the vase to be quoted, got %v", *catalogue.Projects[1].Price)
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=300, stale-while-revalidate=86400" {
+		t.Errorf("Unexpected Cache-Control %q", w.Header().Get("Cache-Control"))
+	}
+
+	// Unchanged responses are revalidated with the ETag
+	if w := get("/public/catalogue", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	var tagged struct {
+		Projects []PublicProject `json:"projects"`
+	}
+	json.Unmarshal(get("/public/catalogue?tag=home", "").Body.Bytes(), &tagged)
+	if len(tagged.Projects) != 1 || tagged.Projects[0].Name != "Lamp" {
+		t.Errorf("Expected only the public project tagged home, got %+v", tagged.Projects)
+	}
+
+	w = get("/public/catalogue/projects/"+lamp.Slug, "")
+	var detail PublicProject
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if w.Code != http.StatusOK || len(detail.Images) != 1 || len(detail.Models) != 1 || detail.Models[0].Filename != "shade.stl" {
+		t.Errorf("Expected the lamp's image and model, got %d %+v", w.Code, detail)
+	}
+	if w := get(detail.Images[0], ""); w.Code != http.StatusOK || w.Body.String() != "png" || w.Header().Get("ETag") != `"photohash"` {
+		t.Errorf("Expected the lamp's image, got %d %q", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{
+		"/public/catalogue/projects/" + secret.Slug,
+		"/public/catalogue/projects/" + secret.Slug + "/images/3",
+		"/public/catalogue/projects/" + lamp.Slug + "/images/2",
+		"/public/catalogue/projects/missing",
+	} {
+		if w := get(path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+	if w := get("/public/catalogue?limit=0", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid limit, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var paged struct {
+		Projects []PublicProject `json:"projects"`
+		Total    int             `json:"total"`
+	}
+	json.Unmarshal(get("/public/catalogue?limit=1&offset=1", "").Body.Bytes(), &paged)
+	if paged.Total != 2 || len(paged.Projects) != 1 || paged.Projects[0].Name != "Vase" {
+		t.Errorf("Expected the second project alone, got %+v", paged)
+	}
+	w = get("/public/catalogue?offset=9223372036854775807", "")
+	json.Unmarshal(w.Body.Bytes(), &paged)
+	if w.Code != http.StatusOK || paged.Total != 2 || len(paged.Projects) != 0 {
+		t.Errorf("Expected an empty page past the end, got %d %+v", w.Code, paged)
+	}
+
+	// Changes show up once the cached response expires
+	db.Model(&vase).Update("public", false)
+	json.Unmarshal(get("/public/catalogue", "").Body.Bytes(), &catalogue)
+	if catalogue.Total != 2 {
+		t.Errorf("Expected the cached catalogue, got %d projects", catalogue.Total)
+	}
+	handler.SetCacheTTL(0)
+	var fresh struct {
+		Total int `json:"total"`
+	}
+	w = get("/public/catalogue", "")
+	json.Unmarshal(w.Body.Bytes(), &fresh)
+	if fresh.Total != 1 || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Expected the uncached catalogue to drop the vase, got %d projects", fresh.Total)
+	}
+}
+
+// TestUpdateProjectStorefront tests publishing a project with a list price
+func TestUpdateProjectStorefront(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupRouter(tmpDir)
+
+	project := models.Project{Name: "Lamp", Path: filepath.Join(tmpDir, "Lamp"), LastScanned: time.Now()}
+	db.Create(&project)
+	os.MkdirAll(project.Path, 0755)
+	path := "/api/projects/" + strconv.FormatUint(uint64(project.ID), 10)
+
+	if w := sendJSON(router, "PUT", path, `{"name": "Lamp", "public": true, "list_price": 19.99}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.Project
+	db.First(&updated, project.ID)
+	if !updated.Public || updated.ListPrice != 19.99 {
+		t.Errorf("Expected the project public at 19.99, got %v at %v", updated.Public, updated.ListPrice)
+	}
+
+	if w := sendJSON(router, "PUT", path, `{"name": "Lamp", "list_price": -1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a negative price, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/thumbnail"
+	"3dshelf/pkg/units"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessCheck is one item of a project's print-readiness checklist
+type ReadinessCheck struct {
+	Key    string `json:"key"`
+	Label  string `json:"label"`
+	Passed bool   `json:"passed"`
+}
+
+// PrintProfile describes the slicer settings of one G-code file
+type PrintProfile struct {
+	FileID   uint   `json:"file_id"`
+	Filename string `json:"filename"`
+	gcode.Metadata
+
+	// Plates break a sliced 3MF's estimates, which add up over its plates, down by plate
+	Plates []gcode.Plate `json:"plates,omitempty"`
+
+	Display units.Display `json:"display,omitempty"`
+}
+
+// ProjectSummary bundles everything the project detail page needs in one response
+type ProjectSummary struct {
+	Project       models.Project          `json:"project"`
+	FileCounts    map[models.FileType]int `json:"file_counts"`
+	TotalFiles    int                     `json:"total_files"`
+	TotalSize     int64                   `json:"total_size"`
+	CoverImage    *models.ProjectFile     `json:"cover_image"`
+	Tags          []string                `json:"tags"`
+	Readiness     []ReadinessCheck        `json:"readiness"`
+	Ready         bool                    `json:"ready"`
+	PrintProfiles []PrintProfile          `json:"print_profiles"`
+	LargestModel  *models.ProjectFile     `json:"largest_model"`
+	Gallery       []GalleryItem           `json:"gallery"`
+
+	// Cover is what the project is shown with: an image, an embedded slicer
+	// thumbnail or a model, so projects without images have one too
+	Cover *ProjectCover `json:"cover"`
+
+	// CoverThumbnailURL is a thumbnail of the cover, a PNG render for models
+	CoverThumbnailURL string `json:"cover_thumbnail_url,omitempty"`
+
+	// LatestPrints are the project's most recent print jobs, newest first
+	LatestPrints []models.PrintJob `json:"latest_prints"`
+}
+
+// summaryPrintLimit is how many recent print jobs the summary includes
+const summaryPrintLimit = 5
+
+// GalleryItem is a project image or a photo or time-lapse of a print of the project
+type GalleryItem struct {
+	Filename string                `json:"filename"`
+	Kind     models.PrintMediaKind `json:"kind"`
+	URL      string                `json:"url"`
+
+	// ThumbnailURL is a cacheable thumbnail of project images
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+
+	// PrintJobID and PrintOutcome badge media showing a print result
+	PrintJobID   *uint               `json:"print_job_id,omitempty"`
+	PrintOutcome models.PrintOutcome `json:"print_outcome,omitempty"`
+}
+
+// GetProjectSummary returns project info, file counts, cover image, tags,
+// readiness checklist, print profiles and latest prints in a single response
+func (h *ProjectsHandler) GetProjectSummary(c *gin.Context) {
+	id := c.Param("id")
+
+	var project models.Project
+	if err := requestDB(c).Preload("Files").First(&project, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	prefs, ok := requestUnits(c)
+	if !ok {
+		return
+	}
+
+	summary := buildProjectSummary(project)
+	for i := range summary.PrintProfiles {
+		summary.PrintProfiles[i].Display = gcodeDisplay(prefs, summary.PrintProfiles[i].Metadata)
+	}
+
+	var media []models.PrintMedia
+	if err := requestDB(c).Preload("PrintJob").Where("project_id = ?", project.ID).Order("created_at ASC").Find(&media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch print media"})
+		return
+	}
+	addPrintMediaToGallery(&summary, media)
+
+	if err := requestDB(c).Where("project_id = ?", project.ID).Order("started_at DESC, id DESC").Limit(summaryPrintLimit).Find(&summary.LatestPrints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prints"})
+		return
+	}
+	for i := range summary.LatestPrints {
+		summary.LatestPrints[i].Display = printDisplay(prefs, summary.LatestPrints[i])
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// buildProjectSummary computes the summary for a project with its files loaded
+func buildProjectSummary(project models.Project) ProjectSummary {
+	files := project.Files
+	project.Files = nil
+
+	summary := ProjectSummary{
+		Project:       project,
+		FileCounts:    make(map[models.FileType]int),
+		TotalFiles:    len(files),
+		Tags:          project.Tags,
+		PrintProfiles: []PrintProfile{},
+		Gallery:       []GalleryItem{},
+		LatestPrints:  []models.PrintJob{},
+	}
+	if summary.Tags == nil {
+		summary.Tags = []string{}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+	var images []models.ProjectFile
+	for i, file := range files {
+		summary.FileCounts[file.FileType]++
+		summary.TotalSize += file.Size
+
+		switch {
+		case file.FileType == models.FileTypeSTL || file.FileType == models.FileType3MF:
+			if summary.LargestModel == nil || file.Size > summary.LargestModel.Size {
+				summary.LargestModel = &files[i]
+			}
+			if gcode.IsPlateFile(file.Filename) {
+				plates, err := gcode.ReadPlates(file.Filepath)
+				if err != nil {
+					fmt.Printf("Warning: Failed to read plates from %s: %v\n", file.Filepath, err)
+					continue
+				}
+				summary.PrintProfiles = append(summary.PrintProfiles, platesProfile(file, plates))
+			}
+		case file.FileType == models.FileTypeGCode:
+			meta, err := readGCodeMetadata(file)
+			if err != nil {
+				fmt.Printf("Warning: Failed to read G-code metadata from %s: %v\n", file.Filepath, err)
+				continue
+			}
+			summary.PrintProfiles = append(summary.PrintProfiles, PrintProfile{
+				FileID:   file.ID,
+				Filename: file.Filename,
+				Metadata: meta,
+			})
+		case models.IsImageFile(file.Filename):
+			images = append(images, file)
+		}
+	}
+	summary.Cover = pickCover(project, files)
+	summary.CoverImage = pickCoverImage(images)
+	if summary.Cover != nil {
+		summary.CoverThumbnailURL = summary.Cover.ThumbnailURL
+		if summary.Cover.Source == CoverSourceImage {
+			for i := range images {
+				if images[i].ID == summary.Cover.FileID {
+					summary.CoverImage = &images[i]
+				}
+			}
+		}
+	}
+	for _, image := range images {
+		summary.Gallery = append(summary.Gallery, GalleryItem{
+			Filename:     image.Filename,
+			Kind:         models.PrintMediaPhoto,
+			URL:          fmt.Sprintf("/api/projects/%d/files/%d/download", project.ID, image.ID),
+			ThumbnailURL: thumbnail.URL(image.Hash, thumbnail.DefaultSize),
+		})
+	}
+
+	summary.Readiness = []ReadinessCheck{
+		{Key: "models", Label: "Has STL or 3MF models", Passed: summary.LargestModel != nil},
+		{Key: "gcode", Label: "Has sliced G-code", Passed: summary.FileCounts[models.FileTypeGCode] > 0},
+		{Key: "readme", Label: "Has a README", Passed: project.Description != ""},
+		{Key: "images", Label: "Has images", Passed: len(images) > 0},
+		{Key: "license", Label: "Has a license", Passed: project.License != ""},
+		{Key: "healthy", Label: "Files match the filesystem", Passed: project.Status == models.StatusHealthy},
+	}
+	summary.Ready = true
+	for _, check := range summary.Readiness {
+		summary.Ready = summary.Ready && check.Passed
+	}
+
+	return summary
+}
+
+// platesProfile describes a sliced 3MF by its first plate's settings and the
+// estimates of all its plates
+func platesProfile(file models.ProjectFile, plates []gcode.Plate) PrintProfile {
+	profile := PrintProfile{FileID: file.ID, Filename: file.Filename, Plates: plates}
+	for i, plate := range plates {
+		if i == 0 {
+			profile.Metadata = plate.Metadata
+			continue
+		}
+		profile.PrintTimeSeconds += plate.PrintTimeSeconds
+		profile.FilamentGrams += plate.FilamentGrams
+		profile.FilamentMM += plate.FilamentMM
+	}
+	return profile
+}
+
+// addPrintMediaToGallery adds print photos and time-lapses to the gallery,
+// badging project images that are also attached to a print
+func addPrintMediaToGallery(summary *ProjectSummary, media []models.PrintMedia) {
+	byFilename := make(map[string]int, len(summary.Gallery))
+	for i, item := range summary.Gallery {
+		byFilename[item.Filename] = i
+	}
+
+	for _, attachment := range media {
+		jobID := attachment.PrintJobID
+		if i, ok := byFilename[attachment.Filename]; ok {
+			summary.Gallery[i].PrintJobID = &jobID
+			summary.Gallery[i].PrintOutcome = attachment.PrintJob.Outcome
+			continue
+		}
+
+		summary.Gallery = append(summary.Gallery, GalleryItem{
+			Filename:     attachment.Filename,
+			Kind:         attachment.Kind,
+			URL:          fmt.Sprintf("/api/prints/%d/media/%d", attachment.PrintJobID, attachment.ID),
+			PrintJobID:   &jobID,
+			PrintOutcome: attachment.PrintJob.Outcome,
+		})
+	}
+}
+
+// pickCoverImage prefers an image named like a cover or thumbnail, falling back to the first image
+func pickCoverImage(images []models.ProjectFile) *models.ProjectFile {
+	if len(images) == 0 {
+		return nil
+	}
+	for i, image := range images {
+		stem := strings.ToLower(strings.TrimSuffix(filepath.Base(image.Filename), filepath.Ext(image.Filename)))
+		if stem == "cover" || stem == "thumbnail" {
+			return &images[i]
+		}
+	}
+	return &images[0]
+}
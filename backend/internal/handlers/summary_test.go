@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetProjectSummary tests the aggregate project summary endpoint
+func TestGetProjectSummary(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+	router.GET("/api/projects/:id/summary", handler.GetProjectSummary)
+
+	project := models.Project{Name: "Gears", Path: tmpDir, Description: "# Gears", Tags: []string{"mechanical"}}
+	db.Create(&project)
+
+	files := map[string]string{
+		"gear_small.stl": "solid small",
+		"gear_large.stl": "solid large gear with more bytes",
+		"gears.gcode":    "; nozzle_diameter = 0.4\n; filament_type = PLA\nG28\n",
+		"README.md":      "# Gears",
+		"photo.png":      "PNG",
+		"cover.jpg":      "JPEG",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		db.Create(&models.ProjectFile{
+			ProjectID: project.ID,
+			Filename:  name,
+			Filepath:  path,
+			Size:      int64(len(content)),
+			FileType:  models.GetFileTypeFromExtension(name),
+		})
+	}
+
+	started := time.Now().Add(-24 * time.Hour)
+	for i := 0; i < summaryPrintLimit+2; i++ {
+		db.Create(&models.PrintJob{ProjectID: project.ID, Outcome: models.PrintSucceeded, Notes: fmt.Sprintf("print %d", i), StartedAt: started.Add(time.Duration(i) * time.Hour)})
+	}
+	other := models.Project{Name: "Other", Path: t.TempDir()}
+	db.Create(&other)
+	db.Create(&models.PrintJob{ProjectID: other.ID, Outcome: models.PrintFailed, StartedAt: time.Now()})
+
+	t.Run("Aggregates", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/1/summary", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var summary ProjectSummary
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		if summary.Project.Name != "Gears" || summary.Project.Files != nil {
+			t.Errorf("Expected project without embedded files, got %+v", summary.Project)
+		}
+		if summary.TotalFiles != 6 || summary.FileCounts[models.FileTypeSTL] != 2 {
+			t.Errorf("Unexpected file counts: total=%d counts=%v", summary.TotalFiles, summary.FileCounts)
+		}
+		if summary.CoverImage == nil || summary.CoverImage.Filename != "cover.jpg" {
+			t.Errorf("Expected cover.jpg as cover image, got %+v", summary.CoverImage)
+		}
+		if summary.LargestModel == nil || summary.LargestModel.Filename != "gear_large.stl" {
+			t.Errorf("Expected gear_large.stl as largest model, got %+v", summary.LargestModel)
+		}
+		if len(summary.PrintProfiles) != 1 || summary.PrintProfiles[0].Material != "PLA" {
+			t.Errorf("Expected one PLA print profile, got %+v", summary.PrintProfiles)
+		}
+		if len(summary.Tags) != 1 || summary.Tags[0] != "mechanical" {
+			t.Errorf("Expected tags [mechanical], got %v", summary.Tags)
+		}
+
+		if len(summary.LatestPrints) != summaryPrintLimit || summary.LatestPrints[0].Notes != fmt.Sprintf("print %d", summaryPrintLimit+1) {
+			t.Errorf("Expected the %d latest prints of the project, newest first, got %+v", summaryPrintLimit, summary.LatestPrints)
+		}
+		for _, job := range summary.LatestPrints {
+			if job.ProjectID != project.ID {
+				t.Errorf("Expected only prints of the project, got %+v", job)
+			}
+		}
+
+		// Everything but the license is in place
+		if summary.Ready {
+			t.Error("Expected project not to be ready without a license")
+		}
+		for _, check := range summary.Readiness {
+			if check.Passed != (check.Key != "license") {
+				t.Errorf("Unexpected readiness for %s: %v", check.Key, check.Passed)
+			}
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/projects/999/summary", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
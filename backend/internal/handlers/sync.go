@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// syncCatalogueName is the archive entry holding the catalogue metadata
+	syncCatalogueName = "catalogue.json"
+
+	// maxSyncThumbnailBytes leaves larger cover images out of sync archives,
+	// keeping them small enough to fetch over a phone connection
+	maxSyncThumbnailBytes = 2 << 20
+)
+
+// SyncHandler handles offline sync HTTP requests
+type SyncHandler struct{}
+
+// NewSyncHandler creates a new SyncHandler
+func NewSyncHandler() *SyncHandler {
+	return &SyncHandler{}
+}
+
+// SyncCatalogue is the catalogue.json entry of a sync archive
+type SyncCatalogue struct {
+	// Cursor is passed to the delta endpoint to fetch what changed after this archive
+	Cursor      string    `json:"cursor"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Full is set for snapshots, whose projects replace everything the client holds
+	Full bool `json:"full"`
+
+	// Projects are the projects added or changed, each with its complete file list
+	Projects []SyncProject `json:"projects"`
+
+	// DeletedProjectIDs lists projects removed since the cursor; empty in snapshots
+	DeletedProjectIDs []uint `json:"deleted_project_ids"`
+}
+
+// SyncProject is a project's catalogue metadata for offline browsing
+type SyncProject struct {
+	ID          uint                 `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Status      models.ProjectStatus `json:"status"`
+	Tags        []string             `json:"tags"`
+	License     string               `json:"license,omitempty"`
+	Designer    string               `json:"designer,omitempty"`
+	Source      string               `json:"source,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+
+	// Thumbnail is the archive entry of the project's cover image, if it has one
+	Thumbnail string `json:"thumbnail,omitempty"`
+
+	Files []SyncFile `json:"files"`
+}
+
+// SyncFile describes a project file; the file itself is downloaded separately
+type SyncFile struct {
+	ID        uint            `json:"id"`
+	Filename  string          `json:"filename"`
+	FileType  models.FileType `json:"file_type"`
+	Size      int64           `json:"size"`
+	Hash      string          `json:"hash,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	// Path is the file's slash-separated path in the project directory
+	Path string `json:"path"`
+}
+
+// GetSnapshot streams a ZIP with the whole catalogue's metadata and project
+// cover thumbnails, but no model files, for clients browsing offline
+func (h *SyncHandler) GetSnapshot(c *gin.Context) {
+	h.writeArchive(c, nil)
+}
+
+// GetDelta streams an archive shaped like the snapshot with only the
+// projects added, changed or deleted since ?cursor=
+func (h *SyncHandler) GetDelta(c *gin.Context) {
+	since, err := parseSyncCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sync cursor", "details": err.Error()})
+		return
+	}
+	h.writeArchive(c, &since)
+}
+
+// writeArchive builds the catalogue of projects changed after since, or of
+// every project when since is nil, and streams it with thumbnails
+func (h *SyncHandler) writeArchive(c *gin.Context, since *time.Time) {
+	db := requestDB(c)
+
+	// Taken before querying, so changes made while the archive is built show up in the next delta
+	generatedAt := time.Now()
+
+	catalogue := SyncCatalogue{
+		Cursor:            formatSyncCursor(generatedAt),
+		GeneratedAt:       generatedAt,
+		Full:              since == nil,
+		Projects:          []SyncProject{},
+		DeletedProjectIDs: []uint{},
+	}
+
+	query := db.Preload("Files").Order("id")
+	if since != nil {
+		// File changes without a project update still count, so the client gets the new file list
+		query = query.Where("updated_at > ? OR id IN (?)", *since,
+			db.Model(&models.ProjectFile{}).Select("project_id").Where("updated_at > ?", *since))
+	}
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		return
+	}
+
+	if since != nil {
+		if err := db.Unscoped().Model(&models.Project{}).Where("deleted_at > ?", *since).Order("id").
+			Pluck("id", &catalogue.DeletedProjectIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted projects"})
+			return
+		}
+	}
+
+	thumbnails := make(map[string]string)
+	for _, project := range projects {
+		syncProject := newSyncProject(project)
+		if cover := syncThumbnail(project.Files); cover != nil {
+			syncProject.Thumbnail = fmt.Sprintf("thumbnails/%d%s", project.ID, strings.ToLower(filepath.Ext(cover.Filename)))
+			thumbnails[syncProject.Thumbnail] = cover.Filepath
+		}
+		catalogue.Projects = append(catalogue.Projects, syncProject)
+	}
+
+	name := "3dshelf-snapshot.zip"
+	if since != nil {
+		name = "3dshelf-delta.zip"
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	c.Header("Cache-Control", "no-store")
+
+	zipWriter := zip.NewWriter(c.Writer)
+	defer zipWriter.Close()
+
+	entry, err := zipWriter.Create(syncCatalogueName)
+	if err == nil {
+		err = json.NewEncoder(entry).Encode(catalogue)
+	}
+	if err != nil {
+		fmt.Printf("Error creating sync archive: %v\n", err)
+		return
+	}
+
+	// Thumbnails are already compressed images; storing them saves the CPU
+	for _, project := range catalogue.Projects {
+		if project.Thumbnail == "" {
+			continue
+		}
+		if err := zipThumbnail(zipWriter, project.Thumbnail, thumbnails[project.Thumbnail]); err != nil {
+			fmt.Printf("Warning: Failed to add thumbnail for project %d to sync archive: %v\n", project.ID, err)
+		}
+	}
+}
+
+// newSyncProject copies a project's catalogue metadata, with its files in filename order
+func newSyncProject(project models.Project) SyncProject {
+	syncProject := SyncProject{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		Status:      project.Status,
+		Tags:        project.Tags,
+		License:     project.License,
+		Designer:    project.Designer,
+		Source:      project.Source,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+		Files:       make([]SyncFile, 0, len(project.Files)),
+	}
+	if syncProject.Tags == nil {
+		syncProject.Tags = []string{}
+	}
+	for _, file := range project.Files {
+		syncProject.Files = append(syncProject.Files, SyncFile{
+			ID:        file.ID,
+			Filename:  file.Filename,
+			Path:      syncFilePath(project, file),
+			FileType:  file.FileType,
+			Size:      file.Size,
+			Hash:      file.Hash,
+			UpdatedAt: file.UpdatedAt,
+		})
+	}
+	sort.Slice(syncProject.Files, func(i, j int) bool { return syncProject.Files[i].Filename < syncProject.Files[j].Filename })
+	return syncProject
+}
+
+// syncFilePath is where a file sits in its project directory, or its name for
+// files outside it such as a flat project's
+func syncFilePath(project models.Project, file models.ProjectFile) string {
+	rel, err := filepath.Rel(project.Path, file.Filepath)
+	if err != nil || project.IsFlat() || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return file.Filename
+	}
+	return filepath.ToSlash(rel)
+}
+
+// syncThumbnail picks the cover image to ship with a project, leaving out
+// images too large for a sync archive
+func syncThumbnail(files []models.ProjectFile) *models.ProjectFile {
+	var images []models.ProjectFile
+	for _, file := range files {
+		if models.IsImageFile(file.Filename) && file.Size <= maxSyncThumbnailBytes {
+			images = append(images, file)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Filename < images[j].Filename })
+	return pickCoverImage(images)
+}
+
+// zipThumbnail stores the image at path in the archive under name
+func zipThumbnail(zipWriter *zip.Writer, name, path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	entry, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, io.LimitReader(source, maxSyncThumbnailBytes))
+	return err
+}
+
+// formatSyncCursor encodes the time an archive was generated as a cursor
+func formatSyncCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// parseSyncCursor decodes a cursor from a previous sync archive
+func parseSyncCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, fmt.Errorf("cursor is required; fetch a snapshot first")
+	}
+	nanos, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || nanos <= 0 {
+		return time.Time{}, fmt.Errorf("malformed cursor %q", cursor)
+	}
+	return time.Unix(0, nanos), nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readSyncArchive returns the catalogue and entry contents of a sync archive
+func readSyncArchive(t *testing.T, w *httptest.ResponseRecorder) (SyncCatalogue, map[string][]byte) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	reader, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+
+	entries := make(map[string][]byte)
+	for _, file := range reader.File {
+		rc, _ := file.Open()
+		entries[file.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	var catalogue SyncCatalogue
+	if err := json.Unmarshal(entries[syncCatalogueName], &catalogue); err != nil {
+		t.Fatalf("Failed to parse catalogue: %v", err)
+	}
+	return catalogue, entries
+}
+
+// TestSync tests the offline snapshot and the deltas following it
+func TestSync(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewSyncHandler()
+	router.GET("/api/sync/snapshot", handler.GetSnapshot)
+	router.GET("/api/sync/delta", handler.GetDelta)
+
+	addFile := func(project models.Project, name, content string) models.ProjectFile {
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, Size: int64(len(content)), FileType: models.GetFileTypeFromExtension(name)}
+		db.Create(&file)
+		return file
+	}
+
+	gears := models.Project{Name: "Gears", Path: filepath.Join(tmpDir, "gears"), Tags: []string{"mechanical"}}
+	clips := models.Project{Name: "Clips", Path: filepath.Join(tmpDir, "clips")}
+	stand := models.Project{Name: "Stand", Path: filepath.Join(tmpDir, "stand")}
+	db.Create(&gears)
+	db.Create(&clips)
+	db.Create(&stand)
+	addFile(gears, "gear.stl", "solid gear")
+	addFile(gears, "cover.png", "PNG cover")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	snapshot, entries := readSyncArchive(t, get("/api/sync/snapshot"))
+
+	t.Run("Snapshot", func(t *testing.T) {
+		if !snapshot.Full || len(snapshot.Projects) != 3 || snapshot.Cursor == "" {
+			t.Fatalf("Unexpected snapshot: %+v", snapshot)
+		}
+		first := snapshot.Projects[0]
+		if first.Name != "Gears" || len(first.Files) != 2 || first.Files[0].Filename != "cover.png" {
+			t.Errorf("Unexpected project in snapshot: %+v", first)
+		}
+		// The files sit outside the project directory, so their names stand in for paths
+		if first.Files[1].Path != "gear.stl" {
+			t.Errorf("Expected the file name as its path, got %q", first.Files[1].Path)
+		}
+		nested := syncFilePath(gears, models.ProjectFile{Filename: "hull.stl", Filepath: filepath.Join(gears.Path, "parts", "hull.stl")})
+		if nested != "parts/hull.stl" {
+			t.Errorf("Expected the path in the project directory, got %q", nested)
+		}
+		if first.Thumbnail == "" || string(entries[first.Thumbnail]) != "PNG cover" {
+			t.Errorf("Expected cover thumbnail in archive, got %q", first.Thumbnail)
+		}
+		if _, ok := entries["gear.stl"]; ok || len(entries) != 2 {
+			t.Errorf("Expected only the catalogue and thumbnail, got %d entries", len(entries))
+		}
+		if snapshot.Projects[1].Thumbnail != "" {
+			t.Errorf("Expected no thumbnail for a project without images")
+		}
+	})
+
+	t.Run("Delta", func(t *testing.T) {
+		db.Model(&clips).Update("name", "Cable Clips")
+		addFile(stand, "stand.3mf", "3MF")
+		db.Delete(&gears)
+
+		delta, _ := readSyncArchive(t, get("/api/sync/delta?cursor="+snapshot.Cursor))
+		if delta.Full || len(delta.Projects) != 2 || delta.Projects[0].Name != "Cable Clips" || len(delta.Projects[1].Files) != 1 {
+			t.Errorf("Unexpected changed projects: %+v", delta.Projects)
+		}
+		if len(delta.DeletedProjectIDs) != 1 || delta.DeletedProjectIDs[0] != gears.ID {
+			t.Errorf("Expected project %d deleted, got %v", gears.ID, delta.DeletedProjectIDs)
+		}
+
+		unchanged, _ := readSyncArchive(t, get("/api/sync/delta?cursor="+delta.Cursor))
+		if len(unchanged.Projects) != 0 || len(unchanged.DeletedProjectIDs) != 0 {
+			t.Errorf("Expected an empty delta, got %+v", unchanged)
+		}
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		for _, query := range []string{"", "?cursor=yesterday"} {
+			if w := get("/api/sync/delta" + query); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+			}
+		}
+	})
+}
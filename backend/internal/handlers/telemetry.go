@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"3dshelf/pkg/telemetry"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetTelemetry lets the admin API preview the anonymous usage report
+func (h *AdminHandler) SetTelemetry(reporter *telemetry.Reporter) {
+	h.telemetry = reporter
+}
+
+// GetTelemetry returns whether usage reports are sent and exactly what the
+// next one contains, so it can be reviewed before opting in
+func (h *AdminHandler) GetTelemetry(c *gin.Context) {
+	if h.telemetry == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	status, err := h.telemetry.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build usage report"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
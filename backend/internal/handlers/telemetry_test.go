@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"3dshelf/pkg/features"
+	"3dshelf/pkg/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetTelemetry tests previewing the usage report before opting in
+func TestGetTelemetry(t *testing.T) {
+	db := setupTestDB(t)
+	createTestData(t, db)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminHandler := NewAdminHandler(nil)
+	adminHandler.SetTelemetry(telemetry.New(db, features.New()))
+	router.GET("/api/admin/telemetry", adminHandler.GetTelemetry)
+
+	w := sendJSON(router, "GET", "/api/admin/telemetry", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var status telemetry.Status
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to parse status: %v", err)
+	}
+	if status.Enabled || status.Report.InstanceID == "" || status.Report.Projects != "1-9" {
+		t.Errorf("Expected a preview of a disabled report, got %+v", status)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/thumbnail"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// immutableCacheControl lets browsers keep thumbnails without revalidating:
+// their URL carries the content hash of the image, so it changes with it
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// ThumbnailsHandler serves generated thumbnails of project images and of the
+// thumbnails slicers embed in G-code and 3MF files
+type ThumbnailsHandler struct {
+	cache *thumbnail.Cache
+}
+
+// NewThumbnailsHandler creates a new ThumbnailsHandler keeping thumbnails in cache
+func NewThumbnailsHandler(cache *thumbnail.Cache) *ThumbnailsHandler {
+	return &ThumbnailsHandler{cache: cache}
+}
+
+// GetThumbnail serves the thumbnail of the project image, or G-code or 3MF
+// file, with the given content hash at the given size, generating it on first
+// request
+func (h *ThumbnailsHandler) GetThumbnail(c *gin.Context) {
+	hash := c.Param("hash")
+	size, err := strconv.Atoi(c.Param("size"))
+	if err != nil || !thumbnail.ValidSize(size) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thumbnail size", "sizes": thumbnail.Sizes})
+		return
+	}
+	if !thumbnail.ValidHash(hash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+		return
+	}
+
+	var files []models.ProjectFile
+	if err := requestDB(c).Where("hash = ?", hash).Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up image", "details": err.Error()})
+		return
+	}
+
+	for _, file := range files {
+		if !hasThumbnail(file) {
+			continue
+		}
+		if _, err := os.Stat(file.Filepath); err != nil {
+			continue
+		}
+
+		path, err := h.cache.Get(file.Filepath, hash, size)
+		if err != nil {
+			fmt.Printf("Warning: Failed to generate thumbnail of %s: %v\n", file.Filepath, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to generate thumbnail", "details": err.Error()})
+			return
+		}
+		c.Header("Cache-Control", immutableCacheControl)
+		c.Header("ETag", fmt.Sprintf(`"%s-%d"`, hash, size))
+		c.File(path)
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+}
+
+// GetFileThumbnail serves the thumbnail of a project file at ?size=256: of
+// the thumbnail its slicer embedded for G-code and 3MF files, or of the image
+// itself. Thumbnails of files with a content hash are served from the cache,
+// extracted by scans or on first request; the others are generated every time.
+func (h *ThumbnailsHandler) GetFileThumbnail(c *gin.Context) {
+	size := thumbnail.DefaultSize
+	if raw := c.Query("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || !thumbnail.ValidSize(parsed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thumbnail size", "sizes": thumbnail.Sizes})
+			return
+		}
+		size = parsed
+	}
+
+	var file models.ProjectFile
+	if err := requestDB(c).Where("id = ? AND project_id = ?", c.Param("fileId"), c.Param("id")).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if !hasThumbnail(file) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only images, G-code and 3MF files have thumbnails"})
+		return
+	}
+	if _, err := os.Stat(file.Filepath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on filesystem"})
+		return
+	}
+
+	if !thumbnail.ValidHash(file.Hash) {
+		var buf bytes.Buffer
+		if err := thumbnail.Generate(&buf, file.Filepath, size); err != nil {
+			thumbnailError(c, file, err)
+			return
+		}
+		c.Data(http.StatusOK, http.DetectContentType(buf.Bytes()), buf.Bytes())
+		return
+	}
+
+	path, err := h.cache.Get(file.Filepath, file.Hash, size)
+	if err != nil {
+		thumbnailError(c, file, err)
+		return
+	}
+	c.Header("ETag", fmt.Sprintf(`"%s-%d"`, file.Hash, size))
+	c.File(path)
+}
+
+// thumbnailError reports a thumbnail that couldn't be generated: missing
+// when the file embeds none
+func thumbnailError(c *gin.Context, file models.ProjectFile, err error) {
+	if errors.Is(err, gcode.ErrNoThumbnail) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+		return
+	}
+	fmt.Printf("Warning: Failed to generate thumbnail of %s: %v\n", file.Filepath, err)
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to generate thumbnail", "details": err.Error()})
+}
+
+// LiveHashes returns the content hashes of the images, G-code and 3MF files
+// in the library, whose thumbnails the cache cleanup keeps
+func (h *ThumbnailsHandler) LiveHashes() (map[string]bool, error) {
+	var files []models.ProjectFile
+	if err := database.GetDB().Select("filename", "hash").Where("hash <> ''").Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]bool)
+	for _, file := range files {
+		if hasThumbnail(file) {
+			hashes[file.Hash] = true
+		}
+	}
+	return hashes, nil
+}
+
+// hasThumbnail reports whether thumbnails can be generated of a file: it is
+// an image or may embed one
+func hasThumbnail(file models.ProjectFile) bool {
+	return models.IsImageFile(file.Filename) || gcode.EmbedsThumbnails(file.Filename)
+}
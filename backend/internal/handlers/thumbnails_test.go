@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/thumbnail"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetThumbnail tests serving cacheable thumbnails by content hash
+func TestGetThumbnail(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewThumbnailsHandler(thumbnail.New(filepath.Join(tmpDir, "thumbnails")))
+	router.GET("/api/thumbnails/:hash/:size", handler.GetThumbnail)
+
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 600, 300)))
+	path := filepath.Join(tmpDir, "cover.png")
+	os.WriteFile(path, encoded.Bytes(), 0644)
+	hash := fmt.Sprintf("%x", sha256.Sum256(encoded.Bytes()))
+
+	project := models.Project{Name: "Gears", Path: tmpDir}
+	db.Create(&project)
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "cover.png", Filepath: path, FileType: models.FileTypeOther, Hash: hash})
+	db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "gear.stl", Filepath: path, FileType: models.FileTypeSTL, Hash: fmt.Sprintf("%064d", 1)})
+
+	url := thumbnail.URL(hash, thumbnail.DefaultSize)
+	w := sendJSON(router, "GET", url, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != immutableCacheControl {
+		t.Errorf("Expected immutable caching, got %q", got)
+	}
+	config, _, err := image.DecodeConfig(w.Body)
+	if err != nil || config.Width != 256 || config.Height != 128 {
+		t.Errorf("Expected a 256x128 thumbnail, got %dx%d (%v)", config.Width, config.Height, err)
+	}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	revalidated := httptest.NewRecorder()
+	router.ServeHTTP(revalidated, req)
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d for a matching ETag, got %d", http.StatusNotModified, revalidated.Code)
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{"Unsupported size", fmt.Sprintf("/api/thumbnails/%s/300", hash), http.StatusBadRequest},
+		{"Unknown hash", thumbnail.URL(fmt.Sprintf("%064d", 2), 128), http.StatusNotFound},
+		{"Not an image", thumbnail.URL(fmt.Sprintf("%064d", 1), 128), http.StatusNotFound},
+		{"Invalid hash", "/api/thumbnails/cover/128", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		if w := sendJSON(router, "GET", tc.path, ""); w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expectedCode, w.Code)
+		}
+	}
+
+	live, err := handler.LiveHashes()
+	if err != nil || len(live) != 1 || !live[hash] {
+		t.Errorf("Expected only the image hash to be live, got %v (%v)", live, err)
+	}
+}
+
+// TestGetFileThumbnail tests serving the thumbnail of a project file by its ID
+func TestGetFileThumbnail(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewThumbnailsHandler(thumbnail.New(filepath.Join(tmpDir, "thumbnails")))
+	router.GET("/api/projects/:id/files/:fileId/thumbnail", handler.GetFileThumbnail)
+
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 600, 300)))
+	sliced := filepath.Join(tmpDir, "benchy.gcode")
+	os.WriteFile(sliced, []byte("; thumbnail begin 600x300 0\n; "+base64.StdEncoding.EncodeToString(encoded.Bytes())+"\n; thumbnail end\nG28\n"), 0644)
+	plain := filepath.Join(tmpDir, "plain.gcode")
+	os.WriteFile(plain, []byte("G28\n"), 0644)
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	hashed := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.gcode", Filepath: sliced, FileType: models.FileTypeGCode, Hash: fmt.Sprintf("%064d", 1)}
+	unhashed := models.ProjectFile{ProjectID: project.ID, Filename: "copy.gcode", Filepath: sliced, FileType: models.FileTypeGCode}
+	bare := models.ProjectFile{ProjectID: project.ID, Filename: "plain.gcode", Filepath: plain, FileType: models.FileTypeGCode, Hash: fmt.Sprintf("%064d", 2)}
+	model := models.ProjectFile{ProjectID: project.ID, Filename: "benchy.stl", Filepath: sliced, FileType: models.FileTypeSTL}
+	for _, file := range []*models.ProjectFile{&hashed, &unhashed, &bare, &model} {
+		db.Create(file)
+	}
+	url := func(file models.ProjectFile, query string) string {
+		return fmt.Sprintf("/api/projects/%d/files/%d/thumbnail%s", project.ID, file.ID, query)
+	}
+
+	for name, tc := range map[string]struct {
+		url   string
+		width int
+	}{
+		"Cached":         {url(hashed, ""), 256},
+		"Sized":          {url(hashed, "?size=128"), 128},
+		"Without a hash": {url(unhashed, "?size=512"), 512},
+	} {
+		w := sendJSON(router, "GET", tc.url, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d: %s", name, http.StatusOK, w.Code, w.Body.String())
+			continue
+		}
+		if config, _, err := image.DecodeConfig(w.Body); err != nil || config.Width != tc.width {
+			t.Errorf("%s: expected a %dpx wide thumbnail, got %d (%v)", name, tc.width, config.Width, err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		url    string
+		status int
+	}{
+		"No embedded thumbnail": {url(bare, ""), http.StatusNotFound},
+		"Not embedding":         {url(model, ""), http.StatusBadRequest},
+		"Invalid size":          {url(hashed, "?size=100"), http.StatusBadRequest},
+		"Missing file":          {fmt.Sprintf("/api/projects/%d/files/999/thumbnail", project.ID), http.StatusNotFound},
+		"Other project":         {fmt.Sprintf("/api/projects/999/files/%d/thumbnail", hashed.ID), http.StatusNotFound},
+	} {
+		if w := sendJSON(router, "GET", tc.url, ""); w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
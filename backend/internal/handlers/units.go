@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/units"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// unitsHeader carries a client's own unit preset, letting each user override the instance preference
+const unitsHeader = "X-Units"
+
+// requestUnits returns the units the response is displayed in: the ?units= or
+// X-Units preset when given, otherwise the instance preference. It writes the
+// error response and reports false when they cannot be determined.
+func requestUnits(c *gin.Context) (units.Preferences, bool) {
+	preset := c.Query("units")
+	if preset == "" {
+		preset = c.GetHeader(unitsHeader)
+	}
+	if preset != "" {
+		prefs, err := units.Parse(preset)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return units.Preferences{}, false
+		}
+		return prefs, true
+	}
+
+	prefs, err := loadUnits(requestDB(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load unit preference"})
+		return units.Preferences{}, false
+	}
+	return prefs, true
+}
+
+// loadUnits returns the instance unit preference, metric unless saved through the admin API
+func loadUnits(db *gorm.DB) (units.Preferences, error) {
+	prefs := units.Metric
+	if _, err := database.LoadSetting(db, units.SettingKey, &prefs); err != nil {
+		return units.Metric, err
+	}
+	return prefs, prefs.Validate()
+}
+
+func filamentDisplay(prefs units.Preferences, filament models.Filament) units.Display {
+	display := units.Display{
+		"diameter_mm":     prefs.LengthOf(filament.DiameterMM),
+		"weight_grams":    prefs.MassOf(filament.WeightGrams),
+		"remaining_grams": prefs.MassOf(filament.RemainingGrams),
+	}
+	if filament.NozzleTempC != 0 {
+		display["nozzle_temp_c"] = prefs.TemperatureOf(float64(filament.NozzleTempC))
+	}
+	if filament.BedTempC != 0 {
+		display["bed_temp_c"] = prefs.TemperatureOf(float64(filament.BedTempC))
+	}
+	return display
+}
+
+func printDisplay(prefs units.Preferences, job models.PrintJob) units.Display {
+	return units.Display{"filament_grams": prefs.MassOf(job.FilamentGrams)}
+}
+
+func calibrationDisplay(prefs units.Preferences, calibration models.Calibration) units.Display {
+	display := units.Display{}
+	if calibration.NozzleTempC != nil {
+		display["nozzle_temp_c"] = prefs.TemperatureOf(float64(*calibration.NozzleTempC))
+	}
+	return display
+}
+
+func fileProfileDisplay(prefs units.Preferences, profile models.FileProfile) units.Display {
+	display := units.Display{}
+	if profile.LayerHeight != nil {
+		display["layer_height"] = prefs.LengthOf(*profile.LayerHeight)
+	}
+	return display
+}
+
+func gcodeDisplay(prefs units.Preferences, meta gcode.Metadata) units.Display {
+	display := units.Display{}
+	if meta.NozzleDiameter != 0 {
+		display["nozzle_diameter"] = prefs.LengthOf(meta.NozzleDiameter)
+	}
+	if meta.LayerHeight != 0 {
+		display["layer_height"] = prefs.LengthOf(meta.LayerHeight)
+	}
+	if meta.NozzleTemp != 0 {
+		display["nozzle_temp"] = prefs.TemperatureOf(meta.NozzleTemp)
+	}
+	if meta.BedTemp != 0 {
+		display["bed_temp"] = prefs.TemperatureOf(meta.BedTemp)
+	}
+	return display
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/scanner"
+	"3dshelf/pkg/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUnitPreferences tests displaying quantities in the instance or requested units
+func TestUnitPreferences(t *testing.T) {
+	db := setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := NewAdminHandler(scanner.New(db, t.TempDir()))
+	router.PUT("/api/admin/settings", admin.UpdateSettings)
+	filaments := NewFilamentsHandler()
+	router.GET("/api/filaments/:id", filaments.GetFilament)
+	prints := NewPrintsHandler()
+	router.GET("/api/prints", prints.GetPrints)
+
+	db.Create(&models.Filament{Name: "Galaxy Black", Material: "PETG", DiameterMM: 1.75, NozzleTempC: 245, WeightGrams: 1000})
+	db.Create(&models.Project{Name: "Benchy", Path: "/test/benchy"})
+	job := models.PrintJob{ProjectID: 1, FilamentGrams: 56.7}
+	job.Validate()
+	db.Create(&job)
+
+	getFilament := func(units string) models.Filament {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/filaments/1", nil)
+		if units != "" {
+			req.Header.Set("X-Units", units)
+		}
+		router.ServeHTTP(w, req)
+		var filament models.Filament
+		json.Unmarshal(w.Body.Bytes(), &filament)
+		return filament
+	}
+
+	filament := getFilament("")
+	if filament.Display["nozzle_temp_c"].Text != "245 °C" || filament.Display["weight_grams"].Unit != "g" {
+		t.Errorf("Expected metric display by default, got %+v", filament.Display)
+	}
+	if _, ok := filament.Display["bed_temp_c"]; ok {
+		t.Error("Expected unset temperatures to be left out")
+	}
+
+	filament = getFilament("imperial")
+	if filament.NozzleTempC != 245 || filament.Display["nozzle_temp_c"].Text != "473 °F" || filament.Display["diameter_mm"].Unit != "in" {
+		t.Errorf("Expected raw values with an imperial display, got %+v", filament)
+	}
+
+	// The instance preference may mix units
+	if w := sendJSON(router, "PUT", "/api/admin/settings", `{"units": {"mass": "oz"}}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w := sendJSON(router, "GET", "/api/prints", "")
+	var history struct {
+		Prints []models.PrintJob `json:"prints"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history.Prints) != 1 || history.Prints[0].Display["filament_grams"] != (units.Value{Value: 2, Unit: "oz", Text: "2 oz"}) {
+		t.Errorf("Expected the instance preference to apply, got %s", w.Body.String())
+	}
+	if display := getFilament("").Display; display["weight_grams"].Unit != "oz" || display["diameter_mm"].Unit != "mm" {
+		t.Errorf("Expected mixed units, got %+v", display)
+	}
+
+	// A request may still ask for metric
+	w = sendJSON(router, "GET", "/api/prints?units=metric", "")
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if history.Prints[0].Display["filament_grams"].Text != "56.7 g" {
+		t.Errorf("Expected ?units= to override the instance preference, got %+v", history.Prints[0].Display)
+	}
+
+	if w := sendJSON(router, "GET", "/api/prints?units=nautical", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown units, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := sendJSON(router, "PUT", "/api/admin/settings", `{"units": {"length": "ft"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown length unit, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"fmt"
+	"mime/multipart"
+	"path"
+	"strings"
+)
+
+// uploadPaths returns the name each uploaded file is stored under. Folder
+// uploads send a "paths" field per file, aligned with the files, holding its
+// path relative to the dropped folder (a browser's webkitRelativePath);
+// multipart parsing keeps only base names, so without it files land at the
+// project's top level. A path that fails validation is reported in invalid,
+// and the file keeps its base name.
+func uploadPaths(form *multipart.Form, files []*multipart.FileHeader) (names []string, invalid map[int]string, err error) {
+	names = make([]string, len(files))
+	for i, fileHeader := range files {
+		names[i] = fileHeader.Filename
+	}
+
+	paths := form.Value["paths"]
+	if len(paths) == 0 {
+		return names, nil, nil
+	}
+	if len(paths) != len(files) {
+		return nil, nil, fmt.Errorf("got %d paths for %d files", len(paths), len(files))
+	}
+
+	invalid = make(map[int]string)
+	for i, relPath := range paths {
+		if relPath == "" {
+			continue
+		}
+		if err := validateUploadPath(relPath, files[i].Filename); err != nil {
+			invalid[i] = err.Error()
+			continue
+		}
+		names[i] = relPath
+	}
+	return names, invalid, nil
+}
+
+// validateUploadPath checks that a folder upload's relative path stays inside
+// the project, names the uploaded file and is not deeper than scans descend
+func validateUploadPath(relPath, filename string) error {
+	if strings.Contains(relPath, `\`) || path.IsAbs(relPath) {
+		return fmt.Errorf("invalid path %q: must be a relative path using forward slashes", relPath)
+	}
+
+	parts := strings.Split(relPath, "/")
+	for _, part := range parts {
+		// Hidden components would be skipped by scans, and ".." would leave the project
+		if part == "" || strings.HasPrefix(part, ".") {
+			return fmt.Errorf("invalid path %q: empty, relative or hidden components are not allowed", relPath)
+		}
+	}
+	if parts[len(parts)-1] != filename {
+		return fmt.Errorf("invalid path %q: does not end in the uploaded filename %s", relPath, filename)
+	}
+	if depth := len(parts) - 1; depth > models.MaxScanDepth {
+		return fmt.Errorf("invalid path %q: more than %d folders deep", relPath, models.MaxScanDepth)
+	}
+	return nil
+}
+
+// stripUploadRoot drops the top-level folder from every valid path when they
+// all share it, and returns the folder dropped
+func stripUploadRoot(names []string, invalid map[int]string) string {
+	root := ""
+	for i, name := range names {
+		if _, ok := invalid[i]; ok {
+			continue
+		}
+		first, _, nested := strings.Cut(name, "/")
+		if !nested || (root != "" && first != root) {
+			return ""
+		}
+		root = first
+	}
+	if root == "" {
+		return ""
+	}
+
+	for i, name := range names {
+		if _, ok := invalid[i]; !ok {
+			names[i] = strings.TrimPrefix(name, root+"/")
+		}
+	}
+	return root
+}
+
+// uploadDepth is how many folders deep a stored upload name is
+func uploadDepth(name string) int {
+	return strings.Count(name, "/")
+}
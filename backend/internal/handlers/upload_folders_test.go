@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestUploadFolder tests that folder uploads recreate their directory structure in the project
+func TestUploadFolder(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadFolder(router, project.ID, []string{"Benchy/benchy.stl", "Benchy/parts/hull.stl", "Benchy/parts/supports/raft.stl"}, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Counts[UploadUploaded] != 3 {
+		t.Fatalf("Expected 3 uploaded files, got %+v", response.Results)
+	}
+	for _, relPath := range []string{"Benchy/benchy.stl", "Benchy/parts/hull.stl", "Benchy/parts/supports/raft.stl"} {
+		if _, err := os.Stat(filepath.Join(project.Path, relPath)); err != nil {
+			t.Errorf("Expected %s in the project: %v", relPath, err)
+		}
+		var file models.ProjectFile
+		if err := db.Where("project_id = ? AND filename = ?", project.ID, relPath).First(&file).Error; err != nil {
+			t.Errorf("Expected a record named %s: %v", relPath, err)
+		}
+	}
+
+	var updated models.Project
+	db.First(&updated, project.ID)
+	if updated.ScanSettings.MaxDepth != 3 {
+		t.Errorf("Expected the scan depth raised to 3, got %d", updated.ScanSettings.MaxDepth)
+	}
+}
+
+// TestUploadFolderStripRoot tests dropping the folder shared by every path
+func TestUploadFolderStripRoot(t *testing.T) {
+	testCases := []struct {
+		name          string
+		paths         []string
+		expectedNames []string
+	}{
+		{name: "Shared root", paths: []string{"Benchy/benchy.stl", "Benchy/parts/hull.stl"}, expectedNames: []string{"benchy.stl", "parts/hull.stl"}},
+		{name: "Different roots", paths: []string{"Benchy/benchy.stl", "Boat/hull.stl"}, expectedNames: []string{"Benchy/benchy.stl", "Boat/hull.stl"}},
+		{name: "Top-level file", paths: []string{"Benchy/benchy.stl", "hull.stl"}, expectedNames: []string{"Benchy/benchy.stl", "hull.stl"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tmpDir := t.TempDir()
+			router := setupUploadSessionRouter(tmpDir)
+			project, _ := createUploadTestProject(t, db, tmpDir)
+
+			w := uploadFolder(router, project.ID, tc.paths, map[string]string{"strip_root": "true"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var response UploadResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			for i, name := range tc.expectedNames {
+				if response.Results[i].Filename != name {
+					t.Errorf("Expected %s stored as %s, got %s", tc.paths[i], name, response.Results[i].Filename)
+				}
+			}
+		})
+	}
+}
+
+// TestUploadFolderInvalidPaths tests that paths escaping the project or not naming the file are rejected
+func TestUploadFolderInvalidPaths(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{name: "Parent directory", path: "../escape.stl"},
+		{name: "Nested parent directory", path: "Benchy/../../escape.stl"},
+		{name: "Absolute", path: "/tmp/escape.stl"},
+		{name: "Backslashes", path: `Benchy\escape.stl`},
+		{name: "Hidden folder", path: ".git/escape.stl"},
+		{name: "Empty component", path: "Benchy//escape.stl"},
+		{name: "Different filename", path: "Benchy/other.stl"},
+		{name: "Too deep", path: strings.Repeat("d/", models.MaxScanDepth+1) + "escape.stl"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateUploadPath(tc.path, "escape.stl")
+			if err == nil {
+				t.Errorf("Expected %q to be rejected", tc.path)
+			}
+		})
+	}
+
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadFolder(router, project.ID, []string{"Benchy/benchy.stl", "../escape.stl"}, map[string]string{"commit_mode": "per_file"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Results[1].Status != UploadFailed || response.Results[1].Reason != ReasonInvalidPath {
+		t.Errorf("Expected the escaping path to fail, got %+v", response.Results[1])
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "escape.stl")); !os.IsNotExist(err) {
+		t.Error("The escaping file must not be written outside the project")
+	}
+}
+
+// TestUploadFolderPathCount tests that paths must line up with the files
+func TestUploadFolderPathCount(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID, map[string]string{"a.stl": "a", "b.stl": "b"}, map[string]string{"paths": "Benchy/a.stl"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// uploadFolder uploads one file per relative path, in order, as a browser folder upload does
+func uploadFolder(router http.Handler, projectID uint, paths []string, values map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, relPath := range paths {
+		part, _ := writer.CreateFormFile("files", filepath.Base(relPath))
+		part.Write([]byte("content of " + relPath))
+		writer.WriteField("paths", relPath)
+	}
+	for key, value := range values {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/projects/"+strconv.Itoa(int(projectID))+"/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UploadIDHeader carries the client-chosen ID an upload's progress is reported under
+	UploadIDHeader = "X-Upload-ID"
+
+	maxUploadIDLength = 128
+
+	// uploadProgressRetention is how long a finished upload's progress stays
+	// readable, so the last poll sees how it ended
+	uploadProgressRetention = 5 * time.Minute
+)
+
+// UploadState is the phase of an upload being tracked
+type UploadState string
+
+const (
+	// UploadReceiving is an upload whose body is still arriving
+	UploadReceiving UploadState = "receiving"
+	// UploadProcessing is an upload received in full whose files are being staged and committed
+	UploadProcessing UploadState = "processing"
+	// UploadCompleted is an upload that applied at least one file
+	UploadCompleted UploadState = "completed"
+	// UploadFailedState is an upload that applied nothing
+	UploadFailedState UploadState = "failed"
+)
+
+// UploadProgress reports how much of an upload the server has received
+type UploadProgress struct {
+	UploadID      string      `json:"upload_id"`
+	ProjectID     uint        `json:"project_id"`
+	State         UploadState `json:"state"`
+	ReceivedBytes int64       `json:"received_bytes"`
+	// TotalBytes is the request's Content-Length, or -1 when the client did not send one
+	TotalBytes int64 `json:"total_bytes"`
+	// Percent is set when the total is known
+	Percent        *float64   `json:"percent,omitempty"`
+	BytesPerSecond float64    `json:"bytes_per_second"`
+	StatusCode     int        `json:"status_code,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// trackedUpload is the progress of one upload; received is updated as its body is read
+type trackedUpload struct {
+	received atomic.Int64
+
+	// Guarded by uploadTracker.mu
+	progress UploadProgress
+}
+
+// uploadTracker keeps the progress of in-flight uploads in memory, so it is
+// only visible on the instance receiving the upload
+type uploadTracker struct {
+	mu      sync.Mutex
+	uploads map[string]*trackedUpload
+}
+
+// start begins tracking an upload under id, reporting false when an upload
+// with the id is still in flight
+func (t *uploadTracker) start(id string, projectID uint, total int64) (*trackedUpload, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(time.Now())
+	if t.uploads == nil {
+		t.uploads = make(map[string]*trackedUpload)
+	}
+	if existing, ok := t.uploads[id]; ok && existing.progress.FinishedAt == nil {
+		return nil, false
+	}
+
+	upload := &trackedUpload{progress: UploadProgress{
+		UploadID:   id,
+		ProjectID:  projectID,
+		State:      UploadReceiving,
+		TotalBytes: total,
+		StartedAt:  time.Now(),
+	}}
+	t.uploads[id] = upload
+	return upload, true
+}
+
+// setState moves an upload to the next phase
+func (t *uploadTracker) setState(upload *trackedUpload, state UploadState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	upload.progress.State = state
+}
+
+// finish records how an upload ended
+func (t *uploadTracker) finish(upload *trackedUpload, statusCode int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	upload.progress.FinishedAt = &now
+	upload.progress.StatusCode = statusCode
+	upload.progress.State = UploadFailedState
+	if statusCode >= 200 && statusCode < 300 {
+		upload.progress.State = UploadCompleted
+	}
+}
+
+// get returns a snapshot of an upload's progress
+func (t *uploadTracker) get(id string) (UploadProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.expire(now)
+	upload, ok := t.uploads[id]
+	if !ok {
+		return UploadProgress{}, false
+	}
+
+	progress := upload.progress
+	progress.ReceivedBytes = upload.received.Load()
+	if progress.TotalBytes > 0 {
+		percent := min(100, float64(progress.ReceivedBytes)*100/float64(progress.TotalBytes))
+		progress.Percent = &percent
+	}
+	end := now
+	if progress.FinishedAt != nil {
+		end = *progress.FinishedAt
+	}
+	if elapsed := end.Sub(progress.StartedAt).Seconds(); elapsed > 0 {
+		progress.BytesPerSecond = float64(progress.ReceivedBytes) / elapsed
+	}
+	return progress, true
+}
+
+// expire forgets uploads that finished longer than the retention ago; t.mu must be held
+func (t *uploadTracker) expire(now time.Time) {
+	for id, upload := range t.uploads {
+		if finished := upload.progress.FinishedAt; finished != nil && now.Sub(*finished) > uploadProgressRetention {
+			delete(t.uploads, id)
+		}
+	}
+}
+
+// countingBody counts the bytes read from a request body into an upload's progress
+type countingBody struct {
+	io.ReadCloser
+	upload *trackedUpload
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.upload.received.Add(int64(n))
+	return n, err
+}
+
+// trackUpload starts reporting the progress of an upload sent with an
+// X-Upload-ID header or upload_id query parameter. It returns nil when the
+// client asked for no tracking, and writes the error response and reports
+// false when the ID is invalid or in use.
+func (h *ProjectsHandler) trackUpload(c *gin.Context, projectID uint) (*trackedUpload, bool) {
+	id := c.GetHeader(UploadIDHeader)
+	if id == "" {
+		id = c.Query("upload_id")
+	}
+	if id == "" {
+		return nil, true
+	}
+	if len(id) > maxUploadIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+		return nil, false
+	}
+
+	upload, ok := h.uploads.start(id, projectID, c.Request.ContentLength)
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "An upload with this ID is already in progress"})
+		return nil, false
+	}
+	c.Request.Body = &countingBody{ReadCloser: c.Request.Body, upload: upload}
+	return upload, true
+}
+
+// GetUploadProgress reports how much of an in-flight upload has been
+// received, and how recently finished uploads ended
+func (h *ProjectsHandler) GetUploadProgress(c *gin.Context) {
+	progress, ok := h.uploads.get(c.Param("sessionId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestUploadProgress tests reporting bytes received while an upload is in flight
+func TestUploadProgress(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("files", "large.gcode")
+	part.Write(bytes.Repeat([]byte("G1 X10 Y10\n"), 10000))
+	writer.Close()
+	payload := body.Bytes()
+
+	getProgress := func(id string) (UploadProgress, int) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/uploads/"+id+"/progress", nil)
+		router.ServeHTTP(w, req)
+		var progress UploadProgress
+		json.Unmarshal(w.Body.Bytes(), &progress)
+		return progress, w.Code
+	}
+	upload := func(id string, body io.Reader) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/projects/"+strconv.Itoa(int(project.ID))+"/files", body)
+		req.ContentLength = int64(len(payload))
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set(UploadIDHeader, id)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Send half the body and look at the upload while it is still arriving
+	reader, pipe := io.Pipe()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- upload("upload-1", reader) }()
+	half := len(payload) / 2
+	pipe.Write(payload[:half])
+
+	// The reader counts a chunk just after the pipe hands it over
+	progress, code := getProgress("upload-1")
+	for deadline := time.Now().Add(5 * time.Second); progress.ReceivedBytes < int64(half) && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		progress, code = getProgress("upload-1")
+	}
+	if code != http.StatusOK || progress.State != UploadReceiving {
+		t.Fatalf("Expected a receiving upload, got %d %+v", code, progress)
+	}
+	if progress.ReceivedBytes < int64(half) || progress.TotalBytes != int64(len(payload)) || progress.Percent == nil {
+		t.Errorf("Expected at least %d of %d bytes received, got %+v", half, len(payload), progress)
+	}
+
+	if w := upload("upload-1", bytes.NewReader(payload)); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 reusing the ID of an upload in flight, got %d", w.Code)
+	}
+
+	pipe.Write(payload[half:])
+	pipe.Close()
+	select {
+	case w := <-done:
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected the upload to succeed, got %d: %s", w.Code, w.Body.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Upload did not finish")
+	}
+
+	progress, _ = getProgress("upload-1")
+	if progress.State != UploadCompleted || progress.ReceivedBytes != int64(len(payload)) || *progress.Percent != 100 {
+		t.Errorf("Expected a completed upload with every byte received, got %+v", progress)
+	}
+	if progress.StatusCode != http.StatusOK || progress.FinishedAt == nil {
+		t.Errorf("Expected the final status to be recorded, got %+v", progress)
+	}
+
+	if _, code := getProgress("unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown upload, got %d", code)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+)
+
+// maxResolutionsSize bounds the JSON resolutions part of an upload
+const maxResolutionsSize = 1 << 20
+
+// legacyResolutionPrefix names the per-file form fields older clients send
+// instead of the resolutions part, as resolution_<filename>
+const legacyResolutionPrefix = "resolution_"
+
+// Valid reports whether r is a known resolution
+func (r ConflictResolution) Valid() bool {
+	switch r {
+	case ConflictOverwrite, ConflictSkip, ConflictRename:
+		return true
+	}
+	return false
+}
+
+// UploadResolutions is the JSON "resolutions" part of an upload: how to
+// handle conflicting files, by filename and for every file not listed
+type UploadResolutions struct {
+	// Files maps uploaded filenames to their resolution
+	Files map[string]ConflictResolution `json:"files,omitempty"`
+
+	// All applies to every conflicting file not listed in Files
+	All ConflictResolution `json:"all,omitempty"`
+}
+
+// Validate checks that every resolution is known
+func (r UploadResolutions) Validate() error {
+	if r.All != "" && !r.All.Valid() {
+		return fmt.Errorf("invalid resolution %q for all files", r.All)
+	}
+	for filename, resolution := range r.Files {
+		if !resolution.Valid() {
+			return fmt.Errorf("invalid resolution %q for %s", resolution, filename)
+		}
+	}
+	return nil
+}
+
+// resolution returns how to handle a conflicting file and whether the client chose it
+func (r UploadResolutions) resolution(filename string) (ConflictResolution, bool) {
+	if resolution, ok := r.Files[filename]; ok {
+		return resolution, true
+	}
+	if r.All != "" {
+		return r.All, true
+	}
+	return "", false
+}
+
+// parseUploadResolutions reads the resolutions part of an upload form, sent
+// as a field or a JSON file part, and merges the legacy resolution_<filename>
+// fields into it; the JSON part wins when both name a file
+func parseUploadResolutions(form *multipart.Form) (UploadResolutions, error) {
+	var resolutions UploadResolutions
+
+	var data []byte
+	if values := form.Value["resolutions"]; len(values) > 0 {
+		data = []byte(values[0])
+	} else if parts := form.File["resolutions"]; len(parts) > 0 {
+		part, err := parts[0].Open()
+		if err != nil {
+			return resolutions, fmt.Errorf("failed to read resolutions: %v", err)
+		}
+		defer part.Close()
+		if data, err = io.ReadAll(io.LimitReader(part, maxResolutionsSize+1)); err != nil {
+			return resolutions, fmt.Errorf("failed to read resolutions: %v", err)
+		}
+	}
+	if len(data) > maxResolutionsSize {
+		return resolutions, fmt.Errorf("resolutions exceed %d bytes", maxResolutionsSize)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &resolutions); err != nil {
+			return resolutions, fmt.Errorf("invalid resolutions: %v", err)
+		}
+		if err := resolutions.Validate(); err != nil {
+			return resolutions, err
+		}
+	}
+
+	// Legacy fields are checked per file, failing only the file they name
+	for key, values := range form.Value {
+		if !strings.HasPrefix(key, legacyResolutionPrefix) || len(values) == 0 {
+			continue
+		}
+		filename := strings.TrimPrefix(key, legacyResolutionPrefix)
+		if _, ok := resolutions.Files[filename]; ok {
+			continue
+		}
+		if resolutions.Files == nil {
+			resolutions.Files = make(map[string]ConflictResolution)
+		}
+		resolutions.Files[filename] = ConflictResolution(values[0])
+	}
+
+	return resolutions, nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestUploadJSONResolutions tests resolving conflicts through the JSON resolutions part
+func TestUploadJSONResolutions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		values         map[string]string
+		policy         ConflictResolution
+		expectedCode   int
+		expectedStatus UploadStatus
+		expectedReason UploadReason
+	}{
+		{name: "Per file", values: map[string]string{"resolutions": `{"files": {"model.stl": "overwrite"}}`}, expectedCode: http.StatusOK, expectedStatus: UploadOverwritten},
+		{name: "Apply to all", values: map[string]string{"resolutions": `{"all": "rename"}`}, expectedCode: http.StatusOK, expectedStatus: UploadRenamed},
+		{name: "Files take precedence over all", values: map[string]string{"resolutions": `{"all": "overwrite", "files": {"model.stl": "skip"}}`}, expectedCode: http.StatusOK, expectedStatus: UploadSkipped, expectedReason: ReasonSkipRequested},
+		{name: "JSON takes precedence over legacy fields", values: map[string]string{"resolutions": `{"files": {"model.stl": "rename"}}`, "resolution_model.stl": "overwrite"}, expectedCode: http.StatusOK, expectedStatus: UploadRenamed},
+		{name: "Legacy fields take precedence over all", values: map[string]string{"resolutions": `{"all": "rename"}`, "resolution_model.stl": "overwrite"}, expectedCode: http.StatusOK, expectedStatus: UploadOverwritten},
+		{name: "Default policy", policy: ConflictOverwrite, expectedCode: http.StatusOK, expectedStatus: UploadOverwritten},
+		{name: "Invalid JSON", values: map[string]string{"resolutions": `{"all": `}, expectedCode: http.StatusBadRequest},
+		{name: "Unknown resolution", values: map[string]string{"resolutions": `{"all": "merge"}`}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tmpDir := t.TempDir()
+			router := setupUploadSessionRouter(tmpDir)
+			project, _ := createUploadTestProject(t, db, tmpDir)
+			if tc.policy != "" {
+				handler := NewProjectsHandler(tmpDir)
+				handler.SetConflictPolicy(tc.policy)
+				router.POST("/api/policy/:id/files", handler.UploadProjectFiles)
+			}
+
+			var w *httptest.ResponseRecorder
+			if tc.policy != "" {
+				w = uploadForm(router, "/api/policy/"+strconv.Itoa(int(project.ID))+"/files", map[string]string{"model.stl": "new content"}, nil)
+			} else {
+				w = uploadWithSession(t, router, project.ID, map[string]string{"model.stl": "new content"}, tc.values)
+			}
+			if w.Code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response UploadResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if len(response.Results) != 1 || response.Results[0].Status != tc.expectedStatus || response.Results[0].Reason != tc.expectedReason {
+				t.Errorf("Expected %s/%q, got %+v", tc.expectedStatus, tc.expectedReason, response.Results)
+			}
+		})
+	}
+}
+
+// TestUploadJSONResolutionsPart tests sending the resolutions as a JSON file part, as browsers do for Blobs
+func TestUploadJSONResolutionsPart(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, existing := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadForm(router, "/api/projects/"+strconv.Itoa(int(project.ID))+"/files",
+		map[string]string{"model.stl": "replacement"}, []byte(`{"all": "overwrite"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	content, _ := os.ReadFile(existing.Filepath)
+	if string(content) != "replacement" {
+		t.Errorf("Expected overwritten content, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(project.Path, "blob")); !os.IsNotExist(err) {
+		t.Error("The resolutions part must not be uploaded as a file")
+	}
+}
+
+// uploadForm posts files, and the resolutions as a JSON file part when given, to path
+func uploadForm(router http.Handler, path string, files map[string]string, resolutions []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte(content))
+	}
+	if resolutions != nil {
+		part, _ := writer.CreateFormFile("resolutions", "blob")
+		part.Write(resolutions)
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
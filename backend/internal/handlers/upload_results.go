@@ -0,0 +1,104 @@
+package handlers
+
+import "3dshelf/internal/models"
+
+// UploadStatus is the outcome of one file of an upload
+type UploadStatus string
+
+const (
+	// UploadUploaded is a new file added to the project
+	UploadUploaded UploadStatus = "uploaded"
+	// UploadRenamed is a conflicting file added under a new name
+	UploadRenamed UploadStatus = "renamed"
+	// UploadOverwritten is a conflicting file that replaced the existing one
+	UploadOverwritten UploadStatus = "overwritten"
+	// UploadSkipped is a file left out on purpose; the project is unchanged
+	UploadSkipped UploadStatus = "skipped"
+	// UploadFailed is a file that could not be applied and may be retried
+	UploadFailed UploadStatus = "failed"
+)
+
+// UploadReason explains why a file was skipped or failed
+type UploadReason string
+
+const (
+	// ReasonUnresolvedConflict skips a file that conflicts and has no resolution
+	ReasonUnresolvedConflict UploadReason = "unresolved_conflict"
+	// ReasonSkipRequested skips a file whose resolution was skip
+	ReasonSkipRequested UploadReason = "skip_requested"
+	// ReasonAlreadyApplied skips a file an earlier request of the upload session applied
+	ReasonAlreadyApplied UploadReason = "already_applied"
+
+	// ReasonUnsupportedType fails a file whose type is not accepted
+	ReasonUnsupportedType UploadReason = "unsupported_type"
+	// ReasonInvalidPath fails a file of a folder upload whose relative path is rejected
+	ReasonInvalidPath UploadReason = "invalid_path"
+	// ReasonInvalidResolution fails a conflicting file with an unknown resolution
+	ReasonInvalidResolution UploadReason = "invalid_resolution"
+	// ReasonStale fails a file whose conflict state changed since the upload session's check
+	ReasonStale UploadReason = "stale"
+	// ReasonStagingFailed fails a file that could not be written to the staging area
+	ReasonStagingFailed UploadReason = "staging_failed"
+	// ReasonCommitFailed fails a staged file that could not be moved into the project
+	ReasonCommitFailed UploadReason = "commit_failed"
+	// ReasonAborted fails a staged file left uncommitted because an atomic upload failed
+	ReasonAborted UploadReason = "aborted"
+)
+
+// UploadFileResult is the outcome of one uploaded file
+type UploadFileResult struct {
+	// Filename is the name the client uploaded, or its relative path in a folder upload
+	Filename string       `json:"filename"`
+	Status   UploadStatus `json:"status"`
+	Reason   UploadReason `json:"reason,omitempty"`
+
+	// Error describes a failure for people
+	Error string `json:"error,omitempty"`
+
+	// File is the resulting record of an uploaded, renamed or overwritten file
+	File *models.ProjectFile `json:"file,omitempty"`
+}
+
+// UploadResponse reports an upload with one result per file, in request order,
+// and how many files ended in each status
+type UploadResponse struct {
+	Message    string               `json:"message"`
+	Error      string               `json:"error,omitempty"`
+	CommitMode UploadCommitMode     `json:"commit_mode"`
+	Results    []UploadFileResult   `json:"results"`
+	Counts     map[UploadStatus]int `json:"counts"`
+
+	// Warnings are problems after the files were applied, such as failing to update the session
+	Warnings []string              `json:"warnings,omitempty"`
+	Session  *models.UploadSession `json:"session,omitempty"`
+}
+
+// failedUpload is the result of a file that failed for reason
+func failedUpload(filename string, reason UploadReason, err string) UploadFileResult {
+	return UploadFileResult{Filename: filename, Status: UploadFailed, Reason: reason, Error: err}
+}
+
+// countUploadResults counts results by status; every status is present
+func countUploadResults(results []UploadFileResult) map[UploadStatus]int {
+	counts := map[UploadStatus]int{
+		UploadUploaded:    0,
+		UploadRenamed:     0,
+		UploadOverwritten: 0,
+		UploadSkipped:     0,
+		UploadFailed:      0,
+	}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// hasUploadReason reports whether any result failed or was skipped for reason
+func hasUploadReason(results []UploadFileResult, reason UploadReason) bool {
+	for _, result := range results {
+		if result.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestUploadResults tests the per-file status and reason reported for each conflict resolution
+func TestUploadResults(t *testing.T) {
+	testCases := []struct {
+		name           string
+		resolution     string
+		expectedCode   int
+		expectedStatus UploadStatus
+		expectedReason UploadReason
+	}{
+		{name: "No conflict", expectedCode: http.StatusOK, expectedStatus: UploadUploaded},
+		{name: "Unresolved conflict", resolution: "-", expectedCode: http.StatusOK, expectedStatus: UploadSkipped, expectedReason: ReasonUnresolvedConflict},
+		{name: "Skip", resolution: "skip", expectedCode: http.StatusOK, expectedStatus: UploadSkipped, expectedReason: ReasonSkipRequested},
+		{name: "Rename", resolution: "rename", expectedCode: http.StatusOK, expectedStatus: UploadRenamed},
+		{name: "Overwrite", resolution: "overwrite", expectedCode: http.StatusOK, expectedStatus: UploadOverwritten},
+		{name: "Invalid resolution", resolution: "merge", expectedCode: http.StatusBadRequest, expectedStatus: UploadFailed, expectedReason: ReasonInvalidResolution},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tmpDir := t.TempDir()
+			router := setupUploadSessionRouter(tmpDir)
+			project, _ := createUploadTestProject(t, db, tmpDir)
+
+			filename := "model.stl"
+			values := map[string]string{}
+			switch tc.resolution {
+			case "":
+				filename = "new.stl"
+			case "-":
+			default:
+				values["resolution_model.stl"] = tc.resolution
+			}
+
+			w := uploadWithSession(t, router, project.ID, map[string]string{filename: "new content"}, values)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+
+			var response UploadResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Results) != 1 {
+				t.Fatalf("Expected 1 result, got %+v", response.Results)
+			}
+			result := response.Results[0]
+			if result.Filename != filename || result.Status != tc.expectedStatus || result.Reason != tc.expectedReason {
+				t.Errorf("Expected %s/%q for %s, got %+v", tc.expectedStatus, tc.expectedReason, filename, result)
+			}
+			if response.Counts[tc.expectedStatus] != 1 || len(response.Counts) != 5 {
+				t.Errorf("Expected counts of every status, got %v", response.Counts)
+			}
+
+			applied := tc.expectedStatus == UploadUploaded || tc.expectedStatus == UploadRenamed || tc.expectedStatus == UploadOverwritten
+			if applied != (result.File != nil) {
+				t.Errorf("Expected a file record only for applied files, got %+v", result.File)
+			}
+			if tc.expectedStatus == UploadRenamed && (result.File.Filename == filename || !strings.HasPrefix(result.File.Filename, "model_")) {
+				t.Errorf("Expected the renamed record under a new name, got %s", result.File.Filename)
+			}
+			if tc.expectedStatus == UploadFailed && response.Error == "" {
+				t.Error("Expected an error message when nothing was uploaded")
+			}
+		})
+	}
+}
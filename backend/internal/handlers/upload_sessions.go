@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uploadSessionTTL bounds how long a conflict check stays valid for uploads
+const uploadSessionTTL = time.Hour
+
+var (
+	errUploadSessionNotFound = errors.New("upload session not found")
+	errUploadSessionExpired  = errors.New("upload session expired")
+)
+
+// projectLockWait is how long a mutation waits for another instance to finish with a project
+const projectLockWait = 30 * time.Second
+
+// projectLocks serializes mutations of a single project's files
+type projectLocks struct {
+	mu    sync.Mutex
+	locks map[uint]*sync.Mutex
+}
+
+// lock acquires the mutex for the project, then its advisory lock shared with
+// other instances using the database, and returns the unlock function
+func (p *projectLocks) lock(projectID uint) (func(), error) {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = make(map[uint]*sync.Mutex)
+	}
+	lock, exists := p.locks[projectID]
+	if !exists {
+		lock = &sync.Mutex{}
+		p.locks[projectID] = lock
+	}
+	p.mu.Unlock()
+
+	lock.Lock()
+	_, release, err := database.LockWait(context.Background(), database.GetDB(), database.ProjectLock(projectID), projectLockWait)
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+	return func() {
+		release()
+		lock.Unlock()
+	}, nil
+}
+
+// lockProject locks a project for mutation, writing the error response and
+// returning false when the lock cannot be taken
+func (h *ProjectsHandler) lockProject(c *gin.Context, projectID uint) (func(), bool) {
+	unlock, err := h.locks.lock(projectID)
+	switch {
+	case errors.Is(err, database.ErrLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Project is being modified by another operation, try again later"})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock project"})
+		return nil, false
+	}
+	return unlock, true
+}
+
+// newSessionToken generates a random opaque upload session token
+func newSessionToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createUploadSession persists the outcome of a conflict check
+func createUploadSession(db *gorm.DB, projectID uint, filenames []string, conflicts []FileConflict) (*models.UploadSession, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.UploadSession{
+		Token:     token,
+		ProjectID: projectID,
+		Status:    models.UploadSessionPending,
+		Filenames: filenames,
+		Conflicts: make([]models.UploadSessionConflict, 0, len(conflicts)),
+		Completed: []string{},
+		ExpiresAt: time.Now().Add(uploadSessionTTL),
+	}
+
+	for _, conflict := range conflicts {
+		snapshot := models.UploadSessionConflict{Filename: conflict.Filename}
+		if conflict.ExistingFile != nil {
+			snapshot.FileID = conflict.ExistingFile.ID
+			snapshot.Hash = conflict.ExistingFile.Hash
+			snapshot.Size = conflict.ExistingFile.Size
+		}
+		session.Conflicts = append(session.Conflicts, snapshot)
+	}
+
+	if err := db.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// loadUploadSession finds a usable session for the project
+func loadUploadSession(db *gorm.DB, projectID uint, token string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := db.Where("token = ? AND project_id = ?", token, projectID).First(&session).Error; err != nil {
+		return nil, errUploadSessionNotFound
+	}
+
+	if session.Expired(time.Now()) {
+		return nil, errUploadSessionExpired
+	}
+
+	return &session, nil
+}
+
+// uploadSessionError maps session lookup errors to an HTTP status and message
+func uploadSessionError(err error) (int, string) {
+	if errors.Is(err, errUploadSessionExpired) {
+		return http.StatusGone, "Upload session expired"
+	}
+	return http.StatusNotFound, "Upload session not found"
+}
+
+// staleReason explains why a file no longer matches the session's conflict
+// snapshot, or returns an empty string when the resolution is still valid
+func staleReason(session *models.UploadSession, filename string, existing *models.ProjectFile) string {
+	if !session.Includes(filename) {
+		return "File was not part of the conflict check"
+	}
+
+	snapshot, hadConflict := session.ConflictFor(filename)
+	switch {
+	case hadConflict && existing == nil:
+		return "Conflicting file was removed since the conflict check"
+	case !hadConflict && existing != nil:
+		return "File was added since the conflict check"
+	case hadConflict && (snapshot.FileID != existing.ID || snapshot.Hash != existing.Hash):
+		return "Conflicting file changed since the conflict check"
+	}
+
+	return ""
+}
+
+// GetUploadSession returns the state of an upload session so clients can resume it
+func (h *ProjectsHandler) GetUploadSession(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var project models.Project
+	if err := requestDB(c).First(&project, projectID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	session, err := loadUploadSession(requestDB(c), project.ID, c.Param("token"))
+	if err != nil {
+		status, message := uploadSessionError(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
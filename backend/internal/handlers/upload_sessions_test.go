@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupUploadSessionRouter creates a router exposing the upload flow routes
+func setupUploadSessionRouter(tmpDir string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewProjectsHandler(tmpDir)
+
+	api := router.Group("/api")
+	{
+		api.POST("/projects/:id/files/check-conflicts", handler.CheckUploadConflicts)
+		api.POST("/projects/:id/files", handler.UploadProjectFiles)
+		api.GET("/projects/:id/upload-sessions/:token", handler.GetUploadSession)
+		api.GET("/uploads/:sessionId/progress", handler.GetUploadProgress)
+	}
+
+	return router
+}
+
+// createUploadTestProject creates a project on disk with one existing file
+func createUploadTestProject(t *testing.T, db *gorm.DB, tmpDir string) (models.Project, models.ProjectFile) {
+	project := models.Project{
+		Name:        "Session Project",
+		Path:        filepath.Join(tmpDir, "session_project"),
+		Status:      models.StatusHealthy,
+		LastScanned: time.Now(),
+	}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatalf("Failed to create test project: %v", err)
+	}
+	if err := os.MkdirAll(project.Path, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+
+	existingPath := filepath.Join(project.Path, "model.stl")
+	if err := os.WriteFile(existingPath, []byte("old content"), 0644); err != nil {
+		t.Fatalf("Failed to create existing file: %v", err)
+	}
+
+	existing := models.ProjectFile{
+		ProjectID: project.ID,
+		Filename:  "model.stl",
+		Filepath:  existingPath,
+		FileType:  models.FileTypeSTL,
+		Size:      11,
+		Hash:      "oldhash",
+	}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("Failed to create existing file record: %v", err)
+	}
+
+	return project, existing
+}
+
+// checkConflicts runs the check phase and returns the parsed response
+func checkConflicts(t *testing.T, router *gin.Engine, projectID uint, filenames ...string) UploadCheckResponse {
+	body, _ := json.Marshal(UploadCheckRequest{Filenames: filenames})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/projects/"+strconv.Itoa(int(projectID))+"/files/check-conflicts", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected check status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response UploadCheckResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal check response: %v", err)
+	}
+	return response
+}
+
+// uploadWithSession uploads files with form values and returns the recorder
+func uploadWithSession(t *testing.T, router *gin.Engine, projectID uint, files map[string]string, values map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	for key, value := range values {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/projects/"+strconv.Itoa(int(projectID))+"/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestUploadSessionFlow tests resolving conflicts through a session and resuming it
+func TestUploadSessionFlow(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	check := checkConflicts(t, router, project.ID, "model.stl", "new.stl")
+	if check.SessionToken == "" {
+		t.Fatal("Check response should carry a session token")
+	}
+	if len(check.Conflicts) != 1 || len(check.Safe) != 1 {
+		t.Fatalf("Expected 1 conflict and 1 safe file, got %+v", check)
+	}
+
+	// First request only applies the conflict resolution
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"model.stl": "new content"},
+		map[string]string{"session_token": check.SessionToken, "resolution_model.stl": "overwrite"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var session models.UploadSession
+	db.Where("token = ?", check.SessionToken).First(&session)
+	if session.Status != models.UploadSessionPartial {
+		t.Errorf("Expected partial session, got %s", session.Status)
+	}
+
+	// Resuming with both files must not re-apply the completed overwrite
+	w = uploadWithSession(t, router, project.ID,
+		map[string]string{"model.stl": "newer content", "new.stl": "fresh"},
+		map[string]string{"session_token": check.SessionToken, "resolution_model.stl": "overwrite"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Counts[UploadUploaded] != 1 || response.Counts[UploadSkipped] != 1 {
+		t.Errorf("Expected 1 uploaded and 1 skipped file on resume, got %+v", response)
+	}
+	for _, result := range response.Results {
+		if result.Filename == "model.stl" && result.Reason != ReasonAlreadyApplied {
+			t.Errorf("Expected the applied overwrite to be skipped as already applied, got %+v", result)
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(project.Path, "model.stl"))
+	if string(content) != "new content" {
+		t.Errorf("Completed resolution should not be applied twice, got content %q", content)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/projects/"+strconv.Itoa(int(project.ID))+"/upload-sessions/"+check.SessionToken, nil)
+	router.ServeHTTP(w, req)
+
+	json.Unmarshal(w.Body.Bytes(), &session)
+	if w.Code != http.StatusOK || session.Status != models.UploadSessionCompleted {
+		t.Errorf("Expected completed session, got %d %+v", w.Code, session)
+	}
+}
+
+// TestUploadSessionStaleConflict tests that resolutions are rejected after concurrent changes
+func TestUploadSessionStaleConflict(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, existing := createUploadTestProject(t, db, tmpDir)
+
+	check := checkConflicts(t, router, project.ID, "model.stl")
+
+	// Someone else replaces the file after the check
+	db.Model(&existing).Update("hash", "changedhash")
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"model.stl": "new content"},
+		map[string]string{"session_token": check.SessionToken, "resolution_model.stl": "overwrite"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Results) != 1 || response.Results[0].Status != UploadFailed || response.Results[0].Reason != ReasonStale {
+		t.Errorf("Expected 1 stale file, got %+v", response)
+	}
+
+	content, _ := os.ReadFile(existing.Filepath)
+	if string(content) != "old content" {
+		t.Error("Stale resolution must not overwrite the file")
+	}
+}
+
+// TestUploadSessionErrors tests unknown and expired session tokens
+func TestUploadSessionErrors(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"other.stl": "content"},
+		map[string]string{"session_token": "unknown"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown token, got %d", http.StatusNotFound, w.Code)
+	}
+
+	check := checkConflicts(t, router, project.ID, "other.stl")
+	db.Model(&models.UploadSession{}).Where("token = ?", check.SessionToken).Update("expires_at", time.Now().Add(-time.Minute))
+
+	w = uploadWithSession(t, router, project.ID,
+		map[string]string{"other.stl": "content"},
+		map[string]string{"session_token": check.SessionToken})
+	if w.Code != http.StatusGone {
+		t.Errorf("Expected status %d for expired token, got %d", http.StatusGone, w.Code)
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+)
+
+// UploadCommitMode controls how staged uploads are moved into a project
+type UploadCommitMode string
+
+const (
+	// CommitAtomic applies every file in the request or none of them
+	CommitAtomic UploadCommitMode = "atomic"
+	// CommitPerFile applies each file that staged successfully on its own
+	CommitPerFile UploadCommitMode = "per_file"
+)
+
+// stagingDirPrefix names per-request staging directories inside a project;
+// the leading dot keeps them out of scans
+const stagingDirPrefix = ".upload-staging-"
+
+// stagedFile is an uploaded file written to the staging area but not yet committed
+type stagedFile struct {
+	// Source is the name the client uploaded; Filename is the name committed
+	Source     string
+	Filename   string
+	FileType   models.FileType
+	StagedPath string
+	Size       int64
+	Hash       string
+
+	// Replaces is the existing record overwritten by this file, if any
+	Replaces *models.ProjectFile
+}
+
+// committedFile tracks a committed file so it can be rolled back
+type committedFile struct {
+	record       models.ProjectFile
+	destPath     string
+	originalPath string
+	backupPath   string
+}
+
+// newStagingDir creates a per-request staging directory on the project's
+// filesystem so commits are cheap renames
+func newStagingDir(projectPath string) (string, error) {
+	return os.MkdirTemp(projectPath, stagingDirPrefix)
+}
+
+// stageUpload copies an uploaded file into the staging directory and hashes it;
+// source is the name it was uploaded under and filename the name to commit
+func stageUpload(stagingDir string, fileHeader *multipart.FileHeader, source, filename string, fileType models.FileType) (*stagedFile, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %v", fileHeader.Filename, err)
+	}
+	defer src.Close()
+
+	dest, err := os.CreateTemp(stagingDir, "file-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %v", fileHeader.Filename, err)
+	}
+
+	// Parts larger than the memory limit are spooled to a temp file, which is
+	// renamed over dest rather than copied when it is on the same filesystem
+	hasher := sha256.New()
+	var size int64
+	moved := false
+	if spooled, ok := src.(*os.File); ok {
+		size, moved, err = moveSpooled(spooled, dest.Name(), hasher)
+	}
+	if err == nil && !moved {
+		size, err = io.Copy(io.MultiWriter(dest, hasher), src)
+	}
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest.Name())
+		return nil, fmt.Errorf("failed to copy file %s: %v", fileHeader.Filename, err)
+	}
+
+	return &stagedFile{
+		Source:     source,
+		Filename:   filename,
+		FileType:   fileType,
+		StagedPath: dest.Name(),
+		Size:       size,
+		Hash:       fmt.Sprintf("%x", hasher.Sum(nil)),
+	}, nil
+}
+
+// moveSpooled hashes a spooled upload and renames it to dest, reporting
+// whether it was moved. When the rename fails, as it does across
+// filesystems, the hash is reset and spooled rewound so it can be copied.
+func moveSpooled(spooled *os.File, dest string, hasher hash.Hash) (int64, bool, error) {
+	size, err := io.Copy(hasher, spooled)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := os.Rename(spooled.Name(), dest); err == nil {
+		return size, true, nil
+	}
+
+	hasher.Reset()
+	_, err = spooled.Seek(0, io.SeekStart)
+	return 0, false, err
+}
+
+// commitStaged moves a staged file into the project and records it in the
+// database, keeping a backup of any overwritten file until the request ends
+func commitStaged(db *gorm.DB, project *models.Project, staged *stagedFile) (*committedFile, error) {
+	committed := &committedFile{destPath: filepath.Join(project.Path, staged.Filename)}
+
+	if staged.Replaces != nil {
+		committed.originalPath = staged.Replaces.Filepath
+		committed.backupPath = staged.StagedPath + ".previous"
+		if err := os.Rename(staged.Replaces.Filepath, committed.backupPath); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to replace file %s: %v", staged.Filename, err)
+			}
+			committed.backupPath = ""
+		}
+	}
+
+	// Files of a folder upload recreate its subdirectories
+	if err := fileperm.MkdirAll(filepath.Dir(committed.destPath)); err != nil {
+		committed.restore()
+		return nil, fmt.Errorf("failed to create folder for %s: %v", staged.Filename, err)
+	}
+	if err := os.Rename(staged.StagedPath, committed.destPath); err != nil {
+		committed.restore()
+		return nil, fmt.Errorf("failed to move file %s into project: %v", staged.Filename, err)
+	}
+	if err := fileperm.File(committed.destPath); err != nil {
+		committed.undo()
+		return nil, fmt.Errorf("failed to set permissions of %s: %v", staged.Filename, err)
+	}
+
+	if staged.Replaces != nil {
+		if err := db.Delete(staged.Replaces).Error; err != nil {
+			committed.undo()
+			return nil, fmt.Errorf("failed to remove existing file record %s: %v", staged.Filename, err)
+		}
+	}
+
+	committed.record = models.ProjectFile{
+		ProjectID: project.ID,
+		Filename:  staged.Filename,
+		Filepath:  committed.destPath,
+		FileType:  staged.FileType,
+		Size:      staged.Size,
+		Hash:      staged.Hash,
+	}
+	if err := db.Create(&committed.record).Error; err != nil {
+		committed.undo()
+		return nil, fmt.Errorf("failed to save file record for %s: %v", staged.Filename, err)
+	}
+
+	return committed, nil
+}
+
+// undo removes the committed file and restores whatever it replaced
+func (c *committedFile) undo() {
+	os.Remove(c.destPath)
+	c.restore()
+}
+
+// restore moves the backed-up original back into place
+func (c *committedFile) restore() {
+	if c.backupPath != "" {
+		os.Rename(c.backupPath, c.originalPath)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// assertNoStagingDirs fails if a staging directory was left in the project
+func assertNoStagingDirs(t *testing.T, projectPath string) {
+	entries, err := os.ReadDir(projectPath)
+	if err != nil {
+		t.Fatalf("Failed to read project directory: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), stagingDirPrefix) {
+			t.Errorf("Staging directory %s was not cleaned up", entry.Name())
+		}
+	}
+}
+
+// TestUploadAtomicCommit tests that a failing file aborts the whole request
+func TestUploadAtomicCommit(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, existing := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"model.stl": "replacement", "good.stl": "good", "notes.txt": "unsupported"},
+		map[string]string{"resolution_model.stl": "overwrite"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(project.Path, "good.stl")); !os.IsNotExist(err) {
+		t.Error("No file should be committed when the request aborts")
+	}
+
+	content, _ := os.ReadFile(existing.Filepath)
+	if string(content) != "old content" {
+		t.Errorf("Overwrite must not be applied when the request aborts, got %q", content)
+	}
+
+	var count int64
+	db.Model(&models.ProjectFile{}).Where("project_id = ?", project.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected only the original file record, got %d", count)
+	}
+
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	reasons := make(map[string]UploadReason)
+	for _, result := range response.Results {
+		reasons[result.Filename] = result.Reason
+	}
+	if reasons["notes.txt"] != ReasonUnsupportedType || reasons["good.stl"] != ReasonAborted || reasons["model.stl"] != ReasonAborted {
+		t.Errorf("Expected the unsupported file to fail and the others to abort, got %+v", response.Results)
+	}
+
+	assertNoStagingDirs(t, project.Path)
+}
+
+// TestUploadAtomicOverwrite tests that a successful atomic request replaces files
+func TestUploadAtomicOverwrite(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, existing := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"model.stl": "replacement", "good.stl": "good"},
+		map[string]string{"resolution_model.stl": "overwrite"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	content, _ := os.ReadFile(existing.Filepath)
+	if string(content) != "replacement" {
+		t.Errorf("Expected overwritten content, got %q", content)
+	}
+
+	var files []models.ProjectFile
+	db.Where("project_id = ?", project.ID).Find(&files)
+	if len(files) != 2 {
+		t.Errorf("Expected 2 file records, got %d", len(files))
+	}
+	for _, file := range files {
+		if file.ID == existing.ID {
+			t.Error("Overwritten file record should be replaced")
+		}
+	}
+
+	assertNoStagingDirs(t, project.Path)
+}
+
+// TestUploadPerFileCommit tests that per_file mode keeps files that succeeded
+func TestUploadPerFileCommit(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"good.stl": "good", "notes.txt": "unsupported"},
+		map[string]string{"commit_mode": "per_file"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response UploadResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Counts[UploadUploaded] != 1 || response.Counts[UploadFailed] != 1 {
+		t.Errorf("Expected 1 uploaded file and 1 failure, got %+v", response)
+	}
+
+	if _, err := os.Stat(filepath.Join(project.Path, "good.stl")); err != nil {
+		t.Errorf("Valid file should be committed in per_file mode: %v", err)
+	}
+
+	assertNoStagingDirs(t, project.Path)
+}
+
+// TestUploadInvalidCommitMode tests rejecting unknown commit modes
+func TestUploadInvalidCommitMode(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadSessionRouter(tmpDir)
+	project, _ := createUploadTestProject(t, db, tmpDir)
+
+	w := uploadWithSession(t, router, project.ID,
+		map[string]string{"good.stl": "good"},
+		map[string]string{"commit_mode": "yolo"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestStageUploadSpooled tests that parts spooled to temp files are moved into
+// staging on the same filesystem, and parts held in memory are copied
+func TestStageUploadSpooled(t *testing.T) {
+	spoolDir := t.TempDir()
+	t.Setenv("TMPDIR", spoolDir)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, content := range map[string]string{"small.stl": "tiny", "large.stl": strings.Repeat("solid ", 100)} {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte(content))
+	}
+	writer.Close()
+	// The small part fits in memory; the large one is spooled
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(len("tiny")) + 1)
+	if err != nil {
+		t.Fatalf("Failed to read form: %v", err)
+	}
+	defer form.RemoveAll()
+
+	stagingDir := t.TempDir()
+	for _, header := range form.File["files"] {
+		src, _ := header.Open()
+		content, _ := io.ReadAll(src)
+		src.Close()
+
+		staged, err := stageUpload(stagingDir, header, header.Filename, header.Filename, models.FileTypeSTL)
+		if err != nil {
+			t.Fatalf("Failed to stage %s: %v", header.Filename, err)
+		}
+		stagedContent, _ := os.ReadFile(staged.StagedPath)
+		if !bytes.Equal(stagedContent, content) || staged.Size != int64(len(content)) || staged.Hash != fmt.Sprintf("%x", sha256.Sum256(content)) {
+			t.Errorf("Expected %s staged with its content, got %+v", header.Filename, staged)
+		}
+	}
+
+	if entries, _ := os.ReadDir(spoolDir); len(entries) != 0 {
+		t.Errorf("Expected the spooled part moved out of the temp directory, found %d files", len(entries))
+	}
+}
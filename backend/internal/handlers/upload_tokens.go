@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultUploadTokenTTL is how long a phone upload token lasts unless the request says otherwise
+	defaultUploadTokenTTL = 30 * time.Minute
+
+	// maxUploadTokenTTL bounds how long a token may be asked to last
+	maxUploadTokenTTL = 24 * time.Hour
+
+	// uploadTokenQRSize is the width and height of the QR code PNG in pixels
+	uploadTokenQRSize = 512
+
+	// uploadPageContentSecurityPolicy replaces the API's policy for the phone
+	// upload page, allowing its inline styles and posting its form back
+	uploadPageContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'; base-uri 'none'"
+)
+
+var (
+	errUploadTokenNotFound = errors.New("upload token not found")
+	errUploadTokenExpired  = errors.New("upload token expired")
+)
+
+// UploadTokenRequest configures a new phone upload token
+type UploadTokenRequest struct {
+	// ExpiresInMinutes is how long the token lasts; 30 minutes when zero
+	ExpiresInMinutes int `json:"expires_in_minutes"`
+}
+
+// UploadTokenResponse is a created token with the links to hand to a phone
+type UploadTokenResponse struct {
+	models.UploadToken
+
+	// UploadURL is the page the QR code opens; QRURL renders the QR code as a PNG
+	UploadURL string `json:"upload_url"`
+	QRURL     string `json:"qr_url"`
+}
+
+// uploadPage is the page a phone opens from the QR code. It is a plain form
+// so it works without scripts, which the API's security policy forbids.
+var uploadPage = template.Must(template.New("upload").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Add photos to {{.Project}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0 auto; max-width: 28rem; padding: 1.5rem; }
+input, button { display: block; font-size: 1.1rem; margin: 1rem 0; width: 100%; }
+button { background: #3182ce; border: 0; border-radius: 0.5rem; color: #fff; padding: 0.9rem; }
+.message { background: #f0fff4; border-radius: 0.5rem; padding: 0.75rem; }
+.error { background: #fff5f5; }
+</style>
+</head>
+<body>
+<h1>{{.Project}}</h1>
+{{if .Message}}<p class="message{{if .Failed}} error{{end}}">{{.Message}}</p>{{end}}
+{{range .Errors}}<p class="message error">{{.}}</p>{{end}}
+{{if .Usable}}
+<form method="post" enctype="multipart/form-data">
+<input type="file" name="files" accept="image/*" capture="environment" multiple required>
+<button type="submit">Upload photos</button>
+</form>
+<p>This link stops working at {{.ExpiresAt.Format "15:04"}}.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// uploadPageData fills the phone upload page
+type uploadPageData struct {
+	Project   string
+	ExpiresAt time.Time
+	Usable    bool
+	Message   string
+	Failed    bool
+	Errors    []string
+}
+
+// CreateUploadToken creates a short-lived token for adding photos to a
+// project from a phone, along with the page and QR code links for it
+func (h *ProjectsHandler) CreateUploadToken(c *gin.Context) {
+	var project models.Project
+	if err := requestDB(c).First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if rejectFlatProject(c, &project) {
+		return
+	}
+
+	var req UploadTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	ttl := defaultUploadTokenTTL
+	if req.ExpiresInMinutes != 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+		if ttl <= 0 || ttl > maxUploadTokenTTL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_minutes must be between 1 and %d", int(maxUploadTokenTTL.Minutes()))})
+			return
+		}
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload token"})
+		return
+	}
+	uploadToken := models.UploadToken{Token: token, ProjectID: project.ID, ExpiresAt: time.Now().Add(ttl)}
+	if err := requestDB(c).Create(&uploadToken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, UploadTokenResponse{
+		UploadToken: uploadToken,
+		UploadURL:   h.uploadTokenURL(c, token),
+		QRURL:       fmt.Sprintf("%s/api/projects/%d/upload-tokens/%s/qr", requestBaseURL(c), project.ID, token),
+	})
+}
+
+// GetUploadTokenQR renders a QR code opening the token's upload page
+func (h *ProjectsHandler) GetUploadTokenQR(c *gin.Context) {
+	uploadToken, _, err := loadUploadToken(requestDB(c), c.Param("token"))
+	if err == nil && fmt.Sprint(uploadToken.ProjectID) != c.Param("id") {
+		err = errUploadTokenNotFound
+	}
+	if err != nil {
+		status, message := uploadTokenError(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	png, err := qrcode.Encode(h.uploadTokenURL(c, uploadToken.Token), qrcode.Medium, uploadTokenQRSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render QR code"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// RevokeUploadToken stops a token accepting uploads before it expires
+func (h *ProjectsHandler) RevokeUploadToken(c *gin.Context) {
+	var uploadToken models.UploadToken
+	if err := requestDB(c).Where("token = ? AND project_id = ?", c.Param("token"), c.Param("id")).First(&uploadToken).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload token not found"})
+		return
+	}
+
+	if uploadToken.RevokedAt == nil {
+		now := time.Now()
+		uploadToken.RevokedAt = &now
+		if err := requestDB(c).Model(&uploadToken).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke upload token"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, uploadToken)
+}
+
+// GetUploadPage serves the phone upload page for a token
+func (h *ProjectsHandler) GetUploadPage(c *gin.Context) {
+	uploadToken, project, err := loadUploadToken(requestDB(c), c.Param("token"))
+	if err != nil {
+		status, message := uploadTokenError(err)
+		renderUploadPage(c, status, uploadPageData{Message: message, Failed: true})
+		return
+	}
+	renderUploadPage(c, http.StatusOK, uploadPageData{Project: project.Name, ExpiresAt: uploadToken.ExpiresAt, Usable: true})
+}
+
+// UploadWithToken adds the photos posted from the upload page to the token's
+// project. Browsers submitting the page get it back with the outcome; other
+// clients get JSON.
+func (h *ProjectsHandler) UploadWithToken(c *gin.Context) {
+	asPage := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+
+	uploadToken, project, err := loadUploadToken(requestDB(c), c.Param("token"))
+	if err != nil {
+		status, message := uploadTokenError(err)
+		if asPage {
+			renderUploadPage(c, status, uploadPageData{Message: message, Failed: true})
+		} else {
+			c.JSON(status, gin.H{"error": message})
+		}
+		return
+	}
+
+	var files []models.ProjectFile
+	var failed []string
+	status, message := http.StatusCreated, ""
+	if form, err := c.MultipartForm(); err != nil || len(form.File["files"]) == 0 {
+		status, message = http.StatusBadRequest, "No photos provided"
+	} else if h.maxUploadFiles > 0 && len(form.File["files"]) > h.maxUploadFiles {
+		status, message = http.StatusRequestEntityTooLarge, fmt.Sprintf("Too many photos, at most %d at once", h.maxUploadFiles)
+	} else if unlock, ok := h.lockProject(c, project.ID); !ok {
+		return
+	} else {
+		files, failed = h.saveTokenUploads(c, project, form.File["files"])
+		unlock()
+
+		if len(files) == 0 {
+			status, message = http.StatusBadRequest, "No photos were uploaded"
+		} else {
+			message = fmt.Sprintf("Uploaded %d photo(s)", len(files))
+			uploadToken.Uploaded += len(files)
+			if err := requestDB(c).Model(uploadToken).Update("uploaded", uploadToken.Uploaded).Error; err != nil {
+				fmt.Printf("Warning: Failed to count uploads for token of project %d: %v\n", project.ID, err)
+			}
+		}
+	}
+
+	if asPage {
+		renderUploadPage(c, status, uploadPageData{
+			Project:   project.Name,
+			ExpiresAt: uploadToken.ExpiresAt,
+			Usable:    true,
+			Message:   message,
+			Failed:    len(files) == 0,
+			Errors:    failed,
+		})
+		return
+	}
+	if files == nil {
+		files = []models.ProjectFile{}
+	}
+	c.JSON(status, gin.H{"message": message, "files": files, "errors": failed})
+}
+
+// saveTokenUploads writes uploaded photos into the project directory, where
+// they join the project's gallery, and records them as project files
+func (h *ProjectsHandler) saveTokenUploads(c *gin.Context, project *models.Project, uploads []*multipart.FileHeader) ([]models.ProjectFile, []string) {
+	var saved []models.ProjectFile
+	var failed []string
+	for _, fileHeader := range uploads {
+		name := filepath.Base(fileHeader.Filename)
+		if !models.IsImageFile(name) {
+			failed = append(failed, fmt.Sprintf("Not a photo: %s", name))
+			continue
+		}
+
+		// Phones tend to name every photo image.jpg, so nothing is overwritten
+		dest := fsutil.AvailablePath(project.Path, name)
+		if err := c.SaveUploadedFile(fileHeader, dest); err != nil {
+			failed = append(failed, fmt.Sprintf("Failed to save photo %s: %v", name, err))
+			continue
+		}
+		// Stripping matters here: phone photos carry the location they were taken at
+		dest, err := h.images.Normalize(dest)
+		if err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to process photo %s: %v", name, err))
+			continue
+		}
+		if err := fileperm.File(dest); err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to save photo %s: %v", name, err))
+			continue
+		}
+		size, hash, err := fsutil.HashFile(dest)
+		if err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to hash photo %s: %v", name, err))
+			continue
+		}
+
+		file := models.ProjectFile{
+			ProjectID: project.ID,
+			Filename:  filepath.Base(dest),
+			Filepath:  dest,
+			FileType:  models.GetFileTypeFromExtension(dest),
+			Size:      size,
+			Hash:      hash,
+		}
+		if err := requestDB(c).Create(&file).Error; err != nil {
+			os.Remove(dest)
+			failed = append(failed, fmt.Sprintf("Failed to record photo %s: %v", name, err))
+			continue
+		}
+		saved = append(saved, file)
+	}
+	return saved, failed
+}
+
+// uploadTokenURL is the upload page for a token. With a public URL it goes
+// through the web UI, which forwards /api to the server and which a phone on
+// the same network is more likely to reach.
+func (h *ProjectsHandler) uploadTokenURL(c *gin.Context, token string) string {
+	base := requestBaseURL(c)
+	if h.publicURL != "" {
+		base = h.publicURL
+	}
+	return fmt.Sprintf("%s/api/upload/%s", base, token)
+}
+
+// loadUploadToken finds a usable token and its project
+func loadUploadToken(db *gorm.DB, token string) (*models.UploadToken, *models.Project, error) {
+	var uploadToken models.UploadToken
+	if err := db.Where("token = ?", token).First(&uploadToken).Error; err != nil {
+		return nil, nil, errUploadTokenNotFound
+	}
+	if !uploadToken.Usable(time.Now()) {
+		return nil, nil, errUploadTokenExpired
+	}
+
+	var project models.Project
+	if err := db.First(&project, uploadToken.ProjectID).Error; err != nil {
+		return nil, nil, errUploadTokenNotFound
+	}
+	return &uploadToken, &project, nil
+}
+
+// uploadTokenError maps token lookup errors to an HTTP status and message
+func uploadTokenError(err error) (int, string) {
+	if errors.Is(err, errUploadTokenExpired) {
+		return http.StatusGone, "This upload link has expired, scan a new QR code"
+	}
+	return http.StatusNotFound, "Upload link not found"
+}
+
+// renderUploadPage writes the phone upload page
+func renderUploadPage(c *gin.Context, status int, data uploadPageData) {
+	c.Header("Content-Security-Policy", uploadPageContentSecurityPolicy)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := uploadPage.Execute(c.Writer, data); err != nil {
+		fmt.Printf("Error rendering upload page: %v\n", err)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupUploadTokenRouter creates a router exposing the phone upload routes
+func setupUploadTokenRouter(handler *ProjectsHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	api := router.Group("/api")
+	{
+		api.POST("/projects/:id/upload-tokens", handler.CreateUploadToken)
+		api.GET("/projects/:id/upload-tokens/:token/qr", handler.GetUploadTokenQR)
+		api.DELETE("/projects/:id/upload-tokens/:token", handler.RevokeUploadToken)
+		api.GET("/upload/:token", handler.GetUploadPage)
+		api.POST("/upload/:token", handler.UploadWithToken)
+	}
+
+	return router
+}
+
+// uploadPhotos posts files to a phone upload URL with the given Accept header
+func uploadPhotos(router http.Handler, token, accept string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/upload/"+token, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Accept", accept)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestUploadTokens tests creating a phone upload token and uploading photos with it
+func TestUploadTokens(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	router := setupUploadTokenRouter(NewProjectsHandler(tmpDir))
+
+	project := models.Project{Name: "Benchy", Path: tmpDir}
+	db.Create(&project)
+	os.WriteFile(filepath.Join(tmpDir, "image.jpg"), []byte("existing"), 0644)
+
+	w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/upload-tokens", project.ID), "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created UploadTokenResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Token == "" || !strings.HasSuffix(created.UploadURL, "/api/upload/"+created.Token) {
+		t.Fatalf("Unexpected token response: %+v", created)
+	}
+	if remaining := time.Until(created.ExpiresAt); remaining < 29*time.Minute || remaining > 31*time.Minute {
+		t.Errorf("Expected the token to last 30 minutes, expires in %v", remaining)
+	}
+
+	t.Run("QRCode", func(t *testing.T) {
+		w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/upload-tokens/%s/qr", project.ID, created.Token), "")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("Expected PNG, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if _, err := png.Decode(w.Body); err != nil {
+			t.Errorf("Expected a valid PNG: %v", err)
+		}
+
+		if w := sendJSON(router, "GET", fmt.Sprintf("/api/projects/%d/upload-tokens/%s/qr", project.ID+1, created.Token), ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for another project, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		w := sendJSON(router, "GET", "/api/upload/"+created.Token, "")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<form") || !strings.Contains(w.Body.String(), "Benchy") {
+			t.Errorf("Expected upload page, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Header().Get("Content-Security-Policy"), "form-action 'self'") {
+			t.Errorf("Expected a policy allowing the form, got %q", w.Header().Get("Content-Security-Policy"))
+		}
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		w := uploadPhotos(router, created.Token, "application/json", map[string]string{"image.jpg": "JPEG", "notes.txt": "text"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response struct {
+			Files  []models.ProjectFile `json:"files"`
+			Errors []string             `json:"errors"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Files) != 1 || response.Files[0].Filename != "image_1.jpg" || len(response.Errors) != 1 {
+			t.Fatalf("Expected one photo saved under a free name and one rejected file, got %+v", response)
+		}
+		if content, _ := os.ReadFile(filepath.Join(tmpDir, "image.jpg")); string(content) != "existing" {
+			t.Errorf("Expected the existing photo to be kept, got %q", content)
+		}
+
+		var token models.UploadToken
+		db.Where("token = ?", created.Token).First(&token)
+		if token.Uploaded != 1 {
+			t.Errorf("Expected 1 upload counted, got %d", token.Uploaded)
+		}
+
+		w = uploadPhotos(router, created.Token, "text/html,application/xhtml+xml", map[string]string{"print.png": "PNG"})
+		if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "Uploaded 1 photo(s)") {
+			t.Errorf("Expected the page with the outcome, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		w := sendJSON(router, "DELETE", fmt.Sprintf("/api/projects/%d/upload-tokens/%s", project.ID, created.Token), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := uploadPhotos(router, created.Token, "application/json", map[string]string{"late.jpg": "JPEG"}); w.Code != http.StatusGone {
+			t.Errorf("Expected status %d after revoking, got %d", http.StatusGone, w.Code)
+		}
+		if w := sendJSON(router, "GET", "/api/upload/unknown", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for an unknown token, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("InvalidExpiry", func(t *testing.T) {
+		w := sendJSON(router, "POST", fmt.Sprintf("/api/projects/%d/upload-tokens", project.ID), `{"expires_in_minutes": 10000}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"3dshelf/internal/version"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader lets clients ask for an API version on unversioned routes,
+// and tells them which version answered
+const APIVersionHeader = "API-Version"
+
+// versionedPath matches the version segment of /api/v1/projects
+var versionedPath = regexp.MustCompile(`^/api/(v[0-9]+)(/|$)`)
+
+// APIVersions serves versioned routes such as /api/v1/projects from the
+// routes registered under /api, so route patterns stay the same for the
+// limits, logging and instrumentation keyed on them. Unversioned /api routes
+// keep working as the current version but are deprecated: they answer with
+// Deprecation and a Link to their versioned successor, plus Sunset when
+// sunset is set. Clients may pin a version with the API-Version header;
+// unsupported versions are refused before reaching the router. Paths outside
+// /api are passed through untouched.
+func APIVersions(next http.Handler, sunset time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" && !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requested := r.Header.Get(APIVersionHeader)
+		pathVersion := ""
+		if match := versionedPath.FindStringSubmatch(r.URL.Path); match != nil {
+			pathVersion = match[1]
+		}
+
+		switch {
+		case pathVersion != "" && !version.SupportsAPI(pathVersion):
+			writeVersionError(w, http.StatusNotFound, "Unsupported API version", pathVersion)
+			return
+		case requested != "" && !version.SupportsAPI(requested):
+			writeVersionError(w, http.StatusBadRequest, "Unsupported API version", requested)
+			return
+		case requested != "" && pathVersion != "" && requested != pathVersion:
+			writeVersionError(w, http.StatusBadRequest, "API-Version header does not match the path", requested)
+			return
+		}
+
+		served := pathVersion
+		if served == "" {
+			served = requested
+		}
+		if served == "" {
+			served = version.CurrentAPI()
+		}
+		w.Header().Set(APIVersionHeader, served)
+
+		if pathVersion == "" {
+			successor := "/api/" + served + strings.TrimPrefix(r.URL.Path, "/api")
+			w.Header().Set("Deprecation", "true")
+			w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Route the request as the unversioned path it was registered under
+		routed := r.Clone(r.Context())
+		routed.URL.Path = "/api" + strings.TrimPrefix(r.URL.Path, "/api/"+pathVersion)
+		if routed.URL.RawPath != "" {
+			routed.URL.RawPath = "/api" + strings.TrimPrefix(r.URL.RawPath, "/api/"+pathVersion)
+		}
+		next.ServeHTTP(w, routed)
+	})
+}
+
+// writeVersionError answers a request for a version the server doesn't serve
+func writeVersionError(w http.ResponseWriter, status int, message, requested string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":     message,
+		"requested": requested,
+		"supported": version.APIVersions,
+	})
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAPIVersions tests serving versioned and deprecated unversioned routes
+func TestAPIVersions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/projects/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"route": c.FullPath(), "id": c.Param("id")})
+	})
+	router.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	sunset := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)
+	handler := APIVersions(router, sunset)
+
+	testCases := []struct {
+		name         string
+		path         string
+		header       string
+		expectedCode int
+		deprecated   bool
+	}{
+		{"Versioned", "/api/v1/projects/3", "", http.StatusOK, false},
+		{"Versioned pinned", "/api/v1/projects/3", "v1", http.StatusOK, false},
+		{"Unversioned", "/api/projects/3", "", http.StatusOK, true},
+		{"Unversioned pinned", "/api/projects/3", "v1", http.StatusOK, true},
+		{"Unknown path version", "/api/v9/projects/3", "", http.StatusNotFound, false},
+		{"Unknown header version", "/api/projects/3", "v9", http.StatusBadRequest, false},
+		{"Outside the API", "/health", "", http.StatusOK, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(APIVersionHeader, tc.header)
+			}
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+			if deprecated := w.Header().Get("Deprecation") == "true"; deprecated != tc.deprecated {
+				t.Errorf("Expected deprecated=%v, got headers %v", tc.deprecated, w.Header())
+			}
+			if tc.deprecated {
+				if link := w.Header().Get("Link"); link != `</api/v1/projects/3>; rel="successor-version"` {
+					t.Errorf("Expected a link to the versioned route, got %q", link)
+				}
+				if got := w.Header().Get("Sunset"); got != "Wed, 30 Jun 2027 00:00:00 GMT" {
+					t.Errorf("Expected the sunset date, got %q", got)
+				}
+			}
+			if tc.expectedCode == http.StatusOK && tc.path != "/health" && w.Header().Get(APIVersionHeader) != "v1" {
+				t.Errorf("Expected the served version v1, got %q", w.Header().Get(APIVersionHeader))
+			}
+		})
+	}
+}
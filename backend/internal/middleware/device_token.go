@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"3dshelf/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeviceTokenKey is the context key holding the *models.DeviceToken a request
+// was authenticated with
+const DeviceTokenKey = "device_token"
+
+// DeviceTokenHeader carries a device token for clients that can't send an
+// Authorization header, named like the header OctoPrint plugins already send
+const DeviceTokenHeader = "X-Api-Key"
+
+// RequireDeviceToken only lets through requests carrying a device token that
+// hasn't been revoked, as "Authorization: Bearer <token>" or in X-Api-Key,
+// and records when each token was last used
+func RequireDeviceToken(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(DeviceTokenHeader)
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			token = strings.TrimSpace(bearer)
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Device token required"})
+			return
+		}
+
+		var deviceToken models.DeviceToken
+		err := db.WithContext(c.Request.Context()).Where("token_hash = ?", models.HashDeviceToken(token)).First(&deviceToken).Error
+		if err != nil || !deviceToken.Usable() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or revoked device token"})
+			return
+		}
+
+		now := time.Now()
+		deviceToken.LastUsedAt = &now
+		db.WithContext(c.Request.Context()).Model(&deviceToken).UpdateColumn("last_used_at", now)
+
+		c.Set(DeviceTokenKey, &deviceToken)
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"3dshelf/pkg/features"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireFeature answers 404 for routes of a subsystem whose feature flag is
+// off, so dark-launched endpoints look absent until they are enabled
+func RequireFeature(flags *features.Flags, flag features.Flag) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !flags.Enabled(flag) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Feature not enabled"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/pkg/features"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequireFeature tests that gated routes answer 404 while their flag is off
+func TestRequireFeature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	flags := features.New()
+	router := gin.New()
+	router.GET("/api/watch", RequireFeature(flags, features.Watcher), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/watch", nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusNotFound {
+		t.Errorf("Expected status %d while the flag is off, got %d", http.StatusNotFound, code)
+	}
+	flags.Set(map[features.Flag]bool{features.Watcher: true})
+	if code := get(); code != http.StatusOK {
+		t.Errorf("Expected status %d once the flag is on, got %d", http.StatusOK, code)
+	}
+}
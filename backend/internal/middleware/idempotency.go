@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"3dshelf/internal/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key identifying a request across retries
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader marks responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyKeyTTL is how long a completed request is replayed for
+	idempotencyKeyTTL = 24 * time.Hour
+
+	// idempotencyPendingTTL is how long a request may run before its key is
+	// considered abandoned, such as by an instance that crashed mid-upload
+	idempotencyPendingTTL = time.Hour
+
+	// maxIdempotentResponseBytes bounds stored responses; larger ones are not replayed
+	maxIdempotentResponseBytes = 1 << 20
+)
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry: the first request with a key runs and its successful response is
+// stored, and retries with the same key and body get that response back
+// without running again. Reusing a key for a different request answers 422,
+// and retrying while the first request is still running answers 409. Failed
+// requests release their key so they can be retried.
+func Idempotency(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid Idempotency-Key"})
+			return
+		}
+
+		// Outcomes are stored even when the client has gone away, which is
+		// exactly when it will retry
+		tx := db.WithContext(context.WithoutCancel(c.Request.Context()))
+
+		now := time.Now()
+		if err := tx.Where("created_at < ? OR (completed_at IS NULL AND created_at < ?)",
+			now.Add(-idempotencyKeyTTL), now.Add(-idempotencyPendingTTL)).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			return
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.IdempotencyKey{
+			Key:       key,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.RequestURI(),
+			CreatedAt: now,
+		})
+		if result.Error != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			replayIdempotent(c, tx, key)
+			return
+		}
+
+		fingerprint := newFingerprint(c.Request)
+		body := &teeBody{Reader: io.TeeReader(c.Request.Body, fingerprint), Closer: c.Request.Body}
+		c.Request.Body = body
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		stored := status >= 200 && status < 300 && !writer.overflow
+		if stored {
+			// Handlers may stop reading early; the fingerprint covers the whole body
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				stored = false
+			}
+		}
+		if !stored {
+			if err := tx.Delete(&models.IdempotencyKey{}, "key = ?", key).Error; err != nil {
+				fmt.Printf("Warning: Failed to release Idempotency-Key %q: %v\n", key, err)
+			}
+			return
+		}
+
+		completed := time.Now()
+		if err := tx.Model(&models.IdempotencyKey{}).Where("key = ?", key).Updates(map[string]any{
+			"fingerprint":  fingerprint.Sum(),
+			"status_code":  status,
+			"content_type": writer.Header().Get("Content-Type"),
+			"response":     writer.body.Bytes(),
+			"completed_at": &completed,
+		}).Error; err != nil {
+			fmt.Printf("Warning: Failed to store response for Idempotency-Key %q: %v\n", key, err)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key was already used
+func replayIdempotent(c *gin.Context, tx *gorm.DB, key string) {
+	var record models.IdempotencyKey
+	err := tx.First(&record, "key = ?", key).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+	// A record that vanished was just released by a failed request
+	if err != nil || record.CompletedAt == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	fingerprint := newFingerprint(c.Request)
+	if _, err := io.Copy(fingerprint, c.Request.Body); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	if fingerprint.Sum() != record.Fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Response)
+	c.Abort()
+}
+
+// fingerprint hashes a request's method, URI and body. Multipart boundaries
+// are left out, so a client that re-encodes the same form on retry produces
+// the same fingerprint.
+type fingerprint struct {
+	hash     hash.Hash
+	boundary []byte
+	pending  []byte
+}
+
+func newFingerprint(r *http.Request) *fingerprint {
+	f := &fingerprint{hash: sha256.New()}
+	fmt.Fprintf(f.hash, "%s %s\n", r.Method, r.URL.RequestURI())
+	if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		f.boundary = []byte(params["boundary"])
+	}
+	return f
+}
+
+func (f *fingerprint) Write(p []byte) (int, error) {
+	if len(f.boundary) == 0 {
+		return f.hash.Write(p)
+	}
+
+	// Hold back a boundary's length minus one, which may be the start of a
+	// boundary split across writes
+	buf := bytes.ReplaceAll(append(f.pending, p...), f.boundary, nil)
+	keep := min(len(buf), len(f.boundary)-1)
+	f.hash.Write(buf[:len(buf)-keep])
+	f.pending = append(f.pending[:0], buf[len(buf)-keep:]...)
+	return len(p), nil
+}
+
+// Sum returns the hex-encoded fingerprint of everything written
+func (f *fingerprint) Sum() string {
+	f.hash.Write(f.pending)
+	f.pending = nil
+	return hex.EncodeToString(f.hash.Sum(nil))
+}
+
+// teeBody is a request body read through the fingerprint
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// recordingWriter keeps a copy of the response body for replay
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	if w.overflow || w.body.Len()+len(data) > maxIdempotentResponseBytes {
+		w.overflow = true
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestIdempotency tests replaying, rejecting and releasing idempotency keys
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "idempotency.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	created := 0
+	router := gin.New()
+	router.POST("/api/projects", Idempotency(db), func(c *gin.Context) {
+		var request struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil || request.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		created++
+		c.JSON(http.StatusCreated, gin.H{"id": created, "name": request.Name})
+	})
+	router.POST("/api/projects/1/files", Idempotency(db), func(c *gin.Context) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form"})
+			return
+		}
+		created++
+		c.JSON(http.StatusOK, gin.H{"uploaded": len(form.File["files"]), "upload": created})
+	})
+
+	send := func(path, key, contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("/api/projects", "create-1", "application/json", `{"name":"Benchy"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+
+	t.Run("Retry replays the response", func(t *testing.T) {
+		w := send("/api/projects", "create-1", "application/json", `{"name":"Benchy"}`)
+		if w.Code != http.StatusCreated || w.Body.String() != first.Body.String() {
+			t.Errorf("Expected the original response, got %d: %s", w.Code, w.Body.String())
+		}
+		if w.Header().Get(IdempotentReplayedHeader) != "true" {
+			t.Error("Expected the replayed header")
+		}
+		if created != 1 {
+			t.Errorf("Expected the handler to run once, ran %d times", created)
+		}
+	})
+
+	t.Run("Key reused for another body", func(t *testing.T) {
+		w := send("/api/projects", "create-1", "application/json", `{"name":"Calibration cube"}`)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("Failed requests release the key", func(t *testing.T) {
+		if w := send("/api/projects", "create-2", "application/json", `{}`); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400, got %d", w.Code)
+		}
+		if w := send("/api/projects", "create-2", "application/json", `{"name":"Gear"}`); w.Code != http.StatusCreated {
+			t.Errorf("Expected the retry to run, got %d", w.Code)
+		}
+	})
+
+	t.Run("Requests in progress", func(t *testing.T) {
+		db.Create(&models.IdempotencyKey{Key: "create-3", Method: "POST", Path: "/api/projects"})
+		if w := send("/api/projects", "create-3", "application/json", `{"name":"Gear"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("Without a key", func(t *testing.T) {
+		before := created
+		send("/api/projects", "", "application/json", `{"name":"Benchy"}`)
+		send("/api/projects", "", "application/json", `{"name":"Benchy"}`)
+		if created != before+2 {
+			t.Errorf("Expected both requests to run")
+		}
+	})
+
+	t.Run("Multipart retries with a new boundary", func(t *testing.T) {
+		form := func(content string) (string, string) {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, _ := writer.CreateFormFile("files", "benchy.stl")
+			part.Write([]byte(content))
+			writer.Close()
+			return writer.FormDataContentType(), body.String()
+		}
+
+		contentType, body := form("solid benchy")
+		upload := send("/api/projects/1/files", "upload-1", contentType, body)
+		if upload.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", upload.Code, upload.Body.String())
+		}
+		before := created
+
+		contentType, body = form("solid benchy")
+		if w := send("/api/projects/1/files", "upload-1", contentType, body); w.Body.String() != upload.Body.String() {
+			t.Errorf("Expected the re-encoded form to replay, got %d: %s", w.Code, w.Body.String())
+		}
+		contentType, body = form("solid gear")
+		if w := send("/api/projects/1/files", "upload-1", contentType, body); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422 for other file contents, got %d", w.Code)
+		}
+		if created != before {
+			t.Errorf("Expected retries not to upload again")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"3dshelf/internal/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProjectSlugs lets project routes take a project's slug in place of its
+// numeric ID: a slug in the :id parameter is replaced by the ID it belongs to
+// before the handler runs, so handlers only ever see IDs
+func ProjectSlugs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.Param("id")
+		if value == "" {
+			c.Next()
+			return
+		}
+		if _, err := strconv.ParseUint(value, 10, 64); err == nil {
+			c.Next()
+			return
+		}
+
+		var project models.Project
+		err := db.WithContext(c.Request.Context()).Select("id").Where("slug = ?", value).First(&project).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve project"})
+			return
+		}
+
+		for i := range c.Params {
+			if c.Params[i].Key == "id" {
+				c.Params[i].Value = strconv.FormatUint(uint64(project.ID), 10)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestProjectSlugs tests that project routes accept slugs in place of IDs
+func TestProjectSlugs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "slugs.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Project{}); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	first := models.Project{Name: "Desk Organizer", Path: "/library/Desk_Organizer"}
+	second := models.Project{Name: "Desk Organizer", Path: "/library/Desk_Organizer_2"}
+	db.Create(&first)
+	db.Create(&second)
+	if first.Slug != "desk-organizer" || second.Slug != "desk-organizer-2" {
+		t.Fatalf("Expected numbered slugs, got %q and %q", first.Slug, second.Slug)
+	}
+
+	router := gin.New()
+	router.Use(ProjectSlugs(db))
+	router.GET("/api/projects/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Param("id"))
+	})
+
+	testCases := []struct {
+		path         string
+		expectedCode int
+		expectedID   string
+	}{
+		{"/api/projects/desk-organizer-2", http.StatusOK, "2"},
+		{"/api/projects/1", http.StatusOK, "1"},
+		{"/api/projects/999", http.StatusOK, "999"},
+		{"/api/projects/missing", http.StatusNotFound, ""},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tc.path, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != tc.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.expectedCode, w.Code)
+		}
+		if tc.expectedID != "" && w.Body.String() != tc.expectedID {
+			t.Errorf("%s: expected the handler to see ID %s, got %s", tc.path, tc.expectedID, w.Body.String())
+		}
+	}
+}
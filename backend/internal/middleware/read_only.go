@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"3dshelf/pkg/maintenance"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnly refuses changes with 503 while the instance is in maintenance mode,
+// telling clients when to retry. Reads always pass, as do the routes in
+// allowed, looked up by method and route pattern like "PUT /api/admin/maintenance"
+// for the toggle itself and POST routes that don't change anything.
+func ReadOnly(mode *maintenance.Mode, allowed map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		state := mode.Get()
+		if !state.Enabled || allowed[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(state.RetryAfter().Seconds())))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Server is in read-only maintenance mode",
+			"message": state.Message,
+		})
+	}
+}
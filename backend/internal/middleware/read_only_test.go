@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/pkg/maintenance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestReadOnly tests that maintenance mode refuses changes but not reads or allowed routes
+func TestReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mode := maintenance.New()
+	router := gin.New()
+	router.Use(ReadOnly(mode, map[string]bool{"PUT /api/admin/maintenance": true}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/projects", ok)
+	router.POST("/api/projects", ok)
+	router.PUT("/api/admin/maintenance", ok)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("POST", "/api/projects"); w.Code != http.StatusOK {
+		t.Errorf("Expected changes to pass outside maintenance, got %d", w.Code)
+	}
+
+	mode.Set(maintenance.State{Enabled: true, Message: "Backing up", RetryAfterSeconds: 120})
+	w := send("POST", "/api/projects")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d during maintenance, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("Expected Retry-After 120, got %q", got)
+	}
+	if code := send("GET", "/api/projects").Code; code != http.StatusOK {
+		t.Errorf("Expected reads to pass during maintenance, got %d", code)
+	}
+	if code := send("PUT", "/api/admin/maintenance").Code; code != http.StatusOK {
+		t.Errorf("Expected allowed routes to pass during maintenance, got %d", code)
+	}
+}
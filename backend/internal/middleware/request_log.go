@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"3dshelf/pkg/requestlog"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userHeaders carry the user an authenticating reverse proxy let through,
+// such as Authelia or oauth2-proxy, in the order they are trusted
+var userHeaders = []string{"Remote-User", "X-Forwarded-User", "X-Auth-Request-User"}
+
+// maxLoggedErrorBytes bounds how much of an error response is kept to read
+// its message from
+const maxLoggedErrorBytes = 4096
+
+// countingBody counts the request body bytes a handler reads
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+// errorWriter keeps the start of error responses to log their message
+type errorWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorWriter) record(data []byte) {
+	if w.Status() < 400 || w.body.Len() >= maxLoggedErrorBytes {
+		return
+	}
+	w.body.Write(data[:min(len(data), maxLoggedErrorBytes-w.body.Len())])
+}
+
+// message is the "error" of a JSON error response, else the gin errors of the request
+func (w *errorWriter) message(c *gin.Context) string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(w.body.Bytes(), &response) == nil && response.Error != "" {
+		return response.Error
+	}
+	return strings.TrimSpace(c.Errors.String())
+}
+
+// RequestLog records every request in log: route, status, latency, bytes read
+// and written, the user and the error message of failures, so upload problems
+// can be looked into over the admin API. It should come before middleware
+// that limits or refuses bodies, so refused requests are counted too. A nil
+// log records nothing.
+func RequestLog(log *requestlog.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if log == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		body := &countingBody{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+		writer := &errorWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		entry := requestlog.Entry{
+			Time:            start,
+			Method:          c.Request.Method,
+			Route:           c.FullPath(),
+			Path:            c.Request.URL.Path,
+			Status:          c.Writer.Status(),
+			LatencyMs:       float64(time.Since(start)) / float64(time.Millisecond),
+			BytesIn:         body.read,
+			DeclaredBytesIn: c.Request.ContentLength,
+			BytesOut:        int64(max(c.Writer.Size(), 0)),
+			ClientIP:        c.ClientIP(),
+			UserAgent:       c.Request.UserAgent(),
+		}
+		if entry.Status >= 400 {
+			entry.Error = writer.message(c)
+		}
+		for _, header := range userHeaders {
+			if user := c.GetHeader(header); user != "" {
+				entry.User = user
+				break
+			}
+		}
+		if user, _, ok := c.Request.BasicAuth(); ok && entry.User == "" {
+			entry.User = user
+		}
+		log.Add(entry)
+	}
+}
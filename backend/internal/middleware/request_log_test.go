@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"3dshelf/pkg/requestlog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequestLog tests that requests are recorded with their sizes, user and errors
+func TestRequestLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := requestlog.New(10)
+	router := gin.New()
+	router.Use(RequestLog(log))
+	router.Use(LimitRequests(RouteLimit{MaxBodyBytes: 8}, nil))
+	router.POST("/api/projects/:id/files", func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "ok"})
+	})
+
+	send := func(body string, chunked bool) {
+		req, _ := http.NewRequest("POST", "/api/projects/3/files", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("Remote-User", "alex")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send("1234", false)
+	send("much too long", false)
+	send("much too long", true)
+
+	entries := log.Entries(requestlog.Filter{})
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	ok, declared, chunked := entries[2], entries[1], entries[0]
+
+	if ok.Route != "/api/projects/:id/files" || ok.Path != "/api/projects/3/files" || ok.Status != http.StatusCreated ||
+		ok.BytesIn != 4 || ok.BytesOut == 0 || ok.User != "alex" || ok.Error != "" {
+		t.Errorf("Unexpected entry for an accepted upload: %+v", ok)
+	}
+	if declared.Status != http.StatusRequestEntityTooLarge || declared.BytesIn != 0 || declared.DeclaredBytesIn != 13 ||
+		declared.Error != "Request body too large" {
+		t.Errorf("Unexpected entry for a refused upload: %+v", declared)
+	}
+	if chunked.Status != http.StatusBadRequest || chunked.DeclaredBytesIn != -1 || chunked.BytesIn == 0 ||
+		chunked.Error != "Failed to parse multipart form" {
+		t.Errorf("Unexpected entry for a cut off upload: %+v", chunked)
+	}
+}
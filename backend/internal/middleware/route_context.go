@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteContext tags the request context with the matched route so database
+// instrumentation can attribute slow queries to the endpoint that issued them
+func RouteContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := database.WithRoute(c.Request.Context(), c.Request.Method+" "+route)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"3dshelf/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRouteContext tests that the matched route is attached to the request context
+func TestRouteContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RouteContext())
+
+	var route string
+	router.GET("/api/projects/:id", func(c *gin.Context) {
+		route = database.RouteFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/projects/42", nil)
+	router.ServeHTTP(w, req)
+
+	if route != "GET /api/projects/:id" {
+		t.Errorf("Expected route 'GET /api/projects/:id', got '%s'", route)
+	}
+}
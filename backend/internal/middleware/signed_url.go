@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"3dshelf/pkg/signing"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedHashKey is the context key holding the content hash bound into a verified signed URL
+const SignedHashKey = "signed_sha256"
+
+// RequireSignedURL only lets requests through whose expires, sha256 and
+// signature query parameters were produced by the signer for this path
+func RequireSignedURL(signer *signing.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expiresUnix, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing or invalid signature"})
+			return
+		}
+
+		hash := c.Query("sha256")
+		err = signer.Verify(c.Request.URL.Path, time.Unix(expiresUnix, 0), hash, c.Query("signature"), time.Now())
+		if errors.Is(err, signing.ErrExpired) {
+			c.AbortWithStatusJSON(http.StatusGone, gin.H{"error": "Signed URL expired"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing or invalid signature"})
+			return
+		}
+
+		c.Set(SignedHashKey, hash)
+		c.Next()
+	}
+}
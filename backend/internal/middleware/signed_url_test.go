@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"3dshelf/pkg/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequireSignedURL tests accepting, expiring and rejecting signed URLs
+func TestRequireSignedURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := signing.New([]byte("secret"))
+	router := gin.New()
+	router.GET("/api/files/:id/download", RequireSignedURL(signer), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(SignedHashKey))
+	})
+
+	signedURL := func(path string, expires time.Time, hash string) string {
+		query := url.Values{}
+		query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+		query.Set("sha256", hash)
+		query.Set("signature", signer.Sign(path, expires, hash))
+		return path + "?" + query.Encode()
+	}
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{name: "Valid", url: signedURL("/api/files/1/download", time.Now().Add(time.Hour), "abc"), expectedStatus: http.StatusOK},
+		{name: "Expired", url: signedURL("/api/files/1/download", time.Now().Add(-time.Hour), "abc"), expectedStatus: http.StatusGone},
+		{name: "Signed for another file", url: strings.Replace(signedURL("/api/files/2/download", time.Now().Add(time.Hour), "abc"), "/2/", "/1/", 1), expectedStatus: http.StatusForbidden},
+		{name: "Unsigned", url: "/api/files/1/download", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusOK && w.Body.String() != "abc" {
+				t.Errorf("Expected signed hash in context, got %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AssemblyPart is one model of an assembly and how many of it one assembly needs
+type AssemblyPart struct {
+	// Filename is relative to the project directory, as on ProjectFile
+	Filename string `json:"filename"`
+	Quantity int    `json:"quantity"`
+
+	// FileID is the project file currently at Filename; set on responses only
+	FileID *uint `json:"file_id,omitempty"`
+}
+
+// Assembly groups the model files of a project that are printed together to
+// build one thing, like four legs and a top. Parts are keyed by filename so
+// rescans keep them.
+type Assembly struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ProjectID uint           `json:"project_id" gorm:"index;not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	Parts     []AssemblyPart `json:"parts" gorm:"serializer:json"`
+	Notes     string         `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Validate trims and checks the assembly's fields
+func (a *Assembly) Validate() error {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Notes = strings.TrimSpace(a.Notes)
+
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(a.Parts) == 0 {
+		return fmt.Errorf("an assembly needs at least one part")
+	}
+	seen := make(map[string]bool, len(a.Parts))
+	for _, part := range a.Parts {
+		if part.Quantity < 1 {
+			return fmt.Errorf("quantity of %s must be at least 1", part.Filename)
+		}
+		if seen[part.Filename] {
+			return fmt.Errorf("%s is listed more than once", part.Filename)
+		}
+		seen[part.Filename] = true
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BOMItemKind distinguishes printed parts from purchased hardware in a bill of materials
+type BOMItemKind string
+
+const (
+	// BOMPrinted items are printed from a project file, possibly another project's
+	BOMPrinted BOMItemKind = "printed"
+	// BOMHardware items are bought, like screws or bearings; they are not tracked in inventory
+	BOMHardware BOMItemKind = "hardware"
+)
+
+// BOMItem is one line of a project's bill of materials
+type BOMItem struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	ProjectID uint        `json:"project_id" gorm:"index;not null"`
+	Kind      BOMItemKind `json:"kind" gorm:"not null"`
+
+	// FileID is the model printed for BOMPrinted items
+	FileID *uint `json:"file_id"`
+
+	Name      string    `json:"name" gorm:"not null"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate trims and checks the item's fields
+func (i *BOMItem) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+
+	switch i.Kind {
+	case BOMPrinted:
+		if i.FileID == nil {
+			return fmt.Errorf("file_id is required for printed items")
+		}
+	case BOMHardware:
+		if i.Name == "" {
+			return fmt.Errorf("name is required for hardware items")
+		}
+		i.FileID = nil
+	default:
+		return fmt.Errorf("unsupported kind: %s", i.Kind)
+	}
+
+	if i.Quantity < 1 {
+		return fmt.Errorf("quantity must be at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"3dshelf/pkg/units"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calibration records calibration results for a printer and filament combination.
+// Settings left empty were not measured by this calibration.
+type Calibration struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Printer string `json:"printer" gorm:"index;not null"`
+
+	// FilamentID ties the results to one spool; Material covers every spool of a material
+	FilamentID *uint  `json:"filament_id" gorm:"index"`
+	Material   string `json:"material"`
+
+	// Flow is the extrusion multiplier, e.g. 0.98
+	Flow            *float64 `json:"flow"`
+	PressureAdvance *float64 `json:"pressure_advance"`
+
+	// NozzleTempC is the best temperature found with a temperature tower
+	NozzleTempC *int `json:"nozzle_temp_c"`
+
+	Notes        string    `json:"notes" gorm:"type:text"`
+	CalibratedAt time.Time `json:"calibrated_at" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Display converts the quantities to the requested units; never persisted
+	Display units.Display `json:"display,omitempty" gorm:"-"`
+}
+
+// Validate fills in defaults and checks the measured values
+func (c *Calibration) Validate() error {
+	c.Printer = strings.TrimSpace(c.Printer)
+	if c.Printer == "" {
+		return fmt.Errorf("printer is required")
+	}
+	c.Material = strings.TrimSpace(c.Material)
+	if c.FilamentID == nil && c.Material == "" {
+		return fmt.Errorf("filament_id or material is required")
+	}
+
+	if c.Flow == nil && c.PressureAdvance == nil && c.NozzleTempC == nil {
+		return fmt.Errorf("at least one of flow, pressure_advance or nozzle_temp_c is required")
+	}
+	if c.Flow != nil && (*c.Flow <= 0 || *c.Flow > 2) {
+		return fmt.Errorf("flow must be between 0 and 2")
+	}
+	if c.PressureAdvance != nil && (*c.PressureAdvance < 0 || *c.PressureAdvance > 2) {
+		return fmt.Errorf("pressure_advance must be between 0 and 2")
+	}
+	if c.NozzleTempC != nil && (*c.NozzleTempC < 100 || *c.NozzleTempC > 500) {
+		return fmt.Errorf("nozzle_temp_c must be between 100 and 500")
+	}
+
+	if c.CalibratedAt.IsZero() {
+		c.CalibratedAt = time.Now()
+	}
+	return nil
+}
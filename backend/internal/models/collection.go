@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Collection groups projects imported together from a remote collection or list
+type Collection struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	SourceURL string    `json:"source_url" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Aggregate computed by list queries; never persisted
+	ProjectCount int64 `json:"project_count" gorm:"->;-:migration"`
+
+	// Relationships
+	Projects []Project `json:"projects,omitempty" gorm:"foreignKey:CollectionID"`
+}
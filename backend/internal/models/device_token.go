@@ -0,0 +1,44 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// DeviceToken lets a printer, such as an OctoPrint plugin, list and download
+// the G-code of the projects it was issued for and nothing else. Only a hash
+// of the token is stored; the token itself is shown once when it is issued.
+type DeviceToken struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+
+	TokenHash string `json:"-" gorm:"uniqueIndex;not null"`
+
+	// Prefix is the start of the token, to tell tokens apart in listings
+	Prefix string `json:"prefix"`
+
+	// ProjectIDs are the projects whose G-code the token may fetch
+	ProjectIDs []uint `json:"project_ids" gorm:"serializer:json"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HashDeviceToken is the hash a device token is stored and looked up by
+func HashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Usable reports whether the token has not been revoked
+func (t *DeviceToken) Usable() bool {
+	return t.RevokedAt == nil
+}
+
+// Allows reports whether the token may fetch files of the project
+func (t *DeviceToken) Allows(projectID uint) bool {
+	return slices.Contains(t.ProjectIDs, projectID)
+}
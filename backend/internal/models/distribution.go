@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// DistributionKind is how a project was published for sharing
+type DistributionKind string
+
+const (
+	// DistributionIPFS projects are added to and pinned on an IPFS node
+	DistributionIPFS DistributionKind = "ipfs"
+	// DistributionTorrent projects are packed into a BitTorrent metainfo file
+	DistributionTorrent DistributionKind = "torrent"
+)
+
+// DistributionKinds are the kinds projects can be published as
+var DistributionKinds = []DistributionKind{DistributionIPFS, DistributionTorrent}
+
+// Distribution records a project published to a peer-to-peer network and the
+// content ID it is found by there
+type Distribution struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	ProjectID uint             `json:"project_id" gorm:"index;not null"`
+	Kind      DistributionKind `json:"kind" gorm:"not null"`
+
+	// ContentID is the directory's CID on IPFS, or the torrent's info hash
+	ContentID string `json:"content_id" gorm:"index;not null"`
+	// URI is the ipfs:// URI or magnet link peers fetch the project with
+	URI string `json:"uri"`
+
+	FileCount int   `json:"file_count"`
+	Size      int64 `json:"size"`
+
+	// Torrent is the .torrent file of torrent distributions
+	Torrent []byte `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"3dshelf/pkg/units"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultFilamentDiameter is the diameter assumed when none is given, in millimetres
+const defaultFilamentDiameter = 1.75
+
+// DefaultMaterialDensity is the density assumed for unknown materials, PLA's, in g/cm³
+const DefaultMaterialDensity = 1.24
+
+// materialDensities are typical densities of filament materials, in g/cm³
+var materialDensities = map[string]float64{
+	"PLA":   1.24,
+	"PETG":  1.27,
+	"ABS":   1.04,
+	"ASA":   1.07,
+	"TPU":   1.21,
+	"PC":    1.20,
+	"PA":    1.14,
+	"NYLON": 1.14,
+	"HIPS":  1.04,
+	"PVA":   1.23,
+	"PP":    0.90,
+}
+
+// MaterialDensity returns the typical density of a filament material in g/cm³,
+// and whether the material is known. Variants like "PLA+" or "PETG-CF" take
+// their base material's.
+func MaterialDensity(material string) (float64, bool) {
+	material = strings.ToUpper(strings.TrimSpace(material))
+	if density, ok := materialDensities[material]; ok {
+		return density, true
+	}
+	base, _, _ := strings.Cut(strings.TrimRight(material, "+"), "-")
+	if density, ok := materialDensities[strings.TrimRight(base, "+")]; ok {
+		return density, true
+	}
+	return DefaultMaterialDensity, false
+}
+
+// Filament is a spool of printing material on the shelf
+type Filament struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"not null"`
+	Brand    string `json:"brand"`
+	Material string `json:"material"`
+	Color    string `json:"color"`
+
+	DiameterMM  float64 `json:"diameter_mm"`
+	NozzleTempC int     `json:"nozzle_temp_c"`
+	BedTempC    int     `json:"bed_temp_c"`
+
+	// WeightGrams is the net filament weight of a full spool; RemainingGrams what is left
+	WeightGrams    float64 `json:"weight_grams"`
+	RemainingGrams float64 `json:"remaining_grams"`
+
+	Notes     string    `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Display converts the quantities to the requested units; never persisted
+	Display units.Display `json:"display,omitempty" gorm:"-"`
+}
+
+// Validate fills in defaults and checks the spool's fields
+func (f *Filament) Validate() error {
+	f.Brand = strings.TrimSpace(f.Brand)
+	f.Material = strings.TrimSpace(f.Material)
+	f.Color = strings.TrimSpace(f.Color)
+
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		f.Name = strings.TrimSpace(strings.Join([]string{f.Brand, f.Material, f.Color}, " "))
+	}
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if f.DiameterMM == 0 {
+		f.DiameterMM = defaultFilamentDiameter
+	}
+	if f.DiameterMM < 0 || f.NozzleTempC < 0 || f.BedTempC < 0 {
+		return fmt.Errorf("diameter_mm, nozzle_temp_c and bed_temp_c must not be negative")
+	}
+	if f.WeightGrams < 0 || f.RemainingGrams < 0 {
+		return fmt.Errorf("weight_grams and remaining_grams must not be negative")
+	}
+	if f.WeightGrams > 0 && f.RemainingGrams > f.WeightGrams {
+		return fmt.Errorf("remaining_grams must not exceed weight_grams")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// FileActivity records when a file was last downloaded and printed. Like
+// FileProfile it is keyed by filename rather than file ID, so rescans keep it.
+type FileActivity struct {
+	ID        uint `json:"-" gorm:"primaryKey"`
+	ProjectID uint `json:"-" gorm:"uniqueIndex:idx_file_activity;not null"`
+
+	// Filename is relative to the project directory, as on ProjectFile
+	Filename string `json:"-" gorm:"uniqueIndex:idx_file_activity;not null"`
+
+	LastDownloadedAt *time.Time `json:"last_downloaded_at"`
+	LastPrintedAt    *time.Time `json:"last_printed_at"`
+	UpdatedAt        time.Time  `json:"-"`
+}
+
+// FilenameKeyed are the records kept by a file's name rather than its ID,
+// which follow the file when it is renamed and go with it when it is deleted
+var FilenameKeyed = []interface{}{&FileProfile{}, &FileActivity{}}
+
+// LastUsedAt is the later of the last download and print, or nil when the
+// file was neither
+func (a *FileActivity) LastUsedAt() *time.Time {
+	switch {
+	case a.LastDownloadedAt == nil:
+		return a.LastPrintedAt
+	case a.LastPrintedAt == nil || a.LastDownloadedAt.After(*a.LastPrintedAt):
+		return a.LastDownloadedAt
+	default:
+		return a.LastPrintedAt
+	}
+}
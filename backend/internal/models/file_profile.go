@@ -0,0 +1,64 @@
+package models
+
+import (
+	"3dshelf/pkg/units"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SupportMode is where a model needs supports
+type SupportMode string
+
+const (
+	SupportsNone       SupportMode = "none"
+	SupportsBuildPlate SupportMode = "build_plate"
+	SupportsEverywhere SupportMode = "everywhere"
+)
+
+// FileProfile holds the recommended print settings of one model file. It is
+// keyed by filename rather than file ID so rescans keep it.
+type FileProfile struct {
+	ID        uint `json:"-" gorm:"primaryKey"`
+	ProjectID uint `json:"-" gorm:"uniqueIndex:idx_file_profile;not null"`
+
+	// Filename is relative to the project directory, as on ProjectFile
+	Filename string `json:"-" gorm:"uniqueIndex:idx_file_profile;not null"`
+
+	LayerHeight   *float64    `json:"layer_height"`
+	InfillPercent *int        `json:"infill_percent"`
+	InfillPattern string      `json:"infill_pattern"`
+	Supports      SupportMode `json:"supports"`
+	Orientation   string      `json:"orientation" gorm:"type:text"`
+	Notes         string      `json:"notes" gorm:"type:text"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+
+	// Display converts the quantities to the requested units; never persisted
+	Display units.Display `json:"display,omitempty" gorm:"-"`
+}
+
+// Validate trims and checks the recommendations
+func (p *FileProfile) Validate() error {
+	p.InfillPattern = strings.TrimSpace(p.InfillPattern)
+	p.Orientation = strings.TrimSpace(p.Orientation)
+	p.Notes = strings.TrimSpace(p.Notes)
+
+	if p.LayerHeight != nil && (*p.LayerHeight <= 0 || *p.LayerHeight > 2) {
+		return fmt.Errorf("layer_height must be between 0 and 2")
+	}
+	if p.InfillPercent != nil && (*p.InfillPercent < 0 || *p.InfillPercent > 100) {
+		return fmt.Errorf("infill_percent must be between 0 and 100")
+	}
+	switch p.Supports {
+	case "", SupportsNone, SupportsBuildPlate, SupportsEverywhere:
+	default:
+		return fmt.Errorf("unsupported supports value: %s", p.Supports)
+	}
+	return nil
+}
+
+// IsEmpty reports whether the profile recommends nothing
+func (p *FileProfile) IsEmpty() bool {
+	return p.LayerHeight == nil && p.InfillPercent == nil && p.InfillPattern == "" &&
+		p.Supports == "" && p.Orientation == "" && p.Notes == ""
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// GeometryFingerprint caches the shape fingerprint and bounding box of a
+// model's content, keyed by its content hash so copies share one and edits
+// get a new one. A model that can't be read keeps its error instead, so it
+// isn't read again.
+type GeometryFingerprint struct {
+	Hash string `json:"hash" gorm:"primaryKey"`
+
+	// Version is the fingerprint algorithm's; other versions are recomputed
+	Version int `json:"version"`
+
+	Vector    []float64 `json:"vector,omitempty" gorm:"serializer:json"`
+	Triangles int       `json:"triangles"`
+
+	// Size is the extent of the model's bounding box along X, Y and Z, in model units
+	Size []float64 `json:"size,omitempty" gorm:"serializer:json"`
+
+	// Volume is the volume the model encloses, in cubic model units
+	Volume *float64 `json:"volume,omitempty"`
+
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyKey records a mutating request sent with an Idempotency-Key
+// header and the response it produced, replayed when the request is retried
+type IdempotencyKey struct {
+	Key         string     `json:"key" gorm:"primaryKey"`
+	Method      string     `json:"method" gorm:"not null"`
+	Path        string     `json:"path" gorm:"not null"`
+	Fingerprint string     `json:"fingerprint"`
+	StatusCode  int        `json:"status_code"`
+	ContentType string     `json:"content_type"`
+	Response    []byte     `json:"-"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// ImportJobStatus represents the lifecycle state of an import job
+type ImportJobStatus string
+
+const (
+	ImportJobPending ImportJobStatus = "pending"
+	ImportJobRunning ImportJobStatus = "running"
+	// ImportJobRateLimited jobs are waiting for the remote API to accept requests again
+	ImportJobRateLimited ImportJobStatus = "rate_limited"
+	ImportJobCompleted   ImportJobStatus = "completed"
+	ImportJobFailed      ImportJobStatus = "failed"
+)
+
+// ImportItemStatus represents the state of one model within an import job
+type ImportItemStatus string
+
+const (
+	ImportItemPending  ImportItemStatus = "pending"
+	ImportItemImported ImportItemStatus = "imported"
+	ImportItemFailed   ImportItemStatus = "failed"
+)
+
+// ImportJob records a background import of a remote collection or an export archive
+type ImportJob struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	SourceURL    string          `json:"source_url" gorm:"not null"`
+	Provider     string          `json:"provider" gorm:"not null"`
+	Status       ImportJobStatus `json:"status" gorm:"index;not null"`
+	CollectionID *uint           `json:"collection_id"`
+	Total        int             `json:"total"`
+	Imported     int             `json:"imported"`
+	Failed       int             `json:"failed"`
+	Error        string          `json:"error,omitempty"`
+
+	// RetryAfter is when a rate limited job continues
+	RetryAfter *time.Time `json:"retry_after,omitempty"`
+
+	// Archive is where an uploaded export archive is kept until every model in
+	// it is imported; it is empty for remote collections
+	Archive string `json:"-"`
+
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	Items []ImportItem `json:"items,omitempty" gorm:"foreignKey:JobID"`
+}
+
+// Finished reports whether the job has stopped for good
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportJobCompleted || j.Status == ImportJobFailed
+}
+
+// ImportItem tracks one model of an import job
+type ImportItem struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	JobID     uint             `json:"job_id" gorm:"index;not null"`
+	RemoteID  string           `json:"remote_id" gorm:"not null"`
+	Name      string           `json:"name"`
+	URL       string           `json:"url"`
+	Status    ImportItemStatus `json:"status" gorm:"not null"`
+	ProjectID *uint            `json:"project_id"`
+	Error     string           `json:"error,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
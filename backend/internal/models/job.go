@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus represents the lifecycle state of a queued background job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	// JobDead jobs failed on every attempt their retry policy allowed
+	JobDead      JobStatus = "dead"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Job is a unit of background work persisted in the job queue
+type Job struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Type string `json:"type" gorm:"index;not null"`
+	// Key deduplicates jobs: only one unfinished job of a type may hold a key
+	Key     string          `json:"key,omitempty" gorm:"index"`
+	Payload json.RawMessage `json:"payload"`
+	Status  JobStatus       `json:"status" gorm:"index;not null"`
+
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	Error       string `json:"error,omitempty"`
+
+	// LockedBy names the worker running the job, which holds it until
+	// LockedUntil unless it renews the lease
+	LockedBy    string     `json:"locked_by,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// RunAt is when a pending job becomes due, pushed back between retries
+	RunAt      time.Time  `json:"run_at" gorm:"index"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Finished reports whether the job will not run again unless retried
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobDead || j.Status == JobCancelled
+}
+
+// DecodePayload unmarshals the job's payload into v
+func (j *Job) DecodePayload(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
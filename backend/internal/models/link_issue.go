@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// LinkIssueKind says what a link check found broken
+type LinkIssueKind string
+
+const (
+	// LinkIssueSource is a project source URL that no longer resolves
+	LinkIssueSource LinkIssueKind = "source_unreachable"
+	// LinkIssueMissingFile is a local file a README or file notes link to that is gone
+	LinkIssueMissingFile LinkIssueKind = "missing_file"
+)
+
+// LinkIssue is a broken link found by the last link check of a project. Issues
+// are replaced on every check, keeping when each was first seen.
+type LinkIssue struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	ProjectID uint          `json:"project_id" gorm:"uniqueIndex:idx_link_issue;not null"`
+	Kind      LinkIssueKind `json:"kind" gorm:"uniqueIndex:idx_link_issue;not null"`
+
+	// Target is the URL, or the path relative to the project directory
+	Target string `json:"target" gorm:"uniqueIndex:idx_link_issue;not null"`
+
+	// Referrer is the file linking to a missing file, such as README.md
+	Referrer string `json:"referrer,omitempty"`
+
+	// Detail is why the target is broken
+	Detail string `json:"detail"`
+
+	FirstSeenAt   time.Time `json:"first_seen_at"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
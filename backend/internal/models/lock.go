@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Lock is an advisory lock shared by every instance using the database
+type Lock struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Owner     string    `json:"owner" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OrderStatus is where an order stands on its way to the customer
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPrinting  OrderStatus = "printing"
+	OrderReady     OrderStatus = "ready"
+	OrderShipped   OrderStatus = "shipped"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+// OrderStatuses lists the supported order statuses, in fulfillment order
+var OrderStatuses = []OrderStatus{OrderPending, OrderPrinting, OrderReady, OrderShipped, OrderCancelled}
+
+// Open reports whether an order still has to be fulfilled
+func (s OrderStatus) Open() bool {
+	return s == OrderPending || s == OrderPrinting || s == OrderReady
+}
+
+// OrderItem is a project, or one model of it, and how many copies were ordered
+type OrderItem struct {
+	ProjectID uint  `json:"project_id"`
+	FileID    *uint `json:"file_id,omitempty"`
+	Quantity  int   `json:"quantity"`
+
+	// Description is free text such as a colour or size the customer asked for
+	Description string `json:"description,omitempty"`
+}
+
+// Order is a commission or sale of prints from the library, tracked until it
+// ships. Print jobs recorded with the order's ID count toward its items.
+type Order struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Customer string `json:"customer" gorm:"not null"`
+	Contact  string `json:"contact"`
+
+	// Reference is the order's number on the shop it came from, like Etsy
+	Reference string `json:"reference" gorm:"index"`
+
+	Items   []OrderItem `json:"items" gorm:"serializer:json"`
+	DueDate *time.Time  `json:"due_date" gorm:"index"`
+	Status  OrderStatus `json:"status" gorm:"index;not null"`
+	Price   float64     `json:"price"`
+
+	Notes     string    `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate fills in defaults and checks the order's fields
+func (o *Order) Validate() error {
+	o.Customer = strings.TrimSpace(o.Customer)
+	o.Contact = strings.TrimSpace(o.Contact)
+	o.Reference = strings.TrimSpace(o.Reference)
+	o.Notes = strings.TrimSpace(o.Notes)
+
+	if o.Customer == "" {
+		return fmt.Errorf("customer is required")
+	}
+	if o.Status == "" {
+		o.Status = OrderPending
+	}
+	if !slices.Contains(OrderStatuses, o.Status) {
+		return fmt.Errorf("unsupported status: %s", o.Status)
+	}
+	if o.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+
+	if len(o.Items) == 0 {
+		return fmt.Errorf("an order needs at least one item")
+	}
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.Description = strings.TrimSpace(item.Description)
+		if item.ProjectID == 0 {
+			return fmt.Errorf("project_id is required on every item")
+		}
+		if item.Quantity < 1 {
+			return fmt.Errorf("quantity must be at least 1")
+		}
+	}
+	return nil
+}
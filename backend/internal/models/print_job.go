@@ -0,0 +1,150 @@
+package models
+
+import (
+	"3dshelf/pkg/units"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PrintOutcome is how a print job ended
+type PrintOutcome string
+
+const (
+	PrintSucceeded PrintOutcome = "success"
+	PrintFailed    PrintOutcome = "failed"
+	PrintCancelled PrintOutcome = "cancelled"
+
+	// PrintInProgress is a print a printer reported started but not yet ended
+	PrintInProgress PrintOutcome = "printing"
+)
+
+// FailureReason is a structured cause of a failed print
+type FailureReason string
+
+const (
+	FailureWarping        FailureReason = "warping"
+	FailureAdhesion       FailureReason = "adhesion"
+	FailureClog           FailureReason = "clog"
+	FailurePowerLoss      FailureReason = "power_loss"
+	FailureLayerShift     FailureReason = "layer_shift"
+	FailureUnderExtrusion FailureReason = "under_extrusion"
+	FailureStringing      FailureReason = "stringing"
+	FailureSupports       FailureReason = "supports"
+	FailureFilamentRunout FailureReason = "filament_runout"
+	FailureReasonOther    FailureReason = "other"
+)
+
+// FailureReasons lists the supported failure reasons
+var FailureReasons = []FailureReason{
+	FailureWarping, FailureAdhesion, FailureClog, FailurePowerLoss, FailureLayerShift,
+	FailureUnderExtrusion, FailureStringing, FailureSupports, FailureFilamentRunout, FailureReasonOther,
+}
+
+// PrintJob records one physical print of a project
+type PrintJob struct {
+	ID        uint  `json:"id" gorm:"primaryKey"`
+	ProjectID uint  `json:"project_id" gorm:"index;not null"`
+	FileID    *uint `json:"file_id"`
+
+	Printer  string `json:"printer"`
+	Material string `json:"material"`
+
+	// FilamentID is the spool the print used, taken off its remaining weight
+	FilamentID *uint `json:"filament_id,omitempty"`
+
+	// OrderID is the order the print was made for, counted toward its items
+	OrderID *uint `json:"order_id,omitempty" gorm:"index"`
+
+	// ExternalID identifies the job on the printer that reported it, so the
+	// report of its end updates the record made when it started
+	ExternalID string `json:"external_id,omitempty" gorm:"index"`
+
+	// FilamentGrams, DurationSeconds and Cost record what the print consumed
+	FilamentGrams   float64 `json:"filament_grams"`
+	DurationSeconds int64   `json:"duration_seconds"`
+	Cost            float64 `json:"cost"`
+
+	Outcome PrintOutcome `json:"outcome" gorm:"not null"`
+
+	// FailureReasons tags why a failed print failed; FailureSettings records the
+	// settings suspected of causing it, e.g. {"bed_temp": "55"}
+	FailureReasons  []FailureReason   `json:"failure_reasons" gorm:"serializer:json"`
+	FailureSettings map[string]string `json:"failure_settings" gorm:"serializer:json"`
+
+	Notes      string     `json:"notes" gorm:"type:text"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	Project Project      `json:"-" gorm:"foreignKey:ProjectID"`
+	Media   []PrintMedia `json:"media,omitempty" gorm:"foreignKey:PrintJobID"`
+
+	// Display converts the quantities to the requested units; never persisted
+	Display units.Display `json:"display,omitempty" gorm:"-"`
+}
+
+// Validate fills in defaults and checks the recorded values
+func (j *PrintJob) Validate() error {
+	switch j.Outcome {
+	case "":
+		j.Outcome = PrintSucceeded
+	case PrintSucceeded, PrintFailed, PrintCancelled:
+	case PrintInProgress:
+		if j.FinishedAt != nil {
+			return fmt.Errorf("a finished print can't be in progress")
+		}
+	default:
+		return fmt.Errorf("unsupported outcome: %s", j.Outcome)
+	}
+
+	if j.FilamentGrams < 0 || j.DurationSeconds < 0 || j.Cost < 0 {
+		return fmt.Errorf("filament_grams, duration_seconds and cost must not be negative")
+	}
+
+	if j.StartedAt.IsZero() {
+		j.StartedAt = time.Now()
+	}
+	if j.FinishedAt != nil && j.FinishedAt.Before(j.StartedAt) {
+		return fmt.Errorf("finished_at must not be before started_at")
+	}
+
+	j.Printer = strings.TrimSpace(j.Printer)
+	j.Material = strings.TrimSpace(j.Material)
+	return j.validateFailure()
+}
+
+// validateFailure normalizes the failure tags, which only apply to failed prints
+func (j *PrintJob) validateFailure() error {
+	reasons := make([]FailureReason, 0, len(j.FailureReasons))
+	seen := make(map[FailureReason]bool)
+	for _, reason := range j.FailureReasons {
+		normalized := FailureReason(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(string(reason)))))
+		if !slices.Contains(FailureReasons, normalized) {
+			return fmt.Errorf("unsupported failure reason: %s", reason)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			reasons = append(reasons, normalized)
+		}
+	}
+	j.FailureReasons = reasons
+
+	settings := make(map[string]string, len(j.FailureSettings))
+	for key, value := range j.FailureSettings {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return fmt.Errorf("failure_settings keys must not be empty")
+		}
+		settings[key] = strings.TrimSpace(value)
+	}
+	j.FailureSettings = settings
+
+	if j.Outcome != PrintFailed && (len(j.FailureReasons) > 0 || len(j.FailureSettings) > 0) {
+		return fmt.Errorf("failure_reasons and failure_settings only apply to failed prints")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// PrintMediaDir is the project subdirectory print photos and time-lapses are stored under
+const PrintMediaDir = "prints"
+
+// PrintMediaKind is whether an attachment is a still photo or a video
+type PrintMediaKind string
+
+const (
+	PrintMediaPhoto PrintMediaKind = "photo"
+	PrintMediaVideo PrintMediaKind = "video"
+)
+
+// PrintMediaSource is where an attachment came from
+type PrintMediaSource string
+
+const (
+	PrintMediaUploaded  PrintMediaSource = "upload"
+	PrintMediaOctoPrint PrintMediaSource = "octoprint"
+)
+
+// PrintMedia is a photo or time-lapse of a print job's result
+type PrintMedia struct {
+	ID         uint `json:"id" gorm:"primaryKey"`
+	PrintJobID uint `json:"print_job_id" gorm:"index;not null"`
+	ProjectID  uint `json:"project_id" gorm:"index;not null"`
+
+	// Filename is relative to the project directory, under PrintMediaDir
+	Filename  string           `json:"filename" gorm:"not null"`
+	Kind      PrintMediaKind   `json:"kind" gorm:"not null"`
+	Source    PrintMediaSource `json:"source" gorm:"not null"`
+	Size      int64            `json:"size"`
+	CreatedAt time.Time        `json:"created_at"`
+
+	// Relationships
+	PrintJob PrintJob `json:"-" gorm:"foreignKey:PrintJobID"`
+}
+
+// PrintMediaKindOf classifies a filename as a photo or video, reporting false for anything else
+func PrintMediaKindOf(filename string) (PrintMediaKind, bool) {
+	switch {
+	case IsImageFile(filename):
+		return PrintMediaPhoto, true
+	case IsVideoFile(filename):
+		return PrintMediaVideo, true
+	default:
+		return "", false
+	}
+}
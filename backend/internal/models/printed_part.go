@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PrintedPart tracks physical stock of a printed part
+type PrintedPart struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"not null"`
+
+	// ProjectID and FileID link the part to the model it was printed from
+	ProjectID *uint `json:"project_id" gorm:"index"`
+	FileID    *uint `json:"file_id"`
+
+	Quantity int    `json:"quantity"`
+	Location string `json:"location"`
+	Bin      string `json:"bin"`
+
+	// LowStockThreshold flags the part when quantity drops to it; zero disables the alert
+	LowStockThreshold int `json:"low_stock_threshold"`
+
+	Notes     string    `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// LowStock is computed for responses; never persisted
+	LowStock bool `json:"low_stock" gorm:"-"`
+}
+
+// Validate trims and checks the part's fields
+func (p *PrintedPart) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Quantity < 0 {
+		return fmt.Errorf("quantity must not be negative")
+	}
+	if p.LowStockThreshold < 0 {
+		return fmt.Errorf("low_stock_threshold must not be negative")
+	}
+	return nil
+}
+
+// IsLowStock reports whether the part has reached its low-stock threshold
+func (p *PrintedPart) IsLowStock() bool {
+	return p.LowStockThreshold > 0 && p.Quantity <= p.LowStockThreshold
+}
+
+// AfterFind computes LowStock for loaded parts
+func (p *PrintedPart) AfterFind(tx *gorm.DB) error {
+	p.LowStock = p.IsLowStock()
+	return nil
+}
+
+// AfterSave computes LowStock for created and updated parts
+func (p *PrintedPart) AfterSave(tx *gorm.DB) error {
+	p.LowStock = p.IsLowStock()
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxBuildSizeMM bounds a build volume axis, well beyond any desktop printer
+const maxBuildSizeMM = 10000
+
+// Printer is a printer on the shelf and the volume it can print within
+type Printer struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"uniqueIndex;not null"`
+
+	// BuildXMM and BuildYMM are the bed's printable width and depth, BuildZMM
+	// the printable height, in millimetres
+	BuildXMM float64 `json:"build_x_mm" gorm:"not null"`
+	BuildYMM float64 `json:"build_y_mm" gorm:"not null"`
+	BuildZMM float64 `json:"build_z_mm" gorm:"not null"`
+
+	Notes     string    `json:"notes" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate trims and checks the printer's fields
+func (p *Printer) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, size := range []float64{p.BuildXMM, p.BuildYMM, p.BuildZMM} {
+		if size <= 0 || size > maxBuildSizeMM {
+			return fmt.Errorf("build_x_mm, build_y_mm and build_z_mm must be between 0 and %d", maxBuildSizeMM)
+		}
+	}
+	return nil
+}
+
+// Fits reports whether a model with the given bounding box size, in
+// millimetres, fits the build volume as it is oriented or turned a quarter
+// turn on the bed. Turning it at other angles or laying it on another side
+// may still fit it; that is left to the slicer.
+func (p Printer) Fits(size [3]float64) bool {
+	if size[2] > p.BuildZMM {
+		return false
+	}
+	return (size[0] <= p.BuildXMM && size[1] <= p.BuildYMM) ||
+		(size[1] <= p.BuildXMM && size[0] <= p.BuildYMM)
+}
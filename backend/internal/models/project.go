@@ -1,6 +1,10 @@
 package models
 
 import (
+	"3dshelf/pkg/gcode"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,25 +28,87 @@ const (
 	FileTypeGCode  FileType = "gcode"
 	FileTypeCAD    FileType = "cad"
 	FileTypeREADME FileType = "readme"
+	FileTypeImage  FileType = "image"
 	FileTypeOther  FileType = "other"
 )
 
+// ProjectLayout describes how a project is stored on disk
+type ProjectLayout string
+
+const (
+	// LayoutDirectory projects own a directory
+	LayoutDirectory ProjectLayout = "directory"
+	// LayoutFlat projects are loose files in the scan root; their path is a virtual identifier
+	LayoutFlat ProjectLayout = "flat"
+)
+
 // Project represents a 3D printing project
 type Project struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"not null"`
+	Slug        string         `json:"slug" gorm:"uniqueIndex"`
 	Path        string         `json:"path" gorm:"uniqueIndex;not null"`
 	Description string         `json:"description" gorm:"type:text"`
 	Status      ProjectStatus  `json:"status" gorm:"default:healthy"`
+	Layout      ProjectLayout  `json:"layout" gorm:"default:directory"`
 	LastScanned time.Time      `json:"last_scanned"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// LinkCheckFlagged is set while link checks hold the project inconsistent,
+	// so they only clear the status they set
+	LinkCheckFlagged bool `json:"-"`
+
+	// Metadata parsed from README front matter
+	Tags     []string `json:"tags" gorm:"serializer:json"`
+	License  string   `json:"license"`
+	Designer string   `json:"designer"`
+	Source   string   `json:"source"`
+
+	// ScanSettings override how this project is scanned
+	ScanSettings ProjectScanSettings `json:"scan_settings" gorm:"serializer:json"`
+
+	// CollectionID is set for projects imported as part of a remote collection
+	CollectionID *uint `json:"collection_id,omitempty" gorm:"index"`
+
+	// Public lists the project in the storefront catalogue, at ListPrice
+	// when set; 0 leaves the price to a quote
+	Public    bool    `json:"public" gorm:"index"`
+	ListPrice float64 `json:"list_price"`
+
+	// Rating is how much the project is liked, from 1 to MaxRating stars;
+	// 0 is unrated
+	Rating int `json:"rating"`
+
+	// CoverFilename is the file chosen as the project's cover; when empty, or
+	// once the file is gone, a cover is picked automatically
+	CoverFilename string `json:"cover_filename,omitempty"`
+
+	// Aggregates computed by list queries; never persisted
+	FileCount int64 `json:"file_count" gorm:"->;-:migration"`
+	TotalSize int64 `json:"total_size" gorm:"->;-:migration"`
+
 	// Relationships
 	Files []ProjectFile `json:"files,omitempty" gorm:"foreignKey:ProjectID"`
 }
 
+// MaxRating is the highest project rating
+const MaxRating = 5
+
+// ValidateRating checks that a rating is 0, for unrated, or 1 to MaxRating stars
+func ValidateRating(rating int) error {
+	if rating < 0 || rating > MaxRating {
+		return fmt.Errorf("rating must be between 0 and %d", MaxRating)
+	}
+	return nil
+}
+
+// IsFlat reports whether the project is made of loose files rather than a directory
+func (p *Project) IsFlat() bool {
+	return p.Layout == LayoutFlat
+}
+
 // ProjectFile represents a file within a project
 type ProjectFile struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
@@ -51,14 +117,48 @@ type ProjectFile struct {
 	Filepath  string    `json:"filepath" gorm:"not null"`
 	FileType  FileType  `json:"file_type" gorm:"not null"`
 	Size      int64     `json:"size"`
-	Hash      string    `json:"hash"` // For integrity checking
+	Hash      string    `json:"hash" gorm:"index"` // For integrity checking
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// Extracted holds metadata read by an external extractor registered for the file's extension
+	Extracted map[string]any `json:"extracted,omitempty" gorm:"serializer:json"`
+
+	// GCodeMetadata is the slicer metadata of G-code files, read when scanned
+	GCodeMetadata *gcode.Metadata `json:"gcode_metadata,omitempty" gorm:"column:gcode_metadata;serializer:json"`
+
+	// Text is what a text extractor read from the file, such as a PDF or
+	// photographed model card, indexed for search; file details return it
+	Text string `json:"-"`
+
+	// DuplicateOf points at the canonical copy when deduplication replaced this file with a link
+	DuplicateOf *uint `json:"duplicate_of,omitempty"`
+
+	// SourceModels and SlicedVariants pair G-code files with the models they
+	// were sliced from; computed by file listings, never persisted
+	SourceModels   []uint `json:"source_models,omitempty" gorm:"-"`
+	SlicedVariants []uint `json:"sliced_variants,omitempty" gorm:"-"`
+
+	// Profile holds the file's recommended print settings, stored separately so rescans keep them
+	Profile *FileProfile `json:"profile,omitempty" gorm:"-"`
+
 	// Relationships
 	Project Project `json:"-" gorm:"foreignKey:ProjectID"`
 }
 
+// FileTypeExtensions lists the extensions recognized for each file type, in
+// lowercase; README files are recognized by name instead
+var FileTypeExtensions = []struct {
+	Type       FileType
+	Extensions []string
+}{
+	{FileTypeSTL, []string{".stl", ".stl.zst"}},
+	{FileType3MF, []string{".3mf"}},
+	{FileTypeGCode, []string{".gcode", ".gco"}},
+	{FileTypeCAD, []string{".dwg", ".step", ".stp", ".iges", ".igs"}},
+	{FileTypeImage, []string{".jpg", ".jpeg", ".png", ".webp", ".gif"}},
+}
+
 // GetFileTypeFromExtension determines the file type based on file extension
 func GetFileTypeFromExtension(filename string) FileType {
 	if len(filename) < 3 {
@@ -82,6 +182,12 @@ func GetFileTypeFromExtension(filename string) FileType {
 	switch ext {
 	case ".stl", ".STL":
 		return FileTypeSTL
+	case ".zst", ".ZST":
+		// STL files may be stored compressed; other types can't be read that way
+		if stem := strings.ToLower(filename[:len(filename)-len(ext)]); strings.HasSuffix(stem, ".stl") {
+			return FileTypeSTL
+		}
+		return FileTypeOther
 	case ".3mf", ".3MF":
 		return FileType3MF
 	case ".gcode", ".gco", ".GCODE", ".GCO":
@@ -89,6 +195,29 @@ func GetFileTypeFromExtension(filename string) FileType {
 	case ".dwg", ".DWG", ".step", ".iges", ".stp", ".igs", ".STEP", ".IGES", ".STP", ".IGS":
 		return FileTypeCAD
 	default:
+		if IsImageFile(filename) {
+			return FileTypeImage
+		}
 		return FileTypeOther
 	}
 }
+
+// IsImageFile reports whether a filename looks like a picture of the project
+func IsImageFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".png", ".jpg", ".jpeg", ".webp", ".gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsVideoFile reports whether a filename looks like a video, such as a print time-lapse
+func IsVideoFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mp4", ".mkv", ".webm", ".mov", ".mpg":
+		return true
+	default:
+		return false
+	}
+}
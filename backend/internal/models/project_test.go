@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,6 +29,16 @@ func TestGetFileTypeFromExtension(t *testing.T) {
 			filename:     "/path/to/model.stl",
 			expectedType: FileTypeSTL,
 		},
+		{
+			name:         "Compressed STL file",
+			filename:     "model.STL.zst",
+			expectedType: FileTypeSTL,
+		},
+		{
+			name:         "Compressed other file",
+			filename:     "notes.txt.zst",
+			expectedType: FileTypeOther,
+		},
 
 		// 3MF files
 		{
@@ -102,17 +113,29 @@ func TestGetFileTypeFromExtension(t *testing.T) {
 			expectedType: FileTypeREADME,
 		},
 
+		// Image files
+		{
+			name:         "JPEG image",
+			filename:     "photo.jpg",
+			expectedType: FileTypeImage,
+		},
+		{
+			name:         "PNG image uppercase",
+			filename:     "COVER.PNG",
+			expectedType: FileTypeImage,
+		},
+		{
+			name:         "WebP image",
+			filename:     "render.webp",
+			expectedType: FileTypeImage,
+		},
+
 		// Other files
 		{
 			name:         "Text file",
 			filename:     "notes.txt",
 			expectedType: FileTypeOther,
 		},
-		{
-			name:         "Image file",
-			filename:     "photo.jpg",
-			expectedType: FileTypeOther,
-		},
 		{
 			name:         "Unknown extension",
 			filename:     "file.xyz",
@@ -184,6 +207,7 @@ func TestFileTypeConstants(t *testing.T) {
 		FileTypeGCode:  "gcode",
 		FileTypeCAD:    "cad",
 		FileTypeREADME: "readme",
+		FileTypeImage:  "image",
 		FileTypeOther:  "other",
 	}
 
@@ -194,7 +218,7 @@ func TestFileTypeConstants(t *testing.T) {
 	}
 
 	// Ensure all constants are unique
-	allTypes := []FileType{FileTypeSTL, FileType3MF, FileTypeGCode, FileTypeCAD, FileTypeREADME, FileTypeOther}
+	allTypes := []FileType{FileTypeSTL, FileType3MF, FileTypeGCode, FileTypeCAD, FileTypeREADME, FileTypeImage, FileTypeOther}
 	typeMap := make(map[FileType]bool)
 	for _, ft := range allTypes {
 		if typeMap[ft] {
@@ -393,7 +417,7 @@ func TestProjectStatusValidation(t *testing.T) {
 
 // TestFileTypeValidation tests that file type values are valid
 func TestFileTypeValidation(t *testing.T) {
-	validTypes := []FileType{FileTypeSTL, FileType3MF, FileTypeGCode, FileTypeCAD, FileTypeREADME, FileTypeOther}
+	validTypes := []FileType{FileTypeSTL, FileType3MF, FileTypeGCode, FileTypeCAD, FileTypeREADME, FileTypeImage, FileTypeOther}
 
 	for _, fileType := range validTypes {
 		file := ProjectFile{FileType: fileType}
@@ -458,3 +482,33 @@ func TestGetFileTypeFromExtensionBoundaryConditions(t *testing.T) {
 		}
 	})
 }
+
+// TestFileTypeExtensions tests the advertised extensions agree with GetFileTypeFromExtension
+func TestFileTypeExtensions(t *testing.T) {
+	for _, fileType := range FileTypeExtensions {
+		for _, ext := range fileType.Extensions {
+			if got := GetFileTypeFromExtension("model" + ext); got != fileType.Type {
+				t.Errorf("Extension %s: expected %s, got %s", ext, fileType.Type, got)
+			}
+		}
+	}
+}
+
+// TestSlugify tests turning project names into URL-safe slugs
+func TestSlugify(t *testing.T) {
+	testCases := map[string]string{
+		"Benchy":                 "benchy",
+		"  Desk Organizer v2 ":   "desk-organizer-v2",
+		"Café Table":             "cafe-table",
+		"Gear/Box (Large)":       "gear-box-large",
+		"2024":                   "project-2024",
+		"!!!":                    "project",
+		"日本":                     "project",
+		strings.Repeat("a", 100): strings.Repeat("a", 64),
+	}
+	for name, expected := range testCases {
+		if got := Slugify(name); got != expected {
+			t.Errorf("Slugify(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ReplicatedProject maps a project mirrored from the replication source to
+// its local copy
+type ReplicatedProject struct {
+	// SourceID is the project's ID on the source instance
+	SourceID  uint `json:"source_id" gorm:"primaryKey;autoIncrement:false"`
+	ProjectID uint `json:"project_id" gorm:"uniqueIndex;not null"`
+
+	// SourceUpdatedAt is the source's updated_at for the copy last mirrored
+	SourceUpdatedAt time.Time `json:"source_updated_at"`
+	ReplicatedAt    time.Time `json:"replicated_at"`
+}
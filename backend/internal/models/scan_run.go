@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// ScanTrigger identifies what started a scan run
+type ScanTrigger string
+
+const (
+	ScanTriggerManual  ScanTrigger = "manual"
+	ScanTriggerWatcher ScanTrigger = "watcher"
+)
+
+// ScanRunStatus represents the lifecycle state of a scan run
+type ScanRunStatus string
+
+const (
+	ScanRunPending   ScanRunStatus = "pending"
+	ScanRunRunning   ScanRunStatus = "running"
+	ScanRunCompleted ScanRunStatus = "completed"
+	ScanRunFailed    ScanRunStatus = "failed"
+	ScanRunCancelled ScanRunStatus = "cancelled"
+)
+
+// ScanRun records a single execution of the filesystem scanner. Runs started
+// in the background are pending until a job worker picks them up, and report
+// their progress while running.
+type ScanRun struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	Trigger         ScanTrigger   `json:"trigger" gorm:"not null"`
+	Status          ScanRunStatus `json:"status" gorm:"index;not null"`
+	Roots           []string      `json:"roots" gorm:"serializer:json"`
+	StartedAt       time.Time     `json:"started_at" gorm:"index"`
+	FinishedAt      *time.Time    `json:"finished_at"`
+	ProjectsAdded   int           `json:"projects_added"`
+	ProjectsUpdated int           `json:"projects_updated"`
+	ProjectsRemoved int           `json:"projects_removed"`
+	Errors          []string      `json:"errors" gorm:"serializer:json"`
+	DirsVisited     int           `json:"dirs_visited"`
+	ProjectsFound   int           `json:"projects_found"`
+	FilesHashed     int           `json:"files_hashed"`
+	CancelRequested bool          `json:"cancel_requested"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
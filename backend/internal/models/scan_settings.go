@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// HashPolicy controls how project files are hashed during scans
+type HashPolicy string
+
+const (
+	// HashFull computes a SHA-256 of every file
+	HashFull HashPolicy = "full"
+	// HashNone skips hashing; conflict checks, dedupe and checksum headers then have nothing to compare
+	HashNone HashPolicy = "none"
+)
+
+// MaxScanDepth bounds how many subdirectory levels a project scan may descend
+const MaxScanDepth = 16
+
+// ProjectScanSettings override how a single project is scanned
+type ProjectScanSettings struct {
+	// Exclude lists glob patterns matched against each file's relative path and base name
+	Exclude []string `json:"exclude,omitempty"`
+	// MaxDepth is how many subdirectory levels belong to the project; 0 scans only top-level files
+	MaxDepth int `json:"max_depth"`
+	// HashPolicy defaults to full hashing when empty
+	HashPolicy HashPolicy `json:"hash_policy,omitempty"`
+}
+
+// Validate checks the settings for bad patterns and out-of-range values
+func (s ProjectScanSettings) Validate() error {
+	for _, pattern := range s.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+	}
+	if s.MaxDepth < 0 || s.MaxDepth > MaxScanDepth {
+		return fmt.Errorf("max_depth must be between 0 and %d", MaxScanDepth)
+	}
+	switch s.HashPolicy {
+	case "", HashFull, HashNone:
+	default:
+		return fmt.Errorf("unsupported hash policy: %s", s.HashPolicy)
+	}
+	return nil
+}
+
+// Excludes reports whether a file, given by its path relative to the project, is excluded
+func (s ProjectScanSettings) Excludes(relPath string) bool {
+	base := filepath.Base(relPath)
+	for _, pattern := range s.Exclude {
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, base); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxSectionPreview is the largest number of projects previewed per section
+const MaxSectionPreview = 50
+
+// DefaultSectionPreview is the preview size used when a section sets none
+const DefaultSectionPreview = 10
+
+// SectionSort orders the projects of a library section
+type SectionSort string
+
+const (
+	SortNewest      SectionSort = "newest"
+	SortUpdated     SectionSort = "updated"
+	SortName        SectionSort = "name"
+	SortLastScanned SectionSort = "last_scanned"
+)
+
+// SectionFilter selects the projects shown in a section. Empty fields match everything.
+type SectionFilter struct {
+	// Query matches project names and descriptions
+	Query    string        `json:"query,omitempty"`
+	Tags     []string      `json:"tags,omitempty"`
+	Designer string        `json:"designer,omitempty"`
+	Status   ProjectStatus `json:"status,omitempty"`
+
+	// HasFileTypes and MissingFileTypes require projects to have, or lack, files of every listed type
+	HasFileTypes     []FileType `json:"has_file_types,omitempty"`
+	MissingFileTypes []FileType `json:"missing_file_types,omitempty"`
+
+	AddedWithinDays int `json:"added_within_days,omitempty"`
+}
+
+// Section is a named saved filter the home screen shows as a row of projects
+type Section struct {
+	ID       uint          `json:"id" gorm:"primaryKey"`
+	Name     string        `json:"name" gorm:"not null"`
+	Position int           `json:"position"`
+	Filter   SectionFilter `json:"filter" gorm:"serializer:json"`
+	Sort     SectionSort   `json:"sort" gorm:"default:newest"`
+
+	// Limit is how many projects are previewed; zero uses DefaultSectionPreview
+	Limit int `json:"limit"`
+
+	// Cover is an image URL shown for the section
+	Cover string `json:"cover"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate fills in defaults and checks the section definition
+func (s *Section) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch s.Sort {
+	case "":
+		s.Sort = SortNewest
+	case SortNewest, SortUpdated, SortName, SortLastScanned:
+	default:
+		return fmt.Errorf("unsupported sort: %s", s.Sort)
+	}
+
+	if s.Limit < 0 || s.Limit > MaxSectionPreview {
+		return fmt.Errorf("limit must be between 0 and %d", MaxSectionPreview)
+	}
+	if s.Filter.AddedWithinDays < 0 {
+		return fmt.Errorf("added_within_days must not be negative")
+	}
+
+	switch s.Filter.Status {
+	case "", StatusHealthy, StatusInconsistent, StatusError:
+	default:
+		return fmt.Errorf("unsupported status: %s", s.Filter.Status)
+	}
+
+	for _, fileType := range append(append([]FileType{}, s.Filter.HasFileTypes...), s.Filter.MissingFileTypes...) {
+		switch fileType {
+		case FileTypeSTL, FileType3MF, FileTypeGCode, FileTypeCAD, FileTypeREADME, FileTypeImage, FileTypeOther:
+		default:
+			return fmt.Errorf("unsupported file type: %s", fileType)
+		}
+	}
+
+	return nil
+}
+
+// PreviewLimit returns how many projects the section previews
+func (s *Section) PreviewLimit() int {
+	if s.Limit == 0 {
+		return DefaultSectionPreview
+	}
+	return s.Limit
+}
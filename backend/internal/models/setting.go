@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Setting is a runtime setting changed through the admin API, stored as JSON
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
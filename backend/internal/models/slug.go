@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// maxSlugLength bounds slugs so long names still make readable URLs
+const maxSlugLength = 64
+
+// reservedSlugs are paths under /api/projects that aren't projects, so a
+// project can't be reached by these slugs
+var reservedSlugs = map[string]bool{"compact": true, "scan": true, "search": true, "stats": true, "upload": true}
+
+// Slugify turns a name into a URL-safe slug of lowercase letters, digits and
+// dashes. Accents are dropped, so "Café Table" becomes "cafe-table". A slug
+// made only of digits could be mistaken for a project ID, so it gets a
+// "project-" prefix, and names with nothing usable become "project".
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left by decomposing accented letters
+		default:
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		return "project"
+	}
+	if strings.Trim(slug, "0123456789") == "" {
+		return "project-" + slug
+	}
+	return slug
+}
+
+// UniqueSlug returns the slug of name, with a -2, -3... suffix when another
+// project, deleted ones included, already has it. excludeID is the project
+// being renamed, whose own slug doesn't count as taken.
+func UniqueSlug(db *gorm.DB, name string, excludeID uint) (string, error) {
+	base := Slugify(name)
+
+	var taken []string
+	if err := db.Unscoped().Model(&Project{}).
+		Where("id != ? AND (slug = ? OR slug LIKE ?)", excludeID, base, base+"-%").
+		Pluck("slug", &taken).Error; err != nil {
+		return "", err
+	}
+	used := make(map[string]bool, len(taken)+len(reservedSlugs))
+	for slug := range reservedSlugs {
+		used[slug] = true
+	}
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+// BeforeCreate gives a new project a unique slug from its name, unless one was set
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.Slug != "" {
+		return nil
+	}
+	slug, err := UniqueSlug(tx.Session(&gorm.Session{NewDB: true}), p.Name, 0)
+	if err != nil {
+		return err
+	}
+	p.Slug = slug
+	return nil
+}
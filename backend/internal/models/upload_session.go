@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// UploadSessionStatus represents the lifecycle state of an upload session
+type UploadSessionStatus string
+
+const (
+	UploadSessionPending   UploadSessionStatus = "pending"
+	UploadSessionPartial   UploadSessionStatus = "partial"
+	UploadSessionCompleted UploadSessionStatus = "completed"
+)
+
+// UploadSessionConflict snapshots an existing file that conflicted during the check phase
+type UploadSessionConflict struct {
+	Filename string `json:"filename"`
+	FileID   uint   `json:"file_id"`
+	Hash     string `json:"hash"`
+	Size     int64  `json:"size"`
+}
+
+// UploadSession links a conflict check to the uploads that resolve it, so
+// resolutions are applied against the state the client actually saw
+type UploadSession struct {
+	ID        uint                    `json:"-" gorm:"primaryKey"`
+	Token     string                  `json:"token" gorm:"uniqueIndex;not null"`
+	ProjectID uint                    `json:"project_id" gorm:"index;not null"`
+	Status    UploadSessionStatus     `json:"status" gorm:"not null"`
+	Filenames []string                `json:"filenames" gorm:"serializer:json"`
+	Conflicts []UploadSessionConflict `json:"conflicts" gorm:"serializer:json"`
+	Completed []string                `json:"completed" gorm:"serializer:json"`
+	ExpiresAt time.Time               `json:"expires_at"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
+
+// Expired reports whether the session can no longer be used
+func (s *UploadSession) Expired(now time.Time) bool {
+	return now.After(s.ExpiresAt)
+}
+
+// Includes reports whether the filename was part of the conflict check
+func (s *UploadSession) Includes(filename string) bool {
+	return slices.Contains(s.Filenames, filename)
+}
+
+// ConflictFor returns the conflict snapshot recorded for the filename, if any
+func (s *UploadSession) ConflictFor(filename string) (UploadSessionConflict, bool) {
+	for _, conflict := range s.Conflicts {
+		if conflict.Filename == filename {
+			return conflict, true
+		}
+	}
+	return UploadSessionConflict{}, false
+}
+
+// IsCompleted reports whether the filename was already handled by an earlier request
+func (s *UploadSession) IsCompleted(filename string) bool {
+	return slices.Contains(s.Completed, filename)
+}
+
+// MarkCompleted records that the filename has been handled and refreshes the status
+func (s *UploadSession) MarkCompleted(filename string) {
+	if !s.IsCompleted(filename) {
+		s.Completed = append(s.Completed, filename)
+	}
+
+	s.Status = UploadSessionPartial
+	if len(s.Completed) >= len(s.Filenames) {
+		s.Status = UploadSessionCompleted
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUploadSessionMarkCompleted tests status transitions as files are handled
+func TestUploadSessionMarkCompleted(t *testing.T) {
+	session := UploadSession{
+		Status:    UploadSessionPending,
+		Filenames: []string{"a.stl", "b.stl"},
+	}
+
+	session.MarkCompleted("a.stl")
+	session.MarkCompleted("a.stl")
+	if session.Status != UploadSessionPartial || len(session.Completed) != 1 {
+		t.Errorf("Expected partial session with 1 completed file, got %+v", session)
+	}
+
+	session.MarkCompleted("b.stl")
+	if session.Status != UploadSessionCompleted {
+		t.Errorf("Expected completed session, got %s", session.Status)
+	}
+
+	if !session.IsCompleted("b.stl") || session.IsCompleted("c.stl") {
+		t.Error("IsCompleted should reflect handled files only")
+	}
+}
+
+// TestUploadSessionLookups tests conflict snapshot and expiry helpers
+func TestUploadSessionLookups(t *testing.T) {
+	now := time.Now()
+	session := UploadSession{
+		Filenames: []string{"a.stl"},
+		Conflicts: []UploadSessionConflict{{Filename: "a.stl", FileID: 7, Hash: "abc"}},
+		ExpiresAt: now.Add(time.Minute),
+	}
+
+	if conflict, ok := session.ConflictFor("a.stl"); !ok || conflict.FileID != 7 {
+		t.Errorf("Expected conflict snapshot for a.stl, got %+v", conflict)
+	}
+	if _, ok := session.ConflictFor("b.stl"); ok {
+		t.Error("Expected no conflict snapshot for b.stl")
+	}
+	if !session.Includes("a.stl") || session.Includes("b.stl") {
+		t.Error("Includes should only match checked filenames")
+	}
+	if session.Expired(now) || !session.Expired(now.Add(2*time.Minute)) {
+		t.Error("Expired should compare against ExpiresAt")
+	}
+}
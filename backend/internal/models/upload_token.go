@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UploadToken lets whoever holds it add photos to one project until it
+// expires, so a phone that scanned its QR code can upload without signing in
+type UploadToken struct {
+	ID        uint       `json:"-" gorm:"primaryKey"`
+	Token     string     `json:"token" gorm:"uniqueIndex;not null"`
+	ProjectID uint       `json:"project_id" gorm:"index;not null"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	// Uploaded counts the photos added with the token
+	Uploaded int `json:"uploaded"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Usable reports whether the token still accepts uploads
+func (t *UploadToken) Usable(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"3dshelf/internal/config"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// New builds an http.Server for the given handler using the tuned timeouts,
+// header limits and protocol settings from the configuration
+func New(cfg *config.Config, handler http.Handler) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	if cfg.EnableH2C {
+		// Cleartext HTTP/2 for deployments behind a TLS-terminating proxy
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		Protocols:         protocols,
+		HTTP2: &http.HTTP2Config{
+			// Allow many parallel file downloads over a single connection
+			MaxConcurrentStreams: 250,
+		},
+	}
+}
+
+// Run serves until the process receives SIGINT or SIGTERM, then shuts down
+// gracefully so in-flight uploads and downloads can complete
+func Run(cfg *config.Config, srv *http.Server) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLSCertFile != "" {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		log.Printf("Shutting down server (waiting up to %v for active requests)", cfg.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
+
+// WaitForSignal blocks until the process receives SIGINT or SIGTERM
+func WaitForSignal() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"3dshelf/internal/config"
+)
+
+// testConfig returns a configuration with distinct server tuning values
+func testConfig() *config.Config {
+	return &config.Config{
+		Port:              "9091",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       3 * time.Minute,
+		ShutdownTimeout:   time.Second,
+		MaxHeaderBytes:    4096,
+	}
+}
+
+// TestNew tests that server tuning is applied from configuration
+func TestNew(t *testing.T) {
+	cfg := testConfig()
+	handler := http.NewServeMux()
+
+	srv := New(cfg, handler)
+
+	if srv.Addr != ":9091" {
+		t.Errorf("Expected addr ':9091', got '%s'", srv.Addr)
+	}
+	if srv.Handler != handler {
+		t.Error("Expected handler to be set")
+	}
+	if srv.ReadHeaderTimeout != cfg.ReadHeaderTimeout || srv.ReadTimeout != cfg.ReadTimeout {
+		t.Errorf("Read timeouts not applied: %v / %v", srv.ReadHeaderTimeout, srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != cfg.WriteTimeout || srv.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("Write/idle timeouts not applied: %v / %v", srv.WriteTimeout, srv.IdleTimeout)
+	}
+	if srv.MaxHeaderBytes != 4096 {
+		t.Errorf("Expected MaxHeaderBytes 4096, got %d", srv.MaxHeaderBytes)
+	}
+	if !srv.Protocols.HTTP1() || !srv.Protocols.HTTP2() {
+		t.Error("Expected HTTP/1 and HTTP/2 to be enabled")
+	}
+	if srv.Protocols.UnencryptedHTTP2() {
+		t.Error("Cleartext HTTP/2 should be disabled by default")
+	}
+}
+
+// TestNewH2C tests enabling cleartext HTTP/2
+func TestNewH2C(t *testing.T) {
+	cfg := testConfig()
+	cfg.EnableH2C = true
+
+	srv := New(cfg, http.NewServeMux())
+
+	if !srv.Protocols.UnencryptedHTTP2() {
+		t.Error("Expected cleartext HTTP/2 to be enabled")
+	}
+}
@@ -0,0 +1,20 @@
+package version
+
+// APIVersions are the API versions the server serves under /api/<version>,
+// oldest first; the last one is current
+var APIVersions = []string{"v1"}
+
+// CurrentAPI is the API version unversioned /api routes are served as
+func CurrentAPI() string {
+	return APIVersions[len(APIVersions)-1]
+}
+
+// SupportsAPI reports whether the server serves the given API version
+func SupportsAPI(v string) bool {
+	for _, supported := range APIVersions {
+		if v == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package bambu
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// Bambu printers in LAN mode take the user bblp with the access code shown
+// on the printer's screen, on these ports
+const (
+	username  = "bblp"
+	mqttPort  = "8883"
+	ftpsPort  = "990"
+	keepAlive = 60 * time.Second
+)
+
+// Print states reported in gcode_state
+const (
+	StateIdle     = "IDLE"
+	StatePrepare  = "PREPARE"
+	StateRunning  = "RUNNING"
+	StatePause    = "PAUSE"
+	StateFinished = "FINISH"
+	StateFailed   = "FAILED"
+)
+
+// errorCancelled is the print_error of a print stopped on the printer or from
+// the app, which Bambu reports as FAILED
+const errorCancelled = 50348044
+
+// Client talks to a Bambu Lab printer (X1, P1, A1) on the local network,
+// over the MQTT broker and FTPS server it runs in LAN mode
+type Client struct {
+	Host       string
+	Serial     string
+	AccessCode string
+
+	// dialer connects to the printer; tests replace it to skip TLS
+	dialer func(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// New creates a client for the printer at host with serial number serial
+func New(host, serial, accessCode string) *Client {
+	c := &Client{Host: host, Serial: serial, AccessCode: accessCode}
+	c.dialer = c.dialTLS
+	return c
+}
+
+// dial connects to a port of the printer
+func (c *Client) dial(ctx context.Context, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return c.dialer(ctx, addr)
+}
+
+// dialTLS connects with TLS. Printers present a certificate signed by Bambu's
+// own CA naming the printer's serial number, so the name is checked instead of
+// the chain. Sessions are resumed across connections, as the FTPS server
+// requires of data connections.
+func (c *Client) dialTLS(ctx context.Context, addr string) (net.Conn, error) {
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: true,
+		ClientSessionCache: sessionCache,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("printer sent no certificate")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			if !strings.EqualFold(cert.Subject.CommonName, c.Serial) {
+				return fmt.Errorf("printer certificate is for %q, not serial %s", cert.Subject.CommonName, c.Serial)
+			}
+			return nil
+		},
+	}}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// sessionCache keeps TLS sessions for resuming them
+var sessionCache = tls.NewLRUClientSessionCache(16)
+
+// Status is the printer's report of what it is printing. Printers may only
+// report what changed, so Watch merges each report into the last status.
+type Status struct {
+	State string `json:"gcode_state"`
+
+	// GCodeFile is the file printed; Metadata/plate_N.gcode within a sliced 3MF,
+	// whose name is then the subtask name
+	GCodeFile   string `json:"gcode_file"`
+	SubtaskName string `json:"subtask_name"`
+	SubtaskID   string `json:"subtask_id"`
+	TaskID      string `json:"task_id"`
+
+	Percent          int `json:"mc_percent"`
+	RemainingMinutes int `json:"mc_remaining_time"`
+	Layer            int `json:"layer_num"`
+	TotalLayers      int `json:"total_layer_num"`
+	PrintError       int `json:"print_error"`
+
+	NozzleTemperature float64 `json:"nozzle_temper"`
+	BedTemperature    float64 `json:"bed_temper"`
+}
+
+// Active reports whether a print is under way, paused included
+func (s Status) Active() bool {
+	switch s.State {
+	case StatePrepare, StateRunning, StatePause:
+		return true
+	}
+	return false
+}
+
+// Cancelled reports whether a failed print was stopped by the user
+func (s Status) Cancelled() bool {
+	return s.State == StateFailed && s.PrintError == errorCancelled
+}
+
+// Plate is the plate of a sliced 3MF printed, 0 for a G-code file
+func (s Status) Plate() int {
+	var plate int
+	if _, err := fmt.Sscanf(path.Base(s.GCodeFile), "plate_%d.gcode", &plate); err != nil {
+		return 0
+	}
+	return plate
+}
+
+// File is the name of the file printed on the printer's storage
+func (s Status) File() string {
+	if s.Plate() > 0 && s.SubtaskName != "" {
+		return s.SubtaskName + ".gcode.3mf"
+	}
+	return strings.TrimPrefix(s.GCodeFile, "/sdcard/")
+}
+
+// Job identifies the print on the printer, telling prints of one file apart
+func (s Status) Job() string {
+	if s.SubtaskID != "" && s.SubtaskID != "0" {
+		return s.SubtaskID
+	}
+	return s.File()
+}
+
+// PrintOptions are the choices of starting a print
+type PrintOptions struct {
+	// Plate is the plate of a sliced 3MF to print, 1 when 0
+	Plate int
+
+	// UseAMS feeds filament from the AMS, slots assigned by AMSMapping in the
+	// order of the file's filaments
+	UseAMS     bool
+	AMSMapping []int
+
+	Timelapse   bool
+	BedLeveling bool
+}
+
+// StartPrint starts printing a file already uploaded as name: a sliced 3MF,
+// from Bambu Studio or OrcaSlicer, or plain G-code
+func (c *Client) StartPrint(ctx context.Context, name string, opts PrintOptions) error {
+	command := map[string]any{
+		"sequence_id": "0",
+		"command":     "gcode_file",
+		"param":       "/sdcard/" + name,
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".3mf") {
+		plate := max(opts.Plate, 1)
+		mapping := opts.AMSMapping
+		if mapping == nil {
+			mapping = []int{}
+		}
+		command = map[string]any{
+			"sequence_id":    "0",
+			"command":        "project_file",
+			"param":          fmt.Sprintf("Metadata/plate_%d.gcode", plate),
+			"url":            "file:///sdcard/" + name,
+			"subtask_name":   strings.TrimSuffix(strings.TrimSuffix(name, path.Ext(name)), ".gcode"),
+			"project_id":     "0",
+			"profile_id":     "0",
+			"task_id":        "0",
+			"subtask_id":     "0",
+			"bed_type":       "auto",
+			"use_ams":        opts.UseAMS,
+			"ams_mapping":    mapping,
+			"timelapse":      opts.Timelapse,
+			"bed_leveling":   opts.BedLeveling,
+			"flow_cali":      false,
+			"vibration_cali": false,
+			"layer_inspect":  false,
+		}
+	}
+
+	session, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.disconnect()
+	return c.request(session, map[string]any{"print": command})
+}
+
+// Watch follows the printer's reports, calling report with the merged status
+// after each, until ctx is done or the connection fails
+func (c *Client) Watch(ctx context.Context, report func(Status)) error {
+	session, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.disconnect()
+	stopOnCancel := context.AfterFunc(ctx, func() { session.conn.Close() })
+	defer stopOnCancel()
+
+	if err := session.subscribe("device/" + c.Serial + "/report"); err != nil {
+		return err
+	}
+	// Ask for a full report rather than waiting for changes
+	if err := c.request(session, map[string]any{"pushing": map[string]any{"sequence_id": "0", "command": "pushall"}}); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(keepAlive / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if session.ping() != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	var status Status
+	for {
+		msg, err := session.readMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		var envelope struct {
+			Print json.RawMessage `json:"print"`
+		}
+		if json.Unmarshal(msg.Payload, &envelope) != nil || envelope.Print == nil {
+			continue
+		}
+		// Fields the report leaves out keep their last value
+		if err := json.Unmarshal(envelope.Print, &status); err != nil {
+			continue
+		}
+		report(status)
+	}
+}
+
+// connect opens an MQTT session with the printer
+func (c *Client) connect(ctx context.Context) (*mqttConn, error) {
+	conn, err := c.dial(ctx, net.JoinHostPort(c.Host, mqttPort))
+	if err != nil {
+		return nil, err
+	}
+	clientID := fmt.Sprintf("3dshelf-%d", time.Now().UnixNano())
+	session, err := connectMQTT(conn, clientID, username, c.AccessCode, uint16(keepAlive/time.Second))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// request publishes a command to the printer
+func (c *Client) request(session *mqttConn, command any) error {
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	return session.publish("device/"+c.Serial+"/request", payload)
+}
@@ -0,0 +1,205 @@
+package bambu
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient returns a client whose connections, without TLS, go to ports
+func testClient(t *testing.T, ports map[string]net.Listener) *Client {
+	c := New("127.0.0.1", "01S00C123456789", "12345678")
+	c.dialer = func(ctx context.Context, addr string) (net.Conn, error) {
+		_, port, _ := net.SplitHostPort(addr)
+		if listener, ok := ports[port]; ok {
+			addr = listener.Addr().String()
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	return c
+}
+
+// fakeBroker accepts one MQTT session, answering CONNECT with code and then
+// passing the session to serve
+func fakeBroker(t *testing.T, code byte, serve func(*mqttConn)) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		session := &mqttConn{conn: conn, reader: bufio.NewReader(conn)}
+		if kind, _, err := session.readPacket(); err != nil || kind != packetConnect {
+			return
+		}
+		session.write(packetConnAck, []byte{0, code})
+		if code == 0 {
+			serve(session)
+		}
+	}()
+	return listener
+}
+
+func TestWatch(t *testing.T) {
+	broker := fakeBroker(t, 0, func(session *mqttConn) {
+		if kind, _, _ := session.readPacket(); kind != packetSubscribe {
+			return
+		}
+		session.write(packetSubAck, []byte{0, 1, 0})
+		if _, body, _ := session.readPacket(); !strings.Contains(string(body), "pushall") {
+			return
+		}
+		topic := "device/01S00C123456789/report"
+		session.publish(topic, []byte(`{"print":{"gcode_state":"RUNNING","subtask_name":"gear","subtask_id":"77","gcode_file":"/data/Metadata/plate_2.gcode","mc_percent":10}}`))
+		session.publish(topic, []byte(`{"info":{"command":"get_version"}}`))
+		session.publish(topic, []byte(`{"print":{"mc_percent":50,"layer_num":12}}`))
+	})
+
+	var reports []Status
+	err := testClient(t, map[string]net.Listener{mqttPort: broker}).Watch(context.Background(), func(s Status) {
+		reports = append(reports, s)
+	})
+	if err == nil {
+		t.Fatal("Expected Watch to end with the connection")
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 print reports, got %+v", reports)
+	}
+	last := reports[1]
+	if last.State != StateRunning || last.Percent != 50 || last.Layer != 12 || !last.Active() {
+		t.Errorf("Expected the second report merged into the first, got %+v", last)
+	}
+	if last.Plate() != 2 || last.File() != "gear.gcode.3mf" || last.Job() != "77" {
+		t.Errorf("Unexpected plate %d, file %q or job %q", last.Plate(), last.File(), last.Job())
+	}
+}
+
+func TestWatchRefused(t *testing.T) {
+	broker := fakeBroker(t, 5, nil)
+	err := testClient(t, map[string]net.Listener{mqttPort: broker}).Watch(context.Background(), func(Status) {})
+	if !errors.Is(err, errConnectionRefused) {
+		t.Errorf("Expected a refused connection, got %v", err)
+	}
+}
+
+func TestStartPrint(t *testing.T) {
+	published := make(chan message, 1)
+	broker := fakeBroker(t, 0, func(session *mqttConn) {
+		msg, err := session.readMessage()
+		if err == nil {
+			published <- msg
+		}
+	})
+
+	err := testClient(t, map[string]net.Listener{mqttPort: broker}).StartPrint(context.Background(), "gears.gcode.3mf",
+		PrintOptions{Plate: 2, UseAMS: true, AMSMapping: []int{1, 0}})
+	if err != nil {
+		t.Fatalf("StartPrint failed: %v", err)
+	}
+
+	var msg message
+	select {
+	case msg = <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a command published")
+	}
+	var request struct {
+		Print struct {
+			Command     string `json:"command"`
+			Param       string `json:"param"`
+			URL         string `json:"url"`
+			SubtaskName string `json:"subtask_name"`
+			UseAMS      bool   `json:"use_ams"`
+			AMSMapping  []int  `json:"ams_mapping"`
+		} `json:"print"`
+	}
+	if err := json.Unmarshal(msg.Payload, &request); err != nil {
+		t.Fatalf("Invalid command: %v", err)
+	}
+	cmd := request.Print
+	if msg.Topic != "device/01S00C123456789/request" || cmd.Command != "project_file" ||
+		cmd.Param != "Metadata/plate_2.gcode" || cmd.URL != "file:///sdcard/gears.gcode.3mf" ||
+		cmd.SubtaskName != "gears" || !cmd.UseAMS || len(cmd.AMSMapping) != 2 {
+		t.Errorf("Unexpected command on %s: %s", msg.Topic, msg.Payload)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	control, _ := net.Listen("tcp", "127.0.0.1:0")
+	data, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer control.Close()
+	defer data.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := control.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 Ready\r\n")
+
+		port := data.Addr().(*net.TCPAddr).Port
+		replies := map[string]string{
+			"USER": "331 Password required", "PASS": "230 Logged in", "PBSZ": "200 OK",
+			"PROT": "200 OK", "TYPE": "200 Binary",
+			"PASV": fmt.Sprintf("227 Entering Passive Mode (10,0,0,9,%d,%d)", port>>8, port&0xFF),
+		}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+			if verb != "STOR" {
+				fmt.Fprintf(conn, "%s\r\n", replies[verb])
+				continue
+			}
+			fmt.Fprint(conn, "150 Opening data connection\r\n")
+			upload, err := data.Accept()
+			if err != nil {
+				return
+			}
+			content, _ := io.ReadAll(upload)
+			upload.Close()
+			received <- arg + ":" + string(content)
+			fmt.Fprint(conn, "226 Transfer complete\r\n")
+		}
+	}()
+
+	c := testClient(t, map[string]net.Listener{ftpsPort: control, fmt.Sprint(data.Addr().(*net.TCPAddr).Port): data})
+	if err := c.Upload(context.Background(), "gears.gcode.3mf", strings.NewReader("PK plates")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if got := <-received; got != "gears.gcode.3mf:PK plates" {
+		t.Errorf("Expected the file stored, got %q", got)
+	}
+}
+
+func TestStatusCancelled(t *testing.T) {
+	if !(Status{State: StateFailed, PrintError: errorCancelled}).Cancelled() {
+		t.Error("Expected a stopped print to be cancelled")
+	}
+	if (Status{State: StateFailed, PrintError: 1}).Cancelled() {
+		t.Error("Expected other errors to be failures")
+	}
+	if job := (Status{GCodeFile: "/sdcard/cube.gcode", SubtaskID: "0"}).Job(); job != "cube.gcode" {
+		t.Errorf("Expected the file to identify a job without an ID, got %q", job)
+	}
+}
@@ -0,0 +1,112 @@
+package bambu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Upload stores a file on the printer's storage under name, over the FTPS
+// server Bambu printers run in LAN mode
+func (c *Client) Upload(ctx context.Context, name string, r io.Reader) error {
+	conn, err := c.dial(ctx, net.JoinHostPort(c.Host, ftpsPort))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stopOnCancel := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stopOnCancel()
+
+	control := textproto.NewConn(conn)
+	if _, _, err := control.ReadResponse(220); err != nil {
+		return fmt.Errorf("ftp greeting: %w", err)
+	}
+	steps := []struct {
+		command string
+		code    int
+	}{
+		{"USER " + username, 331},
+		{"PASS " + c.AccessCode, 230},
+		// Data connections are encrypted too
+		{"PBSZ 0", 200},
+		{"PROT P", 200},
+		{"TYPE I", 200},
+	}
+	for _, step := range steps {
+		if err := ftpCommand(control, step.code, step.command); err != nil {
+			return err
+		}
+	}
+
+	if _, err := control.Cmd("PASV"); err != nil {
+		return err
+	}
+	_, reply, err := control.ReadResponse(227)
+	if err != nil {
+		return fmt.Errorf("ftp PASV: %w", err)
+	}
+	port, err := passivePort(reply)
+	if err != nil {
+		return err
+	}
+	// The printer's own address is used over the one it replies, which NAT may have rewritten
+	data, err := c.dial(ctx, net.JoinHostPort(c.Host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	if _, err := control.Cmd("STOR %s", name); err != nil {
+		return err
+	}
+	// 125 or 150, whether or not the data connection is already open
+	if _, _, err := control.ReadCodeLine(1); err != nil {
+		return fmt.Errorf("ftp STOR %s: %w", name, err)
+	}
+	if _, err := io.Copy(data, r); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	if _, _, err := control.ReadResponse(226); err != nil {
+		return fmt.Errorf("ftp STOR %s: %w", name, err)
+	}
+
+	control.Cmd("QUIT")
+	return nil
+}
+
+// ftpCommand sends a command and expects code in reply
+func ftpCommand(control *textproto.Conn, code int, command string) error {
+	if _, err := control.Cmd("%s", command); err != nil {
+		return err
+	}
+	if _, _, err := control.ReadResponse(code); err != nil {
+		verb, _, _ := strings.Cut(command, " ")
+		return fmt.Errorf("ftp %s: %w", verb, err)
+	}
+	return nil
+}
+
+// passivePort reads the port of a "Entering Passive Mode (h1,h2,h3,h4,p1,p2)" reply
+func passivePort(reply string) (int, error) {
+	start, end := strings.Index(reply, "("), strings.Index(reply, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("invalid ftp PASV reply %q", reply)
+	}
+	fields := strings.Split(reply[start+1:end], ",")
+	if len(fields) != 6 {
+		return 0, fmt.Errorf("invalid ftp PASV reply %q", reply)
+	}
+	high, err1 := strconv.Atoi(strings.TrimSpace(fields[4]))
+	low, err2 := strconv.Atoi(strings.TrimSpace(fields[5]))
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid ftp PASV reply %q", reply)
+	}
+	return high<<8 | low, nil
+}
@@ -0,0 +1,207 @@
+package bambu
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// MQTT 3.1.1 packet types, as the high nibble of a packet's first byte; only
+// what talking to a printer's broker takes is implemented
+const (
+	packetConnect     = 0x10
+	packetConnAck     = 0x20
+	packetPublish     = 0x30
+	packetSubscribe   = 0x82
+	packetSubAck      = 0x90
+	packetPingReq     = 0xC0
+	packetPingResp    = 0xD0
+	packetDisconnect  = 0xE0
+	maxPacketLength   = 256 << 20
+	connectFlagsLogin = 0x80 | 0x40 | 0x02 // username, password, clean session
+)
+
+// errConnectionRefused is returned when the printer refuses the access code
+var errConnectionRefused = errors.New("printer refused the MQTT connection; check the access code")
+
+// mqttConn is an MQTT session with a printer's broker
+type mqttConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	mu       sync.Mutex
+	packetID uint16
+}
+
+// message is a message published on a topic
+type message struct {
+	Topic   string
+	Payload []byte
+}
+
+// connectMQTT logs in to the broker on conn; keepAlive is in seconds
+func connectMQTT(conn net.Conn, clientID, username, password string, keepAlive uint16) (*mqttConn, error) {
+	m := &mqttConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4, connectFlagsLogin)
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendString(body, clientID)
+	body = appendString(body, username)
+	body = appendString(body, password)
+	if err := m.write(packetConnect, body); err != nil {
+		return nil, err
+	}
+
+	kind, ack, err := m.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if kind != packetConnAck || len(ack) != 2 {
+		return nil, fmt.Errorf("unexpected MQTT packet 0x%02x waiting for CONNACK", kind)
+	}
+	if ack[1] != 0 {
+		if ack[1] == 4 || ack[1] == 5 {
+			return nil, errConnectionRefused
+		}
+		return nil, fmt.Errorf("printer refused the MQTT connection with code %d", ack[1])
+	}
+	return m, nil
+}
+
+// subscribe asks for the messages on topic; the SUBACK arrives through readMessage
+func (m *mqttConn) subscribe(topic string) error {
+	m.mu.Lock()
+	m.packetID++
+	id := m.packetID
+	m.mu.Unlock()
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, topic)
+	body = append(body, 0) // at most once
+	return m.write(packetSubscribe, body)
+}
+
+// publish sends payload on topic at most once
+func (m *mqttConn) publish(topic string, payload []byte) error {
+	return m.write(packetPublish, append(appendString(nil, topic), payload...))
+}
+
+// ping keeps the session alive when nothing else is sent
+func (m *mqttConn) ping() error {
+	return m.write(packetPingReq, nil)
+}
+
+// disconnect ends the session cleanly
+func (m *mqttConn) disconnect() error {
+	m.write(packetDisconnect, nil)
+	return m.conn.Close()
+}
+
+// readMessage returns the next published message, skipping acknowledgements
+func (m *mqttConn) readMessage() (message, error) {
+	for {
+		kind, body, err := m.readPacket()
+		if err != nil {
+			return message{}, err
+		}
+		switch kind & 0xF0 {
+		case packetPublish:
+			return parsePublish(kind, body)
+		case packetSubAck:
+			if len(body) == 3 && body[2] == 0x80 {
+				return message{}, errors.New("printer refused the MQTT subscription")
+			}
+		case packetPingResp:
+		default:
+			return message{}, fmt.Errorf("unexpected MQTT packet 0x%02x", kind)
+		}
+	}
+}
+
+// parsePublish reads a PUBLISH packet's topic and payload
+func parsePublish(kind byte, body []byte) (message, error) {
+	if len(body) < 2 {
+		return message{}, errors.New("short MQTT PUBLISH packet")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	rest := body[2:]
+	if n > len(rest) {
+		return message{}, errors.New("short MQTT PUBLISH topic")
+	}
+	msg := message{Topic: string(rest[:n])}
+	rest = rest[n:]
+	// Messages sent with acknowledgement carry a packet ID, which at most once delivery leaves unanswered
+	if (kind>>1)&0x03 > 0 {
+		if len(rest) < 2 {
+			return message{}, errors.New("short MQTT PUBLISH packet ID")
+		}
+		rest = rest[2:]
+	}
+	msg.Payload = rest
+	return msg, nil
+}
+
+// write sends one packet
+func (m *mqttConn) write(kind byte, body []byte) error {
+	packet := []byte{kind}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.conn.Write(packet)
+	return err
+}
+
+// readPacket reads one packet's first byte and body
+func (m *mqttConn) readPacket() (byte, []byte, error) {
+	kind, err := m.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := m.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("invalid MQTT packet length")
+		}
+		multiplier *= 128
+	}
+	if length > maxPacketLength {
+		return 0, nil, fmt.Errorf("MQTT packet of %d bytes is too large", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(m.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return kind, body, nil
+}
+
+// appendString appends an MQTT length-prefixed string
+func appendString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint16(b, uint16(len(s))), s...)
+}
@@ -2,7 +2,11 @@ package database
 
 import (
 	"3dshelf/internal/models"
+	"context"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -11,21 +15,28 @@ import (
 // DB holds the database connection
 var DB *gorm.DB
 
+// busyTimeout is how many milliseconds a connection waits for another
+// connection's write lock, such as a worker process claiming jobs
+const busyTimeout = 5000
+
+// migrationLockTimeout is how long an instance waits for another to finish migrating
+const migrationLockTimeout = 5 * time.Minute
+
 // Initialize initializes the database connection and runs migrations
 func Initialize(databasePath string) error {
 	var err error
 
-	DB, err = gorm.Open(sqlite.Open(databasePath), &gorm.Config{})
+	dsn := databasePath
+	if dsn != "" && !strings.Contains(dsn, "?") {
+		dsn = fmt.Sprintf("%s?_busy_timeout=%d", dsn, busyTimeout)
+	}
+	DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return err
 	}
 
 	// Run auto migrations
-	err = DB.AutoMigrate(
-		&models.Project{},
-		&models.ProjectFile{},
-	)
-	if err != nil {
+	if err := Migrate(DB); err != nil {
 		return err
 	}
 
@@ -33,6 +44,100 @@ func Initialize(databasePath string) error {
 	return nil
 }
 
+// migratedModels are the models Migrate creates and updates tables for
+var migratedModels = []interface{}{
+	&models.Project{},
+	&models.ProjectFile{},
+	&models.ScanRun{},
+	&models.UploadSession{},
+	&models.UploadToken{},
+	&models.DeviceToken{},
+	&models.Setting{},
+	&models.Section{},
+	&models.Collection{},
+	&models.ImportJob{},
+	&models.ImportItem{},
+	&models.PrintJob{},
+	&models.PrintMedia{},
+	&models.PrintedPart{},
+	&models.BOMItem{},
+	&models.Filament{},
+	&models.Calibration{},
+	&models.FileProfile{},
+	&models.FileActivity{},
+	&models.LinkIssue{},
+	&models.Assembly{},
+	&models.Job{},
+	&models.IdempotencyKey{},
+	&models.GeometryFingerprint{},
+	&models.Printer{},
+	&models.Order{},
+	&models.Distribution{},
+	&models.ReplicatedProject{},
+}
+
+// Migrate runs auto migrations for every model on the given connection
+func Migrate(db *gorm.DB) error {
+	// Instances starting together take turns, so the schema is changed once
+	if err := db.AutoMigrate(&models.Lock{}); err != nil {
+		return err
+	}
+	_, unlock, err := LockWait(context.Background(), db, MigrationLock, migrationLockTimeout)
+	if err != nil {
+		return fmt.Errorf("waiting for migration lock: %w", err)
+	}
+	defer unlock()
+
+	if err := db.AutoMigrate(migratedModels...); err != nil {
+		return err
+	}
+	if err := backfillProjectSlugs(db); err != nil {
+		return fmt.Errorf("assigning project slugs: %w", err)
+	}
+	if err := backfillImageFileTypes(db); err != nil {
+		return fmt.Errorf("classifying image files: %w", err)
+	}
+
+	return EnsureSearchIndex(db)
+}
+
+// backfillProjectSlugs gives a slug to projects created before projects had one
+func backfillProjectSlugs(db *gorm.DB) error {
+	var projects []models.Project
+	if err := db.Unscoped().Select("id", "name").Where("slug IS NULL OR slug = ''").Order("id ASC").Find(&projects).Error; err != nil {
+		return err
+	}
+	for _, project := range projects {
+		slug, err := models.UniqueSlug(db, project.Name, project.ID)
+		if err != nil {
+			return err
+		}
+		if err := db.Unscoped().Model(&models.Project{}).Where("id = ?", project.ID).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillImageFileTypes classifies image files recorded as other files
+// before images had a type of their own
+func backfillImageFileTypes(db *gorm.DB) error {
+	var files []models.ProjectFile
+	if err := db.Select("id", "filename").Where("file_type = ?", models.FileTypeOther).Find(&files).Error; err != nil {
+		return err
+	}
+	var ids []uint
+	for _, file := range files {
+		if models.IsImageFile(file.Filename) {
+			ids = append(ids, file.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Model(&models.ProjectFile{}).Where("id IN ?", ids).UpdateColumn("file_type", models.FileTypeImage).Error
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
@@ -3,6 +3,8 @@ package database
 import (
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -196,6 +198,28 @@ func TestDatabaseMigrations(t *testing.T) {
 	}
 }
 
+// TestMigrateBackfillsSlugs tests that projects created before slugs get one
+func TestMigrateBackfillsSlugs(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "test_slugs.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	for i, name := range []string{"Benchy", "Benchy", "Calibration Cube"} {
+		project := models.Project{Name: name, Path: filepath.Join("/test/slugs", strconv.Itoa(i))}
+		DB.Create(&project)
+	}
+	DB.Model(&models.Project{}).Where("1 = 1").UpdateColumn("slug", nil)
+
+	if err := Migrate(DB); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var slugs []string
+	DB.Model(&models.Project{}).Order("id ASC").Pluck("slug", &slugs)
+	if strings.Join(slugs, ",") != "benchy,benchy-2,calibration-cube" {
+		t.Errorf("Expected slugs in creation order, got %v", slugs)
+	}
+}
+
 // TestDatabaseConstraints tests database constraints and validations
 func TestDatabaseConstraints(t *testing.T) {
 	tmpDir := t.TempDir()
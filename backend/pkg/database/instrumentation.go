@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryStartKey = "3dshelf:query_start"
+
+	// maxRecentSlowQueries bounds how many slow queries are kept for the metrics endpoint
+	maxRecentSlowQueries = 20
+)
+
+type routeContextKey struct{}
+
+// WithRoute returns a context carrying the HTTP route that issues queries,
+// so slow query logs can be attributed to an endpoint
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// RouteFromContext returns the route stored by WithRoute, if any
+func RouteFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// OperationStats aggregates timings for one kind of database operation
+type OperationStats struct {
+	Count     int64   `json:"count"`
+	SlowCount int64   `json:"slow_count"`
+	TotalMs   float64 `json:"total_ms"`
+	MaxMs     float64 `json:"max_ms"`
+	AvgMs     float64 `json:"avg_ms"`
+}
+
+// SlowQuery describes a single query that exceeded the slow threshold
+type SlowQuery struct {
+	Operation  string    `json:"operation"`
+	SQL        string    `json:"sql"`
+	Route      string    `json:"route,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// QuerySnapshot is a point-in-time copy of the collected query statistics
+type QuerySnapshot struct {
+	SlowThresholdMs float64                    `json:"slow_threshold_ms"`
+	Operations      map[string]*OperationStats `json:"operations"`
+	RecentSlow      []SlowQuery                `json:"recent_slow"`
+}
+
+// QueryStats is a GORM plugin that records query durations and logs slow queries
+type QueryStats struct {
+	threshold time.Duration
+
+	mu         sync.Mutex
+	operations map[string]*OperationStats
+	recentSlow []SlowQuery
+}
+
+// NewQueryStats creates a QueryStats plugin logging queries slower than threshold.
+// A zero threshold disables slow query logging but keeps aggregate timings.
+func NewQueryStats(threshold time.Duration) *QueryStats {
+	return &QueryStats{
+		threshold:  threshold,
+		operations: make(map[string]*OperationStats),
+	}
+}
+
+// Instrument registers a QueryStats plugin on the given connection
+func Instrument(db *gorm.DB, threshold time.Duration) (*QueryStats, error) {
+	stats := NewQueryStats(threshold)
+	if err := db.Use(stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Name implements gorm.Plugin
+func (q *QueryStats) Name() string {
+	return "3dshelf:query_stats"
+}
+
+// Initialize implements gorm.Plugin by wrapping every callback chain
+func (q *QueryStats) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	if err := callback.Create().Before("gorm:create").Register("3dshelf:before_create", q.before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("3dshelf:after_create", q.after("create")); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register("3dshelf:before_query", q.before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register("3dshelf:after_query", q.after("query")); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("3dshelf:before_update", q.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("3dshelf:after_update", q.after("update")); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("3dshelf:before_delete", q.before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register("3dshelf:after_delete", q.after("delete")); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register("3dshelf:before_row", q.before); err != nil {
+		return err
+	}
+	if err := callback.Row().After("gorm:row").Register("3dshelf:after_row", q.after("row")); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register("3dshelf:before_raw", q.before); err != nil {
+		return err
+	}
+	return callback.Raw().After("gorm:raw").Register("3dshelf:after_raw", q.after("raw"))
+}
+
+// before stamps the statement with its start time
+func (q *QueryStats) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+// after records the elapsed time for the statement under the given operation
+func (q *QueryStats) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		q.record(db, operation, time.Since(start))
+	}
+}
+
+// record updates aggregate stats and logs the statement if it was slow
+func (q *QueryStats) record(db *gorm.DB, operation string, elapsed time.Duration) {
+	ms := float64(elapsed) / float64(time.Millisecond)
+	slow := q.threshold > 0 && elapsed >= q.threshold
+
+	q.mu.Lock()
+	op, exists := q.operations[operation]
+	if !exists {
+		op = &OperationStats{}
+		q.operations[operation] = op
+	}
+	op.Count++
+	op.TotalMs += ms
+	if ms > op.MaxMs {
+		op.MaxMs = ms
+	}
+	if slow {
+		op.SlowCount++
+	}
+	q.mu.Unlock()
+
+	if !slow {
+		return
+	}
+
+	sql := db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
+	route := RouteFromContext(db.Statement.Context)
+	log.Printf("[SLOW QUERY] %.2fms | %s | route=%q | %s", ms, operation, route, sql)
+
+	q.mu.Lock()
+	q.recentSlow = append(q.recentSlow, SlowQuery{
+		Operation:  operation,
+		SQL:        sql,
+		Route:      route,
+		DurationMs: ms,
+		Timestamp:  time.Now(),
+	})
+	if len(q.recentSlow) > maxRecentSlowQueries {
+		q.recentSlow = q.recentSlow[len(q.recentSlow)-maxRecentSlowQueries:]
+	}
+	q.mu.Unlock()
+}
+
+// Snapshot returns a copy of the collected statistics
+func (q *QueryStats) Snapshot() QuerySnapshot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	snapshot := QuerySnapshot{
+		SlowThresholdMs: float64(q.threshold) / float64(time.Millisecond),
+		Operations:      make(map[string]*OperationStats, len(q.operations)),
+		RecentSlow:      make([]SlowQuery, len(q.recentSlow)),
+	}
+
+	for name, op := range q.operations {
+		copied := *op
+		if copied.Count > 0 {
+			copied.AvgMs = copied.TotalMs / float64(copied.Count)
+		}
+		snapshot.Operations[name] = &copied
+	}
+	copy(snapshot.RecentSlow, q.recentSlow)
+
+	return snapshot
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupInstrumentedDB creates an in-memory database with query stats registered
+func setupInstrumentedDB(t *testing.T, threshold time.Duration) (*gorm.DB, *QueryStats) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	stats, err := Instrument(db, threshold)
+	if err != nil {
+		t.Fatalf("Failed to instrument database: %v", err)
+	}
+
+	return db, stats
+}
+
+// TestQueryStatsAggregates tests that operations are counted per kind
+func TestQueryStatsAggregates(t *testing.T) {
+	db, stats := setupInstrumentedDB(t, time.Hour)
+
+	// A set slug skips the lookup for a free one, which would count as a query
+	project := models.Project{Name: "Stats", Slug: "stats", Path: "/test/stats", LastScanned: time.Now()}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	var projects []models.Project
+	db.Find(&projects)
+	db.Find(&projects)
+	db.Model(&project).Update("description", "updated")
+	db.Delete(&project)
+
+	snapshot := stats.Snapshot()
+
+	expected := map[string]int64{"create": 1, "query": 2, "update": 1, "delete": 1}
+	for operation, count := range expected {
+		op, exists := snapshot.Operations[operation]
+		if !exists {
+			t.Errorf("Expected stats for operation %s", operation)
+			continue
+		}
+		if op.Count != count {
+			t.Errorf("Expected %d %s operations, got %d", count, operation, op.Count)
+		}
+		if op.SlowCount != 0 {
+			t.Errorf("Expected no slow %s operations with a one hour threshold", operation)
+		}
+	}
+
+	if len(snapshot.RecentSlow) != 0 {
+		t.Errorf("Expected no slow queries, got %d", len(snapshot.RecentSlow))
+	}
+}
+
+// TestQueryStatsSlowQueries tests that slow queries are kept with their route
+func TestQueryStatsSlowQueries(t *testing.T) {
+	db, stats := setupInstrumentedDB(t, time.Nanosecond)
+
+	ctx := WithRoute(context.Background(), "GET /api/projects")
+	var projects []models.Project
+	db.WithContext(ctx).Find(&projects)
+
+	snapshot := stats.Snapshot()
+	if len(snapshot.RecentSlow) != 1 {
+		t.Fatalf("Expected 1 slow query, got %d", len(snapshot.RecentSlow))
+	}
+
+	slow := snapshot.RecentSlow[0]
+	if slow.Route != "GET /api/projects" {
+		t.Errorf("Expected route 'GET /api/projects', got '%s'", slow.Route)
+	}
+	if slow.Operation != "query" || slow.SQL == "" {
+		t.Errorf("Unexpected slow query record: %+v", slow)
+	}
+
+	for i := 0; i < maxRecentSlowQueries+5; i++ {
+		db.Find(&projects)
+	}
+
+	if got := len(stats.Snapshot().RecentSlow); got != maxRecentSlowQueries {
+		t.Errorf("Expected recent slow queries to be capped at %d, got %d", maxRecentSlowQueries, got)
+	}
+}
+
+// TestRouteFromContext tests reading routes from contexts
+func TestRouteFromContext(t *testing.T) {
+	if route := RouteFromContext(context.Background()); route != "" {
+		t.Errorf("Expected empty route, got '%s'", route)
+	}
+
+	ctx := WithRoute(context.Background(), "POST /api/projects/scan")
+	if route := RouteFromContext(ctx); route != "POST /api/projects/scan" {
+		t.Errorf("Expected route to round-trip, got '%s'", route)
+	}
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"3dshelf/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// LibraryLock guards operations spanning the whole library, such as scans and deduplication
+	LibraryLock = "library"
+
+	// MigrationLock lets instances starting together take turns migrating the schema
+	MigrationLock = "migrate"
+
+	// lockTTL is how long a lock is held without renewal; locks of instances
+	// that crashed lapse after it
+	lockTTL = time.Minute
+
+	// lockRetryInterval is how often LockWait tries a held lock again
+	lockRetryInterval = 250 * time.Millisecond
+)
+
+// lockRenewInterval is how often held locks are renewed
+var lockRenewInterval = lockTTL / 3
+
+// ErrLocked is returned when another operation holds a lock
+var ErrLocked = errors.New("locked by another operation")
+
+// ErrLockLost is the cause a lock's context is cancelled with when renewing
+// the lock finds it lapsed and taken by another operation
+var ErrLockLost = errors.New("lock lost to another operation")
+
+// lockOwners numbers this process's acquisitions, so two operations in one
+// process exclude each other just like two instances do
+var lockOwners atomic.Uint64
+
+// ProjectLock names the lock guarding mutations of a project's files
+func ProjectLock(projectID uint) string {
+	return fmt.Sprintf("project:%d", projectID)
+}
+
+// Lock takes the named advisory lock, failing with ErrLocked while another
+// operation on any instance holds it. The lock is renewed until the returned
+// function releases it. The returned context, derived from ctx, is cancelled
+// with ErrLockLost when a renewal finds the lock taken over, so operations
+// that run long stop rather than carry on unguarded.
+func Lock(ctx context.Context, db *gorm.DB, name string) (context.Context, func(), error) {
+	owner := fmt.Sprintf("%s-%d#%d", hostname(), os.Getpid(), lockOwners.Add(1))
+
+	now := time.Now()
+	if err := db.Where("name = ? AND expires_at < ?", name, now).Delete(&models.Lock{}).Error; err != nil {
+		return nil, nil, err
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Lock{Name: name, Owner: owner, ExpiresAt: now.Add(lockTTL)})
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrLocked
+	}
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			result := db.Model(&models.Lock{}).Where("name = ? AND owner = ?", name, owner).
+				Update("expires_at", time.Now().Add(lockTTL))
+			if result.Error != nil {
+				fmt.Printf("Warning: Failed to renew lock %s: %v\n", name, result.Error)
+				continue
+			}
+			if result.RowsAffected == 0 {
+				fmt.Printf("Warning: Lock %s lapsed and was taken by another operation\n", name)
+				cancel(ErrLockLost)
+				return
+			}
+		}
+	}()
+
+	return lockCtx, func() {
+		close(stop)
+		<-stopped
+		cancel(nil)
+		if err := db.Where("name = ? AND owner = ?", name, owner).Delete(&models.Lock{}).Error; err != nil {
+			fmt.Printf("Warning: Failed to release lock %s: %v\n", name, err)
+		}
+	}, nil
+}
+
+// LockWait is like Lock, but waits up to timeout for the lock to be released
+func LockWait(ctx context.Context, db *gorm.DB, name string, timeout time.Duration) (context.Context, func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lockCtx, unlock, err := Lock(ctx, db, name)
+		if !errors.Is(err, ErrLocked) || time.Now().After(deadline) {
+			return lockCtx, unlock, err
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"3dshelf/internal/models"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLock tests advisory locks exclude other owners until released or expired
+func TestLock(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "locks.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	_, unlock, err := Lock(context.Background(), DB, LibraryLock)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if _, _, err := Lock(context.Background(), DB, LibraryLock); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked for a held lock, got %v", err)
+	}
+	_, other, err := Lock(context.Background(), DB, ProjectLock(7))
+	if err != nil {
+		t.Fatalf("Expected other locks to be independent, got %v", err)
+	}
+	other()
+
+	unlock()
+	_, unlock, err = Lock(context.Background(), DB, LibraryLock)
+	if err != nil {
+		t.Fatalf("Expected released lock to be free, got %v", err)
+	}
+	unlock()
+
+	// A lock of an instance that crashed lapses
+	DB.Create(&models.Lock{Name: ProjectLock(1), Owner: "crashed-1#1", ExpiresAt: time.Now().Add(-time.Second)})
+	_, unlock, err = Lock(context.Background(), DB, ProjectLock(1))
+	if err != nil {
+		t.Fatalf("Expected expired lock to be taken over, got %v", err)
+	}
+	unlock()
+
+	var count int64
+	DB.Model(&models.Lock{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected every lock to be released, got %d", count)
+	}
+}
+
+// TestLockWait tests waiting for a held lock to be released
+func TestLockWait(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "locks.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	_, unlock, err := Lock(context.Background(), DB, ProjectLock(1))
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if _, _, err := LockWait(context.Background(), DB, ProjectLock(1), 100*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked after the timeout, got %v", err)
+	}
+
+	time.AfterFunc(300*time.Millisecond, unlock)
+	_, waited, err := LockWait(context.Background(), DB, ProjectLock(1), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expected the lock once released, got %v", err)
+	}
+	waited()
+}
+
+// TestLockLost tests a holder whose lock lapsed and was taken over learns of it
+func TestLockLost(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "locks.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func(interval time.Duration) { lockRenewInterval = interval }(lockRenewInterval)
+	lockRenewInterval = 20 * time.Millisecond
+
+	lockCtx, unlock, err := Lock(context.Background(), DB, LibraryLock)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	// Another instance took the lock over after it lapsed
+	DB.Model(&models.Lock{}).Where("name = ?", LibraryLock).Update("owner", "other-1#1")
+
+	select {
+	case <-lockCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the context to be cancelled once the lock was lost")
+	}
+	if cause := context.Cause(lockCtx); !errors.Is(cause, ErrLockLost) {
+		t.Errorf("Expected %v as the cause, got %v", ErrLockLost, cause)
+	}
+	unlock()
+
+	var lock models.Lock
+	if err := DB.Where("name = ?", LibraryLock).First(&lock).Error; err != nil || lock.Owner != "other-1#1" {
+		t.Errorf("Expected releasing to leave the new holder's lock, got %+v %v", lock, err)
+	}
+}
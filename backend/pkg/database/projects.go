@@ -0,0 +1,105 @@
+package database
+
+import (
+	"3dshelf/internal/models"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// projectRecords are the models whose rows belong to a single project, by
+// project_id, and are deleted with it
+var projectRecords = []interface{}{
+	&models.ProjectFile{},
+	&models.FileProfile{},
+	&models.FileActivity{},
+	&models.LinkIssue{},
+	&models.PrintMedia{},
+	&models.BOMItem{},
+	&models.Assembly{},
+	&models.Distribution{},
+	&models.UploadSession{},
+	&models.UploadToken{},
+	&models.ReplicatedProject{},
+}
+
+// DeleteProjectRecords deletes the records belonging to the projects and
+// drops the references records outliving them keep, such as printed parts,
+// orders and device tokens. Print jobs are kept as history. The caller deletes
+// the projects themselves, in the same transaction.
+func DeleteProjectRecords(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, record := range projectRecords {
+		if err := tx.Where("project_id IN ?", ids).Delete(record).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.PrintedPart{}).Where("project_id IN ?", ids).
+		Updates(map[string]interface{}{"project_id": nil, "file_id": nil}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.ImportItem{}).Where("project_id IN ?", ids).Update("project_id", nil).Error; err != nil {
+		return err
+	}
+
+	// Orders and device tokens list their projects in JSON columns
+	if err := dropOrderItems(tx, ids); err != nil {
+		return err
+	}
+	var tokens []models.DeviceToken
+	if err := tx.Find(&tokens).Error; err != nil {
+		return err
+	}
+	for i := range tokens {
+		token := &tokens[i]
+		remaining := slices.DeleteFunc(slices.Clone(token.ProjectIDs), func(id uint) bool {
+			return slices.Contains(ids, id)
+		})
+		if len(remaining) == len(token.ProjectIDs) {
+			continue
+		}
+		token.ProjectIDs = remaining
+		if err := tx.Model(token).Select("project_ids").Updates(token).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropOrderItems removes the items of the projects from orders, deleting
+// orders left with none as an order needs at least one item
+func dropOrderItems(tx *gorm.DB, ids []uint) error {
+	var orders []models.Order
+	if err := tx.Find(&orders).Error; err != nil {
+		return err
+	}
+
+	for i := range orders {
+		order := &orders[i]
+		remaining := slices.DeleteFunc(slices.Clone(order.Items), func(item models.OrderItem) bool {
+			return slices.Contains(ids, item.ProjectID)
+		})
+		if len(remaining) == len(order.Items) {
+			continue
+		}
+
+		if len(remaining) > 0 {
+			order.Items = remaining
+			if err := tx.Model(order).Select("items").Updates(order).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Delete(order).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PrintJob{}).Where("order_id = ?", order.ID).Update("order_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
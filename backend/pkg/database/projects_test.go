@@ -0,0 +1,155 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// outlivingRecords are the models referencing projects whose rows are kept
+// when a project is deleted, with the reference dropped or, for print jobs,
+// kept as history
+var outlivingRecords = []interface{}{
+	&models.PrintJob{},
+	&models.PrintedPart{},
+	&models.ImportItem{},
+	&models.Order{},
+	&models.DeviceToken{},
+}
+
+// referencesProjects reports whether a model has a ProjectID or ProjectIDs
+// field, directly or on the elements of a slice stored as JSON
+func referencesProjects(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "ProjectID" || field.Name == "ProjectIDs" {
+			return true
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct &&
+			strings.Contains(field.Tag.Get("gorm"), "serializer:json") && referencesProjects(field.Type.Elem()) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestDeleteProjectRecordsCoversModels tests that every migrated model
+// referencing projects is cleaned up by DeleteProjectRecords
+func TestDeleteProjectRecordsCoversModels(t *testing.T) {
+	handled := map[reflect.Type]bool{}
+	for _, model := range append(slices.Clone(projectRecords), outlivingRecords...) {
+		handled[reflect.TypeOf(model).Elem()] = true
+	}
+
+	for _, model := range migratedModels {
+		typ := reflect.TypeOf(model).Elem()
+		if referencesProjects(typ) && !handled[typ] {
+			t.Errorf("%s references projects but DeleteProjectRecords does not clean it up", typ.Name())
+		}
+	}
+}
+
+// TestDeleteProjectRecords tests that records of deleted projects are deleted
+// or unlinked while other projects' records are left alone
+func TestDeleteProjectRecords(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "projects.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	deleted := models.Project{Name: "Deleted", Path: "/library/deleted"}
+	kept := models.Project{Name: "Kept", Path: "/library/kept"}
+	for _, project := range []*models.Project{&deleted, &kept} {
+		if err := DB.Create(project).Error; err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+	}
+
+	// One row of every project record for each project, with strings and IDs
+	// set from the project so unique indexes are satisfied
+	for _, model := range projectRecords {
+		typ := reflect.TypeOf(model).Elem()
+		for _, project := range []models.Project{deleted, kept} {
+			record := reflect.New(typ).Elem()
+			for i := 0; i < typ.NumField(); i++ {
+				switch typ.Field(i).Type.Kind() {
+				case reflect.String:
+					record.Field(i).SetString(fmt.Sprintf("%s-%d", typ.Field(i).Name, project.ID))
+				case reflect.Uint:
+					record.Field(i).SetUint(uint64(project.ID))
+				}
+			}
+			if err := DB.Create(record.Addr().Interface()).Error; err != nil {
+				t.Fatalf("Failed to create %s: %v", typ.Name(), err)
+			}
+		}
+	}
+
+	fileID := uint(7)
+	part := models.PrintedPart{Name: "Leg", ProjectID: &deleted.ID, FileID: &fileID}
+	item := models.ImportItem{JobID: 1, RemoteID: "remote", ProjectID: &deleted.ID}
+	job := models.PrintJob{ProjectID: deleted.ID}
+	token := models.DeviceToken{Name: "Printer", TokenHash: "hash", ProjectIDs: []uint{deleted.ID, kept.ID}}
+	mixed := models.Order{Customer: "Mixed", Items: []models.OrderItem{{ProjectID: deleted.ID, Quantity: 1}, {ProjectID: kept.ID, Quantity: 2}}}
+	emptied := models.Order{Customer: "Emptied", Items: []models.OrderItem{{ProjectID: deleted.ID, Quantity: 1}}}
+	for _, record := range []interface{}{&part, &item, &job, &token, &mixed, &emptied} {
+		if err := DB.Create(record).Error; err != nil {
+			t.Fatalf("Failed to create %T: %v", record, err)
+		}
+	}
+	orderJob := models.PrintJob{ProjectID: kept.ID, OrderID: &emptied.ID}
+	if err := DB.Create(&orderJob).Error; err != nil {
+		t.Fatalf("Failed to create print job: %v", err)
+	}
+
+	if err := DeleteProjectRecords(DB, []uint{deleted.ID}); err != nil {
+		t.Fatalf("DeleteProjectRecords failed: %v", err)
+	}
+
+	for _, model := range projectRecords {
+		for _, check := range []struct {
+			projectID uint
+			want      int64
+		}{{deleted.ID, 0}, {kept.ID, 1}} {
+			var count int64
+			if err := DB.Model(model).Where("project_id = ?", check.projectID).Count(&count).Error; err != nil {
+				t.Fatalf("Failed to count %T: %v", model, err)
+			}
+			if count != check.want {
+				t.Errorf("Expected %d %T rows of project %d, got %d", check.want, model, check.projectID, count)
+			}
+		}
+	}
+
+	DB.First(&part, part.ID)
+	if part.ProjectID != nil || part.FileID != nil {
+		t.Errorf("Expected the printed part to be unlinked, got %+v", part)
+	}
+	DB.First(&item, item.ID)
+	if item.ProjectID != nil {
+		t.Errorf("Expected the import item to be unlinked, got %+v", item)
+	}
+	if err := DB.First(&job, job.ID).Error; err != nil {
+		t.Errorf("Expected the print job to be kept: %v", err)
+	}
+	DB.First(&token, token.ID)
+	if !reflect.DeepEqual(token.ProjectIDs, []uint{kept.ID}) {
+		t.Errorf("Expected the device token to keep only project %d, got %v", kept.ID, token.ProjectIDs)
+	}
+
+	DB.First(&mixed, mixed.ID)
+	if len(mixed.Items) != 1 || mixed.Items[0].ProjectID != kept.ID {
+		t.Errorf("Expected the order to keep only the other project's item, got %+v", mixed.Items)
+	}
+	if err := DB.First(&models.Order{}, emptied.ID).Error; err == nil {
+		t.Error("Expected the order left without items to be deleted")
+	}
+	DB.First(&orderJob, orderJob.ID)
+	if orderJob.OrderID != nil {
+		t.Errorf("Expected the print job of the deleted order to be unlinked, got order %d", *orderJob.OrderID)
+	}
+}
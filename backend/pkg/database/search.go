@@ -0,0 +1,65 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// SearchIndexTable is the FTS4 table indexing project names, tags and designers.
+// Its docid is the project ID.
+const SearchIndexTable = "project_search"
+
+// searchIndexStatements create the index and the triggers that keep it in sync with projects
+var searchIndexStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS project_search USING fts4(name, tags, designer)`,
+	`CREATE TRIGGER IF NOT EXISTS project_search_insert AFTER INSERT ON projects
+	WHEN new.deleted_at IS NULL BEGIN
+		INSERT INTO project_search(docid, name, tags, designer) VALUES (new.id, new.name, new.tags, new.designer);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS project_search_update AFTER UPDATE ON projects BEGIN
+		DELETE FROM project_search WHERE docid = old.id;
+		INSERT INTO project_search(docid, name, tags, designer)
+			SELECT new.id, new.name, new.tags, new.designer WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS project_search_delete AFTER DELETE ON projects BEGIN
+		DELETE FROM project_search WHERE docid = old.id;
+	END`,
+}
+
+// EnsureSearchIndex creates the full-text search index and rebuilds it from the projects table
+func EnsureSearchIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range searchIndexStatements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM project_search").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO project_search(docid, name, tags, designer)
+			SELECT id, name, tags, designer FROM projects WHERE deleted_at IS NULL`).Error
+	})
+}
+
+// SearchWords splits text into the lowercase words the search index matches on
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// PrefixMatchQuery turns free text into an FTS MATCH expression where every word is
+// a prefix term. Punctuation is dropped so user input cannot inject query syntax.
+// It returns an empty string when the text has no searchable words.
+func PrefixMatchQuery(text string) string {
+	words := SearchWords(text)
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + "*"
+	}
+	return strings.Join(terms, " ")
+}
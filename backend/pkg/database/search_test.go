@@ -0,0 +1,49 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// TestPrefixMatchQuery tests building FTS prefix queries from user input
+func TestPrefixMatchQuery(t *testing.T) {
+	testCases := map[string]string{
+		"be":              "be*",
+		"Tiny  Benchy":    "tiny* benchy*",
+		`gear" OR name:*`: "gear* or* name*",
+		"  -- ":           "",
+		"Böen":            "böen*",
+	}
+
+	for input, expected := range testCases {
+		if got := PrefixMatchQuery(input); got != expected {
+			t.Errorf("PrefixMatchQuery(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+// TestEnsureSearchIndexRebuild tests that the index is rebuilt from existing projects
+func TestEnsureSearchIndexRebuild(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "search.db")
+	if err := Initialize(dbPath); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	DB.Create(&models.Project{Name: "Benchy", Path: "/test/benchy", Tags: []string{"boat"}})
+
+	// Drift the index, then rebuild it as a restart would
+	DB.Exec("DELETE FROM project_search")
+	if err := EnsureSearchIndex(DB); err != nil {
+		t.Fatalf("EnsureSearchIndex failed: %v", err)
+	}
+
+	var ids []uint
+	if err := DB.Raw("SELECT docid FROM project_search WHERE project_search MATCH ?", "boat*").Scan(&ids).Error; err != nil {
+		t.Fatalf("Search query failed: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Errorf("Expected 1 match after rebuild, got %d", len(ids))
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"3dshelf/internal/models"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoadSetting decodes a persisted setting into dest. It reports false when
+// the setting has never been saved, leaving dest untouched.
+func LoadSetting(db *gorm.DB, key string, dest interface{}) (bool, error) {
+	var setting models.Setting
+	if err := db.Where("key = ?", key).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal([]byte(setting.Value), dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveSetting persists a setting as JSON, replacing any previous value
+func SaveSetting(db *gorm.DB, key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{Key: key, Value: string(encoded)}).Error
+}
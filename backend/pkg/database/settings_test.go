@@ -0,0 +1,39 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestSettings tests saving, replacing and loading JSON settings
+func TestSettings(t *testing.T) {
+	if err := Initialize(filepath.Join(t.TempDir(), "settings.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	type example struct {
+		Enabled bool     `json:"enabled"`
+		Items   []string `json:"items"`
+	}
+
+	var loaded example
+	found, err := LoadSetting(DB, "example", &loaded)
+	if err != nil || found {
+		t.Fatalf("Expected missing setting, got found=%v err=%v", found, err)
+	}
+
+	if err := SaveSetting(DB, "example", example{Enabled: true, Items: []string{"a"}}); err != nil {
+		t.Fatalf("SaveSetting failed: %v", err)
+	}
+	if err := SaveSetting(DB, "example", example{Items: []string{"b", "c"}}); err != nil {
+		t.Fatalf("SaveSetting replace failed: %v", err)
+	}
+
+	found, err = LoadSetting(DB, "example", &loaded)
+	if err != nil || !found {
+		t.Fatalf("Expected saved setting, got found=%v err=%v", found, err)
+	}
+	if loaded.Enabled || len(loaded.Items) != 2 || loaded.Items[1] != "c" {
+		t.Errorf("Expected replaced value, got %+v", loaded)
+	}
+}
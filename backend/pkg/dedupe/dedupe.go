@@ -0,0 +1,329 @@
+package dedupe
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/fsutil"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// projectLockWait is how long applying a plan waits for an upload, rename or
+// other change to a project it relinks files in
+const projectLockWait = 30 * time.Second
+
+// Policy selects which copy of a duplicate becomes canonical
+type Policy string
+
+const (
+	// KeepNewest keeps the most recently updated copy
+	KeepNewest Policy = "keep_newest"
+	// KeepLargestProject keeps the copy in the project with the most files
+	KeepLargestProject Policy = "keep_largest_project"
+)
+
+// Action selects what happens to non-canonical copies
+type Action string
+
+const (
+	// ActionLink replaces duplicates with links to the canonical copy
+	ActionLink Action = "link"
+	// ActionDelete removes duplicates from disk and the database
+	ActionDelete Action = "delete"
+)
+
+// Options configures a deduplication plan
+type Options struct {
+	Policy Policy `json:"policy"`
+	Action Action `json:"action"`
+}
+
+// Validate checks the options and fills in defaults
+func (o *Options) Validate() error {
+	if o.Policy == "" {
+		o.Policy = KeepNewest
+	}
+	if o.Action == "" {
+		o.Action = ActionLink
+	}
+
+	if o.Policy != KeepNewest && o.Policy != KeepLargestProject {
+		return fmt.Errorf("unsupported policy: %s", o.Policy)
+	}
+	if o.Action != ActionLink && o.Action != ActionDelete {
+		return fmt.Errorf("unsupported action: %s", o.Action)
+	}
+	return nil
+}
+
+// FileRef describes one copy of a duplicated file
+type FileRef struct {
+	FileID      uint   `json:"file_id"`
+	ProjectID   uint   `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	Filename    string `json:"filename"`
+	Filepath    string `json:"filepath"`
+}
+
+// Group is a set of files sharing the same content hash
+type Group struct {
+	Hash             string    `json:"hash"`
+	Size             int64     `json:"size"`
+	Canonical        FileRef   `json:"canonical"`
+	Duplicates       []FileRef `json:"duplicates"`
+	ReclaimableBytes int64     `json:"reclaimable_bytes"`
+}
+
+// Plan is the dry-run report of what deduplication would change
+type Plan struct {
+	Options          Options `json:"options"`
+	Groups           []Group `json:"groups"`
+	DuplicateCount   int     `json:"duplicate_count"`
+	ReclaimableBytes int64   `json:"reclaimable_bytes"`
+
+	// Token fingerprints the plan; executing requires the token of a matching dry run
+	Token string `json:"token"`
+}
+
+// Result summarizes an executed plan
+type Result struct {
+	Applied          int      `json:"applied"`
+	ReclaimedBytes   int64    `json:"reclaimed_bytes"`
+	Errors           []string `json:"errors,omitempty"`
+	DuplicateCount   int      `json:"duplicate_count"`
+	ReclaimableBytes int64    `json:"reclaimable_bytes"`
+}
+
+// candidate is a file row joined with its project for planning
+type candidate struct {
+	models.ProjectFile
+	ProjectName      string
+	ProjectFileCount int64
+}
+
+// BuildPlan groups files by hash and selects a canonical copy for each group
+func BuildPlan(db *gorm.DB, opts Options) (*Plan, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var candidates []candidate
+	err := db.Table("project_files").
+		Select("project_files.*, projects.name AS project_name, "+
+			"(SELECT COUNT(*) FROM project_files pf WHERE pf.project_id = project_files.project_id) AS project_file_count").
+		Joins("JOIN projects ON projects.id = project_files.project_id AND projects.deleted_at IS NULL").
+		Where("project_files.hash <> '' AND project_files.duplicate_of IS NULL").
+		Where("project_files.hash IN (?)", db.Table("project_files").
+			Select("hash").
+			Where("hash <> '' AND duplicate_of IS NULL").
+			Group("hash").
+			Having("COUNT(*) > 1")).
+		Order("project_files.hash, project_files.id").
+		Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byHash := make(map[string][]candidate)
+	var hashes []string
+	for _, c := range candidates {
+		if _, seen := byHash[c.Hash]; !seen {
+			hashes = append(hashes, c.Hash)
+		}
+		byHash[c.Hash] = append(byHash[c.Hash], c)
+	}
+
+	plan := &Plan{Options: opts, Groups: []Group{}}
+	for _, hash := range hashes {
+		files := byHash[hash]
+		sort.SliceStable(files, func(i, j int) bool { return prefer(opts.Policy, files[i], files[j]) })
+
+		canonical := files[0]
+		group := Group{Hash: hash, Size: canonical.Size, Canonical: ref(canonical)}
+		for _, dup := range files[1:] {
+			if sameFile(canonical.Filepath, dup.Filepath) {
+				// Already linked on disk; nothing to reclaim
+				continue
+			}
+			group.Duplicates = append(group.Duplicates, ref(dup))
+			group.ReclaimableBytes += dup.Size
+		}
+
+		if len(group.Duplicates) == 0 {
+			continue
+		}
+
+		plan.Groups = append(plan.Groups, group)
+		plan.DuplicateCount += len(group.Duplicates)
+		plan.ReclaimableBytes += group.ReclaimableBytes
+	}
+
+	plan.Token = fingerprint(plan)
+	return plan, nil
+}
+
+// Execute applies a plan to the filesystem and database. Each project is
+// locked while its files change, so uploads and renames wait; it stops once
+// ctx is done, as when the library lock it runs under is lost.
+func Execute(ctx context.Context, db *gorm.DB, plan *Plan) *Result {
+	result := &Result{DuplicateCount: plan.DuplicateCount, ReclaimableBytes: plan.ReclaimableBytes}
+
+	for _, group := range plan.Groups {
+		if ctx.Err() != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("stopped: %v", context.Cause(ctx)))
+			break
+		}
+		executeGroup(ctx, db, plan.Options.Action, group, result)
+	}
+
+	return result
+}
+
+// executeGroup consolidates one group's duplicates into its canonical copy
+func executeGroup(ctx context.Context, db *gorm.DB, action Action, group Group, result *Result) {
+	unlock, err := lockProject(ctx, db, group.Canonical.ProjectID)
+	if err != nil {
+		for _, dup := range group.Duplicates {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", dup.Filepath, err))
+		}
+		return
+	}
+	defer unlock()
+
+	// Stored hashes may be stale, so the copies are compared as they are on
+	// disk before one replaces or removes another
+	_, canonicalHash, canonicalErr := fsutil.HashFile(group.Canonical.Filepath)
+	for _, dup := range group.Duplicates {
+		err := canonicalErr
+		if err == nil {
+			err = applyDuplicate(ctx, db, action, group.Canonical, canonicalHash, dup)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", dup.Filepath, err))
+			continue
+		}
+
+		result.Applied++
+		result.ReclaimedBytes += group.Size
+	}
+}
+
+// applyDuplicate links or deletes one duplicate, holding its project's lock
+// when it is in another project than the canonical copy
+func applyDuplicate(ctx context.Context, db *gorm.DB, action Action, canonical FileRef, canonicalHash string, dup FileRef) error {
+	if dup.ProjectID != canonical.ProjectID {
+		unlock, err := lockProject(ctx, db, dup.ProjectID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	if err := checkContent(canonicalHash, dup); err != nil {
+		return err
+	}
+	switch action {
+	case ActionLink:
+		return linkDuplicate(db, canonical, dup)
+	case ActionDelete:
+		return deleteDuplicate(db, dup)
+	}
+	return nil
+}
+
+// lockProject waits for other changes to a project to finish and keeps them
+// out until the returned function is called
+func lockProject(ctx context.Context, db *gorm.DB, projectID uint) (func(), error) {
+	_, unlock, err := database.LockWait(ctx, db, database.ProjectLock(projectID), projectLockWait)
+	return unlock, err
+}
+
+// prefer reports whether a should be kept over b under the policy
+func prefer(policy Policy, a, b candidate) bool {
+	if policy == KeepLargestProject && a.ProjectFileCount != b.ProjectFileCount {
+		return a.ProjectFileCount > b.ProjectFileCount
+	}
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// linkDuplicate atomically replaces a duplicate with a link to the canonical copy
+func linkDuplicate(db *gorm.DB, canonical, dup FileRef) error {
+	tmp := dup.Filepath + ".dedupe-tmp"
+	os.Remove(tmp)
+
+	if err := os.Link(canonical.Filepath, tmp); err != nil {
+		// Hard links can't cross filesystems; fall back to a symlink
+		if err := os.Symlink(canonical.Filepath, tmp); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmp, dup.Filepath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return db.Model(&models.ProjectFile{}).Where("id = ?", dup.FileID).Update("duplicate_of", canonical.FileID).Error
+}
+
+// deleteDuplicate removes a duplicate from disk and the database
+func deleteDuplicate(db *gorm.DB, dup FileRef) error {
+	if err := os.Remove(dup.Filepath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return db.Delete(&models.ProjectFile{}, dup.FileID).Error
+}
+
+// checkContent verifies that a duplicate still has the canonical copy's content
+func checkContent(canonicalHash string, dup FileRef) error {
+	_, hash, err := fsutil.HashFile(dup.Filepath)
+	if err != nil {
+		return err
+	}
+	if hash != canonicalHash {
+		return fmt.Errorf("content no longer matches the canonical copy; rescan and review a new plan")
+	}
+	return nil
+}
+
+// sameFile reports whether two paths already refer to the same file on disk
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// ref converts a planning candidate to a report reference
+func ref(c candidate) FileRef {
+	return FileRef{
+		FileID:      c.ID,
+		ProjectID:   c.ProjectID,
+		ProjectName: c.ProjectName,
+		Filename:    c.Filename,
+		Filepath:    c.Filepath,
+	}
+}
+
+// fingerprint hashes the decisions in a plan so a later execution can
+// prove it matches what was reviewed
+func fingerprint(plan *Plan) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s\n", plan.Options.Policy, plan.Options.Action)
+	for _, group := range plan.Groups {
+		fmt.Fprintf(h, "%s:%d", group.Hash, group.Canonical.FileID)
+		for _, dup := range group.Duplicates {
+			fmt.Fprintf(h, ",%d", dup.FileID)
+		}
+		fmt.Fprintln(h)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,286 @@
+package dedupe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a test database for dedupe tests
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.Project{}, &models.ProjectFile{}, &models.Lock{}); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// createFile writes a project file to disk and records it in the database
+func createFile(t *testing.T, db *gorm.DB, project *models.Project, name, content, hash string, updatedAt time.Time) models.ProjectFile {
+	path := filepath.Join(project.Path, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+
+	file := models.ProjectFile{
+		ProjectID: project.ID,
+		Filename:  name,
+		Filepath:  path,
+		FileType:  models.GetFileTypeFromExtension(filepath.Ext(name)),
+		Size:      int64(len(content)),
+		Hash:      hash,
+	}
+	if err := db.Create(&file).Error; err != nil {
+		t.Fatalf("Failed to create file record: %v", err)
+	}
+	if err := db.Model(&file).UpdateColumn("updated_at", updatedAt).Error; err != nil {
+		t.Fatalf("Failed to set updated_at: %v", err)
+	}
+	return file
+}
+
+// createProject creates a project directory and record
+func createProject(t *testing.T, db *gorm.DB, base, name string) *models.Project {
+	path := filepath.Join(base, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+
+	project := &models.Project{Name: name, Path: path}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	return project
+}
+
+// fixture holds the duplicated files created by setupLibrary
+type fixture struct {
+	small, large, newest models.ProjectFile
+}
+
+// setupLibrary creates three copies of the same model: one in a small project,
+// one in a project with extra files, and the most recently updated one
+func setupLibrary(t *testing.T, db *gorm.DB) fixture {
+	base := t.TempDir()
+	now := time.Now()
+
+	small := createProject(t, db, base, "Small")
+	large := createProject(t, db, base, "Large")
+	newest := createProject(t, db, base, "Newest")
+
+	f := fixture{
+		small:  createFile(t, db, small, "part.stl", "solid part", "samehash", now.Add(-2*time.Hour)),
+		large:  createFile(t, db, large, "part.stl", "solid part", "samehash", now.Add(-3*time.Hour)),
+		newest: createFile(t, db, newest, "copy.stl", "solid part", "samehash", now),
+	}
+	createFile(t, db, large, "README.md", "# Large", "readmehash", now)
+	createFile(t, db, large, "other.stl", "solid other", "otherhash", now)
+
+	return f
+}
+
+func TestBuildPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		canonical func(fixture) uint
+	}{
+		{"keep newest", KeepNewest, func(f fixture) uint { return f.newest.ID }},
+		{"keep largest project", KeepLargestProject, func(f fixture) uint { return f.large.ID }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			f := setupLibrary(t, db)
+
+			plan, err := BuildPlan(db, Options{Policy: tt.policy})
+			if err != nil {
+				t.Fatalf("BuildPlan failed: %v", err)
+			}
+
+			if len(plan.Groups) != 1 {
+				t.Fatalf("Expected 1 duplicate group, got %d", len(plan.Groups))
+			}
+			group := plan.Groups[0]
+			if group.Canonical.FileID != tt.canonical(f) {
+				t.Errorf("Expected canonical file %d, got %d", tt.canonical(f), group.Canonical.FileID)
+			}
+			if plan.DuplicateCount != 2 {
+				t.Errorf("Expected 2 duplicates, got %d", plan.DuplicateCount)
+			}
+			if plan.ReclaimableBytes != 2*int64(len("solid part")) {
+				t.Errorf("Expected %d reclaimable bytes, got %d", 2*len("solid part"), plan.ReclaimableBytes)
+			}
+			if plan.Options.Action != ActionLink {
+				t.Errorf("Expected default action %s, got %s", ActionLink, plan.Options.Action)
+			}
+		})
+	}
+}
+
+func TestBuildPlanToken(t *testing.T) {
+	db := setupTestDB(t)
+	setupLibrary(t, db)
+
+	first, err := BuildPlan(db, Options{})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	second, err := BuildPlan(db, Options{})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if first.Token != second.Token {
+		t.Error("Expected identical plans to share a token")
+	}
+
+	other, err := BuildPlan(db, Options{Policy: KeepLargestProject})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if other.Token == first.Token {
+		t.Error("Expected a different policy to produce a different token")
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := (&Options{Policy: "keep_oldest"}).Validate(); err == nil {
+		t.Error("Expected unsupported policy to fail validation")
+	}
+	if err := (&Options{Action: "archive"}).Validate(); err == nil {
+		t.Error("Expected unsupported action to fail validation")
+	}
+}
+
+func TestExecuteLink(t *testing.T) {
+	db := setupTestDB(t)
+	f := setupLibrary(t, db)
+
+	plan, err := BuildPlan(db, Options{Policy: KeepNewest, Action: ActionLink})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	result := Execute(context.Background(), db, plan)
+	if result.Applied != 2 || len(result.Errors) != 0 {
+		t.Fatalf("Expected 2 applied without errors, got %d applied, errors %v", result.Applied, result.Errors)
+	}
+
+	for _, dup := range []models.ProjectFile{f.small, f.large} {
+		if !sameFile(f.newest.Filepath, dup.Filepath) {
+			t.Errorf("Expected %s to be linked to the canonical copy", dup.Filepath)
+		}
+
+		var updated models.ProjectFile
+		db.First(&updated, dup.ID)
+		if updated.DuplicateOf == nil || *updated.DuplicateOf != f.newest.ID {
+			t.Errorf("Expected file %d to reference canonical file %d", dup.ID, f.newest.ID)
+		}
+	}
+
+	again, err := BuildPlan(db, Options{})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	if again.DuplicateCount != 0 {
+		t.Errorf("Expected no duplicates after linking, got %d", again.DuplicateCount)
+	}
+}
+
+func TestExecuteDelete(t *testing.T) {
+	db := setupTestDB(t)
+	f := setupLibrary(t, db)
+
+	plan, err := BuildPlan(db, Options{Policy: KeepLargestProject, Action: ActionDelete})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	result := Execute(context.Background(), db, plan)
+	if result.Applied != 2 {
+		t.Fatalf("Expected 2 applied, got %d (errors %v)", result.Applied, result.Errors)
+	}
+
+	if _, err := os.Stat(f.large.Filepath); err != nil {
+		t.Errorf("Expected canonical copy to remain: %v", err)
+	}
+	for _, dup := range []models.ProjectFile{f.small, f.newest} {
+		if _, err := os.Stat(dup.Filepath); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted", dup.Filepath)
+		}
+
+		var count int64
+		db.Model(&models.ProjectFile{}).Where("id = ?", dup.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected file record %d to be deleted", dup.ID)
+		}
+	}
+}
+
+func TestExecuteStopped(t *testing.T) {
+	db := setupTestDB(t)
+	f := setupLibrary(t, db)
+
+	plan, err := BuildPlan(db, Options{Policy: KeepNewest, Action: ActionDelete})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	// The library lock the plan is applied under was taken over
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(database.ErrLockLost)
+	result := Execute(ctx, db, plan)
+	if result.Applied != 0 || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], database.ErrLockLost.Error()) {
+		t.Fatalf("Expected nothing applied once the lock was lost, got %d applied, errors %v", result.Applied, result.Errors)
+	}
+	if _, err := os.Stat(f.small.Filepath); err != nil {
+		t.Errorf("Expected %s to remain: %v", f.small.Filepath, err)
+	}
+}
+
+func TestExecuteChangedContent(t *testing.T) {
+	db := setupTestDB(t)
+	f := setupLibrary(t, db)
+
+	plan, err := BuildPlan(db, Options{Policy: KeepNewest, Action: ActionLink})
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+
+	// The stored hash is stale once the file changes on disk
+	if err := os.WriteFile(f.small.Filepath, []byte("solid edited"), 0644); err != nil {
+		t.Fatalf("Failed to edit %s: %v", f.small.Filepath, err)
+	}
+
+	result := Execute(context.Background(), db, plan)
+	if result.Applied != 1 || len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 applied and 1 error, got %d applied, errors %v", result.Applied, result.Errors)
+	}
+	if sameFile(f.newest.Filepath, f.small.Filepath) {
+		t.Error("Expected the changed file not to be replaced with a link")
+	}
+	if content, _ := os.ReadFile(f.small.Filepath); string(content) != "solid edited" {
+		t.Errorf("Expected the changed content kept, got %q", content)
+	}
+
+	var updated models.ProjectFile
+	db.First(&updated, f.small.ID)
+	if updated.DuplicateOf != nil {
+		t.Errorf("Expected file %d not to reference the canonical copy", f.small.ID)
+	}
+}
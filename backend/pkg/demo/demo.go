@@ -0,0 +1,232 @@
+package demo
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/scanner"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SettingKey is the settings key the manifest of seeded data is persisted under
+	SettingKey = "demo"
+
+	// dirPrefix starts the directory name of every sample project, keeping them
+	// apart from the library's own projects
+	dirPrefix = "Demo_"
+)
+
+var (
+	// ErrSeeded is returned when seeding a library that already holds the sample data
+	ErrSeeded = errors.New("demo data is already seeded")
+
+	// ErrNotSeeded is returned when clearing a library without sample data
+	ErrNotSeeded = errors.New("demo data is not seeded")
+
+	// ErrPathExists is returned when a sample project's directory is already taken
+	ErrPathExists = errors.New("a demo project directory already exists")
+)
+
+// Manifest records what Seed created, so Clear removes it without touching
+// the library's own projects
+type Manifest struct {
+	ProjectIDs  []uint    `json:"project_ids"`
+	Paths       []string  `json:"paths"`
+	FilamentIDs []uint    `json:"filament_ids"`
+	PrintJobIDs []uint    `json:"print_job_ids"`
+	SeededAt    time.Time `json:"seeded_at"`
+}
+
+// Seeder fills a library with sample projects, spools and prints for
+// screenshots, frontend development and trying the app out
+type Seeder struct {
+	db       *gorm.DB
+	scanner  *scanner.Scanner
+	scanPath string
+}
+
+// New creates a Seeder writing sample projects under scanPath and recording
+// them through scanner
+func New(db *gorm.DB, scanner *scanner.Scanner, scanPath string) *Seeder {
+	return &Seeder{db: db, scanner: scanner, scanPath: scanPath}
+}
+
+// Load returns the manifest of the seeded data, or nil when the library holds none
+func (s *Seeder) Load() (*Manifest, error) {
+	var manifest Manifest
+	found, err := database.LoadSetting(s.db, SettingKey, &manifest)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// Seed writes the sample projects to disk, scans them in and records spools
+// and print history for them. A failure removes whatever was created.
+func (s *Seeder) Seed() (*Manifest, error) {
+	if existing, err := s.Load(); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrSeeded
+	}
+
+	// Refuse before writing anything rather than mixing with the library's own files
+	for _, sample := range sampleProjects {
+		if _, err := os.Lstat(s.projectPath(sample)); err == nil {
+			return nil, fmt.Errorf("%w: %s", ErrPathExists, s.projectPath(sample))
+		}
+	}
+
+	manifest := &Manifest{SeededAt: time.Now()}
+	if err := s.seed(manifest); err != nil {
+		if cleanupErr := s.remove(manifest); cleanupErr != nil {
+			fmt.Printf("Warning: Failed to remove partial demo data: %v\n", cleanupErr)
+		}
+		return nil, err
+	}
+	if err := database.SaveSetting(s.db, SettingKey, manifest); err != nil {
+		s.remove(manifest)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// seed creates the sample data, recording each piece in manifest as it goes
+func (s *Seeder) seed(manifest *Manifest) error {
+	spools := make(map[string]*models.Filament, len(sampleFilaments))
+	for _, sample := range sampleFilaments {
+		filament := sample
+		if err := filament.Validate(); err != nil {
+			return err
+		}
+		if err := s.db.Create(&filament).Error; err != nil {
+			return err
+		}
+		manifest.FilamentIDs = append(manifest.FilamentIDs, filament.ID)
+		spools[filament.Material+" "+filament.Color] = &filament
+	}
+
+	for _, sample := range sampleProjects {
+		path := s.projectPath(sample)
+		manifest.Paths = append(manifest.Paths, path)
+		if err := writeProject(path, sample); err != nil {
+			return fmt.Errorf("failed to write %s: %v", sample.Name, err)
+		}
+
+		project, err := s.scanner.ImportProject(path)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %v", sample.Name, err)
+		}
+		manifest.ProjectIDs = append(manifest.ProjectIDs, project.ID)
+		if err := s.db.Model(project).Update("name", sample.Name).Error; err != nil {
+			return err
+		}
+
+		for i, sampleJob := range sample.Prints {
+			job := sampleJob.printJob(project.ID, time.Now().AddDate(0, 0, -7*(len(sample.Prints)-i)))
+			var file models.ProjectFile
+			if err := s.db.Where("project_id = ? AND filename = ?", project.ID, sampleJob.File).First(&file).Error; err == nil {
+				job.FileID = &file.ID
+			}
+			if err := job.Validate(); err != nil {
+				return err
+			}
+			if err := s.db.Create(&job).Error; err != nil {
+				return err
+			}
+			manifest.PrintJobIDs = append(manifest.PrintJobIDs, job.ID)
+
+			if spool := spools[sampleJob.Material+" "+sampleJob.Color]; spool != nil && spool.RemainingGrams >= job.FilamentGrams {
+				spool.RemainingGrams -= job.FilamentGrams
+				if err := s.db.Model(spool).Update("remaining_grams", spool.RemainingGrams).Error; err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// projectPath is where a sample project is written
+func (s *Seeder) projectPath(sample sampleProject) string {
+	return filepath.Join(s.scanPath, dirPrefix+sample.Dir)
+}
+
+// writeProject writes a sample project's files into a new directory at path
+func writeProject(path string, sample sampleProject) error {
+	if err := fileperm.MkdirAll(path); err != nil {
+		return err
+	}
+	for name, content := range sample.files() {
+		filePath := filepath.Join(path, name)
+		if err := os.WriteFile(filePath, content, 0644); err != nil {
+			return err
+		}
+		if err := fileperm.File(filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes the seeded data, returning the manifest of what was removed
+func (s *Seeder) Clear() (*Manifest, error) {
+	manifest, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, ErrNotSeeded
+	}
+	if err := s.remove(manifest); err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("key = ?", SettingKey).Delete(&models.Setting{}).Error; err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// remove deletes the directories and records in manifest, along with
+// anything the library attached to the sample projects since, such as prints
+// logged against them. Directories go first, so a scan running meanwhile drops
+// the projects rather than re-adding them.
+func (s *Seeder) remove(manifest *Manifest) error {
+	for _, path := range manifest.Paths {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(manifest.ProjectIDs) > 0 {
+			if err := database.DeleteProjectRecords(tx, manifest.ProjectIDs); err != nil {
+				return err
+			}
+			// Prints of sample projects are sample data too
+			if err := tx.Where("project_id IN ?", manifest.ProjectIDs).Delete(&models.PrintJob{}).Error; err != nil {
+				return err
+			}
+			// Hard deletes free the paths, so the library can be seeded again
+			if err := tx.Unscoped().Delete(&models.Project{}, manifest.ProjectIDs).Error; err != nil {
+				return err
+			}
+		}
+		if len(manifest.FilamentIDs) > 0 {
+			if err := tx.Where("filament_id IN ?", manifest.FilamentIDs).Delete(&models.Calibration{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Filament{}, manifest.FilamentIDs).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
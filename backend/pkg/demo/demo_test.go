@@ -0,0 +1,147 @@
+package demo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/scanner"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupSeeder creates a Seeder over an empty library and database
+func setupSeeder(t *testing.T) (*Seeder, *gorm.DB, string) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	scanPath := t.TempDir()
+	return New(db, scanner.New(db, scanPath), scanPath), db, scanPath
+}
+
+// TestSeed tests that the sample library is scanned in with its metadata and history
+func TestSeed(t *testing.T) {
+	seeder, db, scanPath := setupSeeder(t)
+
+	manifest, err := seeder.Seed()
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if len(manifest.ProjectIDs) != len(sampleProjects) || len(manifest.FilamentIDs) != len(sampleFilaments) {
+		t.Fatalf("Expected %d projects and %d spools, got %+v", len(sampleProjects), len(sampleFilaments), manifest)
+	}
+
+	var project models.Project
+	if err := db.Preload("Files").Where("path = ?", filepath.Join(scanPath, "Demo_Headphone_Stand")).First(&project).Error; err != nil {
+		t.Fatalf("Expected the headphone stand to be scanned in: %v", err)
+	}
+	if project.Name != "Headphone Stand" || project.Designer != "Jonas Weber" || !slices.Contains(project.Tags, "desk") {
+		t.Errorf("Expected the README metadata to be applied, got %+v", project)
+	}
+	types := make(map[models.FileType]int)
+	for _, file := range project.Files {
+		types[file.FileType]++
+	}
+	if types[models.FileTypeSTL] != 2 || types[models.FileTypeGCode] != 1 || types[models.FileTypeREADME] != 1 || types[models.FileTypeImage] != 1 {
+		t.Errorf("Expected two models, a G-code file, a README and a cover, got %v", types)
+	}
+
+	meta, err := gcode.ReadMetadata(filepath.Join(project.Path, "stand_0.28mm_PLA_MK3S.gcode"))
+	if err != nil || meta.Printer != "MK3S" || meta.Material != "PLA" {
+		t.Errorf("Expected G-code the slicer comment parser understands, got %+v (%v)", meta, err)
+	}
+	if sources, _ := gcode.ReadSourceModels(filepath.Join(project.Path, "stand_0.28mm_PLA_MK3S.gcode")); !slices.Equal(sources, []string{"stand.stl"}) {
+		t.Errorf("Expected the G-code to name its source model, got %v", sources)
+	}
+
+	var prints []models.PrintJob
+	db.Where("project_id = ?", project.ID).Order("started_at").Find(&prints)
+	if len(prints) != 2 || prints[0].Outcome != models.PrintFailed || prints[1].Outcome != models.PrintSucceeded || prints[1].FileID == nil {
+		t.Errorf("Expected a failed then a successful print of the G-code file, got %+v", prints)
+	}
+
+	if _, err := seeder.Seed(); !errors.Is(err, ErrSeeded) {
+		t.Errorf("Expected seeding twice to fail with ErrSeeded, got %v", err)
+	}
+}
+
+// TestSeedRefusesExistingDirectories tests that seeding never writes into the library's own directories
+func TestSeedRefusesExistingDirectories(t *testing.T) {
+	seeder, db, scanPath := setupSeeder(t)
+	os.MkdirAll(filepath.Join(scanPath, "Demo_Spiral_Planter"), 0755)
+
+	if _, err := seeder.Seed(); !errors.Is(err, ErrPathExists) {
+		t.Fatalf("Expected ErrPathExists, got %v", err)
+	}
+	var count int64
+	db.Model(&models.Project{}).Count(&count)
+	if entries, _ := os.ReadDir(scanPath); count != 0 || len(entries) != 1 {
+		t.Errorf("Expected nothing to be created, got %d projects and %d directories", count, len(entries))
+	}
+}
+
+// TestClear tests that clearing removes the sample library and leaves the rest alone
+func TestClear(t *testing.T) {
+	seeder, db, scanPath := setupSeeder(t)
+	if _, err := seeder.Clear(); !errors.Is(err, ErrNotSeeded) {
+		t.Errorf("Expected ErrNotSeeded before seeding, got %v", err)
+	}
+
+	ownPath := filepath.Join(scanPath, "Benchy")
+	os.MkdirAll(ownPath, 0755)
+	os.WriteFile(filepath.Join(ownPath, "benchy.stl"), []byte("solid benchy"), 0644)
+	own, err := scanner.New(db, scanPath).ImportProject(ownPath)
+	if err != nil {
+		t.Fatalf("Failed to import the library's own project: %v", err)
+	}
+	ownSpool := models.Filament{Name: "My spool"}
+	db.Create(&ownSpool)
+
+	manifest, err := seeder.Seed()
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	// Prints, publications and upload links of sample projects go with them
+	db.Create(&models.PrintJob{ProjectID: manifest.ProjectIDs[0], Outcome: models.PrintSucceeded})
+	db.Create(&models.Distribution{ProjectID: manifest.ProjectIDs[0], Kind: models.DistributionIPFS, ContentID: "bafy"})
+	db.Create(&models.UploadToken{Token: "demo-token", ProjectID: manifest.ProjectIDs[0], ExpiresAt: time.Now().Add(time.Hour)})
+
+	if _, err := seeder.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	for _, path := range manifest.Paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	var projects, prints, distributions, tokens, spools int64
+	db.Unscoped().Model(&models.Project{}).Count(&projects)
+	db.Model(&models.PrintJob{}).Count(&prints)
+	db.Model(&models.Distribution{}).Count(&distributions)
+	db.Model(&models.UploadToken{}).Count(&tokens)
+	db.Model(&models.Filament{}).Count(&spools)
+	if projects != 1 || prints != 0 || distributions != 0 || tokens != 0 || spools != 1 {
+		t.Errorf("Expected only the library's own project and spool to remain, got %d projects, %d prints, %d distributions, %d upload tokens, %d spools", projects, prints, distributions, tokens, spools)
+	}
+	if err := db.First(&models.Project{}, own.ID).Error; err != nil {
+		t.Errorf("Expected the library's own project to remain: %v", err)
+	}
+	if loaded, _ := seeder.Load(); loaded != nil {
+		t.Errorf("Expected the manifest to be removed, got %+v", loaded)
+	}
+
+	if _, err := seeder.Seed(); err != nil {
+		t.Errorf("Expected the library to be seeded again after clearing, got %v", err)
+	}
+}
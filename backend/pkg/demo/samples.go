@@ -0,0 +1,264 @@
+package demo
+
+import (
+	"3dshelf/internal/models"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"time"
+)
+
+// sampleModel is a box-shaped STL standing in for a real model
+type sampleModel struct {
+	File    string
+	X, Y, Z float64
+}
+
+// sampleGCode is G-code sliced from one of the project's models
+type sampleGCode struct {
+	File        string
+	Model       string
+	Printer     string
+	Material    string
+	LayerHeight float64
+	Minutes     int
+}
+
+// samplePrint is an entry in a sample project's print history
+type samplePrint struct {
+	File     string
+	Printer  string
+	Material string
+	Color    string
+	Grams    float64
+	Minutes  int
+	Outcome  models.PrintOutcome
+	Reasons  []models.FailureReason
+	Notes    string
+}
+
+// sampleProject describes a sample project and its files
+type sampleProject struct {
+	Name        string
+	Dir         string
+	Description string
+	Tags        []string
+	License     string
+	Designer    string
+	Source      string
+
+	Models []sampleModel
+	GCode  []sampleGCode
+	// CAD files are written with a placeholder STEP body
+	CAD []string
+	// Cover is the colour of the project's cover.png
+	Cover color.RGBA
+
+	Prints []samplePrint
+}
+
+// sampleFilaments are the spools on the sample shelf
+var sampleFilaments = []models.Filament{
+	{Brand: "Prusament", Material: "PLA", Color: "Galaxy Black", NozzleTempC: 215, BedTempC: 60, WeightGrams: 1000, RemainingGrams: 820},
+	{Brand: "Prusament", Material: "PETG", Color: "Orange", NozzleTempC: 240, BedTempC: 85, WeightGrams: 1000, RemainingGrams: 1000},
+	{Brand: "eSun", Material: "PLA", Color: "White", NozzleTempC: 210, BedTempC: 60, WeightGrams: 1000, RemainingGrams: 430},
+}
+
+// sampleProjects is the demo library
+var sampleProjects = []sampleProject{
+	{
+		Name:        "Calibration Cube",
+		Dir:         "Calibration_Cube",
+		Description: "A 20mm cube for checking dimensional accuracy and extruder steps. Measure each axis with calipers after printing.",
+		Tags:        []string{"calibration", "beginner"},
+		License:     "CC0-1.0",
+		Designer:    "3dShelf",
+		Source:      "https://example.com/models/calibration-cube",
+		Models:      []sampleModel{{File: "cube.stl", X: 20, Y: 20, Z: 20}},
+		GCode:       []sampleGCode{{File: "cube_0.2mm_PLA_MK4.gcode", Model: "cube.stl", Printer: "MK4", Material: "PLA", LayerHeight: 0.2, Minutes: 22}},
+		Cover:       color.RGBA{R: 0x2b, G: 0x2b, B: 0x2b, A: 0xff},
+		Prints: []samplePrint{
+			{File: "cube_0.2mm_PLA_MK4.gcode", Printer: "MK4", Material: "PLA", Color: "Galaxy Black", Grams: 2, Minutes: 4, Outcome: models.PrintFailed, Reasons: []models.FailureReason{models.FailureAdhesion}, Notes: "First layer lifted in one corner, cleaned the sheet"},
+			{File: "cube_0.2mm_PLA_MK4.gcode", Printer: "MK4", Material: "PLA", Color: "Galaxy Black", Grams: 7, Minutes: 22, Outcome: models.PrintSucceeded, Notes: "20.02 x 19.98 x 20.01mm"},
+		},
+	},
+	{
+		Name:        "Cable Clips",
+		Dir:         "Cable_Clips",
+		Description: "Snap-on clips for routing cables along a desk edge, in two sizes for 16mm and 25mm desk tops.",
+		Tags:        []string{"organization", "desk"},
+		License:     "CC-BY-4.0",
+		Designer:    "Marta Ferrer",
+		Source:      "https://example.com/models/cable-clips",
+		Models:      []sampleModel{{File: "clip_16mm.stl", X: 18, Y: 30, Z: 12}, {File: "clip_25mm.stl", X: 27, Y: 30, Z: 12}},
+		GCode:       []sampleGCode{{File: "clip_16mm_0.2mm_PETG_MK4.gcode", Model: "clip_16mm.stl", Printer: "MK4", Material: "PETG", LayerHeight: 0.2, Minutes: 38}},
+		Cover:       color.RGBA{R: 0xf2, G: 0x7a, B: 0x1a, A: 0xff},
+		Prints: []samplePrint{
+			{File: "clip_16mm_0.2mm_PETG_MK4.gcode", Printer: "MK4", Material: "PETG", Color: "Orange", Grams: 14, Minutes: 38, Outcome: models.PrintSucceeded, Notes: "Plate of eight"},
+			{File: "clip_16mm_0.2mm_PETG_MK4.gcode", Printer: "MK4", Material: "PETG", Color: "Orange", Grams: 14, Minutes: 38, Outcome: models.PrintSucceeded},
+		},
+	},
+	{
+		Name:        "Headphone Stand",
+		Dir:         "Headphone_Stand",
+		Description: "A weighted headphone stand with a hollow base that takes coins or sand for stability.",
+		Tags:        []string{"desk", "decor"},
+		License:     "CC-BY-NC-4.0",
+		Designer:    "Jonas Weber",
+		Source:      "https://example.com/models/headphone-stand",
+		Models:      []sampleModel{{File: "stand.stl", X: 90, Y: 60, Z: 250}, {File: "base.stl", X: 120, Y: 120, Z: 20}},
+		GCode:       []sampleGCode{{File: "stand_0.28mm_PLA_MK3S.gcode", Model: "stand.stl", Printer: "MK3S", Material: "PLA", LayerHeight: 0.28, Minutes: 410}},
+		Cover:       color.RGBA{R: 0xf5, G: 0xf5, B: 0xf0, A: 0xff},
+		Prints: []samplePrint{
+			{File: "stand_0.28mm_PLA_MK3S.gcode", Printer: "MK3S", Material: "PLA", Color: "White", Grams: 95, Minutes: 180, Outcome: models.PrintFailed, Reasons: []models.FailureReason{models.FailureWarping, models.FailureLayerShift}, Notes: "Draft from the window, enclosure next time"},
+			{File: "stand_0.28mm_PLA_MK3S.gcode", Printer: "MK3S", Material: "PLA", Color: "White", Grams: 186, Minutes: 410, Outcome: models.PrintSucceeded},
+		},
+	},
+	{
+		Name:        "Spiral Planter",
+		Dir:         "Spiral_Planter",
+		Description: "A twisted planter designed for vase mode, with a separate drip tray.",
+		Tags:        []string{"garden", "vase-mode"},
+		License:     "CC-BY-SA-4.0",
+		Designer:    "Ana Ruiz",
+		Source:      "https://example.com/models/spiral-planter",
+		Models:      []sampleModel{{File: "planter.stl", X: 110, Y: 110, Z: 120}, {File: "tray.stl", X: 120, Y: 120, Z: 10}},
+		GCode:       []sampleGCode{{File: "planter_vase_0.3mm_PLA_MK4.gcode", Model: "planter.stl", Printer: "MK4", Material: "PLA", LayerHeight: 0.3, Minutes: 95}},
+		Cover:       color.RGBA{R: 0x3f, G: 0x8f, B: 0x4f, A: 0xff},
+		Prints: []samplePrint{
+			{File: "planter_vase_0.3mm_PLA_MK4.gcode", Printer: "MK4", Material: "PLA", Color: "White", Grams: 12, Minutes: 15, Outcome: models.PrintCancelled, Notes: "Forgot to switch the spool"},
+		},
+	},
+	{
+		Name:        "Planetary Gear Set",
+		Dir:         "Planetary_Gear_Set",
+		Description: "A print-in-place planetary gearbox with a 5:1 ratio. The STEP file is included for remixing.",
+		Tags:        []string{"mechanical", "educational"},
+		License:     "MIT",
+		Designer:    "3dShelf",
+		Source:      "https://example.com/models/planetary-gear-set",
+		Models:      []sampleModel{{File: "gearbox.stl", X: 60, Y: 60, Z: 15}},
+		CAD:         []string{"gearbox.step"},
+		Cover:       color.RGBA{R: 0x4a, G: 0x6f, B: 0xa5, A: 0xff},
+	},
+}
+
+// printJob builds the print history entry, started at startedAt
+func (p samplePrint) printJob(projectID uint, startedAt time.Time) models.PrintJob {
+	finishedAt := startedAt.Add(time.Duration(p.Minutes) * time.Minute)
+	return models.PrintJob{
+		ProjectID:       projectID,
+		Printer:         p.Printer,
+		Material:        p.Material,
+		FilamentGrams:   p.Grams,
+		DurationSeconds: int64(p.Minutes) * 60,
+		Outcome:         p.Outcome,
+		FailureReasons:  p.Reasons,
+		Notes:           p.Notes,
+		StartedAt:       startedAt,
+		FinishedAt:      &finishedAt,
+	}
+}
+
+// files returns the project's files by name
+func (p sampleProject) files() map[string][]byte {
+	files := map[string][]byte{"README.md": p.readme()}
+	for _, model := range p.Models {
+		files[model.File] = boxSTL(strings.TrimSuffix(model.File, ".stl"), model.X, model.Y, model.Z)
+	}
+	for _, gcode := range p.GCode {
+		files[gcode.File] = gcode.content()
+	}
+	for _, cad := range p.CAD {
+		files[cad] = []byte(fmt.Sprintf("ISO-10303-21;\nHEADER;\nFILE_DESCRIPTION(('%s'),'2;1');\nFILE_NAME('%s','',(''),(''),'3dShelf demo','','');\nENDSEC;\nDATA;\nENDSEC;\nEND-ISO-10303-21;\n", p.Name, cad))
+	}
+	files["cover.png"] = coverPNG(p.Cover)
+	return files
+}
+
+// readme renders the README with the project's metadata as front matter
+func (p sampleProject) readme() []byte {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(p.Tags, ", "))
+	fmt.Fprintf(&b, "license: %s\n", p.License)
+	fmt.Fprintf(&b, "designer: %s\n", p.Designer)
+	fmt.Fprintf(&b, "source: %s\n", p.Source)
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n%s\n", p.Name, p.Description)
+	return []byte(b.String())
+}
+
+// content is a short G-code file carrying the comments slicers write
+func (g sampleGCode) content() []byte {
+	var b strings.Builder
+	b.WriteString("; generated by PrusaSlicer 2.7.1\n")
+	fmt.Fprintf(&b, "; printer_model = %s\n", g.Printer)
+	b.WriteString("; nozzle_diameter = 0.4\n")
+	fmt.Fprintf(&b, "; filament_type = %s\n", g.Material)
+	fmt.Fprintf(&b, "; layer_height = %.2f\n", g.LayerHeight)
+	fmt.Fprintf(&b, "; estimated printing time (normal mode) = %dh %dm 0s\n", g.Minutes/60, g.Minutes%60)
+	b.WriteString("G28 ; home all axes\nG1 Z0.2 F3000\n")
+	fmt.Fprintf(&b, "; printing object %s id:0 copy 0\n", g.Model)
+	b.WriteString("G1 X10 Y10 E0.5 F1500\nG1 X30 Y10 E1.2\nG1 X30 Y30 E1.9\nG1 X10 Y30 E2.6\n")
+	fmt.Fprintf(&b, "; stop printing object %s id:0 copy 0\n", g.Model)
+	b.WriteString("M104 S0\nM140 S0\nM84\n")
+	return []byte(b.String())
+}
+
+// boxSTL is an ASCII STL of an x by y by z millimetre box
+func boxSTL(name string, x, y, z float64) []byte {
+	corners := [8][3]float64{
+		{0, 0, 0}, {x, 0, 0}, {x, y, 0}, {0, y, 0},
+		{0, 0, z}, {x, 0, z}, {x, y, z}, {0, y, z},
+	}
+	// Two outward-facing triangles per side
+	faces := []struct {
+		normal [3]float64
+		a, b   [3]int
+	}{
+		{[3]float64{0, 0, -1}, [3]int{0, 2, 1}, [3]int{0, 3, 2}},
+		{[3]float64{0, 0, 1}, [3]int{4, 5, 6}, [3]int{4, 6, 7}},
+		{[3]float64{0, -1, 0}, [3]int{0, 1, 5}, [3]int{0, 5, 4}},
+		{[3]float64{1, 0, 0}, [3]int{1, 2, 6}, [3]int{1, 6, 5}},
+		{[3]float64{0, 1, 0}, [3]int{2, 3, 7}, [3]int{2, 7, 6}},
+		{[3]float64{-1, 0, 0}, [3]int{3, 0, 4}, [3]int{3, 4, 7}},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "solid %s\n", name)
+	for _, face := range faces {
+		for _, triangle := range [][3]int{face.a, face.b} {
+			fmt.Fprintf(&b, "  facet normal %g %g %g\n    outer loop\n", face.normal[0], face.normal[1], face.normal[2])
+			for _, corner := range triangle {
+				v := corners[corner]
+				fmt.Fprintf(&b, "      vertex %g %g %g\n", v[0], v[1], v[2])
+			}
+			b.WriteString("    endloop\n  endfacet\n")
+		}
+	}
+	fmt.Fprintf(&b, "endsolid %s\n", name)
+	return []byte(b.String())
+}
+
+// coverPNG is a 320x240 cover picture in the given colour with a lighter band
+func coverPNG(fill color.RGBA) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	band := color.RGBA{R: fill.R/2 + 0x80, G: fill.G/2 + 0x80, B: fill.B/2 + 0x80, A: 0xff}
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			if y > 150 && y < 180 {
+				img.SetRGBA(x, y, band)
+			} else {
+				img.SetRGBA(x, y, fill)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
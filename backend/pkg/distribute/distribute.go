@@ -0,0 +1,187 @@
+package distribute
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/ipfs"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/torrent"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// JobType identifies publishing runs in the job queue
+const JobType = "distribute"
+
+var (
+	// ErrNotPublic is returned when publishing a project that isn't marked public
+	ErrNotPublic = errors.New("only public projects can be distributed")
+
+	// ErrUnknownKind is returned for a distribution kind other than ipfs or torrent
+	ErrUnknownKind = errors.New("unknown distribution kind")
+
+	// ErrIPFSNotConfigured is returned when publishing to IPFS without a node
+	ErrIPFSNotConfigured = errors.New("IPFS is not configured")
+
+	// ErrNoFiles is returned when publishing a project without files
+	ErrNoFiles = errors.New("project has no files")
+)
+
+// Publisher publishes public projects to IPFS or as torrents in the
+// background, so creators can share large model packs without hosting them
+type Publisher struct {
+	db       *gorm.DB
+	queue    *jobs.Queue
+	ipfs     *ipfs.Client
+	trackers []string
+}
+
+// jobPayload is the queued job's reference to the project and how it is published
+type jobPayload struct {
+	ProjectID uint                    `json:"project_id"`
+	Kind      models.DistributionKind `json:"kind"`
+}
+
+// New creates a Publisher adding projects to the IPFS node of node, or only
+// creating torrents when it is nil, and registers its runs on queue. Torrents
+// are announced to trackers. Adding to a node that is down is retried.
+func New(db *gorm.DB, queue *jobs.Queue, node *ipfs.Client, trackers []string) *Publisher {
+	p := &Publisher{
+		db:       db,
+		queue:    queue,
+		ipfs:     node,
+		trackers: trackers,
+	}
+	queue.Register(JobType, jobs.DefaultRetryPolicy, p.runJob)
+	return p
+}
+
+// Start queues publishing a public project. A second request for the same
+// project and kind while one is unfinished returns that job.
+func (p *Publisher) Start(projectID uint, kind models.DistributionKind) (*models.Job, error) {
+	if err := p.check(kind); err != nil {
+		return nil, err
+	}
+	var project models.Project
+	if err := p.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+	if !project.Public {
+		return nil, ErrNotPublic
+	}
+
+	key := strconv.FormatUint(uint64(projectID), 10) + ":" + string(kind)
+	return p.queue.EnqueueOnce(JobType, key, jobPayload{ProjectID: projectID, Kind: kind})
+}
+
+// runJob publishes the project a queued job refers to
+func (p *Publisher) runJob(ctx context.Context, queued *models.Job) error {
+	var payload jobPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return err
+	}
+	_, err := p.Publish(ctx, payload.ProjectID, payload.Kind)
+	return err
+}
+
+// Publish publishes a public project's files under a directory named after
+// its slug and records the content ID. Publishing unchanged files again
+// returns the existing record.
+func (p *Publisher) Publish(ctx context.Context, projectID uint, kind models.DistributionKind) (*models.Distribution, error) {
+	if err := p.check(kind); err != nil {
+		return nil, err
+	}
+	var project models.Project
+	if err := p.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+	if !project.Public {
+		return nil, ErrNotPublic
+	}
+	files, size, err := p.projectFiles(project)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := models.Distribution{ProjectID: project.ID, Kind: kind, FileCount: len(files), Size: size}
+	switch kind {
+	case models.DistributionIPFS:
+		added := make([]ipfs.File, len(files))
+		for i, f := range files {
+			added[i] = ipfs.File(f)
+		}
+		cid, err := p.ipfs.AddDirectory(ctx, project.Slug, added)
+		if err != nil {
+			return nil, err
+		}
+		distribution.ContentID, distribution.URI = cid, "ipfs://"+cid
+	case models.DistributionTorrent:
+		t, err := torrent.Create(ctx, project.Slug, files, p.trackers)
+		if err != nil {
+			return nil, err
+		}
+		distribution.ContentID, distribution.URI, distribution.Torrent = t.InfoHash, t.Magnet(), t.Data
+	}
+
+	var existing models.Distribution
+	err = p.db.Where("project_id = ? AND kind = ? AND content_id = ?", project.ID, kind, distribution.ContentID).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := p.db.Create(&distribution).Error; err != nil {
+		return nil, err
+	}
+	return &distribution, nil
+}
+
+// check rejects kinds that can't be published
+func (p *Publisher) check(kind models.DistributionKind) error {
+	if !slices.Contains(models.DistributionKinds, kind) {
+		return ErrUnknownKind
+	}
+	if kind == models.DistributionIPFS && p.ipfs == nil {
+		return ErrIPFSNotConfigured
+	}
+	return nil
+}
+
+// projectFiles lists a project's files by their path in the project
+// directory, or by name for files outside it such as a flat project's
+func (p *Publisher) projectFiles(project models.Project) ([]torrent.File, int64, error) {
+	var records []models.ProjectFile
+	if err := p.db.Where("project_id = ?", project.ID).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(records) == 0 {
+		return nil, 0, ErrNoFiles
+	}
+
+	files := make([]torrent.File, 0, len(records))
+	seen := map[string]bool{}
+	var size int64
+	for _, record := range records {
+		rel, err := filepath.Rel(project.Path, record.Filepath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			rel = filepath.Base(record.Filename)
+		}
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			return nil, 0, fmt.Errorf("two files are published as %s", rel)
+		}
+		seen[rel] = true
+		files = append(files, torrent.File{Path: rel, Source: record.Filepath})
+		size += record.Size
+	}
+	// Sorted, so unchanged files always give the same content ID
+	slices.SortFunc(files, func(a, b torrent.File) int { return strings.Compare(a.Path, b.Path) })
+	return files, size, nil
+}
@@ -0,0 +1,111 @@
+package distribute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/ipfs"
+	"3dshelf/pkg/jobs"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a migrated in-memory database
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// setupProject creates a public project with a model in a subdirectory
+func setupProject(t *testing.T, db *gorm.DB) models.Project {
+	dir := t.TempDir()
+	project := models.Project{Name: "Benchy", Path: dir, Public: true}
+	db.Create(&project)
+	os.MkdirAll(filepath.Join(dir, "parts"), 0755)
+	for name, content := range map[string]string{"README.md": "# Benchy", "parts/hull.stl": "solid hull"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: filepath.Base(name), Filepath: path, Size: int64(len(content))})
+	}
+	return project
+}
+
+// TestPublishTorrent tests packing a project into a torrent and recording it once
+func TestPublishTorrent(t *testing.T) {
+	db := setupTestDB(t)
+	project := setupProject(t, db)
+	publisher := New(db, jobs.New(db), nil, []string{"https://tracker.example.com/announce"})
+
+	distribution, err := publisher.Publish(context.Background(), project.ID, models.DistributionTorrent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if distribution.FileCount != 2 || distribution.Size != 18 || len(distribution.ContentID) != 40 || len(distribution.Torrent) == 0 {
+		t.Errorf("Unexpected distribution %+v", distribution)
+	}
+	if !strings.HasPrefix(distribution.URI, "magnet:?xt=urn:btih:"+distribution.ContentID+"&dn="+project.Slug) {
+		t.Errorf("Expected a magnet link, got %s", distribution.URI)
+	}
+	if !strings.Contains(string(distribution.Torrent), "4:pathl5:parts8:hull.stle") {
+		t.Error("Expected the model under its directory in the project")
+	}
+
+	again, err := publisher.Publish(context.Background(), project.ID, models.DistributionTorrent)
+	if err != nil || again.ID != distribution.ID {
+		t.Errorf("Expected unchanged files to return the same distribution, got %+v %v", again, err)
+	}
+
+	if _, err := publisher.Publish(context.Background(), project.ID, models.DistributionIPFS); !errors.Is(err, ErrIPFSNotConfigured) {
+		t.Errorf("Expected ErrIPFSNotConfigured, got %v", err)
+	}
+	if _, err := publisher.Start(project.ID, "zip"); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("Expected ErrUnknownKind, got %v", err)
+	}
+	db.Model(&project).Update("public", false)
+	if _, err := publisher.Start(project.ID, models.DistributionTorrent); !errors.Is(err, ErrNotPublic) {
+		t.Errorf("Expected ErrNotPublic, got %v", err)
+	}
+}
+
+// TestPublishIPFS tests adding a project to the IPFS node
+func TestPublishIPFS(t *testing.T) {
+	db := setupTestDB(t)
+	project := setupProject(t, db)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		fmt.Fprintf(w, `{"Name": %q, "Hash": "bafyroot"}`+"\n", project.Slug)
+	}))
+	defer server.Close()
+	publisher := New(db, jobs.New(db), ipfs.New(server.URL), nil)
+
+	distribution, err := publisher.Publish(context.Background(), project.ID, models.DistributionIPFS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if distribution.ContentID != "bafyroot" || distribution.URI != "ipfs://bafyroot" || distribution.Torrent != nil {
+		t.Errorf("Unexpected distribution %+v", distribution)
+	}
+
+	job, err := publisher.Start(project.ID, models.DistributionIPFS)
+	if err != nil || job.Type != JobType {
+		t.Errorf("Expected a queued %s job, got %+v %v", JobType, job, err)
+	}
+}
@@ -0,0 +1,181 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the request version written by this build; extractors
+// should reject versions they do not understand
+const ProtocolVersion = 1
+
+const (
+	// DefaultTimeout bounds how long one extractor run may take
+	DefaultTimeout = 30 * time.Second
+
+	// maxOutputSize bounds how much an extractor may write to stdout or stderr
+	maxOutputSize = 1 << 20 // 1MB
+)
+
+// Request is written to an extractor's stdin as a single JSON document
+type Request struct {
+	Version   int    `json:"version"`
+	Path      string `json:"path"`
+	Filename  string `json:"filename"`
+	Extension string `json:"extension"`
+}
+
+// Response is the single JSON document an extractor writes to stdout. An
+// extractor that cannot read a file sets Error rather than exiting non-zero.
+type Response struct {
+	Metadata map[string]any `json:"metadata"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Extractor is an external command that reads metadata from one file extension
+type Extractor struct {
+	Extension string
+	Command   string
+	Args      []string
+}
+
+// Registry runs external extractors keyed by file extension
+type Registry struct {
+	extractors map[string]Extractor
+	timeout    time.Duration
+}
+
+// New creates an empty Registry whose extractors are stopped after timeout
+func New(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{
+		extractors: make(map[string]Extractor),
+		timeout:    timeout,
+	}
+}
+
+// Register runs command with args for files with the given extension,
+// replacing any extractor already registered for it
+func (r *Registry) Register(extension, command string, args ...string) {
+	extension = normalizeExtension(extension)
+	r.extractors[extension] = Extractor{Extension: extension, Command: command, Args: args}
+}
+
+// RegisterList registers extractors from a list of "extension=command args..."
+// items, such as ".step=/usr/local/bin/step-meta --json"
+func (r *Registry) RegisterList(items []string) error {
+	for _, item := range items {
+		extension, command, ok := strings.Cut(item, "=")
+		fields := strings.Fields(command)
+		if !ok || strings.TrimSpace(extension) == "" || len(fields) == 0 {
+			return fmt.Errorf("invalid extractor %q: expected extension=command", item)
+		}
+		r.Register(extension, fields[0], fields[1:]...)
+	}
+	return nil
+}
+
+// Extensions returns the extensions extractors are registered for
+func (r *Registry) Extensions() []string {
+	extensions := make([]string, 0, len(r.extractors))
+	for extension := range r.extractors {
+		extensions = append(extensions, extension)
+	}
+	return extensions
+}
+
+// For returns the extractor registered for a file's extension
+func (r *Registry) For(filename string) (Extractor, bool) {
+	if r == nil {
+		return Extractor{}, false
+	}
+	extractor, ok := r.extractors[normalizeExtension(filepath.Ext(filename))]
+	return extractor, ok
+}
+
+// Extract runs the extractor registered for filename against the file at path.
+// It returns nil metadata without error when no extractor handles the file.
+func (r *Registry) Extract(ctx context.Context, path, filename string) (map[string]any, error) {
+	extractor, ok := r.For(filename)
+	if !ok {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return extractor.Run(ctx, Request{
+		Version:   ProtocolVersion,
+		Path:      path,
+		Filename:  filename,
+		Extension: extractor.Extension,
+	})
+}
+
+// Run writes req to the extractor's stdin and reads its response from stdout
+func (e Extractor) Run(ctx context.Context, req Request) (map[string]any, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on children that outlive a killed extractor and hold its output open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("extractor %s: %w", e.Command, ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("extractor %s: %v: %s", e.Command, err, message)
+		}
+		return nil, fmt.Errorf("extractor %s: %w", e.Command, err)
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("extractor %s: output exceeds %d bytes", e.Command, maxOutputSize)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("extractor %s: invalid response: %v", e.Command, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("extractor %s: %s", e.Command, resp.Error)
+	}
+	return resp.Metadata, nil
+}
+
+func normalizeExtension(extension string) string {
+	extension = strings.ToLower(strings.TrimSpace(extension))
+	if extension != "" && !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	return extension
+}
+
+// limitedBuffer keeps the first maxOutputSize bytes written to it and
+// discards the rest, so a runaway extractor cannot exhaust memory
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutputSize - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
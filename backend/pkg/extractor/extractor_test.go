@@ -0,0 +1,94 @@
+package extractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript creates an executable shell script acting as an extractor
+func writeScript(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "extract.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write extractor script: %v", err)
+	}
+	return path
+}
+
+// TestExtract tests the request written to an extractor and the metadata read back
+func TestExtract(t *testing.T) {
+	registry := New(5 * time.Second)
+	registry.Register("STEP", writeScript(t, `read req; printf '{"metadata": {"bodies": 2, "request": %s}}' "$req"`))
+
+	metadata, err := registry.Extract(context.Background(), "/data/bracket/bracket.STEP", "bracket.STEP")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if metadata["bodies"] != float64(2) {
+		t.Errorf("Expected bodies 2, got %v", metadata["bodies"])
+	}
+	req, _ := metadata["request"].(map[string]any)
+	if req["version"] != float64(ProtocolVersion) || req["path"] != "/data/bracket/bracket.STEP" ||
+		req["filename"] != "bracket.STEP" || req["extension"] != ".step" {
+		t.Errorf("Unexpected request: %v", req)
+	}
+
+	metadata, err = registry.Extract(context.Background(), "/data/bracket/bracket.stl", "bracket.stl")
+	if metadata != nil || err != nil {
+		t.Errorf("Expected no metadata for unregistered extensions, got %v, %v", metadata, err)
+	}
+}
+
+// TestExtractFailures tests extractor failures are reported as errors
+func TestExtractFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"error response", `echo '{"error": "unsupported STEP schema"}'`, "unsupported STEP schema"},
+		{"non-zero exit", `echo "no license" >&2; exit 3`, "no license"},
+		{"invalid response", `echo "not json"`, "invalid response"},
+		{"timeout", `exec sleep 5`, "deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := New(200 * time.Millisecond)
+			registry.Register(".blend", writeScript(t, tt.script))
+
+			_, err := registry.Extract(context.Background(), "/data/scene.blend", "scene.blend")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestRegisterList tests extractors are registered from configuration items
+func TestRegisterList(t *testing.T) {
+	registry := New(0)
+	if err := registry.RegisterList([]string{".step=/usr/local/bin/step-meta --json", "blend=blender-meta"}); err != nil {
+		t.Fatalf("RegisterList failed: %v", err)
+	}
+
+	step, ok := registry.For("part.step")
+	if !ok || step.Command != "/usr/local/bin/step-meta" || len(step.Args) != 1 || step.Args[0] != "--json" {
+		t.Errorf("Unexpected STEP extractor: %+v", step)
+	}
+	if _, ok := registry.For("scene.blend"); !ok {
+		t.Error("Expected an extractor for .blend files")
+	}
+	if len(registry.Extensions()) != 2 {
+		t.Errorf("Expected 2 extensions, got %v", registry.Extensions())
+	}
+
+	for _, item := range []string{"step-meta", ".step=", "=step-meta"} {
+		if err := New(0).RegisterList([]string{item}); err == nil {
+			t.Errorf("Expected %q to be rejected", item)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTextLength bounds the text kept from one file, in bytes
+const MaxTextLength = 64 << 10 // 64KB
+
+// inputPlaceholder is replaced by the file's path in text extractor arguments
+const inputPlaceholder = "{input}"
+
+// TextRegistry runs commands that print the text of a file, such as
+// pdftotext for PDF model cards or tesseract for photographed ones, keyed by
+// file extension. Unlike metadata extractors they need no protocol: whatever
+// the command writes to stdout is the file's text.
+type TextRegistry struct {
+	extractors map[string]Extractor
+	timeout    time.Duration
+}
+
+// NewText creates an empty TextRegistry whose commands are stopped after timeout
+func NewText(timeout time.Duration) *TextRegistry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &TextRegistry{
+		extractors: make(map[string]Extractor),
+		timeout:    timeout,
+	}
+}
+
+// Register runs command with args for files with the given extension. An
+// {input} argument is replaced by the file's path; without one the path is
+// passed last.
+func (r *TextRegistry) Register(extension, command string, args ...string) {
+	extension = normalizeExtension(extension)
+	r.extractors[extension] = Extractor{Extension: extension, Command: command, Args: args}
+}
+
+// RegisterList registers commands from a list of "extension=command args..."
+// items, such as ".pdf=pdftotext -layout {input} -"
+func (r *TextRegistry) RegisterList(items []string) error {
+	for _, item := range items {
+		extension, command, ok := strings.Cut(item, "=")
+		fields := strings.Fields(command)
+		if !ok || strings.TrimSpace(extension) == "" || len(fields) == 0 {
+			return fmt.Errorf("invalid text extractor %q: expected extension=command", item)
+		}
+		r.Register(extension, fields[0], fields[1:]...)
+	}
+	return nil
+}
+
+// Extract runs the command registered for filename against the file at path
+// and returns its text with whitespace collapsed, cut at MaxTextLength. It
+// returns an empty string without error when no command handles the file.
+func (r *TextRegistry) Extract(ctx context.Context, path, filename string) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	extractor, ok := r.extractors[normalizeExtension(filepath.Ext(filename))]
+	if !ok {
+		return "", nil
+	}
+
+	args := make([]string, 0, len(extractor.Args)+1)
+	substituted := false
+	for _, arg := range extractor.Args {
+		if strings.Contains(arg, inputPlaceholder) {
+			arg = strings.ReplaceAll(arg, inputPlaceholder, path)
+			substituted = true
+		}
+		args = append(args, arg)
+	}
+	if !substituted {
+		args = append(args, path)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, extractor.Command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("text extractor %s: %w", extractor.Command, ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("text extractor %s: %v: %s", extractor.Command, err, message)
+		}
+		return "", fmt.Errorf("text extractor %s: %w", extractor.Command, err)
+	}
+	// A long document is cut rather than rejected; its start is what gets indexed
+	return normalizeText(stdout.String()), nil
+}
+
+// normalizeText collapses runs of whitespace, which OCR and PDF layouts are
+// full of, and cuts the text at MaxTextLength on a character boundary
+func normalizeText(text string) string {
+	text = strings.Join(strings.Fields(strings.ToValidUTF8(text, "")), " ")
+	if len(text) <= MaxTextLength {
+		return text
+	}
+	cut := MaxTextLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
@@ -0,0 +1,58 @@
+package extractor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExtractText tests the file path is passed to text extractors and their output normalized
+func TestExtractText(t *testing.T) {
+	registry := NewText(5 * time.Second)
+	if err := registry.RegisterList([]string{
+		".pdf=" + writeScript(t, `printf 'input: %s\n\n  Layer   height:\t0.16mm\n' "$2"`) + " --layout {input} -",
+		"png=" + writeScript(t, `echo "ocr of $1"`),
+	}); err != nil {
+		t.Fatalf("RegisterList failed: %v", err)
+	}
+
+	text, err := registry.Extract(context.Background(), "/data/card/card.PDF", "card.PDF")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if text != "input: /data/card/card.PDF Layer height: 0.16mm" {
+		t.Errorf("Unexpected PDF text %q", text)
+	}
+
+	// Without a placeholder the path is passed last
+	if text, _ := registry.Extract(context.Background(), "/data/card/card.png", "card.png"); text != "ocr of /data/card/card.png" {
+		t.Errorf("Unexpected image text %q", text)
+	}
+	if text, err := registry.Extract(context.Background(), "/data/card/model.stl", "model.stl"); text != "" || err != nil {
+		t.Errorf("Expected no text for unregistered extensions, got %q, %v", text, err)
+	}
+
+	if err := registry.RegisterList([]string{"pdftotext"}); err == nil {
+		t.Error("Expected an error for an item without an extension")
+	}
+}
+
+// TestExtractTextFailures tests failing commands are reported and long text cut
+func TestExtractTextFailures(t *testing.T) {
+	registry := NewText(200 * time.Millisecond)
+	registry.Register(".pdf", writeScript(t, `echo "Syntax Error: Couldn't find trailer dictionary" >&2; exit 1`))
+	registry.Register(".jpg", writeScript(t, `exec sleep 5`))
+
+	if _, err := registry.Extract(context.Background(), "/data/card.pdf", "card.pdf"); err == nil || !strings.Contains(err.Error(), "trailer dictionary") {
+		t.Errorf("Expected the command's error output, got %v", err)
+	}
+	if _, err := registry.Extract(context.Background(), "/data/card.jpg", "card.jpg"); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+
+	long := normalizeText(strings.Repeat("é", MaxTextLength))
+	if len(long) > MaxTextLength || !strings.HasSuffix(long, "é") {
+		t.Errorf("Expected text cut at %d bytes on a character boundary, got %d bytes", MaxTextLength, len(long))
+	}
+}
@@ -0,0 +1,236 @@
+// Package farm plans batches of prints across several printers: which parts
+// go on which printer's plates, and how long the batch takes.
+package farm
+
+import "sort"
+
+// DefaultSpacing is the gap kept between parts on a plate, in millimetres
+const DefaultSpacing = 5.0
+
+// Part is one requested model, printed Quantity times
+type Part struct {
+	ID uint
+
+	// Size is the model's bounding box along X, Y and Z in millimetres
+	Size [3]float64
+
+	// PrintTimeSeconds is how long one copy takes to print; 0 when unknown
+	PrintTimeSeconds int64
+
+	Quantity int
+}
+
+// Printer is a printer parts can be planned on
+type Printer struct {
+	ID   uint
+	Name string
+
+	// Build is the build volume along X, Y and Z in millimetres
+	Build [3]float64
+}
+
+// PlateItem is how many copies of a part a plate holds
+type PlateItem struct {
+	PartID uint `json:"part_id"`
+	Copies int  `json:"copies"`
+}
+
+// Plate is one print job on a printer
+type Plate struct {
+	Items            []PlateItem `json:"items"`
+	PrintTimeSeconds int64       `json:"print_time_seconds"`
+}
+
+// PrinterPlan is the plates a printer prints one after another
+type PrinterPlan struct {
+	PrinterID        uint    `json:"printer_id"`
+	Name             string  `json:"name"`
+	Plates           []Plate `json:"plates"`
+	PrintTimeSeconds int64   `json:"print_time_seconds"`
+}
+
+// Unplaced is a part no printer can print
+type Unplaced struct {
+	PartID   uint   `json:"part_id"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// Plan is a suggested batch: the plates of each printer and the makespan,
+// the time until the last printer finishes
+type Plan struct {
+	Printers        []PrinterPlan `json:"printers"`
+	MakespanSeconds int64         `json:"makespan_seconds"`
+	Unplaced        []Unplaced    `json:"unplaced"`
+}
+
+// partCopy is one copy of a part waiting to be placed
+type partCopy struct {
+	part *Part
+}
+
+// openPlate is a plate being filled, with the footprints packed on it
+type openPlate struct {
+	copies []partCopy
+}
+
+// printerState tracks the plates planned on a printer so far
+type printerState struct {
+	printer Printer
+	plates  []*openPlate
+	load    int64
+}
+
+// Schedule suggests a batch plan for the parts on the printers. Copies are
+// placed longest first, each on the printer that would finish it soonest:
+// onto that printer's last plate when it still has room, otherwise onto a
+// new plate. Copies on one plate are taken to print one after another, so a
+// plate takes the sum of their print times, while printers run in parallel.
+// Plates are packed in rows of bounding box footprints, turned a quarter
+// turn when that fits better, spacing apart; this is a coarse estimate of
+// what a slicer's arrangement achieves.
+func Schedule(parts []Part, printers []Printer, spacing float64) Plan {
+	plan := Plan{Printers: []PrinterPlan{}, Unplaced: []Unplaced{}}
+	states := make([]*printerState, len(printers))
+	for i, printer := range printers {
+		states[i] = &printerState{printer: printer}
+	}
+
+	var copies []partCopy
+	for i := range parts {
+		part := &parts[i]
+		fits := false
+		for _, printer := range printers {
+			fits = fits || fitsVolume(part.Size, printer.Build)
+		}
+		if !fits {
+			plan.Unplaced = append(plan.Unplaced, Unplaced{PartID: part.ID, Quantity: part.Quantity, Reason: "too large for every printer"})
+			continue
+		}
+		for range part.Quantity {
+			copies = append(copies, partCopy{part: part})
+		}
+	}
+	// Longest first balances printers best; larger footprints break ties so
+	// they are packed before the small parts that fill gaps
+	sort.SliceStable(copies, func(i, j int) bool {
+		a, b := copies[i].part, copies[j].part
+		if a.PrintTimeSeconds != b.PrintTimeSeconds {
+			return a.PrintTimeSeconds > b.PrintTimeSeconds
+		}
+		return a.Size[0]*a.Size[1] > b.Size[0]*b.Size[1]
+	})
+
+	for _, c := range copies {
+		var best *printerState
+		var bestPlate *openPlate
+		bestEnd := int64(-1)
+		for _, state := range states {
+			if !fitsVolume(c.part.Size, state.printer.Build) {
+				continue
+			}
+			plate, end := state.place(c, spacing)
+			// Ties go to the printer with fewer plates, then to the earlier one
+			if best == nil || end < bestEnd || (end == bestEnd && len(state.plates) < len(best.plates)) {
+				best, bestPlate, bestEnd = state, plate, end
+			}
+		}
+		if bestPlate == nil {
+			bestPlate = &openPlate{}
+			best.plates = append(best.plates, bestPlate)
+		}
+		bestPlate.copies = append(bestPlate.copies, c)
+		best.load += c.part.PrintTimeSeconds
+	}
+
+	for _, state := range states {
+		printerPlan := PrinterPlan{PrinterID: state.printer.ID, Name: state.printer.Name, Plates: []Plate{}, PrintTimeSeconds: state.load}
+		for _, plate := range state.plates {
+			printerPlan.Plates = append(printerPlan.Plates, plate.summary())
+		}
+		plan.Printers = append(plan.Printers, printerPlan)
+		plan.MakespanSeconds = max(plan.MakespanSeconds, state.load)
+	}
+	return plan
+}
+
+// place returns the plate a copy would go on, nil for a new one, and when
+// the printer would finish the copy there
+func (s *printerState) place(c partCopy, spacing float64) (*openPlate, int64) {
+	end := s.load + c.part.PrintTimeSeconds
+	if len(s.plates) == 0 {
+		return nil, end
+	}
+	last := s.plates[len(s.plates)-1]
+	footprints := make([][2]float64, 0, len(last.copies)+1)
+	for _, placed := range last.copies {
+		footprints = append(footprints, [2]float64{placed.part.Size[0], placed.part.Size[1]})
+	}
+	footprints = append(footprints, [2]float64{c.part.Size[0], c.part.Size[1]})
+	if packs(footprints, s.printer.Build, spacing) {
+		return last, end
+	}
+	return nil, end
+}
+
+// summary counts the copies of each part on a plate, in the order placed
+func (p *openPlate) summary() Plate {
+	plate := Plate{Items: []PlateItem{}}
+	index := make(map[uint]int)
+	for _, c := range p.copies {
+		plate.PrintTimeSeconds += c.part.PrintTimeSeconds
+		if i, ok := index[c.part.ID]; ok {
+			plate.Items[i].Copies++
+			continue
+		}
+		index[c.part.ID] = len(plate.Items)
+		plate.Items = append(plate.Items, PlateItem{PartID: c.part.ID, Copies: 1})
+	}
+	return plate
+}
+
+// fitsVolume reports whether a bounding box fits a build volume as it is or
+// turned a quarter turn on the bed
+func fitsVolume(size, build [3]float64) bool {
+	if size[2] > build[2] {
+		return false
+	}
+	return (size[0] <= build[0] && size[1] <= build[1]) || (size[1] <= build[0] && size[0] <= build[1])
+}
+
+// packs reports whether footprints fit a bed packed in rows, deepest first,
+// each turned to lie along the row when it fits the bed that way
+func packs(footprints [][2]float64, build [3]float64, spacing float64) bool {
+	width, depth := build[0]+spacing, build[1]+spacing
+
+	oriented := make([][2]float64, len(footprints))
+	for i, f := range footprints {
+		w, d := f[0]+spacing, f[1]+spacing
+		// Lying long side along X keeps rows shallow
+		if d > w && d <= width {
+			w, d = d, w
+		}
+		if w > width {
+			w, d = d, w
+		}
+		oriented[i] = [2]float64{w, d}
+	}
+	sort.SliceStable(oriented, func(i, j int) bool { return oriented[i][1] > oriented[j][1] })
+
+	var x, y, rowDepth float64
+	for _, f := range oriented {
+		if f[0] > width {
+			return false
+		}
+		if x+f[0] > width {
+			y += rowDepth
+			x, rowDepth = 0, 0
+		}
+		x += f[0]
+		rowDepth = max(rowDepth, f[1])
+		if y+rowDepth > depth {
+			return false
+		}
+	}
+	return true
+}
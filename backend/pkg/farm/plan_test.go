@@ -0,0 +1,65 @@
+package farm
+
+import "testing"
+
+// TestSchedule tests parts are spread over printers and packed onto plates
+func TestSchedule(t *testing.T) {
+	printers := []Printer{
+		{ID: 1, Name: "Mini", Build: [3]float64{180, 180, 180}},
+		{ID: 2, Name: "MK4", Build: [3]float64{250, 210, 220}},
+	}
+	parts := []Part{
+		// Only the MK4 takes the panel, turned a quarter turn
+		{ID: 10, Size: [3]float64{200, 240, 5}, PrintTimeSeconds: 3 * 3600, Quantity: 1},
+		// Four brackets fit a plate of either printer
+		{ID: 11, Size: [3]float64{60, 60, 20}, PrintTimeSeconds: 3600, Quantity: 4},
+		{ID: 12, Size: [3]float64{300, 300, 300}, PrintTimeSeconds: 3600, Quantity: 2},
+	}
+
+	plan := Schedule(parts, printers, DefaultSpacing)
+	if len(plan.Unplaced) != 1 || plan.Unplaced[0].PartID != 12 || plan.Unplaced[0].Quantity != 2 {
+		t.Errorf("Expected the oversized part unplaced, got %+v", plan.Unplaced)
+	}
+
+	mini, mk4 := plan.Printers[0], plan.Printers[1]
+	if len(mk4.Plates) != 1 || mk4.Plates[0].Items[0].PartID != 10 {
+		t.Errorf("Expected the panel alone on the MK4, got %+v", mk4.Plates)
+	}
+	// The brackets go to the Mini while the MK4 prints the panel; the last
+	// finishes as early on either and goes to the first
+	if len(mini.Plates) != 1 || mini.Plates[0].Items[0].Copies != 4 || mini.PrintTimeSeconds != 4*3600 {
+		t.Errorf("Expected four brackets on one Mini plate, got %+v", mini)
+	}
+	if mk4.PrintTimeSeconds != 3*3600 || plan.MakespanSeconds != 4*3600 {
+		t.Errorf("Expected a 4 hour makespan, got %d and %d", mk4.PrintTimeSeconds, plan.MakespanSeconds)
+	}
+}
+
+// TestPacks tests footprints are packed in rows with spacing
+func TestPacks(t *testing.T) {
+	bed := [3]float64{180, 180, 180}
+	testCases := []struct {
+		name       string
+		footprints [][2]float64
+		expected   bool
+	}{
+		{"nine in a grid", repeat([2]float64{55, 55}, 9), true},
+		{"ten in a grid", repeat([2]float64{55, 55}, 10), false},
+		{"turned to fit", [][2]float64{{100, 180}, {70, 180}}, true},
+		{"wider than the bed", [][2]float64{{200, 50}}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := packs(tc.footprints, bed, DefaultSpacing); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func repeat(footprint [2]float64, count int) [][2]float64 {
+	footprints := make([][2]float64, count)
+	for i := range footprints {
+		footprints[i] = footprint
+	}
+	return footprints
+}
@@ -0,0 +1,117 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SettingKey is the settings key flag overrides are persisted under
+const SettingKey = "features"
+
+// Flag names a subsystem that can be switched on or off per instance
+type Flag string
+
+const (
+	// FullTextSearch enables type-ahead suggestions from the full-text index
+	FullTextSearch Flag = "fts"
+	// Watcher enables watching the scan path for changes instead of relying on manual scans
+	Watcher Flag = "watcher"
+	// Integrations enables third-party services such as OctoPrint and remote collection imports
+	Integrations Flag = "integrations"
+	// Storefront serves the public catalogue of projects marked public, without authentication
+	Storefront Flag = "storefront"
+)
+
+// Definition describes a flag and its state when not configured
+type Definition struct {
+	Name        Flag   `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Definitions lists every known flag
+var Definitions = []Definition{
+	{Name: FullTextSearch, Description: "Type-ahead search suggestions from the full-text index", Default: true},
+	{Name: Watcher, Description: "Watch the scan path for changes", Default: false},
+	{Name: Integrations, Description: "OctoPrint and remote collection imports", Default: true},
+	{Name: Storefront, Description: "Public catalogue of the projects marked public", Default: false},
+}
+
+// Flags is the set of flags enabled on this instance; it is safe for concurrent use
+type Flags struct {
+	mu      sync.RWMutex
+	enabled map[Flag]bool
+}
+
+// New returns flags in their default states
+func New() *Flags {
+	enabled := make(map[Flag]bool, len(Definitions))
+	for _, def := range Definitions {
+		enabled[def.Name] = def.Default
+	}
+	return &Flags{enabled: enabled}
+}
+
+// Enabled reports whether flag is switched on
+func (f *Flags) Enabled(flag Flag) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.enabled[flag]
+}
+
+// All returns a copy of every flag's state
+func (f *Flags) All() map[Flag]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	all := make(map[Flag]bool, len(f.enabled))
+	for flag, enabled := range f.enabled {
+		all[flag] = enabled
+	}
+	return all
+}
+
+// Set switches the given flags, leaving the others unchanged
+func (f *Flags) Set(values map[Flag]bool) error {
+	if err := Validate(values); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for flag, enabled := range values {
+		f.enabled[flag] = enabled
+	}
+	return nil
+}
+
+// Validate checks every flag in values is known
+func Validate(values map[Flag]bool) error {
+	for flag := range values {
+		if !known(flag) {
+			return fmt.Errorf("unknown feature flag: %s", flag)
+		}
+	}
+	return nil
+}
+
+// ParseList reads flags from a list where "name" enables a flag and "-name"
+// disables it; Set and Validate reject unknown names
+func ParseList(items []string) map[Flag]bool {
+	values := make(map[Flag]bool, len(items))
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		values[Flag(strings.TrimPrefix(item, "-"))] = !strings.HasPrefix(item, "-")
+	}
+	return values
+}
+
+func known(flag Flag) bool {
+	for _, def := range Definitions {
+		if def.Name == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package features
+
+import "testing"
+
+func TestFlags(t *testing.T) {
+	flags := New()
+	if !flags.Enabled(FullTextSearch) || flags.Enabled(Watcher) {
+		t.Errorf("Expected default states, got %v", flags.All())
+	}
+
+	if err := flags.Set(ParseList([]string{" Watcher ", "-fts"})); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !flags.Enabled(Watcher) || flags.Enabled(FullTextSearch) || !flags.Enabled(Integrations) {
+		t.Errorf("Expected only the listed flags to change, got %v", flags.All())
+	}
+
+	all := flags.All()
+	all[Watcher] = false
+	if !flags.Enabled(Watcher) {
+		t.Error("Expected All to return a copy")
+	}
+
+	if err := flags.Set(map[Flag]bool{"teleporter": true}); err == nil {
+		t.Error("Expected error for an unknown flag")
+	}
+	if _, ok := flags.All()["teleporter"]; ok {
+		t.Error("Expected unknown flags not to be stored")
+	}
+}
@@ -0,0 +1,172 @@
+package fileperm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KeepOwner leaves files owned by the user or group the server runs as
+const KeepOwner = -1
+
+// Policy is how directories and files the server creates in the library are
+// owned and permissioned, so they stay usable by the people and other
+// services sharing it, such as a NAS user outside the container
+type Policy struct {
+	// UID and GID own created files; KeepOwner leaves them to the server's user or group
+	UID int
+	GID int
+
+	DirMode  os.FileMode
+	FileMode os.FileMode
+}
+
+// Default keeps the server's own user and group with conventional modes
+func Default() Policy {
+	return Policy{UID: KeepOwner, GID: KeepOwner, DirMode: 0755, FileMode: 0644}
+}
+
+// Validate checks the modes leave the server able to use what it creates
+func (p Policy) Validate() error {
+	if p.DirMode&^os.ModePerm != 0 || p.DirMode&0700 != 0700 {
+		return fmt.Errorf("directory mode %#o is not valid (must be a permission mode the owner can read, write and enter)", p.DirMode)
+	}
+	if p.FileMode&^os.ModePerm != 0 || p.FileMode&0600 != 0600 {
+		return fmt.Errorf("file mode %#o is not valid (must be a permission mode the owner can read and write)", p.FileMode)
+	}
+	if p.UID < KeepOwner || p.GID < KeepOwner {
+		return fmt.Errorf("uid %d or gid %d is not valid", p.UID, p.GID)
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current = Default()
+)
+
+// Set makes policy apply to everything the server creates from now on
+func Set(policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = policy
+	return nil
+}
+
+// Get returns the policy in effect
+func Get() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// MkdirAll creates path and any missing parents, applying the policy to each
+// directory it creates; existing directories are left alone
+func MkdirAll(path string) error {
+	policy := Get()
+
+	// Find the directories that don't exist yet, deepest first
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, dir)
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(path, policy.DirMode); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := policy.apply(missing[i], policy.DirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dir applies the policy to a directory the server created
+func Dir(path string) error {
+	policy := Get()
+	return policy.apply(path, policy.DirMode)
+}
+
+// File applies the policy to a file the server wrote
+func File(path string) error {
+	policy := Get()
+	return policy.apply(path, policy.FileMode)
+}
+
+// Tree applies the policy to a directory the server created and everything in it
+func Tree(root string) error {
+	policy := Get()
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Links are left alone: chmod would follow them out of the tree
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if d.IsDir() {
+			return policy.apply(path, policy.DirMode)
+		}
+		return policy.apply(path, policy.FileMode)
+	})
+}
+
+// apply sets the mode, which the process umask may have narrowed, and the owner
+func (p Policy) apply(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+	if p.UID == KeepOwner && p.GID == KeepOwner {
+		return nil
+	}
+	if err := os.Lchown(path, p.UID, p.GID); err != nil {
+		return fmt.Errorf("failed to change owner of %s to %d:%d: %v", path, p.UID, p.GID, err)
+	}
+	return nil
+}
+
+// Preflight checks that the server can create directories and files under
+// root and hand them to the configured owner, so a misconfigured volume fails
+// at startup instead of on the first upload
+func Preflight(root string) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("scan path %s is not accessible: %v", root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("scan path %s is not a directory", root)
+	}
+
+	dir, err := os.MkdirTemp(root, ".permission-check-")
+	if err != nil {
+		return fmt.Errorf("scan path %s is not writable by uid %d: %v", root, os.Geteuid(), err)
+	}
+	defer os.RemoveAll(dir)
+	if err := Dir(dir); err != nil {
+		return fmt.Errorf("scan path %s: %v", root, err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		return fmt.Errorf("scan path %s is not writable by uid %d: %v", root, os.Geteuid(), err)
+	}
+	if err := File(file); err != nil {
+		return fmt.Errorf("scan path %s: %v", root, err)
+	}
+	return nil
+}
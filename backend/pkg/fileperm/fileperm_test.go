@@ -0,0 +1,121 @@
+package fileperm
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// usePolicy applies policy for the rest of the test
+func usePolicy(t *testing.T, policy Policy) {
+	if err := Set(policy); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	t.Cleanup(func() { Set(Default()) })
+}
+
+// assertMode fails unless path has mode
+func assertMode(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("Expected %s to have mode %#o, got %#o", path, mode, info.Mode().Perm())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{name: "Default", policy: Default()},
+		{name: "Group writable", policy: Policy{UID: 1000, GID: 1000, DirMode: 0775, FileMode: 0664}},
+		{name: "Directory the owner can't enter", policy: Policy{UID: KeepOwner, GID: KeepOwner, DirMode: 0644, FileMode: 0644}, wantErr: true},
+		{name: "File the owner can't write", policy: Policy{UID: KeepOwner, GID: KeepOwner, DirMode: 0755, FileMode: 0444}, wantErr: true},
+		{name: "Setuid bit", policy: Policy{UID: KeepOwner, GID: KeepOwner, DirMode: 0755, FileMode: 0644 | os.ModeSetuid}, wantErr: true},
+		{name: "Negative uid", policy: Policy{UID: -2, GID: KeepOwner, DirMode: 0755, FileMode: 0644}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestMkdirAll tests that created directories get the policy's mode despite the umask
+func TestMkdirAll(t *testing.T) {
+	usePolicy(t, Policy{UID: KeepOwner, GID: KeepOwner, DirMode: 0770, FileMode: 0660})
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	root := t.TempDir()
+	os.Chmod(root, 0700)
+	if err := MkdirAll(filepath.Join(root, "collection", "project")); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	assertMode(t, filepath.Join(root, "collection"), 0770)
+	assertMode(t, filepath.Join(root, "collection", "project"), 0770)
+	assertMode(t, root, 0700)
+
+	file := filepath.Join(root, "collection", "project", "model.stl")
+	os.WriteFile(file, []byte("solid"), 0600)
+	if err := File(file); err != nil {
+		t.Fatalf("File failed: %v", err)
+	}
+	assertMode(t, file, 0660)
+}
+
+// TestTree tests applying the policy to a downloaded directory
+func TestTree(t *testing.T) {
+	// The server's own uid and gid can always be applied
+	usePolicy(t, Policy{UID: os.Getuid(), GID: os.Getgid(), DirMode: 0750, FileMode: 0640})
+
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "images"), 0700)
+	os.WriteFile(filepath.Join(root, "images", "photo.jpg"), nil, 0600)
+	os.WriteFile(filepath.Join(root, "model.stl"), nil, 0600)
+
+	if err := Tree(root); err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	assertMode(t, root, 0750)
+	assertMode(t, filepath.Join(root, "images"), 0750)
+	assertMode(t, filepath.Join(root, "images", "photo.jpg"), 0640)
+	assertMode(t, filepath.Join(root, "model.stl"), 0640)
+}
+
+// TestPreflight tests checking a scan root before serving
+func TestPreflight(t *testing.T) {
+	root := t.TempDir()
+	if err := Preflight(root); err != nil {
+		t.Errorf("Expected a writable directory to pass, got %v", err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("Expected the check to clean up, got %v", entries)
+	}
+
+	file := filepath.Join(root, "models.txt")
+	os.WriteFile(file, nil, 0644)
+	if err := Preflight(file); err == nil {
+		t.Error("Expected error for a file")
+	}
+	if err := Preflight(filepath.Join(root, "missing")); err == nil {
+		t.Error("Expected error for a missing directory")
+	}
+
+	if os.Geteuid() != 0 {
+		// Only root may give files away
+		usePolicy(t, Policy{UID: os.Getuid() + 1, GID: KeepOwner, DirMode: 0755, FileMode: 0644})
+		if err := Preflight(root); err == nil {
+			t.Error("Expected error when files can't be given to the configured owner")
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package frontmatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const delimiter = "---"
+
+// Metadata is the structured project metadata kept in README front matter
+type Metadata struct {
+	Tags     []string `json:"tags"`
+	License  string   `json:"license"`
+	Designer string   `json:"designer"`
+	Source   string   `json:"source"`
+
+	// Extra preserves unrecognized keys so rewriting a README doesn't drop them
+	Extra yaml.MapSlice `json:"-"`
+}
+
+// IsZero reports whether the metadata carries no values
+func (m Metadata) IsZero() bool {
+	return len(m.Tags) == 0 && m.License == "" && m.Designer == "" && m.Source == "" && len(m.Extra) == 0
+}
+
+// Split separates a leading front matter block from the markdown body.
+// found is false when the content has no front matter.
+func Split(content []byte) (header []byte, body []byte, found bool) {
+	normalized := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte(delimiter+"\n")) {
+		return nil, content, false
+	}
+
+	rest := normalized[len(delimiter)+1:]
+	for offset := 0; offset <= len(rest); {
+		end := bytes.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+
+		if trimmed := string(bytes.TrimRight(line, " \t")); trimmed == delimiter || trimmed == "..." {
+			header = rest[:offset]
+			if end < 0 {
+				return header, nil, true
+			}
+			return header, bytes.TrimLeft(rest[offset+end+1:], "\n"), true
+		}
+
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+
+	// An opening delimiter without a closing one is just markdown
+	return nil, content, false
+}
+
+// Parse extracts metadata from README content and returns the remaining body
+func Parse(content []byte) (Metadata, []byte, error) {
+	header, body, found := Split(content)
+	if !found {
+		return Metadata{}, body, nil
+	}
+
+	var fields yaml.MapSlice
+	if err := yaml.Unmarshal(header, &fields); err != nil {
+		return Metadata{}, content, fmt.Errorf("invalid front matter: %v", err)
+	}
+
+	var meta Metadata
+	for _, item := range fields {
+		key := fmt.Sprint(item.Key)
+		switch key {
+		case "tags":
+			meta.Tags = parseTags(item.Value)
+		case "license":
+			meta.License = scalar(item.Value)
+		case "designer":
+			meta.Designer = scalar(item.Value)
+		case "source":
+			meta.Source = scalar(item.Value)
+		default:
+			meta.Extra = append(meta.Extra, item)
+		}
+	}
+
+	return meta, body, nil
+}
+
+// Render writes metadata as front matter followed by the markdown body.
+// Empty metadata yields the body unchanged.
+func Render(meta Metadata, body []byte) ([]byte, error) {
+	if meta.IsZero() {
+		return body, nil
+	}
+
+	var fields yaml.MapSlice
+	if len(meta.Tags) > 0 {
+		fields = append(fields, yaml.MapItem{Key: "tags", Value: meta.Tags})
+	}
+	if meta.License != "" {
+		fields = append(fields, yaml.MapItem{Key: "license", Value: meta.License})
+	}
+	if meta.Designer != "" {
+		fields = append(fields, yaml.MapItem{Key: "designer", Value: meta.Designer})
+	}
+	if meta.Source != "" {
+		fields = append(fields, yaml.MapItem{Key: "source", Value: meta.Source})
+	}
+	fields = append(fields, meta.Extra...)
+
+	header, err := yaml.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(delimiter + "\n")
+	if len(body) > 0 {
+		buf.WriteString("\n")
+		buf.Write(body)
+	}
+	return buf.Bytes(), nil
+}
+
+// Template returns a starter README with empty front matter fields for a project
+func Template(projectName string) string {
+	return fmt.Sprintf(`---
+tags: []
+license: ""
+designer: ""
+source: ""
+---
+
+# %s
+
+Describe the project, print settings and assembly notes here.
+`, projectName)
+}
+
+// parseTags accepts either a YAML list or a comma-separated string
+func parseTags(value interface{}) []string {
+	var raw []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			raw = append(raw, scalar(item))
+		}
+	case string:
+		raw = strings.Split(v, ",")
+	case nil:
+		return nil
+	default:
+		raw = []string{scalar(v)}
+	}
+
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// scalar formats a YAML scalar as a string
+func scalar(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
@@ -0,0 +1,113 @@
+package frontmatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected Metadata
+		body     string
+	}{
+		{
+			name:    "No front matter",
+			content: "# Project\nJust markdown.",
+			body:    "# Project\nJust markdown.",
+		},
+		{
+			name:     "List tags",
+			content:  "---\ntags: [benchy, calibration]\nlicense: CC-BY-4.0\ndesigner: Jane\nsource: https://example.com/benchy\n---\n\n# Benchy\n",
+			expected: Metadata{Tags: []string{"benchy", "calibration"}, License: "CC-BY-4.0", Designer: "Jane", Source: "https://example.com/benchy"},
+			body:     "# Benchy\n",
+		},
+		{
+			name:     "Comma separated tags",
+			content:  "---\ntags: gears, , mechanical \n---\nBody",
+			expected: Metadata{Tags: []string{"gears", "mechanical"}},
+			body:     "Body",
+		},
+		{
+			name:     "Windows line endings",
+			content:  "---\r\nlicense: MIT\r\n---\r\nBody\r\n",
+			expected: Metadata{License: "MIT"},
+			body:     "Body\n",
+		},
+		{
+			name:    "Unclosed front matter",
+			content: "---\nnot: closed\n",
+			body:    "---\nnot: closed\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, body, err := Parse([]byte(tc.content))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(meta, tc.expected) {
+				t.Errorf("Expected metadata %#v, got %#v", tc.expected, meta)
+			}
+			if string(body) != tc.body {
+				t.Errorf("Expected body %q, got %q", tc.body, body)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, _, err := Parse([]byte("---\ntags: [unterminated\n---\n")); err == nil {
+		t.Error("Expected invalid YAML to fail")
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	content := "---\ntags: [a, b]\nlicense: MIT\nprinter: mk4\n---\n\n# Title\n"
+
+	meta, body, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	rendered, err := Render(meta, body)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	again, againBody, err := Parse(rendered)
+	if err != nil {
+		t.Fatalf("Parse of rendered content failed: %v", err)
+	}
+	if !reflect.DeepEqual(meta, again) || string(body) != string(againBody) {
+		t.Errorf("Round trip changed content:\n%s", rendered)
+	}
+	if !strings.Contains(string(rendered), "printer: mk4") {
+		t.Error("Expected unknown keys to be preserved")
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	rendered, err := Render(Metadata{}, []byte("# Body"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if string(rendered) != "# Body" {
+		t.Errorf("Expected body unchanged, got %q", rendered)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	meta, body, err := Parse([]byte(Template("Benchy")))
+	if err != nil {
+		t.Fatalf("Template does not parse: %v", err)
+	}
+	if !meta.IsZero() {
+		t.Errorf("Expected empty template metadata, got %#v", meta)
+	}
+	if !strings.HasPrefix(string(body), "# Benchy") {
+		t.Errorf("Expected template body to start with the project name, got %q", body)
+	}
+}
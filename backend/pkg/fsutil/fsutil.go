@@ -0,0 +1,65 @@
+// Package fsutil holds small filesystem helpers shared by the packages that
+// bring files into the library
+package fsutil
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LinkOrCopy hard links src at dest, copying it when they are on different filesystems
+func LinkOrCopy(src, dest string) error {
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := out.ReadFrom(in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// AvailablePath returns a path in dir for name that does not exist yet,
+// numbering the name as stem_1.ext, stem_2.ext... when it is taken. Dangling
+// symlinks count as taken, so nothing is ever written through one.
+func AvailablePath(dir, name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	path := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s_%d%s", stem, i, ext))
+	}
+}
+
+// HashFile returns the size and SHA-256 of a file, in hex as scans record it
+func HashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
@@ -0,0 +1,58 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLinkOrCopy tests the file is linked with its content
+func TestLinkOrCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "part.stl")
+	os.WriteFile(src, []byte("solid part"), 0644)
+
+	dest := filepath.Join(dir, "copy.stl")
+	if err := LinkOrCopy(src, dest); err != nil {
+		t.Fatalf("LinkOrCopy failed: %v", err)
+	}
+	if content, err := os.ReadFile(dest); err != nil || string(content) != "solid part" {
+		t.Errorf("Expected the content at dest, got %q %v", content, err)
+	}
+
+	if err := LinkOrCopy(filepath.Join(dir, "missing.stl"), filepath.Join(dir, "other.stl")); err == nil {
+		t.Error("Expected an error for a missing source")
+	}
+}
+
+// TestAvailablePath tests taken names are numbered, dangling symlinks included
+func TestAvailablePath(t *testing.T) {
+	dir := t.TempDir()
+	if got := AvailablePath(dir, "photo.jpg"); got != filepath.Join(dir, "photo.jpg") {
+		t.Errorf("Expected the name kept while it is free, got %s", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "photo.jpg"), []byte("JPEG"), 0644)
+	os.Symlink(filepath.Join(dir, "missing.jpg"), filepath.Join(dir, "photo_1.jpg"))
+	if got := AvailablePath(dir, "photo.jpg"); got != filepath.Join(dir, "photo_2.jpg") {
+		t.Errorf("Expected photo_2.jpg past the file and the dangling symlink, got %s", got)
+	}
+}
+
+// TestHashFile tests the size and hex SHA-256 of a file are returned
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "part.stl")
+	os.WriteFile(path, []byte("solid part"), 0644)
+
+	size, hash, err := HashFile(path)
+	want := fmt.Sprintf("%x", sha256.Sum256([]byte("solid part")))
+	if err != nil || size != 10 || hash != want {
+		t.Errorf("Expected 10 bytes hashing to %s, got %d %s %v", want, size, hash, err)
+	}
+
+	if _, _, err := HashFile(filepath.Join(t.TempDir(), "missing.stl")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,159 @@
+package gcode
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Filament is the filament one extruder, tool or AMS slot of a
+// multi-material print is loaded with
+type Filament struct {
+	// Tool is the extruder or filament index the file switches to with T<tool>,
+	// counted from 0; Bambu printers map it to an AMS slot when printing
+	Tool int `json:"tool"`
+
+	Material string `json:"material,omitempty"`
+	Color    string `json:"color,omitempty"`
+
+	// Name is the slicer's filament profile, like "Bambu PLA Basic"
+	Name string `json:"name,omitempty"`
+
+	// Grams and MM are the slicer's estimates of what the tool uses; a
+	// configured tool the print never switches to uses none
+	Grams float64 `json:"grams,omitempty"`
+	MM    float64 `json:"mm,omitempty"`
+}
+
+// Used reports whether the print needs the filament, as far as the slicer's
+// estimates tell
+func (f Filament) Used() bool {
+	return f.Grams > 0 || f.MM > 0
+}
+
+// filamentKeys maps slicer comment keys (lowercased) listing a value per
+// extruder to filament fields
+var filamentKeys = map[string]string{
+	"filament_type":        "material",
+	"filament_colour":      "color",
+	"filament_color":       "color",
+	"extruder_colour":      "extruder_color",
+	"filament_settings_id": "name",
+	"filament used [g]":    "grams",
+	"filament used [mm]":   "mm",
+	"filament used":        "meters",
+}
+
+// ReadFilaments lists the filament of each extruder a G-code file was sliced
+// for, from the per-extruder lists in slicer comments:
+//
+//	; filament_type = PLA;PETG                   (PrusaSlicer, OrcaSlicer, Bambu Studio)
+//	; filament_colour = #FFFFFF;#FF0000
+//	; filament used [g] = 10.5, 2.1
+//	;EXTRUDER_TRAIN.1.MATERIAL.TYPE:PLA          (Cura)
+//
+// A single-material print lists one filament; a file without such comments lists none.
+func ReadFilaments(path string) ([]Filament, error) {
+	lists := make(map[string][]string)
+	// The tail is read first so header values win, as for the metadata
+	err := readWindows(path, func(r io.Reader) { parseFilamentComments(r, lists) })
+	if err != nil {
+		return nil, err
+	}
+	return buildFilaments(lists), nil
+}
+
+// parseFilamentComments collects the per-extruder lists in r by field
+func parseFilamentComments(r io.Reader, lists map[string][]string) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ";") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, ";"))
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:sep]))
+		value := strings.TrimSpace(line[sep+1:])
+		if value == "" {
+			continue
+		}
+
+		// Cura writes one line per extruder train
+		if rest, ok := strings.CutPrefix(key, "extruder_train."); ok {
+			index, field, _ := strings.Cut(rest, ".")
+			tool, err := strconv.Atoi(index)
+			if err != nil || tool < 0 || tool > 63 || field != "material.type" {
+				continue
+			}
+			materials := lists["material"]
+			for len(materials) <= tool {
+				materials = append(materials, "")
+			}
+			materials[tool] = value
+			lists["material"] = materials
+			continue
+		}
+
+		field, known := filamentKeys[key]
+		if !known {
+			continue
+		}
+		separators := ";"
+		if field == "grams" || field == "mm" || field == "meters" {
+			separators = ",;"
+		}
+		var values []string
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+			values = append(values, strings.Trim(strings.TrimSpace(part), `"`))
+		}
+		lists[field] = values
+	}
+}
+
+// buildFilaments turns the per-extruder lists into one filament per extruder
+func buildFilaments(lists map[string][]string) []Filament {
+	count := 0
+	for _, values := range lists {
+		count = max(count, len(values))
+	}
+
+	filaments := make([]Filament, 0, count)
+	for i := range count {
+		value := func(field string) string {
+			if values := lists[field]; i < len(values) {
+				return values[i]
+			}
+			return ""
+		}
+		number := func(field string) float64 {
+			parsed, _ := strconv.ParseFloat(strings.TrimSuffix(value(field), "m"), 64)
+			return max(parsed, 0)
+		}
+
+		filament := Filament{
+			Tool:     i,
+			Material: value("material"),
+			Color:    value("color"),
+			Name:     value("name"),
+			Grams:    number("grams"),
+			MM:       number("mm"),
+		}
+		// Without a filament color the extruder's color stands in, as in the slicer
+		if filament.Color == "" {
+			filament.Color = value("extruder_color")
+		}
+		if filament.MM == 0 {
+			filament.MM = number("meters") * 1000
+		}
+		filaments = append(filaments, filament)
+	}
+	return filaments
+}
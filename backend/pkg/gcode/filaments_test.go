@@ -0,0 +1,57 @@
+package gcode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReadFilaments(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []Filament
+	}{
+		{
+			name: "OrcaSlicer multi-material",
+			content: "G28\nT1\n; filament used [mm] = 1200.5,0,300\n; filament used [g] = 3.5,0,1.0\n" +
+				"; filament_colour = #FFFFFF;#000000;#FF0000\n; filament_type = PLA;PLA;PETG\n" +
+				"; filament_settings_id = \"Bambu PLA Basic\";\"Bambu PLA Basic\";\"Generic PETG\"\n",
+			expected: []Filament{
+				{Tool: 0, Material: "PLA", Color: "#FFFFFF", Name: "Bambu PLA Basic", Grams: 3.5, MM: 1200.5},
+				{Tool: 1, Material: "PLA", Color: "#000000", Name: "Bambu PLA Basic"},
+				{Tool: 2, Material: "PETG", Color: "#FF0000", Name: "Generic PETG", Grams: 1, MM: 300},
+			},
+		},
+		{
+			name:     "PrusaSlicer extruder colors",
+			content:  "; extruder_colour = #FF8000;#0000FF\n; filament_colour = \"\";#00FF00\n; filament_type = PLA;PLA\n",
+			expected: []Filament{{Tool: 0, Material: "PLA", Color: "#FF8000"}, {Tool: 1, Material: "PLA", Color: "#00FF00"}},
+		},
+		{
+			name:     "Cura extruder trains",
+			content:  ";FLAVOR:Marlin\n;EXTRUDER_TRAIN.0.MATERIAL.TYPE:PLA\n;EXTRUDER_TRAIN.1.MATERIAL.TYPE:PVA\n;Filament used: 1.5m, 0.25m\n",
+			expected: []Filament{{Tool: 0, Material: "PLA", MM: 1500}, {Tool: 1, Material: "PVA", MM: 250}},
+		},
+		{
+			name:     "No filament comments",
+			content:  "G28\nG1 X1\n",
+			expected: []Filament{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filaments, err := ReadFilaments(writeGCode(t, tc.content))
+			if err != nil {
+				t.Fatalf("ReadFilaments failed: %v", err)
+			}
+			if !slices.Equal(filaments, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, filaments)
+			}
+		})
+	}
+
+	if (Filament{Tool: 1}).Used() || !(Filament{Grams: 1}).Used() {
+		t.Error("Expected only filaments with estimated use to be used")
+	}
+}
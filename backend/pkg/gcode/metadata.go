@@ -0,0 +1,361 @@
+package gcode
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// scanWindow is how much of the start and end of a file is searched for
+// slicer comments; PrusaSlicer and OrcaSlicer write their config at the end
+const scanWindow = 64 << 10 // 64KB
+
+// Metadata is the slicer information recorded in G-code comments
+type Metadata struct {
+	// Slicer names the slicer that generated the file, with its version
+	Slicer string `json:"slicer,omitempty"`
+
+	Printer        string  `json:"printer,omitempty"`
+	NozzleDiameter float64 `json:"nozzle_diameter,omitempty"`
+	Material       string  `json:"material,omitempty"`
+	LayerHeight    float64 `json:"layer_height,omitempty"`
+
+	// PrintTimeSeconds, FilamentGrams and FilamentMM are the slicer's estimates
+	// of what one print takes, summed over extruders
+	PrintTimeSeconds int64   `json:"print_time_seconds,omitempty"`
+	FilamentGrams    float64 `json:"filament_grams,omitempty"`
+	FilamentMM       float64 `json:"filament_mm,omitempty"`
+
+	// NozzleTemp and BedTemp are the print temperatures in °C, read from the
+	// slicer's settings or else the first temperature commands
+	NozzleTemp float64 `json:"nozzle_temp,omitempty"`
+	BedTemp    float64 `json:"bed_temp,omitempty"`
+}
+
+// keyAliases maps slicer comment keys (lowercased) to metadata fields
+var keyAliases = map[string]string{
+	"printer_model":                         "printer",
+	"printer_settings_id":                   "printer",
+	"target_machine.name":                   "printer",
+	"nozzle_diameter":                       "nozzle",
+	"extruder_train.0.nozzle.diameter":      "nozzle",
+	"filament_type":                         "material",
+	"extruder_train.0.material.type":        "material",
+	"layer_height":                          "layer_height",
+	"layer height":                          "layer_height",
+	"estimated printing time (normal mode)": "print_time",
+	"model printing time":                   "print_time",
+	"time":                                  "print_time_seconds",
+	"total filament used [g]":               "filament_grams",
+	"filament used [g]":                     "filament_grams",
+	"filament used [mm]":                    "filament_mm",
+	"filament used":                         "filament_meters",
+	"temperature":                           "nozzle_temp",
+	"nozzle_temperature":                    "nozzle_temp",
+	"extruder_train.0.initial_temperature":  "nozzle_temp",
+	"bed_temperature":                       "bed_temp",
+	"build_plate.initial_temperature":       "bed_temp",
+
+	// Simplify3D writes "key,value" settings and a build summary
+	"layerheight":      "layer_height",
+	"extruderdiameter": "nozzle",
+	"build time":       "print_time",
+	"filament length":  "filament_length",
+	"plastic weight":   "plastic_weight",
+}
+
+// slicerMarkers introduce the slicer name in a comment, lowercased:
+//
+//	; generated by PrusaSlicer 2.7.1 on 2024-01-15 at 10:00:00 UTC
+//	;Generated with Cura_SteamEngine 5.6.0
+//	; G-Code generated by Simplify3D(R) Version 4.1.2
+var slicerMarkers = []string{"generated by ", "generated with "}
+
+// temperatures are the first nozzle and bed temperatures a G-code file sets
+type temperatures struct {
+	nozzle, bed float64
+}
+
+// ReadMetadata extracts slicer metadata from a G-code file
+func ReadMetadata(path string) (Metadata, error) {
+	var meta Metadata
+	var commands temperatures
+	// The tail is read first so header values, which describe the actual print, win
+	err := readWindows(path, func(r io.Reader) { commands.merge(parseComments(r, &meta)) })
+	if err != nil {
+		return Metadata{}, err
+	}
+	meta.fillTemperatures(commands)
+	return meta, nil
+}
+
+// merge takes the temperatures other found
+func (t *temperatures) merge(other temperatures) {
+	if other.nozzle != 0 {
+		t.nozzle = other.nozzle
+	}
+	if other.bed != 0 {
+		t.bed = other.bed
+	}
+}
+
+// fillTemperatures falls back to the temperature commands for temperatures
+// the slicer's settings left out
+func (m *Metadata) fillTemperatures(commands temperatures) {
+	if m.NozzleTemp == 0 {
+		m.NozzleTemp = commands.nozzle
+	}
+	if m.BedTemp == 0 {
+		m.BedTemp = commands.bed
+	}
+}
+
+// readWindows passes the last scanWindow bytes of a file, when larger than
+// that, and then the first scanWindow bytes to parse
+func readWindows(path string, parse func(io.Reader)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() > scanWindow {
+		tail := make([]byte, scanWindow)
+		if _, err := file.ReadAt(tail, info.Size()-scanWindow); err != nil && err != io.EOF {
+			return err
+		}
+		parse(bytes.NewReader(tail))
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	parse(io.LimitReader(file, scanWindow))
+	return nil
+}
+
+// parseComments fills meta from "; key = value", ";KEY:value" and
+// Simplify3D's ";   key,value" comment lines, returning the temperatures the
+// first M104/M109 and M140/M190 commands set
+func parseComments(r io.Reader, meta *Metadata) temperatures {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	var commands temperatures
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ";") {
+			parseTemperatureCommand(line, &commands)
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, ";"))
+
+		if slicer, ok := parseSlicer(line); ok {
+			meta.Slicer = slicer
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			sep = strings.IndexByte(line, ',')
+		}
+		if sep <= 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:sep]))
+		value := strings.TrimSpace(line[sep+1:])
+
+		field, known := keyAliases[key]
+		if !known || value == "" {
+			continue
+		}
+
+		// Multi-extruder configs list one value per extruder; the first describes
+		// the print, while filament use adds up across them
+		perExtruder := value
+		if i := strings.IndexAny(value, ",;"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		value = strings.Trim(value, `"`)
+
+		switch field {
+		case "printer":
+			meta.Printer = value
+		case "nozzle":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				meta.NozzleDiameter = parsed
+			}
+		case "material":
+			meta.Material = value
+		case "layer_height":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				meta.LayerHeight = parsed
+			}
+		case "print_time":
+			// Bambu Studio and OrcaSlicer follow the model time with the total, which
+			// counts preparation like bed leveling too
+			if _, total, found := strings.Cut(perExtruder, "total estimated time:"); found {
+				value = strings.TrimSpace(total)
+			}
+			if parsed, ok := parseDuration(value); ok {
+				meta.PrintTimeSeconds = parsed
+			}
+		case "print_time_seconds":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+				meta.PrintTimeSeconds = int64(parsed)
+			}
+		case "filament_grams":
+			// PrusaSlicer writes the total after the per-extruder list, so the total wins
+			if parsed, ok := sumValues(perExtruder, ""); ok && (key == "total filament used [g]" || meta.FilamentGrams == 0) {
+				meta.FilamentGrams = parsed
+			}
+		case "filament_mm":
+			if parsed, ok := sumValues(perExtruder, ""); ok {
+				meta.FilamentMM = parsed
+			}
+		case "filament_meters":
+			// Cura writes ";Filament used: 1.23m, 0.5m"
+			if parsed, ok := sumValues(perExtruder, "m"); ok {
+				meta.FilamentMM = parsed * 1000
+			}
+		case "filament_length":
+			// Simplify3D writes "Filament length: 4512.3 mm (4.51 m)"
+			if parsed, ok := leadingValue(value, "mm"); ok {
+				meta.FilamentMM = parsed
+			}
+		case "plastic_weight":
+			// Simplify3D writes "Plastic weight: 13.49 g (0.03 lb)"
+			if parsed, ok := leadingValue(value, "g"); ok {
+				meta.FilamentGrams = parsed
+			}
+		case "nozzle_temp":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+				meta.NozzleTemp = parsed
+			}
+		case "bed_temp":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+				meta.BedTemp = parsed
+			}
+		}
+	}
+	return commands
+}
+
+// parseSlicer reads the slicer name from a comment naming what generated the
+// file, leaving out the date PrusaSlicer and its forks follow it with
+func parseSlicer(comment string) (string, bool) {
+	lower := strings.ToLower(comment)
+	for _, marker := range slicerMarkers {
+		if i := strings.Index(lower, marker); i >= 0 {
+			slicer := strings.TrimSpace(comment[i+len(marker):])
+			if on := strings.Index(slicer, " on "); on >= 0 {
+				slicer = slicer[:on]
+			}
+			return slicer, slicer != ""
+		}
+	}
+	// Bambu Studio writes just its name and version in the header block
+	if strings.HasPrefix(comment, "BambuStudio ") && !strings.ContainsAny(comment, "=:") {
+		return comment, true
+	}
+	return "", false
+}
+
+// parseTemperatureCommand records the temperature a G-code command sets,
+// unless an earlier command set it already. Setting 0 turns a heater off and
+// is skipped.
+func parseTemperatureCommand(line string, commands *temperatures) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+	var target *float64
+	switch fields[0] {
+	case "M104", "M109":
+		target = &commands.nozzle
+	case "M140", "M190":
+		target = &commands.bed
+	default:
+		return
+	}
+	if *target != 0 {
+		return
+	}
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, ";") {
+			return
+		}
+		if value, ok := strings.CutPrefix(field, "S"); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+				*target = parsed
+			}
+			return
+		}
+	}
+}
+
+// leadingValue reads the number a value starts with, when followed by unit
+func leadingValue(value, unit string) (float64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 || fields[1] != unit {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(fields[0], 64)
+	return parsed, err == nil && parsed > 0
+}
+
+// parseDuration reads slicer estimates like "1d 2h 3m 4s", or Simplify3D's
+// "1 hours 23 minutes", as seconds
+func parseDuration(value string) (int64, bool) {
+	units := map[byte]int64{'d': 86400, 'h': 3600, 'm': 60, 's': 1}
+	var total int64
+	parts := strings.Fields(value)
+	if len(parts) == 0 {
+		return 0, false
+	}
+	if _, err := strconv.ParseInt(parts[len(parts)-1], 10, 64); err != nil && len(parts)%2 == 0 {
+		if _, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+			// Join each number with the first letter of its unit word
+			joined := make([]string, 0, len(parts)/2)
+			for i := 0; i < len(parts); i += 2 {
+				joined = append(joined, parts[i]+parts[i+1][:1])
+			}
+			parts = joined
+		}
+	}
+	for _, part := range parts {
+		unit, known := units[part[len(part)-1]]
+		if !known {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(part[:len(part)-1], 10, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total += n * unit
+	}
+	return total, true
+}
+
+// sumValues adds up a comma or semicolon separated list of numbers, each
+// optionally followed by suffix
+func sumValues(value, suffix string) (float64, bool) {
+	var total float64
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSuffix(strings.Trim(strings.TrimSpace(part), `"`), suffix)
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || parsed < 0 {
+			return 0, false
+		}
+		total += parsed
+	}
+	return total, total > 0
+}
@@ -0,0 +1,146 @@
+package gcode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGCode writes a G-code file to a temporary directory
+func writeGCode(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "print.gcode")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write G-code: %v", err)
+	}
+	return path
+}
+
+func TestReadMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected Metadata
+	}{
+		{
+			name: "PrusaSlicer footer",
+			content: "; generated by PrusaSlicer 2.7.1\nG28\nG1 X10 Y10\n" +
+				"; prusaslicer_config = begin\n; filament_type = PETG;PLA\n; layer_height = 0.2\n" +
+				"; nozzle_diameter = 0.6,0.4\n; printer_model = MK4\n; prusaslicer_config = end\n",
+			expected: Metadata{Slicer: "PrusaSlicer 2.7.1", Printer: "MK4", NozzleDiameter: 0.6, Material: "PETG", LayerHeight: 0.2},
+		},
+		{
+			name: "PrusaSlicer temperatures",
+			content: "; generated by PrusaSlicer 2.7.1 on 2024-01-15 at 10:00:00 UTC\nM140 S55\nM104 S200\nG28\n" +
+				"; bed_temperature = 60\n; temperature = 215,230\n",
+			expected: Metadata{Slicer: "PrusaSlicer 2.7.1", NozzleTemp: 215, BedTemp: 60},
+		},
+		{
+			name: "Cura header",
+			content: ";FLAVOR:Marlin\n;TARGET_MACHINE.NAME:Creality Ender-3\n" +
+				";EXTRUDER_TRAIN.0.NOZZLE.DIAMETER:0.4\n;EXTRUDER_TRAIN.0.MATERIAL.TYPE:PLA\n;Layer height: 0.12\nG28\n",
+			expected: Metadata{Printer: "Creality Ender-3", NozzleDiameter: 0.4, Material: "PLA", LayerHeight: 0.12},
+		},
+		{
+			name: "Cura Griffin header",
+			content: ";START_OF_HEADER\n;FLAVOR:Griffin\n;GENERATOR.NAME:Cura_SteamEngine\n;EXTRUDER_TRAIN.0.INITIAL_TEMPERATURE:205\n" +
+				";BUILD_PLATE.INITIAL_TEMPERATURE:60\n;END_OF_HEADER\n;Generated with Cura_SteamEngine 5.6.0\nM104 S190\n",
+			expected: Metadata{Slicer: "Cura_SteamEngine 5.6.0", NozzleTemp: 205, BedTemp: 60},
+		},
+		{
+			name: "Simplify3D",
+			content: "; G-Code generated by Simplify3D(R) Version 4.1.2\n; Jan 15, 2024 at 10:00:00 AM\n" +
+				";   layerHeight,0.2000\n;   extruderDiameter,0.4\nM140 S70\nM104 S0\nM104 T0 S240 ; heat\nM109 S250\nG28\n" +
+				"; Build Summary\n;   Build time: 2 hours 13 minutes\n;   Filament length: 4512.3 mm (4.51 m)\n" +
+				";   Plastic volume: 10853.43 mm^3 (10.85 cc)\n;   Plastic weight: 13.49 g (0.03 lb)\n",
+			expected: Metadata{
+				Slicer: "Simplify3D(R) Version 4.1.2", NozzleDiameter: 0.4, LayerHeight: 0.2,
+				PrintTimeSeconds: 7980, FilamentMM: 4512.3, FilamentGrams: 13.49, NozzleTemp: 240, BedTemp: 70,
+			},
+		},
+		{
+			name:     "Bambu Studio header",
+			content:  "; HEADER_BLOCK_START\n; BambuStudio 01.08.02.56\n; HEADER_BLOCK_END\nM140 S55\nM104 S220\n",
+			expected: Metadata{Slicer: "BambuStudio 01.08.02.56", NozzleTemp: 220, BedTemp: 55},
+		},
+		{
+			name:     "No slicer comments",
+			content:  "G28\nG1 X0 Y0\n",
+			expected: Metadata{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, err := ReadMetadata(writeGCode(t, tc.content))
+			if err != nil {
+				t.Fatalf("ReadMetadata failed: %v", err)
+			}
+			if meta != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, meta)
+			}
+		})
+	}
+}
+
+func TestReadMetadataLargeFile(t *testing.T) {
+	moves := strings.Repeat("G1 X1 Y1 E0.1\n", 20000)
+	content := "; nozzle_diameter = 0.4\n" + moves + "; filament_type = ASA\n"
+
+	meta, err := ReadMetadata(writeGCode(t, content))
+	if err != nil {
+		t.Fatalf("ReadMetadata failed: %v", err)
+	}
+	if meta.NozzleDiameter != 0.4 || meta.Material != "ASA" {
+		t.Errorf("Expected header and footer values, got %+v", meta)
+	}
+}
+
+func TestReadMetadataMissingFile(t *testing.T) {
+	if _, err := ReadMetadata("/nonexistent/print.gcode"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestReadMetadataEstimates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected Metadata
+	}{
+		{
+			name: "PrusaSlicer footer",
+			content: "G28\n; filament used [mm] = 1200.5, 300\n; filament used [g] = 3.5, 1.0\n" +
+				"; total filament used [g] = 4.5\n; estimated printing time (normal mode) = 1d 2h 3m 4s\n" +
+				"; estimated printing time (silent mode) = 2d 0h 0m 0s\n",
+			expected: Metadata{PrintTimeSeconds: 93784, FilamentGrams: 4.5, FilamentMM: 1500.5},
+		},
+		{
+			name:     "Cura header",
+			content:  ";FLAVOR:Marlin\n;TIME:3723\n;Filament used: 1.5m, 0.25m\n;TIME_ELAPSED:12.5\nG28\n",
+			expected: Metadata{PrintTimeSeconds: 3723, FilamentMM: 1750},
+		},
+		{
+			name:     "Bambu Studio header",
+			content:  "; HEADER_BLOCK_START\n; model printing time: 1h 2m 3s; total estimated time: 1h 10m 5s\n; HEADER_BLOCK_END\n",
+			expected: Metadata{PrintTimeSeconds: 4205},
+		},
+		{
+			name:     "Unreadable estimates",
+			content:  "; estimated printing time (normal mode) = soon\n; filament used [g] = n/a\n",
+			expected: Metadata{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, err := ReadMetadata(writeGCode(t, tc.content))
+			if err != nil {
+				t.Fatalf("ReadMetadata failed: %v", err)
+			}
+			if meta != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, meta)
+			}
+		})
+	}
+}
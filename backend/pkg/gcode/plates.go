@@ -0,0 +1,324 @@
+package gcode
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sliceInfoEntry is where Bambu Studio and OrcaSlicer describe the plates of a
+// sliced 3MF
+const sliceInfoEntry = "Metadata/slice_info.config"
+
+// Plate is one build plate of a sliced file: what is printed on it and the
+// slicer's estimates for printing it
+type Plate struct {
+	Index int `json:"index"`
+	Metadata
+
+	// Objects are the object instances on the plate, named the way Klipper's
+	// EXCLUDE_OBJECT and the printer's cancel-object menu show them
+	Objects []string `json:"objects"`
+
+	// SkippedObjects were left out of slicing in the slicer
+	SkippedObjects []string `json:"skipped_objects,omitempty"`
+
+	// Filaments are the filaments the plate uses, by the tool the AMS maps to a slot
+	Filaments []Filament `json:"filaments,omitempty"`
+
+	// GCode is the plate's G-code entry within a sliced 3MF
+	GCode string `json:"gcode,omitempty"`
+}
+
+// IsPlateFile reports whether a filename is a sliced 3MF, as Bambu Studio and
+// OrcaSlicer export plates to send to a printer
+func IsPlateFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".gcode.3mf")
+}
+
+// ReadObjects returns the object instances a G-code file labels for
+// cancelling, in the order they first appear:
+//
+//	EXCLUDE_OBJECT_DEFINE NAME=benchy.stl_id_0_copy_0 ...  (Klipper)
+//	M486 Abenchy.stl id:0 copy 0                          (Marlin, Prusa)
+//	; printing object benchy.stl id:0 copy 0              (PrusaSlicer, OrcaSlicer)
+//
+// Objects are labelled through the whole print, so unlike the metadata the
+// whole file is read.
+func ReadObjects(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseObjects(file)
+}
+
+// parseObjects lists the object labels in r. Slicers label objects in comments as
+// well as for the firmware, so the firmware's names are preferred when given.
+func parseObjects(r io.Reader) ([]string, error) {
+	var klipper, marlin, comments objectNames
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "EXCLUDE_OBJECT_DEFINE "):
+			klipper.add(objectParam(line, "NAME="))
+		case strings.HasPrefix(line, "M486 "):
+			command, _, _ := strings.Cut(line, ";")
+			if i := strings.Index(command, " A"); i >= 0 {
+				marlin.add(command[i+2:])
+			}
+		case strings.HasPrefix(line, ";"):
+			comment := strings.TrimSpace(strings.TrimPrefix(line, ";"))
+			if name, found := strings.CutPrefix(comment, "printing object "); found {
+				comments.add(name)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, names := range []objectNames{klipper, marlin, comments} {
+		if len(names.list) > 0 {
+			return names.list, nil
+		}
+	}
+	return []string{}, nil
+}
+
+// objectNames collects object names once each, in order
+type objectNames struct {
+	list []string
+	seen map[string]bool
+}
+
+func (n *objectNames) add(name string) {
+	name = strings.Trim(strings.TrimSpace(name), `"'`)
+	if name == "" || n.seen[name] {
+		return
+	}
+	if n.seen == nil {
+		n.seen = make(map[string]bool)
+	}
+	n.seen[name] = true
+	n.list = append(n.list, name)
+}
+
+// objectParam returns the value of a KEY=value parameter of a Klipper
+// command, which may be quoted to hold spaces
+func objectParam(line, key string) string {
+	i := strings.Index(line, " "+key)
+	if i < 0 {
+		return ""
+	}
+	value := line[i+1+len(key):]
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	value, _, _ = strings.Cut(value, " ")
+	return value
+}
+
+// sliceInfo is the part of slice_info.config describing plates
+type sliceInfo struct {
+	Plates []struct {
+		Metadata []struct {
+			Key   string `xml:"key,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"metadata"`
+		Objects []struct {
+			Name    string `xml:"name,attr"`
+			Skipped bool   `xml:"skipped,attr"`
+		} `xml:"object"`
+		Filaments []struct {
+			ID    int     `xml:"id,attr"`
+			Type  string  `xml:"type,attr"`
+			Color string  `xml:"color,attr"`
+			UsedM float64 `xml:"used_m,attr"`
+			UsedG float64 `xml:"used_g,attr"`
+		} `xml:"filament"`
+	} `xml:"plate"`
+}
+
+// ReadPlates returns the plates of a sliced 3MF, ordered by index. The
+// slicer's slice_info.config describes them when present; otherwise each
+// Metadata/plate_N.gcode entry is read. A 3MF without sliced plates has none.
+func ReadPlates(filename string) ([]Plate, error) {
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	gcodeEntries := make(map[int]*zip.File)
+	var info *zip.File
+	for _, entry := range archive.File {
+		if entry.Name == sliceInfoEntry {
+			info = entry
+		}
+		if index, ok := plateIndex(entry.Name); ok {
+			gcodeEntries[index] = entry
+		}
+	}
+
+	var plates []Plate
+	if info != nil {
+		plates, err = readSliceInfo(info)
+	} else {
+		plates, err = readPlateGCode(gcodeEntries)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range plates {
+		if entry, ok := gcodeEntries[plates[i].Index]; ok {
+			plates[i].GCode = entry.Name
+		}
+	}
+	sort.Slice(plates, func(i, j int) bool { return plates[i].Index < plates[j].Index })
+	return plates, nil
+}
+
+// plateIndex reads N from a Metadata/plate_N.gcode entry name
+func plateIndex(name string) (int, bool) {
+	dir, base := path.Split(name)
+	if dir != "Metadata/" || !strings.HasPrefix(base, "plate_") || !strings.HasSuffix(base, ".gcode") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(base, "plate_"), ".gcode"))
+	return index, err == nil && index > 0
+}
+
+// readSliceInfo reads the plates described by slice_info.config
+func readSliceInfo(entry *zip.File) ([]Plate, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var info sliceInfo
+	if err := xml.NewDecoder(reader).Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", sliceInfoEntry, err)
+	}
+
+	plates := make([]Plate, 0, len(info.Plates))
+	for _, p := range info.Plates {
+		plate := Plate{Objects: []string{}}
+		for _, m := range p.Metadata {
+			switch m.Key {
+			case "index":
+				plate.Index, _ = strconv.Atoi(m.Value)
+			case "prediction":
+				plate.PrintTimeSeconds, _ = strconv.ParseInt(m.Value, 10, 64)
+			case "weight":
+				plate.FilamentGrams, _ = strconv.ParseFloat(m.Value, 64)
+			case "nozzle_diameters":
+				first, _, _ := strings.Cut(m.Value, ",")
+				plate.NozzleDiameter, _ = strconv.ParseFloat(strings.TrimSpace(first), 64)
+			}
+		}
+		for _, object := range p.Objects {
+			if object.Skipped {
+				plate.SkippedObjects = append(plate.SkippedObjects, object.Name)
+				continue
+			}
+			plate.Objects = append(plate.Objects, object.Name)
+		}
+
+		// As in G-code the first filament describes the print, while use adds up
+		var grams float64
+		for _, filament := range p.Filaments {
+			if plate.Material == "" {
+				plate.Material = filament.Type
+			}
+			plate.FilamentMM += filament.UsedM * 1000
+			grams += filament.UsedG
+			plate.Filaments = append(plate.Filaments, Filament{
+				Tool:     max(filament.ID-1, 0),
+				Material: filament.Type,
+				Color:    filament.Color,
+				Grams:    filament.UsedG,
+				MM:       filament.UsedM * 1000,
+			})
+		}
+		if plate.FilamentGrams == 0 {
+			plate.FilamentGrams = grams
+		}
+
+		plates = append(plates, plate)
+	}
+	return plates, nil
+}
+
+// readPlateGCode reads the plates from their G-code entries, which unlike
+// files on disk are read whole
+func readPlateGCode(entries map[int]*zip.File) ([]Plate, error) {
+	plates := make([]Plate, 0, len(entries))
+	for index, entry := range entries {
+		plate := Plate{Index: index}
+
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		lists := make(map[string][]string)
+		var objectsErr error
+		err = fanOut(reader,
+			func(r io.Reader) { plate.Metadata.fillTemperatures(parseComments(r, &plate.Metadata)) },
+			func(r io.Reader) { parseFilamentComments(r, lists) },
+			func(r io.Reader) { plate.Objects, objectsErr = parseObjects(r) },
+		)
+		reader.Close()
+		if err = errors.Join(err, objectsErr); err != nil {
+			return nil, err
+		}
+		plate.Filaments = buildFilaments(lists)
+
+		plates = append(plates, plate)
+	}
+	return plates, nil
+}
+
+// fanOut reads r once, passing it to each parser at the same time
+func fanOut(r io.Reader, parsers ...func(io.Reader)) error {
+	var wg sync.WaitGroup
+	writers := make([]io.Writer, len(parsers))
+	pipes := make([]*io.PipeWriter, len(parsers))
+	for i, parse := range parsers {
+		pr, pw := io.Pipe()
+		writers[i], pipes[i] = pw, pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parse(pr)
+			// A parser stopping early mustn't hold up the others
+			io.Copy(io.Discard, pr)
+		}()
+	}
+
+	_, err := io.Copy(io.MultiWriter(writers...), r)
+	for _, pw := range pipes {
+		pw.CloseWithError(err)
+	}
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,160 @@
+package gcode
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writePlateFile writes a sliced 3MF holding entries to a temporary directory
+func writePlateFile(t *testing.T, entries map[string]string) string {
+	path := filepath.Join(t.TempDir(), "print.gcode.3mf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create 3MF: %v", err)
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to write 3MF: %v", err)
+	}
+	file.Close()
+	return path
+}
+
+func TestReadObjects(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "Klipper exclude object",
+			content: "EXCLUDE_OBJECT_DEFINE NAME=gear.stl_id_0_copy_0 CENTER=10,10 POLYGON=[[0,0]]\n" +
+				"EXCLUDE_OBJECT_DEFINE NAME='wheel cap' CENTER=20,20\n" +
+				"; printing object gear.stl id:0 copy 0\nEXCLUDE_OBJECT_START NAME=gear.stl_id_0_copy_0\nG1 X1\n",
+			expected: []string{"gear.stl_id_0_copy_0", "wheel cap"},
+		},
+		{
+			name:     "Marlin object labels",
+			content:  "M486 T2\nM486 S0\nM486 Agear.stl id:0 copy 0\nG1 X1\nM486 S1 A\"gear.stl id:0 copy 1\"\nM486 S0\n",
+			expected: []string{"gear.stl id:0 copy 0", "gear.stl id:0 copy 1"},
+		},
+		{
+			name:     "Slicer comments",
+			content:  "; printing object gear.stl id:0 copy 0\n; stop printing object gear.stl id:0 copy 0\n; printing object gear.stl id:0 copy 1\n; printing object gear.stl id:0 copy 0\n",
+			expected: []string{"gear.stl id:0 copy 0", "gear.stl id:0 copy 1"},
+		},
+		{
+			name:     "No labels",
+			content:  "G28\nG1 X1\n",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objects, err := ReadObjects(writeGCode(t, tc.content))
+			if err != nil {
+				t.Fatalf("ReadObjects failed: %v", err)
+			}
+			if !slices.Equal(objects, tc.expected) {
+				t.Errorf("Expected %q, got %q", tc.expected, objects)
+			}
+		})
+	}
+}
+
+func TestReadPlatesSliceInfo(t *testing.T) {
+	path := writePlateFile(t, map[string]string{
+		"3D/3dmodel.model": "<model/>",
+		sliceInfoEntry: `<?xml version="1.0" encoding="UTF-8"?>
+<config>
+  <header><header_item key="X-BBL-Client-Type" value="slicer"/></header>
+  <plate>
+    <metadata key="index" value="2"/>
+    <metadata key="prediction" value="1800"/>
+    <metadata key="weight" value="8.5"/>
+    <object identify_id="12" name="wheel.stl" skipped="false"/>
+  </plate>
+  <plate>
+    <metadata key="index" value="1"/>
+    <metadata key="prediction" value="5120"/>
+    <metadata key="nozzle_diameters" value="0.4"/>
+    <object identify_id="7" name="gear.stl" skipped="false"/>
+    <object identify_id="8" name="gear.stl" skipped="false"/>
+    <object identify_id="9" name="spacer.stl" skipped="true"/>
+    <filament id="1" type="PETG" used_m="4.5" used_g="13.2"/>
+    <filament id="2" type="PLA" color="#FF0000" used_m="0.5" used_g="1.5"/>
+  </plate>
+</config>`,
+		"Metadata/plate_1.gcode": "G28\n",
+		"Metadata/plate_2.gcode": "G28\n",
+	})
+
+	plates, err := ReadPlates(path)
+	if err != nil {
+		t.Fatalf("ReadPlates failed: %v", err)
+	}
+	if len(plates) != 2 || plates[0].Index != 1 || plates[1].Index != 2 {
+		t.Fatalf("Expected plates 1 and 2 in order, got %+v", plates)
+	}
+
+	first := plates[0]
+	if first.PrintTimeSeconds != 5120 || first.FilamentGrams != 14.7 || first.FilamentMM != 5000 ||
+		first.Material != "PETG" || first.NozzleDiameter != 0.4 {
+		t.Errorf("Unexpected estimates for plate 1: %+v", first.Metadata)
+	}
+	if !slices.Equal(first.Objects, []string{"gear.stl", "gear.stl"}) || !slices.Equal(first.SkippedObjects, []string{"spacer.stl"}) {
+		t.Errorf("Unexpected objects on plate 1: %q, skipped %q", first.Objects, first.SkippedObjects)
+	}
+	if len(first.Filaments) != 2 || first.Filaments[1] != (Filament{Tool: 1, Material: "PLA", Color: "#FF0000", Grams: 1.5, MM: 500}) {
+		t.Errorf("Unexpected filaments on plate 1: %+v", first.Filaments)
+	}
+	if first.GCode != "Metadata/plate_1.gcode" {
+		t.Errorf("Expected the plate's G-code entry, got %q", first.GCode)
+	}
+	if plates[1].FilamentGrams != 8.5 || !slices.Equal(plates[1].Objects, []string{"wheel.stl"}) {
+		t.Errorf("Unexpected plate 2: %+v", plates[1])
+	}
+}
+
+func TestReadPlatesGCodeEntries(t *testing.T) {
+	path := writePlateFile(t, map[string]string{
+		"Metadata/plate_1.gcode": "; model printing time: 10m; total estimated time: 12m\n" +
+			"; filament_type = PLA;PETG\n; filament_colour = #FFFFFF;#00FF00\nEXCLUDE_OBJECT_DEFINE NAME=gear.stl_id_0_copy_0\nG28\n",
+		"Metadata/plate_1.png": "png",
+	})
+
+	plates, err := ReadPlates(path)
+	if err != nil {
+		t.Fatalf("ReadPlates failed: %v", err)
+	}
+	if len(plates) != 1 {
+		t.Fatalf("Expected 1 plate, got %+v", plates)
+	}
+	plate := plates[0]
+	if plate.Index != 1 || plate.PrintTimeSeconds != 720 || plate.Material != "PLA" ||
+		!slices.Equal(plate.Objects, []string{"gear.stl_id_0_copy_0"}) || len(plate.Filaments) != 2 || plate.Filaments[1].Color != "#00FF00" {
+		t.Errorf("Unexpected plate: %+v", plate)
+	}
+}
+
+func TestReadPlatesUnsliced(t *testing.T) {
+	plates, err := ReadPlates(writePlateFile(t, map[string]string{"3D/3dmodel.model": "<model/>"}))
+	if err != nil || len(plates) != 0 {
+		t.Errorf("Expected no plates for an unsliced 3MF, got %+v, %v", plates, err)
+	}
+
+	if _, err := ReadPlates(writeGCode(t, "G28\n")); err == nil {
+		t.Error("Expected an error for a file that is not a 3MF")
+	}
+}
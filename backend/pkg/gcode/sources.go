@@ -0,0 +1,73 @@
+package gcode
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// ReadSourceModels returns the names of the models a G-code file was sliced
+// from, as recorded in slicer comments:
+//
+//	;MESH:benchy.stl                          (Cura)
+//	; printing object benchy.stl id:0 copy 0  (PrusaSlicer, OrcaSlicer)
+//	; objects_info = {"objects":[{"name":"benchy.stl",...}]}  (PrusaSlicer)
+func ReadSourceModels(path string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	err := readWindows(path, func(r io.Reader) { parseSourceComments(r, add) })
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// parseSourceComments calls add for every model name referenced in r
+func parseSourceComments(r io.Reader, add func(string)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ";") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, ";"))
+
+		switch {
+		case strings.HasPrefix(line, "MESH:"):
+			if mesh := strings.TrimPrefix(line, "MESH:"); mesh != "NONMESH" {
+				add(mesh)
+			}
+		case strings.HasPrefix(line, "printing object "):
+			name := strings.TrimPrefix(line, "printing object ")
+			if i := strings.LastIndex(name, " id:"); i >= 0 {
+				name = name[:i]
+			}
+			add(name)
+		case strings.HasPrefix(line, "objects_info"):
+			var info struct {
+				Objects []struct {
+					Name string `json:"name"`
+				} `json:"objects"`
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(line, "objects_info"))
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(value, "="))), &info); err != nil {
+				continue
+			}
+			for _, object := range info.Objects {
+				add(object.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package gcode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReadSourceModels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "Cura meshes",
+			content:  ";FLAVOR:Marlin\n;LAYER:0\n;MESH:benchy.stl\nG1 X1\n;MESH:NONMESH\n;MESH:benchy.stl\n;MESH:base.stl\n",
+			expected: []string{"benchy.stl", "base.stl"},
+		},
+		{
+			name:     "PrusaSlicer object labels",
+			content:  "; printing object benchy v2.stl id:0 copy 0\nG1 X1\n; stop printing object benchy v2.stl id:0 copy 0\n",
+			expected: []string{"benchy v2.stl"},
+		},
+		{
+			name:     "PrusaSlicer objects info",
+			content:  "; objects_info = {\"objects\":[{\"name\":\"hull.stl\",\"polygon\":[[1,2]]},{\"name\":\"mast.stl\"}]}\nG28\n",
+			expected: []string{"hull.stl", "mast.stl"},
+		},
+		{
+			name:     "No references",
+			content:  "G28\n; layer_height = 0.2\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := ReadSourceModels(writeGCode(t, tc.content))
+			if err != nil {
+				t.Fatalf("ReadSourceModels failed: %v", err)
+			}
+			if !slices.Equal(names, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package gcode
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoThumbnail is returned for files that embed no thumbnail
+var ErrNoThumbnail = errors.New("no embedded thumbnail")
+
+// thumbnailScanLimit bounds how much of a G-code header is searched for
+// thumbnails, which slicers write before the first command
+const thumbnailScanLimit = 16 << 20 // 16MB
+
+// packageThumbnails are the 3MF entries slicers keep thumbnails in, most
+// preferred first: the package thumbnail, then Bambu Studio's and
+// OrcaSlicer's plate renders
+var packageThumbnails = []string{
+	"Metadata/thumbnail.png",
+	"Metadata/plate_1.png",
+	"Auxiliaries/.thumbnails/thumbnail_middle.png",
+}
+
+// EmbedsThumbnails reports whether a file is of a kind slicers embed
+// thumbnails in: G-code or 3MF
+func EmbedsThumbnails(filename string) bool {
+	lower := strings.ToLower(filename)
+	return strings.HasSuffix(lower, ".gcode") || strings.HasSuffix(lower, ".gco") || strings.HasSuffix(lower, ".3mf")
+}
+
+// HasThumbnail reports whether a G-code or 3MF file embeds a thumbnail,
+// without decoding it
+func HasThumbnail(filePath string) bool {
+	if is3MF(filePath) {
+		archive, err := zip.OpenReader(filePath)
+		if err != nil {
+			return false
+		}
+		defer archive.Close()
+		return packageThumbnail(&archive.Reader) != nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	found := false
+	err = thumbnailBlocks(file, func(thumbnailBlock) bool {
+		found = true
+		return false
+	})
+	return err == nil && found
+}
+
+// ReadThumbnail returns the thumbnail a slicer embedded in a G-code or 3MF
+// file. G-code files often carry several sizes, of which the largest is
+// returned; PNG and JPEG thumbnails are read, QOI ones skipped.
+func ReadThumbnail(filePath string) (image.Image, error) {
+	if is3MF(filePath) {
+		return readPackageThumbnail(filePath)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var largest *thumbnailBlock
+	err = thumbnailBlocks(file, func(block thumbnailBlock) bool {
+		if block.format != "" && (largest == nil || block.pixels() > largest.pixels()) {
+			largest = &block
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if largest == nil {
+		return nil, ErrNoThumbnail
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(largest.data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %dx%d thumbnail: %w", largest.width, largest.height, err)
+	}
+	if largest.format == "jpg" {
+		return jpeg.Decode(bytes.NewReader(data))
+	}
+	return png.Decode(bytes.NewReader(data))
+}
+
+// thumbnailBlock is a base64 thumbnail in G-code comments:
+//
+//	; thumbnail begin 300x300 18408
+//	; iVBORw0KGgoAAAANSUhEUgAAASwAAAEsCAYAAAB5fY51AAAACXBIWXMAAAsTAAALEw...
+//	; thumbnail end
+//
+// Blocks starting "thumbnail_JPG" hold JPEGs and "thumbnail_QOI" QOI images.
+type thumbnailBlock struct {
+	width, height int
+
+	// format is "png" or "jpg", empty for formats that can't be decoded
+	format string
+	data   []byte
+}
+
+func (b *thumbnailBlock) pixels() int {
+	return b.width * b.height
+}
+
+// thumbnailBlocks passes the thumbnail blocks in a G-code header to found
+// until it returns false. Only the comments before the first command are read.
+func thumbnailBlocks(r io.Reader, found func(thumbnailBlock) bool) error {
+	scanner := bufio.NewScanner(io.LimitReader(r, thumbnailScanLimit))
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	var block *thumbnailBlock
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, ";")
+		if !ok {
+			break
+		}
+		comment = strings.TrimSpace(comment)
+
+		if block != nil {
+			if strings.HasPrefix(comment, "thumbnail") && strings.HasSuffix(comment, " end") {
+				if !found(*block) {
+					return nil
+				}
+				block = nil
+				continue
+			}
+			block.data = append(block.data, comment...)
+			continue
+		}
+
+		fields := strings.Fields(comment)
+		if len(fields) < 3 || fields[1] != "begin" || !strings.HasPrefix(fields[0], "thumbnail") {
+			continue
+		}
+		width, height, ok := strings.Cut(fields[2], "x")
+		if !ok {
+			continue
+		}
+		block = &thumbnailBlock{}
+		block.width, _ = strconv.Atoi(width)
+		block.height, _ = strconv.Atoi(height)
+		switch strings.ToUpper(strings.TrimPrefix(fields[0], "thumbnail")) {
+		case "", "_PNG":
+			block.format = "png"
+		case "_JPG":
+			block.format = "jpg"
+		}
+	}
+	return scanner.Err()
+}
+
+// readPackageThumbnail decodes the thumbnail of a 3MF package
+func readPackageThumbnail(filePath string) (image.Image, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	entry := packageThumbnail(&archive.Reader)
+	if entry == nil {
+		return nil, ErrNoThumbnail
+	}
+	content, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	img, _, err := image.Decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid thumbnail %s: %w", entry.Name, err)
+	}
+	return img, nil
+}
+
+// packageThumbnail finds a 3MF's thumbnail entry: a well-known one, else the
+// first PNG or JPEG under Metadata
+func packageThumbnail(archive *zip.Reader) *zip.File {
+	entries := make(map[string]*zip.File, len(archive.File))
+	var images []string
+	for _, entry := range archive.File {
+		entries[entry.Name] = entry
+		switch strings.ToLower(path.Ext(entry.Name)) {
+		case ".png", ".jpg", ".jpeg":
+			if strings.HasPrefix(entry.Name, "Metadata/") {
+				images = append(images, entry.Name)
+			}
+		}
+	}
+
+	for _, name := range packageThumbnails {
+		if entry, ok := entries[name]; ok {
+			return entry
+		}
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	sort.Strings(images)
+	return entries[images[0]]
+}
+
+// is3MF reports whether a file is a 3MF package, sliced or not
+func is3MF(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), ".3mf")
+}
@@ -0,0 +1,90 @@
+package gcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encodePNG returns a width x height PNG
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// thumbnailComment embeds an image in G-code comments the way PrusaSlicer
+// does, wrapping the base64 at 78 columns
+func thumbnailComment(kind string, width, height int, data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	fmt.Fprintf(&b, "; %s begin %dx%d %d\n", kind, width, height, len(encoded))
+	for len(encoded) > 78 {
+		fmt.Fprintf(&b, "; %s\n", encoded[:78])
+		encoded = encoded[78:]
+	}
+	fmt.Fprintf(&b, "; %s\n; %s end\n", encoded, kind)
+	return b.String()
+}
+
+func TestReadThumbnailGCode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "benchy.gcode")
+	content := "; generated by PrusaSlicer 2.7.1\n\n" +
+		thumbnailComment("thumbnail", 16, 16, encodePNG(t, 16, 16)) +
+		thumbnailComment("thumbnail_QOI", 640, 480, []byte("qoif")) +
+		thumbnailComment("thumbnail", 220, 124, encodePNG(t, 220, 124)) +
+		"\nG28\n" + thumbnailComment("thumbnail", 400, 400, encodePNG(t, 400, 400))
+	os.WriteFile(path, []byte(content), 0644)
+
+	if !HasThumbnail(path) {
+		t.Fatal("Expected the thumbnail found")
+	}
+	img, err := ReadThumbnail(path)
+	if err != nil {
+		t.Fatalf("Failed to read thumbnail: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 220 || bounds.Dy() != 124 {
+		t.Errorf("Expected the largest PNG of the header, got %v", bounds)
+	}
+
+	plain := filepath.Join(dir, "plain.gcode")
+	os.WriteFile(plain, []byte("; generated by Cura\nG28\n"), 0644)
+	if HasThumbnail(plain) {
+		t.Error("Expected no thumbnail in plain G-code")
+	}
+	if _, err := ReadThumbnail(plain); err != ErrNoThumbnail {
+		t.Errorf("Expected ErrNoThumbnail, got %v", err)
+	}
+}
+
+func TestReadThumbnailPackage(t *testing.T) {
+	path := writePlateFile(t, map[string]string{
+		"3D/3dmodel.model":     "<model/>",
+		"Metadata/top_1.png":   string(encodePNG(t, 32, 32)),
+		"Metadata/plate_1.png": string(encodePNG(t, 64, 48)),
+	})
+	if !HasThumbnail(path) {
+		t.Fatal("Expected the plate render found")
+	}
+	img, err := ReadThumbnail(path)
+	if err != nil {
+		t.Fatalf("Failed to read thumbnail: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 64 || bounds.Dy() != 48 {
+		t.Errorf("Expected the plate render, got %v", bounds)
+	}
+
+	unsliced := writePlateFile(t, map[string]string{"3D/3dmodel.model": "<model/>"})
+	if _, err := ReadThumbnail(unsliced); err != ErrNoThumbnail {
+		t.Errorf("Expected ErrNoThumbnail, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWebPEncoder is the cwebp binary looked up on PATH for WebP conversion
+	DefaultWebPEncoder = "cwebp"
+
+	// jpegQuality is used when a JPEG must be re-encoded to apply its orientation
+	jpegQuality = 92
+
+	// encoderTimeout bounds one WebP conversion
+	encoderTimeout = 2 * time.Minute
+)
+
+// Options controls how uploaded and imported images are normalized
+type Options struct {
+	// StripMetadata removes EXIF, XMP, IPTC and text metadata, such as GPS
+	// positions and camera serial numbers, applying any EXIF orientation to
+	// the pixels first so images still display upright
+	StripMetadata bool
+
+	// WebPQuality converts JPEG and PNG images to WebP at this quality (1-100);
+	// 0 keeps their format. Converted images are always stripped.
+	WebPQuality int
+
+	// WebPEncoder is the cwebp binary used for conversion
+	WebPEncoder string
+}
+
+// Normalizer rewrites images according to its options. A nil Normalizer
+// leaves images untouched.
+type Normalizer struct {
+	opts Options
+}
+
+// New creates a Normalizer with the given options
+func New(opts Options) *Normalizer {
+	if opts.WebPEncoder == "" {
+		opts.WebPEncoder = DefaultWebPEncoder
+	}
+	return &Normalizer{opts: opts}
+}
+
+// Enabled reports whether the normalizer changes images at all
+func (n *Normalizer) Enabled() bool {
+	return n != nil && (n.opts.StripMetadata || n.opts.WebPQuality > 0)
+}
+
+// CheckEncoder reports an error when WebP conversion is enabled but its encoder can't be found
+func (n *Normalizer) CheckEncoder() error {
+	if n == nil || n.opts.WebPQuality <= 0 {
+		return nil
+	}
+	if _, err := exec.LookPath(n.opts.WebPEncoder); err != nil {
+		return fmt.Errorf("WebP encoder %s not found: %v", n.opts.WebPEncoder, err)
+	}
+	return nil
+}
+
+// Handles reports whether filename is an image format the normalizer rewrites
+func Handles(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return true
+	}
+	return false
+}
+
+// Normalize rewrites the image at path in place and returns the path of the
+// result, which ends in .webp when the image was converted. Files of other
+// formats are left as they are.
+func (n *Normalizer) Normalize(path string) (string, error) {
+	if !n.Enabled() || !Handles(path) {
+		return path, nil
+	}
+
+	convert := n.opts.WebPQuality > 0 && strings.ToLower(filepath.Ext(path)) != ".webp"
+	if n.opts.StripMetadata || convert {
+		if err := stripFile(path); err != nil {
+			return path, fmt.Errorf("failed to strip metadata from %s: %v", filepath.Base(path), err)
+		}
+	}
+	if convert {
+		return n.convertWebP(path)
+	}
+	return path, nil
+}
+
+// NormalizeDir normalizes every image under dir, skipping hidden files and
+// directories. An image that fails is left as it is and the others are still
+// normalized; the first failure is returned.
+func (n *Normalizer) NormalizeDir(dir string) error {
+	if !n.Enabled() {
+		return nil
+	}
+	var first error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, err := n.Normalize(path); err != nil && first == nil {
+			first = err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return first
+}
+
+// convertWebP encodes the image at path as WebP next to it and removes the
+// original. An existing file with the WebP name keeps the image in its format.
+func (n *Normalizer) convertWebP(path string) (string, error) {
+	dest := strings.TrimSuffix(path, filepath.Ext(path)) + ".webp"
+	if _, err := os.Stat(dest); err == nil {
+		return path, nil
+	}
+
+	// Encode to a hidden name so scans never see a partial file
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(dest)+".tmp")
+	defer os.Remove(tmp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), encoderTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, n.opts.WebPEncoder, "-quiet", "-metadata", "none",
+		"-q", strconv.Itoa(n.opts.WebPQuality), path, "-o", tmp)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return path, fmt.Errorf("failed to convert %s to WebP: %v: %s", filepath.Base(path), err, message)
+		}
+		return path, fmt.Errorf("failed to convert %s to WebP: %v", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		return path, fmt.Errorf("failed to convert %s to WebP: %v", filepath.Base(path), err)
+	}
+	if err := os.Remove(path); err != nil {
+		os.Remove(dest)
+		return path, fmt.Errorf("failed to replace %s with its WebP version: %v", filepath.Base(path), err)
+	}
+	return dest, nil
+}
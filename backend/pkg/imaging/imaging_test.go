@@ -0,0 +1,254 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testImage is 32x16 with a red 8x8 top-left block, so rotations can be told
+// apart after JPEG compression
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			if x < 8 && y < 8 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return img
+}
+
+// exifSegment builds a JPEG APP1 segment holding an orientation and a GPS-like marker string
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	ifd := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(ifd[0:], 1)
+	binary.BigEndian.PutUint16(ifd[2:], 0x0112)
+	binary.BigEndian.PutUint16(ifd[4:], 3)
+	binary.BigEndian.PutUint32(ifd[6:], 1)
+	binary.BigEndian.PutUint16(ifd[10:], orientation)
+	payload := append([]byte("Exif\x00\x00"), append(tiff, append(ifd, []byte("GPS 52.37N 4.89E")...)...)...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithSegments encodes testImage as a JPEG with the segments inserted after its signature
+func jpegWithSegments(t *testing.T, segments ...[]byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	for _, segment := range segments {
+		out = append(out, segment...)
+	}
+	return append(out, data[2:]...)
+}
+
+// writeImage writes data to name in a temporary directory
+func writeImage(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	return path
+}
+
+// TestNormalizeJPEG tests stripping JPEG metadata and applying its orientation
+func TestNormalizeJPEG(t *testing.T) {
+	testCases := []struct {
+		name        string
+		orientation uint16
+		width       int
+		height      int
+		// redX and redY locate the middle of the original top-left block after normalizing
+		redX, redY int
+	}{
+		{name: "Upright", orientation: 1, width: 32, height: 16, redX: 4, redY: 4},
+		{name: "Rotated 180", orientation: 3, width: 32, height: 16, redX: 27, redY: 11},
+		{name: "Rotated 90 clockwise", orientation: 6, width: 16, height: 32, redX: 11, redY: 4},
+		{name: "Rotated 90 counter-clockwise", orientation: 8, width: 16, height: 32, redX: 4, redY: 27},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := []byte{0xFF, 0xFE, 0x00, 0x09, 'c', 'a', 'm', 'e', 'r', 'a', '1'}
+			path := writeImage(t, "photo.jpg", jpegWithSegments(t, exifSegment(tc.orientation), comment))
+
+			result, err := New(Options{StripMetadata: true}).Normalize(path)
+			if err != nil {
+				t.Fatalf("Normalize failed: %v", err)
+			}
+			if result != path {
+				t.Errorf("Expected the image to keep its path, got %s", result)
+			}
+
+			data, _ := os.ReadFile(path)
+			if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte("GPS")) || bytes.Contains(data, []byte("camera1")) {
+				t.Error("Expected EXIF and comments to be stripped")
+			}
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Normalized image does not decode: %v", err)
+			}
+			if img.Bounds().Dx() != tc.width || img.Bounds().Dy() != tc.height {
+				t.Fatalf("Expected %dx%d, got %v", tc.width, tc.height, img.Bounds())
+			}
+			if r, g, _, _ := img.At(tc.redX, tc.redY).RGBA(); r < 0xC000 || g > 0x4000 {
+				t.Errorf("Expected the red pixel at %d,%d", tc.redX, tc.redY)
+			}
+		})
+	}
+}
+
+// TestNormalizePNG tests stripping PNG text chunks while keeping the image
+func TestNormalizePNG(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage())
+	data := buf.Bytes()
+
+	text := []byte("Comment\x00taken at 52.37N 4.89E")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(append([]byte("tEXt"), text...)))
+	// Insert the chunk after IHDR: 8 bytes of signature and a 25 byte chunk
+	withText := append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+	path := writeImage(t, "render.png", withText)
+
+	if _, err := New(Options{StripMetadata: true}).Normalize(path); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	stripped, _ := os.ReadFile(path)
+	if !bytes.Equal(stripped, data) {
+		t.Error("Expected the text chunk removed and everything else kept")
+	}
+}
+
+// TestNormalizeWebP tests stripping WebP EXIF and XMP chunks and their flags
+func TestNormalizeWebP(t *testing.T) {
+	chunk := func(fourcc string, payload []byte) []byte {
+		out := append([]byte(fourcc), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	vp8x := chunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP, 0, 0, 0, 3, 0, 0, 1, 0, 0})
+	body := append([]byte("WEBP"), vp8x...)
+	body = append(body, chunk("VP8L", []byte("pixels"))...)
+	body = append(body, chunk("EXIF", []byte("GPS 52.37N"))...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	path := writeImage(t, "photo.webp", append(data, body...))
+
+	if _, err := New(Options{StripMetadata: true}).Normalize(path); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	stripped, _ := os.ReadFile(path)
+	if bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("xmpmeta")) {
+		t.Error("Expected EXIF and XMP chunks to be removed")
+	}
+	if !bytes.Contains(stripped, []byte("pixels")) {
+		t.Error("Expected the image chunk to be kept")
+	}
+	if stripped[20]&(webpFlagEXIF|webpFlagXMP) != 0 {
+		t.Error("Expected the metadata flags to be cleared")
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+		t.Errorf("Expected RIFF size %d, got %d", len(stripped)-8, size)
+	}
+}
+
+// TestNormalizeConvertsToWebP tests conversion through the configured encoder
+func TestNormalizeConvertsToWebP(t *testing.T) {
+	// Stands in for cwebp: -quiet -metadata none -q N input -o output
+	encoder := filepath.Join(t.TempDir(), "fake-cwebp")
+	if err := os.WriteFile(encoder, []byte("#!/bin/sh\ncp \"$6\" \"$8\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write encoder: %v", err)
+	}
+	path := writeImage(t, "photo.jpg", jpegWithSegments(t, exifSegment(1)))
+
+	result, err := New(Options{WebPQuality: 80, WebPEncoder: encoder}).Normalize(path)
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if filepath.Ext(result) != ".webp" {
+		t.Fatalf("Expected a WebP result, got %s", result)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the original to be replaced")
+	}
+	converted, _ := os.ReadFile(result)
+	if bytes.Contains(converted, []byte("Exif")) {
+		t.Error("Expected converted images to be stripped")
+	}
+
+	// A missing encoder fails conversion and keeps the original
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage())
+	path = writeImage(t, "other.png", buf.Bytes())
+	if _, err := New(Options{WebPQuality: 80, WebPEncoder: filepath.Join(t.TempDir(), "missing")}).Normalize(path); err == nil {
+		t.Error("Expected an error for a missing encoder")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the original kept after a failed conversion: %v", err)
+	}
+}
+
+// TestNormalizeDisabled tests that a disabled or nil normalizer leaves files untouched
+func TestNormalizeDisabled(t *testing.T) {
+	data := jpegWithSegments(t, exifSegment(6))
+	path := writeImage(t, "photo.jpg", data)
+
+	var nilNormalizer *Normalizer
+	for _, n := range []*Normalizer{nilNormalizer, New(Options{})} {
+		if _, err := n.Normalize(path); err != nil {
+			t.Fatalf("Normalize failed: %v", err)
+		}
+		if content, _ := os.ReadFile(path); !bytes.Equal(content, data) {
+			t.Error("Expected the image to be left untouched")
+		}
+	}
+}
+
+// TestNormalizeDir tests normalizing a directory, skipping hidden entries and other files
+// and carrying on past a broken image
+func TestNormalizeDir(t *testing.T) {
+	dir := t.TempDir()
+	data := jpegWithSegments(t, exifSegment(1))
+	os.MkdirAll(filepath.Join(dir, "images"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".hidden"), 0755)
+	os.WriteFile(filepath.Join(dir, "images", "photo.jpg"), data, 0644)
+	os.WriteFile(filepath.Join(dir, ".hidden", "photo.jpg"), data, 0644)
+	os.WriteFile(filepath.Join(dir, "model.stl"), []byte("solid"), 0644)
+	os.WriteFile(filepath.Join(dir, "images", "broken.jpg"), []byte("not a jpeg"), 0644)
+
+	if err := New(Options{StripMetadata: true}).NormalizeDir(dir); err == nil {
+		t.Error("Expected the broken image to be reported")
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(dir, "images", "photo.jpg")); bytes.Contains(content, []byte("Exif")) {
+		t.Error("Expected images to be stripped")
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, ".hidden", "photo.jpg")); !bytes.Equal(content, data) {
+		t.Error("Expected hidden directories to be skipped")
+	}
+}
@@ -0,0 +1,325 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+)
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+
+	errTruncated = errors.New("truncated image")
+)
+
+// pngMetadataChunks are the PNG chunks carrying text, EXIF or timestamps
+var pngMetadataChunks = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+// VP8X flags announcing metadata chunks in an extended WebP
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// stripFile removes the metadata of the image at path, detecting its format
+// from its content, and replaces the file only when something was removed
+func stripFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var stripped []byte
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		stripped, err = stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		stripped, err = stripPNG(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		stripped, err = stripWebP(data)
+	default:
+		return errors.New("unrecognized image format")
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(stripped, data) {
+		return nil
+	}
+	return replaceFile(path, stripped)
+}
+
+// replaceFile writes data next to path and moves it into place, keeping the file's mode
+func replaceFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// stripJPEG drops EXIF, XMP, IPTC and comment segments. JPEGs with an EXIF
+// orientation are decoded, turned upright and re-encoded, as dropping the
+// orientation alone would show them sideways; the rest keep their pixels.
+func stripJPEG(data []byte) ([]byte, error) {
+	orientation, err := jpegOrientation(data)
+	if err != nil {
+		return nil, err
+	}
+	if orientation > 1 {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	out := append([]byte{}, jpegSignature...)
+	err = eachJPEGSegment(data, func(marker byte, segment []byte) {
+		if keepJPEGSegment(marker, segment) {
+			out = append(out, segment...)
+		}
+	}, func(rest []byte) {
+		out = append(out, rest...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// keepJPEGSegment reports whether a segment describes the image rather than
+// where, when or with what it was taken: JFIF, ICC color profiles and Adobe
+// color transforms are kept, other application segments and comments dropped
+func keepJPEGSegment(marker byte, segment []byte) bool {
+	switch {
+	case marker == 0xE0, marker == 0xEE:
+		return true
+	case marker == 0xE2:
+		return len(segment) >= 16 && string(segment[4:16]) == "ICC_PROFILE\x00"
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false
+	}
+	return true
+}
+
+// eachJPEGSegment calls segment for each marker segment before the scan data,
+// including its marker, and rest with everything from the start of scan on
+func eachJPEGSegment(data []byte, segment func(marker byte, segment []byte), rest func([]byte)) error {
+	i := len(jpegSignature)
+	for i+2 <= len(data) {
+		if data[i] != 0xFF {
+			return errors.New("invalid JPEG marker")
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0xDA, marker == 0xD9:
+			// Start of scan or end of image: the rest is image data
+			rest(data[i:])
+			return nil
+		case marker == 0x01, marker >= 0xD0 && marker <= 0xD7:
+			// Markers without a length
+			segment(marker, data[i:i+2])
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return errTruncated
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return errTruncated
+		}
+		segment(marker, data[i:i+2+length])
+		i += 2 + length
+	}
+	return errTruncated
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 when it has none
+func jpegOrientation(data []byte) (int, error) {
+	orientation := 1
+	err := eachJPEGSegment(data, func(marker byte, segment []byte) {
+		if marker == 0xE1 && len(segment) > 10 && string(segment[4:10]) == "Exif\x00\x00" {
+			orientation = exifOrientation(segment[10:])
+		}
+	}, func([]byte) {})
+	return orientation, err
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data,
+// returning 1 when it is missing or out of range
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	offset := int64(order.Uint32(tiff[4:]))
+	if offset+2 > int64(len(tiff)) {
+		return 1
+	}
+	entries := int64(order.Uint16(tiff[offset:]))
+	for k := int64(0); k < entries; k++ {
+		entry := offset + 2 + 12*k
+		if entry+12 > int64(len(tiff)) {
+			break
+		}
+		const orientationTag, shortType = 0x0112, 3
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:]) != shortType {
+			return 1
+		}
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient returns img turned upright for its EXIF orientation
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Orientations 5 to 8 are a quarter turn, swapping width and height
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// stripPNG drops text, EXIF and timestamp chunks
+func stripPNG(data []byte) ([]byte, error) {
+	out := append([]byte{}, pngSignature...)
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errTruncated
+		}
+		length := int64(binary.BigEndian.Uint32(data[i:]))
+		end := int64(i) + 12 + length
+		if end > int64(len(data)) {
+			return nil, errTruncated
+		}
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		if string(data[i+4:i+8]) == "IEND" {
+			return out, nil
+		}
+		i = int(end)
+	}
+	return nil, errTruncated
+}
+
+// stripWebP drops EXIF and XMP chunks and clears the flags announcing them
+func stripWebP(data []byte) ([]byte, error) {
+	out := append([]byte{}, data[:12]...)
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errTruncated
+		}
+		fourcc := string(data[i : i+4])
+		size := int64(binary.LittleEndian.Uint32(data[i+4:]))
+		// Chunks are padded to an even size
+		end := int64(i) + 8 + size + size%2
+		if end > int64(len(data)) {
+			return nil, errTruncated
+		}
+
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = int(end)
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,457 @@
+package importer
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// archiveDir is the hidden directory of the scan path export archives are
+	// kept and unpacked in while they are imported
+	archiveDir = ".imports"
+
+	// maxArchiveEntries and maxArchiveBytes bound what an export may unpack to
+	maxArchiveEntries = 100000
+	maxArchiveBytes   = 64 << 30 // 64GB
+)
+
+// ErrUnsupportedArchive is returned when an archive isn't an export of a known tool
+var ErrUnsupportedArchive = errors.New("unsupported export archive")
+
+// ArchiveModel is one model of an export archive with the metadata the tool
+// kept for it
+type ArchiveModel struct {
+	// ID identifies the model within the export
+	ID          string
+	Name        string
+	Description string
+	Tags        []string
+	License     string
+	Designer    string
+	Source      string
+
+	// Collection is the collection the model is grouped into; models of
+	// collections with the same CollectionKey share one
+	Collection    string
+	CollectionKey string
+
+	// Files are the model's files by their path in the export; they keep
+	// their path below Dir in the project
+	Dir   string
+	Files []string
+}
+
+// ArchiveFormat reads the export archives of another library tool
+type ArchiveFormat interface {
+	// Name identifies the format in import jobs and requests
+	Name() string
+	// Detect reports whether an archive with these entries is in this format
+	Detect(names []string) bool
+	// Read lists the models of an export unpacked at root
+	Read(root string) ([]ArchiveModel, error)
+}
+
+// archiveFormats are the exports StartArchive reads, tried in order when the
+// format isn't given
+var archiveFormats = []ArchiveFormat{manyfold{}, printables{}}
+
+// archiveFormat returns the format named name, or nil
+func archiveFormat(name string) ArchiveFormat {
+	for _, format := range archiveFormats {
+		if format.Name() == name {
+			return format
+		}
+	}
+	return nil
+}
+
+// StartArchive keeps an uploaded export archive and imports its models in the
+// background, keeping their tags, creators, licenses and collections. format
+// names the tool that exported it, or is empty to detect it; archives in no
+// known format fail with ErrUnsupportedArchive.
+func (i *Importer) StartArchive(archive io.Reader, filename, format string) (*models.ImportJob, error) {
+	dir := filepath.Join(i.scanPath, archiveDir)
+	if err := fileperm.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(dir, "export-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(file, archive)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	detected, err := detectArchive(file.Name(), format)
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	job := models.ImportJob{
+		SourceURL: filepath.Base(filename),
+		Provider:  detected.Name(),
+		Status:    models.ImportJobPending,
+		Archive:   file.Name(),
+	}
+	if err := i.db.Create(&job).Error; err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	if err := i.launch(job.ID); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// detectArchive returns the format of the archive at path: the one named
+// format, checked against the archive, or the first that recognises it
+func detectArchive(path, format string) (ArchiveFormat, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedArchive, err)
+	}
+	defer reader.Close()
+
+	names := make([]string, 0, len(reader.File))
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+
+	if format != "" {
+		named := archiveFormat(strings.ToLower(format))
+		if named == nil {
+			return nil, fmt.Errorf("%w: unknown format %s", ErrUnsupportedArchive, format)
+		}
+		if !named.Detect(names) {
+			return nil, fmt.Errorf("%w: not a %s export", ErrUnsupportedArchive, named.Name())
+		}
+		return named, nil
+	}
+	for _, candidate := range archiveFormats {
+		if candidate.Detect(names) {
+			return candidate, nil
+		}
+	}
+	return nil, ErrUnsupportedArchive
+}
+
+// runArchive unpacks the job's archive on first run, then imports every
+// pending model. The export is read again on each run, so resumed jobs find
+// their models' metadata; it is removed once every model is imported.
+func (i *Importer) runArchive(ctx context.Context, job *models.ImportJob) error {
+	format := archiveFormat(job.Provider)
+	if format == nil {
+		return i.fail(job, fmt.Errorf("unknown export format %s", job.Provider))
+	}
+
+	job.Status = models.ImportJobRunning
+	if err := i.db.Save(job).Error; err != nil {
+		return err
+	}
+
+	root := strings.TrimSuffix(job.Archive, filepath.Ext(job.Archive))
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		if err := unpackArchive(job.Archive, root); err != nil {
+			return i.fail(job, err)
+		}
+	}
+	exported, err := format.Read(root)
+	if err != nil {
+		return i.fail(job, err)
+	}
+
+	if job.Total == 0 {
+		if len(exported) == 0 {
+			return i.fail(job, errors.New("export has no models"))
+		}
+		if err := i.recordArchiveItems(job, exported); err != nil {
+			return i.fail(job, err)
+		}
+	}
+	byID := make(map[string]ArchiveModel, len(exported))
+	for _, model := range exported {
+		byID[model.ID] = model
+	}
+
+	var items []models.ImportItem
+	if err := i.db.Where("job_id = ? AND status = ?", job.ID, models.ImportItemPending).
+		Order("id ASC").Find(&items).Error; err != nil {
+		return i.fail(job, err)
+	}
+
+	for idx := range items {
+		item := &items[idx]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		model, ok := byID[item.RemoteID]
+		err := fmt.Errorf("model %s is no longer in the export", item.RemoteID)
+		if ok {
+			err = i.importArchiveModel(job, root, model, item)
+		}
+		if err != nil {
+			item.Status = models.ImportItemFailed
+			item.Error = err.Error()
+			job.Failed++
+		} else {
+			item.Status = models.ImportItemImported
+			job.Imported++
+		}
+
+		if err := i.db.Save(item).Error; err != nil {
+			return err
+		}
+		if err := i.db.Save(job).Error; err != nil {
+			return err
+		}
+	}
+
+	// Failed models are retried from the export, so it is kept until none are left
+	if job.Failed == 0 {
+		if err := os.RemoveAll(root); err != nil {
+			fmt.Printf("Warning: Failed to remove unpacked export %s: %v\n", root, err)
+		}
+		if err := os.Remove(job.Archive); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: Failed to remove export archive %s: %v\n", job.Archive, err)
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = models.ImportJobCompleted
+	job.FinishedAt = &finishedAt
+	return i.db.Save(job).Error
+}
+
+// recordArchiveItems stores an item on the job for every exported model
+func (i *Importer) recordArchiveItems(job *models.ImportJob, exported []ArchiveModel) error {
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range exported {
+			item := models.ImportItem{
+				JobID:    job.ID,
+				RemoteID: model.ID,
+				Name:     model.Name,
+				URL:      model.Source,
+				Status:   models.ImportItemPending,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		job.Total = len(exported)
+		return tx.Save(job).Error
+	})
+}
+
+// importArchiveModel moves one exported model into its own project directory,
+// under its collection's, and registers it with the export's metadata
+func (i *Importer) importArchiveModel(job *models.ImportJob, root string, model ArchiveModel, item *models.ImportItem) error {
+	if len(model.Files) == 0 {
+		return errors.New("model has no files in the export")
+	}
+
+	group := model.Collection
+	if group == "" {
+		group = job.Provider
+	}
+	collectionDir := filepath.Join(i.scanPath, i.dirNaming.ImportedDirName(group))
+	projectDir := filepath.Join(collectionDir, i.dirNaming.ImportedDirName(model.Name)+"_"+i.dirNaming.ImportedDirName(model.ID))
+	if err := fileperm.MkdirAll(collectionDir); err != nil {
+		return err
+	}
+
+	stagingDir, err := stage(projectDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	depth := 0
+	for _, file := range model.Files {
+		rel, err := filepath.Rel(filepath.FromSlash(model.Dir), filepath.FromSlash(file))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			rel = path.Base(file)
+		}
+		depth = max(depth, strings.Count(rel, string(filepath.Separator)))
+		dest := filepath.Join(stagingDir, rel)
+		if err := fileperm.MkdirAll(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		// The unpacked export is on the library's filesystem, so files are linked rather than copied
+		if err := fsutil.LinkOrCopy(filepath.Join(root, filepath.FromSlash(file)), dest); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	project, err := i.adopt(stagingDir, projectDir, model.Name)
+	if err != nil {
+		return err
+	}
+	// Exports keep models' files in subdirectories, which the scan has to reach
+	if project.ScanSettings.MaxDepth < depth {
+		project.ScanSettings.MaxDepth = min(depth, models.MaxScanDepth)
+		if err := i.db.Model(project).Select("scan_settings").Updates(project).Error; err != nil {
+			return err
+		}
+		if project, err = i.scanner.ImportProject(project.Path); err != nil {
+			return err
+		}
+	}
+
+	if model.Collection != "" {
+		collection := models.Collection{Name: model.Collection}
+		if err := i.db.Where(models.Collection{SourceURL: model.CollectionKey}).FirstOrCreate(&collection).Error; err != nil {
+			return err
+		}
+		project.CollectionID = &collection.ID
+	}
+	if model.Name != "" {
+		project.Name = model.Name
+	}
+	if model.Description != "" {
+		project.Description = model.Description
+	}
+	if len(model.Tags) > 0 {
+		project.Tags = model.Tags
+	}
+	if model.License != "" {
+		project.License = model.License
+	}
+	if model.Designer != "" {
+		project.Designer = model.Designer
+	}
+	if model.Source != "" {
+		project.Source = model.Source
+	}
+	if err := i.db.Save(project).Error; err != nil {
+		return err
+	}
+	if err := i.scanner.RefreshSidecar(project); err != nil {
+		fmt.Printf("Warning: Failed to write sidecar for %s: %v\n", project.Path, err)
+	}
+
+	item.ProjectID = &project.ID
+	item.Error = ""
+	return nil
+}
+
+// unpackArchive extracts a zip archive into dest, refusing entries that would
+// land outside it and archives that unpack to more than maxArchiveBytes.
+// Entries are written to a hidden directory first, so a failed unpack leaves
+// nothing at dest.
+func unpackArchive(archive, dest string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if len(reader.File) > maxArchiveEntries {
+		return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+
+	unpacking := filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".unpacking")
+	if err := os.RemoveAll(unpacking); err != nil {
+		return err
+	}
+	defer os.RemoveAll(unpacking)
+
+	var total int64
+	for _, file := range reader.File {
+		name := path.Clean(strings.ReplaceAll(file.Name, "\\", "/"))
+		if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q has an unsafe path", file.Name)
+		}
+		target := filepath.Join(unpacking, filepath.FromSlash(name))
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		written, err := unpackEntry(file, target, maxArchiveBytes-total)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		total += written
+	}
+
+	return os.Rename(unpacking, dest)
+}
+
+// unpackEntry writes one archive entry to target, failing past limit bytes
+func unpackEntry(file *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	out, err := os.Create(target)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := io.Copy(out, io.LimitReader(src, limit+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > limit {
+		err = fmt.Errorf("archive unpacks to more than %d bytes", int64(maxArchiveBytes))
+	}
+	return written, err
+}
+
+// exportFiles lists the regular files below dir, relative to root, leaving
+// out hidden files and archiver metadata
+func exportFiles(root, dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(filepath.Join(root, filepath.FromSlash(dir)), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") || d.Name() == "__MACOSX" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
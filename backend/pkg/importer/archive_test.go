@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// exportArchive zips files, keyed by their path in the archive
+func exportArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		entry, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create archive entry: %v", err)
+		}
+		entry.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return &buf
+}
+
+func TestImportManyfoldArchive(t *testing.T) {
+	imp, db, scanPath := newTestImporter(t, &fakeSource{})
+	archive := exportArchive(t, map[string]string{
+		"library/Gear/datapackage.json": `{
+			"name": "gear", "title": "Fidget Gear", "description": "Spins",
+			"keywords": ["fidget", "desk"],
+			"licenses": [{"name": "CC-BY-4.0"}],
+			"contributors": [{"title": "Printer Pal", "roles": ["contributor"]}, {"title": "Gear Maker", "roles": ["creator"]}],
+			"links": [{"path": "https://models.example.com/gear"}],
+			"collections": [{"title": "Desk Toys"}],
+			"resources": [{"path": "gear.stl"}, {"path": "parts/tooth.stl"}, {"path": "../escape.stl"}]
+		}`,
+		"library/Gear/gear.stl":           "solid gear",
+		"library/Gear/parts/tooth.stl":    "solid tooth",
+		"library/Gear/notes.txt":          "not a resource",
+		"library/Rocket/datapackage.json": `{"name": "rocket"}`,
+		"library/Rocket/rocket.3mf":       "rocket",
+		"library/Rocket/.DS_Store":        "finder",
+		"__MACOSX/library/._rocket.3mf":   "resource fork",
+	})
+
+	job, err := imp.StartArchive(archive, "/tmp/manyfold-export.zip", "")
+	if err != nil {
+		t.Fatalf("StartArchive failed: %v", err)
+	}
+	if job.Provider != "manyfold" || job.SourceURL != "manyfold-export.zip" {
+		t.Errorf("Expected a manyfold job for the upload, got %+v", job)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobCompleted || result.Total != 2 || result.Imported != 2 || result.Failed != 0 {
+		t.Fatalf("Unexpected job result: %+v %+v", result, result.Items)
+	}
+
+	var gear models.Project
+	if err := db.Preload("Files").Where("name = ?", "Fidget Gear").First(&gear).Error; err != nil {
+		t.Fatalf("Expected the gear project: %v", err)
+	}
+	if gear.Description != "Spins" || strings.Join(gear.Tags, ",") != "fidget,desk" || gear.License != "CC-BY-4.0" ||
+		gear.Designer != "Gear Maker" || gear.Source != "https://models.example.com/gear" {
+		t.Errorf("Expected the package metadata on the project, got %+v", gear)
+	}
+	if len(gear.Files) != 2 || filepath.Dir(gear.Path) != filepath.Join(scanPath, "Desk_Toys") {
+		t.Errorf("Expected the 2 listed files under the collection directory, got %s with %d files", gear.Path, len(gear.Files))
+	}
+	var collection models.Collection
+	if gear.CollectionID == nil || db.First(&collection, *gear.CollectionID).Error != nil || collection.Name != "Desk Toys" {
+		t.Errorf("Expected the project in the Desk Toys collection, got %+v", collection)
+	}
+
+	var rocket models.Project
+	if err := db.Preload("Files").Where("name = ?", "rocket").First(&rocket).Error; err != nil {
+		t.Fatalf("Expected the rocket project: %v", err)
+	}
+	if rocket.CollectionID != nil || filepath.Dir(rocket.Path) != filepath.Join(scanPath, "manyfold") || len(rocket.Files) != 1 {
+		t.Errorf("Expected the uncollected model with its one file under the provider directory, got %+v", rocket)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Join(scanPath, archiveDir)); len(entries) != 0 {
+		t.Errorf("Expected the export to be removed once imported, got %d entries", len(entries))
+	}
+}
+
+func TestImportPrintablesArchive(t *testing.T) {
+	imp, db, scanPath := newTestImporter(t, &fakeSource{})
+	archive := exportArchive(t, map[string]string{
+		"export/models.json": `{"models": [
+			{"id": 123, "name": "Benchy", "summary": "Boat",
+			 "tags": [{"name": "calibration"}, "boat"],
+			 "license": {"name": "Creative Commons - Attribution", "abbreviation": "CC BY"},
+			 "user": {"publicUsername": "boatmaker"},
+			 "collections": [{"name": "Tests"}]},
+			{"id": "456", "name": "Lost Model"}
+		]}`,
+		"export/123-benchy/benchy.stl": "solid benchy",
+	})
+
+	job, err := imp.StartArchive(archive, "printables.zip", "printables")
+	if err != nil {
+		t.Fatalf("StartArchive failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobCompleted || result.Total != 2 || result.Imported != 1 || result.Failed != 1 {
+		t.Fatalf("Unexpected job result: %+v", result)
+	}
+
+	var benchy models.Project
+	if err := db.Where("name = ?", "Benchy").First(&benchy).Error; err != nil {
+		t.Fatalf("Expected the Benchy project: %v", err)
+	}
+	if benchy.Description != "Boat" || strings.Join(benchy.Tags, ",") != "calibration,boat" || benchy.License != "CC BY" ||
+		benchy.Designer != "boatmaker" || benchy.Source != "https://www.printables.com/model/123" {
+		t.Errorf("Expected the listed metadata on the project, got %+v", benchy)
+	}
+	if benchy.Path != filepath.Join(scanPath, "Tests", "Benchy_123") {
+		t.Errorf("Expected the project under its collection, got %s", benchy.Path)
+	}
+
+	// The export is kept so the failed model can be retried
+	if entries, _ := os.ReadDir(filepath.Join(scanPath, archiveDir)); len(entries) != 2 {
+		t.Errorf("Expected the archive and its unpacked export to be kept, got %d entries", len(entries))
+	}
+	for _, item := range result.Items {
+		if item.RemoteID == "456" && (item.Status != models.ImportItemFailed || item.Error == "") {
+			t.Errorf("Expected the model without files to fail, got %+v", item)
+		}
+	}
+}
+
+func TestStartArchiveUnsupported(t *testing.T) {
+	imp, db, scanPath := newTestImporter(t, &fakeSource{})
+
+	for name, tc := range map[string]struct {
+		archive *bytes.Buffer
+		format  string
+	}{
+		"not a zip":      {bytes.NewBufferString("plain text"), ""},
+		"unknown export": {exportArchive(t, map[string]string{"model.stl": "solid"}), ""},
+		"unknown format": {exportArchive(t, map[string]string{"models.json": "[]"}), "cults"},
+		"wrong format":   {exportArchive(t, map[string]string{"models.json": "[]"}), "manyfold"},
+	} {
+		if _, err := imp.StartArchive(tc.archive, "export.zip", tc.format); !errors.Is(err, ErrUnsupportedArchive) {
+			t.Errorf("%s: expected ErrUnsupportedArchive, got %v", name, err)
+		}
+	}
+
+	var count int64
+	db.Model(&models.ImportJob{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no jobs, got %d", count)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(scanPath, archiveDir)); len(entries) != 0 {
+		t.Errorf("Expected rejected archives to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestUnpackArchiveRejectsUnsafePaths(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "export.zip")
+	os.WriteFile(archive, exportArchive(t, map[string]string{"models.json": "[]", "../escape.stl": "solid"}).Bytes(), 0644)
+
+	dest := filepath.Join(dir, "export")
+	if err := unpackArchive(archive, dest); err == nil {
+		t.Fatal("Expected an unsafe entry to be rejected")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing unpacked, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.stl")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the export, got %v", err)
+	}
+}
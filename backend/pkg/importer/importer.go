@@ -0,0 +1,497 @@
+package importer
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/imaging"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/naming"
+	"3dshelf/pkg/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultRetryAfter is how long to wait when a rate limited API gives no Retry-After
+	defaultRetryAfter = time.Minute
+
+	// maxRateLimitRetries is how many times a rate limited request is retried
+	// before the item fails, so a source that keeps refusing doesn't hold a worker
+	maxRateLimitRetries = 5
+
+	// JobType identifies import runs in the job queue
+	JobType = "import"
+)
+
+var (
+	// ErrUnsupportedURL is returned when no source recognises a collection URL
+	ErrUnsupportedURL = errors.New("unsupported collection URL")
+	// ErrJobActive is returned when resuming a job that is still running
+	ErrJobActive = errors.New("import job is already running")
+	// ErrJobFinished is returned when resuming a job with nothing left to import
+	ErrJobFinished = errors.New("import job has nothing left to import")
+)
+
+// RemoteItem is one model listed in a remote collection
+type RemoteItem struct {
+	ID   string
+	Name string
+	URL  string
+}
+
+// RemoteCollection is a remote collection or list and the models it contains
+type RemoteCollection struct {
+	Name  string
+	Items []RemoteItem
+}
+
+// Source fetches collections from one model-sharing site
+type Source interface {
+	// Name identifies the source in import jobs
+	Name() string
+	// Matches reports whether the URL points at a collection on this site
+	Matches(u *url.URL) bool
+	// FetchCollection lists a collection's models
+	FetchCollection(ctx context.Context, rawURL string) (*RemoteCollection, error)
+	// Download saves a model's files into dir
+	Download(ctx context.Context, item RemoteItem, dir string) error
+}
+
+// RateLimitError is returned by sources when the remote API asks the client to back off
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+}
+
+// Importer runs collection imports on the job queue. Each model becomes its own
+// project under <scan path>/<collection>/, grouped into a Collection. Progress is
+// stored per item, so interrupted or rate limited jobs continue where they stopped.
+// Export archives of other library tools are imported the same way through
+// StartArchive.
+type Importer struct {
+	db       *gorm.DB
+	queue    *jobs.Queue
+	scanner  *scanner.Scanner
+	scanPath string
+	sources  []Source
+
+	// images normalizes downloaded images; nil leaves them as downloaded
+	images *imaging.Normalizer
+
+	// dirNaming turns collection and model names into directory names
+	dirNaming naming.Policy
+}
+
+// jobPayload is the queued job's reference to the import job it runs
+type jobPayload struct {
+	ImportJobID uint `json:"import_job_id"`
+}
+
+// New creates an Importer storing projects under scanPath and registers its
+// runs on queue. Failed items are retried through Resume, so the queue does
+// not retry runs itself.
+func New(db *gorm.DB, queue *jobs.Queue, scanner *scanner.Scanner, scanPath string, sources ...Source) *Importer {
+	i := &Importer{
+		db:       db,
+		queue:    queue,
+		scanner:  scanner,
+		scanPath: scanPath,
+		sources:  sources,
+
+		dirNaming: naming.Default(),
+	}
+	queue.Register(JobType, jobs.NoRetry, i.runJob)
+	return i
+}
+
+// SetImageNormalizer strips and converts the images of imported models
+func (i *Importer) SetImageNormalizer(images *imaging.Normalizer) {
+	i.images = images
+}
+
+// SetDirNaming sets how collection and model names become directory names
+func (i *Importer) SetDirNaming(policy naming.Policy) {
+	i.dirNaming = policy
+}
+
+// Start creates an import job for a collection URL and runs it in the background
+func (i *Importer) Start(rawURL string) (*models.ImportJob, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrUnsupportedURL
+	}
+
+	var source Source
+	for _, candidate := range i.sources {
+		if candidate.Matches(u) {
+			source = candidate
+			break
+		}
+	}
+	if source == nil {
+		return nil, ErrUnsupportedURL
+	}
+
+	job := models.ImportJob{
+		SourceURL: u.String(),
+		Provider:  source.Name(),
+		Status:    models.ImportJobPending,
+	}
+	if err := i.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	if err := i.launch(job.ID); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Resume restarts an interrupted job, retrying its failed items
+func (i *Importer) Resume(jobID uint) (*models.ImportJob, error) {
+	queued, err := i.queued(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if queued != nil {
+		return nil, ErrJobActive
+	}
+
+	var job models.ImportJob
+	if err := i.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if job.Status == models.ImportJobCompleted && job.Failed == 0 {
+		return nil, ErrJobFinished
+	}
+
+	if err := i.db.Model(&models.ImportItem{}).
+		Where("job_id = ? AND status = ?", job.ID, models.ImportItemFailed).
+		Updates(map[string]interface{}{"status": models.ImportItemPending, "error": ""}).Error; err != nil {
+		return nil, err
+	}
+
+	job.Status = models.ImportJobPending
+	job.Failed = 0
+	job.Error = ""
+	job.FinishedAt = nil
+	if err := i.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+
+	if err := i.launch(job.ID); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ResumeUnfinished restarts jobs interrupted by a shutdown
+func (i *Importer) ResumeUnfinished() error {
+	var jobs []models.ImportJob
+	if err := i.db.Where("status IN ?", []models.ImportJobStatus{
+		models.ImportJobPending, models.ImportJobRunning, models.ImportJobRateLimited,
+	}).Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := i.launch(job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the job's current run finishes
+func (i *Importer) Wait(jobID uint) {
+	if queued, err := i.queued(jobID); err == nil && queued != nil {
+		i.queue.Wait(queued.ID)
+	}
+}
+
+// queued returns the queued or running job for an import job, or nil when it has none
+func (i *Importer) queued(jobID uint) (*models.Job, error) {
+	return i.queue.Unfinished(JobType, jobKey(jobID))
+}
+
+// launch queues a run of the job unless one is already queued or running
+func (i *Importer) launch(jobID uint) error {
+	_, err := i.queue.EnqueueOnce(JobType, jobKey(jobID), jobPayload{ImportJobID: jobID})
+	return err
+}
+
+// runJob runs the import job a queued job refers to
+func (i *Importer) runJob(ctx context.Context, queued *models.Job) error {
+	var payload jobPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return err
+	}
+	return i.run(ctx, payload.ImportJobID)
+}
+
+// jobKey deduplicates queued runs of one import job
+func jobKey(jobID uint) string {
+	return strconv.FormatUint(uint64(jobID), 10)
+}
+
+// run lists the collection on first run, then imports every pending item
+func (i *Importer) run(ctx context.Context, jobID uint) error {
+	var job models.ImportJob
+	if err := i.db.First(&job, jobID).Error; err != nil {
+		return err
+	}
+	if job.Archive != "" {
+		return i.runArchive(ctx, &job)
+	}
+
+	var source Source
+	for _, candidate := range i.sources {
+		if candidate.Name() == job.Provider {
+			source = candidate
+			break
+		}
+	}
+	if source == nil {
+		return i.fail(&job, fmt.Errorf("no source configured for %s", job.Provider))
+	}
+
+	job.Status = models.ImportJobRunning
+	if err := i.db.Save(&job).Error; err != nil {
+		return err
+	}
+
+	if job.CollectionID == nil {
+		var remote *RemoteCollection
+		err := i.retryRateLimited(ctx, &job, func() (err error) {
+			remote, err = source.FetchCollection(ctx, job.SourceURL)
+			return err
+		})
+		if ctx.Err() != nil {
+			// Shutting down; the job runs again from where it stopped
+			return ctx.Err()
+		}
+		if err != nil {
+			return i.fail(&job, err)
+		}
+		if err := i.recordCollection(&job, remote); err != nil {
+			return i.fail(&job, err)
+		}
+	}
+
+	var collection models.Collection
+	if err := i.db.First(&collection, *job.CollectionID).Error; err != nil {
+		return i.fail(&job, err)
+	}
+
+	var items []models.ImportItem
+	if err := i.db.Where("job_id = ? AND status = ?", job.ID, models.ImportItemPending).
+		Order("id ASC").Find(&items).Error; err != nil {
+		return i.fail(&job, err)
+	}
+
+	for idx := range items {
+		item := &items[idx]
+
+		err := i.retryRateLimited(ctx, &job, func() error {
+			return i.importItem(ctx, source, &collection, item)
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			item.Status = models.ImportItemFailed
+			item.Error = err.Error()
+			job.Failed++
+		} else {
+			item.Status = models.ImportItemImported
+			job.Imported++
+		}
+
+		if err := i.db.Save(item).Error; err != nil {
+			return err
+		}
+		if err := i.db.Save(&job).Error; err != nil {
+			return err
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = models.ImportJobCompleted
+	job.FinishedAt = &finishedAt
+	return i.db.Save(&job).Error
+}
+
+// recordCollection stores the listed collection and its items on the job
+func (i *Importer) recordCollection(job *models.ImportJob, remote *RemoteCollection) error {
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		collection := models.Collection{Name: remote.Name}
+		if err := tx.Where(models.Collection{SourceURL: job.SourceURL}).FirstOrCreate(&collection).Error; err != nil {
+			return err
+		}
+
+		for _, remoteItem := range remote.Items {
+			item := models.ImportItem{
+				JobID:    job.ID,
+				RemoteID: remoteItem.ID,
+				Name:     remoteItem.Name,
+				URL:      remoteItem.URL,
+				Status:   models.ImportItemPending,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		job.CollectionID = &collection.ID
+		job.Total = len(remote.Items)
+		return tx.Save(job).Error
+	})
+}
+
+// importItem downloads one model into its own project directory and registers it
+func (i *Importer) importItem(ctx context.Context, source Source, collection *models.Collection, item *models.ImportItem) error {
+	collectionDir := filepath.Join(i.scanPath, i.dirNaming.ImportedDirName(collection.Name))
+	projectDir := filepath.Join(collectionDir, i.dirNaming.ImportedDirName(item.Name)+"_"+i.dirNaming.ImportedDirName(item.RemoteID))
+
+	// Download into a hidden directory the scanner ignores, then move it into place
+	stagingDir, err := stage(projectDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	remoteItem := RemoteItem{ID: item.RemoteID, Name: item.Name, URL: item.URL}
+	if err := source.Download(ctx, remoteItem, stagingDir); err != nil {
+		return err
+	}
+	project, err := i.adopt(stagingDir, projectDir, item.Name)
+	if err != nil {
+		return err
+	}
+
+	project.CollectionID = &collection.ID
+	if item.Name != "" {
+		project.Name = item.Name
+	}
+	if project.Source == "" {
+		project.Source = item.URL
+	}
+	if err := i.db.Save(project).Error; err != nil {
+		return err
+	}
+	if err := i.scanner.RefreshSidecar(project); err != nil {
+		fmt.Printf("Warning: Failed to write sidecar for %s: %v\n", project.Path, err)
+	}
+
+	item.ProjectID = &project.ID
+	item.Error = ""
+	return nil
+}
+
+// stage creates the empty hidden directory a model destined for projectDir is
+// gathered in, next to it so moving it into place is a rename
+func stage(projectDir string) (string, error) {
+	stagingDir := filepath.Join(filepath.Dir(projectDir), "."+filepath.Base(projectDir)+".importing")
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return "", err
+	}
+	if err := fileperm.MkdirAll(stagingDir); err != nil {
+		return "", err
+	}
+	return stagingDir, nil
+}
+
+// adopt moves a gathered model into place at projectDir and scans it in.
+// A directory already there is left alone and the model gets a numbered one.
+func (i *Importer) adopt(stagingDir, projectDir, name string) (*models.Project, error) {
+	// A model whose images can't be normalized is still imported
+	if err := i.images.NormalizeDir(stagingDir); err != nil {
+		fmt.Printf("Warning: Failed to normalize images of %s: %v\n", name, err)
+	}
+	if err := fileperm.Tree(stagingDir); err != nil {
+		return nil, err
+	}
+
+	projectDir = freeDir(projectDir)
+	if err := os.Rename(stagingDir, projectDir); err != nil {
+		return nil, err
+	}
+	return i.scanner.ImportProject(projectDir)
+}
+
+// freeDir returns dir, or dir numbered when something is already there
+func freeDir(dir string) string {
+	path := dir
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s_%d", dir, n)
+	}
+}
+
+// retryRateLimited calls fn until it stops being rate limited, recording the
+// wait on the job. It gives up after maxRateLimitRetries retries, or when ctx
+// is done.
+func (i *Importer) retryRateLimited(ctx context.Context, job *models.ImportJob, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+
+		var rateLimited *RateLimitError
+		if !errors.As(err, &rateLimited) {
+			return err
+		}
+		if attempt == maxRateLimitRetries {
+			return fmt.Errorf("gave up after %d retries: %w", maxRateLimitRetries, err)
+		}
+
+		wait := rateLimited.RetryAfter
+		if wait <= 0 {
+			wait = defaultRetryAfter
+		}
+		retryAt := time.Now().Add(wait)
+		job.Status = models.ImportJobRateLimited
+		job.RetryAfter = &retryAt
+		if err := i.db.Save(job).Error; err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		job.Status = models.ImportJobRunning
+		job.RetryAfter = nil
+		if err := i.db.Save(job).Error; err != nil {
+			return err
+		}
+	}
+}
+
+// fail marks the job as failed with the given error
+func (i *Importer) fail(job *models.ImportJob, cause error) error {
+	finishedAt := time.Now()
+	job.Status = models.ImportJobFailed
+	job.Error = cause.Error()
+	job.FinishedAt = &finishedAt
+	if err := i.db.Save(job).Error; err != nil {
+		return err
+	}
+	return cause
+}
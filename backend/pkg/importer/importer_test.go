@@ -0,0 +1,299 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/scanner"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a file-backed database shared by the importer's goroutines
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "import.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// fakeSource serves a fixed collection, rate limiting and failing on request
+type fakeSource struct {
+	mu          sync.Mutex
+	collection  RemoteCollection
+	rateLimits  int
+	failures    map[string]int
+	downloads   []string
+	fetchErrors int
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Matches(u *url.URL) bool { return u.Host == "models.example.com" }
+
+func (f *fakeSource) FetchCollection(ctx context.Context, rawURL string) (*RemoteCollection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fetchErrors > 0 {
+		f.fetchErrors--
+		return nil, errors.New("collection is private")
+	}
+	collection := f.collection
+	return &collection, nil
+}
+
+func (f *fakeSource) Download(ctx context.Context, item RemoteItem, dir string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.rateLimits > 0 {
+		f.rateLimits--
+		return &RateLimitError{RetryAfter: 10 * time.Millisecond}
+	}
+	if f.failures[item.ID] > 0 {
+		f.failures[item.ID]--
+		return errors.New("file not found")
+	}
+
+	f.downloads = append(f.downloads, item.ID)
+	return os.WriteFile(filepath.Join(dir, item.ID+".stl"), []byte("solid "+item.Name), 0644)
+}
+
+// newTestImporter creates an importer over a temporary scan path, running
+// jobs on its own queue
+func newTestImporter(t *testing.T, source Source) (*Importer, *gorm.DB, string) {
+	db := setupTestDB(t)
+	scanPath := t.TempDir()
+	queue := jobs.New(db)
+	imp := New(db, queue, scanner.New(db, scanPath), scanPath, source)
+	if err := queue.Start(2); err != nil {
+		t.Fatalf("Failed to start job queue: %v", err)
+	}
+	t.Cleanup(queue.Stop)
+	return imp, db, scanPath
+}
+
+// loadJob reloads a job with its items
+func loadJob(t *testing.T, db *gorm.DB, id uint) models.ImportJob {
+	var job models.ImportJob
+	if err := db.Preload("Items").First(&job, id).Error; err != nil {
+		t.Fatalf("Failed to load job: %v", err)
+	}
+	return job
+}
+
+func TestImportCollection(t *testing.T) {
+	source := &fakeSource{
+		collection: RemoteCollection{Name: "Desk Toys", Items: []RemoteItem{
+			{ID: "1", Name: "Fidget Gear", URL: "https://models.example.com/things/1"},
+			{ID: "2", Name: "Fidget Gear", URL: "https://models.example.com/things/2"},
+			{ID: "3", Name: "Tiny Rocket"},
+		}},
+		rateLimits: 2,
+	}
+	imp, db, scanPath := newTestImporter(t, source)
+
+	job, err := imp.Start("https://models.example.com/collections/7")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobCompleted || result.Total != 3 || result.Imported != 3 || result.Failed != 0 {
+		t.Fatalf("Unexpected job result: %+v", result)
+	}
+	if result.RetryAfter != nil {
+		t.Error("Expected retry_after to be cleared after the rate limit")
+	}
+
+	var collection models.Collection
+	if err := db.Preload("Projects").First(&collection, *result.CollectionID).Error; err != nil {
+		t.Fatalf("Failed to load collection: %v", err)
+	}
+	if collection.Name != "Desk Toys" || len(collection.Projects) != 3 {
+		t.Fatalf("Expected 3 projects in Desk Toys, got %+v", collection)
+	}
+
+	for _, project := range collection.Projects {
+		if filepath.Dir(project.Path) != filepath.Join(scanPath, "Desk_Toys") {
+			t.Errorf("Expected project under the collection directory, got %s", project.Path)
+		}
+		var files int64
+		db.Model(&models.ProjectFile{}).Where("project_id = ?", project.ID).Count(&files)
+		if files != 1 {
+			t.Errorf("Expected 1 file for %s, got %d", project.Name, files)
+		}
+	}
+	if collection.Projects[0].Name != "Fidget Gear" || collection.Projects[0].Source != "https://models.example.com/things/1" {
+		t.Errorf("Expected remote name and source on the project, got %+v", collection.Projects[0])
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(scanPath, "Desk_Toys"))
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 project directories and no staging leftovers, got %d entries", len(entries))
+	}
+}
+
+func TestImportResumeRetriesFailedItems(t *testing.T) {
+	source := &fakeSource{
+		collection: RemoteCollection{Name: "Calibration", Items: []RemoteItem{
+			{ID: "cube", Name: "Cube"},
+			{ID: "tower", Name: "Temp Tower"},
+		}},
+		failures: map[string]int{"tower": 1},
+	}
+	imp, db, _ := newTestImporter(t, source)
+
+	job, err := imp.Start("https://models.example.com/collections/calibration")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Imported != 1 || result.Failed != 1 {
+		t.Fatalf("Expected one imported and one failed item, got %+v", result)
+	}
+
+	if _, err := imp.Resume(job.ID); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result = loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobCompleted || result.Imported != 2 || result.Failed != 0 {
+		t.Fatalf("Expected all items imported after resume, got %+v", result)
+	}
+	if len(source.downloads) != 2 {
+		t.Errorf("Expected imported items not to be downloaded again, got %v", source.downloads)
+	}
+
+	if _, err := imp.Resume(job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Expected ErrJobFinished, got %v", err)
+	}
+}
+
+func TestImportFailedListing(t *testing.T) {
+	source := &fakeSource{fetchErrors: 1, collection: RemoteCollection{Name: "Later", Items: []RemoteItem{{ID: "1", Name: "One"}}}}
+	imp, db, _ := newTestImporter(t, source)
+
+	job, err := imp.Start("https://models.example.com/collections/1")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobFailed || result.Error != "collection is private" || result.FinishedAt == nil {
+		t.Fatalf("Expected failed job, got %+v", result)
+	}
+
+	if _, err := imp.Resume(job.ID); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	if result = loadJob(t, db, job.ID); result.Status != models.ImportJobCompleted || result.Imported != 1 {
+		t.Errorf("Expected listing to be retried on resume, got %+v", result)
+	}
+}
+
+func TestImportRateLimitGivesUp(t *testing.T) {
+	source := &fakeSource{
+		collection: RemoteCollection{Name: "Busy", Items: []RemoteItem{{ID: "1", Name: "One"}}},
+		rateLimits: maxRateLimitRetries + 1,
+	}
+	imp, db, _ := newTestImporter(t, source)
+
+	job, err := imp.Start("https://models.example.com/collections/busy")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.ImportJobCompleted || result.Failed != 1 || result.Items[0].Error == "" {
+		t.Fatalf("Expected the item to fail once the retries ran out, got %+v", result)
+	}
+
+	// A cancelled run stops waiting for the rate limit at once
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	started := time.Now()
+	err = imp.retryRateLimited(ctx, &result, func() error { return &RateLimitError{RetryAfter: time.Hour} })
+	if !errors.Is(err, context.Canceled) || time.Since(started) > time.Second {
+		t.Errorf("Expected the wait cancelled, got %v after %s", err, time.Since(started))
+	}
+}
+
+func TestImportKeepsExistingDirectory(t *testing.T) {
+	source := &fakeSource{collection: RemoteCollection{Name: "Desk Toys", Items: []RemoteItem{{ID: "1", Name: "Fidget Gear"}}}}
+	imp, db, scanPath := newTestImporter(t, source)
+
+	existing := filepath.Join(scanPath, "Desk_Toys", "Fidget_Gear_1")
+	os.MkdirAll(existing, 0755)
+	os.WriteFile(filepath.Join(existing, "mine.stl"), []byte("solid mine"), 0644)
+
+	job, err := imp.Start("https://models.example.com/collections/7")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	result := loadJob(t, db, job.ID)
+	if result.Imported != 1 {
+		t.Fatalf("Expected the item imported, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(existing, "mine.stl")); err != nil {
+		t.Errorf("Expected the existing directory left alone: %v", err)
+	}
+	var project models.Project
+	db.First(&project, *result.Items[0].ProjectID)
+	if project.Path != existing+"_2" {
+		t.Errorf("Expected the model in a numbered directory, got %s", project.Path)
+	}
+}
+
+func TestImportResumeUnfinished(t *testing.T) {
+	source := &fakeSource{collection: RemoteCollection{Name: "Restart", Items: []RemoteItem{{ID: "1", Name: "One"}}}}
+	imp, db, _ := newTestImporter(t, source)
+
+	// A job left running by a previous process
+	job := models.ImportJob{SourceURL: "https://models.example.com/collections/1", Provider: "fake", Status: models.ImportJobRunning}
+	db.Create(&job)
+
+	if err := imp.ResumeUnfinished(); err != nil {
+		t.Fatalf("ResumeUnfinished failed: %v", err)
+	}
+	imp.Wait(job.ID)
+
+	if result := loadJob(t, db, job.ID); result.Status != models.ImportJobCompleted {
+		t.Errorf("Expected interrupted job to complete, got %+v", result)
+	}
+}
+
+func TestStartUnsupportedURL(t *testing.T) {
+	imp, _, _ := newTestImporter(t, &fakeSource{})
+
+	for _, rawURL := range []string{"", "ftp://models.example.com/collections/1", "https://elsewhere.example.com/list", "not a url"} {
+		if _, err := imp.Start(rawURL); !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("Expected ErrUnsupportedURL for %q, got %v", rawURL, err)
+		}
+	}
+}
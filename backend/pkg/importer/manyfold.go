@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// manyfoldManifest is the Frictionless Data Package Manyfold writes next to each model
+const manyfoldManifest = "datapackage.json"
+
+// manyfold reads Manyfold library exports, which hold a directory per model
+// described by a datapackage.json
+type manyfold struct{}
+
+type manyfoldPackage struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Homepage    string   `json:"homepage"`
+	Keywords    []string `json:"keywords"`
+	Licenses    []struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	} `json:"licenses"`
+	Contributors []struct {
+		Title string   `json:"title"`
+		Roles []string `json:"roles"`
+	} `json:"contributors"`
+	Links []struct {
+		Path string `json:"path"`
+		URL  string `json:"url"`
+	} `json:"links"`
+	Collections []struct {
+		Title string `json:"title"`
+		Name  string `json:"name"`
+	} `json:"collections"`
+	Collection string `json:"collection"`
+	Resources  []struct {
+		Path string `json:"path"`
+	} `json:"resources"`
+}
+
+// Name implements ArchiveFormat
+func (manyfold) Name() string {
+	return "manyfold"
+}
+
+// Detect implements ArchiveFormat for archives holding any datapackage.json
+func (manyfold) Detect(names []string) bool {
+	for _, name := range names {
+		if path.Base(name) == manyfoldManifest {
+			return true
+		}
+	}
+	return false
+}
+
+// Read implements ArchiveFormat, turning every directory with a
+// datapackage.json into a model
+func (m manyfold) Read(root string) ([]ArchiveModel, error) {
+	var models []ArchiveModel
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "__MACOSX") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || d.Name() != manyfoldManifest {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		model, err := m.readModel(root, filepath.ToSlash(rel), p)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.ToSlash(filepath.Join(rel, manyfoldManifest)), err)
+		}
+		models = append(models, model)
+		return nil
+	})
+	return models, err
+}
+
+// readModel maps one model's data package onto an ArchiveModel
+func (manyfold) readModel(root, dir, manifest string) (ArchiveModel, error) {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return ArchiveModel{}, err
+	}
+	var pkg manyfoldPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return ArchiveModel{}, err
+	}
+
+	model := ArchiveModel{
+		ID:          dir,
+		Name:        firstNonEmpty(pkg.Title, pkg.Name, path.Base(dir)),
+		Description: pkg.Description,
+		Tags:        pkg.Keywords,
+		Source:      pkg.Homepage,
+		Collection:  pkg.Collection,
+		Dir:         dir,
+	}
+	if len(pkg.Licenses) > 0 {
+		model.License = firstNonEmpty(pkg.Licenses[0].Name, pkg.Licenses[0].Title)
+	}
+	for _, contributor := range pkg.Contributors {
+		for _, role := range contributor.Roles {
+			if role == "creator" && model.Designer == "" {
+				model.Designer = contributor.Title
+			}
+		}
+	}
+	if model.Designer == "" && len(pkg.Contributors) > 0 {
+		model.Designer = pkg.Contributors[0].Title
+	}
+	if len(pkg.Links) > 0 {
+		model.Source = firstNonEmpty(pkg.Links[0].URL, pkg.Links[0].Path, model.Source)
+	}
+	if len(pkg.Collections) > 0 {
+		model.Collection = firstNonEmpty(pkg.Collections[0].Title, pkg.Collections[0].Name)
+	}
+	if model.Collection != "" {
+		model.CollectionKey = "manyfold:" + model.Collection
+	}
+
+	for _, resource := range pkg.Resources {
+		file := path.Clean(path.Join(dir, filepath.ToSlash(resource.Path)))
+		if resource.Path == "" || !withinDir(dir, file) || file == path.Join(dir, manyfoldManifest) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(file))); err == nil && info.Mode().IsRegular() {
+			model.Files = append(model.Files, file)
+		}
+	}
+	// Packages without resources keep every file of their directory
+	if len(model.Files) == 0 {
+		files, err := exportFiles(root, dir)
+		if err != nil {
+			return ArchiveModel{}, err
+		}
+		for _, file := range files {
+			if path.Base(file) != manyfoldManifest {
+				model.Files = append(model.Files, file)
+			}
+		}
+	}
+	return model, nil
+}
+
+// withinDir reports whether the slash separated path file is below dir
+func withinDir(dir, file string) bool {
+	if dir == "." {
+		return file != ".." && !strings.HasPrefix(file, "../") && !path.IsAbs(file)
+	}
+	return strings.HasPrefix(file, dir+"/")
+}
+
+// firstNonEmpty returns the first of values that isn't blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
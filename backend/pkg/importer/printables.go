@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// printablesModelURL is where a Printables model is published, by its ID
+const printablesModelURL = "https://www.printables.com/model/%s"
+
+// printablesManifests are the files of a Printables data export listing its models
+var printablesManifests = []string{"models.json", "prints.json"}
+
+// printables reads Printables data exports: a JSON listing of the account's
+// models next to a folder of files for each, named after the model's ID
+type printables struct{}
+
+type printablesModel struct {
+	ID          printablesValue   `json:"id"`
+	Name        string            `json:"name"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	URL         string            `json:"url"`
+	Tags        []printablesValue `json:"tags"`
+	License     printablesValue   `json:"license"`
+	User        printablesValue   `json:"user"`
+	Author      printablesValue   `json:"author"`
+	Collections []printablesValue `json:"collections"`
+	Collection  printablesValue   `json:"collection"`
+}
+
+// printablesValue decodes fields Printables exports either as plain values or
+// as objects naming them
+type printablesValue string
+
+func (v *printablesValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*v = ""
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = printablesValue(s)
+	case len(data) > 0 && data[0] == '{':
+		var object struct {
+			Name           string `json:"name"`
+			Title          string `json:"title"`
+			PublicUsername string `json:"publicUsername"`
+			Abbreviation   string `json:"abbreviation"`
+		}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		*v = printablesValue(firstNonEmpty(object.PublicUsername, object.Abbreviation, object.Name, object.Title))
+	default:
+		// Numeric IDs are kept as written
+		*v = printablesValue(data)
+	}
+	return nil
+}
+
+// Name implements ArchiveFormat
+func (printables) Name() string {
+	return "printables"
+}
+
+// Detect implements ArchiveFormat for archives with a model listing at their
+// root or in their top folder
+func (printables) Detect(names []string) bool {
+	for _, name := range names {
+		if depth := strings.Count(strings.Trim(name, "/"), "/"); depth > 1 {
+			continue
+		}
+		for _, manifest := range printablesManifests {
+			if path.Base(name) == manifest {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Read implements ArchiveFormat
+func (printables) Read(root string) ([]ArchiveModel, error) {
+	manifest, err := printablesManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := decodePrintablesModels(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(manifest), err)
+	}
+
+	base := filepath.Dir(manifest)
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := filepath.Rel(root, base)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]ArchiveModel, 0, len(listed))
+	for _, listedModel := range listed {
+		id := string(listedModel.ID)
+		if id == "" {
+			continue
+		}
+		model := ArchiveModel{
+			ID:          id,
+			Name:        firstNonEmpty(listedModel.Name, id),
+			Description: firstNonEmpty(listedModel.Description, listedModel.Summary),
+			License:     string(listedModel.License),
+			Designer:    firstNonEmpty(string(listedModel.User), string(listedModel.Author)),
+			Source:      firstNonEmpty(listedModel.URL, fmt.Sprintf(printablesModelURL, id)),
+			Collection:  string(listedModel.Collection),
+		}
+		for _, tag := range listedModel.Tags {
+			if tag != "" {
+				model.Tags = append(model.Tags, string(tag))
+			}
+		}
+		if len(listedModel.Collections) > 0 {
+			model.Collection = string(listedModel.Collections[0])
+		}
+		if model.Collection != "" {
+			model.CollectionKey = "printables:" + model.Collection
+		}
+
+		// A model's files are in the folder named after its ID, optionally followed by its slug
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || (name != id && !strings.HasPrefix(name, id+"-") && !strings.HasPrefix(name, id+"_")) {
+				continue
+			}
+			model.Dir = filepath.ToSlash(filepath.Join(prefix, name))
+			if model.Files, err = exportFiles(root, model.Dir); err != nil {
+				return nil, err
+			}
+			break
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// printablesManifest finds the model listing at root or in its only folder
+func printablesManifest(root string) (string, error) {
+	dirs := []string{root}
+	if entries, err := os.ReadDir(root); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "__MACOSX" {
+				dirs = append(dirs, filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+	for _, dir := range dirs {
+		for _, manifest := range printablesManifests {
+			candidate := filepath.Join(dir, manifest)
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				return candidate, nil
+			}
+		}
+	}
+	return "", errors.New("export has no model listing")
+}
+
+// decodePrintablesModels reads a listing that is either an array of models
+// or an object holding one
+func decodePrintablesModels(data []byte) ([]printablesModel, error) {
+	var listed []printablesModel
+	if err := json.Unmarshal(data, &listed); err == nil {
+		return listed, nil
+	}
+
+	var wrapped struct {
+		Models []printablesModel `json:"models"`
+		Prints []printablesModel `json:"prints"`
+		Items  []printablesModel `json:"items"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	return append(append(wrapped.Models, wrapped.Prints...), wrapped.Items...), nil
+}
@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ThingiverseAPIURL is the public Thingiverse REST API
+	ThingiverseAPIURL = "https://api.thingiverse.com"
+
+	// thingiversePageSize is how many things are requested per collection page
+	thingiversePageSize = 30
+)
+
+// Thingiverse imports collections through the Thingiverse REST API, which
+// requires an app token
+type Thingiverse struct {
+	Token   string
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewThingiverse creates a Thingiverse source authenticating with token
+func NewThingiverse(token string) *Thingiverse {
+	return &Thingiverse{
+		Token:   token,
+		BaseURL: ThingiverseAPIURL,
+		Client:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Name implements Source
+func (t *Thingiverse) Name() string {
+	return "thingiverse"
+}
+
+// Matches implements Source for URLs like https://www.thingiverse.com/<user>/collections/<id>
+func (t *Thingiverse) Matches(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "thingiverse.com" {
+		return false
+	}
+	_, ok := thingiverseCollectionID(u)
+	return ok
+}
+
+// thingiverseCollectionID extracts the numeric ID following /collections/ in the path
+func thingiverseCollectionID(u *url.URL) (string, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] != "collections" {
+			continue
+		}
+		if _, err := strconv.ParseUint(parts[i+1], 10, 64); err == nil {
+			return parts[i+1], true
+		}
+	}
+	return "", false
+}
+
+type thingiverseCollection struct {
+	Name string `json:"name"`
+}
+
+type thingiverseThing struct {
+	ID        uint64 `json:"id"`
+	Name      string `json:"name"`
+	PublicURL string `json:"public_url"`
+}
+
+type thingiverseFile struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"download_url"`
+}
+
+// FetchCollection implements Source, following the collection's pages
+func (t *Thingiverse) FetchCollection(ctx context.Context, rawURL string) (*RemoteCollection, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := thingiverseCollectionID(u)
+	if !ok {
+		return nil, ErrUnsupportedURL
+	}
+
+	var info thingiverseCollection
+	if err := t.getJSON(ctx, "/collections/"+id, &info); err != nil {
+		return nil, err
+	}
+
+	collection := &RemoteCollection{Name: info.Name}
+	for page := 1; ; page++ {
+		var things []thingiverseThing
+		path := fmt.Sprintf("/collections/%s/things?page=%d&per_page=%d", id, page, thingiversePageSize)
+		if err := t.getJSON(ctx, path, &things); err != nil {
+			return nil, err
+		}
+
+		for _, thing := range things {
+			collection.Items = append(collection.Items, RemoteItem{
+				ID:   strconv.FormatUint(thing.ID, 10),
+				Name: thing.Name,
+				URL:  thing.PublicURL,
+			})
+		}
+		if len(things) < thingiversePageSize {
+			break
+		}
+	}
+
+	return collection, nil
+}
+
+// Download implements Source, saving every file of the thing into dir
+func (t *Thingiverse) Download(ctx context.Context, item RemoteItem, dir string) error {
+	var files []thingiverseFile
+	if err := t.getJSON(ctx, "/things/"+item.ID+"/files", &files); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file.Name)
+		if name == "." || name == string(filepath.Separator) || file.DownloadURL == "" {
+			continue
+		}
+		if err := t.download(ctx, file.DownloadURL, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to download %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// getJSON decodes an API response into dest
+func (t *Thingiverse) getJSON(ctx context.Context, path string, dest interface{}) error {
+	resp, err := t.get(ctx, strings.TrimSuffix(t.BaseURL, "/")+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// download streams a file to path
+func (t *Thingiverse) download(ctx context.Context, fileURL, path string) error {
+	resp, err := t.get(ctx, fileURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// get performs an authenticated request, mapping 429 responses to RateLimitError
+func (t *Thingiverse) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		resp.Body.Close()
+		return nil, &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		resp.Body.Close()
+		return nil, fmt.Errorf("thingiverse returned %s for %s", resp.Status, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newThingiverseServer fakes the parts of the Thingiverse API used by the importer
+func newThingiverseServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("/collections/42", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 42, "name": "Favourite Tools"}`)
+	})
+	mux.HandleFunc("/collections/42/things", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id": 100, "name": "Caliper Holder", "public_url": "https://www.thingiverse.com/thing:100"}]`)
+	})
+	mux.HandleFunc("/things/100/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"name": "holder.stl", "download_url": "%s/files/1/download"}, {"name": "../escape.stl", "download_url": "%s/files/1/download"}]`, server.URL, server.URL)
+	})
+	mux.HandleFunc("/files/1/download", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "solid holder")
+	})
+	mux.HandleFunc("/things/429/files", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestThingiverseMatches(t *testing.T) {
+	source := NewThingiverse("secret")
+
+	testCases := map[string]bool{
+		"https://www.thingiverse.com/maker/collections/42":        true,
+		"https://thingiverse.com/maker/collections/42/things":     true,
+		"https://www.thingiverse.com/thing:100":                   false,
+		"https://www.thingiverse.com/maker/collections/favourite": false,
+		"https://www.printables.com/@maker/collections/42":        false,
+	}
+
+	for rawURL, expected := range testCases {
+		u, _ := url.Parse(rawURL)
+		if got := source.Matches(u); got != expected {
+			t.Errorf("Matches(%s) = %v, expected %v", rawURL, got, expected)
+		}
+	}
+}
+
+func TestThingiverseFetchAndDownload(t *testing.T) {
+	server := newThingiverseServer(t)
+	source := NewThingiverse("secret")
+	source.BaseURL = server.URL
+
+	collection, err := source.FetchCollection(context.Background(), "https://www.thingiverse.com/maker/collections/42")
+	if err != nil {
+		t.Fatalf("FetchCollection failed: %v", err)
+	}
+	if collection.Name != "Favourite Tools" || len(collection.Items) != 1 {
+		t.Fatalf("Unexpected collection: %+v", collection)
+	}
+	item := collection.Items[0]
+	if item.ID != "100" || item.Name != "Caliper Holder" || item.URL != "https://www.thingiverse.com/thing:100" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	dir := t.TempDir()
+	if err := source.Download(context.Background(), item, dir); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "holder.stl"))
+	if err != nil || string(content) != "solid holder" {
+		t.Errorf("Expected downloaded holder.stl, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.stl")); err != nil {
+		t.Errorf("Expected path components to be stripped from file names: %v", err)
+	}
+}
+
+func TestThingiverseErrors(t *testing.T) {
+	server := newThingiverseServer(t)
+
+	t.Run("Rate limited", func(t *testing.T) {
+		source := NewThingiverse("secret")
+		source.BaseURL = server.URL
+
+		err := source.Download(context.Background(), RemoteItem{ID: "429"}, t.TempDir())
+		var rateLimited *RateLimitError
+		if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 30*time.Second {
+			t.Errorf("Expected RateLimitError with 30s, got %v", err)
+		}
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		source := NewThingiverse("wrong")
+		source.BaseURL = server.URL
+
+		if _, err := source.FetchCollection(context.Background(), "https://www.thingiverse.com/maker/collections/42"); err == nil {
+			t.Error("Expected error for rejected token")
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("120"); got != 2*time.Minute {
+		t.Errorf("Expected 2m, got %v", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("Expected 0 for missing header, got %v", got)
+	}
+	if got := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); got < 59*time.Minute {
+		t.Errorf("Expected about an hour for HTTP date, got %v", got)
+	}
+}
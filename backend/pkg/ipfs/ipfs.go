@@ -0,0 +1,139 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Client adds files through the HTTP RPC API of an IPFS node such as Kubo
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// New creates a client for the node whose RPC API is at baseURL, e.g. http://127.0.0.1:5001
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 2 * time.Hour},
+	}
+}
+
+// File is one file to add: its slash-separated path inside the directory and
+// where it is read from
+type File struct {
+	Path   string
+	Source string
+}
+
+// addedEntry is one line of the node's add response
+type addedEntry struct {
+	Name string `json:"Name"`
+	Hash string `json:"Hash"`
+}
+
+// AddDirectory adds and pins files as a directory called name, returning the
+// directory's CID. Files are streamed to the node, not held in memory.
+func (c *Client) AddDirectory(ctx context.Context, name string, files []File) (string, error) {
+	body, form := io.Pipe()
+	// Closing the reader stops the writer should the request end early
+	defer body.Close()
+	writer := multipart.NewWriter(form)
+	go func() {
+		form.CloseWithError(writeDirectory(writer, name, files))
+	}()
+
+	endpoint := c.BaseURL + "/api/v0/add?pin=true&cid-version=1&progress=false"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Message string `json:"Message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		return "", fmt.Errorf("ipfs add returned %s: %s", resp.Status, failure.Message)
+	}
+
+	// The node answers one entry per added file and directory, the root last
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var entry addedEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("invalid ipfs add response: %w", err)
+		}
+		if entry.Name == name {
+			return entry.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("ipfs add response has no entry for %s", name)
+}
+
+// writeDirectory writes the multipart body of an add request: a part for each
+// directory, parents first, then one for each file
+func writeDirectory(writer *multipart.Writer, name string, files []File) error {
+	written := map[string]bool{}
+	var addDir func(dir string) error
+	addDir = func(dir string) error {
+		if written[dir] {
+			return nil
+		}
+		if parent := path.Dir(dir); parent != "." {
+			if err := addDir(parent); err != nil {
+				return err
+			}
+		}
+		written[dir] = true
+		_, err := writer.CreatePart(partHeader(dir, "application/x-directory"))
+		return err
+	}
+
+	for _, file := range files {
+		filePath := path.Join(name, file.Path)
+		if err := addDir(path.Dir(filePath)); err != nil {
+			return err
+		}
+		part, err := writer.CreatePart(partHeader(filePath, "application/octet-stream"))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file.Source)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// partHeader names a part by its path, escaped as the node expects
+func partHeader(filePath, contentType string) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, url.QueryEscape(filePath)))
+	header.Set("Content-Type", contentType)
+	return header
+}
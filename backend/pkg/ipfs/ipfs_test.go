@@ -0,0 +1,89 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAddDirectory tests streaming a directory to the node and reading its CID
+func TestAddDirectory(t *testing.T) {
+	var parts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/add" || r.URL.Query().Get("pin") != "true" {
+			http.Error(w, `{"Message": "unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, `{"Message": "not multipart"}`, http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			name, _ := url.QueryUnescape(part.FileName())
+			content, _ := io.ReadAll(part)
+			parts = append(parts, fmt.Sprintf("%s %s %s", name, part.Header.Get("Content-Type"), content))
+		}
+		fmt.Fprintln(w, `{"Name": "benchy/parts/hull.stl", "Hash": "bafyfile"}`)
+		fmt.Fprintln(w, `{"Name": "benchy/parts", "Hash": "bafyparts"}`)
+		fmt.Fprintln(w, `{"Name": "benchy", "Hash": "bafyroot"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "hull.stl"), []byte("solid hull"), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Benchy"), 0644)
+
+	client := New(server.URL + "/")
+	cid, err := client.AddDirectory(context.Background(), "benchy", []File{
+		{Path: "README.md", Source: filepath.Join(dir, "README.md")},
+		{Path: "parts/hull.stl", Source: filepath.Join(dir, "hull.stl")},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cid != "bafyroot" {
+		t.Errorf("Expected the directory's CID, got %s", cid)
+	}
+	expected := []string{
+		"benchy application/x-directory ",
+		"benchy/README.md application/octet-stream # Benchy",
+		"benchy/parts application/x-directory ",
+		"benchy/parts/hull.stl application/octet-stream solid hull",
+	}
+	if strings.Join(parts, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected parts\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(parts, "\n"))
+	}
+
+	if _, err := client.AddDirectory(context.Background(), "benchy", []File{{Path: "gone.stl", Source: filepath.Join(dir, "gone.stl")}}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestAddDirectoryError tests reporting the node's error message
+func TestAddDirectoryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"Message": "repo is locked", "Code": 0}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "a.stl")
+	os.WriteFile(path, []byte("solid"), 0644)
+	_, err := New(server.URL).AddDirectory(context.Background(), "a", []File{{Path: "a.stl", Source: path}})
+	if err == nil || !strings.Contains(err.Error(), "repo is locked") {
+		t.Errorf("Expected the node's error, got %v", err)
+	}
+}
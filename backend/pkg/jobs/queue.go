@@ -0,0 +1,470 @@
+package jobs
+
+import (
+	"3dshelf/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// pollInterval is how often idle workers look for jobs that became due
+	pollInterval = time.Second
+
+	// leaseDuration is how long a claimed job stays locked to its worker without
+	// a renewal; jobs of workers that crashed are claimed again once it lapses
+	leaseDuration = 2 * time.Minute
+)
+
+var (
+	// ErrUnknownType is returned when enqueuing a job type with no registered handler
+	ErrUnknownType = errors.New("unknown job type")
+	// ErrNotRetryable is returned when retrying a job that has not stopped for good
+	ErrNotRetryable = errors.New("only dead or cancelled jobs can be retried")
+	// ErrNotCancellable is returned when cancelling a job that is not pending
+	ErrNotCancellable = errors.New("only pending jobs can be cancelled")
+	// ErrNotFinished is returned when deleting a job that is still pending or running
+	ErrNotFinished = errors.New("job is still pending or running")
+)
+
+// Handler runs one attempt of a job; returning an error schedules a retry
+// until the type's retry policy gives up
+type Handler func(ctx context.Context, job *models.Job) error
+
+// RetryPolicy decides how often a failing job is attempted and how long
+// to wait between attempts. The delay doubles after each failure.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy retries a job three times over a few minutes
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 30 * time.Second, MaxBackoff: 10 * time.Minute}
+
+// NoRetry moves a job to the dead letters after its first failure
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Delay returns how long to wait before the attempt after the given one
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
+}
+
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Queue runs persisted background jobs on a pool of workers. Several processes
+// may share one queue through the database: each claimed job is leased to the
+// worker running it, and jobs whose lease lapsed are attempted again.
+type Queue struct {
+	db       *gorm.DB
+	id       string
+	handlers map[string]registration
+	wake     chan struct{}
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	waiters map[uint][]chan struct{}
+}
+
+// New creates a Queue storing jobs in db, identified by host and process in job leases
+func New(db *gorm.DB) *Queue {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &Queue{
+		db:       db,
+		id:       fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		handlers: make(map[string]registration),
+		wake:     make(chan struct{}, 1),
+		waiters:  make(map[uint][]chan struct{}),
+	}
+}
+
+// Register runs handler for jobs of the given type; it must be called before Start
+func (q *Queue) Register(jobType string, policy RetryPolicy, handler Handler) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	q.handlers[jobType] = registration{handler: handler, policy: policy}
+}
+
+// Enqueue stores a job of the given type to run as soon as a worker is free
+func (q *Queue) Enqueue(jobType string, payload any) (*models.Job, error) {
+	return q.EnqueueOnce(jobType, "", payload)
+}
+
+// EnqueueOnce is like Enqueue, but returns the existing job instead when an
+// unfinished job of the same type already holds key. An empty key never matches.
+func (q *Queue) EnqueueOnce(jobType, key string, payload any) (*models.Job, error) {
+	registered, ok := q.handlers[jobType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.Job{
+		Type:        jobType,
+		Key:         key,
+		Payload:     data,
+		Status:      models.JobPending,
+		MaxAttempts: registered.policy.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	err = q.db.Transaction(func(tx *gorm.DB) error {
+		if key != "" {
+			existing, err := unfinished(tx, jobType, key)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				job = *existing
+				return nil
+			}
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	q.notify()
+	return &job, nil
+}
+
+// Unfinished returns the pending or running job of a type holding key, or nil when there is none
+func (q *Queue) Unfinished(jobType, key string) (*models.Job, error) {
+	return unfinished(q.db, jobType, key)
+}
+
+func unfinished(db *gorm.DB, jobType, key string) (*models.Job, error) {
+	var job models.Job
+	err := db.Where("type = ? AND key = ? AND status IN ?", jobType, key,
+		[]models.JobStatus{models.JobPending, models.JobRunning}).Limit(1).Find(&job).Error
+	if err != nil || job.ID == 0 {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ID identifies this queue's workers in the leases of the jobs they run
+func (q *Queue) ID() string {
+	return q.id
+}
+
+// Start starts the given number of workers. Jobs this process left running
+// when it last stopped are released at once; other processes' wait for their
+// lease to lapse.
+func (q *Queue) Start(workers int) error {
+	if err := q.db.Model(&models.Job{}).Where("status = ? AND locked_by = ?", models.JobRunning, q.id).
+		Updates(map[string]interface{}{"status": models.JobPending, "locked_by": "", "locked_until": nil}).Error; err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.mu.Lock()
+	q.cancel = cancel
+	q.mu.Unlock()
+
+	for range max(workers, 1) {
+		q.workers.Add(1)
+		go q.work(ctx)
+	}
+	return nil
+}
+
+// Stop cancels running jobs and waits for the workers to exit. Cancelled
+// attempts are released for any worker to run again.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	q.workers.Wait()
+}
+
+// Wait blocks until the job completes, dies or is cancelled, including when
+// another process runs it
+func (q *Queue) Wait(jobID uint) {
+	done := make(chan struct{})
+	q.mu.Lock()
+	q.waiters[jobID] = append(q.waiters[jobID], done)
+	q.mu.Unlock()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		var job models.Job
+		if err := q.db.First(&job, jobID).Error; err != nil || job.Finished() {
+			q.finished(jobID)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Retry queues a dead or cancelled job again with a fresh set of attempts
+func (q *Queue) Retry(jobID uint) (*models.Job, error) {
+	var job models.Job
+	if err := q.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobDead && job.Status != models.JobCancelled {
+		return nil, ErrNotRetryable
+	}
+	registered, ok := q.handlers[job.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, job.Type)
+	}
+
+	job.Status = models.JobPending
+	job.Attempts = 0
+	job.MaxAttempts = registered.policy.MaxAttempts
+	job.Error = ""
+	job.RunAt = time.Now()
+	job.StartedAt = nil
+	job.FinishedAt = nil
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+
+	q.notify()
+	return &job, nil
+}
+
+// Cancel stops a pending job from running
+func (q *Queue) Cancel(jobID uint) (*models.Job, error) {
+	now := time.Now()
+	result := q.db.Model(&models.Job{}).Where("id = ? AND status = ?", jobID, models.JobPending).
+		Updates(map[string]interface{}{"status": models.JobCancelled, "finished_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var job models.Job
+	if err := q.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotCancellable
+	}
+
+	q.finished(jobID)
+	return &job, nil
+}
+
+// Delete removes a finished job's record
+func (q *Queue) Delete(jobID uint) error {
+	var job models.Job
+	if err := q.db.First(&job, jobID).Error; err != nil {
+		return err
+	}
+	if !job.Finished() {
+		return ErrNotFinished
+	}
+	return q.db.Delete(&job).Error
+}
+
+// work runs due jobs until ctx is cancelled
+func (q *Queue) work(ctx context.Context) {
+	defer q.workers.Done()
+
+	for ctx.Err() == nil {
+		job, err := q.claim()
+		if err != nil {
+			fmt.Printf("Warning: Failed to claim job: %v\n", err)
+		}
+		if job != nil {
+			q.execute(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// claim leases the oldest due job of a registered type to this queue, returning
+// nil when there is none. Due jobs are pending ones whose time has come and
+// running ones whose lease lapsed. Workers of several processes may race for
+// a job; only one wins.
+func (q *Queue) claim() (*models.Job, error) {
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+
+	for {
+		now := time.Now()
+		due := q.db.Where("status = ? AND run_at <= ?", models.JobPending, now).
+			Or("status = ? AND (locked_until IS NULL OR locked_until < ?)", models.JobRunning, now)
+
+		var job models.Job
+		if err := q.db.Where(due).Where("type IN ?", types).
+			Order("run_at ASC, id ASC").Limit(1).Find(&job).Error; err != nil || job.ID == 0 {
+			return nil, err
+		}
+
+		lockedUntil := now.Add(leaseDuration)
+		result := q.db.Model(&models.Job{}).Where("id = ?", job.ID).Where(due).Updates(map[string]interface{}{
+			"status":       models.JobRunning,
+			"attempts":     job.Attempts + 1,
+			"started_at":   now,
+			"locked_by":    q.id,
+			"locked_until": lockedUntil,
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.StartedAt = &now
+			job.LockedBy = q.id
+			job.LockedUntil = &lockedUntil
+			return &job, nil
+		}
+	}
+}
+
+// execute runs one attempt of a claimed job and records the outcome
+func (q *Queue) execute(ctx context.Context, job *models.Job) {
+	registered := q.handlers[job.Type]
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	lost := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		q.renewLease(jobCtx, job.ID, cancel, lost)
+	}()
+	err := runHandler(jobCtx, registered.handler, job)
+	cancel()
+	<-renewed
+
+	select {
+	case <-lost:
+		fmt.Printf("Warning: Job %d lost its lease; its outcome is left to the worker that claimed it\n", job.ID)
+		return
+	default:
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"error": "", "locked_by": "", "locked_until": nil}
+	switch {
+	case ctx.Err() != nil:
+		// A shutdown interrupted the attempt, which does not count against the job
+		updates["status"] = models.JobPending
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now
+	case err == nil:
+		updates["status"] = models.JobCompleted
+		updates["finished_at"] = now
+	case job.Attempts >= job.MaxAttempts:
+		updates["status"] = models.JobDead
+		updates["error"] = err.Error()
+		updates["finished_at"] = now
+	default:
+		updates["status"] = models.JobPending
+		updates["error"] = err.Error()
+		updates["run_at"] = now.Add(registered.policy.Delay(job.Attempts))
+	}
+	// A fresh context, so outcomes are recorded even while shutting down
+	if err := q.db.WithContext(context.Background()).Model(&models.Job{}).
+		Where("id = ? AND locked_by = ?", job.ID, q.id).Updates(updates).Error; err != nil {
+		fmt.Printf("Warning: Failed to record outcome of job %d: %v\n", job.ID, err)
+		return
+	}
+
+	if updates["status"] != models.JobPending {
+		q.finished(job.ID)
+	}
+}
+
+// renewLease extends a running job's lease until ctx is done. When another
+// worker took the job over it closes lost and cancels the attempt.
+func (q *Queue) renewLease(ctx context.Context, jobID uint, cancel context.CancelFunc, lost chan struct{}) {
+	ticker := time.NewTicker(leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result := q.db.Model(&models.Job{}).Where("id = ? AND status = ? AND locked_by = ?", jobID, models.JobRunning, q.id).
+			Update("locked_until", time.Now().Add(leaseDuration))
+		if result.Error != nil {
+			fmt.Printf("Warning: Failed to renew lease of job %d: %v\n", jobID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			close(lost)
+			cancel()
+			return
+		}
+	}
+}
+
+// runHandler calls handler, turning a panic into an error so one bad job
+// cannot take down its worker
+func runHandler(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// notify wakes an idle worker
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// finished releases everyone waiting on a job
+func (q *Queue) finished(jobID uint) {
+	q.mu.Lock()
+	waiters := q.waiters[jobID]
+	delete(q.waiters, jobID)
+	q.mu.Unlock()
+
+	for _, done := range waiters {
+		close(done)
+	}
+}
@@ -0,0 +1,256 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a file-backed database shared by the queue's workers
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "jobs.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// startQueue starts a queue's workers, stopping them when the test ends
+func startQueue(t *testing.T, queue *Queue) {
+	if err := queue.Start(2); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(queue.Stop)
+}
+
+func loadJob(t *testing.T, db *gorm.DB, id uint) models.Job {
+	var job models.Job
+	if err := db.First(&job, id).Error; err != nil {
+		t.Fatalf("Failed to load job: %v", err)
+	}
+	return job
+}
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func TestQueueRunsJobs(t *testing.T) {
+	db := setupTestDB(t)
+	queue := New(db)
+
+	received := make(chan string, 1)
+	queue.Register("greet", DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error {
+		var payload testPayload
+		if err := job.DecodePayload(&payload); err != nil {
+			return err
+		}
+		received <- payload.Name
+		return nil
+	})
+	startQueue(t, queue)
+
+	job, err := queue.Enqueue("greet", testPayload{Name: "benchy"})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	queue.Wait(job.ID)
+
+	if name := <-received; name != "benchy" {
+		t.Errorf("Expected payload name benchy, got %q", name)
+	}
+	if result := loadJob(t, db, job.ID); result.Status != models.JobCompleted || result.Attempts != 1 || result.FinishedAt == nil {
+		t.Errorf("Expected completed job, got %+v", result)
+	}
+
+	if _, err := queue.Enqueue("unknown", nil); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	db := setupTestDB(t)
+	queue := New(db)
+
+	var calls atomic.Int32
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}
+	queue.Register("flaky", policy, func(ctx context.Context, job *models.Job) error {
+		if calls.Add(1) == 1 {
+			return errors.New("printer offline")
+		}
+		return nil
+	})
+	queue.Register("broken", RetryPolicy{MaxAttempts: 2, Backoff: 10 * time.Millisecond}, func(ctx context.Context, job *models.Job) error {
+		return errors.New("disk full")
+	})
+	queue.Register("panics", NoRetry, func(ctx context.Context, job *models.Job) error {
+		panic("nil mesh")
+	})
+	startQueue(t, queue)
+
+	flaky, _ := queue.Enqueue("flaky", nil)
+	queue.Wait(flaky.ID)
+	if result := loadJob(t, db, flaky.ID); result.Status != models.JobCompleted || result.Attempts != 2 || result.Error != "" {
+		t.Errorf("Expected job to complete on its second attempt, got %+v", result)
+	}
+
+	broken, _ := queue.Enqueue("broken", nil)
+	queue.Wait(broken.ID)
+	result := loadJob(t, db, broken.ID)
+	if result.Status != models.JobDead || result.Attempts != 2 || result.Error != "disk full" {
+		t.Errorf("Expected dead job after 2 attempts, got %+v", result)
+	}
+
+	retried, err := queue.Retry(broken.ID)
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if retried.Status != models.JobPending || retried.Attempts != 0 {
+		t.Errorf("Expected retried job to be pending with fresh attempts, got %+v", retried)
+	}
+	queue.Wait(broken.ID)
+	if result := loadJob(t, db, broken.ID); result.Status != models.JobDead || result.Attempts != 2 {
+		t.Errorf("Expected retried job to die again, got %+v", result)
+	}
+
+	panics, _ := queue.Enqueue("panics", nil)
+	queue.Wait(panics.ID)
+	if result := loadJob(t, db, panics.ID); result.Status != models.JobDead || result.Error != "job panicked: nil mesh" {
+		t.Errorf("Expected panicking job to die, got %+v", result)
+	}
+}
+
+func TestQueueManagement(t *testing.T) {
+	db := setupTestDB(t)
+	queue := New(db)
+	queue.Register("export", DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error { return nil })
+
+	// Without workers jobs stay pending
+	first, err := queue.EnqueueOnce("export", "project-1", nil)
+	if err != nil {
+		t.Fatalf("EnqueueOnce failed: %v", err)
+	}
+	second, _ := queue.EnqueueOnce("export", "project-1", nil)
+	if second.ID != first.ID {
+		t.Errorf("Expected the unfinished job to be reused, got %d and %d", first.ID, second.ID)
+	}
+	if unfinished, _ := queue.Unfinished("export", "project-1"); unfinished == nil || unfinished.ID != first.ID {
+		t.Errorf("Expected unfinished job %d, got %+v", first.ID, unfinished)
+	}
+
+	if err := queue.Delete(first.ID); !errors.Is(err, ErrNotFinished) {
+		t.Errorf("Expected ErrNotFinished, got %v", err)
+	}
+	if _, err := queue.Retry(first.ID); !errors.Is(err, ErrNotRetryable) {
+		t.Errorf("Expected ErrNotRetryable, got %v", err)
+	}
+
+	cancelled, err := queue.Cancel(first.ID)
+	if err != nil || cancelled.Status != models.JobCancelled {
+		t.Fatalf("Expected cancelled job, got %+v, %v", cancelled, err)
+	}
+	if _, err := queue.Cancel(first.ID); !errors.Is(err, ErrNotCancellable) {
+		t.Errorf("Expected ErrNotCancellable, got %v", err)
+	}
+	queue.Wait(first.ID)
+
+	if third, _ := queue.EnqueueOnce("export", "project-1", nil); third.ID == first.ID {
+		t.Error("Expected a new job once the previous one was cancelled")
+	}
+
+	if err := queue.Delete(first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := db.First(&models.Job{}, first.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected job to be deleted, got %v", err)
+	}
+}
+
+func TestQueueRecoversInterruptedJobs(t *testing.T) {
+	db := setupTestDB(t)
+
+	// A job left running by a previous process
+	job := models.Job{Type: "verify", Status: models.JobRunning, Attempts: 1, MaxAttempts: 3, RunAt: time.Now()}
+	db.Create(&job)
+
+	queue := New(db)
+	queue.Register("verify", DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error { return nil })
+	startQueue(t, queue)
+	queue.Wait(job.ID)
+
+	if result := loadJob(t, db, job.ID); result.Status != models.JobCompleted || result.Attempts != 2 {
+		t.Errorf("Expected interrupted job to complete, got %+v", result)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := policy.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	if got := (RetryPolicy{Backoff: time.Second}).Delay(4); got != 8*time.Second {
+		t.Errorf("Expected unbounded backoff to keep doubling, got %v", got)
+	}
+}
+
+func TestQueueLeases(t *testing.T) {
+	db := setupTestDB(t)
+
+	// One job is held by a live worker elsewhere, the other by one that crashed
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Minute)
+	held := models.Job{Type: "hash", Status: models.JobRunning, Attempts: 1, MaxAttempts: 3, RunAt: time.Now(), LockedBy: "node-a-1", LockedUntil: &future}
+	lapsed := models.Job{Type: "hash", Status: models.JobRunning, Attempts: 1, MaxAttempts: 3, RunAt: time.Now(), LockedBy: "node-b-1", LockedUntil: &past}
+	db.Create(&held)
+	db.Create(&lapsed)
+
+	queue := New(db)
+	queue.Register("hash", DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error { return nil })
+	startQueue(t, queue)
+	queue.Wait(lapsed.ID)
+
+	if result := loadJob(t, db, lapsed.ID); result.Status != models.JobCompleted || result.LockedBy != "" || result.LockedUntil != nil {
+		t.Errorf("Expected the lapsed job to be taken over and completed, got %+v", result)
+	}
+	if result := loadJob(t, db, held.ID); result.Status != models.JobRunning || result.LockedBy != "node-a-1" {
+		t.Errorf("Expected the held job to stay with its worker, got %+v", result)
+	}
+}
+
+func TestQueueStopReleasesJobs(t *testing.T) {
+	db := setupTestDB(t)
+	queue := New(db)
+
+	started := make(chan struct{})
+	queue.Register("convert", DefaultRetryPolicy, func(ctx context.Context, job *models.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := queue.Start(1); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	job, _ := queue.Enqueue("convert", nil)
+	<-started
+	queue.Stop()
+
+	result := loadJob(t, db, job.ID)
+	if result.Status != models.JobPending || result.Attempts != 0 || result.LockedBy != "" || result.Error != "" {
+		t.Errorf("Expected the interrupted job to be released, got %+v", result)
+	}
+}
@@ -0,0 +1,259 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultDPI matches the resolution of common thermal label printers
+	DefaultDPI = 300
+	MinDPI     = 150
+	MaxDPI     = 600
+
+	// MinSizeMM and MaxSizeMM bound custom label dimensions
+	MinSizeMM = 15
+	MaxSizeMM = 200
+
+	// DefaultSize is the preset used when no size is requested
+	DefaultSize = "dk-11209"
+
+	marginMM    = 2.0
+	lineSpacing = 1.25
+	titleScale  = 1.4
+
+	// Body text is kept between these point sizes; lines that do not fit are dropped
+	minBodyPoints = 5.0
+	maxBodyPoints = 11.0
+)
+
+// Size is a label's printable area in millimetres
+type Size struct {
+	WidthMM  float64 `json:"width_mm"`
+	HeightMM float64 `json:"height_mm"`
+}
+
+// Presets are the label stock of common label printers, by stock name
+var Presets = map[string]Size{
+	"dk-11209":   {WidthMM: 62, HeightMM: 29},  // Brother QL small address
+	"dk-11202":   {WidthMM: 62, HeightMM: 100}, // Brother QL shipping
+	"dymo-11354": {WidthMM: 57, HeightMM: 32},  // Dymo LabelWriter multi-purpose
+	"dymo-99012": {WidthMM: 89, HeightMM: 36},  // Dymo LabelWriter large address
+	"zebra-2x1":  {WidthMM: 51, HeightMM: 25},  // 2" x 1" thermal
+	"zebra-4x2":  {WidthMM: 102, HeightMM: 51}, // 4" x 2" thermal
+}
+
+// PresetNames returns the preset names in alphabetical order
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSize resolves a preset name or a custom "<width>x<height>" size in millimetres
+func ParseSize(value string) (Size, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		value = DefaultSize
+	}
+	if size, ok := Presets[value]; ok {
+		return size, nil
+	}
+
+	width, height, ok := strings.Cut(value, "x")
+	if !ok {
+		return Size{}, fmt.Errorf("unknown label size %q (use one of %s or <width>x<height> in mm)", value, strings.Join(PresetNames(), ", "))
+	}
+	var size Size
+	var err error
+	if size.WidthMM, err = strconv.ParseFloat(width, 64); err != nil {
+		return Size{}, fmt.Errorf("invalid label width %q", width)
+	}
+	if size.HeightMM, err = strconv.ParseFloat(height, 64); err != nil {
+		return Size{}, fmt.Errorf("invalid label height %q", height)
+	}
+	return size, size.Validate()
+}
+
+// Validate checks the size is within the supported range
+func (s Size) Validate() error {
+	if s.WidthMM < MinSizeMM || s.WidthMM > MaxSizeMM || s.HeightMM < MinSizeMM || s.HeightMM > MaxSizeMM {
+		return fmt.Errorf("label width and height must be between %dmm and %dmm", MinSizeMM, MaxSizeMM)
+	}
+	return nil
+}
+
+// Pixels converts the size to pixels at dpi
+func (s Size) Pixels(dpi int) (int, int) {
+	return mmToPixels(s.WidthMM, dpi), mmToPixels(s.HeightMM, dpi)
+}
+
+// Label is the content printed on one label
+type Label struct {
+	Title string
+	Lines []string
+
+	// QR is encoded as a QR code next to the text; omitted when empty
+	QR string
+}
+
+var (
+	fontsOnce sync.Once
+	fontsErr  error
+	regular   *opentype.Font
+	bold      *opentype.Font
+)
+
+// loadFonts parses the embedded Go fonts once
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		if regular, fontsErr = opentype.Parse(goregular.TTF); fontsErr != nil {
+			return
+		}
+		bold, fontsErr = opentype.Parse(gobold.TTF)
+	})
+	return fontsErr
+}
+
+// Render draws the label as a black and white image at dpi. Landscape labels put
+// the QR code left of the text, portrait labels above it.
+func Render(l Label, size Size, dpi int) (*image.Gray, error) {
+	if err := size.Validate(); err != nil {
+		return nil, err
+	}
+	if dpi < MinDPI || dpi > MaxDPI {
+		return nil, fmt.Errorf("dpi must be between %d and %d", MinDPI, MaxDPI)
+	}
+	if err := loadFonts(); err != nil {
+		return nil, fmt.Errorf("failed to load fonts: %w", err)
+	}
+
+	width, height := size.Pixels(dpi)
+	margin := mmToPixels(marginMM, dpi)
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	text := image.Rect(margin, margin, width-margin, height-margin)
+	if l.QR != "" {
+		portrait := height > width
+		side := min(text.Dy(), text.Dx()*45/100)
+		if portrait {
+			side = min(text.Dx(), text.Dy()*55/100)
+		}
+
+		code, err := qrcode.New(l.QR, qrcode.Medium)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode QR code: %w", err)
+		}
+		code.DisableBorder = true
+
+		at := image.Pt(margin, margin+(text.Dy()-side)/2)
+		if portrait {
+			at = image.Pt(margin+(text.Dx()-side)/2, margin)
+			text.Min.Y += side + margin
+		} else {
+			text.Min.X += side + margin
+		}
+		qr := code.Image(side)
+		draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(qr.Bounds().Size())}, qr, qr.Bounds().Min, draw.Src)
+	}
+
+	if err := drawText(img, text, l, dpi); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// drawText fits the title and as many lines as readable into area
+func drawText(img *image.Gray, area image.Rectangle, l Label, dpi int) error {
+	if area.Dx() <= 0 || area.Dy() <= 0 {
+		return nil
+	}
+
+	lines := l.Lines
+	var bodyPoints float64
+	for {
+		units := lineSpacing * (titleScale + float64(len(lines)))
+		bodyPoints = min(pixelsToPoints(float64(area.Dy())/units, dpi), maxBodyPoints)
+		if bodyPoints >= minBodyPoints || len(lines) == 0 {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+
+	titleFace, err := opentype.NewFace(bold, &opentype.FaceOptions{Size: bodyPoints * titleScale, DPI: float64(dpi), Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer titleFace.Close()
+	bodyFace, err := opentype.NewFace(regular, &opentype.FaceOptions{Size: bodyPoints, DPI: float64(dpi), Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer bodyFace.Close()
+
+	y := area.Min.Y
+	drawLine(img, titleFace, area, &y, l.Title)
+	for _, line := range lines {
+		drawLine(img, bodyFace, area, &y, line)
+	}
+	return nil
+}
+
+// drawLine writes one line of text at *y, advancing it by the line height
+func drawLine(img *image.Gray, face font.Face, area image.Rectangle, y *int, text string) {
+	metrics := face.Metrics()
+	lineHeight := int(float64((metrics.Ascent + metrics.Descent).Ceil()) * lineSpacing)
+	if *y+lineHeight > area.Max.Y+lineHeight/5 {
+		return
+	}
+
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+		Dot:  fixed.P(area.Min.X, *y+metrics.Ascent.Ceil()),
+	}
+	drawer.DrawString(truncate(drawer, text, fixed.I(area.Dx())))
+	*y += lineHeight
+}
+
+// truncate shortens text with an ellipsis until it fits within width
+func truncate(drawer font.Drawer, text string, width fixed.Int26_6) string {
+	if drawer.MeasureString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if drawer.MeasureString(candidate) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func mmToPixels(mm float64, dpi int) int {
+	return int(mm/25.4*float64(dpi) + 0.5)
+}
+
+func pixelsToPoints(pixels float64, dpi int) float64 {
+	return pixels * 72 / float64(dpi)
+}
@@ -0,0 +1,136 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+)
+
+// TestParseSize tests preset and custom label sizes
+func TestParseSize(t *testing.T) {
+	testCases := map[string]Size{
+		"":           Presets[DefaultSize],
+		"dk-11202":   {WidthMM: 62, HeightMM: 100},
+		"ZEBRA-4X2":  {WidthMM: 102, HeightMM: 51},
+		"50x30":      {WidthMM: 50, HeightMM: 30},
+		" 40.5x20 ":  {WidthMM: 40.5, HeightMM: 20},
+		"dymo-99012": {WidthMM: 89, HeightMM: 36},
+	}
+	for value, expected := range testCases {
+		size, err := ParseSize(value)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", value, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("%q: expected %+v, got %+v", value, expected, size)
+		}
+	}
+
+	for _, value := range []string{"huge", "50", "ax30", "50xb", "10x30", "50x300"} {
+		if _, err := ParseSize(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+// darkPixels counts the non-white pixels of img within rect
+func darkPixels(img *image.Gray, rect image.Rectangle) int {
+	count := 0
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if img.GrayAt(x, y).Y < 128 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// TestRender tests the label layout at the requested resolution
+func TestRender(t *testing.T) {
+	content := Label{
+		Title: "Modular Drawer System With A Very Long Name",
+		Lines: []string{"by Jane", "PLA · 0.4mm nozzle", "#storage #organizer"},
+		QR:    "https://shelf.example.com/projects/7",
+	}
+
+	img, err := Render(content, Presets["dk-11209"], 300)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 732 || size.Y != 343 {
+		t.Errorf("Expected 732x343 pixels for 62x29mm at 300dpi, got %v", size)
+	}
+
+	// Landscape labels put the QR code on the left and the text on the right
+	left := image.Rect(0, 0, 732*40/100, 343)
+	right := image.Rect(732*50/100, 0, 732, 343)
+	if darkPixels(img, left) == 0 || darkPixels(img, right) == 0 {
+		t.Error("Expected both the QR code and the text to be drawn")
+	}
+
+	// Portrait labels stack the QR code above the text
+	img, err = Render(content, Presets["dk-11202"], 150)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	bounds := img.Bounds()
+	top := image.Rect(0, 0, bounds.Dx(), bounds.Dy()/2)
+	bottom := image.Rect(0, bounds.Dy()*6/10, bounds.Dx(), bounds.Dy())
+	if darkPixels(img, top) == 0 || darkPixels(img, bottom) == 0 {
+		t.Error("Expected the QR code above the text")
+	}
+
+	// Without a QR code the text starts at the left margin
+	img, err = Render(Label{Title: "Spool"}, Presets["zebra-2x1"], 300)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if darkPixels(img, image.Rect(0, 0, img.Bounds().Dx()/5, img.Bounds().Dy())) == 0 {
+		t.Error("Expected the title at the left of a label without a QR code")
+	}
+
+	if _, err := Render(content, Presets["dk-11209"], 72); err == nil {
+		t.Error("Expected an error for an unsupported dpi")
+	}
+	if _, err := Render(content, Size{WidthMM: 5, HeightMM: 5}, 300); err == nil {
+		t.Error("Expected an error for a label below the minimum size")
+	}
+}
+
+// TestWritePDF tests the PDF page is sized to the label
+func TestWritePDF(t *testing.T) {
+	size := Presets["zebra-4x2"]
+	img, err := Render(Label{Title: "Box 12", QR: "12"}, size, 150)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WritePDF(&out, img, size); err != nil {
+		t.Fatalf("WritePDF failed: %v", err)
+	}
+	pdf := out.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Error("Expected a complete PDF document")
+	}
+	if !strings.Contains(pdf, "/MediaBox [0 0 289.13 144.57]") {
+		t.Error("Expected the page to be 102x51mm in points")
+	}
+	if !strings.Contains(pdf, "/Width 602 /Height 301") {
+		t.Error("Expected the embedded image at the rendered resolution")
+	}
+
+	// The cross-reference table must point at each object
+	for _, object := range []string{"1 0 obj", "2 0 obj", "3 0 obj", "4 0 obj", "5 0 obj"} {
+		if !strings.Contains(pdf, object) {
+			t.Errorf("Expected %s in the PDF", object)
+		}
+	}
+	if !strings.Contains(pdf, "xref\n0 6\n") {
+		t.Error("Expected a cross-reference table for five objects")
+	}
+}
@@ -0,0 +1,41 @@
+package label
+
+import (
+	"3dshelf/pkg/pdf"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+)
+
+// WritePDF writes img as a single page PDF sized to the label, which print
+// dialogs and label printer drivers scale exactly
+func WritePDF(w io.Writer, img *image.Gray, size Size) error {
+	var pixels bytes.Buffer
+	compressor := zlib.NewWriter(&pixels)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := img.PixOffset(bounds.Min.X, y)
+		if _, err := compressor.Write(img.Pix[offset : offset+bounds.Dx()]); err != nil {
+			return err
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+
+	// PDF user space is in points
+	width := size.WidthMM / 25.4 * 72
+	height := size.HeightMM / 25.4 * 72
+	content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", width, height)
+
+	doc := pdf.New()
+	doc.Add("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	doc.Add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	doc.Add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>", width, height), nil)
+	doc.Add(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+		bounds.Dx(), bounds.Dy(), pixels.Len()), pixels.Bytes())
+	doc.Add(fmt.Sprintf("<< /Length %d >>", len(content)), []byte(content))
+	return doc.Encode(w)
+}
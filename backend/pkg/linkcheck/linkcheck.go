@@ -0,0 +1,423 @@
+package linkcheck
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/jobs"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// readmeFile is the README linking to a project's files, as read by scans
+	readmeFile = "README.md"
+
+	// DeliveryJobType identifies webhook deliveries of new issues in the job queue
+	DeliveryJobType = "link_check_webhook"
+)
+
+// Status is what the last check found
+type Status struct {
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Projects  int        `json:"projects"`
+	Issues    int        `json:"issues"`
+
+	// Error is why the last check failed
+	Error string `json:"error,omitempty"`
+}
+
+// Result is what a check found
+type Result struct {
+	Projects int                `json:"projects"`
+	Issues   []models.LinkIssue `json:"issues"`
+
+	// New are the issues the previous check didn't find
+	New []models.LinkIssue `json:"new"`
+}
+
+// Notification is posted to webhooks when a check finds new issues
+type Notification struct {
+	Event  string          `json:"event"`
+	Issues []NotifiedIssue `json:"issues"`
+}
+
+// NotifiedIssue is a new issue with the name of its project
+type NotifiedIssue struct {
+	models.LinkIssue
+	ProjectName string `json:"project_name"`
+}
+
+// delivery is the payload of a queued webhook delivery
+type delivery struct {
+	Webhook      string       `json:"webhook"`
+	Notification Notification `json:"notification"`
+}
+
+// Checker verifies that project source URLs still resolve and that the files
+// READMEs and file notes link to still exist, flagging projects with broken
+// links as inconsistent. It is safe for concurrent use; checks run one at a time.
+type Checker struct {
+	db    *gorm.DB
+	queue *jobs.Queue
+	HTTP  *http.Client
+
+	// Webhooks are posted the issues each check newly finds, by jobs retried
+	// until the webhook accepts them
+	Webhooks []string
+
+	checking sync.Mutex
+	mu       sync.RWMutex
+	status   Status
+}
+
+// New creates a Checker for the projects in db, delivering webhooks on queue
+func New(db *gorm.DB, queue *jobs.Queue) *Checker {
+	c := &Checker{
+		db:    db,
+		queue: queue,
+		HTTP:  &http.Client{Timeout: 30 * time.Second},
+	}
+	queue.Register(DeliveryJobType, jobs.DefaultRetryPolicy, c.deliver)
+	return c
+}
+
+// Status returns what the last check found, or nil when checking is off
+func (c *Checker) Status() *Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	status := c.status
+	return &status
+}
+
+// Run checks now and then every interval until ctx is done
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := c.Check(ctx); err != nil {
+			fmt.Printf("Warning: Link check failed: %v\n", err)
+		} else if len(result.New) > 0 {
+			fmt.Printf("Link check found %d new broken links across %d projects\n", len(result.New), result.Projects)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks every project, replacing the issues found by the previous
+// check and notifying webhooks of the new ones
+func (c *Checker) Check(ctx context.Context) (Result, error) {
+	c.checking.Lock()
+	defer c.checking.Unlock()
+
+	result, err := c.check(ctx)
+
+	c.mu.Lock()
+	now := time.Now()
+	c.status = Status{CheckedAt: &now, Projects: result.Projects, Issues: len(result.Issues)}
+	if err != nil {
+		c.status.Error = err.Error()
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.New) > 0 {
+		c.notify(result.New)
+	}
+	return result, nil
+}
+
+// check finds and saves the issues of every project
+func (c *Checker) check(ctx context.Context) (Result, error) {
+	result := Result{Issues: []models.LinkIssue{}, New: []models.LinkIssue{}}
+
+	var projects []models.Project
+	if err := c.db.Select("id", "name", "path", "layout", "source", "status", "link_check_flagged").Find(&projects).Error; err != nil {
+		return result, err
+	}
+	var profiles []models.FileProfile
+	if err := c.db.Where("notes <> ''").Order("filename ASC").Find(&profiles).Error; err != nil {
+		return result, err
+	}
+	notes := make(map[uint][]models.FileProfile)
+	for _, profile := range profiles {
+		notes[profile.ProjectID] = append(notes[profile.ProjectID], profile)
+	}
+
+	ids := make([]uint, 0, len(projects))
+	for _, project := range projects {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		found := c.checkProject(ctx, project, notes[project.ID])
+		added, err := c.save(project, found)
+		if err != nil {
+			return result, fmt.Errorf("saving link issues of project %d: %w", project.ID, err)
+		}
+		ids = append(ids, project.ID)
+		result.Projects++
+		result.Issues = append(result.Issues, found...)
+		result.New = append(result.New, added...)
+	}
+
+	// Projects deleted since the previous check have nothing left to flag
+	stale := c.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if len(ids) > 0 {
+		stale = stale.Where("project_id NOT IN ?", ids)
+	}
+	if err := stale.Delete(&models.LinkIssue{}).Error; err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// checkProject finds the broken links of a project
+func (c *Checker) checkProject(ctx context.Context, project models.Project, profiles []models.FileProfile) []models.LinkIssue {
+	now := time.Now()
+	var issues []models.LinkIssue
+
+	if IsWebURL(project.Source) {
+		if err := c.checkURL(ctx, project.Source); err != nil {
+			issues = append(issues, models.LinkIssue{
+				ProjectID: project.ID, Kind: models.LinkIssueSource, Target: project.Source, Detail: err.Error(),
+				FirstSeenAt: now, LastCheckedAt: now,
+			})
+		}
+	}
+
+	// Flat projects have no directory their links could be relative to
+	if project.IsFlat() {
+		return issues
+	}
+	type referrer struct{ name, text string }
+	var referrers []referrer
+	if content, err := os.ReadFile(filepath.Join(project.Path, readmeFile)); err == nil {
+		referrers = append(referrers, referrer{readmeFile, string(content)})
+	}
+	for _, profile := range profiles {
+		referrers = append(referrers, referrer{profile.Filename, profile.Notes})
+	}
+
+	seen := map[string]bool{}
+	for _, referrer := range referrers {
+		for _, target := range LocalLinks(referrer.text) {
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			if _, err := os.Stat(filepath.Join(project.Path, filepath.FromSlash(target))); os.IsNotExist(err) {
+				issues = append(issues, models.LinkIssue{
+					ProjectID: project.ID, Kind: models.LinkIssueMissingFile, Target: target, Referrer: referrer.name,
+					Detail: "file not found", FirstSeenAt: now, LastCheckedAt: now,
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// save replaces a project's issues with found, keeping when each was first
+// seen, and flags the project inconsistent while it has any. It returns the
+// issues that are new.
+func (c *Checker) save(project models.Project, found []models.LinkIssue) ([]models.LinkIssue, error) {
+	var added []models.LinkIssue
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var previous []models.LinkIssue
+		if err := tx.Where("project_id = ?", project.ID).Find(&previous).Error; err != nil {
+			return err
+		}
+		firstSeen := make(map[string]time.Time, len(previous))
+		for _, issue := range previous {
+			firstSeen[string(issue.Kind)+" "+issue.Target] = issue.FirstSeenAt
+		}
+
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.LinkIssue{}).Error; err != nil {
+			return err
+		}
+		for i := range found {
+			at, seen := firstSeen[string(found[i].Kind)+" "+found[i].Target]
+			if seen {
+				found[i].FirstSeenAt = at
+			}
+			if err := tx.Create(&found[i]).Error; err != nil {
+				return err
+			}
+			if !seen {
+				added = append(added, found[i])
+			}
+		}
+
+		// Only projects flagged by link checks are cleared by them; an
+		// inconsistency found by scans is left for scans to clear
+		switch {
+		case len(found) > 0 && project.Status == models.StatusHealthy:
+			return tx.Model(&project).Updates(map[string]interface{}{"status": models.StatusInconsistent, "link_check_flagged": true}).Error
+		case len(found) == 0 && project.LinkCheckFlagged:
+			updates := map[string]interface{}{"link_check_flagged": false}
+			if project.Status == models.StatusInconsistent {
+				updates["status"] = models.StatusHealthy
+			}
+			return tx.Model(&project).Updates(updates).Error
+		}
+		return nil
+	})
+	return added, err
+}
+
+// checkURL fails when target doesn't resolve to a successful response. Sites
+// that refuse HEAD requests are asked with GET.
+func (c *Checker) checkURL(ctx context.Context, target string) error {
+	status, err := c.request(ctx, http.MethodHead, target)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented || status == http.StatusForbidden) {
+		status, err = c.request(ctx, http.MethodGet, target)
+	}
+	if err != nil {
+		return err
+	}
+	if status >= 400 {
+		return fmt.Errorf("returned %d %s", status, http.StatusText(status))
+	}
+	return nil
+}
+
+// request sends a request and returns the response status
+func (c *Checker) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, nil
+}
+
+// notify queues a delivery of the new issues to every webhook, each retried
+// on its own so one unreachable webhook doesn't hold up the others
+func (c *Checker) notify(issues []models.LinkIssue) {
+	if len(c.Webhooks) == 0 {
+		return
+	}
+
+	names := map[uint]string{}
+	var projects []models.Project
+	if err := c.db.Select("id", "name").Find(&projects).Error; err == nil {
+		for _, project := range projects {
+			names[project.ID] = project.Name
+		}
+	}
+	notification := Notification{Event: "link_issues", Issues: make([]NotifiedIssue, len(issues))}
+	for i, issue := range issues {
+		notification.Issues[i] = NotifiedIssue{LinkIssue: issue, ProjectName: names[issue.ProjectID]}
+	}
+
+	for _, webhook := range c.Webhooks {
+		if _, err := c.queue.Enqueue(DeliveryJobType, delivery{Webhook: webhook, Notification: notification}); err != nil {
+			fmt.Printf("Warning: Failed to queue notifying %s of broken links: %v\n", webhook, err)
+		}
+	}
+}
+
+// deliver posts a queued notification to its webhook
+func (c *Checker) deliver(ctx context.Context, job *models.Job) error {
+	var payload delivery
+	if err := job.DecodePayload(&payload); err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload.Notification)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, payload.Webhook, body)
+}
+
+// post sends body as JSON to a webhook
+func (c *Checker) post(ctx context.Context, webhook string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var (
+	// markdownLink matches the target of Markdown links and images
+	markdownLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+["'][^"']*["'])?\s*\)`)
+	// htmlLink matches the target of HTML links and images
+	htmlLink = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+)
+
+// LocalLinks returns the files a Markdown text links to, as clean paths
+// relative to its directory, in order and without duplicates. Web links,
+// anchors, absolute paths and paths leaving the directory are left out.
+func LocalLinks(text string) []string {
+	var links []string
+	seen := map[string]bool{}
+	for _, pattern := range []*regexp.Regexp{markdownLink, htmlLink} {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			target, ok := localTarget(match[1])
+			if ok && !seen[target] {
+				seen[target] = true
+				links = append(links, target)
+			}
+		}
+	}
+	return links
+}
+
+// localTarget returns the path a link target points at within the directory
+func localTarget(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(u.Path)))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+// IsWebURL reports whether raw is an absolute http or https URL; other
+// sources, such as a designer's name, aren't checked
+func IsWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
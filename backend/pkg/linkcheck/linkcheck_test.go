@@ -0,0 +1,178 @@
+package linkcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates an in-memory database with the application schema
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// deliverQueued runs the webhook deliveries queued so far, returning how many
+// there were and how many failed
+func deliverQueued(db *gorm.DB, checker *Checker) (queued, failed int) {
+	var deliveries []models.Job
+	db.Where("type = ? AND status = ?", DeliveryJobType, models.JobPending).Order("id").Find(&deliveries)
+	for _, job := range deliveries {
+		if err := checker.deliver(context.Background(), &job); err != nil {
+			failed++
+		}
+		db.Model(&job).Update("status", models.JobCompleted)
+	}
+	return len(deliveries), failed
+}
+
+func TestLocalLinks(t *testing.T) {
+	text := "![Assembled](images/done.jpg) See [the manual](docs/manual.pdf \"Manual\") and [the source](https://example.com/thing).\n" +
+		"[Top](#top) [mail](mailto:me@example.com) [up](../other/file.stl) [root](/etc/passwd)\n" +
+		`<img src="./images/done.jpg" alt="again"> <a href='parts/lid%20v2.stl'>Lid</a>`
+	expected := []string{"images/done.jpg", "docs/manual.pdf", "parts/lid v2.stl"}
+	if got := LocalLinks(text); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+
+	var notified []Notification
+	mux := http.NewServeMux()
+	mux.HandleFunc("/thing/1", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/thing/2", func(w http.ResponseWriter, r *http.Request) {
+		// Some sites only answer GET
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) })
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		json.NewDecoder(r.Body).Decode(&notification)
+		notified = append(notified, notification)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	broken := models.Project{Name: "Broken", Path: filepath.Join(dir, "broken"), Source: server.URL + "/gone"}
+	fine := models.Project{Name: "Fine", Path: filepath.Join(dir, "fine"), Source: server.URL + "/thing/1"}
+	headless := models.Project{Name: "Headless", Path: filepath.Join(dir, "headless"), Source: server.URL + "/thing/2"}
+	named := models.Project{Name: "Named", Path: filepath.Join(dir, "named"), Source: "Designed by Jo", Status: models.StatusInconsistent}
+	for _, project := range []*models.Project{&broken, &fine, &headless, &named} {
+		db.Create(project)
+		os.MkdirAll(filepath.Join(project.Path, "images"), 0755)
+	}
+	os.WriteFile(filepath.Join(broken.Path, "README.md"), []byte("![Done](images/done.jpg) [Manual](manual.pdf)"), 0644)
+	os.WriteFile(filepath.Join(fine.Path, "README.md"), []byte("![Done](images/done.jpg)"), 0644)
+	os.WriteFile(filepath.Join(fine.Path, "images", "done.jpg"), []byte("JPEG"), 0644)
+	// A scan found Named inconsistent before its README link broke
+	os.WriteFile(filepath.Join(named.Path, "README.md"), []byte("[Manual](manual.pdf)"), 0644)
+	db.Create(&models.FileProfile{ProjectID: fine.ID, Filename: "hull.stl", Notes: "Orient as in [the photo](images/orientation.png)"})
+
+	checker := New(db, jobs.New(db))
+	checker.HTTP = server.Client()
+	checker.Webhooks = []string{server.URL + "/hook", server.URL + "/down"}
+
+	result, err := checker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if result.Projects != 4 || len(result.Issues) != 5 || len(result.New) != 5 {
+		t.Fatalf("Expected 5 issues across 4 projects, got %+v", result)
+	}
+
+	var issues []models.LinkIssue
+	db.Order("project_id, kind, target").Find(&issues)
+	got := make([][3]string, len(issues))
+	for i, issue := range issues {
+		got[i] = [3]string{string(issue.Kind), issue.Target, issue.Referrer}
+	}
+	expected := [][3]string{
+		{string(models.LinkIssueMissingFile), "images/done.jpg", "README.md"},
+		{string(models.LinkIssueMissingFile), "manual.pdf", "README.md"},
+		{string(models.LinkIssueSource), server.URL + "/gone", ""},
+		{string(models.LinkIssueMissingFile), "images/orientation.png", "hull.stl"},
+		{string(models.LinkIssueMissingFile), "manual.pdf", "README.md"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected issues %v, got %v", expected, got)
+	}
+
+	var statuses []models.ProjectStatus
+	db.Model(&models.Project{}).Order("id").Pluck("status", &statuses)
+	if !reflect.DeepEqual(statuses, []models.ProjectStatus{models.StatusInconsistent, models.StatusInconsistent, models.StatusHealthy, models.StatusInconsistent}) {
+		t.Errorf("Expected projects with broken links flagged, got %v", statuses)
+	}
+
+	// Webhooks are notified by queued deliveries, and the one failing is retried by the queue
+	if len(notified) != 0 {
+		t.Errorf("Expected the check not to post webhooks itself, got %d", len(notified))
+	}
+	if queued, failed := deliverQueued(db, checker); queued != 2 || failed != 1 {
+		t.Errorf("Expected a delivery per webhook with the unavailable one failing, got %d (%d failed)", queued, failed)
+	}
+	if len(notified) != 1 || notified[0].Event != "link_issues" || len(notified[0].Issues) != 5 || notified[0].Issues[0].ProjectName != "Broken" {
+		t.Fatalf("Expected one notification of the new issues, got %+v", notified)
+	}
+
+	// Fixed links are cleared, and issues already notified aren't notified again
+	os.WriteFile(filepath.Join(fine.Path, "images", "orientation.png"), []byte("PNG"), 0644)
+	os.WriteFile(filepath.Join(broken.Path, "manual.pdf"), []byte("PDF"), 0644)
+	os.WriteFile(filepath.Join(named.Path, "manual.pdf"), []byte("PDF"), 0644)
+	if result, err = checker.Check(context.Background()); err != nil || len(result.Issues) != 2 || len(result.New) != 0 {
+		t.Fatalf("Expected the two remaining issues and none new, got %+v (%v)", result, err)
+	}
+	if queued, _ := deliverQueued(db, checker); queued != 0 || len(notified) != 1 {
+		t.Errorf("Expected no notification without new issues, got %d queued", queued)
+	}
+	db.First(&fine, fine.ID)
+	if fine.Status != models.StatusHealthy {
+		t.Errorf("Expected the fixed project healthy again, got %s", fine.Status)
+	}
+	db.First(&named, named.ID)
+	if named.Status != models.StatusInconsistent {
+		t.Errorf("Expected the inconsistency link checks didn't flag kept, got %s", named.Status)
+	}
+	var kept models.LinkIssue
+	db.Where("project_id = ? AND target = ?", broken.ID, "images/done.jpg").First(&kept)
+	if !kept.FirstSeenAt.Equal(issues[0].FirstSeenAt) || !kept.LastCheckedAt.After(issues[0].LastCheckedAt) {
+		t.Errorf("Expected the issue to keep when it was first seen, got %v (last checked %v)", kept.FirstSeenAt, kept.LastCheckedAt)
+	}
+
+	// Issues of deleted projects go with them
+	db.Delete(&broken)
+	if result, err = checker.Check(context.Background()); err != nil || len(result.Issues) != 0 {
+		t.Fatalf("Expected no issues left, got %+v (%v)", result, err)
+	}
+	var count int64
+	db.Model(&models.LinkIssue{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the deleted project's issues removed, got %d", count)
+	}
+	if status := checker.Status(); status.CheckedAt == nil || status.Projects != 3 || status.Issues != 0 {
+		t.Errorf("Expected the last check's status, got %+v", status)
+	}
+}
@@ -0,0 +1,101 @@
+package maintenance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// SettingKey is the settings key the maintenance state is persisted under
+	SettingKey = "maintenance"
+
+	// DefaultRetryAfter is suggested to refused clients when no estimate was given
+	DefaultRetryAfter = 5 * time.Minute
+
+	// maxMessageLength bounds the banner message shown to clients
+	maxMessageLength = 500
+)
+
+// State describes whether the API is in read-only maintenance mode
+type State struct {
+	Enabled bool `json:"enabled"`
+
+	// Message is shown to users, such as "Backing up the library"
+	Message string `json:"message,omitempty"`
+
+	// Since is when maintenance mode was switched on
+	Since *time.Time `json:"since,omitempty"`
+
+	// RetryAfterSeconds is the estimate sent with refused requests; 0 uses DefaultRetryAfter
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+}
+
+// Validate checks the state's estimate and message
+func (s State) Validate() error {
+	if s.RetryAfterSeconds < 0 {
+		return fmt.Errorf("retry_after_seconds %d is not valid (must not be negative)", s.RetryAfterSeconds)
+	}
+	if len(s.Message) > maxMessageLength {
+		return fmt.Errorf("message is longer than %d characters", maxMessageLength)
+	}
+	return nil
+}
+
+// RetryAfter is how long refused clients should wait before trying again
+func (s State) RetryAfter() time.Duration {
+	if s.RetryAfterSeconds > 0 {
+		return time.Duration(s.RetryAfterSeconds) * time.Second
+	}
+	return DefaultRetryAfter
+}
+
+// Mode holds the instance's maintenance state; it is safe for concurrent use.
+// A nil Mode is never in maintenance.
+type Mode struct {
+	mu    sync.RWMutex
+	state State
+}
+
+// New returns a Mode with maintenance off
+func New() *Mode {
+	return &Mode{}
+}
+
+// Get returns the current state
+func (m *Mode) Get() State {
+	if m == nil {
+		return State{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Enabled reports whether the API is read-only
+func (m *Mode) Enabled() bool {
+	return m.Get().Enabled
+}
+
+// Set validates and applies state, returning it as applied. Switching
+// maintenance on records when it started, keeping the start of maintenance
+// already under way; switching it off clears the state.
+func (m *Mode) Set(state State) (State, error) {
+	if err := state.Validate(); err != nil {
+		return State{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case !state.Enabled:
+		state = State{}
+	case m.state.Enabled && m.state.Since != nil:
+		state.Since = m.state.Since
+	case state.Since == nil:
+		now := time.Now()
+		state.Since = &now
+	}
+	m.state = state
+	return state, nil
+}
@@ -0,0 +1,44 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMode(t *testing.T) {
+	var nilMode *Mode
+	if nilMode.Enabled() {
+		t.Error("Expected a nil mode never to be in maintenance")
+	}
+
+	mode := New()
+	if mode.Enabled() {
+		t.Fatal("Expected maintenance to be off by default")
+	}
+
+	state, err := mode.Set(State{Enabled: true, Message: "Backing up"})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !mode.Enabled() || state.Since == nil || state.RetryAfter() != DefaultRetryAfter {
+		t.Errorf("Expected maintenance on since now with the default estimate, got %+v", state)
+	}
+
+	// Updating the message keeps when maintenance started
+	since := *state.Since
+	state, _ = mode.Set(State{Enabled: true, Message: "Almost done", RetryAfterSeconds: 60})
+	if !state.Since.Equal(since) || state.RetryAfter() != time.Minute || mode.Get().Message != "Almost done" {
+		t.Errorf("Expected the update applied with the original start, got %+v", state)
+	}
+
+	if _, err := mode.Set(State{Enabled: true, RetryAfterSeconds: -1}); err == nil {
+		t.Error("Expected error for a negative estimate")
+	}
+	if mode.Get().Message != "Almost done" {
+		t.Error("Expected an invalid state not to be applied")
+	}
+
+	if state, _ = mode.Set(State{Message: "ignored"}); state != (State{}) || mode.Enabled() {
+		t.Errorf("Expected switching off to clear the state, got %+v", state)
+	}
+}
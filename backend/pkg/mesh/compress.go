@@ -0,0 +1,106 @@
+package mesh
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressedExt is appended to the name of models stored zstd-compressed,
+// as in benchy.stl.zst
+const CompressedExt = ".zst"
+
+// IsCompressed reports whether the file at path is stored compressed, by its name
+func IsCompressed(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), CompressedExt)
+}
+
+// decompressedFile reads a compressed file as its original content
+type decompressedFile struct {
+	*zstd.Decoder
+	file *os.File
+}
+
+func (d decompressedFile) Close() error {
+	d.Decoder.Close()
+	return d.file.Close()
+}
+
+// Open opens a model file for reading its content, decompressing files
+// stored compressed, and returns the size of the content
+func Open(path string) (io.ReadCloser, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	if !IsCompressed(path) {
+		return file, info.Size(), nil
+	}
+
+	size, err := decompressedSize(file)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	decoder, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return decompressedFile{Decoder: decoder, file: file}, size, nil
+}
+
+// decompressedSize returns the size of a compressed file's content: the one
+// recorded in its frame header, or the one counted by decompressing it when
+// the compressor didn't record it. The file is left at its start.
+func decompressedSize(file *os.File) (int64, error) {
+	header := make([]byte, zstd.HeaderMaxSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	var frame zstd.Header
+	if err := frame.Decode(header[:n]); err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if frame.HasFCS {
+		return int64(frame.FrameContentSize), nil
+	}
+
+	decoder, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(io.Discard, decoder)
+	decoder.Close()
+	if err != nil {
+		return 0, err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return size, err
+}
+
+// Compress writes size bytes of r to w compressed, recording the size so
+// readers needn't decompress the content to learn it
+func Compress(w io.Writer, r io.Reader, size int64) error {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return err
+	}
+	encoder.ResetContentSize(w, size)
+	if _, err := io.Copy(encoder, r); err != nil {
+		encoder.Close()
+		return err
+	}
+	return encoder.Close()
+}
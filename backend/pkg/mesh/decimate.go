@@ -0,0 +1,114 @@
+package mesh
+
+import "sort"
+
+// maxClusterGrid bounds the cells per axis Decimate tries; cell indexes are
+// packed in 21 bits each
+const maxClusterGrid = 1 << 12
+
+// BinarySTLSize returns the size of a binary STL holding triangles
+func BinarySTLSize(triangles int) int64 {
+	return binaryHeaderSize + int64(triangles)*binaryTriangleSize
+}
+
+// Decimate returns a low-poly copy of the mesh with at most maxTriangles
+// triangles, for previews. Vertices are clustered on the finest cubic grid
+// over the mesh's bounds that keeps within the budget, each cell's vertices
+// are merged into their mean, and triangles that collapse are dropped, so the
+// shape and its outline are kept while fine detail goes. Meshes within the
+// budget are returned as they are.
+func Decimate(m *Mesh, maxTriangles int) *Mesh {
+	if maxTriangles <= 0 || len(m.Triangles) <= maxTriangles {
+		return m
+	}
+
+	lo, hi := m.Bounds()
+	extent := max(hi[0]-lo[0], hi[1]-lo[1], hi[2]-lo[2])
+	if extent == 0 {
+		return &Mesh{}
+	}
+
+	// Finer grids keep more triangles; search for the finest within the budget
+	best := &clustering{}
+	low, high := 1, maxClusterGrid
+	for low <= high {
+		grid := (low + high) / 2
+		if candidate, ok := cluster(m, lo, extent, grid, maxTriangles); ok {
+			best = candidate
+			low = grid + 1
+		} else {
+			high = grid - 1
+		}
+	}
+	return best.mesh()
+}
+
+// clustering is a mesh's faces over its merged vertices: the sum and count
+// of the vertices merged into each
+type clustering struct {
+	sums   []Vec3
+	counts []float64
+	faces  [][3]int
+}
+
+// cluster merges the mesh's vertices on a grid of grid cells per axis over
+// the cube of the given extent at lo. It gives up once more than
+// maxTriangles faces remain.
+func cluster(m *Mesh, lo Vec3, extent float64, grid, maxTriangles int) (*clustering, bool) {
+	scale := float64(grid) / extent
+	cellOf := func(v Vec3) uint64 {
+		var key uint64
+		for axis := range 3 {
+			cell := int((v[axis] - lo[axis]) * scale)
+			cell = min(max(cell, 0), grid-1)
+			key = key<<21 | uint64(cell)
+		}
+		return key
+	}
+
+	c := &clustering{}
+	cells := make(map[uint64]int)
+	seen := make(map[[3]int]bool)
+	for _, t := range m.Triangles {
+		var face [3]int
+		for i, v := range t {
+			key := cellOf(v)
+			index, ok := cells[key]
+			if !ok {
+				index = len(c.sums)
+				cells[key] = index
+				c.sums = append(c.sums, Vec3{})
+				c.counts = append(c.counts, 0)
+			}
+			c.sums[index] = c.sums[index].Add(v)
+			c.counts[index]++
+			face[i] = index
+		}
+		if face[0] == face[1] || face[1] == face[2] || face[0] == face[2] {
+			continue
+		}
+
+		// Triangles merged onto the same cells are kept once
+		sorted := face
+		sort.Ints(sorted[:])
+		if seen[sorted] {
+			continue
+		}
+		seen[sorted] = true
+		if c.faces = append(c.faces, face); len(c.faces) > maxTriangles {
+			return nil, false
+		}
+	}
+	return c, true
+}
+
+// mesh places each face's vertices at the mean of the vertices merged into them
+func (c *clustering) mesh() *Mesh {
+	m := &Mesh{Triangles: make([]Triangle, len(c.faces))}
+	for i, face := range c.faces {
+		for j, index := range face {
+			m.Triangles[i][j] = c.sums[index].Scale(1 / c.counts[index])
+		}
+	}
+	return m
+}
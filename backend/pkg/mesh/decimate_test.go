@@ -0,0 +1,61 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// sphere returns a UV sphere of the given radius at the origin, with
+// 2*rings*segments triangles
+func sphere(radius float64, rings, segments int) *Mesh {
+	point := func(ring, segment int) Vec3 {
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(segment) / float64(segments)
+		return Vec3{radius * math.Sin(theta) * math.Cos(phi), radius * math.Sin(theta) * math.Sin(phi), radius * math.Cos(theta)}
+	}
+	m := &Mesh{}
+	for ring := range rings {
+		for segment := range segments {
+			a, b := point(ring, segment), point(ring, segment+1)
+			c, d := point(ring+1, segment+1), point(ring+1, segment)
+			m.Triangles = append(m.Triangles, Triangle{a, d, c}, Triangle{a, c, b})
+		}
+	}
+	return m
+}
+
+func TestDecimate(t *testing.T) {
+	model := sphere(20, 200, 200)
+	decimated := Decimate(model, 2000)
+
+	if count := len(decimated.Triangles); count > 2000 || count < 500 {
+		t.Fatalf("Expected close to 2000 triangles, got %d", count)
+	}
+	lo, hi := decimated.Bounds()
+	for axis := range 3 {
+		if math.Abs(lo[axis]+20) > 2 || math.Abs(hi[axis]-20) > 2 {
+			t.Errorf("Expected the outline kept within a cell, got bounds %v %v", lo, hi)
+		}
+	}
+	if ratio := decimated.Volume() / model.Volume(); ratio < 0.9 || ratio > 1.05 {
+		t.Errorf("Expected the volume roughly kept, got %.2f of it", ratio)
+	}
+	for _, tri := range decimated.Triangles {
+		if tri.Area() == 0 {
+			t.Fatalf("Expected no collapsed triangles, got %v", tri)
+		}
+	}
+}
+
+func TestDecimateWithinBudget(t *testing.T) {
+	model := box(10, 20, 30)
+	if decimated := Decimate(model, 12); decimated != model {
+		t.Error("Expected a mesh within the budget returned as is")
+	}
+	if decimated := Decimate(model, 0); decimated != model {
+		t.Error("Expected no budget to keep the mesh")
+	}
+	if size := BinarySTLSize(12); size != 84+12*50 {
+		t.Errorf("Expected the size of a 12 triangle binary STL, got %d", size)
+	}
+}
@@ -0,0 +1,200 @@
+package mesh
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// FingerprintVersion changes whenever fingerprints are computed differently,
+// so stored ones can be recomputed
+const FingerprintVersion = 1
+
+// Fingerprint sampling: surface points, point pairs, and histogram bins of
+// the pair distances and of the points' distances from the centroid, both
+// relative to their mean
+const (
+	fingerprintPoints = 1024
+	fingerprintPairs  = 4096
+	distanceBins      = 32
+	radialBins        = 16
+	maxRelative       = 3.0
+)
+
+// Fingerprint is a coarse description of a mesh's shape that ignores where
+// it sits, how it is turned and how large it is: the distribution of
+// distances between random points on its surface, the distribution of their
+// distances from its centroid, and how elongated and flat it is. Remixes and
+// re-exports of a model, whose triangles differ, have close fingerprints.
+type Fingerprint []float64
+
+// fingerprintLength is the number of values in a fingerprint: both
+// histograms and the two shape ratios
+const fingerprintLength = distanceBins + radialBins + 2
+
+// ComputeFingerprint fingerprints a mesh. Points are sampled with a fixed
+// seed, so the same mesh always gives the same fingerprint.
+func ComputeFingerprint(m *Mesh) (Fingerprint, error) {
+	points, err := samplePoints(m, fingerprintPoints)
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewPCG(3, 7))
+
+	var centroid Vec3
+	for _, p := range points {
+		centroid = centroid.Add(p)
+	}
+	centroid = centroid.Scale(1 / float64(len(points)))
+
+	fingerprint := make(Fingerprint, fingerprintLength)
+
+	distances := make([]float64, fingerprintPairs)
+	for i := range distances {
+		distances[i] = points[rng.IntN(len(points))].Sub(points[rng.IntN(len(points))]).Length()
+	}
+	histogram(fingerprint[:distanceBins], distances)
+
+	radii := make([]float64, len(points))
+	for i, p := range points {
+		radii[i] = p.Sub(centroid).Length()
+	}
+	histogram(fingerprint[distanceBins:distanceBins+radialBins], radii)
+
+	// The spread along the principal axes, relative to the largest, tells rods
+	// and plates from blobs
+	spread := principalSpread(points, centroid)
+	if spread[0] > 0 {
+		fingerprint[fingerprintLength-2] = math.Sqrt(spread[1] / spread[0])
+		fingerprint[fingerprintLength-1] = math.Sqrt(spread[2] / spread[0])
+	}
+	return fingerprint, nil
+}
+
+// Similarity scores how alike two fingerprints are, from 0 to 1 for
+// identical shapes; fingerprints of different versions score 0
+func Similarity(a, b Fingerprint) float64 {
+	if len(a) != fingerprintLength || len(b) != fingerprintLength {
+		return 0
+	}
+
+	// Each histogram sums to 1, so their L1 distance is at most 2
+	var distances, radii, ratios float64
+	for i := range distanceBins {
+		distances += math.Abs(a[i] - b[i])
+	}
+	for i := distanceBins; i < distanceBins+radialBins; i++ {
+		radii += math.Abs(a[i] - b[i])
+	}
+	for i := distanceBins + radialBins; i < fingerprintLength; i++ {
+		ratios += math.Abs(a[i] - b[i])
+	}
+	difference := 0.4*distances/2 + 0.3*radii/2 + 0.3*ratios/2
+	return math.Max(0, 1-difference)
+}
+
+// histogram bins values relative to their mean into bins, normalized to sum to 1
+func histogram(bins []float64, values []float64) {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if mean == 0 {
+		bins[0] = 1
+		return
+	}
+
+	for _, v := range values {
+		bin := int(v / mean / maxRelative * float64(len(bins)))
+		bins[min(bin, len(bins)-1)]++
+	}
+	for i := range bins {
+		bins[i] /= float64(len(values))
+	}
+}
+
+// samplePoints picks count points uniformly over the mesh's surface
+func samplePoints(m *Mesh, count int) ([]Vec3, error) {
+	cumulative := make([]float64, len(m.Triangles))
+	var total float64
+	for i, t := range m.Triangles {
+		total += t.Area()
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return nil, ErrEmptyMesh
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	points := make([]Vec3, count)
+	for i := range points {
+		t := m.Triangles[sort.SearchFloat64s(cumulative, rng.Float64()*total)]
+		// Folding the unit square onto the triangle keeps points uniform
+		u, v := rng.Float64(), rng.Float64()
+		if u+v > 1 {
+			u, v = 1-u, 1-v
+		}
+		points[i] = t[0].Add(t[1].Sub(t[0]).Scale(u)).Add(t[2].Sub(t[0]).Scale(v))
+	}
+	return points, nil
+}
+
+// principalSpread returns the variances of the points along their principal
+// axes, largest first
+func principalSpread(points []Vec3, centroid Vec3) [3]float64 {
+	var cov [3][3]float64
+	for _, p := range points {
+		d := p.Sub(centroid)
+		for i := range 3 {
+			for j := range 3 {
+				cov[i][j] += d[i] * d[j]
+			}
+		}
+	}
+	for i := range 3 {
+		for j := range 3 {
+			cov[i][j] /= float64(len(points))
+		}
+	}
+
+	values := symmetricEigenvalues(cov)
+	sort.Sort(sort.Reverse(sort.Float64Slice(values[:])))
+	return values
+}
+
+// symmetricEigenvalues finds the eigenvalues of a symmetric 3x3 matrix with
+// Jacobi rotations
+func symmetricEigenvalues(a [3][3]float64) [3]float64 {
+	for range 50 {
+		// Zero the largest off-diagonal element
+		p, q := 0, 1
+		for i := range 3 {
+			for j := i + 1; j < 3; j++ {
+				if math.Abs(a[i][j]) > math.Abs(a[p][q]) {
+					p, q = i, j
+				}
+			}
+		}
+		if math.Abs(a[p][q]) < 1e-12 {
+			break
+		}
+
+		theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+		t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+		c := 1 / math.Sqrt(t*t+1)
+		s := t * c
+
+		for k := range 3 {
+			akp, akq := a[k][p], a[k][q]
+			a[k][p] = c*akp - s*akq
+			a[k][q] = s*akp + c*akq
+		}
+		for k := range 3 {
+			apk, aqk := a[p][k], a[q][k]
+			a[p][k] = c*apk - s*aqk
+			a[q][k] = s*apk + c*aqk
+		}
+	}
+	return [3]float64{math.Max(a[0][0], 0), math.Max(a[1][1], 0), math.Max(a[2][2], 0)}
+}
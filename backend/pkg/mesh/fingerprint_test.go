@@ -0,0 +1,80 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// transform rotates a mesh about the z and x axes, scales and moves it
+func transform(m *Mesh, angle, scale float64, offset Vec3) *Mesh {
+	c, s := math.Cos(angle), math.Sin(angle)
+	out := &Mesh{}
+	for _, t := range m.Triangles {
+		var moved Triangle
+		for i, v := range t {
+			v = Vec3{c*v[0] - s*v[1], s*v[0] + c*v[1], v[2]}
+			v = Vec3{v[0], c*v[1] - s*v[2], s*v[1] + c*v[2]}
+			moved[i] = v.Scale(scale).Add(offset)
+		}
+		out.Triangles = append(out.Triangles, moved)
+	}
+	return out
+}
+
+// subdivide splits every triangle into four, as a finer export would
+func subdivide(m *Mesh) *Mesh {
+	out := &Mesh{}
+	for _, t := range m.Triangles {
+		a, b, c := t[0].Add(t[1]).Scale(0.5), t[1].Add(t[2]).Scale(0.5), t[2].Add(t[0]).Scale(0.5)
+		out.Triangles = append(out.Triangles, Triangle{t[0], a, c}, Triangle{a, t[1], b}, Triangle{c, b, t[2]}, Triangle{a, b, c})
+	}
+	return out
+}
+
+func fingerprint(t *testing.T, m *Mesh) Fingerprint {
+	f, err := ComputeFingerprint(m)
+	if err != nil {
+		t.Fatalf("ComputeFingerprint failed: %v", err)
+	}
+	return f
+}
+
+func TestFingerprintSimilarity(t *testing.T) {
+	cube := fingerprint(t, box(10, 10, 10))
+
+	if score := Similarity(cube, fingerprint(t, box(10, 10, 10))); score != 1 {
+		t.Errorf("Expected the same mesh to score 1, got %f", score)
+	}
+
+	moved := fingerprint(t, subdivide(transform(box(10, 10, 10), 0.7, 3, Vec3{50, -20, 8})))
+	movedScore := Similarity(cube, moved)
+	if movedScore < 0.9 {
+		t.Errorf("Expected a moved, turned, scaled and retessellated cube to score at least 0.9, got %f", movedScore)
+	}
+
+	rod := fingerprint(t, box(2, 2, 40))
+	plate := fingerprint(t, box(40, 40, 1))
+	if Similarity(cube, rod) >= movedScore || Similarity(cube, plate) >= movedScore || Similarity(rod, plate) >= movedScore {
+		t.Errorf("Expected different shapes to score below the moved cube (%f): rod %f, plate %f, rod/plate %f",
+			movedScore, Similarity(cube, rod), Similarity(cube, plate), Similarity(rod, plate))
+	}
+
+	if Similarity(cube, Fingerprint{1, 2}) != 0 {
+		t.Error("Expected fingerprints of another length to score 0")
+	}
+	if _, err := ComputeFingerprint(&Mesh{Triangles: []Triangle{{}}}); err == nil {
+		t.Error("Expected an error for a mesh without area")
+	}
+}
+
+func TestSymmetricEigenvalues(t *testing.T) {
+	values := symmetricEigenvalues([3][3]float64{{2, 1, 0}, {1, 2, 0}, {0, 0, 5}})
+	expected := map[float64]bool{1: true, 3: true, 5: true}
+	for _, v := range values {
+		rounded := math.Round(v*1e9) / 1e9
+		if !expected[rounded] {
+			t.Errorf("Unexpected eigenvalue %f in %v", v, values)
+		}
+		delete(expected, rounded)
+	}
+}
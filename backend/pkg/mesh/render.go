@@ -0,0 +1,199 @@
+package mesh
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// View is a standard angle models are rendered from. Models are taken to be
+// Z-up, as slicers lay them on the bed.
+type View string
+
+const (
+	ViewIso    View = "iso"
+	ViewFront  View = "front"
+	ViewBack   View = "back"
+	ViewLeft   View = "left"
+	ViewRight  View = "right"
+	ViewTop    View = "top"
+	ViewBottom View = "bottom"
+)
+
+// Views lists the angles models can be rendered from, the default first
+var Views = []View{ViewIso, ViewFront, ViewBack, ViewLeft, ViewRight, ViewTop, ViewBottom}
+
+// cameras are the direction each view looks at the model from and which way
+// is up on screen
+var cameras = map[View]struct{ eye, up Vec3 }{
+	ViewIso:    {Vec3{1, -1, 1}, Vec3{0, 0, 1}},
+	ViewFront:  {Vec3{0, -1, 0}, Vec3{0, 0, 1}},
+	ViewBack:   {Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+	ViewLeft:   {Vec3{-1, 0, 0}, Vec3{0, 0, 1}},
+	ViewRight:  {Vec3{1, 0, 0}, Vec3{0, 0, 1}},
+	ViewTop:    {Vec3{0, 0, 1}, Vec3{0, 1, 0}},
+	ViewBottom: {Vec3{0, 0, -1}, Vec3{0, -1, 0}},
+}
+
+const (
+	// renderSupersampling renders at this many times the size and scales
+	// down, smoothing edges
+	renderSupersampling = 2
+
+	// renderMargin is the share of each side left empty around the model
+	renderMargin = 0.05
+
+	// renderAmbient is how lit faces turned away from the light still are
+	renderAmbient = 0.3
+)
+
+// renderColor is the colour models are shaded in
+var renderColor = color.RGBA{R: 96, G: 142, B: 204, A: 255}
+
+// ValidView reports whether models can be rendered from view
+func ValidView(view View) bool {
+	_, ok := cameras[view]
+	return ok
+}
+
+// Render draws a shaded image of the mesh from a standard angle, fitted into
+// a size x size square on a transparent background. The projection is
+// orthographic and faces are flat shaded both ways round, so meshes with
+// inconsistent winding render whole. Unknown views render as ViewIso.
+func Render(m *Mesh, view View, size int) *image.RGBA {
+	camera, ok := cameras[view]
+	if !ok {
+		camera = cameras[ViewIso]
+	}
+	w := camera.eye.Scale(1 / camera.eye.Length())
+	u := camera.up.Cross(w)
+	u = u.Scale(1 / u.Length())
+	v := w.Cross(u)
+	light := w.Add(v.Scale(0.6)).Sub(u.Scale(0.4))
+	light = light.Scale(1 / light.Length())
+
+	// Fit the projected bounds into the square, keeping the aspect ratio
+	scaled := size * renderSupersampling
+	lo := Vec3{math.Inf(1), math.Inf(1)}
+	hi := Vec3{math.Inf(-1), math.Inf(-1)}
+	for _, t := range m.Triangles {
+		for _, p := range t {
+			x, y := p.Dot(u), p.Dot(v)
+			lo[0], lo[1] = math.Min(lo[0], x), math.Min(lo[1], y)
+			hi[0], hi[1] = math.Max(hi[0], x), math.Max(hi[1], y)
+		}
+	}
+	canvas := newRaster(scaled)
+	if extent := math.Max(hi[0]-lo[0], hi[1]-lo[1]); extent > 0 {
+		scale := float64(scaled) * (1 - 2*renderMargin) / extent
+		offsetX := (float64(scaled) - (hi[0]-lo[0])*scale) / 2
+		offsetY := (float64(scaled) - (hi[1]-lo[1])*scale) / 2
+
+		for _, t := range m.Triangles {
+			normal := t.Normal()
+			if normal == (Vec3{}) {
+				continue
+			}
+			if normal.Dot(w) < 0 {
+				normal = normal.Scale(-1)
+			}
+			shade := renderAmbient + (1-renderAmbient)*math.Max(0, normal.Dot(light))
+
+			var screen [3]Vec3
+			for i, p := range t {
+				screen[i] = Vec3{
+					offsetX + (p.Dot(u)-lo[0])*scale,
+					float64(scaled) - offsetY - (p.Dot(v)-lo[1])*scale,
+					p.Dot(w),
+				}
+			}
+			canvas.fill(screen, shade)
+		}
+	}
+	return canvas.downsample(size)
+}
+
+// raster is a supersampled canvas with a depth buffer
+type raster struct {
+	size  int
+	shade []float64
+	depth []float64
+}
+
+func newRaster(size int) *raster {
+	r := &raster{size: size, shade: make([]float64, size*size), depth: make([]float64, size*size)}
+	for i := range r.depth {
+		r.depth[i] = math.Inf(-1)
+		r.shade[i] = -1
+	}
+	return r
+}
+
+// fill draws a triangle in screen space, keeping pixels nearest the camera;
+// larger depths are nearer
+func (r *raster) fill(t [3]Vec3, shade float64) {
+	area := edge(t[0], t[1], t[2][0], t[2][1])
+	if area == 0 {
+		return
+	}
+	minX := max(0, int(math.Floor(math.Min(t[0][0], math.Min(t[1][0], t[2][0])))))
+	maxX := min(r.size-1, int(math.Ceil(math.Max(t[0][0], math.Max(t[1][0], t[2][0])))))
+	minY := max(0, int(math.Floor(math.Min(t[0][1], math.Min(t[1][1], t[2][1])))))
+	maxY := min(r.size-1, int(math.Ceil(math.Max(t[0][1], math.Max(t[1][1], t[2][1])))))
+
+	for y := minY; y <= maxY; y++ {
+		py := float64(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float64(x) + 0.5
+			// Barycentric weights, all of the area's sign inside the triangle
+			w0 := edge(t[1], t[2], px, py) / area
+			w1 := edge(t[2], t[0], px, py) / area
+			w2 := 1 - w0 - w1
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			depth := w0*t[0][2] + w1*t[1][2] + w2*t[2][2]
+			if i := y*r.size + x; depth > r.depth[i] {
+				r.depth[i] = depth
+				r.shade[i] = shade
+			}
+		}
+	}
+}
+
+// edge is twice the signed area of the triangle a, b, (x, y)
+func edge(a, b Vec3, x, y float64) float64 {
+	return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+}
+
+// downsample averages the supersampled canvas into a size x size image
+func (r *raster) downsample(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	factor := r.size / size
+	samples := float64(factor * factor)
+	for y := range size {
+		for x := range size {
+			var red, green, blue, alpha float64
+			for sy := range factor {
+				for sx := range factor {
+					shade := r.shade[(y*factor+sy)*r.size+x*factor+sx]
+					if shade < 0 {
+						continue
+					}
+					red += float64(renderColor.R) * shade
+					green += float64(renderColor.G) * shade
+					blue += float64(renderColor.B) * shade
+					alpha += 255
+				}
+			}
+			// RGBA is premultiplied, so covered samples add up as they are
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(math.Round(red / samples)),
+				G: uint8(math.Round(green / samples)),
+				B: uint8(math.Round(blue / samples)),
+				A: uint8(math.Round(alpha / samples)),
+			})
+		}
+	}
+	return img
+}
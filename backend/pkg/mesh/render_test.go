@@ -0,0 +1,59 @@
+package mesh
+
+import (
+	"image"
+	"testing"
+)
+
+// coverage returns the share of an image's pixels that are opaque
+func coverage(img *image.RGBA) float64 {
+	opaque := 0
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] == 255 {
+			opaque++
+		}
+	}
+	return float64(opaque) / float64(len(img.Pix)/4)
+}
+
+func TestRender(t *testing.T) {
+	// A box twice as wide as it is tall fills the width and half the height from the front
+	front := Render(box(20, 10, 10), ViewFront, 128)
+	if bounds := front.Bounds(); bounds.Dx() != 128 || bounds.Dy() != 128 {
+		t.Fatalf("Expected a 128x128 image, got %v", bounds)
+	}
+	if share := coverage(front); share < 0.35 || share > 0.45 {
+		t.Errorf("Expected the front face to cover about 40%% of the image, got %.2f", share)
+	}
+	if front.RGBAAt(64, 64).A != 255 || front.RGBAAt(64, 10).A != 0 {
+		t.Error("Expected the model centred on a transparent background")
+	}
+
+	// The top is square, and seen from above fills the inner square
+	if share := coverage(Render(box(20, 20, 5), ViewTop, 128)); share < 0.75 || share > 0.85 {
+		t.Errorf("Expected the top to cover about 80%% of the image, got %.2f", share)
+	}
+
+	// The isometric view sees three faces, each shaded differently
+	iso := Render(box(10, 10, 10), ViewIso, 256)
+	shades := map[uint8]bool{}
+	for _, p := range []image.Point{{128, 60}, {90, 160}, {166, 160}} {
+		pixel := iso.RGBAAt(p.X, p.Y)
+		if pixel.A != 255 {
+			t.Fatalf("Expected %v on the model, got %v", p, pixel)
+		}
+		shades[pixel.B] = true
+	}
+	if len(shades) != 3 {
+		t.Errorf("Expected three differently shaded faces, got %v", shades)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	if share := coverage(Render(&Mesh{}, ViewIso, 64)); share != 0 {
+		t.Errorf("Expected an empty mesh to render transparent, got %.2f covered", share)
+	}
+	if !ValidView(ViewBottom) || ValidView("sideways") {
+		t.Error("Expected only the standard views to be valid")
+	}
+}
@@ -0,0 +1,293 @@
+package mesh
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// binaryHeaderSize is the 80-byte header and triangle count of a binary STL,
+// and binaryTriangleSize the normal, three vertices and attribute of each triangle
+const (
+	binaryHeaderSize   = 84
+	binaryTriangleSize = 50
+)
+
+// ErrEmptyMesh is returned for a model without triangles
+var ErrEmptyMesh = errors.New("mesh has no triangles")
+
+// Vec3 is a point or direction in model units, usually millimetres
+type Vec3 [3]float64
+
+// Sub returns v - o
+func (v Vec3) Sub(o Vec3) Vec3 { return Vec3{v[0] - o[0], v[1] - o[1], v[2] - o[2]} }
+
+// Add returns v + o
+func (v Vec3) Add(o Vec3) Vec3 { return Vec3{v[0] + o[0], v[1] + o[1], v[2] + o[2]} }
+
+// Scale returns v * s
+func (v Vec3) Scale(s float64) Vec3 { return Vec3{v[0] * s, v[1] * s, v[2] * s} }
+
+// Cross returns the cross product v × o
+func (v Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{v[1]*o[2] - v[2]*o[1], v[2]*o[0] - v[0]*o[2], v[0]*o[1] - v[1]*o[0]}
+}
+
+// Dot returns the dot product v · o
+func (v Vec3) Dot(o Vec3) float64 { return v[0]*o[0] + v[1]*o[1] + v[2]*o[2] }
+
+// Length returns the Euclidean length of v
+func (v Vec3) Length() float64 { return math.Sqrt(v.Dot(v)) }
+
+// Triangle is one facet of a mesh
+type Triangle [3]Vec3
+
+// Area returns the triangle's surface area
+func (t Triangle) Area() float64 {
+	return t[1].Sub(t[0]).Cross(t[2].Sub(t[0])).Length() / 2
+}
+
+// Normal returns the unit normal given by the winding of the vertices, or the
+// zero vector for a degenerate triangle
+func (t Triangle) Normal() Vec3 {
+	n := t[1].Sub(t[0]).Cross(t[2].Sub(t[0]))
+	length := n.Length()
+	if length == 0 {
+		return Vec3{}
+	}
+	return n.Scale(1 / length)
+}
+
+// Mesh is a triangle soup, as STL files hold
+type Mesh struct {
+	Triangles []Triangle
+}
+
+// Bounds returns the corners of the mesh's axis-aligned bounding box
+func (m *Mesh) Bounds() (lo, hi Vec3) {
+	if len(m.Triangles) == 0 {
+		return Vec3{}, Vec3{}
+	}
+	lo, hi = m.Triangles[0][0], m.Triangles[0][0]
+	for _, t := range m.Triangles {
+		for _, v := range t {
+			for axis := range 3 {
+				lo[axis] = math.Min(lo[axis], v[axis])
+				hi[axis] = math.Max(hi[axis], v[axis])
+			}
+		}
+	}
+	return lo, hi
+}
+
+// Area returns the mesh's total surface area
+func (m *Mesh) Area() float64 {
+	var area float64
+	for _, t := range m.Triangles {
+		area += t.Area()
+	}
+	return area
+}
+
+// Volume returns the volume the mesh encloses, assuming it is closed and
+// consistently wound; open meshes give an approximation
+func (m *Mesh) Volume() float64 {
+	var volume float64
+	for _, t := range m.Triangles {
+		volume += t[0].Dot(t[1].Cross(t[2])) / 6
+	}
+	return math.Abs(volume)
+}
+
+// Encoding is how an STL file stores its triangles
+type Encoding string
+
+const (
+	EncodingBinary Encoding = "binary"
+	EncodingASCII  Encoding = "ascii"
+)
+
+// ReadSTLFile reads a binary or ASCII STL file, decompressing it when it is
+// stored compressed
+func ReadSTLFile(path string) (*Mesh, error) {
+	file, size, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadSTL(file, size)
+}
+
+// STLFileEncoding reports whether an STL file is binary or ASCII from its header
+func STLFileEncoding(path string) (Encoding, error) {
+	file, size, err := Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, binaryHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return detectEncoding(header[:n], size)
+}
+
+// detectEncoding tells a binary STL of size bytes from an ASCII one by its header
+func detectEncoding(header []byte, size int64) (Encoding, error) {
+	if len(header) == binaryHeaderSize {
+		count := int64(binary.LittleEndian.Uint32(header[80:]))
+		if binaryHeaderSize+count*binaryTriangleSize == size {
+			return EncodingBinary, nil
+		}
+	}
+	if !bytes.HasPrefix(bytes.TrimLeft(header, " \t\r\n"), []byte("solid")) {
+		return "", errors.New("not an STL file")
+	}
+	return EncodingASCII, nil
+}
+
+// ReadSTL reads a binary or ASCII STL of size bytes. Binary files are told
+// apart by their size matching the triangle count in their header, since
+// some binary exporters start the header with "solid" too.
+func ReadSTL(r io.Reader, size int64) (*Mesh, error) {
+	reader := bufio.NewReaderSize(r, 64<<10)
+	header, err := reader.Peek(binaryHeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	encoding, err := detectEncoding(header, size)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == EncodingBinary {
+		return readBinarySTL(reader, int64(binary.LittleEndian.Uint32(header[80:])))
+	}
+	return readASCIISTL(reader)
+}
+
+// readBinarySTL reads count triangles after the header
+func readBinarySTL(r io.Reader, count int64) (*Mesh, error) {
+	if _, err := io.CopyN(io.Discard, r, binaryHeaderSize); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrEmptyMesh
+	}
+
+	mesh := &Mesh{Triangles: make([]Triangle, 0, count)}
+	record := make([]byte, binaryTriangleSize)
+	for range count {
+		if _, err := io.ReadFull(r, record); err != nil {
+			return nil, fmt.Errorf("truncated STL: %w", err)
+		}
+		var t Triangle
+		// The stored normal is skipped; it is often wrong and can be recomputed
+		for v := range 3 {
+			for axis := range 3 {
+				offset := 12 + v*12 + axis*4
+				t[v][axis] = float64(math.Float32frombits(binary.LittleEndian.Uint32(record[offset:])))
+			}
+		}
+		mesh.Triangles = append(mesh.Triangles, t)
+	}
+	return mesh, nil
+}
+
+// readASCIISTL reads the "vertex x y z" lines of an ASCII STL, three to a facet
+func readASCIISTL(r io.Reader) (*Mesh, error) {
+	mesh := &Mesh{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	var t Triangle
+	vertex := 0
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "vertex") {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid STL vertex %q", scanner.Text())
+		}
+		for axis := range 3 {
+			value, err := strconv.ParseFloat(fields[axis+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid STL vertex %q", scanner.Text())
+			}
+			t[vertex][axis] = value
+		}
+		if vertex++; vertex == 3 {
+			mesh.Triangles = append(mesh.Triangles, t)
+			vertex = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(mesh.Triangles) == 0 {
+		return nil, ErrEmptyMesh
+	}
+	return mesh, nil
+}
+
+// WriteBinarySTL writes the mesh as a binary STL, with normals computed from
+// the winding
+func WriteBinarySTL(w io.Writer, m *Mesh) error {
+	writer := bufio.NewWriter(w)
+	header := make([]byte, binaryHeaderSize)
+	copy(header, "binary STL written by 3DShelf")
+	binary.LittleEndian.PutUint32(header[80:], uint32(len(m.Triangles)))
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]byte, binaryTriangleSize)
+	for _, t := range m.Triangles {
+		values := append([]Vec3{t.Normal()}, t[:]...)
+		for i, v := range values {
+			for axis := range 3 {
+				binary.LittleEndian.PutUint32(record[i*12+axis*4:], math.Float32bits(float32(v[axis])))
+			}
+		}
+		if _, err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// WriteASCIISTL writes the mesh as an ASCII STL solid called name, with
+// normals computed from the winding. Coordinates are written with the
+// precision of binary STLs, so converting one to ASCII keeps every vertex.
+func WriteASCIISTL(w io.Writer, m *Mesh, name string) error {
+	writer := bufio.NewWriter(w)
+	name = strings.Join(strings.Fields(name), "_")
+	fmt.Fprintf(writer, "solid %s\n", name)
+
+	vector := func(v Vec3) string {
+		parts := make([]string, 3)
+		for axis := range 3 {
+			parts[axis] = strconv.FormatFloat(v[axis], 'g', -1, 32)
+		}
+		return strings.Join(parts, " ")
+	}
+	for _, t := range m.Triangles {
+		fmt.Fprintf(writer, "  facet normal %s\n    outer loop\n", vector(t.Normal()))
+		for _, v := range t {
+			fmt.Fprintf(writer, "      vertex %s\n", vector(v))
+		}
+		fmt.Fprint(writer, "    endloop\n  endfacet\n")
+	}
+	fmt.Fprintf(writer, "endsolid %s\n", name)
+	return writer.Flush()
+}
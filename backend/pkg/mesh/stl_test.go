@@ -0,0 +1,148 @@
+package mesh
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// box returns a closed box mesh of the given size with a corner at the origin
+func box(x, y, z float64) *Mesh {
+	v := func(i, j, k float64) Vec3 { return Vec3{i * x, j * y, k * z} }
+	quads := [][4]Vec3{
+		{v(0, 0, 0), v(0, 1, 0), v(1, 1, 0), v(1, 0, 0)}, // bottom
+		{v(0, 0, 1), v(1, 0, 1), v(1, 1, 1), v(0, 1, 1)}, // top
+		{v(0, 0, 0), v(1, 0, 0), v(1, 0, 1), v(0, 0, 1)}, // front
+		{v(0, 1, 0), v(0, 1, 1), v(1, 1, 1), v(1, 1, 0)}, // back
+		{v(0, 0, 0), v(0, 0, 1), v(0, 1, 1), v(0, 1, 0)}, // left
+		{v(1, 0, 0), v(1, 1, 0), v(1, 1, 1), v(1, 0, 1)}, // right
+	}
+	m := &Mesh{}
+	for _, q := range quads {
+		m.Triangles = append(m.Triangles, Triangle{q[0], q[1], q[2]}, Triangle{q[0], q[2], q[3]})
+	}
+	return m
+}
+
+func TestReadBinarySTL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBinarySTL(&buf, box(10, 20, 30)); err != nil {
+		t.Fatalf("WriteBinarySTL failed: %v", err)
+	}
+	// A header starting with "solid" doesn't make a binary file ASCII
+	data := buf.Bytes()
+	copy(data, "solid pretending")
+
+	path := filepath.Join(t.TempDir(), "box.stl")
+	os.WriteFile(path, data, 0644)
+	m, err := ReadSTLFile(path)
+	if err != nil {
+		t.Fatalf("ReadSTLFile failed: %v", err)
+	}
+	if len(m.Triangles) != 12 {
+		t.Fatalf("Expected 12 triangles, got %d", len(m.Triangles))
+	}
+	if lo, hi := m.Bounds(); lo != (Vec3{}) || hi != (Vec3{10, 20, 30}) {
+		t.Errorf("Unexpected bounds %v - %v", lo, hi)
+	}
+	if volume := m.Volume(); math.Abs(volume-6000) > 1e-6 {
+		t.Errorf("Expected a volume of 6000, got %f", volume)
+	}
+	if area := m.Area(); math.Abs(area-2200) > 1e-6 {
+		t.Errorf("Expected an area of 2200, got %f", area)
+	}
+}
+
+func TestReadASCIISTL(t *testing.T) {
+	content := `solid tri
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1.5e0 0
+    endloop
+  endfacet
+endsolid tri
+`
+	m, err := ReadSTL(strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("ReadSTL failed: %v", err)
+	}
+	if len(m.Triangles) != 1 || m.Triangles[0][2] != (Vec3{0, 1.5, 0}) {
+		t.Errorf("Unexpected triangles %v", m.Triangles)
+	}
+
+	testCases := map[string]string{
+		"Not an STL":   "PK\x03\x04 zip",
+		"Empty solid":  "solid empty\nendsolid empty\n",
+		"Bad vertex":   "solid bad\nvertex 1 two 3\n",
+		"Short vertex": "solid bad\nvertex 1 2\n",
+	}
+	for name, content := range testCases {
+		if _, err := ReadSTL(strings.NewReader(content), int64(len(content))); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWriteASCIISTL(t *testing.T) {
+	var binaryBuf bytes.Buffer
+	WriteBinarySTL(&binaryBuf, box(10, 20.125, 30))
+	original, _ := ReadSTL(bytes.NewReader(binaryBuf.Bytes()), int64(binaryBuf.Len()))
+
+	var buf bytes.Buffer
+	if err := WriteASCIISTL(&buf, original, "my box"); err != nil {
+		t.Fatalf("WriteASCIISTL failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "solid my_box\n") {
+		t.Errorf("Expected the solid to be named, got %q", buf.String()[:20])
+	}
+
+	path := filepath.Join(t.TempDir(), "box.stl")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	if encoding, err := STLFileEncoding(path); err != nil || encoding != EncodingASCII {
+		t.Errorf("Expected an ASCII STL, got %s %v", encoding, err)
+	}
+	converted, err := ReadSTLFile(path)
+	if err != nil {
+		t.Fatalf("ReadSTLFile failed: %v", err)
+	}
+	if len(converted.Triangles) != len(original.Triangles) {
+		t.Fatalf("Expected %d triangles, got %d", len(original.Triangles), len(converted.Triangles))
+	}
+	for i := range original.Triangles {
+		if converted.Triangles[i] != original.Triangles[i] {
+			t.Fatalf("Triangle %d changed: %v != %v", i, converted.Triangles[i], original.Triangles[i])
+		}
+	}
+}
+
+func TestReadCompressedSTL(t *testing.T) {
+	var buf bytes.Buffer
+	WriteBinarySTL(&buf, box(10, 20, 30))
+
+	path := filepath.Join(t.TempDir(), "box.stl"+CompressedExt)
+	file, _ := os.Create(path)
+	if err := Compress(file, bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	file.Close()
+	if info, _ := os.Stat(path); info.Size() >= int64(buf.Len()) {
+		t.Errorf("Expected the compressed file to be smaller than %d bytes, got %d", buf.Len(), info.Size())
+	}
+
+	reader, size, err := Open(path)
+	if err != nil || size != int64(buf.Len()) {
+		t.Fatalf("Expected the content size %d, got %d %v", buf.Len(), size, err)
+	}
+	reader.Close()
+	if encoding, err := STLFileEncoding(path); err != nil || encoding != EncodingBinary {
+		t.Errorf("Expected a binary STL, got %s %v", encoding, err)
+	}
+	if m, err := ReadSTLFile(path); err != nil || len(m.Triangles) != 12 {
+		t.Errorf("Expected 12 triangles from the compressed file, got %v", err)
+	}
+}
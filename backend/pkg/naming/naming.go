@@ -0,0 +1,145 @@
+package naming
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MinMaxBytes and MaxMaxBytes bound Policy.MaxBytes; the upper bound leaves
+// room under the usual 255 byte limit for the _2, _3... added to taken names
+const (
+	MinMaxBytes = 16
+	MaxMaxBytes = 240
+)
+
+// fallbackName names directories of projects whose names have nothing usable
+const fallbackName = "project"
+
+// untitledName names directories of imported projects that have no name at all
+const untitledName = "untitled"
+
+// transliterations spell letters that don't decompose into an ASCII letter and accents
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "TH",
+	'ı': "i",
+}
+
+// reservedNames can't name a file or directory on Windows, whatever the extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Policy is how project names become directory names, so directories are
+// valid on the filesystems a library is shared from, like a NAS exported to
+// Windows and macOS clients. Project names themselves are kept as given.
+type Policy struct {
+	// Transliterate spells names in ASCII, so "Café Ørsted" gets the directory
+	// Cafe_Orsted; letters with no ASCII spelling become underscores. Names
+	// keep their own script otherwise.
+	Transliterate bool
+
+	// MaxBytes bounds directory names, in bytes of UTF-8
+	MaxBytes int
+}
+
+// Default keeps names in their own script with room for long ones
+func Default() Policy {
+	return Policy{MaxBytes: 120}
+}
+
+// Validate checks the length limit is usable
+func (p Policy) Validate() error {
+	if p.MaxBytes < MinMaxBytes || p.MaxBytes > MaxMaxBytes {
+		return fmt.Errorf("directory name limit %d is not valid (must be between %d and %d bytes)", p.MaxBytes, MinMaxBytes, MaxMaxBytes)
+	}
+	return nil
+}
+
+// DirName turns a project name into the name of its directory. Names are
+// composed to NFC, so the same name typed on macOS and Linux gets the same
+// directory; whitespace and characters that aren't valid in file names on
+// common filesystems become underscores, leading dots that would hide the
+// directory are dropped and names reserved on Windows get an underscore.
+func (p Policy) DirName(name string) string {
+	name = norm.NFC.String(strings.TrimSpace(name))
+	if p.Transliterate {
+		name = transliterate(name)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r), unicode.IsControl(r), strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteByte('_')
+		case r == utf8.RuneError:
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	dirName := strings.TrimLeft(b.String(), ".")
+
+	if p.MaxBytes > 0 && len(dirName) > p.MaxBytes {
+		dirName = truncate(dirName, p.MaxBytes)
+	}
+	// Windows drops trailing dots and spaces, so names ending in them can't be opened there
+	dirName = strings.TrimRight(dirName, ". ")
+
+	if dirName == "" {
+		return fallbackName
+	}
+	stem, _, _ := strings.Cut(dirName, ".")
+	if reservedNames[strings.ToUpper(stem)] {
+		dirName += "_"
+	}
+	return dirName
+}
+
+// ImportedDirName names the directory of a project imported or mirrored from
+// elsewhere by DirName, calling projects that arrive without a name untitled
+func (p Policy) ImportedDirName(name string) string {
+	if strings.Trim(name, ". \t\n") == "" {
+		return untitledName
+	}
+	return p.DirName(name)
+}
+
+// transliterate spells a name in ASCII, dropping accents and replacing
+// letters with no ASCII spelling by underscores
+func transliterate(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks left by decomposing accented letters
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// truncate cuts a name to at most maxBytes without splitting a character
+func truncate(name string, maxBytes int) string {
+	end := 0
+	for i, r := range name {
+		if i+utf8.RuneLen(r) > maxBytes {
+			break
+		}
+		end = i + utf8.RuneLen(r)
+	}
+	return name[:end]
+}
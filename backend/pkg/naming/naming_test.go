@@ -0,0 +1,74 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestDirName tests turning project names into directory names
+func TestDirName(t *testing.T) {
+	testCases := []struct {
+		policy   Policy
+		name     string
+		expected string
+	}{
+		{Default(), "Desk Organizer", "Desk_Organizer"},
+		{Default(), "Gear/Box", "Gear_Box"},
+		{Default(), `What? "A" <B>: C|D*`, "What___A___B___C_D_"},
+		{Default(), "Café", "Café"},
+		{Default(), "Cafe\u0301", "Café"},
+		{Default(), "日本の城", "日本の城"},
+		{Default(), ".hidden", "hidden"},
+		{Default(), "Version 2.", "Version_2"},
+		{Default(), "con", "con_"},
+		{Default(), "LPT1.stl", "LPT1.stl_"},
+		{Default(), "...", "project"},
+		{Policy{Transliterate: true, MaxBytes: 120}, "Café Ørsted", "Cafe_Orsted"},
+		{Policy{Transliterate: true, MaxBytes: 120}, "Straße", "Strasse"},
+		{Policy{Transliterate: true, MaxBytes: 120}, "日本 Castle", "___Castle"},
+	}
+	for _, tc := range testCases {
+		if got := tc.policy.DirName(tc.name); got != tc.expected {
+			t.Errorf("DirName(%q) with %+v = %q, expected %q", tc.name, tc.policy, got, tc.expected)
+		}
+	}
+}
+
+// TestImportedDirName tests naming the directories of imported projects
+func TestImportedDirName(t *testing.T) {
+	testCases := map[string]string{
+		"Desk Toys":   "Desk_Toys",
+		"a/b\\c:d":    "a_b_c_d",
+		"..hidden":    "hidden",
+		"   ":         "untitled",
+		"Würfel (v2)": "Würfel_(v2)",
+		`what?"<>|*`:  "what______",
+	}
+
+	for input, expected := range testCases {
+		if got := Default().ImportedDirName(input); got != expected {
+			t.Errorf("ImportedDirName(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+// TestDirNameLength tests that long names are cut without splitting characters
+func TestDirNameLength(t *testing.T) {
+	policy := Policy{MaxBytes: 16}
+	for _, name := range []string{strings.Repeat("a", 40), strings.Repeat("é", 40), strings.Repeat("城", 40)} {
+		got := policy.DirName(name)
+		if len(got) > policy.MaxBytes || !utf8.ValidString(got) || got == "" {
+			t.Errorf("DirName(%q) = %q, expected a valid name of at most %d bytes", name, got, policy.MaxBytes)
+		}
+	}
+}
+
+// TestValidate tests the bounds of the length limit
+func TestValidate(t *testing.T) {
+	for maxBytes, valid := range map[int]bool{0: false, MinMaxBytes - 1: false, MinMaxBytes: true, 120: true, MaxMaxBytes: true, 255: false} {
+		if err := (Policy{MaxBytes: maxBytes}).Validate(); (err == nil) != valid {
+			t.Errorf("Validate with MaxBytes %d: expected valid=%v, got %v", maxBytes, valid, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package octoprint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTimelapseNotFound is returned when no rendered time-lapse matches
+var ErrTimelapseNotFound = errors.New("timelapse not found")
+
+// timelapseDateLayout is how OctoPrint formats time-lapse dates
+const timelapseDateLayout = "2006-01-02 15:04"
+
+// Client talks to the OctoPrint REST API with an application key
+type Client struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+// New creates a client for the OctoPrint instance at baseURL
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		APIKey:  apiKey,
+		HTTP:    &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Timelapse is a rendered time-lapse video stored by OctoPrint
+type Timelapse struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Date  string `json:"date"`
+
+	// URL is the download path, relative to the OctoPrint base URL
+	URL string `json:"url"`
+}
+
+// RenderedAt parses the time-lapse date, returning the zero time when it is missing
+func (t Timelapse) RenderedAt() time.Time {
+	at, _ := time.ParseInLocation(timelapseDateLayout, t.Date, time.Local)
+	return at
+}
+
+// Timelapses lists the rendered time-lapses
+func (c *Client) Timelapses(ctx context.Context) ([]Timelapse, error) {
+	resp, err := c.get(ctx, c.BaseURL+"/api/timelapse")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var listing struct {
+		Files []Timelapse `json:"files"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("invalid timelapse listing: %w", err)
+	}
+	return listing.Files, nil
+}
+
+// FindTimelapse returns the named time-lapse, or the most recent one when name is empty
+func (c *Client) FindTimelapse(ctx context.Context, name string) (Timelapse, error) {
+	timelapses, err := c.Timelapses(ctx)
+	if err != nil {
+		return Timelapse{}, err
+	}
+
+	var found *Timelapse
+	for i, timelapse := range timelapses {
+		if name != "" {
+			if timelapse.Name == name {
+				return timelapse, nil
+			}
+			continue
+		}
+		if found == nil || timelapse.RenderedAt().After(found.RenderedAt()) {
+			found = &timelapses[i]
+		}
+	}
+	if found == nil {
+		return Timelapse{}, ErrTimelapseNotFound
+	}
+	return *found, nil
+}
+
+// Download streams a time-lapse video to w
+func (c *Client) Download(ctx context.Context, timelapse Timelapse, w io.Writer) error {
+	// Only ever send the API key to the configured instance
+	link, err := url.Parse(timelapse.URL)
+	if err != nil || link.Path == "" {
+		return fmt.Errorf("invalid timelapse URL %q", timelapse.URL)
+	}
+	target := c.BaseURL + "/" + strings.TrimPrefix(link.EscapedPath(), "/")
+	if link.IsAbs() {
+		base, err := url.Parse(c.BaseURL)
+		if err != nil || link.Host != base.Host {
+			return fmt.Errorf("timelapse URL %q is not on the OctoPrint host", timelapse.URL)
+		}
+		target = link.String()
+	}
+
+	resp, err := c.get(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// get performs an authenticated request, treating non-2xx responses as errors
+func (c *Client) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", c.APIKey)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("octoprint returned %s for %s", resp.Status, req.URL.Path)
+	}
+	return resp, nil
+}
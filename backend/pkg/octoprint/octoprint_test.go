@@ -0,0 +1,67 @@
+package octoprint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFindTimelapse tests picking time-lapses by name or recency
+func TestFindTimelapse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"files": [
+			{"name": "b.mp4", "date": "2026-02-01 08:00", "url": "/downloads/timelapse/b.mp4"},
+			{"name": "c.mp4", "date": "2026-02-03 18:45", "url": "/downloads/timelapse/c.mp4"},
+			{"name": "a.mp4", "date": "2026-01-15 12:00", "url": "/downloads/timelapse/a.mp4"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL+"/", "key")
+	ctx := context.Background()
+
+	latest, err := client.FindTimelapse(ctx, "")
+	if err != nil || latest.Name != "c.mp4" {
+		t.Errorf("Expected the most recent time-lapse, got %+v (%v)", latest, err)
+	}
+	named, err := client.FindTimelapse(ctx, "a.mp4")
+	if err != nil || named.Name != "a.mp4" {
+		t.Errorf("Expected the named time-lapse, got %+v (%v)", named, err)
+	}
+	if _, err := client.FindTimelapse(ctx, "z.mp4"); !errors.Is(err, ErrTimelapseNotFound) {
+		t.Errorf("Expected ErrTimelapseNotFound, got %v", err)
+	}
+}
+
+// TestDownload tests the API key is only sent to the configured host
+func TestDownload(t *testing.T) {
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Api-Key")
+		w.Write([]byte("frames"))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "key")
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := client.Download(ctx, Timelapse{URL: "/downloads/timelapse/a.mp4"}, &out); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if out.String() != "frames" || gotKey != "key" {
+		t.Errorf("Expected an authenticated download, got %q with key %q", out.String(), gotKey)
+	}
+
+	out.Reset()
+	if err := client.Download(ctx, Timelapse{URL: server.URL + "/downloads/timelapse/a.mp4"}, &out); err != nil {
+		t.Errorf("Expected absolute URLs on the same host to download, got %v", err)
+	}
+
+	if err := client.Download(ctx, Timelapse{URL: "https://elsewhere.example.com/a.mp4"}, &out); err == nil {
+		t.Error("Expected URLs on another host to be refused")
+	}
+}
@@ -0,0 +1,93 @@
+// Package pairing links G-code files to the models they were sliced from
+package pairing
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/mesh"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// slicedNameSeparators may follow the model name in a G-code filename, as in
+// benchy_0.2mm_PLA_MK4.gcode for benchy.stl
+const slicedNameSeparators = "_-. ("
+
+// Pair links each G-code file to the STL or 3MF models it was sliced from,
+// filling SourceModels and SlicedVariants in place. A G-code file named after
+// a model is paired by name, preferring the longest matching model name;
+// otherwise the models referenced by its slicer comments are used.
+func Pair(files []models.ProjectFile) {
+	modelsByName := make(map[string][]int)
+	for i, file := range files {
+		if file.FileType == models.FileTypeSTL || file.FileType == models.FileType3MF {
+			name := modelName(file.Filename)
+			modelsByName[name] = append(modelsByName[name], i)
+		}
+	}
+	if len(modelsByName) == 0 {
+		return
+	}
+
+	for i := range files {
+		if files[i].FileType != models.FileTypeGCode {
+			continue
+		}
+
+		sources := modelsByName[slicedModelName(modelName(files[i].Filename), modelsByName)]
+		if len(sources) == 0 {
+			sources = referencedModels(files[i], modelsByName)
+		}
+
+		for _, source := range sources {
+			files[i].SourceModels = append(files[i].SourceModels, files[source].ID)
+			files[source].SlicedVariants = append(files[source].SlicedVariants, files[i].ID)
+		}
+	}
+}
+
+// slicedModelName returns the longest model name the G-code name is, or starts with
+func slicedModelName(name string, modelsByName map[string][]int) string {
+	best := ""
+	for candidate := range modelsByName {
+		if len(candidate) <= len(best) || !strings.HasPrefix(name, candidate) {
+			continue
+		}
+		if len(name) == len(candidate) || strings.ContainsRune(slicedNameSeparators, rune(name[len(candidate)])) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// referencedModels returns the models named in a G-code file's slicer comments
+func referencedModels(file models.ProjectFile, modelsByName map[string][]int) []int {
+	names, err := gcode.ReadSourceModels(file.Filepath)
+	if err != nil {
+		fmt.Printf("Warning: Failed to read G-code source models from %s: %v\n", file.Filepath, err)
+		return nil
+	}
+
+	var sources []int
+	seen := make(map[int]bool)
+	for _, name := range names {
+		for _, source := range modelsByName[modelName(name)] {
+			if !seen[source] {
+				seen[source] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources
+}
+
+// modelName is the lowercased base name of a file without its model or G-code extension
+func modelName(filename string) string {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(filepath.FromSlash(filename))), mesh.CompressedExt)
+	switch ext := filepath.Ext(name); ext {
+	case ".stl", ".3mf", ".obj", ".step", ".stp", ".gcode", ".gco", ".bgcode":
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
@@ -0,0 +1,75 @@
+// Package pdf writes minimal PDF documents from hand-built objects, enough
+// for the single purpose pages the library prints
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document is a PDF being built object by object. Objects are numbered from 1
+// in the order they are added, and object 1 must be the catalog.
+type Document struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// New starts an empty document
+func New() *Document {
+	doc := &Document{}
+	doc.buf.WriteString("%PDF-1.4\n")
+	return doc
+}
+
+// Add appends an object with the given dictionary and, unless it is nil, its
+// stream, returning the object's number
+func (d *Document) Add(body string, stream []byte) int {
+	d.offsets = append(d.offsets, d.buf.Len())
+	fmt.Fprintf(&d.buf, "%d 0 obj\n%s\n", len(d.offsets), body)
+	if stream != nil {
+		d.buf.WriteString("stream\n")
+		d.buf.Write(stream)
+		d.buf.WriteString("\nendstream\n")
+	}
+	d.buf.WriteString("endobj\n")
+	return len(d.offsets)
+}
+
+// Encode writes the document with its cross-reference table and trailer
+func (d *Document) Encode(w io.Writer) error {
+	var doc bytes.Buffer
+	doc.Write(d.buf.Bytes())
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(d.offsets)+1)
+	for _, offset := range d.offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.offsets)+1, xref)
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// String encodes text as a PDF string literal in WinAnsi, which matches
+// Latin-1 for the characters it shares; other characters are replaced
+func String(s string) string {
+	var out strings.Builder
+	out.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			out.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	out.WriteByte(')')
+	return out.String()
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestEncode tests objects are numbered in order and the cross-reference
+// table points at each of them
+func TestEncode(t *testing.T) {
+	doc := New()
+	if n := doc.Add("<< /Type /Catalog /Pages 2 0 R >>", nil); n != 1 {
+		t.Errorf("Expected the catalog to be object 1, got %d", n)
+	}
+	if n := doc.Add("<< /Length 3 >>", []byte("abc")); n != 2 {
+		t.Errorf("Expected object 2, got %d", n)
+	}
+
+	var out bytes.Buffer
+	if err := doc.Encode(&out); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	raw := out.String()
+	if !strings.HasPrefix(raw, "%PDF-1.4\n") || !strings.HasSuffix(raw, "%%EOF\n") {
+		t.Error("Expected a complete PDF document")
+	}
+	if !strings.Contains(raw, "stream\nabc\nendstream\n") || !strings.Contains(raw, "/Size 3 /Root 1 0 R") {
+		t.Errorf("Unexpected document:\n%s", raw)
+	}
+
+	xref := raw[strings.Index(raw, "xref\n"):]
+	for n := 1; n <= 2; n++ {
+		var offset int
+		entry := strings.Split(xref, "\n")[2+n]
+		if _, err := fmt.Sscanf(entry, "%010d", &offset); err != nil {
+			t.Fatalf("Failed to parse xref entry %q: %v", entry, err)
+		}
+		if want := fmt.Sprintf("%d 0 obj\n", n); !strings.HasPrefix(raw[offset:], want) {
+			t.Errorf("Expected xref entry %d to point at %q, got %q", n, want, raw[offset:offset+len(want)])
+		}
+	}
+}
+
+// TestString tests text is escaped and encoded as WinAnsi
+func TestString(t *testing.T) {
+	cases := map[string]string{
+		"Quote (draft)": `(Quote \(draft\))`,
+		`a\b`:           `(a\\b)`,
+		"café":          `(caf\351)`,
+		"Ada — Etsy":    "(Ada ? Etsy)",
+	}
+	for in, want := range cases {
+		if got := String(in); got != want {
+			t.Errorf("String(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"3dshelf/pkg/mesh"
+	"3dshelf/pkg/thumbnail"
+)
+
+// DefaultMaxTriangles is the triangle budget of previews: models with more
+// are decimated, as browsers struggle to render millions of triangles
+const DefaultMaxTriangles = 500000
+
+// staleTempAge is how old an unfinished decimation must be to be pruned
+const staleTempAge = time.Hour
+
+// Cache keeps low-poly previews of large STL models and PNG renders of models
+// on disk, keyed by the content hash of their source and the triangle budget
+// they were reduced to, or the view and size they were rendered at
+type Cache struct {
+	dir          string
+	maxTriangles int
+
+	// mu keeps concurrent requests from decimating the same model twice
+	mu sync.Mutex
+}
+
+// New creates a Cache keeping previews in dir, decimating models to at most
+// maxTriangles triangles; 0 turns decimation off
+func New(dir string, maxTriangles int) *Cache {
+	return &Cache{dir: dir, maxTriangles: maxTriangles}
+}
+
+// MaxTriangles returns the triangle budget of previews, 0 when decimation is off
+func (c *Cache) MaxTriangles() int {
+	return c.maxTriangles
+}
+
+// path is where the preview is kept, sharded by the first hash byte
+func (c *Cache) path(hash string) string {
+	return filepath.Join(c.dir, hash[:2], hash+"-"+strconv.Itoa(c.maxTriangles)+".stl")
+}
+
+// Get returns the path of the preview of the STL model at src, whose content
+// hash is hash, decimating it when it isn't cached yet. Previews are binary
+// STLs. An empty path means the model is served as it is: decimation is off,
+// the model has no hash to key its preview on, or its content is no larger
+// than a binary STL within the budget, so it can't have more triangles.
+func (c *Cache) Get(src, hash string) (string, error) {
+	if c.maxTriangles <= 0 || !thumbnail.ValidHash(hash) {
+		return "", nil
+	}
+	path := c.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	content, size, err := mesh.Open(src)
+	if err != nil {
+		return "", err
+	}
+	content.Close()
+	if size <= mesh.BinarySTLSize(c.maxTriangles) {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	// Large ASCII models within the budget are cached too, as binary STLs a
+	// fraction of their size
+	err = write(path, func(w io.Writer) error {
+		model, err := mesh.ReadSTLFile(src)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", src, err)
+		}
+		return mesh.WriteBinarySTL(w, mesh.Decimate(model, c.maxTriangles))
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Render returns the path of a size x size PNG render of the STL model at
+// src from view, rendering it when it isn't cached yet. Renders are keyed by
+// the model's content hash; models without one are rendered uncached with
+// the Render function.
+func (c *Cache) Render(src, hash string, view mesh.View, size int) (string, error) {
+	if !thumbnail.ValidHash(hash) || !mesh.ValidView(view) || !thumbnail.ValidSize(size) {
+		return "", fmt.Errorf("invalid render %q from %s at %d", hash, view, size)
+	}
+	path := filepath.Join(c.dir, hash[:2], fmt.Sprintf("%s-%s-%d.png", hash, view, size))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := write(path, func(w io.Writer) error { return Render(w, src, view, size) }); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Render writes a size x size PNG render of the STL model at src from view
+func Render(w io.Writer, src string, view mesh.View, size int) error {
+	model, err := mesh.ReadSTLFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	return png.Encode(w, mesh.Render(model, view, size))
+}
+
+// write creates the cache file at path from what generate writes, through a
+// temporary file so readers never see it half written
+func write(path string, generate func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".preview-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := generate(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prune removes the previews and renders whose content hash isn't in live,
+// the models still in the library, previews decimated to another budget, and
+// leftovers of interrupted generations, returning how many files it removed
+func (c *Cache) Prune(live map[string]bool) (int, error) {
+	budget := strconv.Itoa(c.maxTriangles) + ".stl"
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") {
+			info, err := d.Info()
+			if err != nil || time.Since(info.ModTime()) < staleTempAge {
+				return nil
+			}
+		} else if hash, rest, _ := strings.Cut(d.Name(), "-"); live[hash] && (rest == budget || strings.HasSuffix(rest, ".png")) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// Run prunes the cache every interval until ctx is done, asking live for the
+// content hashes of the models still in the library
+func (c *Cache) Run(ctx context.Context, interval time.Duration, live func() (map[string]bool, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		hashes, err := live()
+		if err != nil {
+			fmt.Printf("Warning: Failed to list models for preview cleanup: %v\n", err)
+			continue
+		}
+		if removed, err := c.Prune(hashes); err != nil {
+			fmt.Printf("Warning: Preview cleanup failed: %v\n", err)
+		} else if removed > 0 {
+			fmt.Printf("Removed %d stale previews\n", removed)
+		}
+	}
+}
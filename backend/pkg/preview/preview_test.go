@@ -0,0 +1,158 @@
+package preview
+
+import (
+	"image"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/pkg/mesh"
+)
+
+const testHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// writeTerrain writes a wavy height field of 2*n*n triangles as a binary STL
+func writeTerrain(t *testing.T, path string, n int) {
+	height := func(x, y int) mesh.Vec3 {
+		return mesh.Vec3{float64(x), float64(y), 5 * math.Sin(float64(x)/10) * math.Cos(float64(y)/10)}
+	}
+	model := &mesh.Mesh{}
+	for x := range n {
+		for y := range n {
+			a, b, c, d := height(x, y), height(x+1, y), height(x+1, y+1), height(x, y+1)
+			model.Triangles = append(model.Triangles, mesh.Triangle{a, b, c}, mesh.Triangle{a, c, d})
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	defer f.Close()
+	if err := mesh.WriteBinarySTL(f, model); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+}
+
+// TestCacheGet tests decimating and reusing previews
+func TestCacheGet(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "terrain.stl")
+	writeTerrain(t, src, 100)
+	cache := New(filepath.Join(dir, "cache"), 1000)
+
+	path, err := cache.Get(src, testHash)
+	if err != nil || path == "" {
+		t.Fatalf("Failed to decimate preview: %q %v", path, err)
+	}
+	preview, err := mesh.ReadSTLFile(path)
+	if err != nil || len(preview.Triangles) > 1000 || len(preview.Triangles) < 100 {
+		t.Errorf("Expected a preview within the budget, got %v", err)
+	}
+
+	// Cached previews are served without reading the source again
+	os.Remove(src)
+	if again, err := cache.Get(src, testHash); err != nil || again != path {
+		t.Errorf("Expected the cached preview, got %q (%v)", again, err)
+	}
+}
+
+// TestCacheGetWholeModels tests which models are served as they are
+func TestCacheGetWholeModels(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "terrain.stl")
+	writeTerrain(t, src, 10)
+
+	for name, tc := range map[string]struct {
+		cache *Cache
+		hash  string
+	}{
+		"within budget":   {New(filepath.Join(dir, "cache"), 200), testHash},
+		"decimation off":  {New(filepath.Join(dir, "cache"), 0), testHash},
+		"without a hash":  {New(filepath.Join(dir, "cache"), 10), ""},
+		"an invalid hash": {New(filepath.Join(dir, "cache"), 10), "../../etc"},
+	} {
+		if path, err := tc.cache.Get(src, tc.hash); err != nil || path != "" {
+			t.Errorf("%s: expected the model served as it is, got %q (%v)", name, path, err)
+		}
+	}
+	if _, err := New(filepath.Join(dir, "cache"), 10).Get(filepath.Join(dir, "missing.stl"), testHash); err == nil {
+		t.Error("Expected an error for a missing model")
+	}
+}
+
+// TestCacheRender tests rendering and reusing PNG renders
+func TestCacheRender(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "terrain.stl")
+	writeTerrain(t, src, 20)
+	cache := New(filepath.Join(dir, "cache"), 0)
+
+	path, err := cache.Render(src, testHash, mesh.ViewIso, 256)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	f, _ := os.Open(path)
+	config, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil || format != "png" || config.Width != 256 || config.Height != 256 {
+		t.Errorf("Expected a 256x256 PNG, got %s %dx%d (%v)", format, config.Width, config.Height, err)
+	}
+
+	// Cached renders are served without reading the source again
+	os.Remove(src)
+	if again, err := cache.Render(src, testHash, mesh.ViewIso, 256); err != nil || again != path {
+		t.Errorf("Expected the cached render, got %q (%v)", again, err)
+	}
+
+	for name, tc := range map[string]struct {
+		hash string
+		view mesh.View
+		size int
+	}{
+		"without a hash":    {"", mesh.ViewIso, 256},
+		"an unknown view":   {testHash, "sideways", 256},
+		"a size not served": {testHash, mesh.ViewTop, 100},
+		"a missing model":   {testHash, mesh.ViewTop, 256},
+	} {
+		if _, err := cache.Render(src, tc.hash, tc.view, tc.size); err == nil {
+			t.Errorf("Expected an error rendering %s", name)
+		}
+	}
+}
+
+// TestCachePrune tests removing previews of models gone from the library
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "terrain.stl")
+	writeTerrain(t, src, 30)
+	cache := New(filepath.Join(dir, "cache"), 100)
+
+	kept, _ := cache.Get(src, testHash)
+	const goneHash = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	gone, _ := cache.Get(src, goneHash)
+	rebudgeted, _ := New(filepath.Join(dir, "cache"), 200).Get(src, testHash)
+	render, _ := cache.Render(src, testHash, mesh.ViewIso, 128)
+	goneRender, _ := cache.Render(src, goneHash, mesh.ViewTop, 128)
+	stale := filepath.Join(dir, "cache", "9f", ".preview-stale")
+	os.WriteFile(stale, []byte("partial"), 0644)
+	old := time.Now().Add(-2 * staleTempAge)
+	os.Chtimes(stale, old, old)
+
+	removed, err := cache.Prune(map[string]bool{testHash: true})
+	if err != nil || removed != 4 {
+		t.Fatalf("Expected 4 files removed, got %d (%v)", removed, err)
+	}
+	for _, path := range []string{kept, render} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s kept: %v", path, err)
+		}
+	}
+	for _, path := range []string{gone, rebudgeted, stale, goneRender} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s removed, got %v", path, err)
+		}
+	}
+}
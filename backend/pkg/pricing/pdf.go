@@ -0,0 +1,122 @@
+package pricing
+
+import (
+	"3dshelf/pkg/pdf"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 portrait in points, with the layout of a quote page
+const (
+	pageWidth   = 595.28
+	pageHeight  = 841.89
+	pageMargin  = 50.0
+	fontSize    = 9.0
+	lineHeight  = 14.0
+	titleSize   = 16.0
+	maxNameRune = 40
+)
+
+// columns are the x positions of the line item table's columns
+var columns = []float64{pageMargin, 260, 295, 360, 420, 475, 535}
+
+// WritePDF writes the quote as an A4 PDF: the title, the details under it
+// such as the customer and date, the line items and the totals. It uses the
+// standard Helvetica fonts, so text outside Latin-1 is replaced.
+func WritePDF(w io.Writer, quote Quote, title string, details []string) error {
+	var pages []*bytes.Buffer
+	var page *bytes.Buffer
+	var y float64
+	newPage := func() {
+		page = &bytes.Buffer{}
+		pages = append(pages, page)
+		y = pageHeight - pageMargin
+	}
+	text := func(x float64, bold bool, size float64, s string) {
+		font := "F1"
+		if bold {
+			font = "F2"
+		}
+		fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdf.String(s))
+	}
+	// row writes one table row, starting a new page when this one is full
+	row := func(bold bool, cells ...string) {
+		if y < pageMargin {
+			newPage()
+		}
+		for i, cell := range cells {
+			if cell != "" {
+				text(columns[i], bold, fontSize, cell)
+			}
+		}
+		y -= lineHeight
+	}
+
+	newPage()
+	text(pageMargin, true, titleSize, title)
+	y -= titleSize * 1.6
+	for _, detail := range details {
+		row(false, detail)
+	}
+	y -= lineHeight
+	row(true, "Item", "Qty", "Material", "Filament", "Time", "Unit", "Total")
+	for _, line := range quote.Lines {
+		name := line.Name
+		if runes := []rune(name); len(runes) > maxNameRune {
+			name = string(runes[:maxNameRune-1]) + "..."
+		}
+		time := formatDuration(line.PrintTimeSeconds)
+		if line.Source == "estimate" {
+			time += "*"
+		}
+		row(false, name, fmt.Sprint(line.Quantity), line.Material, fmt.Sprintf("%.1f g", line.FilamentGrams),
+			time, money(line.UnitCost), money(line.Cost))
+	}
+
+	y -= lineHeight / 2
+	totals := [][2]string{
+		{"Print costs", money(quote.Cost)},
+		{"Setup fee", money(quote.SetupFee)},
+		{fmt.Sprintf("Margin (%g%%)", quote.MarginPercent), money(quote.Margin)},
+	}
+	for _, total := range totals {
+		row(false, "", "", "", "", total[0], "", total[1])
+	}
+	if quote.MinimumApplied {
+		row(false, "", "", "", "", "Minimum price applied")
+	}
+	row(true, "", "", "", "", "Total ("+quote.Currency+")", "", money(quote.Total))
+	y -= lineHeight
+	row(false, "Prices per copy; * time and filament estimated from the model volume.")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page and its
+	// content stream follow
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	doc := pdf.New()
+	doc.Add("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	doc.Add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)), nil)
+	doc.Add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	doc.Add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+	for i, content := range pages {
+		doc.Add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i), nil)
+		doc.Add(fmt.Sprintf("<< /Length %d >>", content.Len()), content.Bytes())
+	}
+	return doc.Encode(w)
+}
+
+// money formats an amount with two decimals
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// formatDuration formats seconds as hours and minutes
+func formatDuration(seconds int64) string {
+	minutes := (seconds + 30) / 60
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
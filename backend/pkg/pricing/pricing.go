@@ -0,0 +1,178 @@
+// Package pricing prices prints for customers: the material, machine time and
+// labor they cost, plus the seller's setup fee and margin.
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SettingKey is the settings key the instance pricing rules are persisted under
+const SettingKey = "pricing"
+
+// Rules are a seller's costs and margin. Costs are in Currency.
+type Rules struct {
+	Currency string `json:"currency"`
+
+	// MaterialCostPerKg is what filament costs; MaterialCosts overrides it for
+	// some materials, keyed by material name like "PETG"
+	MaterialCostPerKg float64            `json:"material_cost_per_kg"`
+	MaterialCosts     map[string]float64 `json:"material_costs,omitempty"`
+
+	// MachineCostPerHour covers electricity and wear of printing
+	MachineCostPerHour float64 `json:"machine_cost_per_hour"`
+
+	// LaborCostPerHour is charged for LaborMinutesPerPart of handling, like
+	// removing supports and packing, per printed copy
+	LaborCostPerHour    float64 `json:"labor_cost_per_hour"`
+	LaborMinutesPerPart float64 `json:"labor_minutes_per_part"`
+
+	// SetupFee is added once per quote; MarginPercent on top of all costs
+	SetupFee      float64 `json:"setup_fee"`
+	MarginPercent float64 `json:"margin_percent"`
+
+	// MinimumPrice is the least a quote totals
+	MinimumPrice float64 `json:"minimum_price"`
+
+	// FillRatio is the share of a model's solid volume a print uses, and
+	// GramsPerHour how fast a printer lays filament down; they estimate models
+	// without sliced G-code
+	FillRatio    float64 `json:"fill_ratio"`
+	GramsPerHour float64 `json:"grams_per_hour"`
+}
+
+// Default are the rules used until a seller configures their own
+var Default = Rules{
+	Currency:            "USD",
+	MaterialCostPerKg:   25,
+	MachineCostPerHour:  1.5,
+	LaborCostPerHour:    20,
+	LaborMinutesPerPart: 5,
+	MarginPercent:       30,
+	FillRatio:           0.35,
+	GramsPerHour:        12,
+}
+
+// Validate checks the rules are usable
+func (r *Rules) Validate() error {
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{"material_cost_per_kg", r.MaterialCostPerKg},
+		{"machine_cost_per_hour", r.MachineCostPerHour},
+		{"labor_cost_per_hour", r.LaborCostPerHour},
+		{"labor_minutes_per_part", r.LaborMinutesPerPart},
+		{"setup_fee", r.SetupFee},
+		{"margin_percent", r.MarginPercent},
+		{"minimum_price", r.MinimumPrice},
+	} {
+		if field.value < 0 || math.IsInf(field.value, 0) || math.IsNaN(field.value) {
+			return fmt.Errorf("%s must not be negative", field.name)
+		}
+	}
+	for material, cost := range r.MaterialCosts {
+		if cost < 0 {
+			return fmt.Errorf("material cost of %s must not be negative", material)
+		}
+	}
+	if r.FillRatio <= 0 || r.FillRatio > 1 {
+		return fmt.Errorf("fill_ratio must be above 0 and at most 1")
+	}
+	if r.GramsPerHour <= 0 {
+		return fmt.Errorf("grams_per_hour must be positive")
+	}
+	return nil
+}
+
+// MaterialCost returns the cost of a kilogram of a material, ignoring case
+func (r Rules) MaterialCost(material string) float64 {
+	for name, cost := range r.MaterialCosts {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(material)) {
+			return cost
+		}
+	}
+	return r.MaterialCostPerKg
+}
+
+// Estimate returns the filament grams and print time of a model with a solid
+// volume in cm³, printed in a material of density g/cm³
+func (r Rules) Estimate(volumeCM3, density float64) (float64, int64) {
+	grams := volumeCM3 * density * r.FillRatio
+	return grams, int64(math.Round(grams / r.GramsPerHour * 3600))
+}
+
+// Part is a model to print Quantity copies of, with what one copy takes
+type Part struct {
+	Name     string `json:"name"`
+	FileID   uint   `json:"file_id,omitempty"`
+	Quantity int    `json:"quantity"`
+	Material string `json:"material"`
+
+	FilamentGrams    float64 `json:"filament_grams"`
+	PrintTimeSeconds int64   `json:"print_time_seconds"`
+
+	// Source is where the grams and time came from: "gcode" for the slicer's
+	// estimates, "estimate" for the model's volume
+	Source string `json:"source"`
+}
+
+// Line is a part as priced: the cost of one copy and of all of them
+type Line struct {
+	Part
+
+	MaterialCost float64 `json:"material_cost"`
+	MachineCost  float64 `json:"machine_cost"`
+	LaborCost    float64 `json:"labor_cost"`
+	UnitCost     float64 `json:"unit_cost"`
+	Cost         float64 `json:"cost"`
+}
+
+// Quote is an itemized price. Total is Cost plus SetupFee plus Margin, raised
+// to the minimum price when below it.
+type Quote struct {
+	Currency string `json:"currency"`
+	Lines    []Line `json:"lines"`
+
+	Cost          float64 `json:"cost"`
+	SetupFee      float64 `json:"setup_fee"`
+	MarginPercent float64 `json:"margin_percent"`
+	Margin        float64 `json:"margin"`
+	Total         float64 `json:"total"`
+
+	MinimumApplied bool `json:"minimum_applied,omitempty"`
+}
+
+// Price prices the parts by the rules. Amounts are rounded to cents.
+func Price(parts []Part, rules Rules) Quote {
+	quote := Quote{Currency: rules.Currency, Lines: []Line{}, SetupFee: rules.SetupFee, MarginPercent: rules.MarginPercent}
+	for _, part := range parts {
+		line := Line{
+			Part:         part,
+			MaterialCost: cents(part.FilamentGrams / 1000 * rules.MaterialCost(part.Material)),
+			MachineCost:  cents(float64(part.PrintTimeSeconds) / 3600 * rules.MachineCostPerHour),
+			LaborCost:    cents(rules.LaborMinutesPerPart / 60 * rules.LaborCostPerHour),
+		}
+		line.UnitCost = cents(line.MaterialCost + line.MachineCost + line.LaborCost)
+		line.Cost = cents(line.UnitCost * float64(part.Quantity))
+		quote.Lines = append(quote.Lines, line)
+		quote.Cost += line.Cost
+	}
+	quote.Cost = cents(quote.Cost)
+	quote.Margin = cents((quote.Cost + quote.SetupFee) * rules.MarginPercent / 100)
+	quote.Total = cents(quote.Cost + quote.SetupFee + quote.Margin)
+	if quote.Total < rules.MinimumPrice {
+		quote.Total, quote.MinimumApplied = rules.MinimumPrice, true
+	}
+	return quote
+}
+
+// cents rounds an amount to two decimals
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,95 @@
+package pricing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestPrice tests itemizing parts and adding the setup fee and margin
+func TestPrice(t *testing.T) {
+	rules := Default
+	rules.MaterialCosts = map[string]float64{"petg": 30}
+	rules.SetupFee = 5
+
+	quote := Price([]Part{
+		// 100g of PLA over 2h: 2.50 material, 3.00 machine, 1.67 labor
+		{Name: "vase.gcode", Quantity: 2, Material: "PLA", FilamentGrams: 100, PrintTimeSeconds: 7200, Source: "gcode"},
+		// 50g of PETG over 1h: 1.50 material, 1.50 machine, 1.67 labor
+		{Name: "clip.stl", Quantity: 1, Material: "PETG", FilamentGrams: 50, PrintTimeSeconds: 3600, Source: "estimate"},
+	}, rules)
+
+	if len(quote.Lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(quote.Lines))
+	}
+	vase, clip := quote.Lines[0], quote.Lines[1]
+	if vase.MaterialCost != 2.5 || vase.MachineCost != 3 || vase.LaborCost != 1.67 || vase.UnitCost != 7.17 || vase.Cost != 14.34 {
+		t.Errorf("Unexpected vase line: %+v", vase)
+	}
+	if clip.MaterialCost != 1.5 || clip.UnitCost != 4.67 {
+		t.Errorf("Expected PETG priced at its own cost, got %+v", clip)
+	}
+	if quote.Cost != 19.01 || quote.Margin != 7.2 || quote.Total != 31.21 || quote.Currency != "USD" {
+		t.Errorf("Unexpected totals: %+v", quote)
+	}
+
+	rules.MinimumPrice = 50
+	if quote := Price(nil, rules); quote.Total != 50 || !quote.MinimumApplied {
+		t.Errorf("Expected the minimum price, got %+v", quote)
+	}
+}
+
+// TestRulesValidate tests rejecting unusable pricing rules
+func TestRulesValidate(t *testing.T) {
+	rules := Default
+	rules.Currency = " eur "
+	if err := rules.Validate(); err != nil || rules.Currency != "EUR" {
+		t.Errorf("Expected valid rules in EUR, got %v %q", err, rules.Currency)
+	}
+
+	for name, change := range map[string]func(*Rules){
+		"no currency":       func(r *Rules) { r.Currency = "" },
+		"negative margin":   func(r *Rules) { r.MarginPercent = -1 },
+		"negative material": func(r *Rules) { r.MaterialCosts = map[string]float64{"PLA": -2} },
+		"no fill":           func(r *Rules) { r.FillRatio = 0 },
+		"no print speed":    func(r *Rules) { r.GramsPerHour = 0 },
+	} {
+		rules := Default
+		change(&rules)
+		if err := rules.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestEstimate tests estimating a model from its volume
+func TestEstimate(t *testing.T) {
+	grams, seconds := Default.Estimate(100, 1.24)
+	if grams < 43.39 || grams > 43.41 || seconds != 13020 {
+		t.Errorf("Expected 43.4g over 13020s, got %.2fg over %ds", grams, seconds)
+	}
+}
+
+// TestWritePDF tests the quote PDF structure and text encoding
+func TestWritePDF(t *testing.T) {
+	lines := make([]Part, 80)
+	for i := range lines {
+		lines[i] = Part{Name: "part (café)", Quantity: 1, Material: "PLA", FilamentGrams: 10, PrintTimeSeconds: 600}
+	}
+	var out bytes.Buffer
+	if err := WritePDF(&out, Price(lines, Default), "Quote for Ada", []string{"Customer: Ada — Etsy"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pdf := out.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Error("Expected a complete PDF document")
+	}
+	// 80 lines don't fit one page
+	if !strings.Contains(pdf, "/Count 2") {
+		t.Error("Expected the line items to run onto a second page")
+	}
+	if !strings.Contains(pdf, `(part \(caf\351\))`) || !strings.Contains(pdf, "(Customer: Ada ? Etsy)") {
+		t.Error("Expected text escaped and encoded as WinAnsi")
+	}
+}
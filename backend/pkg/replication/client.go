@@ -0,0 +1,171 @@
+package replication
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// catalogueEntry is the sync archive entry holding the catalogue
+const catalogueEntry = "catalogue.json"
+
+// ErrHashMismatch is returned when a downloaded file doesn't match the hash the source listed
+var ErrHashMismatch = errors.New("downloaded file does not match its hash")
+
+// Client reads another 3DShelf instance's catalogue and files through its API.
+// Credentials for a proxy in front of it can be given in the URL.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient creates a client for the instance at baseURL, e.g. https://shelf.example.com
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: time.Hour},
+	}
+}
+
+// Catalogue is the part of a source sync catalogue replication reads
+type Catalogue struct {
+	Cursor            string          `json:"cursor"`
+	Full              bool            `json:"full"`
+	Projects          []SourceProject `json:"projects"`
+	DeletedProjectIDs []uint          `json:"deleted_project_ids"`
+}
+
+// SourceProject is a project on the source, with its complete file list
+type SourceProject struct {
+	ID          uint         `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	License     string       `json:"license"`
+	Designer    string       `json:"designer"`
+	Source      string       `json:"source"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Files       []SourceFile `json:"files"`
+}
+
+// SourceFile is a file of a source project
+type SourceFile struct {
+	ID       uint   `json:"id"`
+	Filename string `json:"filename"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Hash     string `json:"hash"`
+}
+
+// Catalogue fetches the projects changed since cursor, or a full snapshot
+// when cursor is empty
+func (c *Client) Catalogue(ctx context.Context, cursor string) (*Catalogue, error) {
+	endpoint := c.BaseURL + "/api/sync/snapshot"
+	if cursor != "" {
+		endpoint = c.BaseURL + "/api/sync/delta?cursor=" + url.QueryEscape(cursor)
+	}
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Reading a ZIP needs random access, so the archive is spooled to disk
+	archive, err := os.CreateTemp("", "3dshelf-replication-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	size, err := io.Copy(archive, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download catalogue: %w", err)
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync archive: %w", err)
+	}
+	entry, err := reader.Open(catalogueEntry)
+	if err != nil {
+		return nil, fmt.Errorf("sync archive has no %s", catalogueEntry)
+	}
+	defer entry.Close()
+
+	var catalogue Catalogue
+	if err := json.NewDecoder(entry).Decode(&catalogue); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", catalogueEntry, err)
+	}
+	return &catalogue, nil
+}
+
+// Download saves a source project's file at dest and returns its size. The
+// file must match the hash the catalogue listed, and the checksum the source
+// sent with it; otherwise nothing is left at dest. Compressed models are
+// fetched as stored, which the hash is of.
+func (c *Client) Download(ctx context.Context, projectID uint, file SourceFile, dest string) (int64, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("%s/api/projects/%d/files/%d/download?raw=true", c.BaseURL, projectID, file.ID))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return 0, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return 0, err
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	for _, expected := range []string{file.Hash, resp.Header.Get("X-Checksum-SHA256")} {
+		if expected != "" && !strings.EqualFold(expected, sum) {
+			os.Remove(dest)
+			return 0, ErrHashMismatch
+		}
+	}
+	return size, nil
+}
+
+// Source names the instance, without any credentials in its URL
+func (c *Client) Source() string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return c.BaseURL
+	}
+	return u.Redacted()
+}
+
+// get requests a source URL, failing on any status but 200
+func (c *Client) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned %s", req.URL.Redacted(), resp.Status)
+	}
+	return resp, nil
+}
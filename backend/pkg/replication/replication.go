@@ -0,0 +1,415 @@
+package replication
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/naming"
+	"3dshelf/pkg/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// JobType identifies replication runs in the job queue
+	JobType = "replicate"
+
+	// SettingKey is the settings key the replication status is persisted under
+	SettingKey = "replication"
+
+	// DefaultDir is the directory under the scan path mirrored projects are kept in
+	DefaultDir = "mirror"
+)
+
+// Status is how replication from the source instance stands
+type Status struct {
+	// Source is the instance mirrored; a new source starts over from a full snapshot
+	Source string `json:"source"`
+	// Cursor is the source's sync cursor after the last complete run
+	Cursor string `json:"cursor,omitempty"`
+
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+
+	// Projects counts the mirrored projects
+	Projects int `json:"projects"`
+
+	// Updated, Deleted, Failed and the files and bytes fetched are of the last run
+	Updated      int   `json:"updated"`
+	Deleted      int   `json:"deleted"`
+	Failed       int   `json:"failed"`
+	FilesFetched int   `json:"files_fetched"`
+	BytesFetched int64 `json:"bytes_fetched"`
+}
+
+// Replicator mirrors the library of another 3DShelf instance: it pulls the
+// projects changed since its last run through the source's sync API and
+// downloads the files whose hash changed, verifying each one. Mirrored
+// projects are ordinary projects under <scan path>/<dir>; local changes to
+// them are overwritten when the source changes them.
+type Replicator struct {
+	db       *gorm.DB
+	queue    *jobs.Queue
+	scanner  *scanner.Scanner
+	client   *Client
+	mirrorTo string
+
+	// dirNaming turns project names into directory names
+	dirNaming naming.Policy
+}
+
+// New creates a Replicator mirroring the instance client talks to into dir
+// under scanPath, and registers its runs on queue. A failed run is retried.
+func New(db *gorm.DB, queue *jobs.Queue, scanner *scanner.Scanner, client *Client, scanPath, dir string) *Replicator {
+	r := &Replicator{
+		db:       db,
+		queue:    queue,
+		scanner:  scanner,
+		client:   client,
+		mirrorTo: filepath.Join(scanPath, dir),
+
+		dirNaming: naming.Default(),
+	}
+	queue.Register(JobType, jobs.DefaultRetryPolicy, r.runJob)
+	return r
+}
+
+// SetDirNaming sets how project names become directory names
+func (r *Replicator) SetDirNaming(policy naming.Policy) {
+	r.dirNaming = policy
+}
+
+// Start queues a replication run, or returns the one queued or running
+func (r *Replicator) Start() (*models.Job, error) {
+	return r.queue.EnqueueOnce(JobType, JobType, struct{}{})
+}
+
+// Schedule queues a run now and then every interval until ctx is done
+func (r *Replicator) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Start(); err != nil {
+			fmt.Printf("Warning: Failed to queue replication: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Source names the instance mirrored, without credentials
+func (r *Replicator) Source() string {
+	return r.client.Source()
+}
+
+// Status returns the replication status
+func (r *Replicator) Status() (Status, error) {
+	status := Status{Source: r.client.Source()}
+	if _, err := database.LoadSetting(r.db, SettingKey, &status); err != nil {
+		return status, err
+	}
+	var count int64
+	if err := r.db.Model(&models.ReplicatedProject{}).Count(&count).Error; err != nil {
+		return status, err
+	}
+	status.Projects = int(count)
+	return status, nil
+}
+
+// runJob runs a queued replication
+func (r *Replicator) runJob(ctx context.Context, _ *models.Job) error {
+	_, err := r.Replicate(ctx)
+	return err
+}
+
+// Replicate pulls what changed on the source since the last complete run: it
+// mirrors changed projects and removes deleted ones. The cursor only moves on
+// when every project replicated, so failed ones are retried by the next run.
+func (r *Replicator) Replicate(ctx context.Context) (Status, error) {
+	status, err := r.Status()
+	if err != nil {
+		return status, err
+	}
+	if status.Source != r.client.Source() {
+		status = Status{Source: r.client.Source()}
+	}
+	now := time.Now()
+	status.LastRunAt = &now
+	status.Updated, status.Deleted, status.Failed, status.FilesFetched, status.BytesFetched = 0, 0, 0, 0, 0
+
+	catalogue, err := r.client.Catalogue(ctx, status.Cursor)
+	if err != nil {
+		return r.finish(status, err)
+	}
+
+	var failures []string
+	for _, project := range catalogue.Projects {
+		if err := ctx.Err(); err != nil {
+			return r.finish(status, err)
+		}
+		if err := r.mirrorProject(ctx, project, &status); err != nil {
+			status.Failed++
+			failures = append(failures, fmt.Sprintf("%s: %v", project.Name, err))
+			continue
+		}
+		status.Updated++
+	}
+	for _, sourceID := range catalogue.DeletedProjectIDs {
+		removed, err := r.removeProject(sourceID)
+		if err != nil {
+			status.Failed++
+			failures = append(failures, fmt.Sprintf("project %d: %v", sourceID, err))
+			continue
+		}
+		if removed {
+			status.Deleted++
+		}
+	}
+	// A snapshot lists every project, so those mirrored but missing from it were deleted
+	if catalogue.Full {
+		deleted, err := r.removeUnlisted(catalogue.Projects)
+		status.Deleted += deleted
+		if err != nil {
+			status.Failed++
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return r.finish(status, fmt.Errorf("%d of the changes failed: %s", len(failures), strings.Join(failures, "; ")))
+	}
+	status.Cursor = catalogue.Cursor
+	return r.finish(status, nil)
+}
+
+// finish records how a run ended
+func (r *Replicator) finish(status Status, runErr error) (Status, error) {
+	status.LastError = ""
+	if runErr != nil {
+		status.LastError = runErr.Error()
+	} else {
+		status.LastSuccessAt = status.LastRunAt
+	}
+	var count int64
+	r.db.Model(&models.ReplicatedProject{}).Count(&count)
+	status.Projects = int(count)
+
+	if err := database.SaveSetting(r.db, SettingKey, status); err != nil && runErr == nil {
+		runErr = err
+	}
+	return status, runErr
+}
+
+// mirrorProject brings the local copy of a source project up to date. Its
+// files are assembled in a hidden directory the scanner ignores, reusing the
+// unchanged ones, and moved into place once all of them were verified.
+func (r *Replicator) mirrorProject(ctx context.Context, source SourceProject, status *Status) error {
+	var mapping models.ReplicatedProject
+	var local *models.Project
+	err := r.db.First(&mapping, source.ID).Error
+	switch {
+	case err == nil:
+		var project models.Project
+		if err := r.db.Preload("Files").First(&project, mapping.ProjectID).Error; err == nil {
+			local = &project
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	projectDir := filepath.Join(r.mirrorTo, r.dirNaming.ImportedDirName(source.Name)+"_"+strconv.FormatUint(uint64(source.ID), 10))
+	if local != nil {
+		// The directory is replaced below, so it must be one the replicator created
+		if !r.mirrored(local.Path) {
+			return fmt.Errorf("project %d is at %s, outside the mirror; refusing to replace it", local.ID, local.Path)
+		}
+		projectDir = local.Path
+	}
+	if err := fileperm.MkdirAll(r.mirrorTo); err != nil {
+		return err
+	}
+	stagingDir := filepath.Join(r.mirrorTo, "."+filepath.Base(projectDir)+".replicating")
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return err
+	}
+	if err := fileperm.MkdirAll(stagingDir); err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	depth := 0
+	unchanged := map[string]string{}
+	if local != nil {
+		for _, file := range local.Files {
+			if file.Hash != "" {
+				unchanged[file.Filepath] = file.Hash
+			}
+		}
+	}
+
+	for _, file := range source.Files {
+		rel, err := safeRelPath(file.Path, file.Filename)
+		if err != nil {
+			return err
+		}
+		depth = max(depth, strings.Count(rel, string(filepath.Separator)))
+		dest := filepath.Join(stagingDir, rel)
+		if err := fileperm.MkdirAll(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		current := filepath.Join(projectDir, rel)
+		if file.Hash != "" && unchanged[current] == file.Hash {
+			if err := fsutil.LinkOrCopy(current, dest); err == nil {
+				continue
+			}
+		}
+		size, err := r.client.Download(ctx, source.ID, file, dest)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		status.FilesFetched++
+		status.BytesFetched += size
+	}
+	if err := fileperm.Tree(stagingDir); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(projectDir); err != nil {
+		return err
+	}
+	if err := os.Rename(stagingDir, projectDir); err != nil {
+		return err
+	}
+
+	project, err := r.scanner.ImportProject(projectDir)
+	if err != nil {
+		return err
+	}
+	// The scan has to reach every file the source has, and hash them to tell
+	// the next run which are unchanged
+	if project.ScanSettings.MaxDepth < depth || project.ScanSettings.HashPolicy == models.HashNone {
+		project.ScanSettings.MaxDepth = min(max(project.ScanSettings.MaxDepth, depth), models.MaxScanDepth)
+		project.ScanSettings.HashPolicy = ""
+		if err := r.db.Model(project).Select("scan_settings").Updates(project).Error; err != nil {
+			return err
+		}
+		if project, err = r.scanner.ImportProject(projectDir); err != nil {
+			return err
+		}
+	}
+	project.Name = source.Name
+	project.Description = source.Description
+	project.Tags = source.Tags
+	project.License = source.License
+	project.Designer = source.Designer
+	project.Source = source.Source
+	if err := r.db.Save(project).Error; err != nil {
+		return err
+	}
+
+	mapping = models.ReplicatedProject{
+		SourceID:        source.ID,
+		ProjectID:       project.ID,
+		SourceUpdatedAt: source.UpdatedAt,
+		ReplicatedAt:    time.Now(),
+	}
+	return r.db.Save(&mapping).Error
+}
+
+// removeProject deletes the local copy of a project deleted on the source,
+// reporting whether there was one
+func (r *Replicator) removeProject(sourceID uint) (bool, error) {
+	var mapping models.ReplicatedProject
+	if err := r.db.First(&mapping, sourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var project models.Project
+	err := r.db.First(&project, mapping.ProjectID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if err == nil {
+		if err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := database.DeleteProjectRecords(tx, []uint{project.ID}); err != nil {
+				return err
+			}
+			return tx.Delete(&project).Error
+		}); err != nil {
+			return false, err
+		}
+		// Only directories the replicator created are removed
+		if r.mirrored(project.Path) {
+			if err := os.RemoveAll(project.Path); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, r.db.Delete(&mapping).Error
+}
+
+// mirrored reports whether path is inside the mirror directory, where only
+// projects the replicator created live
+func (r *Replicator) mirrored(path string) bool {
+	return strings.HasPrefix(filepath.Clean(path), r.mirrorTo+string(filepath.Separator))
+}
+
+// removeUnlisted removes the mirrored projects a snapshot no longer lists
+func (r *Replicator) removeUnlisted(listed []SourceProject) (int, error) {
+	keep := make([]uint, 0, len(listed))
+	for _, project := range listed {
+		keep = append(keep, project.ID)
+	}
+	var stale []uint
+	query := r.db.Model(&models.ReplicatedProject{})
+	if len(keep) > 0 {
+		query = query.Where("source_id NOT IN ?", keep)
+	}
+	if err := query.Pluck("source_id", &stale).Error; err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, sourceID := range stale {
+		if _, err := r.removeProject(sourceID); err != nil {
+			return removed, fmt.Errorf("project %d: %w", sourceID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// safeRelPath checks a source file's path stays inside the project directory,
+// falling back to its name when the source sent none
+func safeRelPath(filePath, filename string) (string, error) {
+	if filePath == "" {
+		filePath = filename
+	}
+	clean := path.Clean(filePath)
+	if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid file path %q", filePath)
+	}
+	return filepath.FromSlash(clean), nil
+}
@@ -0,0 +1,299 @@
+package replication
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/scanner"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a migrated in-memory database
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// fakeSource serves a sync catalogue and its files like a 3DShelf instance
+type fakeSource struct {
+	mu        sync.Mutex
+	catalogue Catalogue
+	contents  map[uint]string
+	// corrupt files are served with content that doesn't match their hash
+	corrupt map[uint]bool
+	// catalogueRequest is the last catalogue requested
+	catalogueRequest string
+	downloads        []uint
+}
+
+// addFile lists a file with its content and hash
+func (f *fakeSource) addFile(project *SourceProject, id uint, path, content string) {
+	sum := sha256.Sum256([]byte(content))
+	project.Files = append(project.Files, SourceFile{ID: id, Filename: filepath.Base(path), Path: path, Size: int64(len(content)), Hash: hex.EncodeToString(sum[:])})
+	f.contents[id] = content
+}
+
+func (f *fakeSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var projectID, fileID uint
+	if _, err := fmt.Sscanf(r.URL.Path, "/api/projects/%d/files/%d/download", &projectID, &fileID); err == nil {
+		content, ok := f.contents[fileID]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		sum := sha256.Sum256([]byte(content))
+		w.Header().Set("X-Checksum-SHA256", hex.EncodeToString(sum[:]))
+		if f.corrupt[fileID] {
+			content += " truncated"
+		}
+		f.downloads = append(f.downloads, fileID)
+		fmt.Fprint(w, content)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/sync/snapshot", "/api/sync/delta":
+		f.catalogueRequest = r.URL.RequestURI()
+		if _, _, ok := r.BasicAuth(); ok {
+			f.catalogueRequest += " with credentials"
+		}
+		catalogue := f.catalogue
+		catalogue.Full = r.URL.Path == "/api/sync/snapshot"
+		archive := zip.NewWriter(w)
+		entry, _ := archive.Create(catalogueEntry)
+		json.NewEncoder(entry).Encode(catalogue)
+		archive.Close()
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestReplicator creates a replicator mirroring source into a temporary scan path
+func newTestReplicator(t *testing.T, source *fakeSource) (*Replicator, *gorm.DB, string) {
+	db := setupTestDB(t)
+	server := httptest.NewServer(source)
+	t.Cleanup(server.Close)
+	scanPath := t.TempDir()
+	return New(db, jobs.New(db), scanner.New(db, scanPath), NewClient(server.URL), scanPath, DefaultDir), db, scanPath
+}
+
+// TestReplicate tests mirroring a source project and reusing its unchanged files
+func TestReplicate(t *testing.T) {
+	source := &fakeSource{contents: map[uint]string{}, corrupt: map[uint]bool{}}
+	benchy := SourceProject{ID: 7, Name: "Benchy", Description: "Torture test", Tags: []string{"calibration"}, License: "CC-BY", UpdatedAt: time.Now()}
+	source.addFile(&benchy, 1, "benchy.stl", "solid benchy")
+	source.addFile(&benchy, 2, "parts/hull.stl", "solid hull")
+	source.catalogue = Catalogue{Cursor: "c1", Projects: []SourceProject{benchy}}
+	replicator, db, scanPath := newTestReplicator(t, source)
+
+	status, err := replicator.Replicate(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.Cursor != "c1" || status.Updated != 1 || status.FilesFetched != 2 || status.BytesFetched != 22 || status.Projects != 1 || status.LastSuccessAt == nil {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	var mapping models.ReplicatedProject
+	if err := db.First(&mapping, benchy.ID).Error; err != nil {
+		t.Fatalf("Expected the project to be mapped: %v", err)
+	}
+	var project models.Project
+	db.Preload("Files").First(&project, mapping.ProjectID)
+	if project.Path != filepath.Join(scanPath, DefaultDir, "Benchy_7") || project.Description != "Torture test" || project.License != "CC-BY" || len(project.Files) != 2 {
+		t.Errorf("Unexpected mirrored project %+v", project)
+	}
+	if content, _ := os.ReadFile(filepath.Join(project.Path, "parts", "hull.stl")); string(content) != "solid hull" {
+		t.Errorf("Expected the file under its directory, got %q", content)
+	}
+
+	// Only the changed file is downloaded again, from the delta since the cursor
+	source.mu.Lock()
+	benchy.Files = benchy.Files[1:]
+	source.addFile(&benchy, 3, "benchy.stl", "solid benchy v2")
+	source.catalogue = Catalogue{Cursor: "c2", Projects: []SourceProject{benchy}}
+	source.downloads = nil
+	source.mu.Unlock()
+
+	status, err = replicator.Replicate(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(source.downloads) != "[3]" || status.FilesFetched != 1 || status.Cursor != "c2" {
+		t.Errorf("Expected only the changed file to be fetched, got %v %+v", source.downloads, status)
+	}
+	if source.catalogueRequest != "/api/sync/delta?cursor=c1" {
+		t.Errorf("Expected a delta since the cursor, got %s", source.catalogueRequest)
+	}
+	var files []models.ProjectFile
+	db.Where("project_id = ?", project.ID).Find(&files)
+	if len(files) != 2 {
+		t.Errorf("Expected the project rescanned with 2 files, got %d", len(files))
+	}
+	if content, _ := os.ReadFile(filepath.Join(project.Path, "benchy.stl")); string(content) != "solid benchy v2" {
+		t.Errorf("Expected the new version, got %q", content)
+	}
+
+	job, err := replicator.Start()
+	if err != nil || job.Type != JobType {
+		t.Errorf("Expected a queued %s job, got %+v %v", JobType, job, err)
+	}
+}
+
+// TestReplicateHashMismatch tests a corrupt download failing the run without moving the cursor
+func TestReplicateHashMismatch(t *testing.T) {
+	source := &fakeSource{contents: map[uint]string{}, corrupt: map[uint]bool{1: true}}
+	benchy := SourceProject{ID: 7, Name: "Benchy"}
+	source.addFile(&benchy, 1, "benchy.stl", "solid benchy")
+	source.catalogue = Catalogue{Cursor: "c1", Projects: []SourceProject{benchy}}
+	replicator, db, scanPath := newTestReplicator(t, source)
+
+	status, err := replicator.Replicate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), ErrHashMismatch.Error()) {
+		t.Fatalf("Expected a hash mismatch, got %v", err)
+	}
+	if status.Cursor != "" || status.Failed != 1 || status.LastError == "" || status.LastSuccessAt != nil {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	var count int64
+	db.Model(&models.Project{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no project, got %d", count)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(scanPath, DefaultDir)); len(entries) != 0 {
+		t.Errorf("Expected nothing left in the mirror, got %d entries", len(entries))
+	}
+
+	// The status survives for the next run, which retries from a snapshot
+	saved, err := replicator.Status()
+	if err != nil || saved.LastError != status.LastError {
+		t.Errorf("Expected the failure to be saved, got %+v %v", saved, err)
+	}
+	source.corrupt[1] = false
+	if status, err := replicator.Replicate(context.Background()); err != nil || status.Cursor != "c1" {
+		t.Errorf("Expected the retry to succeed, got %+v %v", status, err)
+	}
+}
+
+// TestReplicateDeletions tests removing projects deleted on the source
+func TestReplicateDeletions(t *testing.T) {
+	source := &fakeSource{contents: map[uint]string{}}
+	benchy := SourceProject{ID: 7, Name: "Benchy"}
+	cube := SourceProject{ID: 8, Name: "Cube"}
+	vase := SourceProject{ID: 9, Name: "Vase"}
+	source.addFile(&benchy, 1, "benchy.stl", "solid benchy")
+	source.addFile(&cube, 2, "cube.stl", "solid cube")
+	source.addFile(&vase, 3, "vase.stl", "solid vase")
+	source.catalogue = Catalogue{Cursor: "c1", Projects: []SourceProject{benchy, cube, vase}}
+	replicator, db, scanPath := newTestReplicator(t, source)
+
+	if _, err := replicator.Replicate(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	source.catalogue = Catalogue{Cursor: "c2", DeletedProjectIDs: []uint{8, 404}}
+	status, err := replicator.Replicate(context.Background())
+	if err != nil || status.Deleted != 1 || status.Projects != 2 {
+		t.Errorf("Expected the deleted project to be removed, got %+v %v", status, err)
+	}
+	if _, err := os.Stat(filepath.Join(scanPath, DefaultDir, "Cube_8")); !os.IsNotExist(err) {
+		t.Errorf("Expected the project directory to be removed, got %v", err)
+	}
+
+	// A new source starts over from a snapshot, which lists every project
+	replicator.client = NewClient(strings.Replace(replicator.client.BaseURL, "http://", "http://mirror:secret@", 1))
+	source.catalogue = Catalogue{Cursor: "c3", Projects: []SourceProject{benchy}}
+	status, err = replicator.Replicate(context.Background())
+	if err != nil || status.Deleted != 1 || status.Projects != 1 {
+		t.Errorf("Expected the unlisted project to be removed, got %+v %v", status, err)
+	}
+	if source.catalogueRequest != "/api/sync/snapshot with credentials" {
+		t.Errorf("Expected a snapshot for the new source, got %s", source.catalogueRequest)
+	}
+	if strings.Contains(status.Source, "secret") {
+		t.Errorf("Expected the credentials to be redacted, got %s", status.Source)
+	}
+	var projects []models.Project
+	db.Find(&projects)
+	if len(projects) != 1 || projects[0].Name != "Benchy" {
+		t.Errorf("Expected only Benchy left, got %+v", projects)
+	}
+	if err := db.First(&models.ReplicatedProject{}, vase.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected the mapping to be removed, got %v", err)
+	}
+}
+
+// TestReplicateOutsideMirror tests refusing to replace a mapped project that
+// isn't in the mirror directory
+func TestReplicateOutsideMirror(t *testing.T) {
+	source := &fakeSource{contents: map[uint]string{}}
+	benchy := SourceProject{ID: 7, Name: "Benchy"}
+	source.addFile(&benchy, 1, "benchy.stl", "solid benchy")
+	source.catalogue = Catalogue{Cursor: "c1", Projects: []SourceProject{benchy}}
+	replicator, db, scanPath := newTestReplicator(t, source)
+
+	// A mapping pointing at a library project, as after editing its path
+	library := filepath.Join(scanPath, "Benchy")
+	os.MkdirAll(library, 0755)
+	os.WriteFile(filepath.Join(library, "mine.stl"), []byte("solid mine"), 0644)
+	project := models.Project{Name: "Benchy", Path: library}
+	db.Create(&project)
+	db.Create(&models.ReplicatedProject{SourceID: benchy.ID, ProjectID: project.ID})
+
+	status, err := replicator.Replicate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "outside the mirror") || status.Failed != 1 {
+		t.Errorf("Expected the project refused, got %+v %v", status, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(library, "mine.stl")); string(content) != "solid mine" {
+		t.Errorf("Expected the library directory left alone, got %q", content)
+	}
+}
+
+// TestSafeRelPath tests rejecting paths that leave the project directory
+func TestSafeRelPath(t *testing.T) {
+	for _, tc := range []struct {
+		path, filename, expected string
+	}{
+		{"parts/hull.stl", "hull.stl", filepath.Join("parts", "hull.stl")},
+		{"", "benchy.stl", "benchy.stl"},
+		{"parts/../benchy.stl", "benchy.stl", "benchy.stl"},
+		{"../escape.stl", "escape.stl", ""},
+		{"/etc/passwd", "passwd", ""},
+		{".", "", ""},
+	} {
+		got, err := safeRelPath(tc.path, tc.filename)
+		if tc.expected == "" && err == nil {
+			t.Errorf("Expected %q to be rejected, got %q", tc.path, got)
+		}
+		if tc.expected != "" && (err != nil || got != tc.expected) {
+			t.Errorf("Expected %q for %q, got %q %v", tc.expected, tc.path, got, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package requestlog
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSize is how many requests are kept unless configured
+const DefaultSize = 500
+
+// Entry describes one handled request
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+
+	// Route is the matched route pattern, like /api/projects/:id/files; empty
+	// for requests no route matched
+	Route string `json:"route"`
+	Path  string `json:"path"`
+
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+
+	// BytesIn is the request body read by the server, which for a refused
+	// upload may be less than it declared in DeclaredBytesIn; -1 when it
+	// declared no length
+	BytesIn         int64 `json:"bytes_in"`
+	DeclaredBytesIn int64 `json:"declared_bytes_in"`
+	BytesOut        int64 `json:"bytes_out"`
+
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// User is who an authenticating reverse proxy says sent the request
+	User string `json:"user,omitempty"`
+
+	// Error is the error message of failed requests
+	Error string `json:"error,omitempty"`
+}
+
+// Filter selects entries; zero fields match every entry
+type Filter struct {
+	Method string
+
+	// Route matches entries whose route pattern or path contains it
+	Route string
+
+	// MinStatus and MaxStatus bound the status code
+	MinStatus int
+	MaxStatus int
+
+	Since time.Time
+
+	// Limit bounds how many entries are returned, newest first
+	Limit int
+}
+
+// matches reports whether the entry passes the filter
+func (f Filter) matches(e Entry) bool {
+	switch {
+	case f.Method != "" && !strings.EqualFold(f.Method, e.Method):
+		return false
+	case f.Route != "" && !strings.Contains(e.Route, f.Route) && !strings.Contains(e.Path, f.Route):
+		return false
+	case f.MinStatus > 0 && e.Status < f.MinStatus:
+		return false
+	case f.MaxStatus > 0 && e.Status > f.MaxStatus:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	}
+	return true
+}
+
+// Log keeps the most recent requests in a fixed-size ring buffer, so it costs
+// the same memory however busy the server is; it is safe for concurrent use.
+// Entries live in memory and are lost on restart. A nil Log records nothing.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// New returns a Log keeping the last size requests
+func New(size int) *Log {
+	if size < 1 {
+		size = DefaultSize
+	}
+	return &Log{entries: make([]Entry, size)}
+}
+
+// Size is how many requests the log keeps
+func (l *Log) Size() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.entries)
+}
+
+// Add records a request, replacing the oldest one when the log is full
+func (l *Log) Add(e Entry) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Entries returns the recorded requests matching the filter, newest first
+func (l *Log) Entries(f Filter) []Entry {
+	matched := []Entry{}
+	if l == nil {
+		return matched
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	for i := 1; i <= count; i++ {
+		e := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if !f.matches(e) {
+			continue
+		}
+		matched = append(matched, e)
+		if f.Limit > 0 && len(matched) == f.Limit {
+			break
+		}
+	}
+	return matched
+}
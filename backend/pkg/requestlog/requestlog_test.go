@@ -0,0 +1,57 @@
+package requestlog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLogRing tests that the log keeps the newest entries once full
+func TestLogRing(t *testing.T) {
+	log := New(3)
+	for i := 1; i <= 5; i++ {
+		log.Add(Entry{Path: "/api/projects", Status: 200 + i})
+	}
+
+	entries := log.Entries(Filter{})
+	if len(entries) != 3 || entries[0].Status != 205 || entries[2].Status != 203 {
+		t.Errorf("Expected the last 3 entries newest first, got %+v", entries)
+	}
+}
+
+// TestLogFilter tests selecting entries by route, method, status and time
+func TestLogFilter(t *testing.T) {
+	now := time.Now()
+	log := New(10)
+	log.Add(Entry{Time: now.Add(-time.Hour), Method: "GET", Route: "/api/projects", Path: "/api/projects", Status: 200})
+	log.Add(Entry{Time: now, Method: "POST", Route: "/api/projects/:id/files", Path: "/api/projects/3/files", Status: 413})
+	log.Add(Entry{Time: now, Method: "POST", Route: "/api/projects/:id/files", Path: "/api/projects/4/files", Status: 201})
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{"All", Filter{}, 3},
+		{"Route pattern", Filter{Route: "/files"}, 2},
+		{"Path", Filter{Route: "/projects/3/"}, 1},
+		{"Method", Filter{Method: "post"}, 2},
+		{"Errors", Filter{MinStatus: 400}, 1},
+		{"Successes", Filter{MaxStatus: 299}, 2},
+		{"Since", Filter{Since: now.Add(-time.Minute)}, 2},
+		{"Limit", Filter{Limit: 1}, 1},
+	}
+	for _, tc := range testCases {
+		if got := log.Entries(tc.filter); len(got) != tc.expected {
+			t.Errorf("%s: expected %d entries, got %d", tc.name, tc.expected, len(got))
+		}
+	}
+}
+
+// TestNilLog tests that a nil log records nothing
+func TestNilLog(t *testing.T) {
+	var log *Log
+	log.Add(Entry{Status: 200})
+	if entries := log.Entries(Filter{}); len(entries) != 0 || log.Size() != 0 {
+		t.Errorf("Expected a nil log to be empty, got %+v", entries)
+	}
+}
@@ -0,0 +1,448 @@
+// Package retention deletes G-code that is cheap to regenerate and no longer
+// printed, by rules reviewed in a dry run before a background job applies them.
+package retention
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/pairing"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// JobType identifies retention runs in the job queue
+	JobType = "gcode_retention"
+
+	// SettingKey is the settings key the last run is persisted under
+	SettingKey = "gcode_retention"
+
+	// projectLockWait is how long a run waits for an upload, rename or other
+	// change to a project it deletes files of
+	projectLockWait = 30 * time.Second
+)
+
+// Reasons a G-code file old enough to delete is kept
+const (
+	SpareExcluded  = "excluded_tag"
+	SparePrinted   = "printed"
+	SpareLatest    = "latest_for_model"
+	SpareCanonical = "canonical_copy"
+)
+
+// ErrStale is returned when the files a run would delete are no longer those
+// of the reviewed dry run
+var ErrStale = errors.New("library changed since the dry run; review a new plan before applying")
+
+// Rules select the G-code files a retention run deletes
+type Rules struct {
+	// MaxAgeDays deletes G-code last modified more than this many days ago
+	// and not printed within them
+	MaxAgeDays int `json:"max_age_days"`
+
+	// KeepLatest spares the newest G-code sliced from each model, however old
+	KeepLatest bool `json:"keep_latest"`
+
+	// ExcludeTags spares the G-code of projects with any of these tags
+	ExcludeTags []string `json:"exclude_tags"`
+}
+
+// Validate checks the rules and normalizes the tags
+func (r *Rules) Validate() error {
+	if r.MaxAgeDays < 1 {
+		return fmt.Errorf("max_age_days must be at least 1")
+	}
+	tags := make([]string, 0, len(r.ExcludeTags))
+	for _, tag := range r.ExcludeTags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	r.ExcludeTags = tags
+	return nil
+}
+
+// excludes reports whether a project's tags spare its G-code
+func (r Rules) excludes(tags []string) bool {
+	for _, tag := range tags {
+		for _, excluded := range r.ExcludeTags {
+			if strings.EqualFold(tag, excluded) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Candidate is a G-code file the rules delete
+type Candidate struct {
+	FileID        uint       `json:"file_id"`
+	ProjectID     uint       `json:"project_id"`
+	ProjectName   string     `json:"project_name"`
+	Filename      string     `json:"filename"`
+	Filepath      string     `json:"filepath"`
+	Size          int64      `json:"size"`
+	ModifiedAt    time.Time  `json:"modified_at"`
+	AgeDays       int        `json:"age_days"`
+	LastPrintedAt *time.Time `json:"last_printed_at,omitempty"`
+}
+
+// Plan is the dry-run report of what a retention run would delete
+type Plan struct {
+	Rules            Rules       `json:"rules"`
+	Cutoff           time.Time   `json:"cutoff"`
+	Files            []Candidate `json:"files"`
+	Count            int         `json:"count"`
+	ReclaimableBytes int64       `json:"reclaimable_bytes"`
+
+	// Spared counts the G-code old enough to delete that is kept, by reason
+	Spared map[string]int `json:"spared"`
+
+	// Token fingerprints the plan; applying requires the token of a matching dry run
+	Token string `json:"token"`
+}
+
+// Result summarizes an applied plan
+type Result struct {
+	Deleted        int      `json:"deleted"`
+	ReclaimedBytes int64    `json:"reclaimed_bytes"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// Run is how the last retention run went
+type Run struct {
+	JobID      uint       `json:"job_id"`
+	Rules      Rules      `json:"rules"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Result     *Result    `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// jobPayload is what a queued run applies
+type jobPayload struct {
+	Rules Rules  `json:"rules"`
+	Token string `json:"token"`
+}
+
+// BuildPlan lists the G-code files the rules delete as of now. G-code is
+// spared when its project has an excluded tag, when it was printed since
+// the cutoff, when KeepLatest keeps it for its model, or when deduplicated
+// copies link to it.
+func BuildPlan(db *gorm.DB, rules Rules, now time.Time) (*Plan, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	cutoff := now.AddDate(0, 0, -rules.MaxAgeDays)
+
+	var projects []models.Project
+	err := db.Where("id IN (?)", db.Model(&models.ProjectFile{}).Select("project_id").Where("file_type = ?", models.FileTypeGCode)).
+		Preload("Files", func(db *gorm.DB) *gorm.DB {
+			return db.Where("file_type IN ?", []models.FileType{models.FileTypeGCode, models.FileTypeSTL, models.FileType3MF}).Order("filename")
+		}).
+		Order("name ASC, id ASC").
+		Find(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+	printed, err := printedSince(db, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	var canonicalIDs []uint
+	if err := db.Model(&models.ProjectFile{}).Where("duplicate_of IS NOT NULL").Distinct().Pluck("duplicate_of", &canonicalIDs).Error; err != nil {
+		return nil, err
+	}
+	canonical := make(map[uint]bool, len(canonicalIDs))
+	for _, id := range canonicalIDs {
+		canonical[id] = true
+	}
+
+	plan := &Plan{Rules: rules, Cutoff: cutoff, Files: []Candidate{}, Spared: make(map[string]int)}
+	for _, project := range projects {
+		pairing.Pair(project.Files)
+		modified := make(map[uint]time.Time, len(project.Files))
+		for _, file := range project.Files {
+			modified[file.ID] = modifiedAt(file)
+		}
+		latest := latestVariants(project.Files, modified)
+
+		for _, file := range project.Files {
+			if file.FileType != models.FileTypeGCode || modified[file.ID].After(cutoff) {
+				continue
+			}
+			lastPrinted, recentlyPrinted := printed.last(project.ID, file)
+			switch {
+			case rules.excludes(project.Tags):
+				plan.Spared[SpareExcluded]++
+			case recentlyPrinted:
+				plan.Spared[SparePrinted]++
+			case rules.KeepLatest && latest[file.ID]:
+				plan.Spared[SpareLatest]++
+			case canonical[file.ID]:
+				plan.Spared[SpareCanonical]++
+			default:
+				plan.Files = append(plan.Files, Candidate{
+					FileID:        file.ID,
+					ProjectID:     project.ID,
+					ProjectName:   project.Name,
+					Filename:      file.Filename,
+					Filepath:      file.Filepath,
+					Size:          file.Size,
+					ModifiedAt:    modified[file.ID],
+					AgeDays:       int(now.Sub(modified[file.ID]).Hours() / 24),
+					LastPrintedAt: lastPrinted,
+				})
+				plan.ReclaimableBytes += file.Size
+			}
+		}
+	}
+
+	plan.Count = len(plan.Files)
+	plan.Token = fingerprint(plan)
+	return plan, nil
+}
+
+// Execute deletes the plan's files from disk and the database, with the
+// records kept by their names, and clears those chosen as covers. Each
+// project is locked while its files go, so uploads and renames wait; it
+// stops once ctx is done, as when the library lock it runs under is lost.
+func Execute(ctx context.Context, db *gorm.DB, plan *Plan) *Result {
+	result := &Result{}
+	touched := make(map[uint]bool)
+	for _, file := range plan.Files {
+		if ctx.Err() != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("stopped: %v", context.Cause(ctx)))
+			break
+		}
+		if err := deleteLocked(ctx, db, file); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", file.Filepath, err))
+			continue
+		}
+		result.Deleted++
+		result.ReclaimedBytes += file.Size
+		touched[file.ProjectID] = true
+	}
+
+	for projectID := range touched {
+		if err := db.Model(&models.Project{}).Where("id = ?", projectID).Update("last_scanned", time.Now()).Error; err != nil {
+			fmt.Printf("Warning: Failed to update project last_scanned timestamp: %v\n", err)
+		}
+	}
+	return result
+}
+
+// deleteLocked deletes a file while holding its project's lock
+func deleteLocked(ctx context.Context, db *gorm.DB, file Candidate) error {
+	_, unlock, err := database.LockWait(ctx, db, database.ProjectLock(file.ProjectID), projectLockWait)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return deleteFile(db, file)
+}
+
+// deleteFile removes one G-code file and the records kept by its name. The
+// file is moved aside until its records are gone, so a failure on either
+// side leaves both as they were.
+func deleteFile(db *gorm.DB, file Candidate) error {
+	// Hidden, like staging directories, so scans skip it
+	asidePath := filepath.Join(filepath.Dir(file.Filepath), fmt.Sprintf(".delete-retention-%d", file.FileID))
+	if err := os.Rename(file.Filepath, asidePath); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		asidePath = ""
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.ProjectFile{}, file.FileID).Error; err != nil {
+			return err
+		}
+		for _, keyed := range models.FilenameKeyed {
+			if err := tx.Where("project_id = ? AND filename = ?", file.ProjectID, file.Filename).Delete(keyed).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Project{}).Where("id = ? AND cover_filename = ?", file.ProjectID, file.Filename).Update("cover_filename", "").Error
+	})
+	if err != nil {
+		if asidePath != "" {
+			os.Rename(asidePath, file.Filepath)
+		}
+		return err
+	}
+	if asidePath != "" {
+		if err := os.Remove(asidePath); err != nil {
+			fmt.Printf("Warning: Failed to remove deleted file %s: %v\n", asidePath, err)
+		}
+	}
+	return nil
+}
+
+// modifiedAt prefers the on-disk modification time of a file, falling back
+// to the last recorded update
+func modifiedAt(file models.ProjectFile) time.Time {
+	if info, err := os.Stat(file.Filepath); err == nil {
+		return info.ModTime()
+	}
+	return file.UpdatedAt
+}
+
+// latestVariants returns the newest G-code sliced from each model, by file ID
+func latestVariants(files []models.ProjectFile, modified map[uint]time.Time) map[uint]bool {
+	latest := make(map[uint]bool)
+	for _, file := range files {
+		if len(file.SlicedVariants) == 0 {
+			continue
+		}
+		variants := append([]uint{}, file.SlicedVariants...)
+		sort.Slice(variants, func(i, j int) bool {
+			if a, b := modified[variants[i]], modified[variants[j]]; !a.Equal(b) {
+				return a.After(b)
+			}
+			return variants[i] > variants[j]
+		})
+		latest[variants[0]] = true
+	}
+	return latest
+}
+
+// prints are the files printed since a cutoff: by their activity, kept by
+// filename, and by the recorded prints of their file IDs
+type prints struct {
+	activity map[uint]map[string]*time.Time
+	fileIDs  map[uint]bool
+	cutoff   time.Time
+}
+
+// printedSince loads what was printed since the cutoff
+func printedSince(db *gorm.DB, cutoff time.Time) (*prints, error) {
+	var activities []models.FileActivity
+	if err := db.Where("last_printed_at IS NOT NULL").Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	p := &prints{activity: make(map[uint]map[string]*time.Time), fileIDs: make(map[uint]bool), cutoff: cutoff}
+	for _, activity := range activities {
+		if p.activity[activity.ProjectID] == nil {
+			p.activity[activity.ProjectID] = make(map[string]*time.Time)
+		}
+		p.activity[activity.ProjectID][activity.Filename] = activity.LastPrintedAt
+	}
+
+	var fileIDs []uint
+	if err := db.Model(&models.PrintJob{}).Where("file_id IS NOT NULL AND started_at >= ?", cutoff).Distinct().Pluck("file_id", &fileIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range fileIDs {
+		p.fileIDs[id] = true
+	}
+	return p, nil
+}
+
+// last returns when a file was last printed, and whether that was since the cutoff
+func (p *prints) last(projectID uint, file models.ProjectFile) (*time.Time, bool) {
+	lastPrinted := p.activity[projectID][file.Filename]
+	return lastPrinted, p.fileIDs[file.ID] || (lastPrinted != nil && !lastPrinted.Before(p.cutoff))
+}
+
+// fingerprint hashes the decisions in a plan so a later run can prove it
+// matches what was reviewed
+func fingerprint(plan *Plan) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d|%t|%s\n", plan.Rules.MaxAgeDays, plan.Rules.KeepLatest, strings.Join(plan.Rules.ExcludeTags, ","))
+	for _, file := range plan.Files {
+		fmt.Fprintf(h, "%d:%s\n", file.FileID, file.Filepath)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Retainer applies reviewed retention plans as background jobs
+type Retainer struct {
+	db    *gorm.DB
+	queue *jobs.Queue
+
+	// now is the clock plans are built against
+	now func() time.Time
+}
+
+// New creates a Retainer and registers its runs on queue. A failed run is
+// not retried, as the plan must be reviewed again.
+func New(db *gorm.DB, queue *jobs.Queue) *Retainer {
+	r := &Retainer{db: db, queue: queue, now: time.Now}
+	queue.Register(JobType, jobs.NoRetry, r.runJob)
+	return r
+}
+
+// Plan builds the dry-run report of the rules as of now
+func (r *Retainer) Plan(rules Rules) (*Plan, error) {
+	return BuildPlan(r.db, rules, r.now())
+}
+
+// Start queues a run applying the rules once the plan they build still
+// matches the reviewed token, or returns the run queued or running
+func (r *Retainer) Start(rules Rules, token string) (*models.Job, error) {
+	return r.queue.EnqueueOnce(JobType, JobType, jobPayload{Rules: rules, Token: token})
+}
+
+// LastRun returns how the last run went, or nil before the first one
+func (r *Retainer) LastRun() (*Run, error) {
+	var run Run
+	found, err := database.LoadSetting(r.db, SettingKey, &run)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// runJob applies a queued plan while holding the library, so no scan or
+// other library operation changes the files meanwhile; uploads, renames and
+// other changes to a project wait while its files are deleted
+func (r *Retainer) runJob(ctx context.Context, job *models.Job) error {
+	var payload jobPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return err
+	}
+	run := Run{JobID: job.ID, Rules: payload.Rules, StartedAt: r.now()}
+
+	err := func() error {
+		ctx, unlock, err := database.Lock(ctx, r.db, database.LibraryLock)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		plan, err := r.Plan(payload.Rules)
+		if err != nil {
+			return err
+		}
+		if plan.Token != payload.Token {
+			return ErrStale
+		}
+		run.Result = Execute(ctx, r.db, plan)
+		if len(run.Result.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d of %d file(s)", len(run.Result.Errors), plan.Count)
+		}
+		return nil
+	}()
+
+	finished := r.now()
+	run.FinishedAt = &finished
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if saveErr := database.SaveSetting(r.db, SettingKey, run); saveErr != nil {
+		fmt.Printf("Warning: Failed to save G-code retention run: %v\n", saveErr)
+	}
+	return err
+}
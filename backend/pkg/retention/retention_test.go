@@ -0,0 +1,194 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a migrated in-memory database
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// addFile writes a project file last modified age ago and records it
+func addFile(t *testing.T, db *gorm.DB, project models.Project, name string, age time.Duration) models.ProjectFile {
+	path := filepath.Join(project.Path, name)
+	if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	modified := time.Now().Add(-age)
+	os.Chtimes(path, modified, modified)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: name, Filepath: path, FileType: models.GetFileTypeFromExtension(name), Size: int64(len(name))}
+	db.Create(&file)
+	return file
+}
+
+const day = 24 * time.Hour
+
+// TestBuildPlan tests which old G-code the rules delete and which they spare
+func TestBuildPlan(t *testing.T) {
+	db := setupTestDB(t)
+
+	bracket := models.Project{Name: "Bracket", Path: t.TempDir()}
+	db.Create(&bracket)
+	addFile(t, db, bracket, "bracket.stl", 800*day)
+	oldest := addFile(t, db, bracket, "bracket_0.2mm.gcode", 700*day)
+	newest := addFile(t, db, bracket, "bracket_0.1mm.gcode", 500*day)
+	printed := addFile(t, db, bracket, "bracket_0.3mm.gcode", 600*day)
+	addFile(t, db, bracket, "bracket_fast.gcode", 10*day)
+	orphan := addFile(t, db, bracket, "calibration.gcode", 400*day)
+	recently := time.Now().Add(-30 * day)
+	db.Create(&models.FileActivity{ProjectID: bracket.ID, Filename: printed.Filename, LastPrintedAt: &recently})
+
+	archived := models.Project{Name: "Archive", Path: t.TempDir(), Tags: []string{"Keep"}}
+	db.Create(&archived)
+	addFile(t, db, archived, "old.gcode", 900*day)
+
+	plan, err := BuildPlan(db, Rules{MaxAgeDays: 365, ExcludeTags: []string{" keep ", ""}}, time.Now())
+	if err != nil {
+		t.Fatalf("BuildPlan failed: %v", err)
+	}
+	deleted := map[uint]bool{}
+	for _, file := range plan.Files {
+		deleted[file.FileID] = true
+	}
+	if len(plan.Files) != 3 || !deleted[oldest.ID] || !deleted[newest.ID] || !deleted[orphan.ID] {
+		t.Errorf("Expected the old unprinted G-code of Bracket, got %+v", plan.Files)
+	}
+	if plan.Spared[SparePrinted] != 1 || plan.Spared[SpareExcluded] != 1 {
+		t.Errorf("Expected the printed and excluded G-code spared, got %v", plan.Spared)
+	}
+	if plan.Count != 3 || plan.ReclaimableBytes != oldest.Size+newest.Size+orphan.Size {
+		t.Errorf("Unexpected totals %d and %d bytes", plan.Count, plan.ReclaimableBytes)
+	}
+	if plan.Rules.ExcludeTags[0] != "keep" || len(plan.Rules.ExcludeTags) != 1 {
+		t.Errorf("Expected the tags normalized, got %q", plan.Rules.ExcludeTags)
+	}
+
+	// Once the recent variant is gone, KeepLatest keeps the newest old one
+	fast := models.ProjectFile{}
+	db.Where("filename = ?", "bracket_fast.gcode").First(&fast)
+	db.Delete(&fast)
+	plan, _ = BuildPlan(db, Rules{MaxAgeDays: 365, KeepLatest: true, ExcludeTags: []string{"keep"}}, time.Now())
+	for _, file := range plan.Files {
+		if file.FileID == newest.ID {
+			t.Errorf("Expected the latest variant of bracket.stl kept, got %+v", plan.Files)
+		}
+	}
+	if plan.Count != 2 || plan.Spared[SpareLatest] != 1 {
+		t.Errorf("Expected the oldest and unpaired G-code deleted, got %+v spared %v", plan.Files, plan.Spared)
+	}
+
+	again, _ := BuildPlan(db, Rules{MaxAgeDays: 365, KeepLatest: true, ExcludeTags: []string{"keep"}}, time.Now())
+	if again.Token != plan.Token {
+		t.Error("Expected the same plan to keep its token")
+	}
+	if other, _ := BuildPlan(db, Rules{MaxAgeDays: 365, ExcludeTags: []string{"keep"}}, time.Now()); other.Token == plan.Token {
+		t.Error("Expected other rules to change the token")
+	}
+
+	if _, err := BuildPlan(db, Rules{}, time.Now()); err == nil {
+		t.Error("Expected an error without a maximum age")
+	}
+}
+
+// TestExecute tests a run deleting files with the records kept by their name
+func TestExecute(t *testing.T) {
+	db := setupTestDB(t)
+	queue := jobs.New(db)
+	retainer := New(db, queue)
+
+	project := models.Project{Name: "Bracket", Path: t.TempDir(), CoverFilename: "old.gcode"}
+	db.Create(&project)
+	old := addFile(t, db, project, "old.gcode", 400*day)
+	longAgo := time.Now().Add(-500 * day)
+	db.Create(&models.FileActivity{ProjectID: project.ID, Filename: old.Filename, LastPrintedAt: &longAgo})
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: old.Filename, Notes: "0.2mm"})
+
+	plan, err := retainer.Plan(Rules{MaxAgeDays: 365})
+	if err != nil || plan.Count != 1 || plan.Files[0].LastPrintedAt == nil {
+		t.Fatalf("Expected the file printed long ago in the plan, got %+v %v", plan, err)
+	}
+
+	job, err := retainer.Start(plan.Rules, plan.Token)
+	if err != nil || job.Type != JobType {
+		t.Fatalf("Expected a queued %s job, got %+v %v", JobType, job, err)
+	}
+	if err := retainer.runJob(context.Background(), job); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if _, err := os.Stat(old.Filepath); !os.IsNotExist(err) {
+		t.Errorf("Expected the file deleted from disk, got %v", err)
+	}
+	var files, activities, profiles int64
+	db.Model(&models.ProjectFile{}).Count(&files)
+	db.Model(&models.FileActivity{}).Count(&activities)
+	db.Model(&models.FileProfile{}).Count(&profiles)
+	db.First(&project, project.ID)
+	if files != 0 || activities != 0 || profiles != 0 || project.CoverFilename != "" || project.LastScanned.IsZero() {
+		t.Errorf("Expected the file, its activity, profile and cover gone, got %d files %d activities %d profiles cover %q", files, activities, profiles, project.CoverFilename)
+	}
+	if entries, _ := os.ReadDir(project.Path); len(entries) != 0 {
+		t.Errorf("Expected nothing left in the project directory, got %v", entries)
+	}
+
+	run, err := retainer.LastRun()
+	if err != nil || run == nil || run.JobID != job.ID || run.Result == nil || run.Result.Deleted != 1 || run.Error != "" {
+		t.Errorf("Expected the run saved, got %+v %v", run, err)
+	}
+
+	t.Run("Database failure", func(t *testing.T) {
+		kept := addFile(t, db, project, "kept.gcode", 400*day)
+		db.Migrator().DropTable(&models.FileProfile{})
+		defer db.AutoMigrate(&models.FileProfile{})
+
+		result := Execute(context.Background(), db, &Plan{Files: []Candidate{{FileID: kept.ID, ProjectID: project.ID, Filename: kept.Filename, Filepath: kept.Filepath}}})
+		if result.Deleted != 0 || len(result.Errors) != 1 {
+			t.Fatalf("Expected the deletion to fail, got %+v", result)
+		}
+		if content, err := os.ReadFile(kept.Filepath); err != nil || string(content) != kept.Filename {
+			t.Errorf("Expected the file put back, got %q %v", content, err)
+		}
+		if err := db.First(&models.ProjectFile{}, kept.ID).Error; err != nil {
+			t.Errorf("Expected the file record kept: %v", err)
+		}
+		db.Delete(&models.ProjectFile{}, kept.ID)
+		os.Remove(kept.Filepath)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		addFile(t, db, project, "other.gcode", 400*day)
+		payload, _ := json.Marshal(jobPayload{Rules: Rules{MaxAgeDays: 365}, Token: plan.Token})
+		if err := retainer.runJob(context.Background(), &models.Job{ID: job.ID + 1, Payload: payload}); !errors.Is(err, ErrStale) {
+			t.Errorf("Expected %v, got %v", ErrStale, err)
+		}
+		var count int64
+		db.Model(&models.ProjectFile{}).Count(&count)
+		if count != 1 {
+			t.Errorf("Expected nothing deleted, got %d files", count)
+		}
+		if run, _ := retainer.LastRun(); run == nil || run.Error == "" || run.Result != nil {
+			t.Errorf("Expected the failed run saved, got %+v", run)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/sidecar"
+	"fmt"
+	"os"
+)
+
+// DetectionSettingKey is the settings key detection rules are persisted under
+const DetectionSettingKey = "detection"
+
+// DetectionRules decide which directories are treated as projects
+type DetectionRules struct {
+	// FileTypes qualify a directory as a project
+	FileTypes []models.FileType `json:"file_types"`
+	// MinFiles is how many qualifying files a directory needs
+	MinFiles int `json:"min_files"`
+	// READMEOnlyWithSidecar accepts folders with just a README when a .3dshelf.json sidecar is present
+	READMEOnlyWithSidecar bool `json:"readme_only_with_sidecar"`
+	// ImageOnlyWithSidecar accepts folders with just images when a .3dshelf.json sidecar is present
+	ImageOnlyWithSidecar bool `json:"image_only_with_sidecar"`
+}
+
+// DefaultDetectionRules returns the built-in rules: any STL, 3MF or G-code file makes a project
+func DefaultDetectionRules() DetectionRules {
+	return DetectionRules{
+		FileTypes: []models.FileType{models.FileTypeSTL, models.FileType3MF, models.FileTypeGCode},
+		MinFiles:  1,
+	}
+}
+
+// Validate checks that the rules can detect projects
+func (r DetectionRules) Validate() error {
+	if len(r.FileTypes) == 0 {
+		return fmt.Errorf("at least one project file type is required")
+	}
+	for _, fileType := range r.FileTypes {
+		switch fileType {
+		case models.FileTypeSTL, models.FileType3MF, models.FileTypeGCode, models.FileTypeCAD, models.FileTypeREADME, models.FileTypeImage, models.FileTypeOther:
+		default:
+			return fmt.Errorf("unsupported project file type: %s", fileType)
+		}
+	}
+	if r.MinFiles < 1 {
+		return fmt.Errorf("min_files must be at least 1")
+	}
+	return nil
+}
+
+// qualifies reports whether a file type counts towards the minimum
+func (r DetectionRules) qualifies(fileType models.FileType) bool {
+	for _, qualifying := range r.FileTypes {
+		if fileType == qualifying {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDetectionRules replaces the rules used by subsequent scans
+func (s *Scanner) SetDetectionRules(rules DetectionRules) {
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+	s.rules = rules
+}
+
+// DetectionRules returns the rules currently used to detect projects
+func (s *Scanner) DetectionRules() DetectionRules {
+	s.rulesMu.RLock()
+	defer s.rulesMu.RUnlock()
+
+	rules := s.rules
+	rules.FileTypes = append([]models.FileType(nil), s.rules.FileTypes...)
+	return rules
+}
+
+// containsProjectFiles checks if a directory qualifies as a project under the detection rules
+func (s *Scanner) containsProjectFiles(dirPath string) bool {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return false
+	}
+
+	rules := s.DetectionRules()
+	var qualifying, images, others int
+	var hasSidecar, hasREADME bool
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := entry.Name()
+		if filename == sidecar.Filename {
+			hasSidecar = true
+			continue
+		}
+
+		fileType := models.GetFileTypeFromExtension(filename)
+		if rules.qualifies(fileType) {
+			qualifying++
+		}
+		if fileType == models.FileTypeREADME {
+			hasREADME = true
+		}
+		if models.IsImageFile(filename) {
+			images++
+		} else {
+			others++
+		}
+	}
+
+	if qualifying >= rules.MinFiles {
+		return true
+	}
+
+	if hasSidecar {
+		if rules.READMEOnlyWithSidecar && hasREADME {
+			return true
+		}
+		if rules.ImageOnlyWithSidecar && images > 0 && others == 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"3dshelf/internal/models"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FlatMode controls how loose model files at the scan root become projects
+type FlatMode string
+
+const (
+	// FlatOff ignores files at the scan root
+	FlatOff FlatMode = "off"
+	// FlatSingle makes every qualifying root file its own project
+	FlatSingle FlatMode = "single"
+	// FlatPrefix groups root files sharing a filename prefix into one project
+	FlatPrefix FlatMode = "prefix"
+)
+
+// flatPathSuffix marks the virtual path of flat projects so it can't collide with a real directory
+const flatPathSuffix = "#flat"
+
+// ParseFlatMode converts a config value into a FlatMode
+func ParseFlatMode(value string) (FlatMode, error) {
+	switch mode := FlatMode(strings.ToLower(value)); mode {
+	case "", FlatOff:
+		return FlatOff, nil
+	case FlatSingle, FlatPrefix:
+		return mode, nil
+	default:
+		return FlatOff, fmt.Errorf("unsupported flat-file mode: %s", value)
+	}
+}
+
+// SetFlatMode sets how loose files at the scan root are treated
+func (s *Scanner) SetFlatMode(mode FlatMode) {
+	s.flatMode = mode
+}
+
+// flatGroupKey returns the project key a root file belongs to under the mode
+func flatGroupKey(mode FlatMode, filename string) string {
+	if mode == FlatSingle {
+		return filename
+	}
+
+	stem := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if i := strings.IndexAny(stem, "_- ."); i > 0 {
+		return stem[:i]
+	}
+	return stem
+}
+
+// flatProjectPath returns the virtual path identifying a flat project
+func (s *Scanner) flatProjectPath(key string) string {
+	return filepath.Join(s.scanPath, key) + flatPathSuffix
+}
+
+// scanFlatFiles turns loose files at the scan root into flat projects
+func (s *Scanner) scanFlatFiles() error {
+	if s.flatMode == "" || s.flatMode == FlatOff {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.scanPath)
+	if err != nil {
+		return err
+	}
+
+	rules := s.DetectionRules()
+	groups := make(map[string][]string)
+	qualifying := make(map[string]bool)
+
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(filename, ".") {
+			continue
+		}
+
+		fileType := models.GetFileTypeFromExtension(filename)
+		if s.flatMode == FlatSingle && !rules.qualifies(fileType) {
+			continue
+		}
+
+		key := flatGroupKey(s.flatMode, filename)
+		groups[key] = append(groups[key], filepath.Join(s.scanPath, filename))
+		if rules.qualifies(fileType) {
+			qualifying[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		if qualifying[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := s.processFlatProject(key, groups[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processFlatProject creates or refreshes the flat project for a group of root files
+func (s *Scanner) processFlatProject(key string, paths []string) error {
+	path := s.flatProjectPath(key)
+	if s.run != nil {
+		s.run.ProjectsFound++
+	}
+
+	var project models.Project
+	err := s.db.Unscoped().Where("path = ?", path).First(&project).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	restored := false
+	if !created {
+		unlock, err := s.lockProject(project.ID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if restored, err = s.restoreProject(&project); err != nil {
+			return err
+		}
+	}
+	if created {
+		project = models.Project{
+			Name:   key,
+			Path:   path,
+			Layout: models.LayoutFlat,
+			Status: models.StatusHealthy,
+		}
+	}
+	project.LastScanned = time.Now()
+
+	if err := s.db.Save(&project).Error; err != nil {
+		return err
+	}
+
+	if err := s.db.Where("project_id = ?", project.ID).Delete(&models.ProjectFile{}).Error; err != nil {
+		return err
+	}
+	if err := s.recordFiles(&project, s.scanPath, paths); err != nil {
+		return err
+	}
+
+	if s.run != nil {
+		if created || restored {
+			s.run.ProjectsAdded++
+		} else {
+			s.run.ProjectsUpdated++
+		}
+	}
+	return nil
+}
+
+// flatProjectMissing reports whether a flat project should be removed: flat
+// mode was turned off, or none of its files remain in the scan root
+func (s *Scanner) flatProjectMissing(project *models.Project) (bool, error) {
+	if s.flatMode == "" || s.flatMode == FlatOff {
+		return true, nil
+	}
+
+	var files []models.ProjectFile
+	if err := s.db.Where("project_id = ?", project.ID).Find(&files).Error; err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file.Filepath); err == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"3dshelf/internal/models"
+)
+
+// writeRootFiles creates loose files directly in the scan root
+func writeRootFiles(t *testing.T, root string, names ...string) {
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// flatProjects returns flat projects and their filenames keyed by project name
+func flatProjects(t *testing.T, scanner *Scanner) map[string][]string {
+	var projects []models.Project
+	if err := scanner.db.Where("layout = ?", models.LayoutFlat).Preload("Files").Find(&projects).Error; err != nil {
+		t.Fatalf("Failed to load flat projects: %v", err)
+	}
+
+	result := make(map[string][]string)
+	for _, project := range projects {
+		var names []string
+		for _, file := range project.Files {
+			names = append(names, file.Filename)
+		}
+		sort.Strings(names)
+		result[project.Name] = names
+	}
+	return result
+}
+
+func TestParseFlatMode(t *testing.T) {
+	for value, expected := range map[string]FlatMode{"": FlatOff, "off": FlatOff, "single": FlatSingle, "PREFIX": FlatPrefix} {
+		mode, err := ParseFlatMode(value)
+		if err != nil || mode != expected {
+			t.Errorf("ParseFlatMode(%q) = %v, %v; expected %v", value, mode, err, expected)
+		}
+	}
+
+	if _, err := ParseFlatMode("folders"); err == nil {
+		t.Error("Expected unsupported mode to fail")
+	}
+}
+
+func TestFlatFileModes(t *testing.T) {
+	files := []string{"benchy_hull.stl", "benchy_cabin.stl", "benchy-notes.txt", "gear.3mf", "notes.txt"}
+
+	t.Run("Off", func(t *testing.T) {
+		db := setupTestDB(t)
+		root := t.TempDir()
+		writeRootFiles(t, root, files...)
+
+		scanner := New(db, root)
+		if err := scanner.ScanForProjects(); err != nil {
+			t.Fatalf("ScanForProjects failed: %v", err)
+		}
+		if projects := flatProjects(t, scanner); len(projects) != 0 {
+			t.Errorf("Expected no flat projects, got %v", projects)
+		}
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		db := setupTestDB(t)
+		root := t.TempDir()
+		writeRootFiles(t, root, files...)
+
+		scanner := New(db, root)
+		scanner.SetFlatMode(FlatSingle)
+		if err := scanner.ScanForProjects(); err != nil {
+			t.Fatalf("ScanForProjects failed: %v", err)
+		}
+
+		projects := flatProjects(t, scanner)
+		if len(projects) != 3 {
+			t.Fatalf("Expected 3 single-file projects, got %v", projects)
+		}
+		if names := projects["gear.3mf"]; len(names) != 1 || names[0] != "gear.3mf" {
+			t.Errorf("Expected gear.3mf project with its file, got %v", names)
+		}
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		db := setupTestDB(t)
+		root := t.TempDir()
+		writeRootFiles(t, root, files...)
+
+		scanner := New(db, root)
+		scanner.SetFlatMode(FlatPrefix)
+		if err := scanner.ScanForProjects(); err != nil {
+			t.Fatalf("ScanForProjects failed: %v", err)
+		}
+
+		projects := flatProjects(t, scanner)
+		if len(projects) != 2 {
+			t.Fatalf("Expected benchy and gear projects, got %v", projects)
+		}
+		benchy := projects["benchy"]
+		if len(benchy) != 3 || benchy[0] != "benchy-notes.txt" {
+			t.Errorf("Expected benchy group with its notes, got %v", benchy)
+		}
+		if _, exists := projects["notes"]; exists {
+			t.Error("Expected groups without model files to be ignored")
+		}
+	})
+}
+
+func TestFlatProjectRemoval(t *testing.T) {
+	db := setupTestDB(t)
+	root := t.TempDir()
+	writeRootFiles(t, root, "gear.stl", "bolt.stl")
+
+	scanner := New(db, root)
+	scanner.SetFlatMode(FlatSingle)
+
+	run, err := scanner.Run(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if run.ProjectsAdded != 2 {
+		t.Errorf("Expected 2 projects added, got %d", run.ProjectsAdded)
+	}
+
+	os.Remove(filepath.Join(root, "bolt.stl"))
+	run, err = scanner.Run(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if run.ProjectsUpdated != 1 || run.ProjectsRemoved != 1 {
+		t.Errorf("Expected 1 updated and 1 removed, got %d updated, %d removed", run.ProjectsUpdated, run.ProjectsRemoved)
+	}
+
+	scanner.SetFlatMode(FlatOff)
+	if _, err := scanner.Run(models.ScanTriggerManual); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if projects := flatProjects(t, scanner); len(projects) != 0 {
+		t.Errorf("Expected flat projects to be removed once flat mode is off, got %v", projects)
+	}
+}
@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// JobType identifies background scans in the job queue
+	JobType = "scan"
+
+	// jobKey deduplicates background scans: the library is scanned by one at a time
+	jobKey = "library"
+)
+
+var (
+	// ErrNoQueue is returned when starting a background scan without a job queue
+	ErrNoQueue = errors.New("no job queue configured for background scans")
+	// ErrScanCancelled stops a scan whose cancellation was requested
+	ErrScanCancelled = errors.New("scan cancelled")
+	// ErrScanFinished is returned when cancelling a scan that is no longer pending or running
+	ErrScanFinished = errors.New("scan has already finished")
+)
+
+// jobPayload is the queued job's reference to the run it performs
+type jobPayload struct {
+	ScanRunID uint `json:"scan_run_id"`
+}
+
+// SetQueue runs scans started through Start as jobs on queue. Scans are
+// recorded as they go, so failed ones are not retried.
+func (s *Scanner) SetQueue(queue *jobs.Queue) {
+	s.queue = queue
+	queue.Register(JobType, jobs.NoRetry, s.runJob)
+}
+
+// Start queues a full scan and returns its pending run, whose progress is
+// saved as it runs. While a background scan is unfinished its run is
+// returned instead, and database.ErrLocked while another instance or a
+// synchronous run holds the library.
+func (s *Scanner) Start(trigger models.ScanTrigger) (*models.ScanRun, error) {
+	if s.queue == nil {
+		return nil, ErrNoQueue
+	}
+	if queued, err := s.queue.Unfinished(JobType, jobKey); err != nil || queued != nil {
+		return s.queuedRun(queued, err)
+	}
+
+	// Refuse up front rather than leave the job waiting on the lock
+	_, unlock, err := database.Lock(context.Background(), s.db, database.LibraryLock)
+	if err != nil {
+		return nil, err
+	}
+	unlock()
+
+	run := &models.ScanRun{
+		Trigger:   trigger,
+		Status:    models.ScanRunPending,
+		Roots:     []string{s.scanPath},
+		StartedAt: time.Now(),
+		Errors:    []string{},
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	queued, err := s.queue.EnqueueOnce(JobType, jobKey, jobPayload{ScanRunID: run.ID})
+	if err != nil || !queuedFor(queued, run.ID) {
+		// Another request queued a scan in the meantime, or none could be
+		s.db.Delete(run)
+		return s.queuedRun(queued, err)
+	}
+	return run, nil
+}
+
+// Wait blocks until the background scan performing a run finishes
+func (s *Scanner) Wait(runID uint) {
+	if s.queue == nil {
+		return
+	}
+	if queued, err := s.queue.Unfinished(JobType, jobKey); err == nil && queuedFor(queued, runID) {
+		s.queue.Wait(queued.ID)
+	}
+}
+
+// Cancel cancels a scan run. Pending runs are cancelled at once; running ones
+// stop at their next progress check, on whichever instance runs them.
+func (s *Scanner) Cancel(runID uint) (*models.ScanRun, error) {
+	var run models.ScanRun
+	if err := s.db.First(&run, runID).Error; err != nil {
+		return nil, err
+	}
+
+	result := s.db.Model(&models.ScanRun{}).Where("id = ? AND status = ?", runID, models.ScanRunPending).
+		Updates(map[string]interface{}{"status": models.ScanRunCancelled, "cancel_requested": true, "finished_at": time.Now()})
+	if result.Error == nil && result.RowsAffected == 0 {
+		result = s.db.Model(&models.ScanRun{}).Where("id = ? AND status = ?", runID, models.ScanRunRunning).
+			Update("cancel_requested", true)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return &run, ErrScanFinished
+		}
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	err := s.db.First(&run, runID).Error
+	return &run, err
+}
+
+// runJob performs the run a queued job refers to, unless it was cancelled
+// before a worker got to it
+func (s *Scanner) runJob(ctx context.Context, queued *models.Job) error {
+	var payload jobPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Wait out watcher syncs and other instances' library operations
+	ctx, unlock, err := database.LockWait(ctx, s.db, database.LibraryLock, libraryLockWait)
+	if err != nil {
+		var run models.ScanRun
+		if s.db.First(&run, payload.ScanRunID).Error == nil && run.Status == models.ScanRunPending {
+			finishedAt := time.Now()
+			run.Status = models.ScanRunFailed
+			run.FinishedAt = &finishedAt
+			run.Errors = append(run.Errors, err.Error())
+			s.db.Save(&run)
+		}
+		return err
+	}
+	defer unlock()
+
+	// Runs interrupted by a shutdown start over, so their progress is reset too
+	result := s.db.Model(&models.ScanRun{}).Where("id = ? AND status = ?", payload.ScanRunID, models.ScanRunPending).
+		Updates(map[string]interface{}{
+			"status": models.ScanRunRunning, "started_at": time.Now(),
+			"dirs_visited": 0, "projects_found": 0, "files_hashed": 0,
+			"projects_added": 0, "projects_updated": 0, "projects_removed": 0,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var run models.ScanRun
+	if err := s.db.First(&run, payload.ScanRunID).Error; err != nil {
+		return err
+	}
+	if _, err := s.execute(ctx, &run, s.fullScan); err != nil && !errors.Is(err, ErrScanCancelled) {
+		return err
+	}
+	return nil
+}
+
+// queuedRun returns the run a queued scan performs
+func (s *Scanner) queuedRun(queued *models.Job, err error) (*models.ScanRun, error) {
+	if err != nil {
+		return nil, err
+	}
+	var payload jobPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+	var run models.ScanRun
+	if err := s.db.First(&run, payload.ScanRunID).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// queuedFor reports whether a queued scan performs the given run
+func queuedFor(queued *models.Job, runID uint) bool {
+	var payload jobPayload
+	return queued != nil && queued.DecodePayload(&payload) == nil && payload.ScanRunID == runID
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupJobsDB creates a file-backed database the queue's workers share
+func setupJobsDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scan.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Project{}, &models.ProjectFile{}, &models.ScanRun{}, &models.Lock{}, &models.Job{}); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// TestStartScan tests running a scan as a background job
+func TestStartScan(t *testing.T) {
+	db := setupJobsDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+	queue := jobs.New(db)
+	scanner.SetQueue(queue)
+
+	createTestProject(t, tmpDir, "Boat", map[string]string{"benchy.stl": "STL content", "README.md": "# Boat"})
+	createTestProject(t, filepath.Join(tmpDir, "Shelf"), "Cube", map[string]string{"cube.3mf": "3MF content"})
+
+	run, err := scanner.Start(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if run.Status != models.ScanRunPending || run.FinishedAt != nil {
+		t.Errorf("Expected a pending run, got %+v", run)
+	}
+	// Workers aren't started yet, so the first scan is still unfinished
+	again, err := scanner.Start(models.ScanTriggerManual)
+	if err != nil || again.ID != run.ID {
+		t.Errorf("Expected the unfinished run %d again, got %+v %v", run.ID, again, err)
+	}
+
+	if err := queue.Start(1); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+	defer queue.Stop()
+	scanner.Wait(run.ID)
+
+	var finished models.ScanRun
+	db.First(&finished, run.ID)
+	if finished.Status != models.ScanRunCompleted || finished.FinishedAt == nil || finished.ProjectsAdded != 2 {
+		t.Fatalf("Expected the run completed with 2 projects, got %+v", finished)
+	}
+	if finished.DirsVisited != 3 || finished.ProjectsFound != 2 || finished.FilesHashed != 3 {
+		t.Errorf("Expected 3 directories, 2 projects and 3 files in the progress, got %+v", finished)
+	}
+	var count int64
+	db.Model(&models.ScanRun{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected one recorded run, got %d", count)
+	}
+}
+
+// TestStartScanLocked tests background scans are refused while the library is locked
+func TestStartScanLocked(t *testing.T) {
+	db := setupJobsDB(t)
+	scanner := New(db, t.TempDir())
+
+	if _, err := scanner.Start(models.ScanTriggerManual); !errors.Is(err, ErrNoQueue) {
+		t.Errorf("Expected ErrNoQueue without a queue, got %v", err)
+	}
+
+	scanner.SetQueue(jobs.New(db))
+	db.Create(&models.Lock{Name: database.LibraryLock, Owner: "node-b-1#1", ExpiresAt: time.Now().Add(time.Minute)})
+	if _, err := scanner.Start(models.ScanTriggerManual); !errors.Is(err, database.ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+	var count int64
+	db.Model(&models.Job{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no queued scan, got %d", count)
+	}
+}
+
+// TestCancelScan tests cancelling pending and running scans
+func TestCancelScan(t *testing.T) {
+	db := setupJobsDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+	queue := jobs.New(db)
+	scanner.SetQueue(queue)
+	createTestProject(t, tmpDir, "Boat", map[string]string{"benchy.stl": "STL content"})
+
+	// A pending run is cancelled at once, and its job does nothing
+	run, err := scanner.Start(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	cancelled, err := scanner.Cancel(run.ID)
+	if err != nil || cancelled.Status != models.ScanRunCancelled || cancelled.FinishedAt == nil {
+		t.Fatalf("Expected the pending run cancelled, got %+v %v", cancelled, err)
+	}
+	if err := queue.Start(1); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+	defer queue.Stop()
+	scanner.Wait(run.ID)
+	var projects int64
+	db.Model(&models.Project{}).Count(&projects)
+	if projects != 0 {
+		t.Errorf("Expected the cancelled scan not to run, got %d projects", projects)
+	}
+	if _, err := scanner.Cancel(run.ID); !errors.Is(err, ErrScanFinished) {
+		t.Errorf("Expected ErrScanFinished cancelling again, got %v", err)
+	}
+	if _, err := scanner.Cancel(999); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+
+	// A running one stops at its next progress check, which saves its progress
+	running := &models.ScanRun{Trigger: models.ScanTriggerManual, Status: models.ScanRunRunning, StartedAt: time.Now(), Errors: []string{}}
+	db.Create(running)
+	if _, err := scanner.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	running.DirsVisited = 4
+	scanner.run, scanner.ctx = running, context.Background()
+	if err := scanner.checkpoint(); !errors.Is(err, ErrScanCancelled) {
+		t.Errorf("Expected ErrScanCancelled, got %v", err)
+	}
+	scanner.run = nil
+	var saved models.ScanRun
+	db.First(&saved, running.ID)
+	if saved.DirsVisited != 4 || !saved.CancelRequested {
+		t.Errorf("Expected the progress saved with the cancellation, got %+v", saved)
+	}
+}
+
+// TestExecuteInterrupted tests runs interrupted by a shutdown are left to run again
+func TestExecuteInterrupted(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+	createTestProject(t, tmpDir, "Boat", map[string]string{"benchy.stl": "STL content"})
+
+	run := &models.ScanRun{Trigger: models.ScanTriggerManual, Status: models.ScanRunRunning, StartedAt: time.Now(), Errors: []string{}}
+	db.Create(run)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scanner.execute(ctx, run, scanner.fullScan); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the scan interrupted, got %v", err)
+	}
+	if run.Status != models.ScanRunPending || run.FinishedAt != nil || len(run.Errors) != 0 {
+		t.Errorf("Expected the run pending again, got %+v", run)
+	}
+}
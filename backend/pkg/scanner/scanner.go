@@ -2,22 +2,82 @@ package scanner
 
 import (
 	"3dshelf/internal/models"
-	"crypto/sha256"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/extractor"
+	"3dshelf/pkg/frontmatter"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/gcode"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/sidecar"
+	"3dshelf/pkg/thumbnail"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// maxREADMESize bounds how much of a README is read to find front matter
+	maxREADMESize = 1 << 20 // 1MB
+
+	// maxDescriptionLength is how much of the README body is kept as the project description
+	maxDescriptionLength = 1000
+
+	// libraryLockWait is how long a single-project import waits for another
+	// instance's scan to finish
+	libraryLockWait = 10 * time.Minute
+
+	// projectLockWait is how long a scan waits for an upload, rename or other
+	// change to a project it is about to rescan or remove
+	projectLockWait = 30 * time.Second
+
+	// progressInterval is how often a running scan saves its progress and
+	// checks whether it was cancelled
+	progressInterval = time.Second
+)
+
 // Scanner handles filesystem scanning for 3D printing projects
 type Scanner struct {
 	db       *gorm.DB
 	scanPath string
+
+	// writeSidecars refreshes each project's .3dshelf.json after it is scanned
+	writeSidecars bool
+
+	// extractors read metadata from files through external commands; see pkg/extractor
+	extractors *extractor.Registry
+
+	// texts read the text of documents and images for search; see pkg/extractor
+	texts *extractor.TextRegistry
+
+	// thumbnails keeps the thumbnails extracted from G-code and 3MF files; nil
+	// leaves them to be extracted on first request
+	thumbnails *thumbnail.Cache
+
+	// flatMode turns loose files at the scan root into projects; see flat.go
+	flatMode FlatMode
+
+	// rules decide which directories are projects; see detection.go
+	rulesMu sync.RWMutex
+	rules   DetectionRules
+
+	// queue runs scans started in the background; see jobs.go
+	queue *jobs.Queue
+
+	// mu serializes recorded runs; run is the record for the active one,
+	// ctx interrupts it and checked is when it last saved its progress
+	mu      sync.Mutex
+	run     *models.ScanRun
+	ctx     context.Context
+	checked time.Time
 }
 
 // New creates a new Scanner instance
@@ -25,13 +85,146 @@ func New(db *gorm.DB, scanPath string) *Scanner {
 	return &Scanner{
 		db:       db,
 		scanPath: scanPath,
+		rules:    DefaultDetectionRules(),
 	}
 }
 
+// SetWriteSidecars enables or disables writing .3dshelf.json sidecars
+func (s *Scanner) SetWriteSidecars(enabled bool) {
+	s.writeSidecars = enabled
+}
+
+// SetExtractors runs the given external extractors on matching files as they are recorded
+func (s *Scanner) SetExtractors(extractors *extractor.Registry) {
+	s.extractors = extractors
+}
+
+// SetTextExtractors runs the given text extractors on matching files as they are recorded
+func (s *Scanner) SetTextExtractors(texts *extractor.TextRegistry) {
+	s.texts = texts
+}
+
+// SetThumbnails extracts the thumbnails slicers embed in G-code and 3MF files
+// into cache as the files are recorded
+func (s *Scanner) SetThumbnails(cache *thumbnail.Cache) {
+	s.thumbnails = cache
+}
+
 // ScanForProjects scans the filesystem for 3D printing projects
 func (s *Scanner) ScanForProjects() error {
 	// Walk through the scan path
-	return filepath.WalkDir(s.scanPath, s.walkFunction)
+	if err := filepath.WalkDir(s.scanPath, s.walkFunction); err != nil {
+		return err
+	}
+
+	// Pick up loose files at the root when flat-file mode is enabled
+	return s.scanFlatFiles()
+}
+
+// Run performs a full scan and persists its outcome as a ScanRun record.
+// The returned run is always non-nil once it has been created, even when
+// the scan itself fails, so callers can report what happened.
+func (s *Scanner) Run(trigger models.ScanTrigger) (*models.ScanRun, error) {
+	return s.record(trigger, []string{s.scanPath}, s.fullScan)
+}
+
+// fullScan scans the whole library and removes projects no longer on disk
+func (s *Scanner) fullScan() error {
+	if err := s.ScanForProjects(); err != nil {
+		return err
+	}
+	return s.removeMissingProjects()
+}
+
+// record runs scan as a recorded run over roots, excluding other scans
+func (s *Scanner) record(trigger models.ScanTrigger, roots []string, scan func() error) (*models.ScanRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Other instances sharing the library must not scan at the same time;
+	// database.ErrLocked is returned while one does
+	ctx, unlock, err := database.Lock(context.Background(), s.db, database.LibraryLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	run := &models.ScanRun{
+		Trigger:   trigger,
+		Status:    models.ScanRunRunning,
+		Roots:     roots,
+		StartedAt: time.Now(),
+		Errors:    []string{},
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	return s.execute(ctx, run, scan)
+}
+
+// execute runs scan as the active run and records its outcome. The caller
+// holds s.mu and the library lock.
+func (s *Scanner) execute(ctx context.Context, run *models.ScanRun, scan func() error) (*models.ScanRun, error) {
+	s.run, s.ctx, s.checked = run, ctx, time.Now()
+	defer func() { s.run, s.ctx = nil, nil }()
+
+	scanErr := scan()
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	switch {
+	case scanErr == nil:
+		run.Status = models.ScanRunCompleted
+	case errors.Is(scanErr, ErrScanCancelled):
+		run.Status = models.ScanRunCancelled
+		run.CancelRequested = true
+	case errors.Is(scanErr, database.ErrLockLost):
+		run.Status = models.ScanRunFailed
+		run.Errors = append(run.Errors, scanErr.Error())
+	case ctx.Err() != nil:
+		// A shutdown interrupted the run, which starts over when its job runs again
+		run.Status = models.ScanRunPending
+		run.FinishedAt = nil
+	default:
+		run.Status = models.ScanRunFailed
+		run.Errors = append(run.Errors, scanErr.Error())
+	}
+
+	if err := s.db.Save(run).Error; err != nil {
+		return run, err
+	}
+
+	return run, scanErr
+}
+
+// checkpoint saves the active run's progress every progressInterval. It stops
+// the scan with ErrScanCancelled once the run's cancellation was requested,
+// from any instance, and with the context's error once it is interrupted.
+func (s *Scanner) checkpoint() error {
+	if s.run == nil {
+		return nil
+	}
+	if s.ctx.Err() != nil {
+		return context.Cause(s.ctx)
+	}
+	if time.Since(s.checked) < progressInterval {
+		return nil
+	}
+	s.checked = time.Now()
+
+	if err := s.db.Model(s.run).Select("dirs_visited", "projects_found", "files_hashed",
+		"projects_added", "projects_updated", "projects_removed").Updates(s.run).Error; err != nil {
+		return err
+	}
+	var current models.ScanRun
+	if err := s.db.Select("cancel_requested").First(&current, s.run.ID).Error; err != nil {
+		return err
+	}
+	if current.CancelRequested {
+		return ErrScanCancelled
+	}
+	return nil
 }
 
 // walkFunction is called for each file/directory during the walk
@@ -50,57 +243,183 @@ func (s *Scanner) walkFunction(path string, d fs.DirEntry, err error) error {
 		return nil
 	}
 
+	if s.run != nil {
+		s.run.DirsVisited++
+	}
+	if err := s.checkpoint(); err != nil {
+		return err
+	}
+
 	// Check if this directory contains 3D printing files
 	if s.containsProjectFiles(path) {
-		return s.processProject(path)
+		if err := s.processProject(path); err != nil {
+			return err
+		}
+
+		// Projects that scan their own subdirectories own them
+		if s.scansSubdirectories(path) {
+			return filepath.SkipDir
+		}
 	}
 
 	return nil
 }
 
-// containsProjectFiles checks if a directory contains 3D printing related files
-func (s *Scanner) containsProjectFiles(dirPath string) bool {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
+// scansSubdirectories reports whether the project at path includes files from its subdirectories
+func (s *Scanner) scansSubdirectories(path string) bool {
+	var project models.Project
+	if err := s.db.Select("scan_settings").Where("path = ?", path).First(&project).Error; err != nil {
 		return false
 	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filename := entry.Name()
-		fileType := models.GetFileTypeFromExtension(filename)
-
-		// Consider it a project if it contains STL, 3MF, or G-code files
-		if fileType == models.FileTypeSTL || fileType == models.FileType3MF || fileType == models.FileTypeGCode {
-			return true
-		}
-	}
-
-	return false
+	return project.ScanSettings.MaxDepth > 0
 }
 
 // processProject processes a discovered project directory
 func (s *Scanner) processProject(projectPath string) error {
 	projectName := filepath.Base(projectPath)
+	if s.run != nil {
+		s.run.ProjectsFound++
+	}
 
 	// Check if project already exists
 	var existingProject models.Project
-	result := s.db.Where("path = ?", projectPath).First(&existingProject)
+	result := s.db.Unscoped().Where("path = ?", projectPath).First(&existingProject)
 
 	if result.Error == nil {
 		// Project exists, update it
-		return s.updateProject(&existingProject, projectPath)
+		unlock, err := s.lockProject(existingProject.ID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		restored, err := s.restoreProject(&existingProject)
+		if err != nil {
+			return err
+		}
+		if err := s.updateProject(&existingProject, projectPath); err != nil {
+			return err
+		}
+		if s.run != nil {
+			if restored {
+				s.run.ProjectsAdded++
+			} else {
+				s.run.ProjectsUpdated++
+			}
+		}
+		return nil
 	} else if result.Error == gorm.ErrRecordNotFound {
 		// New project, create it
-		return s.createProject(projectName, projectPath)
+		if err := s.createProject(projectName, projectPath); err != nil {
+			return err
+		}
+		if s.run != nil {
+			s.run.ProjectsAdded++
+		}
+		return nil
 	} else {
 		return result.Error
 	}
 }
 
+// ImportProject scans a single directory into a project, creating or updating it,
+// and returns the stored project
+func (s *Scanner) ImportProject(projectPath string) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Wait out library operations on other instances, as s.mu does for this one
+	_, unlock, err := database.LockWait(context.Background(), s.db, database.LibraryLock, libraryLockWait)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := s.processProject(projectPath); err != nil {
+		return nil, err
+	}
+
+	var project models.Project
+	if err := s.db.Where("path = ?", projectPath).First(&project).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// removeMissingProjects deletes projects under the scan path whose
+// directories no longer exist on disk
+func (s *Scanner) removeMissingProjects() error {
+	var projects []models.Project
+	prefix := strings.TrimSuffix(s.scanPath, string(filepath.Separator)) + string(filepath.Separator)
+	if err := s.db.Where("path LIKE ?", prefix+"%").Find(&projects).Error; err != nil {
+		return err
+	}
+
+	for i := range projects {
+		if projects[i].IsFlat() {
+			missing, err := s.flatProjectMissing(&projects[i])
+			if err != nil {
+				return err
+			}
+			if !missing {
+				continue
+			}
+		} else if _, err := os.Stat(projects[i].Path); !os.IsNotExist(err) {
+			continue
+		}
+
+		if err := s.removeProject(&projects[i]); err != nil {
+			return err
+		}
+
+		if s.run != nil {
+			s.run.ProjectsRemoved++
+		}
+	}
+
+	return nil
+}
+
+// removeProject deletes a project missing from disk with its files, once
+// changes other operations are making to it are done
+func (s *Scanner) removeProject(project *models.Project) error {
+	unlock, err := s.lockProject(project.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.db.Where("project_id = ?", project.ID).Delete(&models.ProjectFile{}).Error; err != nil {
+		return err
+	}
+	// A soft delete lists the project in sync deltas; its row keeps the path
+	// and is restored if the directory comes back
+	return s.db.Delete(project).Error
+}
+
+// restoreProject undeletes a project whose directory was removed and has come
+// back, reporting whether it was deleted
+func (s *Scanner) restoreProject(project *models.Project) (bool, error) {
+	if !project.DeletedAt.Valid {
+		return false, nil
+	}
+	if err := s.db.Unscoped().Model(project).Update("deleted_at", nil).Error; err != nil {
+		return false, err
+	}
+	project.DeletedAt = gorm.DeletedAt{}
+	return true, nil
+}
+
+// lockProject waits for other operations changing a project, such as uploads
+// and renames, to finish and keeps them out until the returned function is called
+func (s *Scanner) lockProject(projectID uint) (func(), error) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, unlock, err := database.LockWait(ctx, s.db, database.ProjectLock(projectID), projectLockWait)
+	return unlock, err
+}
+
 // createProject creates a new project in the database
 func (s *Scanner) createProject(name, path string) error {
 	project := models.Project{
@@ -110,22 +429,27 @@ func (s *Scanner) createProject(name, path string) error {
 		LastScanned: time.Now(),
 	}
 
-	// Read README if it exists
-	readmePath := filepath.Join(path, "README.md")
-	if _, err := os.Stat(readmePath); err == nil {
-		description, err := s.readREADME(readmePath)
-		if err == nil {
-			project.Description = description
-		}
+	// Adopt metadata from a sidecar left by a previous library
+	if meta, err := sidecar.Read(path); err != nil {
+		fmt.Printf("Warning: Ignoring sidecar in %s: %v\n", path, err)
+	} else if meta != nil {
+		meta.ApplyTo(&project)
 	}
 
+	// Read README and its front matter if it exists
+	s.ApplyREADME(&project)
+
 	// Create the project
 	if err := s.db.Create(&project).Error; err != nil {
 		return err
 	}
 
 	// Scan and add files
-	return s.scanProjectFiles(&project, path)
+	if err := s.scanProjectFiles(&project, path); err != nil {
+		return err
+	}
+
+	return s.RefreshSidecar(&project)
 }
 
 // updateProject updates an existing project
@@ -133,14 +457,8 @@ func (s *Scanner) updateProject(project *models.Project, path string) error {
 	// Update last scanned time
 	project.LastScanned = time.Now()
 
-	// Update README if it exists
-	readmePath := filepath.Join(path, "README.md")
-	if _, err := os.Stat(readmePath); err == nil {
-		description, err := s.readREADME(readmePath)
-		if err == nil {
-			project.Description = description
-		}
-	}
+	// Update README and its front matter if it exists
+	s.ApplyREADME(project)
 
 	// Save project updates
 	if err := s.db.Save(project).Error; err != nil {
@@ -153,84 +471,207 @@ func (s *Scanner) updateProject(project *models.Project, path string) error {
 	}
 
 	// Rescan files
-	return s.scanProjectFiles(project, path)
+	if err := s.scanProjectFiles(project, path); err != nil {
+		return err
+	}
+
+	return s.RefreshSidecar(project)
 }
 
-// scanProjectFiles scans and adds files for a project
-func (s *Scanner) scanProjectFiles(project *models.Project, projectPath string) error {
-	entries, err := os.ReadDir(projectPath)
+// RefreshSidecar writes the project's .3dshelf.json when sidecars are enabled
+func (s *Scanner) RefreshSidecar(project *models.Project) error {
+	if !s.writeSidecars || project.IsFlat() {
+		return nil
+	}
+	meta, err := sidecar.Build(s.db, project)
 	if err != nil {
 		return err
 	}
+	return sidecar.Write(project.Path, meta)
+}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+// scanProjectFiles scans and adds files for a project, honouring its scan settings
+func (s *Scanner) scanProjectFiles(project *models.Project, projectPath string) error {
+	settings := project.ScanSettings
+
+	var paths []string
+	err := filepath.WalkDir(projectPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == projectPath {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(projectPath, path)
+		if err != nil {
+			return err
 		}
 
-		filename := entry.Name()
-		filePath := filepath.Join(projectPath, filename)
+		if d.IsDir() {
+			// Descend only as deep as the project allows, never into hidden directories
+			depth := strings.Count(relPath, string(filepath.Separator)) + 1
+			if strings.HasPrefix(d.Name(), ".") || depth > settings.MaxDepth || settings.Excludes(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
-		// Get file info
-		fileInfo, err := entry.Info()
+		// The sidecar holds metadata, not project content
+		if strings.HasPrefix(relPath, sidecar.Filename) || settings.Excludes(relPath) {
+			return nil
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.recordFiles(project, projectPath, paths)
+}
+
+// recordFiles hashes the given files and records them for a project, naming
+// each by its path relative to baseDir
+func (s *Scanner) recordFiles(project *models.Project, baseDir string, paths []string) error {
+	for _, filePath := range paths {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+
+		filename, err := filepath.Rel(baseDir, filePath)
 		if err != nil {
 			continue
 		}
 
-		// Calculate file hash for integrity checking
-		hash, err := s.calculateFileHash(filePath)
+		// Get file info
+		fileInfo, err := os.Stat(filePath)
 		if err != nil {
 			continue
 		}
 
+		// Calculate file hash for integrity checking, unless the project opted out
+		var hash string
+		if project.ScanSettings.HashPolicy != models.HashNone {
+			if hash, err = s.calculateFileHash(filePath); err != nil {
+				continue
+			}
+			if s.run != nil {
+				s.run.FilesHashed++
+			}
+		}
+
 		// Create project file record
 		projectFile := models.ProjectFile{
 			ProjectID: project.ID,
 			Filename:  filename,
 			Filepath:  filePath,
-			FileType:  models.GetFileTypeFromExtension(filename),
+			FileType:  models.GetFileTypeFromExtension(filepath.Base(filename)),
 			Size:      fileInfo.Size(),
 			Hash:      hash,
 		}
 
+		// Extractor failures leave the file without metadata rather than failing the scan
+		if projectFile.Extracted, err = s.extractors.Extract(context.Background(), filePath, filename); err != nil {
+			fmt.Printf("Warning: Failed to extract metadata from %s: %v\n", filePath, err)
+		}
+		if projectFile.Text, err = s.texts.Extract(context.Background(), filePath, filename); err != nil {
+			fmt.Printf("Warning: Failed to extract text from %s: %v\n", filePath, err)
+		}
+		if projectFile.FileType == models.FileTypeGCode {
+			if meta, err := gcode.ReadMetadata(filePath); err != nil {
+				fmt.Printf("Warning: Failed to read G-code metadata from %s: %v\n", filePath, err)
+			} else if meta != (gcode.Metadata{}) {
+				projectFile.GCodeMetadata = &meta
+			}
+		}
+
 		if err := s.db.Create(&projectFile).Error; err != nil {
 			return err
 		}
+		s.extractThumbnail(projectFile)
 	}
 
 	return nil
 }
 
-// readREADME reads the content of a README file (first 1000 characters)
-func (s *Scanner) readREADME(readmePath string) (string, error) {
-	file, err := os.Open(readmePath)
+// extractThumbnail caches the thumbnail a G-code or 3MF file embeds, at the
+// size listings link to, so they don't wait on reading the file. Files
+// without a content hash can't be cached and are read on request instead.
+func (s *Scanner) extractThumbnail(file models.ProjectFile) {
+	if s.thumbnails == nil || !gcode.EmbedsThumbnails(file.Filename) || !thumbnail.ValidHash(file.Hash) {
+		return
+	}
+	if _, err := s.thumbnails.Get(file.Filepath, file.Hash, thumbnail.DefaultSize); err != nil && !errors.Is(err, gcode.ErrNoThumbnail) {
+		fmt.Printf("Warning: Failed to extract thumbnail from %s: %v\n", file.Filepath, err)
+	}
+}
+
+// ApplyREADME sets the project's description and front matter metadata from
+// its README.md. Metadata fields are left untouched when the README has no front matter.
+func (s *Scanner) ApplyREADME(project *models.Project) error {
+	readmePath := filepath.Join(project.Path, "README.md")
+	if _, err := os.Stat(readmePath); err != nil {
+		return err
+	}
+
+	description, meta, err := s.readProjectREADME(readmePath)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer file.Close()
 
-	// Read up to 1000 characters for description
-	buffer := make([]byte, 1000)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
-		return "", err
+	project.Description = description
+	if meta != nil {
+		project.Tags = meta.Tags
+		project.License = meta.License
+		project.Designer = meta.Designer
+		project.Source = meta.Source
 	}
+	return nil
+}
 
-	return string(buffer[:n]), nil
+// readREADME reads the description of a README file (first 1000 characters after any front matter)
+func (s *Scanner) readREADME(readmePath string) (string, error) {
+	description, _, err := s.readProjectREADME(readmePath)
+	return description, err
 }
 
-// calculateFileHash calculates SHA-256 hash of a file for integrity checking
-func (s *Scanner) calculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// readProjectREADME reads a README's description and, if present, its front matter metadata
+func (s *Scanner) readProjectREADME(readmePath string) (string, *frontmatter.Metadata, error) {
+	file, err := os.Open(readmePath)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer file.Close()
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	content, err := io.ReadAll(io.LimitReader(file, maxREADMESize))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var meta *frontmatter.Metadata
+	body := content
+	if _, _, found := frontmatter.Split(content); found {
+		parsed, parsedBody, err := frontmatter.Parse(content)
+		if err != nil {
+			fmt.Printf("Warning: Ignoring front matter in %s: %v\n", readmePath, err)
+		} else {
+			meta = &parsed
+			body = parsedBody
+		}
+	}
+
+	// Keep up to 1000 characters for description
+	if len(body) > maxDescriptionLength {
+		body = body[:maxDescriptionLength]
 	}
 
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	return string(body), meta, nil
+}
+
+// calculateFileHash calculates SHA-256 hash of a file for integrity checking
+func (s *Scanner) calculateFileHash(filePath string) (string, error) {
+	_, hash, err := fsutil.HashFile(filePath)
+	return hash, err
 }
@@ -1,8 +1,13 @@
 package scanner
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +15,9 @@ import (
 	"time"
 
 	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/extractor"
+	"3dshelf/pkg/thumbnail"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -23,7 +31,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Run migrations
-	err = db.AutoMigrate(&models.Project{}, &models.ProjectFile{})
+	err = db.AutoMigrate(&models.Project{}, &models.ProjectFile{}, &models.ScanRun{}, &models.Lock{}, &models.PrintJob{}, &models.FileProfile{})
 	if err != nil {
 		t.Fatalf("Failed to run migrations: %v", err)
 	}
@@ -141,6 +149,97 @@ func TestContainsProjectFiles(t *testing.T) {
 	}
 }
 
+// TestContainsProjectFilesRules tests project detection with custom rules
+func TestContainsProjectFilesRules(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	testCases := []struct {
+		name     string
+		rules    DetectionRules
+		files    map[string]string
+		expected bool
+	}{
+		{
+			name:     "CAD files qualify when configured",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeCAD}, MinFiles: 1},
+			files:    map[string]string{"bracket.step": "STEP content"},
+			expected: true,
+		},
+		{
+			name:     "STL no longer qualifies when not configured",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeCAD}, MinFiles: 1},
+			files:    map[string]string{"model.stl": "STL content"},
+			expected: false,
+		},
+		{
+			name:     "Below minimum file count",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 2},
+			files:    map[string]string{"model.stl": "STL content"},
+			expected: false,
+		},
+		{
+			name:     "At minimum file count",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 2},
+			files:    map[string]string{"a.stl": "STL content", "b.stl": "STL content"},
+			expected: true,
+		},
+		{
+			name:     "README only with sidecar",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 1, READMEOnlyWithSidecar: true},
+			files:    map[string]string{"README.md": "# Notes", ".3dshelf.json": "{}"},
+			expected: true,
+		},
+		{
+			name:     "README only without sidecar",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 1, READMEOnlyWithSidecar: true},
+			files:    map[string]string{"README.md": "# Notes"},
+			expected: false,
+		},
+		{
+			name:     "Images only with sidecar",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 1, ImageOnlyWithSidecar: true},
+			files:    map[string]string{"print.jpg": "JPEG", "detail.PNG": "PNG", ".3dshelf.json": "{}"},
+			expected: true,
+		},
+		{
+			name:     "Images mixed with other files",
+			rules:    DetectionRules{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 1, ImageOnlyWithSidecar: true},
+			files:    map[string]string{"print.jpg": "JPEG", "notes.txt": "Notes", ".3dshelf.json": "{}"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scanner.SetDetectionRules(tc.rules)
+			projectPath := createTestProject(t, tmpDir, tc.name, tc.files)
+			if result := scanner.containsProjectFiles(projectPath); result != tc.expected {
+				t.Errorf("Expected %v for %s, got %v", tc.expected, tc.name, result)
+			}
+		})
+	}
+}
+
+// TestDetectionRulesValidate tests validation of detection rules
+func TestDetectionRulesValidate(t *testing.T) {
+	if err := DefaultDetectionRules().Validate(); err != nil {
+		t.Errorf("Default rules should be valid: %v", err)
+	}
+
+	invalid := []DetectionRules{
+		{MinFiles: 1},
+		{FileTypes: []models.FileType{"obj"}, MinFiles: 1},
+		{FileTypes: []models.FileType{models.FileTypeSTL}, MinFiles: 0},
+	}
+	for _, rules := range invalid {
+		if err := rules.Validate(); err == nil {
+			t.Errorf("Expected rules %+v to be invalid", rules)
+		}
+	}
+}
+
 // TestContainsProjectFilesError tests containsProjectFiles with invalid directory
 func TestContainsProjectFilesError(t *testing.T) {
 	db := setupTestDB(t)
@@ -335,7 +434,7 @@ func TestScanProjectFiles(t *testing.T) {
 		"sliced.gco": models.FileTypeGCode,
 		"design.dwg": models.FileTypeCAD,
 		"README.md":  models.FileTypeREADME,
-		"photo.jpg":  models.FileTypeOther,
+		"photo.jpg":  models.FileTypeImage,
 	}
 
 	for _, file := range projectFiles {
@@ -359,6 +458,111 @@ func TestScanProjectFiles(t *testing.T) {
 	}
 }
 
+// TestScanProjectFilesSettings tests per-project exclude patterns, depth and hash policy
+func TestScanProjectFilesSettings(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	projectPath := createTestProject(t, tmpDir, "Archive", map[string]string{
+		"model.stl":     "STL content",
+		"backup.zip":    "ZIP content",
+		"notes.txt":     "Notes",
+		".3dshelf.json": "{}",
+	})
+	for _, dir := range []string{"parts/small", "old", ".cache"} {
+		if err := os.MkdirAll(filepath.Join(projectPath, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for name, content := range map[string]string{
+		"parts/gear.stl":       "Gear",
+		"parts/small/bolt.stl": "Bolt",
+		"old/v1.stl":           "Old",
+		".cache/thumb.png":     "Cache",
+	} {
+		if err := os.WriteFile(filepath.Join(projectPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	filenames := func(settings models.ProjectScanSettings) map[string]string {
+		project := models.Project{Name: "Archive", Path: projectPath + "-" + fmt.Sprint(time.Now().UnixNano()), ScanSettings: settings}
+		db.Create(&project)
+		if err := scanner.scanProjectFiles(&project, projectPath); err != nil {
+			t.Fatalf("scanProjectFiles failed: %v", err)
+		}
+
+		var files []models.ProjectFile
+		db.Where("project_id = ?", project.ID).Find(&files)
+		result := make(map[string]string)
+		for _, file := range files {
+			result[file.Filename] = file.Hash
+		}
+		return result
+	}
+
+	t.Run("Defaults scan top-level files only", func(t *testing.T) {
+		files := filenames(models.ProjectScanSettings{})
+		if len(files) != 3 {
+			t.Errorf("Expected 3 top-level files, got %v", files)
+		}
+		if files["model.stl"] == "" {
+			t.Error("Expected files to be hashed by default")
+		}
+	})
+
+	t.Run("Depth, excludes and no hashing", func(t *testing.T) {
+		files := filenames(models.ProjectScanSettings{
+			Exclude:    []string{"*.zip", "old"},
+			MaxDepth:   1,
+			HashPolicy: models.HashNone,
+		})
+
+		expected := []string{"model.stl", "notes.txt", filepath.Join("parts", "gear.stl")}
+		if len(files) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, files)
+		}
+		for _, name := range expected {
+			hash, exists := files[name]
+			if !exists {
+				t.Errorf("Expected %s to be scanned", name)
+			}
+			if hash != "" {
+				t.Errorf("Expected %s not to be hashed, got %s", name, hash)
+			}
+		}
+	})
+}
+
+// TestWalkFunctionOwnsSubdirectories tests that deep-scanning projects aren't split into sub-projects
+func TestWalkFunctionOwnsSubdirectories(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	projectPath := createTestProject(t, tmpDir, "Kit", map[string]string{"base.stl": "Base"})
+	createTestProject(t, projectPath, "addons", map[string]string{"addon.stl": "Addon"})
+
+	db.Create(&models.Project{Name: "Kit", Path: projectPath, ScanSettings: models.ProjectScanSettings{MaxDepth: 1}})
+
+	if err := scanner.ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.Project{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the addons directory to stay part of Kit, got %d projects", count)
+	}
+
+	var files []models.ProjectFile
+	db.Where("project_id = ?", 1).Find(&files)
+	if len(files) != 2 {
+		t.Errorf("Expected Kit to include its addon file, got %d files", len(files))
+	}
+}
+
 // TestReadREADME tests the readREADME method
 func TestReadREADME(t *testing.T) {
 	db := setupTestDB(t)
@@ -385,6 +589,11 @@ func TestReadREADME(t *testing.T) {
 			content:  "",
 			expected: "",
 		},
+		{
+			name:     "Front matter README",
+			content:  "---\ntags: [benchy]\n---\n\n# Benchy\nBoat.",
+			expected: "# Benchy\nBoat.",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -407,6 +616,100 @@ func TestReadREADME(t *testing.T) {
 	}
 }
 
+// TestCreateProjectFrontMatter tests that README front matter is parsed into project metadata
+func TestCreateProjectFrontMatter(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	readme := "---\ntags: [gears, mechanical]\nlicense: CC-BY-4.0\ndesigner: Jane Maker\nsource: https://example.com/gears\n---\n\n# Gears\n"
+	projectPath := createTestProject(t, tmpDir, "Gears", map[string]string{
+		"gear.stl":  "STL content",
+		"README.md": readme,
+	})
+
+	if err := scanner.createProject("Gears", projectPath); err != nil {
+		t.Fatalf("createProject failed: %v", err)
+	}
+
+	var project models.Project
+	if err := db.Where("path = ?", projectPath).First(&project).Error; err != nil {
+		t.Fatalf("Failed to find created project: %v", err)
+	}
+
+	if strings.Contains(project.Description, "license:") {
+		t.Errorf("Expected front matter to be stripped from description, got '%s'", project.Description)
+	}
+	if len(project.Tags) != 2 || project.Tags[0] != "gears" || project.Tags[1] != "mechanical" {
+		t.Errorf("Expected tags [gears mechanical], got %v", project.Tags)
+	}
+	if project.License != "CC-BY-4.0" || project.Designer != "Jane Maker" || project.Source != "https://example.com/gears" {
+		t.Errorf("Unexpected metadata: license=%q designer=%q source=%q", project.License, project.Designer, project.Source)
+	}
+
+	// Metadata survives losing the database: a fresh scan reads it back from disk
+	db.Unscoped().Where("1 = 1").Delete(&models.ProjectFile{})
+	db.Unscoped().Where("1 = 1").Delete(&models.Project{})
+	if err := scanner.ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var rescanned models.Project
+	if err := db.Where("path = ?", projectPath).First(&rescanned).Error; err != nil {
+		t.Fatalf("Failed to find rescanned project: %v", err)
+	}
+	if rescanned.License != "CC-BY-4.0" || len(rescanned.Tags) != 2 {
+		t.Errorf("Expected metadata to be restored from README, got license=%q tags=%v", rescanned.License, rescanned.Tags)
+	}
+}
+
+// TestSidecars tests that sidecars are adopted on discovery and refreshed when enabled
+func TestSidecars(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	projectPath := createTestProject(t, tmpDir, "Gear_Box", map[string]string{
+		"gear.stl":      "STL content",
+		".3dshelf.json": `{"version": 1, "name": "Gear Box", "tags": ["gears"], "license": "MIT"}`,
+	})
+
+	if err := scanner.createProject("Gear_Box", projectPath); err != nil {
+		t.Fatalf("createProject failed: %v", err)
+	}
+
+	var project models.Project
+	if err := db.Where("path = ?", projectPath).Preload("Files").First(&project).Error; err != nil {
+		t.Fatalf("Failed to find created project: %v", err)
+	}
+	if project.Name != "Gear Box" || project.License != "MIT" || len(project.Tags) != 1 {
+		t.Errorf("Expected metadata adopted from sidecar, got name=%q license=%q tags=%v", project.Name, project.License, project.Tags)
+	}
+	if len(project.Files) != 1 {
+		t.Errorf("Expected the sidecar not to be listed as a project file, got %d files", len(project.Files))
+	}
+
+	// Sidecars are only written once enabled
+	project.License = "CC0"
+	if err := scanner.RefreshSidecar(&project); err != nil {
+		t.Fatalf("RefreshSidecar failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(projectPath, ".3dshelf.json"))
+	if strings.Contains(string(content), "CC0") {
+		t.Error("Expected sidecar to be left alone while writing is disabled")
+	}
+
+	scanner.SetWriteSidecars(true)
+	db.Model(&project).Update("license", "CC0")
+	if err := scanner.updateProject(&project, projectPath); err != nil {
+		t.Fatalf("updateProject failed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(projectPath, ".3dshelf.json"))
+	if !strings.Contains(string(content), `"license": "CC0"`) {
+		t.Errorf("Expected refreshed sidecar, got %s", content)
+	}
+}
+
 // TestReadREADMEError tests readREADME with nonexistent file
 func TestReadREADMEError(t *testing.T) {
 	db := setupTestDB(t)
@@ -581,6 +884,157 @@ func TestScanForProjectsError(t *testing.T) {
 	}
 }
 
+// TestRun tests that a recorded run persists added, updated and removed counts
+func TestRun(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	createTestProject(t, tmpDir, "Keep", map[string]string{"model.stl": "STL content"})
+	gonePath := createTestProject(t, tmpDir, "Gone", map[string]string{"part.3mf": "3MF content"})
+
+	run, err := scanner.Run(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+
+	if run.Status != models.ScanRunCompleted {
+		t.Errorf("Expected status %s, got %s", models.ScanRunCompleted, run.Status)
+	}
+	if run.ProjectsAdded != 2 || run.ProjectsUpdated != 0 || run.ProjectsRemoved != 0 {
+		t.Errorf("Unexpected first run counts: %+v", run)
+	}
+	if run.FinishedAt == nil {
+		t.Error("FinishedAt should be set after the run")
+	}
+
+	if err := os.RemoveAll(gonePath); err != nil {
+		t.Fatalf("Failed to remove project directory: %v", err)
+	}
+
+	run, err = scanner.Run(models.ScanTriggerManual)
+	if err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+
+	if run.ProjectsAdded != 0 || run.ProjectsUpdated != 1 || run.ProjectsRemoved != 1 {
+		t.Errorf("Unexpected second run counts: %+v", run)
+	}
+
+	var count int64
+	db.Model(&models.ScanRun{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 persisted scan runs, got %d", count)
+	}
+
+	var remaining []models.Project
+	db.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].Name != "Keep" {
+		t.Errorf("Expected only 'Keep' to remain, got %+v", remaining)
+	}
+}
+
+// TestRunReturningProject tests that a project directory moved out of the library
+// and back is added again, keeping its ID
+func TestRunReturningProject(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	path := createTestProject(t, tmpDir, "Bracket", map[string]string{"bracket.stl": "STL content"})
+	outside := filepath.Join(t.TempDir(), "Bracket")
+
+	var run *models.ScanRun
+	for i, move := range []func() error{
+		func() error { return nil },
+		func() error { return os.Rename(path, outside) },
+		func() error { return os.Rename(outside, path) },
+	} {
+		if err := move(); err != nil {
+			t.Fatalf("Failed to move the project directory: %v", err)
+		}
+		var err error
+		run, err = scanner.Run(models.ScanTriggerManual)
+		if err != nil || run.Status != models.ScanRunCompleted {
+			t.Fatalf("Run %d failed: %+v %v", i+1, run, err)
+		}
+	}
+	if run.ProjectsAdded != 1 {
+		t.Errorf("Expected the returning project to be counted as added, got %d", run.ProjectsAdded)
+	}
+
+	var projects []models.Project
+	db.Preload("Files").Find(&projects)
+	if len(projects) != 1 || projects[0].ID != 1 || projects[0].Path != path || len(projects[0].Files) != 1 {
+		t.Errorf("Expected the project back with its ID and file, got %+v", projects)
+	}
+}
+
+// TestRunWaitsForProjectLock tests that a scan waits for changes another
+// operation is making to a project before rescanning it
+func TestRunWaitsForProjectLock(t *testing.T) {
+	// Locks are released from another goroutine, so the database must be shared
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scan.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	createTestProject(t, tmpDir, "Bracket", map[string]string{"bracket.stl": "STL content"})
+	if _, err := scanner.Run(models.ScanTriggerManual); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+	var project models.Project
+	db.First(&project)
+
+	// An upload holds the project for a moment
+	_, unlock, err := database.Lock(context.Background(), db, database.ProjectLock(project.ID))
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	time.AfterFunc(300*time.Millisecond, unlock)
+
+	started := time.Now()
+	run, err := scanner.Run(models.ScanTriggerManual)
+	if err != nil || run.ProjectsUpdated != 1 {
+		t.Fatalf("Expected the project rescanned, got %+v %v", run, err)
+	}
+	if waited := time.Since(started); waited < 250*time.Millisecond {
+		t.Errorf("Expected the scan to wait for the project lock, took %v", waited)
+	}
+}
+
+// TestRunError tests that a failed scan is still recorded
+func TestRunError(t *testing.T) {
+	db := setupTestDB(t)
+	scanner := New(db, "/nonexistent/path")
+
+	run, err := scanner.Run(models.ScanTriggerManual)
+	if err == nil {
+		t.Error("Expected error when scanning nonexistent path")
+	}
+
+	if run == nil {
+		t.Fatal("Failed run should still be returned")
+	}
+
+	if run.Status != models.ScanRunFailed || len(run.Errors) != 1 {
+		t.Errorf("Expected failed run with one error, got %+v", run)
+	}
+
+	var stored models.ScanRun
+	if err := db.First(&stored, run.ID).Error; err != nil {
+		t.Fatalf("Failed run should be persisted: %v", err)
+	}
+	if stored.Status != models.ScanRunFailed {
+		t.Errorf("Expected persisted status %s, got %s", models.ScanRunFailed, stored.Status)
+	}
+}
+
 // TestWalkFunction tests the walkFunction method directly
 func TestWalkFunction(t *testing.T) {
 	db := setupTestDB(t)
@@ -686,3 +1140,121 @@ func BenchmarkCalculateFileHash(b *testing.B) {
 		}
 	}
 }
+
+// TestScanExtractors tests external extractor metadata is recorded on scanned files
+func TestScanExtractors(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	createTestProject(t, tmpDir, "Bracket", map[string]string{
+		"bracket.stl":  "STL content",
+		"bracket.step": "STEP content",
+	})
+
+	script := filepath.Join(t.TempDir(), "step-meta.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '{\"metadata\": {\"bodies\": 2}}'\n"), 0755); err != nil {
+		t.Fatalf("Failed to write extractor script: %v", err)
+	}
+	extractors := extractor.New(5 * time.Second)
+	extractors.Register(".step", script)
+
+	scanner := New(db, tmpDir)
+	scanner.SetExtractors(extractors)
+	if err := scanner.ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var files []models.ProjectFile
+	db.Order("filename ASC").Find(&files)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+	if files[0].Extracted["bodies"] != float64(2) {
+		t.Errorf("Expected extracted STEP metadata, got %v", files[0].Extracted)
+	}
+	if files[1].Extracted != nil {
+		t.Errorf("Expected no metadata for the STL file, got %v", files[1].Extracted)
+	}
+}
+
+// TestScanTextExtractors tests the text of model cards is recorded on scanned files
+func TestScanTextExtractors(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	createTestProject(t, tmpDir, "Bracket", map[string]string{
+		"bracket.stl": "STL content",
+		"card.pdf":    "PDF content",
+	})
+
+	script := filepath.Join(t.TempDir(), "pdftotext.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"Quality: 0.16mm\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write text extractor script: %v", err)
+	}
+	texts := extractor.NewText(5 * time.Second)
+	texts.Register(".pdf", script, "{input}", "-")
+
+	scanner := New(db, tmpDir)
+	scanner.SetTextExtractors(texts)
+	if err := scanner.ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var files []models.ProjectFile
+	db.Order("filename ASC").Find(&files)
+	if len(files) != 2 || files[0].Text != "" || files[1].Text != "Quality: 0.16mm" {
+		t.Errorf("Expected text for the PDF only, got %+v", files)
+	}
+}
+
+// TestScanGCodeMetadata tests that G-code slicer metadata is recorded when scanned
+func TestScanGCodeMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	createTestProject(t, tmpDir, "Benchy", map[string]string{
+		"benchy.stl":   "STL content",
+		"benchy.gcode": "; generated by PrusaSlicer 2.7.1 on 2024-01-15 at 10:00:00 UTC\nM140 S60\nM104 S215\n; estimated printing time (normal mode) = 1h 30m 0s\n",
+		"plain.gcode":  "G28\n",
+	})
+
+	if err := New(db, tmpDir).ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var files []models.ProjectFile
+	db.Order("filename ASC").Find(&files)
+	if len(files) != 3 || files[1].GCodeMetadata != nil || files[2].GCodeMetadata != nil {
+		t.Fatalf("Expected metadata for sliced G-code only, got %+v", files)
+	}
+	meta := files[0].GCodeMetadata
+	if meta == nil || meta.Slicer != "PrusaSlicer 2.7.1" || meta.PrintTimeSeconds != 5400 || meta.NozzleTemp != 215 || meta.BedTemp != 60 {
+		t.Errorf("Expected the slicer metadata recorded, got %+v", meta)
+	}
+}
+
+func TestScanThumbnails(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 300, 150)))
+	embedded := "; thumbnail begin 300x150 0\n; " + base64.StdEncoding.EncodeToString(encoded.Bytes()) + "\n; thumbnail end\nG28\n"
+	createTestProject(t, tmpDir, "Benchy", map[string]string{
+		"benchy.gcode": embedded,
+		"plain.gcode":  "G28\n",
+	})
+
+	cacheDir := filepath.Join(t.TempDir(), "thumbnails")
+	scanner := New(db, tmpDir)
+	scanner.SetThumbnails(thumbnail.New(cacheDir))
+	if err := scanner.ScanForProjects(); err != nil {
+		t.Fatalf("ScanForProjects failed: %v", err)
+	}
+
+	var file models.ProjectFile
+	db.Where("filename = ?", "benchy.gcode").First(&file)
+	cached := filepath.Join(cacheDir, file.Hash[:2], fmt.Sprintf("%s-%d", file.Hash, thumbnail.DefaultSize))
+	if _, err := os.Stat(cached); err != nil {
+		t.Errorf("Expected the embedded thumbnail extracted while scanning: %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(cacheDir, "*", "*")); len(entries) != 1 {
+		t.Errorf("Expected only the embedding file's thumbnail cached, got %v", entries)
+	}
+}
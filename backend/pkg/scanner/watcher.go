@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gorm.io/gorm"
+)
+
+// watchPollInterval is how often the watcher checks whether it is still enabled
+const watchPollInterval = 5 * time.Second
+
+// RunDirs rescans the given directories instead of the whole scan path and
+// records the run like Run. Each directory in dirs is rescanned on its own,
+// or through the project whose files include its; those in trees are walked
+// with everything below them, as for directories moved into the library.
+// Projects whose directories are gone are removed either way.
+func (s *Scanner) RunDirs(trigger models.ScanTrigger, dirs, trees []string) (*models.ScanRun, error) {
+	roots := append(append([]string{}, trees...), dirs...)
+	sort.Strings(roots)
+	return s.record(trigger, roots, func() error {
+		if err := s.scanDirs(dirs, trees); err != nil {
+			return err
+		}
+		return s.removeMissingProjects()
+	})
+}
+
+// scanDirs rescans the projects in or owning each directory, once each
+func (s *Scanner) scanDirs(dirs, trees []string) error {
+	scanned := make(map[string]bool)
+	rescan := func(path string) error {
+		if scanned[path] || !isDir(path) {
+			return nil
+		}
+		scanned[path] = true
+		return s.processProject(path)
+	}
+
+	for _, dir := range trees {
+		owner, err := s.owningProject(dir)
+		if err != nil {
+			return err
+		}
+		if owner != "" {
+			if err := rescan(owner); err != nil {
+				return err
+			}
+			continue
+		}
+		// Directories can be moved out again before they are walked
+		if err := filepath.WalkDir(dir, s.walkFunction); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	for _, dir := range dirs {
+		if dir == s.scanPath {
+			if err := s.scanFlatFiles(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		owner, err := s.owningProject(dir)
+		if err != nil {
+			return err
+		}
+		if owner == "" && s.containsProjectFiles(dir) {
+			owner = dir
+		}
+		if owner != "" {
+			if err := rescan(owner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// owningProject returns the path of the project whose files include those in
+// dir: the topmost project above it that scans its subdirectories, or the one
+// at dir itself. It returns "" when no project does.
+func (s *Scanner) owningProject(dir string) (string, error) {
+	rel, err := filepath.Rel(s.scanPath, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+
+	candidate := s.scanPath
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		candidate = filepath.Join(candidate, part)
+
+		var project models.Project
+		err := s.db.Select("path", "scan_settings").Where("path = ?", candidate).First(&project).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if i == len(parts)-1 || project.ScanSettings.MaxDepth > 0 {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// Watcher keeps the library in sync with the scan path as it changes on disk,
+// so manual scans aren't needed. It collects changes until the scan path has
+// been quiet for the debounce, then rescans only the directories that
+// changed. Hidden files and directories, such as sidecars and staging
+// directories, are ignored.
+type Watcher struct {
+	scanner  *Scanner
+	debounce time.Duration
+
+	// enabled is checked every poll; the watcher stops watching while it is false
+	enabled func() bool
+	poll    time.Duration
+}
+
+// NewWatcher creates a Watcher syncing changes through scanner once they have
+// settled for debounce, while enabled reports true
+func NewWatcher(scanner *Scanner, debounce time.Duration, enabled func() bool) *Watcher {
+	return &Watcher{
+		scanner:  scanner,
+		debounce: debounce,
+		enabled:  enabled,
+		poll:     watchPollInterval,
+	}
+}
+
+// Run watches the scan path whenever the watcher is enabled, until ctx is done.
+// Each time watching starts, a full scan picks up what changed in between.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	for {
+		if w.enabled() {
+			if err := w.watch(ctx); err != nil {
+				fmt.Printf("Warning: Failed to watch %s: %v\n", w.scanner.scanPath, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watch follows the scan path until ctx is done or the watcher is disabled
+func (w *Watcher) watch(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	if err := w.addTree(fsw, w.scanner.scanPath); err != nil {
+		return err
+	}
+
+	poll := time.NewTicker(w.poll)
+	defer poll.Stop()
+	settled := time.NewTimer(w.debounce)
+	settled.Stop()
+
+	dirs := make(map[string]bool)
+	trees := make(map[string]bool)
+	full := !w.sync(true, nil, nil)
+	if full {
+		settled.Reset(w.debounce)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-poll.C:
+			if !w.enabled() {
+				return nil
+			}
+
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if w.ignored(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) && isDir(event.Name) {
+				if err := w.addTree(fsw, event.Name); err != nil {
+					fmt.Printf("Warning: Failed to watch %s: %v\n", event.Name, err)
+				}
+				trees[event.Name] = true
+			}
+			// A directory moved away keeps its watches under the old name
+			if event.Has(fsnotify.Rename) {
+				prefix := event.Name + string(filepath.Separator)
+				for _, watched := range fsw.WatchList() {
+					if watched == event.Name || strings.HasPrefix(watched, prefix) {
+						fsw.Remove(watched)
+					}
+				}
+			}
+			dirs[filepath.Dir(event.Name)] = true
+			settled.Reset(w.debounce)
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			// Changes were dropped, so only a full scan catches up
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				full = true
+				settled.Reset(w.debounce)
+				continue
+			}
+			fmt.Printf("Warning: Watching %s: %v\n", w.scanner.scanPath, err)
+
+		case <-settled.C:
+			if !w.sync(full, keys(dirs), keys(trees)) {
+				// Another scan holds the library; try again once it had time to finish
+				settled.Reset(w.debounce)
+				continue
+			}
+			dirs = make(map[string]bool)
+			trees = make(map[string]bool)
+			full = false
+		}
+	}
+}
+
+// sync rescans the changed directories, or everything when full, reporting
+// false when another scan held the library so the changes are still pending
+func (w *Watcher) sync(full bool, dirs, trees []string) bool {
+	var err error
+	if full {
+		_, err = w.scanner.Run(models.ScanTriggerWatcher)
+	} else {
+		_, err = w.scanner.RunDirs(models.ScanTriggerWatcher, dirs, trees)
+	}
+	if errors.Is(err, database.ErrLocked) {
+		return false
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to sync changes in %s: %v\n", w.scanner.scanPath, err)
+	}
+	return true
+}
+
+// addTree watches dir and every directory below it that isn't hidden
+func (w *Watcher) addTree(fsw *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can be removed while they are walked
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.scanner.scanPath && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := fsw.Add(path); err != nil {
+			// Linux bounds the directories that can be watched by fs.inotify.max_user_watches
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// ignored reports whether path is outside the scan path, hidden, or below a hidden directory
+func (w *Watcher) ignored(path string) bool {
+	rel, err := filepath.Rel(w.scanner.scanPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// keys returns the set's members in order
+func keys(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for key := range set {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
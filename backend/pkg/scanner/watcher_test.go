@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestRunDirs tests rescanning only the directories that changed
+func TestRunDirs(t *testing.T) {
+	db := setupTestDB(t)
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+
+	keepPath := createTestProject(t, tmpDir, "Keep", map[string]string{"model.stl": "STL content"})
+	gonePath := createTestProject(t, tmpDir, "Gone", map[string]string{"part.3mf": "3MF content"})
+	deepPath := createTestProject(t, tmpDir, "Deep", map[string]string{"base.stl": "STL content"})
+	if _, err := scanner.Run(models.ScanTriggerManual); err != nil {
+		t.Fatalf("Initial scan failed: %v", err)
+	}
+	db.Model(&models.Project{}).Where("path = ?", deepPath).Update("scan_settings", `{"max_depth": 2}`)
+
+	os.WriteFile(filepath.Join(keepPath, "extra.gcode"), []byte("G28"), 0644)
+	os.RemoveAll(gonePath)
+	os.MkdirAll(filepath.Join(deepPath, "parts"), 0755)
+	os.WriteFile(filepath.Join(deepPath, "parts", "arm.stl"), []byte("solid arm"), 0644)
+	newPath := createTestProject(t, filepath.Join(tmpDir, "Shelf"), "New", map[string]string{"new.stl": "STL content"})
+	// Left out of the dirs, so it isn't picked up
+	createTestProject(t, tmpDir, "Unseen", map[string]string{"unseen.stl": "STL content"})
+
+	run, err := scanner.RunDirs(models.ScanTriggerWatcher, []string{keepPath, tmpDir, filepath.Join(deepPath, "parts")}, []string{filepath.Join(tmpDir, "Shelf")})
+	if err != nil {
+		t.Fatalf("RunDirs failed: %v", err)
+	}
+	if run.Trigger != models.ScanTriggerWatcher || run.ProjectsAdded != 1 || run.ProjectsUpdated != 2 || run.ProjectsRemoved != 1 {
+		t.Errorf("Unexpected run counts: %+v", run)
+	}
+	if len(run.Roots) != 4 {
+		t.Errorf("Expected the changed directories as roots, got %v", run.Roots)
+	}
+
+	counts := map[string]int64{}
+	var projects []models.Project
+	db.Find(&projects)
+	for _, project := range projects {
+		var files int64
+		db.Model(&models.ProjectFile{}).Where("project_id = ?", project.ID).Count(&files)
+		counts[project.Path] = files
+	}
+	expected := map[string]int64{keepPath: 2, deepPath: 2, newPath: 1}
+	if len(counts) != len(expected) {
+		t.Errorf("Expected projects %v, got %v", expected, counts)
+	}
+	for path, files := range expected {
+		if counts[path] != files {
+			t.Errorf("Expected %d files in %s, got %d", files, path, counts[path])
+		}
+	}
+}
+
+// TestWatcher tests syncing changes on disk while the watcher is enabled
+func TestWatcher(t *testing.T) {
+	// The watcher's goroutine needs the same database, which an in-memory one isn't across connections
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "watch.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Project{}, &models.ProjectFile{}, &models.ScanRun{}, &models.Lock{}); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	tmpDir := t.TempDir()
+	scanner := New(db, tmpDir)
+	existing := createTestProject(t, tmpDir, "Existing", map[string]string{"model.stl": "STL content"})
+
+	var enabled atomic.Bool
+	enabled.Store(true)
+	watcher := NewWatcher(scanner, 20*time.Millisecond, enabled.Load)
+	watcher.poll = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	projectFiles := func(path string) int64 {
+		var project models.Project
+		if err := db.Where("path = ?", path).First(&project).Error; err != nil {
+			return -1
+		}
+		var files int64
+		db.Model(&models.ProjectFile{}).Where("project_id = ?", project.ID).Count(&files)
+		return files
+	}
+	waitFor := func(what string, check func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !check() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Starting to watch catches up with a full scan
+	waitFor("the existing project", func() bool { return projectFiles(existing) == 1 })
+
+	added := filepath.Join(tmpDir, "Added")
+	os.MkdirAll(filepath.Join(added, "parts"), 0755)
+	os.WriteFile(filepath.Join(added, "parts", "arm.stl"), []byte("solid arm"), 0644)
+	waitFor("the added project", func() bool { return projectFiles(filepath.Join(added, "parts")) == 1 })
+
+	os.WriteFile(filepath.Join(existing, "extra.3mf"), []byte("3MF"), 0644)
+	waitFor("the added file", func() bool { return projectFiles(existing) == 2 })
+
+	os.RemoveAll(existing)
+	waitFor("the removed project", func() bool { return projectFiles(existing) == -1 })
+
+	var runs int64
+	db.Model(&models.ScanRun{}).Where("trigger = ?", models.ScanTriggerWatcher).Count(&runs)
+	if runs < 4 {
+		t.Errorf("Expected a recorded run per sync, got %d", runs)
+	}
+
+	// Disabled, the watcher leaves changes to the next scan
+	enabled.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	ignored := createTestProject(t, tmpDir, "Ignored", map[string]string{"model.stl": "STL content"})
+	time.Sleep(100 * time.Millisecond)
+	if projectFiles(ignored) != -1 {
+		t.Error("Expected no sync while disabled")
+	}
+	enabled.Store(true)
+	waitFor("the catch-up scan", func() bool { return projectFiles(ignored) == 1 })
+}
+
+// TestWatcherIgnored tests leaving hidden files and directories out
+func TestWatcherIgnored(t *testing.T) {
+	watcher := NewWatcher(New(nil, "/library"), time.Second, func() bool { return true })
+	for path, expected := range map[string]bool{
+		"/library/Benchy/benchy.stl":              false,
+		"/library/Benchy/.3dshelf.json":           true,
+		"/library/.uploads/Benchy/benchy.stl":     true,
+		"/library/mirror/.Benchy_7.replicating/a": true,
+		"/library":              false,
+		"/elsewhere/benchy.stl": true,
+	} {
+		if got := watcher.ignored(path); got != expected {
+			t.Errorf("Expected ignored(%s) to be %v, got %v", path, expected, got)
+		}
+	}
+}
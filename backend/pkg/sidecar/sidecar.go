@@ -0,0 +1,189 @@
+package sidecar
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/fileperm"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Filename is the name of the metadata sidecar kept in each project directory
+const Filename = ".3dshelf.json"
+
+// currentVersion is the sidecar schema version written by this build
+const currentVersion = 1
+
+// maxNoteLength bounds each file's note in the summary, in characters
+const maxNoteLength = 200
+
+// Sidecar is the portable project metadata stored next to the project files
+type Sidecar struct {
+	Version  int      `json:"version"`
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags"`
+	License  string   `json:"license,omitempty"`
+	Designer string   `json:"designer,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Rating   int      `json:"rating,omitempty"`
+
+	// Prints counts the project's print history and Notes summarizes its files'
+	// notes by filename. Both describe the library to readers of the file and
+	// aren't applied on adoption, as the history belongs to the instance.
+	Prints *PrintCounts      `json:"prints,omitempty"`
+	Notes  map[string]string `json:"notes,omitempty"`
+}
+
+// PrintCounts summarizes a project's print history by outcome
+type PrintCounts struct {
+	Total         int        `json:"total"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	Cancelled     int        `json:"cancelled"`
+	LastPrintedAt *time.Time `json:"last_printed_at,omitempty"`
+}
+
+// FromProject builds a sidecar from a project
+func FromProject(project *models.Project) *Sidecar {
+	tags := project.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &Sidecar{
+		Version:  currentVersion,
+		Name:     project.Name,
+		Tags:     tags,
+		License:  project.License,
+		Designer: project.Designer,
+		Source:   project.Source,
+		Rating:   project.Rating,
+	}
+}
+
+// Build builds a project's sidecar with its print history counts and notes
+// summary from db
+func Build(db *gorm.DB, project *models.Project) (*Sidecar, error) {
+	s := FromProject(project)
+
+	var prints []models.PrintJob
+	if err := db.Select("outcome", "started_at").Where("project_id = ? AND outcome <> ?", project.ID, models.PrintInProgress).
+		Find(&prints).Error; err != nil {
+		return nil, err
+	}
+	if len(prints) > 0 {
+		counts := &PrintCounts{Total: len(prints)}
+		for _, job := range prints {
+			switch job.Outcome {
+			case models.PrintSucceeded:
+				counts.Succeeded++
+			case models.PrintFailed:
+				counts.Failed++
+			case models.PrintCancelled:
+				counts.Cancelled++
+			}
+			if counts.LastPrintedAt == nil || job.StartedAt.After(*counts.LastPrintedAt) {
+				startedAt := job.StartedAt.UTC()
+				counts.LastPrintedAt = &startedAt
+			}
+		}
+		s.Prints = counts
+	}
+
+	var profiles []models.FileProfile
+	if err := db.Where("project_id = ? AND notes <> ''", project.ID).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		if s.Notes == nil {
+			s.Notes = make(map[string]string, len(profiles))
+		}
+		s.Notes[profile.Filename] = summarize(profile.Notes)
+	}
+	return s, nil
+}
+
+// summarize shortens a note to its first line, at most maxNoteLength characters
+func summarize(note string) string {
+	note, _, _ = strings.Cut(strings.TrimSpace(note), "\n")
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) <= maxNoteLength {
+		return note
+	}
+	return strings.TrimSpace(string([]rune(note)[:maxNoteLength-1])) + "…"
+}
+
+// ApplyTo copies sidecar metadata onto a project being adopted
+func (s *Sidecar) ApplyTo(project *models.Project) {
+	if s.Name != "" {
+		project.Name = s.Name
+	}
+	project.Tags = s.Tags
+	project.License = s.License
+	project.Designer = s.Designer
+	project.Source = s.Source
+	project.Rating = s.Rating
+}
+
+// Read loads the sidecar from a project directory; it returns nil without
+// error when the directory has none
+func Read(dir string) (*Sidecar, error) {
+	data, err := os.ReadFile(filepath.Join(dir, Filename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Sidecar
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", Filename, err)
+	}
+	if s.Version > currentVersion {
+		return nil, fmt.Errorf("unsupported %s version %d", Filename, s.Version)
+	}
+	return &s, nil
+}
+
+// Write stores the sidecar in a project directory. The file is only rewritten
+// when its content changes, keeping version-controlled libraries quiet.
+func Write(dir string, s *Sidecar) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join(dir, Filename)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(dir, Filename+"-")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fileperm.File(tmp.Name())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
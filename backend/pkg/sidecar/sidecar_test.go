@@ -0,0 +1,131 @@
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	dir := t.TempDir()
+	project := &models.Project{
+		Name:     "Gear Box",
+		Tags:     []string{"gears"},
+		License:  "MIT",
+		Designer: "Jane Maker",
+	}
+
+	if err := Write(dir, FromProject(project)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	read, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if read == nil {
+		t.Fatal("Expected a sidecar to be read back")
+	}
+
+	adopted := &models.Project{Name: "Gear_Box"}
+	read.ApplyTo(adopted)
+	if adopted.Name != "Gear Box" || adopted.License != "MIT" || !reflect.DeepEqual(adopted.Tags, []string{"gears"}) {
+		t.Errorf("Unexpected adopted project: %+v", adopted)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	project := &models.Project{Name: "Gear Box", Path: t.TempDir(), Rating: 4}
+	db.Create(project)
+	last := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for _, job := range []models.PrintJob{
+		{ProjectID: project.ID, Outcome: models.PrintSucceeded, StartedAt: last.AddDate(0, -1, 0)},
+		{ProjectID: project.ID, Outcome: models.PrintSucceeded, StartedAt: last},
+		{ProjectID: project.ID, Outcome: models.PrintFailed, StartedAt: last.AddDate(0, -2, 0)},
+		{ProjectID: project.ID, Outcome: models.PrintInProgress, StartedAt: last.AddDate(0, 0, 1)},
+	} {
+		db.Create(&job)
+	}
+	long := strings.Repeat("a", maxNoteLength+10)
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "gear.stl", Notes: "Print at 0.1mm\nUse PETG for the axle"})
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "lid.stl", Notes: long})
+	db.Create(&models.FileProfile{ProjectID: project.ID, Filename: "case.stl", Orientation: "Lid down"})
+
+	s, err := Build(db, project)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	expected := &PrintCounts{Total: 3, Succeeded: 2, Failed: 1, LastPrintedAt: &last}
+	if !reflect.DeepEqual(s.Prints, expected) {
+		t.Errorf("Expected finished prints counted, got %+v", s.Prints)
+	}
+	if len(s.Notes) != 2 || s.Notes["gear.stl"] != "Print at 0.1mm" || len([]rune(s.Notes["lid.stl"])) != maxNoteLength {
+		t.Errorf("Expected each file's notes summarized, got %q", s.Notes)
+	}
+
+	// A project without history writes neither
+	if s, _ = Build(db, &models.Project{ID: 99, Name: "New"}); s.Prints != nil || s.Notes != nil {
+		t.Errorf("Expected no history for a new project, got %+v", s)
+	}
+}
+
+func TestWriteUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	s := FromProject(&models.Project{Name: "Stable"})
+
+	if err := Write(dir, s); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	path := filepath.Join(dir, Filename)
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(path, past, past)
+
+	if err := Write(dir, s); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	info, _ := os.Stat(path)
+	if !info.ModTime().Equal(past) {
+		t.Error("Expected unchanged sidecar not to be rewritten")
+	}
+}
+
+func TestReadMissing(t *testing.T) {
+	s, err := Read(t.TempDir())
+	if err != nil || s != nil {
+		t.Errorf("Expected no sidecar and no error, got %v, %v", s, err)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"Malformed JSON": "{not json",
+		"Future version": `{"version": 99, "name": "Later"}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, Filename), []byte(content), 0644)
+			if _, err := Read(dir); err == nil {
+				t.Error("Expected Read to fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package signing
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSignVerify tests signature round-trips and tamper detection
+func TestSignVerify(t *testing.T) {
+	signer := New([]byte("test-secret"))
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	signature := signer.Sign("/api/files/1/download", expires, "abc123")
+
+	testCases := []struct {
+		name     string
+		path     string
+		expires  time.Time
+		hash     string
+		sig      string
+		now      time.Time
+		expected error
+	}{
+		{name: "Valid", path: "/api/files/1/download", expires: expires, hash: "abc123", sig: signature, now: now, expected: nil},
+		{name: "Expired", path: "/api/files/1/download", expires: expires, hash: "abc123", sig: signature, now: expires.Add(time.Second), expected: ErrExpired},
+		{name: "Different path", path: "/api/files/2/download", expires: expires, hash: "abc123", sig: signature, now: now, expected: ErrInvalidSignature},
+		{name: "Extended expiry", path: "/api/files/1/download", expires: expires.Add(time.Hour), hash: "abc123", sig: signature, now: now, expected: ErrInvalidSignature},
+		{name: "Different hash", path: "/api/files/1/download", expires: expires, hash: "def456", sig: signature, now: now, expected: ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := signer.Verify(tc.path, tc.expires, tc.hash, tc.sig, tc.now); err != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// TestSignersDiffer tests that different secrets produce different signatures
+func TestSignersDiffer(t *testing.T) {
+	first, err := NewRandom()
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	second, err := NewRandom()
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	signature := first.Sign("/path", expires, "hash")
+	if err := second.Verify("/path", expires, "hash", signature, time.Now()); err != ErrInvalidSignature {
+		t.Errorf("Expected signature from another secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package slicer
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/fileperm"
+	"3dshelf/pkg/fsutil"
+	"3dshelf/pkg/jobs"
+	"3dshelf/pkg/mesh"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// JobType identifies slicing runs in the job queue
+	JobType = "slice"
+
+	// DefaultTimeout bounds how long one slicer run may take
+	DefaultTimeout = 30 * time.Minute
+
+	// maxOutputSize bounds the G-code a run may produce
+	maxOutputSize = 1 << 30 // 1GB
+
+	// maxLogSize bounds how much of the slicer's console output is kept for errors
+	maxLogSize = 4096
+
+	// lockWait is how long a run waits for other changes to the project to finish
+	lockWait = 30 * time.Second
+)
+
+// Presets are the command templates for the slicers known by name
+var Presets = map[string]string{
+	"prusaslicer": "prusa-slicer --export-gcode --load {profile} --output {output} {input}",
+	"curaengine":  "CuraEngine slice -j {profile} -l {input} -o {output}",
+}
+
+var (
+	// ErrUnsupportedFile is returned when slicing a file that isn't an STL or 3MF model
+	ErrUnsupportedFile = errors.New("only STL and 3MF models can be sliced")
+
+	// ErrUnknownProfile is returned when no profile of the requested name exists
+	ErrUnknownProfile = errors.New("unknown slicer profile")
+)
+
+// Slicer runs a slicer command line in the background to turn project models
+// into G-code stored next to them
+type Slicer struct {
+	db          *gorm.DB
+	queue       *jobs.Queue
+	command     []string
+	profilesDir string
+
+	// Timeout bounds each run; DefaultTimeout when zero
+	Timeout time.Duration
+}
+
+// jobPayload is the queued job's reference to the model and profile it slices
+type jobPayload struct {
+	FileID  uint   `json:"file_id"`
+	Profile string `json:"profile"`
+}
+
+// ParseCommand splits a command template, or the name of one of the Presets,
+// into its arguments. The template must place {input} and {output}; {profile}
+// is replaced by the chosen profile's path.
+func ParseCommand(template string) ([]string, error) {
+	if preset, ok := Presets[strings.ToLower(strings.TrimSpace(template))]; ok {
+		template = preset
+	}
+	args := strings.Fields(template)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty slicer command")
+	}
+	for _, placeholder := range []string{"{input}", "{output}"} {
+		if !strings.Contains(template, placeholder) {
+			return nil, fmt.Errorf("slicer command must contain %s", placeholder)
+		}
+	}
+	return args, nil
+}
+
+// New creates a Slicer running command, as returned by ParseCommand, with the
+// profiles in profilesDir, and registers its runs on queue. Slicing is
+// deterministic, so failed runs are not retried.
+func New(db *gorm.DB, queue *jobs.Queue, command []string, profilesDir string) *Slicer {
+	s := &Slicer{
+		db:          db,
+		queue:       queue,
+		command:     command,
+		profilesDir: profilesDir,
+	}
+	queue.Register(JobType, jobs.NoRetry, s.runJob)
+	return s
+}
+
+// Profiles lists the names of the available profiles: the files in the
+// profiles directory without their extension
+func (s *Slicer) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(s.profilesDir)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		profiles = append(profiles, profileName(entry.Name()))
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// Start queues slicing a model file with the named profile. A second request
+// for the same file and profile while one is unfinished returns that job.
+func (s *Slicer) Start(fileID uint, profile string) (*models.Job, error) {
+	var file models.ProjectFile
+	if err := s.db.First(&file, fileID).Error; err != nil {
+		return nil, err
+	}
+	if file.FileType != models.FileTypeSTL && file.FileType != models.FileType3MF {
+		return nil, ErrUnsupportedFile
+	}
+	if _, err := s.profilePath(profile); err != nil {
+		return nil, err
+	}
+
+	key := strconv.FormatUint(uint64(fileID), 10) + ":" + profile
+	return s.queue.EnqueueOnce(JobType, key, jobPayload{FileID: fileID, Profile: profile})
+}
+
+// runJob slices the file a queued job refers to
+func (s *Slicer) runJob(ctx context.Context, queued *models.Job) error {
+	var payload jobPayload
+	if err := queued.DecodePayload(&payload); err != nil {
+		return err
+	}
+	_, err := s.Slice(ctx, payload.FileID, payload.Profile)
+	return err
+}
+
+// Slice runs the slicer on a model file and records the G-code in its
+// project, named after the model and profile so it pairs with the model
+func (s *Slicer) Slice(ctx context.Context, fileID uint, profile string) (*models.ProjectFile, error) {
+	var file models.ProjectFile
+	if err := s.db.Preload("Project").First(&file, fileID).Error; err != nil {
+		return nil, err
+	}
+	profilePath, err := s.profilePath(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "3dshelf-slice-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	// The slicer works on a copy, decompressed when the model is stored
+	// compressed, so whatever it does can't touch the library
+	input := filepath.Join(workDir, "input"+strings.ToLower(filepath.Ext(modelFilename(file))))
+	if err := copyModel(file.Filepath, input); err != nil {
+		return nil, fmt.Errorf("failed to copy %s: %v", file.Filename, err)
+	}
+	output := filepath.Join(workDir, "output.gcode")
+	if err := s.run(ctx, workDir, input, output, profilePath); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(output)
+	if err != nil || info.Size() == 0 {
+		return nil, fmt.Errorf("slicer produced no G-code")
+	}
+	if info.Size() > maxOutputSize {
+		return nil, fmt.Errorf("slicer output exceeds %d bytes", maxOutputSize)
+	}
+
+	_, unlock, err := database.LockWait(ctx, s.db, database.ProjectLock(file.ProjectID), lockWait)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.store(file, profile, output)
+}
+
+// run executes the slicer command in workDir with a bare environment, killing
+// it when ctx is done or the timeout passes
+func (s *Slicer) run(ctx context.Context, workDir, input, output, profilePath string) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	replacer := strings.NewReplacer("{input}", input, "{output}", output, "{profile}", profilePath)
+	args := make([]string, len(s.command))
+	for i, arg := range s.command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	var console limitedBuffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = workDir
+	// Only what a slicer needs to start, so it can't read the server's secrets
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + workDir, "TMPDIR=" + workDir}
+	cmd.Stdout = &console
+	cmd.Stderr = &console
+	// Don't wait on children that outlive a killed slicer and hold its output open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("slicer %s: %w", args[0], ctx.Err())
+		}
+		if message := strings.TrimSpace(console.String()); message != "" {
+			return fmt.Errorf("slicer %s: %v: %s", args[0], err, message)
+		}
+		return fmt.Errorf("slicer %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// store copies the G-code into the model's project under a free name and records it
+func (s *Slicer) store(file models.ProjectFile, profile, output string) (*models.ProjectFile, error) {
+	dir := filepath.Dir(file.Filepath)
+	name := filepath.Base(modelFilename(file))
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	dest := fsutil.AvailablePath(dir, fmt.Sprintf("%s_%s.gcode", stem, profile))
+
+	if err := copyFile(output, dest); err != nil {
+		os.Remove(dest)
+		return nil, fmt.Errorf("failed to store G-code: %v", err)
+	}
+	if err := fileperm.File(dest); err != nil {
+		os.Remove(dest)
+		return nil, err
+	}
+	size, hash, err := fsutil.HashFile(dest)
+	if err != nil {
+		os.Remove(dest)
+		return nil, err
+	}
+
+	filename, err := filepath.Rel(file.Project.Path, dest)
+	if err != nil {
+		filename = filepath.Base(dest)
+	}
+	sliced := models.ProjectFile{
+		ProjectID: file.ProjectID,
+		Filename:  filepath.ToSlash(filename),
+		Filepath:  dest,
+		FileType:  models.FileTypeGCode,
+		Size:      size,
+		Hash:      hash,
+	}
+	if err := s.db.Create(&sliced).Error; err != nil {
+		os.Remove(dest)
+		return nil, err
+	}
+	return &sliced, nil
+}
+
+// profilePath finds the profile file of the given name
+func (s *Slicer) profilePath(profile string) (string, error) {
+	if profile == "" || profile != filepath.Base(profile) || strings.HasPrefix(profile, ".") {
+		return "", fmt.Errorf("%w: %q", ErrUnknownProfile, profile)
+	}
+	entries, err := os.ReadDir(s.profilesDir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && profileName(entry.Name()) == profile {
+			return filepath.Join(s.profilesDir, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownProfile, profile)
+}
+
+// profileName is a profile file's name without its extension, including
+// CuraEngine's compound .def.json
+func profileName(filename string) string {
+	if name, ok := strings.CutSuffix(filename, ".def.json"); ok {
+		return name
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// copyFile copies src to a new file at dest
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// copyModel copies a model file's content to dest, decompressing it when it is stored compressed
+func copyModel(src, dest string) error {
+	in, _, err := mesh.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// modelFilename is a model file's name without the suffix of compressed storage
+func modelFilename(file models.ProjectFile) string {
+	return strings.TrimSuffix(file.Filename, mesh.CompressedExt)
+}
+
+// limitedBuffer keeps the last maxLogSize bytes written to it, where
+// slicers report why they failed
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n, _ := b.Buffer.Write(p)
+	if over := b.Len() - maxLogSize; over > 0 {
+		b.Next(over)
+	}
+	return n, nil
+}
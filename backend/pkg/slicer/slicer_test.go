@@ -0,0 +1,155 @@
+package slicer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/jobs"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a migrated database in a temporary file, so the
+// project locks taken while storing work across connections
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "slice.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// fakeSlicer writes a script that acts like a slicer: it fails when the
+// profile says so and otherwise writes the profile and input into the output
+func fakeSlicer(t *testing.T) []string {
+	script := filepath.Join(t.TempDir(), "fake-slicer")
+	content := `#!/bin/sh
+if grep -q fail "$1"; then
+	echo "profile rejected" >&2
+	exit 1
+fi
+cat "$1" "$2" > "$3"
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write fake slicer: %v", err)
+	}
+	command, err := ParseCommand(script + " {profile} {input} {output}")
+	if err != nil {
+		t.Fatalf("ParseCommand() returned error: %v", err)
+	}
+	return command
+}
+
+// setupSlicer creates a slicer with two profiles and a project holding an STL
+func setupSlicer(t *testing.T) (*Slicer, *gorm.DB, models.ProjectFile) {
+	db := setupTestDB(t)
+	profilesDir := t.TempDir()
+	os.WriteFile(filepath.Join(profilesDir, "pla_0.2mm.ini"), []byte("; PLA\n"), 0644)
+	os.WriteFile(filepath.Join(profilesDir, "broken.ini"), []byte("fail\n"), 0644)
+	os.WriteFile(filepath.Join(profilesDir, ".hidden.ini"), []byte(""), 0644)
+
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, "parts"), 0755)
+	modelPath := filepath.Join(projectDir, "parts", "benchy.stl")
+	os.WriteFile(modelPath, []byte("solid benchy\n"), 0644)
+
+	project := models.Project{Name: "Benchy", Path: projectDir}
+	db.Create(&project)
+	file := models.ProjectFile{ProjectID: project.ID, Filename: "parts/benchy.stl", Filepath: modelPath, FileType: models.FileTypeSTL}
+	db.Create(&file)
+
+	return New(db, jobs.New(db), fakeSlicer(t), profilesDir), db, file
+}
+
+// TestParseCommand tests expanding presets and requiring the placeholders
+func TestParseCommand(t *testing.T) {
+	command, err := ParseCommand("PrusaSlicer")
+	if err != nil || command[0] != "prusa-slicer" {
+		t.Errorf("Expected the PrusaSlicer preset, got %v, %v", command, err)
+	}
+
+	for _, template := range []string{"", "slicer {input}", "slicer -o {output}"} {
+		if _, err := ParseCommand(template); err == nil {
+			t.Errorf("Expected error for %q", template)
+		}
+	}
+}
+
+// TestProfiles tests listing profiles by name
+func TestProfiles(t *testing.T) {
+	s, _, _ := setupSlicer(t)
+	os.WriteFile(filepath.Join(s.profilesDir, "fdmprinter.def.json"), []byte("{}"), 0644)
+
+	profiles, err := s.Profiles()
+	if err != nil {
+		t.Fatalf("Profiles() returned error: %v", err)
+	}
+	if strings.Join(profiles, ",") != "broken,fdmprinter,pla_0.2mm" {
+		t.Errorf("Unexpected profiles: %v", profiles)
+	}
+}
+
+// TestStart tests validating slice requests before queueing them
+func TestStart(t *testing.T) {
+	s, db, file := setupSlicer(t)
+
+	job, err := s.Start(file.ID, "pla_0.2mm")
+	if err != nil {
+		t.Fatalf("Start() returned error: %v", err)
+	}
+	if again, _ := s.Start(file.ID, "pla_0.2mm"); again == nil || again.ID != job.ID {
+		t.Errorf("Expected the unfinished job to be reused, got %+v", again)
+	}
+
+	for _, profile := range []string{"missing", "../pla_0.2mm", ".hidden"} {
+		if _, err := s.Start(file.ID, profile); !errors.Is(err, ErrUnknownProfile) {
+			t.Errorf("Expected ErrUnknownProfile for %q, got %v", profile, err)
+		}
+	}
+
+	readme := models.ProjectFile{ProjectID: file.ProjectID, Filename: "README.md", Filepath: "README.md", FileType: models.FileTypeREADME}
+	db.Create(&readme)
+	if _, err := s.Start(readme.ID, "pla_0.2mm"); !errors.Is(err, ErrUnsupportedFile) {
+		t.Errorf("Expected ErrUnsupportedFile, got %v", err)
+	}
+}
+
+// TestSlice tests storing the G-code next to the model and reporting failures
+func TestSlice(t *testing.T) {
+	s, db, file := setupSlicer(t)
+
+	sliced, err := s.Slice(context.Background(), file.ID, "pla_0.2mm")
+	if err != nil {
+		t.Fatalf("Slice() returned error: %v", err)
+	}
+	if sliced.Filename != "parts/benchy_pla_0.2mm.gcode" || sliced.FileType != models.FileTypeGCode || sliced.Hash == "" {
+		t.Errorf("Unexpected sliced file: %+v", sliced)
+	}
+	if content, _ := os.ReadFile(sliced.Filepath); string(content) != "; PLA\nsolid benchy\n" {
+		t.Errorf("Expected the slicer's output, got %q", content)
+	}
+
+	again, err := s.Slice(context.Background(), file.ID, "pla_0.2mm")
+	if err != nil || again.Filename != "parts/benchy_pla_0.2mm_1.gcode" {
+		t.Errorf("Expected a second run under a free name, got %+v, %v", again, err)
+	}
+
+	if _, err := s.Slice(context.Background(), file.ID, "broken"); err == nil || !strings.Contains(err.Error(), "profile rejected") {
+		t.Errorf("Expected the slicer's console in the error, got %v", err)
+	}
+	var count int64
+	db.Model(&models.ProjectFile{}).Where("file_type = ?", models.FileTypeGCode).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 G-code files recorded, got %d", count)
+	}
+}
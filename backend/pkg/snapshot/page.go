@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// metaTitles, metaDescriptions and metaLicenses are the <meta> names and
+// properties read for each field, most preferred first
+var (
+	metaTitles       = []string{"og:title", "twitter:title"}
+	metaDescriptions = []string{"og:description", "twitter:description", "description"}
+	metaLicenses     = []string{"license", "dcterms.license", "dc.rights", "og:license"}
+)
+
+// metaImages are the <meta> names and properties pointing at page images
+var metaImages = map[string]bool{
+	"og:image": true, "og:image:url": true, "og:image:secure_url": true, "twitter:image": true,
+}
+
+// page is what a source page says about itself
+type page struct {
+	title   string
+	meta    map[string]string
+	license string
+	images  []string
+}
+
+// parsePage reads the title, description, license and images a page
+// advertises through its <title>, Open Graph and Twitter card <meta> tags and
+// rel="license" links. Image URLs are resolved against base, without duplicates.
+func parsePage(doc *html.Node, base *url.URL) (Snapshot, []string) {
+	p := page{meta: make(map[string]string)}
+	p.walk(doc)
+
+	snapshot := Snapshot{
+		Title:       first(p.meta, metaTitles, strings.TrimSpace(p.title)),
+		Description: first(p.meta, metaDescriptions, ""),
+		License:     first(p.meta, metaLicenses, p.license),
+	}
+
+	var images []string
+	seen := make(map[string]bool)
+	for _, raw := range p.images {
+		ref, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || raw == "" {
+			continue
+		}
+		resolved := base.ResolveReference(ref)
+		if (resolved.Scheme != "http" && resolved.Scheme != "https") || seen[resolved.String()] {
+			continue
+		}
+		seen[resolved.String()] = true
+		images = append(images, resolved.String())
+	}
+	return snapshot, images
+}
+
+// walk collects what the page advertises from n and its descendants
+func (p *page) walk(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Title:
+			if p.title == "" {
+				p.title = text(n)
+			}
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property"))
+			if key == "" {
+				key = strings.ToLower(attr(n, "name"))
+			}
+			content := strings.TrimSpace(attr(n, "content"))
+			if metaImages[key] {
+				p.images = append(p.images, content)
+			} else if _, ok := p.meta[key]; !ok && key != "" && content != "" {
+				p.meta[key] = content
+			}
+		case atom.Link, atom.A:
+			rels := strings.Fields(strings.ToLower(attr(n, "rel")))
+			for _, rel := range rels {
+				switch {
+				case rel == "license" && p.license == "":
+					p.license = strings.TrimSpace(text(n))
+					if p.license == "" {
+						p.license = attr(n, "href")
+					}
+				case rel == "image_src" && n.DataAtom == atom.Link:
+					p.images = append(p.images, attr(n, "href"))
+				}
+			}
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.walk(child)
+	}
+}
+
+// first returns the value of the first of keys present in meta, or fallback
+func first(meta map[string]string, keys []string, fallback string) string {
+	for _, key := range keys {
+		if value := meta[key]; value != "" {
+			return value
+		}
+	}
+	return fallback
+}
+
+// attr returns the value of a node's attribute
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// text returns the text of a node and its descendants, whitespace collapsed
+func text(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,254 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// metadataFile holds a snapshot's metadata, next to its page and images
+	metadataFile = "source.json"
+
+	// pageFile is the page as fetched
+	pageFile = "page.html"
+
+	// maxPageSize bounds the page read, in bytes
+	maxPageSize = 5 << 20 // 5MB
+
+	// maxImageSize bounds each image saved, in bytes; larger images are skipped
+	maxImageSize = 20 << 20 // 20MB
+
+	// DefaultMaxImages is how many of a page's images are saved
+	DefaultMaxImages = 10
+)
+
+// ErrNotFound is returned for projects without a snapshot
+var ErrNotFound = errors.New("no source snapshot")
+
+// Snapshot is what was saved of a project's source page
+type Snapshot struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	License     string    `json:"license"`
+	Images      []Image   `json:"images"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Image is one of the page's images and the file it was saved to
+type Image struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+}
+
+// Archive keeps snapshots of source pages on disk, one directory per key
+type Archive struct {
+	dir       string
+	HTTP      *http.Client
+	MaxImages int
+
+	// mu keeps concurrent snapshots of the same key from interleaving
+	mu sync.Mutex
+}
+
+// New creates an Archive keeping snapshots in dir
+func New(dir string) *Archive {
+	return &Archive{
+		dir:       dir,
+		HTTP:      &http.Client{Timeout: time.Minute},
+		MaxImages: DefaultMaxImages,
+	}
+}
+
+// ValidURL reports whether a source can be snapshotted: an absolute http or
+// https URL
+func ValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Take fetches the page at pageURL with its images and saves them under key,
+// replacing any earlier snapshot once the new one is complete. Images that
+// fail to download are left out rather than failing the snapshot.
+func (a *Archive) Take(ctx context.Context, key, pageURL string) (*Snapshot, error) {
+	if !ValidURL(pageURL) {
+		return nil, fmt.Errorf("invalid source URL %q", pageURL)
+	}
+	base, _ := url.Parse(pageURL)
+
+	body, err := a.fetch(ctx, pageURL, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	snapshot, imageURLs := parsePage(doc, base)
+	snapshot.URL = pageURL
+	snapshot.FetchedAt = time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(a.dir, "."+key+"-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.WriteFile(filepath.Join(tmp, pageFile), body, 0644); err != nil {
+		return nil, err
+	}
+	snapshot.Images = []Image{}
+	for _, imageURL := range imageURLs {
+		if len(snapshot.Images) == a.MaxImages {
+			break
+		}
+		filename, err := a.saveImage(ctx, imageURL, tmp, len(snapshot.Images)+1)
+		if err != nil {
+			fmt.Printf("Warning: Failed to save image %s of %s: %v\n", imageURL, pageURL, err)
+			continue
+		}
+		snapshot.Images = append(snapshot.Images, Image{URL: imageURL, Filename: filename})
+	}
+
+	metadata, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, metadataFile), metadata, 0644); err != nil {
+		return nil, err
+	}
+
+	dest := filepath.Join(a.dir, key)
+	if err := os.RemoveAll(dest); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Get returns the snapshot saved under key, or ErrNotFound
+func (a *Archive) Get(key string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(a.dir, key, metadataFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ImagePath returns the path of an image of the snapshot saved under key, or
+// ErrNotFound when it has no such image
+func (a *Archive) ImagePath(key, filename string) (string, error) {
+	snapshot, err := a.Get(key)
+	if err != nil {
+		return "", err
+	}
+	for _, image := range snapshot.Images {
+		if image.Filename == filename {
+			return filepath.Join(a.dir, key, filename), nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Remove deletes the snapshot saved under key, if any
+func (a *Archive) Remove(key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return os.RemoveAll(filepath.Join(a.dir, key))
+}
+
+// fetch reads a URL, refusing responses larger than limit
+func (a *Archive) fetch(ctx context.Context, target string, limit int64) ([]byte, error) {
+	resp, err := a.get(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", target, limit)
+	}
+	return body, nil
+}
+
+// saveImage downloads the nth image into dir, named by its content type
+func (a *Archive) saveImage(ctx context.Context, target, dir string, n int) (string, error) {
+	resp, err := a.get(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := imageExtensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("not an image: %q", mediaType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > maxImageSize {
+		return "", fmt.Errorf("larger than %d bytes", maxImageSize)
+	}
+
+	filename := fmt.Sprintf("image-%d%s", n, ext)
+	return filename, os.WriteFile(filepath.Join(dir, filename), body, 0644)
+}
+
+// imageExtensions are the image types saved and the extension they are saved with
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// get performs a request, failing on error statuses
+func (a *Archive) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned %s", req.URL.Redacted(), resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const thingPage = `<!DOCTYPE html>
+<html><head>
+<title>Benchy by CreativeTools - Thingiverse</title>
+<meta property="og:title" content="3DBenchy">
+<meta name="description" content="Short description">
+<meta property="og:description" content="The jolly 3D printing torture-test">
+<meta property="og:image" content="/images/benchy.png">
+<meta name="twitter:image" content="images/missing.png">
+<meta property="og:image" content="/images/missing.png">
+<meta property="og:image" content="/about">
+<meta property="og:image" content="/images/benchy.png">
+</head><body>
+<p>Licensed under <a rel="license" href="https://creativecommons.org/licenses/by-nd/4.0/">Creative Commons -
+Attribution - No Derivatives</a></p>
+</body></html>`
+
+// newSourceServer serves a thing page with a PNG image, a missing image and an HTML page among its images
+func newSourceServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/thing/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(thingPage))
+	})
+	mux.HandleFunc("/images/benchy.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("PNG"))
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTake(t *testing.T) {
+	server := newSourceServer(t)
+	dir := t.TempDir()
+	archive := New(dir)
+	archive.HTTP = server.Client()
+
+	snapshot, err := archive.Take(context.Background(), "1", server.URL+"/thing/1")
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if snapshot.Title != "3DBenchy" || snapshot.Description != "The jolly 3D printing torture-test" {
+		t.Errorf("Expected the Open Graph title and description, got %q and %q", snapshot.Title, snapshot.Description)
+	}
+	if snapshot.License != "Creative Commons - Attribution - No Derivatives" {
+		t.Errorf("Expected the license link text, got %q", snapshot.License)
+	}
+
+	// The missing image fails, the HTML page isn't an image, and duplicates
+	// are saved once
+	if len(snapshot.Images) != 1 || snapshot.Images[0].URL != server.URL+"/images/benchy.png" || snapshot.Images[0].Filename != "image-1.png" {
+		t.Fatalf("Expected the one image saved, got %+v", snapshot.Images)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "1", "image-1.png")); err != nil || string(content) != "PNG" {
+		t.Errorf("Expected the image saved, got %q (%v)", content, err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "1", pageFile)); err != nil || string(content) != thingPage {
+		t.Errorf("Expected the page saved as fetched (%v)", err)
+	}
+
+	saved, err := archive.Get("1")
+	if err != nil || saved.Title != snapshot.Title || !saved.FetchedAt.Equal(snapshot.FetchedAt) {
+		t.Errorf("Expected the snapshot read back, got %+v (%v)", saved, err)
+	}
+	if path, err := archive.ImagePath("1", "image-1.png"); err != nil || path != filepath.Join(dir, "1", "image-1.png") {
+		t.Errorf("Expected the image path, got %q (%v)", path, err)
+	}
+	if _, err := archive.ImagePath("1", pageFile); err != ErrNotFound {
+		t.Errorf("Expected only images served, got %v", err)
+	}
+
+	// A later snapshot replaces the earlier one whole
+	archive.MaxImages = 0
+	if snapshot, err = archive.Take(context.Background(), "1", server.URL+"/thing/1"); err != nil || len(snapshot.Images) != 0 {
+		t.Fatalf("Expected a snapshot without images, got %+v (%v)", snapshot, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1", "image-1.png")); !os.IsNotExist(err) {
+		t.Error("Expected the earlier snapshot's images removed")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary directories left, got %v", entries)
+	}
+
+	if err := archive.Remove("1"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := archive.Get("1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound once removed, got %v", err)
+	}
+}
+
+func TestTakeErrors(t *testing.T) {
+	server := newSourceServer(t)
+	archive := New(t.TempDir())
+	archive.HTTP = server.Client()
+
+	for _, source := range []string{"", "thingiverse.com/thing:1", "ftp://example.com/thing", server.URL + "/missing"} {
+		if _, err := archive.Take(context.Background(), "1", source); err == nil {
+			t.Errorf("Expected %q to fail", source)
+		}
+	}
+	if _, err := archive.Get("1"); err != ErrNotFound {
+		t.Errorf("Expected no snapshot saved by failures, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package telemetry
+
+import (
+	"3dshelf/internal/models"
+	"3dshelf/internal/version"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/features"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InstanceSettingKey is the settings key the random instance ID is persisted under
+const InstanceSettingKey = "telemetry_instance"
+
+// Report is everything an instance sends. Counts are reported as ranges, and
+// nothing identifies the library, its files or the people using it.
+type Report struct {
+	// InstanceID is random, created the first time a report is sent, so
+	// reports from one instance can be counted once
+	InstanceID string `json:"instance_id"`
+
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	Mode    string `json:"mode"`
+
+	// Projects, Files, PrintJobs and LibrarySize are ranges such as "10-99" or "1-10GB"
+	Projects    string `json:"projects"`
+	Files       string `json:"files"`
+	PrintJobs   string `json:"print_jobs"`
+	LibrarySize string `json:"library_size"`
+
+	// Features and Integrations list what is switched on and configured
+	Features     []string `json:"features"`
+	Integrations []string `json:"integrations"`
+}
+
+// Status is what the preview endpoint shows
+type Status struct {
+	Enabled    bool       `json:"enabled"`
+	URL        string     `json:"url,omitempty"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+
+	// Report is exactly what the next report will send
+	Report Report `json:"report"`
+}
+
+// Reporter builds usage reports and, once opted in, sends them; it is safe
+// for concurrent use
+type Reporter struct {
+	db       *gorm.DB
+	features *features.Flags
+
+	// URL receives reports; empty until the instance opts in
+	URL  string
+	HTTP *http.Client
+
+	// Mode and Integrations describe how the instance is run
+	Mode         string
+	Integrations []string
+
+	mu         sync.Mutex
+	instanceID string
+	lastSentAt *time.Time
+	lastError  string
+}
+
+// New creates a Reporter describing the library in db and the given flags
+func New(db *gorm.DB, flags *features.Flags) *Reporter {
+	return &Reporter{
+		db:       db,
+		features: flags,
+		HTTP:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Status returns whether reports are sent, the outcome of the last one and
+// the report that would be sent now
+func (r *Reporter) Status() (Status, error) {
+	report, err := r.Build()
+	if err != nil {
+		return Status{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Status{
+		Enabled:    r.URL != "",
+		URL:        r.URL,
+		LastSentAt: r.lastSentAt,
+		LastError:  r.lastError,
+		Report:     report,
+	}, nil
+}
+
+// Build assembles the current report
+func (r *Reporter) Build() (Report, error) {
+	instanceID, err := r.loadInstanceID()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		InstanceID:   instanceID,
+		Version:      version.Version,
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		Mode:         r.Mode,
+		Features:     []string{},
+		Integrations: append([]string{}, r.Integrations...),
+	}
+	slices.Sort(report.Integrations)
+	for flag, enabled := range r.features.All() {
+		if enabled {
+			report.Features = append(report.Features, string(flag))
+		}
+	}
+	slices.Sort(report.Features)
+
+	var projects, files, printJobs int64
+	var size struct{ Total int64 }
+	if err := r.db.Model(&models.Project{}).Count(&projects).Error; err != nil {
+		return Report{}, err
+	}
+	if err := r.db.Model(&models.ProjectFile{}).Count(&files).Error; err != nil {
+		return Report{}, err
+	}
+	if err := r.db.Model(&models.PrintJob{}).Count(&printJobs).Error; err != nil {
+		return Report{}, err
+	}
+	if err := r.db.Model(&models.ProjectFile{}).Select("COALESCE(SUM(size), 0) AS total").Scan(&size).Error; err != nil {
+		return Report{}, err
+	}
+	report.Projects = countRange(projects)
+	report.Files = countRange(files)
+	report.PrintJobs = countRange(printJobs)
+	report.LibrarySize = sizeRange(size.Total)
+	return report, nil
+}
+
+// Send builds a report and posts it to URL, saving the instance ID the first time
+func (r *Reporter) Send(ctx context.Context) (Report, error) {
+	report, err := r.Build()
+	if err == nil {
+		err = r.post(ctx, report)
+	}
+	if err == nil {
+		err = database.SaveSetting(r.db, InstanceSettingKey, report.InstanceID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.lastError = err.Error()
+		return report, err
+	}
+	now := time.Now()
+	r.lastSentAt = &now
+	r.lastError = ""
+	return report, nil
+}
+
+// Run sends a report now and then every interval until ctx is done
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Send(ctx); err != nil {
+			fmt.Printf("Warning: Failed to send usage report: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// post sends report as JSON to URL
+func (r *Reporter) post(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// loadInstanceID returns the saved instance ID, or a new one that is saved
+// with the first report sent
+func (r *Reporter) loadInstanceID() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.instanceID != "" {
+		return r.instanceID, nil
+	}
+
+	var saved string
+	if _, err := database.LoadSetting(r.db, InstanceSettingKey, &saved); err != nil {
+		return "", err
+	}
+	if saved == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return "", err
+		}
+		saved = hex.EncodeToString(id)
+	}
+	r.instanceID = saved
+	return saved, nil
+}
+
+// countRange reports n as an order of magnitude, such as "10-99"
+func countRange(n int64) string {
+	switch {
+	case n <= 0:
+		return "0"
+	case n < 10:
+		return "1-9"
+	case n < 100:
+		return "10-99"
+	case n < 1000:
+		return "100-999"
+	case n < 10000:
+		return "1000-9999"
+	default:
+		return "10000+"
+	}
+}
+
+// sizeRange reports a size in bytes as an order of magnitude, such as "1-10GB"
+func sizeRange(bytes int64) string {
+	const gb = 1 << 30
+	switch {
+	case bytes < gb:
+		return "<1GB"
+	case bytes < 10*gb:
+		return "1-10GB"
+	case bytes < 100*gb:
+		return "10-100GB"
+	case bytes < 1000*gb:
+		return "100GB-1TB"
+	default:
+		return "1TB+"
+	}
+}
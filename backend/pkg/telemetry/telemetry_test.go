@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"3dshelf/internal/models"
+	"3dshelf/pkg/database"
+	"3dshelf/pkg/features"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates an in-memory database with the application schema
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+func TestRanges(t *testing.T) {
+	for n, expected := range map[int64]string{0: "0", 1: "1-9", 42: "10-99", 999: "100-999", 5000: "1000-9999", 123456: "10000+"} {
+		if got := countRange(n); got != expected {
+			t.Errorf("countRange(%d) = %q, expected %q", n, got, expected)
+		}
+	}
+	for size, expected := range map[int64]string{0: "<1GB", 3 << 30: "1-10GB", 50 << 30: "10-100GB", 2 << 40: "1TB+"} {
+		if got := sizeRange(size); got != expected {
+			t.Errorf("sizeRange(%d) = %q, expected %q", size, got, expected)
+		}
+	}
+}
+
+// TestBuild tests that reports carry coarse counts and nothing about the library itself
+func TestBuild(t *testing.T) {
+	db := setupTestDB(t)
+	for i := 0; i < 12; i++ {
+		project := models.Project{Name: "Secret project", Path: "/library/secret-" + string(rune('a'+i)), Designer: "Jane Doe"}
+		db.Create(&project)
+		db.Create(&models.ProjectFile{ProjectID: project.ID, Filename: "secret.stl", Filepath: "/library/secret.stl", FileType: models.FileTypeSTL, Size: 1 << 20})
+	}
+
+	flags := features.New()
+	flags.Set(map[features.Flag]bool{features.Watcher: true})
+	reporter := New(db, flags)
+	reporter.Mode = "all"
+	reporter.Integrations = []string{"thingiverse", "octoprint"}
+
+	report, err := reporter.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if report.Projects != "10-99" || report.Files != "10-99" || report.PrintJobs != "0" || report.LibrarySize != "<1GB" {
+		t.Errorf("Expected coarse counts, got %+v", report)
+	}
+	if !reflect.DeepEqual(report.Integrations, []string{"octoprint", "thingiverse"}) {
+		t.Errorf("Expected sorted integrations, got %v", report.Integrations)
+	}
+	found := false
+	for _, flag := range report.Features {
+		found = found || flag == string(features.Watcher)
+	}
+	if !found {
+		t.Errorf("Expected the enabled features, got %v", report.Features)
+	}
+
+	encoded, _ := json.Marshal(report)
+	for _, secret := range []string{"Secret", "secret", "Jane", "/library"} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("Expected the report not to mention %q: %s", secret, encoded)
+		}
+	}
+}
+
+// TestSend tests that the report sent is the one previewed
+func TestSend(t *testing.T) {
+	db := setupTestDB(t)
+	var received Report
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reporter := New(db, features.New())
+	preview, err := reporter.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if preview.Enabled {
+		t.Error("Expected reports to be off until a URL is set")
+	}
+	if found, _ := database.LoadSetting(db, InstanceSettingKey, new(string)); found {
+		t.Error("Expected previewing not to save an instance ID")
+	}
+
+	reporter.URL = server.URL
+	if _, err := reporter.Send(context.Background()); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !reflect.DeepEqual(received, preview.Report) {
+		t.Errorf("Expected the previewed report to be sent, got %+v, previewed %+v", received, preview.Report)
+	}
+	var saved string
+	database.LoadSetting(db, InstanceSettingKey, &saved)
+	if saved != preview.Report.InstanceID {
+		t.Errorf("Expected the instance ID to be saved, got %q", saved)
+	}
+
+	// A restarted reporter keeps the instance ID
+	if report, _ := New(db, features.New()).Build(); report.InstanceID != saved {
+		t.Errorf("Expected the saved instance ID after a restart, got %q", report.InstanceID)
+	}
+
+	fail = true
+	if _, err := reporter.Send(context.Background()); err == nil {
+		t.Fatal("Expected an error when the endpoint refuses the report")
+	}
+	if status, _ := reporter.Status(); status.LastError == "" || status.LastSentAt == nil {
+		t.Errorf("Expected the failure and the last successful send, got %+v", status)
+	}
+}
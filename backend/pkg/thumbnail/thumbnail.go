@@ -0,0 +1,226 @@
+package thumbnail
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"3dshelf/pkg/gcode"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Sizes are the longest edges thumbnails are generated at, in pixels; only
+// these are served so the cache stays bounded
+var Sizes = []int{128, 256, 512}
+
+// DefaultSize is the size listings link to
+const DefaultSize = 256
+
+const (
+	// jpegQuality is used for thumbnails of opaque images
+	jpegQuality = 85
+
+	// staleTempAge is how old an unfinished generation must be to be pruned
+	staleTempAge = time.Hour
+)
+
+// hashPattern is a SHA-256 content hash as stored for project files
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ValidSize reports whether thumbnails are served at size
+func ValidSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidHash reports whether hash is a content hash thumbnails can be keyed on
+func ValidHash(hash string) bool {
+	return hashPattern.MatchString(hash)
+}
+
+// URL is where the thumbnail of the image with the given content hash is
+// served. It changes whenever the image does, so it can be cached forever.
+func URL(hash string, size int) string {
+	if !ValidHash(hash) {
+		return ""
+	}
+	return fmt.Sprintf("/api/thumbnails/%s/%d", hash, size)
+}
+
+// Cache keeps generated thumbnails on disk, keyed by the content hash of
+// their source image and their size
+type Cache struct {
+	dir string
+
+	// mu keeps concurrent requests from generating the same thumbnail twice
+	mu sync.Mutex
+}
+
+// New creates a Cache keeping thumbnails in dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// path is where the thumbnail is kept, sharded by the first hash byte
+func (c *Cache) path(hash string, size int) string {
+	return filepath.Join(c.dir, hash[:2], hash+"-"+strconv.Itoa(size))
+}
+
+// Get returns the path of the thumbnail of the image at src, or of the
+// thumbnail embedded in the G-code or 3MF file at src, whose content hash is
+// hash, generating it when it isn't cached yet. Opaque images are encoded as
+// JPEG and others as PNG; images are never scaled up.
+func (c *Cache) Get(src, hash string, size int) (string, error) {
+	if !ValidHash(hash) || !ValidSize(size) {
+		return "", fmt.Errorf("invalid thumbnail %q at %d", hash, size)
+	}
+	path := c.path(hash, size)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".thumbnail-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Generate(tmp, src, size); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Generate writes the thumbnail of the image at src, or of the thumbnail
+// embedded in the G-code or 3MF file at src, to out, for files without a
+// content hash to cache it by
+func Generate(out io.Writer, src string, size int) error {
+	img, err := decode(src)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", src, err)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, width*size/longest)
+		height = max(1, height*size/longest)
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+
+	if scaled.Opaque() {
+		return jpeg.Encode(out, scaled, &jpeg.Options{Quality: jpegQuality})
+	}
+	return png.Encode(out, scaled)
+}
+
+// decode reads the image at src; G-code and 3MF files are read for the
+// thumbnail their slicer embedded
+func decode(src string) (image.Image, error) {
+	if gcode.EmbedsThumbnails(src) {
+		return gcode.ReadThumbnail(src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	img, _, err := image.Decode(in)
+	return img, err
+}
+
+// Prune removes the thumbnails whose content hash isn't in live, the images
+// still in the library, and leftovers of interrupted generations, returning
+// how many files it removed
+func (c *Cache) Prune(live map[string]bool) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") {
+			info, err := d.Info()
+			if err != nil || time.Since(info.ModTime()) < staleTempAge {
+				return nil
+			}
+		} else if hash, _, _ := strings.Cut(d.Name(), "-"); live[hash] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// Run prunes the cache every interval until ctx is done, asking live for the
+// content hashes of the images still in the library
+func (c *Cache) Run(ctx context.Context, interval time.Duration, live func() (map[string]bool, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		hashes, err := live()
+		if err != nil {
+			fmt.Printf("Warning: Failed to list images for thumbnail cleanup: %v\n", err)
+			continue
+		}
+		if removed, err := c.Prune(hashes); err != nil {
+			fmt.Printf("Warning: Thumbnail cleanup failed: %v\n", err)
+		} else if removed > 0 {
+			fmt.Printf("Removed %d stale thumbnails\n", removed)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package thumbnail
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// writePNG writes a width x height PNG, transparent when alpha is set
+func writePNG(t *testing.T, path string, width, height int, alpha bool) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255}
+			if alpha && x < width/2 {
+				c.A = 0
+			}
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+}
+
+// TestCacheGet tests generating and reusing thumbnails
+func TestCacheGet(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cover.png")
+	writePNG(t, src, 400, 200, false)
+	cache := New(filepath.Join(dir, "cache"))
+
+	path, err := cache.Get(src, testHash, 128)
+	if err != nil {
+		t.Fatalf("Failed to generate thumbnail: %v", err)
+	}
+	f, _ := os.Open(path)
+	config, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil || format != "jpeg" || config.Width != 128 || config.Height != 64 {
+		t.Errorf("Expected a 128x64 JPEG, got %s %dx%d (%v)", format, config.Width, config.Height, err)
+	}
+
+	// Cached thumbnails are served without reading the source again
+	os.Remove(src)
+	if again, err := cache.Get(src, testHash, 128); err != nil || again != path {
+		t.Errorf("Expected the cached thumbnail, got %q (%v)", again, err)
+	}
+
+	if _, err := cache.Get(src, "../../etc", 128); err == nil {
+		t.Error("Expected an error for a hash that isn't a content hash")
+	}
+	if _, err := cache.Get(src, testHash, 100); err == nil {
+		t.Error("Expected an error for a size that isn't served")
+	}
+}
+
+// TestCacheGetTransparent tests that transparent images keep their alpha
+// and small images aren't scaled up
+func TestCacheGetTransparent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "logo.png")
+	writePNG(t, src, 64, 32, true)
+
+	path, err := New(dir).Get(src, testHash, 256)
+	if err != nil {
+		t.Fatalf("Failed to generate thumbnail: %v", err)
+	}
+	f, _ := os.Open(path)
+	config, format, _ := image.DecodeConfig(f)
+	f.Close()
+	if format != "png" || config.Width != 64 || config.Height != 32 {
+		t.Errorf("Expected a 64x32 PNG, got %s %dx%d", format, config.Width, config.Height)
+	}
+}
+
+// TestCacheGetEmbedded tests thumbnails of the images slicers embed in G-code
+func TestCacheGetEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "embedded.png"), 300, 300, false)
+	data, _ := os.ReadFile(filepath.Join(dir, "embedded.png"))
+	src := filepath.Join(dir, "benchy.gcode")
+	content := "; thumbnail begin 300x300 0\n; " + base64.StdEncoding.EncodeToString(data) + "\n; thumbnail end\nG28\n"
+	os.WriteFile(src, []byte(content), 0644)
+
+	path, err := New(filepath.Join(dir, "cache")).Get(src, testHash, 128)
+	if err != nil {
+		t.Fatalf("Failed to generate thumbnail: %v", err)
+	}
+	f, _ := os.Open(path)
+	config, _, _ := image.DecodeConfig(f)
+	f.Close()
+	if config.Width != 128 || config.Height != 128 {
+		t.Errorf("Expected a 128x128 thumbnail, got %dx%d", config.Width, config.Height)
+	}
+
+	os.WriteFile(src, []byte("G28\n"), 0644)
+	if _, err := New(filepath.Join(dir, "cache")).Get(src, strings.Repeat("b", 64), 128); err == nil {
+		t.Error("Expected an error for G-code without a thumbnail")
+	}
+}
+
+// TestCachePrune tests removing thumbnails of images no longer in the library
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cover.png")
+	writePNG(t, src, 32, 32, false)
+	cache := New(filepath.Join(dir, "cache"))
+
+	staleHash := strings.Repeat("a", 64)
+	kept, _ := cache.Get(src, testHash, 128)
+	stale, _ := cache.Get(src, staleHash, 128)
+
+	removed, err := cache.Prune(map[string]bool{testHash: true})
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected one stale thumbnail removed, got %d (%v)", removed, err)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Error("Expected the live thumbnail to be kept")
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Expected the stale thumbnail to be removed")
+	}
+
+	if removed, err := New(filepath.Join(dir, "missing")).Prune(nil); err != nil || removed != 0 {
+		t.Errorf("Expected nothing to prune in a missing cache, got %d (%v)", removed, err)
+	}
+}
+
+// TestURL tests that thumbnail URLs are keyed on valid content hashes only
+func TestURL(t *testing.T) {
+	if got := URL(testHash, DefaultSize); got != "/api/thumbnails/"+testHash+"/256" {
+		t.Errorf("Unexpected URL %q", got)
+	}
+	if got := URL("", DefaultSize); got != "" {
+		t.Errorf("Expected no URL without a hash, got %q", got)
+	}
+}
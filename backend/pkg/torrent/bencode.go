@@ -0,0 +1,54 @@
+package torrent
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// encode writes v bencoded: strings and byte slices as byte strings, integers,
+// lists of any of them and dictionaries with sorted keys
+func encode(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(v), v)
+	case []byte:
+		fmt.Fprintf(buf, "%d:", len(v))
+		buf.Write(v)
+	case int:
+		fmt.Fprintf(buf, "i%de", v)
+	case int64:
+		fmt.Fprintf(buf, "i%de", v)
+	case []string:
+		buf.WriteByte('l')
+		for _, item := range v {
+			encode(buf, item)
+		}
+		buf.WriteByte('e')
+	case []any:
+		buf.WriteByte('l')
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, key := range keys {
+			encode(buf, key)
+			if err := encode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("cannot bencode %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package torrent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// minPieceLength and maxPieceLength bound the piece size, picked so a
+	// torrent has about targetPieces pieces
+	minPieceLength = 256 << 10
+	maxPieceLength = 16 << 20
+	targetPieces   = 1500
+)
+
+// File is one file of a torrent: its slash-separated path inside the torrent
+// and where it is read from
+type File struct {
+	Path   string
+	Source string
+}
+
+// Torrent is a BitTorrent v1 metainfo file
+type Torrent struct {
+	Name        string
+	InfoHash    string
+	Size        int64
+	PieceLength int64
+	Trackers    []string
+
+	// Data is the bencoded .torrent file
+	Data []byte
+}
+
+// Magnet returns the magnet link of the torrent, with its name and trackers
+func (t *Torrent) Magnet() string {
+	link := "magnet:?xt=urn:btih:" + t.InfoHash + "&dn=" + url.QueryEscape(t.Name)
+	for _, tracker := range t.Trackers {
+		link += "&tr=" + url.QueryEscape(tracker)
+	}
+	return link
+}
+
+// Create hashes files into a multi-file torrent named name, announced to
+// trackers. Without trackers peers find each other through DHT.
+func Create(ctx context.Context, name string, files []File, trackers []string) (*Torrent, error) {
+	if len(files) == 0 {
+		return nil, errors.New("a torrent needs at least one file")
+	}
+
+	var size int64
+	entries := make([]any, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file.Source)
+		if err != nil {
+			return nil, err
+		}
+		size += info.Size()
+		entries[i] = map[string]any{"length": info.Size(), "path": strings.Split(file.Path, "/")}
+	}
+	pieceLength := choosePieceLength(size)
+
+	pieces, err := hashPieces(ctx, files, pieceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	info := map[string]any{
+		"name":         name,
+		"piece length": pieceLength,
+		"pieces":       pieces,
+		"files":        entries,
+	}
+	var infoData bytes.Buffer
+	if err := encode(&infoData, info); err != nil {
+		return nil, err
+	}
+	infoHash := sha1.Sum(infoData.Bytes())
+
+	metainfo := map[string]any{"info": info, "created by": "3DShelf"}
+	if len(trackers) > 0 {
+		metainfo["announce"] = trackers[0]
+		tiers := make([]any, len(trackers))
+		for i, tracker := range trackers {
+			tiers[i] = []string{tracker}
+		}
+		metainfo["announce-list"] = tiers
+	}
+	var data bytes.Buffer
+	if err := encode(&data, metainfo); err != nil {
+		return nil, err
+	}
+
+	return &Torrent{
+		Name:        name,
+		InfoHash:    hex.EncodeToString(infoHash[:]),
+		Size:        size,
+		PieceLength: pieceLength,
+		Trackers:    trackers,
+		Data:        data.Bytes(),
+	}, nil
+}
+
+// choosePieceLength picks the power of two piece size giving about targetPieces pieces
+func choosePieceLength(size int64) int64 {
+	length := int64(minPieceLength)
+	for length < maxPieceLength && size/length > targetPieces {
+		length *= 2
+	}
+	return length
+}
+
+// hashPieces reads the files as one stream and returns the SHA-1 of each
+// piece, the last one shorter
+func hashPieces(ctx context.Context, files []File, pieceLength int64) ([]byte, error) {
+	var pieces []byte
+	piece := sha1.New()
+	var filled int64
+	buf := make([]byte, 64<<10)
+
+	for _, file := range files {
+		f, err := os.Open(file.Source)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			if err := ctx.Err(); err != nil {
+				f.Close()
+				return nil, err
+			}
+			n, err := f.Read(buf[:min(int64(len(buf)), pieceLength-filled)])
+			piece.Write(buf[:n])
+			filled += int64(n)
+			if filled == pieceLength {
+				pieces = piece.Sum(pieces)
+				piece.Reset()
+				filled = 0
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+			}
+		}
+		f.Close()
+	}
+	if filled > 0 {
+		pieces = piece.Sum(pieces)
+	}
+	return pieces, nil
+}
@@ -0,0 +1,87 @@
+package torrent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEncode tests bencoding values with sorted dictionary keys
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	err := encode(&buf, map[string]any{"spam": []any{"a", int64(42)}, "cow": []byte("moo"), "path": []string{"dir", "file"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "d3:cow3:moo4:pathl3:dir4:filee4:spaml1:ai42eee"; buf.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, buf.String())
+	}
+	if err := encode(&buf, 1.5); err == nil {
+		t.Error("Expected an error for a float")
+	}
+}
+
+// TestCreate tests hashing files into pieces across file boundaries
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	first := bytes.Repeat([]byte("a"), minPieceLength+100)
+	second := []byte("second file")
+	os.WriteFile(filepath.Join(dir, "a.stl"), first, 0644)
+	os.MkdirAll(filepath.Join(dir, "parts"), 0755)
+	os.WriteFile(filepath.Join(dir, "parts", "b.stl"), second, 0644)
+	files := []File{
+		{Path: "a.stl", Source: filepath.Join(dir, "a.stl")},
+		{Path: "parts/b.stl", Source: filepath.Join(dir, "parts", "b.stl")},
+	}
+
+	created, err := Create(context.Background(), "benchy", files, []string{"udp://tracker.example.com:6969/announce"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.Size != int64(len(first)+len(second)) || created.PieceLength != minPieceLength || len(created.InfoHash) != 40 {
+		t.Errorf("Unexpected torrent %+v", created)
+	}
+
+	// The second piece spans the end of the first file and all of the second
+	whole := append(append([]byte{}, first...), second...)
+	firstPiece, secondPiece := sha1.Sum(whole[:minPieceLength]), sha1.Sum(whole[minPieceLength:])
+	var pieces bytes.Buffer
+	encode(&pieces, append(firstPiece[:], secondPiece[:]...))
+	data := string(created.Data)
+	if !strings.Contains(data, "6:pieces"+pieces.String()) {
+		t.Error("Expected the pieces to hash the files as one stream")
+	}
+	if !strings.Contains(data, "8:announce39:udp://tracker.example.com:6969/announce") || !strings.Contains(data, "4:pathl5:parts5:b.stle") {
+		t.Errorf("Expected the tracker and nested path in the metainfo, got %q", data)
+	}
+	if magnet := created.Magnet(); !strings.HasPrefix(magnet, "magnet:?xt=urn:btih:"+created.InfoHash+"&dn=benchy&tr=udp%3A%2F%2F") {
+		t.Errorf("Unexpected magnet link %s", magnet)
+	}
+
+	// The same files give the same info hash, whatever the trackers
+	again, _ := Create(context.Background(), "benchy", files, nil)
+	if again.InfoHash != created.InfoHash || strings.Contains(string(again.Data), "announce") {
+		t.Errorf("Expected the same info hash without trackers, got %s", again.InfoHash)
+	}
+
+	if _, err := Create(context.Background(), "empty", nil, nil); err == nil {
+		t.Error("Expected an error for a torrent without files")
+	}
+}
+
+// TestChoosePieceLength tests keeping the piece count near the target
+func TestChoosePieceLength(t *testing.T) {
+	for size, expected := range map[int64]int64{
+		1 << 20: minPieceLength,
+		4 << 30: 4 << 20,
+		1 << 40: maxPieceLength,
+	} {
+		if length := choosePieceLength(size); length != expected {
+			t.Errorf("%d bytes: expected pieces of %d, got %d", size, expected, length)
+		}
+	}
+}
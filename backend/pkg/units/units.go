@@ -0,0 +1,138 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SettingKey is the settings key the instance unit preference is persisted under
+const SettingKey = "units"
+
+// Length is the unit lengths are shown in
+type Length string
+
+const (
+	Millimeters Length = "mm"
+	Inches      Length = "in"
+)
+
+// Mass is the unit weights are shown in
+type Mass string
+
+const (
+	Grams  Mass = "g"
+	Ounces Mass = "oz"
+)
+
+// Temperature is the unit temperatures are shown in
+type Temperature string
+
+const (
+	Celsius    Temperature = "c"
+	Fahrenheit Temperature = "f"
+)
+
+const (
+	mmPerInch  = 25.4
+	gramsPerOz = 28.349523125
+)
+
+// Preferences choose the units values are displayed in. Stored values are
+// always millimeters, grams and degrees Celsius.
+type Preferences struct {
+	Length      Length      `json:"length"`
+	Mass        Mass        `json:"mass"`
+	Temperature Temperature `json:"temperature"`
+}
+
+// Metric and Imperial are the preset preferences
+var (
+	Metric   = Preferences{Length: Millimeters, Mass: Grams, Temperature: Celsius}
+	Imperial = Preferences{Length: Inches, Mass: Ounces, Temperature: Fahrenheit}
+)
+
+// Parse returns the preset named by value: "metric" or "imperial"
+func Parse(value string) (Preferences, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "metric":
+		return Metric, nil
+	case "imperial":
+		return Imperial, nil
+	default:
+		return Preferences{}, fmt.Errorf("unsupported units value: %s", value)
+	}
+}
+
+// Validate checks every unit is supported, defaulting empty ones to metric
+func (p *Preferences) Validate() error {
+	switch p.Length {
+	case "":
+		p.Length = Metric.Length
+	case Millimeters, Inches:
+	default:
+		return fmt.Errorf("unsupported length unit: %s", p.Length)
+	}
+	switch p.Mass {
+	case "":
+		p.Mass = Metric.Mass
+	case Grams, Ounces:
+	default:
+		return fmt.Errorf("unsupported mass unit: %s", p.Mass)
+	}
+	switch p.Temperature {
+	case "":
+		p.Temperature = Metric.Temperature
+	case Celsius, Fahrenheit:
+	default:
+		return fmt.Errorf("unsupported temperature unit: %s", p.Temperature)
+	}
+	return nil
+}
+
+// Value is a quantity converted for display
+type Value struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+	Text  string  `json:"text"`
+}
+
+// Display holds the displayed form of a response's quantities, keyed by the
+// name of the raw field it converts
+type Display map[string]Value
+
+// LengthOf converts a length in millimeters
+func (p Preferences) LengthOf(mm float64) Value {
+	if p.Length == Inches {
+		return newValue(mm/mmPerInch, 4, "in")
+	}
+	return newValue(mm, 3, "mm")
+}
+
+// MassOf converts a mass in grams
+func (p Preferences) MassOf(grams float64) Value {
+	if p.Mass == Ounces {
+		return newValue(grams/gramsPerOz, 2, "oz")
+	}
+	return newValue(grams, 1, "g")
+}
+
+// TemperatureOf converts a temperature in degrees Celsius
+func (p Preferences) TemperatureOf(celsius float64) Value {
+	if p.Temperature == Fahrenheit {
+		return newValue(celsius*9/5+32, 0, "°F")
+	}
+	return newValue(celsius, 0, "°C")
+}
+
+// newValue rounds value to decimals places and formats it with unit
+func newValue(value float64, decimals int, unit string) Value {
+	scale := math.Pow(10, float64(decimals))
+	value = math.Round(value*scale) / scale
+	return Value{
+		Value: value,
+		Unit:  unit,
+		Text:  strconv.FormatFloat(value, 'f', -1, 64) + " " + unit,
+	}
+}
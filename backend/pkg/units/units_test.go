@@ -0,0 +1,53 @@
+package units
+
+import "testing"
+
+func TestConversions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    Value
+		expected Value
+	}{
+		{"Millimeters", Metric.LengthOf(0.2), Value{Value: 0.2, Unit: "mm", Text: "0.2 mm"}},
+		{"Inches", Imperial.LengthOf(25.4), Value{Value: 1, Unit: "in", Text: "1 in"}},
+		{"Layer height in inches", Imperial.LengthOf(0.2), Value{Value: 0.0079, Unit: "in", Text: "0.0079 in"}},
+		{"Grams", Metric.MassOf(12.46), Value{Value: 12.5, Unit: "g", Text: "12.5 g"}},
+		{"Ounces", Imperial.MassOf(1000), Value{Value: 35.27, Unit: "oz", Text: "35.27 oz"}},
+		{"Celsius", Metric.TemperatureOf(215), Value{Value: 215, Unit: "°C", Text: "215 °C"}},
+		{"Fahrenheit", Imperial.TemperatureOf(245), Value{Value: 473, Unit: "°F", Text: "473 °F"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.value != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, tc.value)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	if prefs, err := Parse(" Imperial "); err != nil || prefs != Imperial {
+		t.Errorf("Expected imperial preferences, got %+v (%v)", prefs, err)
+	}
+	if _, err := Parse("nautical"); err == nil {
+		t.Error("Expected error for an unknown preset")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	prefs := Preferences{Mass: Ounces}
+	if err := prefs.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if prefs != (Preferences{Length: Millimeters, Mass: Ounces, Temperature: Celsius}) {
+		t.Errorf("Expected unset units to default to metric, got %+v", prefs)
+	}
+
+	invalid := []Preferences{{Length: "ft"}, {Mass: "lb"}, {Temperature: "k"}}
+	for _, prefs := range invalid {
+		if err := prefs.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", prefs)
+		}
+	}
+}
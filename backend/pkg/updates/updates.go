@@ -0,0 +1,229 @@
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRepository is the GitHub repository releases are checked against
+	DefaultRepository = "jparrill/3dShelf"
+
+	// defaultBaseURL is the GitHub REST API
+	defaultBaseURL = "https://api.github.com"
+
+	// maxHighlights bounds the changelog lines reported for a release
+	maxHighlights = 5
+)
+
+// Status is what the last check found
+type Status struct {
+	// Current is the running version; Latest the newest published release
+	Current string `json:"current"`
+	Latest  string `json:"latest,omitempty"`
+
+	// Available is set when Latest is newer than Current. Development builds
+	// have no version to compare and never report an update.
+	Available bool `json:"available"`
+
+	URL         string     `json:"url,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// Highlights are the first bullet points of the release notes
+	Highlights []string `json:"highlights,omitempty"`
+
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+
+	// Error is why the last check failed; the previous findings are kept
+	Error string `json:"error,omitempty"`
+}
+
+// Checker polls GitHub releases for a newer version; it is safe for concurrent use.
+// A nil Checker reports nothing.
+type Checker struct {
+	BaseURL    string
+	Repository string
+	HTTP       *http.Client
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates a Checker comparing the releases of repository, such as
+// "jparrill/3dShelf", with the current version
+func New(repository, current string) *Checker {
+	return &Checker{
+		BaseURL:    defaultBaseURL,
+		Repository: repository,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+		status:     Status{Current: current},
+	}
+}
+
+// Status returns what the last check found, or nil when checking is off
+func (c *Checker) Status() *Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	status := c.status
+	return &status
+}
+
+// Run checks now and then every interval until ctx is done
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if status, err := c.Check(ctx); err != nil {
+			fmt.Printf("Warning: Update check failed: %v\n", err)
+		} else if status.Available {
+			fmt.Printf("Update available: 3dShelf %s (running %s), see %s\n", status.Latest, status.Current, status.URL)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// release is the part of GitHub's release object the checker reads
+type release struct {
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Check fetches the latest release and records whether it is newer than the
+// running version
+func (c *Checker) Check(ctx context.Context) (Status, error) {
+	latest, err := c.fetchLatest(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.status.CheckedAt = &now
+	if err != nil {
+		c.status.Error = err.Error()
+		return c.status, err
+	}
+
+	publishedAt := latest.PublishedAt
+	c.status = Status{
+		Current:     c.status.Current,
+		Latest:      latest.TagName,
+		Available:   Newer(latest.TagName, c.status.Current),
+		URL:         latest.HTMLURL,
+		PublishedAt: &publishedAt,
+		Highlights:  Highlights(latest.Body),
+		CheckedAt:   &now,
+	}
+	return c.status, nil
+}
+
+// fetchLatest gets the newest published, non-prerelease release
+func (c *Checker) fetchLatest(ctx context.Context) (*release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(c.BaseURL, "/"), c.Repository)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var latest release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("invalid release: %w", err)
+	}
+	if latest.TagName == "" {
+		return nil, fmt.Errorf("release has no tag")
+	}
+	return &latest, nil
+}
+
+// Newer reports whether version latest is newer than current. Both are
+// semantic versions with an optional "v" prefix; a release is newer than a
+// pre-release of the same version. Unparseable versions, such as "dev",
+// never compare newer.
+func Newer(latest, current string) bool {
+	l, lpre, ok := parse(latest)
+	if !ok {
+		return false
+	}
+	c, cpre, ok := parse(current)
+	if !ok {
+		return false
+	}
+
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return lpre == "" && cpre != ""
+}
+
+// parse splits a version like "v1.4.0-rc.1" into its numbers and pre-release
+func parse(version string) ([3]int, string, bool) {
+	var numbers [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	core, pre, _ := strings.Cut(version, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return numbers, "", false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, "", false
+		}
+		numbers[i] = n
+	}
+	return numbers, pre, true
+}
+
+// Highlights returns the first bullet points of markdown release notes
+func Highlights(notes string) []string {
+	var highlights []string
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+			continue
+		}
+		line = strings.TrimSpace(strings.ReplaceAll(line[2:], "**", ""))
+		if line == "" {
+			continue
+		}
+		highlights = append(highlights, line)
+		if len(highlights) == maxHighlights {
+			break
+		}
+	}
+	return highlights
+}
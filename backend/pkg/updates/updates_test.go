@@ -0,0 +1,83 @@
+package updates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	testCases := []struct {
+		latest, current string
+		expected        bool
+	}{
+		{"v1.3.0", "v1.2.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "1.99.99", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.2.0", "v1.2.0-rc.1", true},
+		{"v1.2", "v1.2.0", false},
+		{"v1.3.0", "dev", false},
+		{"nightly", "v1.0.0", false},
+	}
+
+	for _, tc := range testCases {
+		if got := Newer(tc.latest, tc.current); got != tc.expected {
+			t.Errorf("Newer(%q, %q) = %v, expected %v", tc.latest, tc.current, got, tc.expected)
+		}
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	notes := "## What's new\n\n- **Maintenance mode** for backups\n* Demo library\n-\n\nSome prose\n- One\n- Two\n- Three\n- Four\n"
+	expected := []string{"Maintenance mode for backups", "Demo library", "One", "Two", "Three"}
+	if got := Highlights(notes); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// TestCheck tests checking the latest release against the running version
+func TestCheck(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/jparrill/3dShelf/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"tag_name": "v1.4.0", "html_url": "https://github.com/jparrill/3dShelf/releases/tag/v1.4.0",
+			"body": "- Update checker\n- Demo library", "published_at": "2026-10-01T12:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	checker := New(DefaultRepository, "v1.3.2")
+	checker.BaseURL = server.URL
+	if got := checker.Status(); got.Available || got.Current != "v1.3.2" || got.CheckedAt != nil {
+		t.Errorf("Expected an unchecked status, got %+v", got)
+	}
+
+	got, err := checker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !got.Available || got.Latest != "v1.4.0" || len(got.Highlights) != 2 || got.PublishedAt == nil {
+		t.Errorf("Expected v1.4.0 to be available with highlights, got %+v", got)
+	}
+
+	// A failed check keeps what the last one found
+	status = http.StatusForbidden
+	if _, err := checker.Check(context.Background()); err == nil {
+		t.Fatal("Expected an error when GitHub refuses the request")
+	}
+	if got := checker.Status(); !got.Available || got.Latest != "v1.4.0" || got.Error == "" {
+		t.Errorf("Expected the previous findings and the error, got %+v", got)
+	}
+
+	var off *Checker
+	if off.Status() != nil {
+		t.Error("Expected a nil checker to report nothing")
+	}
+}
@@ -21,9 +21,10 @@ describe('API Integration Tests', () => {
       server.use(
         http.post('/api/projects/scan', () => {
           return HttpResponse.json({
-            message: 'Scan completed successfully',
-            project_count: 10
-          })
+            message: 'Scan started',
+            job_id: 1,
+            scan_run: { id: 1, status: 'completed', projects_found: 10, errors: [] }
+          }, { status: 202 })
         })
       )
 
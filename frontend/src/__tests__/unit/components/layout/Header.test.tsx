@@ -6,7 +6,8 @@ import { projectsApi } from '@/lib/api'
 // Mock the API
 jest.mock('@/lib/api', () => ({
   projectsApi: {
-    scanProjects: jest.fn()
+    scanProjects: jest.fn(),
+    getInfo: jest.fn()
   }
 }))
 
@@ -19,6 +20,7 @@ describe('Header Component', () => {
 
   beforeEach(() => {
     jest.clearAllMocks()
+    mockProjectsApi.getInfo.mockResolvedValue({ maintenance: { enabled: false } } as any)
   })
 
   const defaultProps = {
@@ -36,6 +38,41 @@ describe('Header Component', () => {
     expect(screen.getByRole('button', { name: /scan projects/i })).toBeInTheDocument()
   })
 
+  it('shows a banner during maintenance', async () => {
+    mockProjectsApi.getInfo.mockResolvedValue({ maintenance: { enabled: true, message: 'Backing up the library' } } as any)
+    render(<Header {...defaultProps} />)
+
+    expect(await screen.findByText('Read-only maintenance')).toBeInTheDocument()
+    expect(screen.getByText('Backing up the library')).toBeInTheDocument()
+  })
+
+  it('shows no banner outside maintenance', async () => {
+    render(<Header {...defaultProps} />)
+
+    await waitFor(() => {
+      expect(mockProjectsApi.getInfo).toHaveBeenCalled()
+    })
+    expect(screen.queryByText('Read-only maintenance')).not.toBeInTheDocument()
+  })
+
+  it('shows a banner when an update is available', async () => {
+    mockProjectsApi.getInfo.mockResolvedValue({
+      maintenance: { enabled: false },
+      update: {
+        current: 'v1.3.0',
+        latest: 'v1.4.0',
+        available: true,
+        url: 'https://github.com/jparrill/3dShelf/releases/tag/v1.4.0',
+        highlights: ['Update checker', 'Demo library']
+      }
+    } as any)
+    render(<Header {...defaultProps} />)
+
+    expect(await screen.findByText('3DShelf v1.4.0 is available')).toBeInTheDocument()
+    expect(screen.getByText(/Update checker · Demo library/)).toBeInTheDocument()
+    expect(screen.getByRole('link', { name: /release notes/i })).toHaveAttribute('href', 'https://github.com/jparrill/3dShelf/releases/tag/v1.4.0')
+  })
+
   it('handles search input changes', async () => {
     const user = userEvent.setup()
     render(<Header {...defaultProps} />)
@@ -8,7 +8,7 @@ import { Project } from '@/types/project'
 jest.mock('@/lib/api', () => ({
   projectsApi: {
     createProject: jest.fn(),
-    uploadProjectFiles: jest.fn()
+    createProjectFromUpload: jest.fn()
   }
 }))
 
@@ -205,13 +205,14 @@ describe('CreateProjectModal', () => {
     })
   })
 
-  it('creates project with files and calls upload API', async () => {
+  it('creates project with files in one upload request', async () => {
     const user = userEvent.setup()
-    mockProjectsApi.createProject.mockResolvedValue(mockProject)
-    mockProjectsApi.uploadProjectFiles.mockResolvedValue({
-      message: 'Files uploaded',
-      uploaded_files: [],
-      uploaded_count: 1
+    mockProjectsApi.createProjectFromUpload.mockResolvedValue({
+      message: 'Created project Test Project with 1 file(s)',
+      commit_mode: 'atomic',
+      results: [{ filename: 'test.stl', status: 'uploaded' }],
+      counts: { uploaded: 1, renamed: 0, overwritten: 0, skipped: 0, failed: 0 },
+      project: mockProject
     })
 
     render(<CreateProjectModal {...defaultProps} />)
@@ -234,13 +235,12 @@ describe('CreateProjectModal', () => {
     const createButton = screen.getByRole('button', { name: /create project/i })
     await user.click(createButton)
 
-    // Verify project creation was called
+    // The project and its files are sent together
     await waitFor(() => {
-      expect(mockProjectsApi.createProject).toHaveBeenCalledWith('Test Project', '')
+      expect(mockProjectsApi.createProjectFromUpload).toHaveBeenCalledWith('Test Project', '', [mockFile])
     })
+    expect(mockProjectsApi.createProject).not.toHaveBeenCalled()
 
-    // For this test, we'll just verify the component behavior up to project creation
-    // File upload testing will be handled in integration tests where the full flow works better
     await waitFor(() => {
       expect(mockOnProjectCreated).toHaveBeenCalledWith(mockProject)
     })
@@ -0,0 +1,90 @@
+import React from 'react'
+import { render, screen, waitFor } from '@testing-library/react'
+import userEvent from '@testing-library/user-event'
+import { ChakraProvider } from '@chakra-ui/react'
+import { PhoneUploadModal } from '@/components/projects/PhoneUploadModal'
+import { UploadToken } from '@/types/project'
+import { projectsApi } from '@/lib/api'
+import theme from '@/lib/theme'
+
+// Mock the API
+jest.mock('@/lib/api')
+const mockProjectsApi = projectsApi as jest.Mocked<typeof projectsApi>
+
+const mockToken: UploadToken = {
+  token: 'abc123',
+  project_id: 1,
+  expires_at: new Date(Date.now() + 30 * 60 * 1000).toISOString(),
+  uploaded: 0,
+  created_at: new Date().toISOString(),
+  upload_url: 'http://shelf.local/api/upload/abc123',
+  qr_url: 'http://shelf.local/api/projects/1/upload-tokens/abc123/qr'
+}
+
+// Custom render function to include ChakraProvider
+const renderWithChakra = (ui: React.ReactElement) => {
+  return render(
+    <ChakraProvider theme={theme}>
+      {ui}
+    </ChakraProvider>
+  )
+}
+
+// Mock toast
+const mockToast = jest.fn()
+jest.mock('@chakra-ui/react', () => ({
+  ...jest.requireActual('@chakra-ui/react'),
+  useToast: () => mockToast
+}))
+
+describe('PhoneUploadModal', () => {
+  const mockOnClose = jest.fn()
+  const user = userEvent.setup()
+
+  beforeEach(() => {
+    jest.clearAllMocks()
+    mockProjectsApi.getUploadTokenQRUrl.mockReturnValue('http://localhost:8080/api/projects/1/upload-tokens/abc123/qr')
+  })
+
+  it('creates a token and shows its QR code when opened', async () => {
+    mockProjectsApi.createUploadToken.mockResolvedValue(mockToken)
+
+    renderWithChakra(
+      <PhoneUploadModal isOpen={true} onClose={mockOnClose} projectId={1} projectName="Benchy" />
+    )
+
+    await waitFor(() => {
+      expect(screen.getByAltText('Upload QR code')).toHaveAttribute('src', 'http://localhost:8080/api/projects/1/upload-tokens/abc123/qr')
+    })
+    expect(mockProjectsApi.createUploadToken).toHaveBeenCalledWith(1)
+    expect(screen.getByText('Benchy')).toBeInTheDocument()
+    expect(screen.getByText(mockToken.upload_url)).toBeInTheDocument()
+  })
+
+  it('shows the error when the token cannot be created', async () => {
+    mockProjectsApi.createUploadToken.mockRejectedValue({ response: { data: { error: 'Not supported for flat-file projects' } } })
+
+    renderWithChakra(
+      <PhoneUploadModal isOpen={true} onClose={mockOnClose} projectId={1} projectName="Benchy" />
+    )
+
+    expect(await screen.findByText('Not supported for flat-file projects')).toBeInTheDocument()
+  })
+
+  it('revokes the token and closes', async () => {
+    mockProjectsApi.createUploadToken.mockResolvedValue(mockToken)
+    mockProjectsApi.revokeUploadToken.mockResolvedValue()
+
+    renderWithChakra(
+      <PhoneUploadModal isOpen={true} onClose={mockOnClose} projectId={1} projectName="Benchy" />
+    )
+
+    await screen.findByAltText('Upload QR code')
+    await user.click(screen.getByText('Revoke Link'))
+
+    await waitFor(() => {
+      expect(mockProjectsApi.revokeUploadToken).toHaveBeenCalledWith(1, 'abc123')
+      expect(mockOnClose).toHaveBeenCalled()
+    })
+  })
+})
@@ -28,7 +28,9 @@ describe('projectsApi', () => {
 
       const result = await projectsApi.getProjects()
 
-      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/projects')
+      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/projects', {
+        params: { include: 'files' }
+      })
       expect(result).toEqual(mockData)
     })
 
@@ -37,7 +39,9 @@ describe('projectsApi', () => {
       mockAxiosInstance.get.mockRejectedValueOnce(mockError)
 
       await expect(projectsApi.getProjects()).rejects.toThrow('API Error')
-      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/projects')
+      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/projects', {
+        params: { include: 'files' }
+      })
     })
   })
 
@@ -88,14 +92,23 @@ describe('projectsApi', () => {
   })
 
   describe('scanProjects', () => {
-    it('initiates project scan successfully', async () => {
-      const mockResponse = { message: 'Scan completed', project_count: 5 }
-      mockAxiosInstance.post.mockResolvedValueOnce({ data: mockResponse })
+    it('initiates project scan and waits for its job', async () => {
+      const pending = { id: 7, status: 'pending', projects_found: 0, errors: [] }
+      const completed = { ...pending, status: 'completed', projects_found: 5 }
+      mockAxiosInstance.post.mockResolvedValueOnce({ data: { message: 'Scan started', job_id: 7, scan_run: pending } })
+      mockAxiosInstance.get.mockResolvedValueOnce({ data: completed })
 
-      const result = await projectsApi.scanProjects()
+      const result = await projectsApi.scanProjects(0)
 
-      expect(mockAxiosInstance.post).toHaveBeenCalledWith('/api/projects/scan')
-      expect(result).toEqual(mockResponse)
+      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/scan/jobs/7')
+      expect(result).toEqual({ message: 'Scan completed', project_count: 5, scan_run: completed })
+    })
+
+    it('rejects when the scan job fails', async () => {
+      const failed = { id: 7, status: 'failed', projects_found: 0, errors: ['permission denied'] }
+      mockAxiosInstance.post.mockResolvedValueOnce({ data: { message: 'Scan started', job_id: 7, scan_run: failed } })
+
+      await expect(projectsApi.scanProjects()).rejects.toThrow('permission denied')
     })
 
     it('handles scan timeout error', async () => {
@@ -181,6 +194,19 @@ describe('projectsApi', () => {
     })
   })
 
+  describe('getLibraryStats', () => {
+    it('fetches the library print time roll-up successfully', async () => {
+      const totals = { gcode_files: 4, unestimated_files: 1, print_time_seconds: 6000, filament_grams: 5, filament_mm: 2000 }
+      const mockStats = { projects: 2, totals, backlog_projects: 1, backlog: totals }
+      mockAxiosInstance.get.mockResolvedValueOnce({ data: mockStats })
+
+      const result = await projectsApi.getLibraryStats()
+
+      expect(mockAxiosInstance.get).toHaveBeenCalledWith('/api/projects/stats')
+      expect(result).toEqual(mockStats)
+    })
+  })
+
   describe('healthCheck', () => {
     it('performs health check successfully', async () => {
       const mockHealth = { status: 'healthy', project_count: 15 }
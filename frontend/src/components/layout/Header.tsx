@@ -1,4 +1,8 @@
 import {
+  Alert,
+  AlertDescription,
+  AlertIcon,
+  AlertTitle,
   Box,
   Flex,
   Heading,
@@ -7,11 +11,13 @@ import {
   InputGroup,
   InputLeftElement,
   useToast,
-  Icon
+  Icon,
+  Link
 } from '@chakra-ui/react'
-import { useState } from 'react'
+import { useEffect, useState } from 'react'
 import { FiSearch, FiRefreshCw, FiFolder, FiPlus } from 'react-icons/fi'
 import { projectsApi } from '@/lib/api'
+import { MaintenanceState, UpdateStatus } from '@/types/project'
 import { showSuccessToast, showErrorToast } from '@/utils/toast'
 
 interface HeaderProps {
@@ -23,8 +29,27 @@ interface HeaderProps {
 export function Header({ onSearch, onScanComplete, onCreateProject }: HeaderProps) {
   const [isScanning, setIsScanning] = useState(false)
   const [searchQuery, setSearchQuery] = useState('')
+  const [maintenance, setMaintenance] = useState<MaintenanceState | null>(null)
+  const [update, setUpdate] = useState<UpdateStatus | null>(null)
   const toast = useToast()
 
+  // Show banners while the server is read-only for maintenance or out of date
+  useEffect(() => {
+    let cancelled = false
+    projectsApi.getInfo()
+      .then((info) => {
+        if (cancelled) return
+        setMaintenance(info.maintenance)
+        setUpdate(info.update ?? null)
+      })
+      .catch(() => {
+        // Older servers and network errors just show no banner
+      })
+    return () => {
+      cancelled = true
+    }
+  }, [])
+
   const handleScan = async () => {
     setIsScanning(true)
     try {
@@ -44,58 +69,83 @@ export function Header({ onSearch, onScanComplete, onCreateProject }: HeaderProp
   }
 
   return (
-    <Box bg="white" borderBottom="1px solid" borderColor="gray.200" px={6} py={4}>
-      <Flex justify="space-between" align="center">
-        <Flex align="center" gap={4}>
-          <Icon as={FiFolder} boxSize={8} color="brand.500" />
-          <Heading size="lg" color="gray.800">
-            3DShelf
-          </Heading>
-        </Flex>
+    <>
+      {maintenance?.enabled && (
+        <Alert status="warning" variant="solid" justifyContent="center">
+          <AlertIcon />
+          <AlertTitle>Read-only maintenance</AlertTitle>
+          <AlertDescription>
+            {maintenance.message || 'Changes are paused until maintenance is over.'}
+          </AlertDescription>
+        </Alert>
+      )}
+      {update?.available && (
+        <Alert status="info" justifyContent="center">
+          <AlertIcon />
+          <AlertTitle>3DShelf {update.latest} is available</AlertTitle>
+          <AlertDescription>
+            {update.highlights?.length ? `${update.highlights.slice(0, 3).join(' · ')}. ` : ''}
+            {update.url && (
+              <Link href={update.url} isExternal fontWeight="semibold">
+                Release notes
+              </Link>
+            )}
+          </AlertDescription>
+        </Alert>
+      )}
+      <Box bg="white" borderBottom="1px solid" borderColor="gray.200" px={6} py={4}>
+        <Flex justify="space-between" align="center">
+          <Flex align="center" gap={4}>
+            <Icon as={FiFolder} boxSize={8} color="brand.500" />
+            <Heading size="lg" color="gray.800">
+              3DShelf
+            </Heading>
+          </Flex>
 
-        <Flex gap={4} align="center">
-          <Box as="form" onSubmit={handleSearchSubmit}>
-            <InputGroup maxW="400px">
-              <InputLeftElement pointerEvents="none">
-                <Icon as={FiSearch} color="gray.400" />
-              </InputLeftElement>
-              <Input
-                placeholder="Search projects..."
-                value={searchQuery}
-                onChange={(e) => setSearchQuery(e.target.value)}
-                bg="gray.50"
-                border="1px solid"
-                borderColor="gray.200"
-                _focus={{
-                  bg: 'white',
-                  borderColor: 'brand.500',
-                  boxShadow: '0 0 0 1px var(--chakra-colors-brand-500)',
-                }}
-              />
-            </InputGroup>
-          </Box>
+          <Flex gap={4} align="center">
+            <Box as="form" onSubmit={handleSearchSubmit}>
+              <InputGroup maxW="400px">
+                <InputLeftElement pointerEvents="none">
+                  <Icon as={FiSearch} color="gray.400" />
+                </InputLeftElement>
+                <Input
+                  placeholder="Search projects..."
+                  value={searchQuery}
+                  onChange={(e) => setSearchQuery(e.target.value)}
+                  bg="gray.50"
+                  border="1px solid"
+                  borderColor="gray.200"
+                  _focus={{
+                    bg: 'white',
+                    borderColor: 'brand.500',
+                    boxShadow: '0 0 0 1px var(--chakra-colors-brand-500)',
+                  }}
+                />
+              </InputGroup>
+            </Box>
 
-          <Button
-            leftIcon={<Icon as={FiPlus} />}
-            onClick={onCreateProject}
-            colorScheme="brand"
-            mr={2}
-          >
-            Create Project
-          </Button>
+            <Button
+              leftIcon={<Icon as={FiPlus} />}
+              onClick={onCreateProject}
+              colorScheme="brand"
+              mr={2}
+            >
+              Create Project
+            </Button>
 
-          <Button
-            leftIcon={<Icon as={FiRefreshCw} />}
-            onClick={handleScan}
-            isLoading={isScanning}
-            loadingText="Scanning..."
-            colorScheme="brand"
-            variant="outline"
-          >
-            Scan Projects
-          </Button>
+            <Button
+              leftIcon={<Icon as={FiRefreshCw} />}
+              onClick={handleScan}
+              isLoading={isScanning}
+              loadingText="Scanning..."
+              colorScheme="brand"
+              variant="outline"
+            >
+              Scan Projects
+            </Button>
+          </Flex>
         </Flex>
-      </Flex>
-    </Box>
+      </Box>
+    </>
   )
-}
\ No newline at end of file
+}
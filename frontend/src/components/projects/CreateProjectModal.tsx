@@ -100,32 +100,23 @@ export function CreateProjectModal({ isOpen, onClose, onProjectCreated }: Create
     setIsCreating(true)
 
     try {
-      // Step 1: Create the project
-      const project = await projectsApi.createProject(name.trim(), description.trim())
+      let project: Project
 
-      showSuccessToast(toast, 'Project created', `Project "${project.name}" has been created successfully`)
-
-      // Step 2: Upload files if any
       if (files.length > 0) {
+        // Projects with files are created and filled in one atomic request
         setUploadStatus('uploading')
-
-        try {
-          const fileList = new DataTransfer()
-          files.forEach(item => fileList.items.add(item.file))
-
-          const uploadResult = await projectsApi.uploadProjectFiles(project.id, fileList.files)
-
-          setUploadProgress(100)
-          setUploadStatus('complete')
-
-          showSuccessToast(toast, 'Files uploaded', `${uploadResult.uploaded_count} file(s) uploaded successfully`)
-
-          if (uploadResult.errors && uploadResult.errors.length > 0) {
-            showWarningToast(toast, 'Some files failed to upload', uploadResult.errors.join(', '))
-          }
-        } catch (uploadError) {
-          showErrorToast(toast, 'File upload failed', 'Failed to upload files to the project')
+        const uploadResult = await projectsApi.createProjectFromUpload(name.trim(), description.trim(), files.map(item => item.file))
+        if (!uploadResult.project) {
+          throw new Error(uploadResult.error || 'Failed to upload files')
         }
+        project = uploadResult.project
+
+        setUploadProgress(100)
+        setUploadStatus('complete')
+        showSuccessToast(toast, 'Project created', `Project "${project.name}" has been created with ${uploadResult.counts.uploaded} file(s)`)
+      } else {
+        project = await projectsApi.createProject(name.trim(), description.trim())
+        showSuccessToast(toast, 'Project created', `Project "${project.name}" has been created successfully`)
       }
 
       onProjectCreated(project)
@@ -0,0 +1,125 @@
+import {
+  Modal,
+  ModalOverlay,
+  ModalContent,
+  ModalHeader,
+  ModalFooter,
+  ModalBody,
+  ModalCloseButton,
+  Button,
+  Text,
+  VStack,
+  Image,
+  Link,
+  Spinner,
+  Center,
+  Alert,
+  AlertIcon,
+  useToast
+} from '@chakra-ui/react'
+import { useEffect, useState } from 'react'
+import { UploadToken } from '@/types/project'
+import { projectsApi } from '@/lib/api'
+import { showSuccessToast, showErrorToast } from '@/utils/toast'
+
+interface PhoneUploadModalProps {
+  isOpen: boolean
+  onClose: () => void
+  projectId: number
+  projectName: string
+}
+
+// Shows a QR code that opens a page on a phone for adding photos to the project
+export function PhoneUploadModal({ isOpen, onClose, projectId, projectName }: PhoneUploadModalProps) {
+  const [uploadToken, setUploadToken] = useState<UploadToken | null>(null)
+  const [error, setError] = useState<string | null>(null)
+  const [isRevoking, setIsRevoking] = useState(false)
+  const toast = useToast()
+
+  // Each opening gets a fresh token, so an old QR code on screen can't be reused for long
+  useEffect(() => {
+    if (!isOpen) return
+
+    let cancelled = false
+    setUploadToken(null)
+    setError(null)
+    projectsApi.createUploadToken(projectId)
+      .then(token => {
+        if (!cancelled) setUploadToken(token)
+      })
+      .catch((err: any) => {
+        if (!cancelled) setError(err.response?.data?.error || 'Failed to create an upload link')
+      })
+    return () => {
+      cancelled = true
+    }
+  }, [isOpen, projectId])
+
+  const handleRevoke = async () => {
+    if (!uploadToken) return
+
+    setIsRevoking(true)
+    try {
+      await projectsApi.revokeUploadToken(projectId, uploadToken.token)
+      showSuccessToast(toast, 'Upload link revoked', 'The QR code no longer accepts photos')
+      onClose()
+    } catch (err: any) {
+      showErrorToast(toast, 'Failed to revoke upload link', err.response?.data?.error || 'An error occurred')
+    } finally {
+      setIsRevoking(false)
+    }
+  }
+
+  return (
+    <Modal isOpen={isOpen} onClose={onClose} size="md">
+      <ModalOverlay />
+      <ModalContent>
+        <ModalHeader>Upload from Phone</ModalHeader>
+        <ModalCloseButton />
+
+        <ModalBody>
+          {error && (
+            <Alert status="error">
+              <AlertIcon />
+              {error}
+            </Alert>
+          )}
+
+          {!error && !uploadToken && (
+            <Center py={8}>
+              <Spinner />
+            </Center>
+          )}
+
+          {uploadToken && (
+            <VStack spacing={4}>
+              <Text textAlign="center">
+                Scan with your phone camera to add photos to <strong>{projectName}</strong>.
+              </Text>
+              <Image
+                src={projectsApi.getUploadTokenQRUrl(projectId, uploadToken.token)}
+                alt="Upload QR code"
+                boxSize="256px"
+              />
+              <Link href={uploadToken.upload_url} isExternal fontSize="sm" color="brand.500" wordBreak="break-all">
+                {uploadToken.upload_url}
+              </Link>
+              <Text fontSize="sm" color="gray.600">
+                Anyone with this code can add photos until {new Date(uploadToken.expires_at).toLocaleTimeString()}.
+              </Text>
+            </VStack>
+          )}
+        </ModalBody>
+
+        <ModalFooter>
+          <Button variant="ghost" mr={3} onClick={handleRevoke} isDisabled={!uploadToken} isLoading={isRevoking}>
+            Revoke Link
+          </Button>
+          <Button colorScheme="brand" onClick={onClose}>
+            Done
+          </Button>
+        </ModalFooter>
+      </ModalContent>
+    </Modal>
+  )
+}
@@ -14,13 +14,21 @@ import {
   useToast,
   Icon,
 } from '@chakra-ui/react'
-import { FiUpload, FiFile } from 'react-icons/fi'
+import { FiUpload, FiFile, FiFolder } from 'react-icons/fi'
 import { ProjectFile, UploadTask, FileConflict, ConflictResolution } from '@/types/project'
 import { projectsApi } from '@/lib/api'
 import { showSuccessToast, showErrorToast } from '@/utils/toast'
 import { FileConflictResolver } from './FileConflictResolver'
 import { UploadProgressList } from './UploadProgressList'
 
+const ACCEPTED_EXTENSIONS = ['.stl', '.3mf', '.gcode', '.gco', '.dwg', '.step', '.iges', '.stp', '.igs', '.md']
+
+// Files picked from a folder are uploaded under their path inside it
+const uploadName = (file: File) => file.webkitRelativePath || file.name
+
+const isAccepted = (file: File) =>
+  ACCEPTED_EXTENSIONS.some(ext => file.name.toLowerCase().endsWith(ext)) || file.name.includes('README')
+
 interface ProjectFileUploadProps {
   projectId: number
   isOpen: boolean
@@ -41,6 +49,7 @@ export const ProjectFileUpload: React.FC<ProjectFileUploadProps> = ({
   const [conflicts, setConflicts] = useState<FileConflict[]>([])
   const [resolutions, setResolutions] = useState<Record<string, ConflictResolution>>({})
   const fileInputRef = useRef<HTMLInputElement>(null)
+  const folderInputRef = useRef<HTMLInputElement>(null)
   const toast = useToast()
 
   const handleClose = () => {
@@ -58,12 +67,13 @@ export const ProjectFileUpload: React.FC<ProjectFileUploadProps> = ({
     const files = event.target.files
     if (!files) return
 
-    const fileArray = Array.from(files)
+    // Folders may hold files the project can't store, such as licenses or photos
+    const fileArray = Array.from(files).filter(file => !file.webkitRelativePath || isAccepted(file))
     setSelectedFiles(fileArray)
 
     const tasks: UploadTask[] = fileArray.map((file, index) => ({
       id: `${Date.now()}-${index}`,
-      filename: file.name,
+      filename: uploadName(file),
       size: file.size,
       status: 'pending',
       progress: 0
@@ -73,7 +83,7 @@ export const ProjectFileUpload: React.FC<ProjectFileUploadProps> = ({
 
   const checkConflicts = async () => {
     try {
-      const filenames = selectedFiles.map(file => file.name)
+      const filenames = selectedFiles.map(uploadName)
 
       if (!filenames || filenames.length === 0) {
         return true
@@ -114,33 +124,39 @@ export const ProjectFileUpload: React.FC<ProjectFileUploadProps> = ({
     try {
       const formData = new FormData()
 
+      const isFolder = selectedFiles.some(file => file.webkitRelativePath)
       selectedFiles.forEach(file => {
         formData.append('files', file)
+        if (isFolder) {
+          formData.append('paths', file.webkitRelativePath)
+        }
       })
 
       if (resolutions && Object.keys(resolutions).length > 0) {
-        for (const [filename, resolution] of Object.entries(resolutions)) {
-          formData.append(`resolution_${filename}`, resolution)
-        }
+        formData.append('resolutions', JSON.stringify({ files: resolutions }))
       }
 
       const response = await projectsApi.uploadFormData(projectId, formData)
 
       setUploadTasks(prev => prev.map(task => {
-        if (response.uploaded_files.some(f => f.filename === task.filename)) {
-          return { ...task, status: 'completed', progress: 100 }
-        } else if (response.skipped_files?.includes(task.filename)) {
-          return { ...task, status: 'skipped', progress: 0 }
-        } else if (response.errors?.some(error => error.includes(task.filename))) {
-          const error = response.errors.find(e => e.includes(task.filename))
-          return { ...task, status: 'failed', progress: 0, error }
+        const result = response.results.find(r => r.filename === task.filename)
+        switch (result?.status) {
+          case 'uploaded':
+          case 'renamed':
+          case 'overwritten':
+            return { ...task, status: 'completed', progress: 100 }
+          case 'skipped':
+            return { ...task, status: 'skipped', progress: 0 }
+          case 'failed':
+            return { ...task, status: 'failed', progress: 0, error: result.error || result.reason }
         }
         return task
       }))
 
-      showSuccessToast(toast, 'Upload completed', `${response.uploaded_count} files uploaded successfully`)
+      const uploadedFiles = response.results.flatMap(r => (r.file ? [r.file] : []))
+      showSuccessToast(toast, 'Upload completed', `${uploadedFiles.length} files uploaded successfully`)
 
-      onUploadComplete(response.uploaded_files)
+      onUploadComplete(uploadedFiles)
 
       setTimeout(() => {
         handleClose()
@@ -181,18 +197,34 @@ export const ProjectFileUpload: React.FC<ProjectFileUploadProps> = ({
                   ref={fileInputRef}
                   type="file"
                   multiple
-                  accept=".stl,.3mf,.gcode,.gco,.dwg,.step,.iges,.stp,.igs,.md"
+                  accept={ACCEPTED_EXTENSIONS.join(',')}
+                  onChange={handleFileSelect}
+                  style={{ display: 'none' }}
+                />
+                <input
+                  ref={folderInputRef}
+                  type="file"
+                  {...{ webkitdirectory: '' }}
                   onChange={handleFileSelect}
                   style={{ display: 'none' }}
                 />
-                <Button
-                  onClick={() => fileInputRef.current?.click()}
-                  leftIcon={<Icon as={FiFile} />}
-                  variant="outline"
-                  width="full"
-                >
-                  {selectedFiles.length > 0 ? `${selectedFiles.length} files selected` : 'Select Files'}
-                </Button>
+                <HStack spacing={3}>
+                  <Button
+                    onClick={() => fileInputRef.current?.click()}
+                    leftIcon={<Icon as={FiFile} />}
+                    variant="outline"
+                    flex={1}
+                  >
+                    {selectedFiles.length > 0 ? `${selectedFiles.length} files selected` : 'Select Files'}
+                  </Button>
+                  <Button
+                    onClick={() => folderInputRef.current?.click()}
+                    leftIcon={<Icon as={FiFolder} />}
+                    variant="outline"
+                  >
+                    Select Folder
+                  </Button>
+                </HStack>
               </Box>
             )}
 
@@ -50,7 +50,7 @@ export function RenameProjectModal({ isOpen, onClose, project, onSuccess }: Rena
     setIsLoading(true)
 
     try {
-      await projectsApi.updateProject(project.id, name.trim(), description.trim())
+      await projectsApi.updateProject(project.id, name.trim(), description.trim(), project.updated_at)
 
       showSuccessToast(toast, 'Project updated', `Project "${project.name}" has been renamed to "${name.trim()}"`)
 
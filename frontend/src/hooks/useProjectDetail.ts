@@ -19,6 +19,7 @@ export function useProjectDetail() {
   const [error, setError] = useState<string | null>(null)
   const [isSyncing, setIsSyncing] = useState(false)
   const [isUploadModalOpen, setIsUploadModalOpen] = useState(false)
+  const [isPhoneUploadOpen, setIsPhoneUploadOpen] = useState(false)
   const [fileToDelete, setFileToDelete] = useState<ProjectFile | null>(null)
   const [isDeletingFile, setIsDeletingFile] = useState(false)
   const cancelRef = useRef<HTMLButtonElement>(null)
@@ -140,6 +141,8 @@ export function useProjectDetail() {
     isSyncing,
     isUploadModalOpen,
     setIsUploadModalOpen,
+    isPhoneUploadOpen,
+    setIsPhoneUploadOpen,
     isDeleteDialogOpen,
     fileToDelete,
     isDeletingFile,
@@ -2,14 +2,46 @@ import axios from 'axios'
 import {
   Project,
   ProjectFile,
+  FileType,
   ProjectStats,
+  LibraryStats,
+  UnusedModelsReport,
+  LinkIssueKind,
+  LinkIssuesReport,
+  LinkCheckResult,
+  MetadataRow,
+  MetadataImportResponse,
+  RetentionRules,
+  RetentionPlan,
+  RetentionRun,
+  ProjectSummary,
+  ProjectCover,
+  ProjectImagesResponse,
+  SourceSnapshot,
   ProjectsResponse,
   ProjectSearchResponse,
   READMEResponse,
+  SearchSuggestResponse,
+  SectionsResponse,
+  ImportJob,
+  Job,
+  JobStatus,
+  JobsResponse,
+  UpdateREADMERequest,
   ScanResponse,
+  ScanJobResponse,
+  ScanRun,
   UploadCheckResponse,
   UploadResponse,
-  ConflictResolution
+  CreateProjectUploadResponse,
+  ConflictResolution,
+  UploadResolutions,
+  UploadProgress,
+  LabelFormat,
+  RenderView,
+  UploadToken,
+  Capabilities,
+  ServerInfo
 } from '@/types/project'
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080'
@@ -56,7 +88,10 @@ async function downloadFromUrl(url: string, fallbackFilename: string): Promise<v
 export const projectsApi = {
   // Get all projects
   getProjects: async (): Promise<ProjectsResponse> => {
-    const response = await api.get('/api/projects')
+    // Project cards break files down by type, so request the full file list
+    const response = await api.get('/api/projects', {
+      params: { include: 'files' }
+    })
     return response.data
   },
 
@@ -73,6 +108,26 @@ export const projectsApi = {
     return response.data
   },
 
+  // Create a project from its files in one request; the name may be left
+  // empty for a zip or folder, which then names the project
+  createProjectFromUpload: async (name: string, description: string, files: File[]): Promise<CreateProjectUploadResponse> => {
+    const formData = new FormData()
+    formData.append('name', name)
+    formData.append('description', description)
+    const isFolder = files.some(file => file.webkitRelativePath)
+    files.forEach(file => {
+      formData.append('files', file)
+      if (isFolder) {
+        formData.append('paths', file.webkitRelativePath)
+      }
+    })
+
+    const response = await api.post('/api/projects/upload', formData, {
+      timeout: 300000 // 5 minutes for file uploads
+    })
+    return response.data
+  },
+
   // Get a specific project
   getProject: async (id: number): Promise<Project> => {
     const response = await api.get(`/api/projects/${id}`)
@@ -87,13 +142,79 @@ export const projectsApi = {
     return response.data
   },
 
-  // Scan filesystem for projects
-  scanProjects: async (): Promise<ScanResponse> => {
-    const response = await api.post('/api/projects/scan', {}, {
+  // Start importing a remote collection
+  createImport: async (url: string): Promise<{ message: string; job: ImportJob }> => {
+    const response = await api.post('/api/imports', { url })
+    return response.data
+  },
+
+  // Get an import job with per-item progress
+  getImport: async (id: number): Promise<ImportJob> => {
+    const response = await api.get(`/api/imports/${id}`)
+    return response.data
+  },
+
+  // List background jobs, optionally only those in one status
+  getJobs: async (status?: JobStatus): Promise<JobsResponse> => {
+    const response = await api.get('/api/jobs', {
+      params: { status }
+    })
+    return response.data
+  },
+
+  // Queue a dead or cancelled job again
+  retryJob: async (id: number): Promise<{ message: string; job: Job }> => {
+    const response = await api.post(`/api/jobs/${id}/retry`)
+    return response.data
+  },
+
+  // Stop a pending job from running
+  cancelJob: async (id: number): Promise<{ message: string; job: Job }> => {
+    const response = await api.post(`/api/jobs/${id}/cancel`)
+    return response.data
+  },
+
+  // Get home screen sections with their project previews
+  getSections: async (): Promise<SectionsResponse> => {
+    const response = await api.get('/api/sections')
+    return response.data
+  },
+
+  // Get type-ahead suggestions for the search box
+  suggestSearch: async (query: string, limit?: number): Promise<SearchSuggestResponse> => {
+    const response = await api.get('/api/search/suggest', {
+      params: { q: query, limit }
+    })
+    return response.data
+  },
+
+  // Scan filesystem for projects, waiting for the background scan to finish
+  scanProjects: async (pollInterval = 1000): Promise<ScanResponse> => {
+    const response = await api.post<ScanJobResponse>('/api/projects/scan', {}, {
       headers: {
         'Content-Type': 'application/json'
       }
     })
+    let run = response.data.scan_run
+    while (run.status === 'pending' || run.status === 'running') {
+      await new Promise((resolve) => setTimeout(resolve, pollInterval))
+      run = await projectsApi.getScanJob(response.data.job_id)
+    }
+    if (run.status !== 'completed') {
+      throw new Error(run.errors[0] ?? `Scan ${run.status}`)
+    }
+    return { message: 'Scan completed', project_count: run.projects_found, scan_run: run }
+  },
+
+  // Get a background scan's progress
+  getScanJob: async (id: number): Promise<ScanRun> => {
+    const response = await api.get(`/api/scan/jobs/${id}`)
+    return response.data
+  },
+
+  // Cancel a pending or running background scan
+  cancelScanJob: async (id: number): Promise<{ message: string; scan_run: ScanRun }> => {
+    const response = await api.post(`/api/scan/jobs/${id}/cancel`)
     return response.data
   },
 
@@ -138,7 +259,8 @@ export const projectsApi = {
   uploadProjectFilesWithResolution: async (
     id: number,
     files: FileList,
-    resolutions?: Record<string, ConflictResolution>
+    resolutions?: Record<string, ConflictResolution>,
+    all?: ConflictResolution
   ): Promise<UploadResponse> => {
     const formData = new FormData()
 
@@ -147,10 +269,9 @@ export const projectsApi = {
     }
 
     // Add conflict resolutions if provided
-    if (resolutions) {
-      for (const [filename, resolution] of Object.entries(resolutions)) {
-        formData.append(`resolution_${filename}`, resolution)
-      }
+    if (resolutions || all) {
+      const part: UploadResolutions = { files: resolutions, all }
+      formData.append('resolutions', JSON.stringify(part))
     }
 
     const response = await api.post(`/api/projects/${id}/files`, formData, {
@@ -159,45 +280,227 @@ export const projectsApi = {
     return response.data
   },
 
-  // Upload FormData directly (used by upload component)
-  uploadFormData: async (id: number, formData: FormData): Promise<UploadResponse> => {
+  // Upload FormData directly (used by upload component); progress is
+  // reported under uploadId when given
+  uploadFormData: async (id: number, formData: FormData, uploadId?: string): Promise<UploadResponse> => {
     const response = await api.post(`/api/projects/${id}/files`, formData, {
-      timeout: 300000 // 5 minutes for file uploads
+      timeout: 300000, // 5 minutes for file uploads
+      headers: uploadId ? { 'X-Upload-ID': uploadId } : undefined
     })
     return response.data
   },
 
+  // Bytes received so far by an upload sent with an upload ID
+  getUploadProgress: async (uploadId: string): Promise<UploadProgress> => {
+    const response = await api.get(`/api/uploads/${encodeURIComponent(uploadId)}/progress`)
+    return response.data
+  },
+
   // Get project README
   getProjectREADME: async (id: number): Promise<READMEResponse> => {
     const response = await api.get(`/api/projects/${id}/readme`)
     return response.data
   },
 
+  // Update project README and its front matter metadata
+  updateProjectREADME: async (id: number, data: UpdateREADMERequest): Promise<READMEResponse> => {
+    const response = await api.put(`/api/projects/${id}/readme`, data)
+    return response.data
+  },
+
   // Get project statistics
   getProjectStats: async (id: number): Promise<ProjectStats> => {
     const response = await api.get(`/api/projects/${id}/stats`)
     return response.data
   },
 
+  // Get the print time and filament of the whole library and its unprinted backlog
+  getLibraryStats: async (): Promise<LibraryStats> => {
+    const response = await api.get('/api/projects/stats')
+    return response.data
+  },
+
+  // Models not printed or downloaded in the last months, largest first
+  getUnusedModels: async (months = 6, limit?: number): Promise<UnusedModelsReport> => {
+    const params = new URLSearchParams({ months: String(months) })
+    if (limit) params.set('limit', String(limit))
+    const response = await api.get(`/api/reports/unused?${params}`)
+    return response.data
+  },
+
+  // List the broken links found by the last link check
+  getLinkIssues: async (kind?: LinkIssueKind, projectId?: number): Promise<LinkIssuesReport> => {
+    const params = new URLSearchParams()
+    if (kind) params.set('kind', kind)
+    if (projectId) params.set('project_id', String(projectId))
+    const response = await api.get(`/api/reports/links?${params}`)
+    return response.data
+  },
+
+  // Check project links now
+  checkLinks: async (): Promise<LinkCheckResult> => {
+    const response = await api.post('/api/reports/links/check')
+    return response.data
+  },
+
+  // Download every project's metadata as a spreadsheet
+  exportMetadataCsv: async (): Promise<void> => {
+    await downloadFromUrl('/api/admin/metadata.csv', '3dshelf-metadata.csv')
+  },
+
+  // Import an edited metadata spreadsheet, only reporting the changes on a dry run
+  importMetadataCsv: async (csv: Blob | string, dryRun = false): Promise<MetadataImportResponse> => {
+    const response = await api.put('/api/admin/metadata.csv', csv, {
+      params: { dry_run: dryRun },
+      headers: {
+        'Content-Type': 'text/csv'
+      }
+    })
+    return response.data
+  },
+
+  // Get every project's metadata
+  getMetadata: async (): Promise<{ projects: MetadataRow[]; count: number }> => {
+    const response = await api.get('/api/admin/metadata.json')
+    return response.data
+  },
+
+  // Import edited metadata rows, only reporting the changes on a dry run
+  importMetadata: async (projects: MetadataRow[], dryRun = false): Promise<MetadataImportResponse> => {
+    const response = await api.put('/api/admin/metadata.json', { projects }, {
+      params: { dry_run: dryRun },
+      headers: {
+        'Content-Type': 'application/json'
+      }
+    })
+    return response.data
+  },
+
+  // Report the G-code the retention rules would delete
+  planRetention: async (rules: RetentionRules): Promise<{ dry_run: boolean; plan: RetentionPlan }> => {
+    const response = await api.post('/api/admin/retention', { ...rules, dry_run: true })
+    return response.data
+  },
+
+  // Delete the G-code of a reviewed retention plan as a background job
+  applyRetention: async (plan: RetentionPlan): Promise<{ message: string; plan: RetentionPlan; job: Job }> => {
+    const response = await api.post('/api/admin/retention', { ...plan.rules, dry_run: false, token: plan.token })
+    return response.data
+  },
+
+  // Get how the last G-code retention run went
+  getRetention: async (): Promise<{ last_run: RetentionRun | null }> => {
+    const response = await api.get('/api/admin/retention')
+    return response.data
+  },
+
+  // Get the aggregate project summary for the detail page
+  getProjectSummary: async (id: number): Promise<ProjectSummary> => {
+    const response = await api.get(`/api/projects/${id}/summary`)
+    return response.data
+  },
+
+  // Choose the file a project is shown with
+  setProjectCover: async (id: number, fileId: number): Promise<{ message: string; cover: ProjectCover }> => {
+    const response = await api.put(`/api/projects/${id}/cover`, { file_id: fileId })
+    return response.data
+  },
+
+  // Go back to picking a project's cover automatically
+  clearProjectCover: async (id: number): Promise<{ message: string; cover: ProjectCover | null }> => {
+    const response = await api.delete(`/api/projects/${id}/cover`)
+    return response.data
+  },
+
+  // List a project's images for its gallery
+  getProjectImages: async (id: number): Promise<ProjectImagesResponse> => {
+    const response = await api.get(`/api/projects/${id}/images`)
+    return response.data
+  },
+
   // Delete a project file
   deleteProjectFile: async (projectId: number, fileId: number): Promise<{ message: string; deleted_file: { id: number; filename: string } }> => {
     const response = await api.delete(`/api/projects/${projectId}/files/${fileId}`)
     return response.data
   },
 
+  // Rename a project file or move it to another folder of the project
+  renameProjectFile: async (projectId: number, fileId: number, filename: string, updatedAt?: string): Promise<{ message: string; from: string; file: ProjectFile }> => {
+    const response = await api.patch(`/api/projects/${projectId}/files/${fileId}`, {
+      filename,
+      updated_at: updatedAt
+    }, {
+      headers: {
+        'Content-Type': 'application/json'
+      }
+    })
+    return response.data
+  },
+
   downloadProjectFile: async (projectId: number, fileId: number): Promise<void> => {
     await downloadFromUrl(`/api/projects/${projectId}/files/${fileId}/download`, `file_${fileId}`)
   },
 
+  // Thumbnail of an image, or of the one a slicer embedded in a G-code or 3MF file, usable as an <img> src
+  getFileThumbnailUrl: (projectId: number, fileId: number, size = 256): string => {
+    return `${API_BASE_URL}/api/projects/${projectId}/files/${fileId}/thumbnail?size=${size}`
+  },
+
+  // Shaded PNG render of an STL model, usable as an <img> src
+  getFileRenderUrl: (fileId: number, view: RenderView = 'iso', size = 512): string => {
+    return `${API_BASE_URL}/api/files/${fileId}/render.png?view=${view}&size=${size}`
+  },
+
   downloadProject: async (projectId: number): Promise<void> => {
     await downloadFromUrl(`/api/projects/${projectId}/download`, `project_${projectId}.zip`)
   },
 
+  // Download the project as a ZIP, optionally only some file types
+  exportProject: async (projectId: number, types?: FileType[]): Promise<void> => {
+    const params = new URLSearchParams()
+    if (types && types.length > 0) params.set('types', types.join(','))
+    await downloadFromUrl(`/api/projects/${projectId}/export?${params}`, `project_${projectId}.zip`)
+  },
+
+  // Download a printable storage box label; size is a label stock like dk-11209 or <width>x<height> in mm
+  downloadProjectLabel: async (projectId: number, format: LabelFormat = 'pdf', size?: string): Promise<void> => {
+    const params = new URLSearchParams({ format })
+    if (size) params.set('size', size)
+    await downloadFromUrl(`/api/projects/${projectId}/label?${params}`, `project-${projectId}-label.${format}`)
+  },
+
+  // Save a snapshot of the page the project's source URL points at
+  snapshotProjectSource: async (projectId: number): Promise<{ message: string; snapshot: SourceSnapshot }> => {
+    const response = await api.post(`/api/projects/${projectId}/snapshot-source`)
+    return response.data
+  },
+
+  getProjectSourceSnapshot: async (projectId: number): Promise<SourceSnapshot> => {
+    const response = await api.get(`/api/projects/${projectId}/snapshot-source`)
+    return response.data
+  },
+
+  // Create a token whose QR code opens a phone upload page for the project
+  createUploadToken: async (projectId: number): Promise<UploadToken> => {
+    const response = await api.post(`/api/projects/${projectId}/upload-tokens`)
+    return response.data
+  },
+
+  revokeUploadToken: async (projectId: number, token: string): Promise<void> => {
+    await api.delete(`/api/projects/${projectId}/upload-tokens/${token}`)
+  },
+
+  // QR code image for an upload token, usable as an <img> src
+  getUploadTokenQRUrl: (projectId: number, token: string): string => {
+    return `${API_BASE_URL}/api/projects/${projectId}/upload-tokens/${token}/qr`
+  },
+
   // Update a project (rename and/or change description)
-  updateProject: async (id: number, name: string, description?: string): Promise<{ message: string; project: Project }> => {
+  updateProject: async (id: number, name: string, description?: string, updatedAt?: string): Promise<{ message: string; project: Project }> => {
     const response = await api.put(`/api/projects/${id}`, {
       name,
-      description: description || ''
+      description: description || '',
+      updated_at: updatedAt
     }, {
       headers: {
         'Content-Type': 'application/json'
@@ -216,6 +519,17 @@ export const projectsApi = {
   healthCheck: async (): Promise<{ status: string; project_count: number }> => {
     const response = await api.get('/api/health')
     return response.data
+  },
+
+  // Feature flags and integrations supported by the backend
+  getCapabilities: async (): Promise<Capabilities> => {
+    const response = await api.get('/api/capabilities')
+    return response.data
+  },
+
+  getInfo: async (): Promise<ServerInfo> => {
+    const response = await api.get('/api/info')
+    return response.data
   }
 }
 
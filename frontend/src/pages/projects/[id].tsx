@@ -39,10 +39,11 @@ import {
   Tooltip,
   HStack
 } from '@chakra-ui/react'
-import { FiArrowLeft, FiRefreshCw, FiFolder, FiUpload, FiTrash2, FiDownload } from 'react-icons/fi'
+import { FiArrowLeft, FiRefreshCw, FiFolder, FiUpload, FiTrash2, FiDownload, FiSmartphone } from 'react-icons/fi'
 import { getFileTypeIcon, getFileTypeName, formatFileSize } from '@/utils/fileTypes'
 import { getProjectStatusColor } from '@/utils/statusColors'
 import { ProjectFileUpload } from '@/components/projects/ProjectFileUpload'
+import { PhoneUploadModal } from '@/components/projects/PhoneUploadModal'
 import { useProjectDetail } from '@/hooks/useProjectDetail'
 
 export default function ProjectDetailPage() {
@@ -56,6 +57,8 @@ export default function ProjectDetailPage() {
     isSyncing,
     isUploadModalOpen,
     setIsUploadModalOpen,
+    isPhoneUploadOpen,
+    setIsPhoneUploadOpen,
     isDeleteDialogOpen,
     fileToDelete,
     isDeletingFile,
@@ -138,6 +141,14 @@ export default function ProjectDetailPage() {
               >
                 Upload Files
               </Button>
+              <Button
+                leftIcon={<Icon as={FiSmartphone} />}
+                onClick={() => setIsPhoneUploadOpen(true)}
+                colorScheme="brand"
+                variant="outline"
+              >
+                Upload from Phone
+              </Button>
               <Button
                 leftIcon={<Icon as={FiRefreshCw} />}
                 onClick={handleSync}
@@ -353,6 +364,20 @@ export default function ProjectDetailPage() {
           onUploadComplete={handleUploadComplete}
         />
       )}
+
+      {/* Phone Upload Modal */}
+      {project && (
+        <PhoneUploadModal
+          projectId={project.id}
+          projectName={project.name}
+          isOpen={isPhoneUploadOpen}
+          onClose={() => {
+            setIsPhoneUploadOpen(false)
+            // Photos may have arrived from the phone meanwhile
+            handleUploadComplete([])
+          }}
+        />
+      )}
     </Box>
   )
 }
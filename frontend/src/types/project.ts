@@ -1,6 +1,16 @@
 export type ProjectStatus = 'healthy' | 'inconsistent' | 'error'
 
-export type FileType = 'stl' | '3mf' | 'gcode' | 'cad' | 'readme' | 'other'
+export type ProjectLayout = 'directory' | 'flat'
+
+export type FileType = 'stl' | '3mf' | 'gcode' | 'cad' | 'readme' | 'image' | 'other'
+
+export interface DisplayValue {
+  value: number
+  unit: string
+  text: string
+}
+
+export type Display = Record<string, DisplayValue>
 
 export interface ProjectFile {
   id: number
@@ -12,29 +22,125 @@ export interface ProjectFile {
   hash: string
   created_at: string
   updated_at: string
+  source_models?: number[]
+  sliced_variants?: number[]
+  profile?: FileProfile
+  extracted?: Record<string, unknown>
+  gcode_metadata?: GCodeMetadata
+}
+
+export interface GCodeMetadata {
+  slicer?: string
+  printer?: string
+  nozzle_diameter?: number
+  material?: string
+  layer_height?: number
+  print_time_seconds?: number
+  filament_grams?: number
+  filament_mm?: number
+  nozzle_temp?: number
+  bed_temp?: number
+}
+
+export type SupportMode = 'none' | 'build_plate' | 'everywhere'
+
+export interface FileProfile {
+  layer_height: number | null
+  infill_percent: number | null
+  infill_pattern: string
+  supports: SupportMode | ''
+  orientation: string
+  notes: string
+  updated_at: string
+  display?: Display
+}
+
+export type HashPolicy = 'full' | 'none'
+
+export interface ProjectScanSettings {
+  exclude?: string[]
+  max_depth: number
+  hash_policy?: HashPolicy
 }
 
 export interface Project {
   id: number
   name: string
+  slug?: string
   path: string
   description: string
   status: ProjectStatus
+  layout?: ProjectLayout
   last_scanned: string
   created_at: string
   updated_at: string
+  tags?: string[]
+  license?: string
+  designer?: string
+  source?: string
+  rating?: number
+  scan_settings?: ProjectScanSettings
+  public?: boolean
+  list_price?: number
+  cover_filename?: string
+  collection_id?: number
+  file_count?: number
+  total_size?: number
   files?: ProjectFile[]
 }
 
+export interface PrintTotals {
+  gcode_files: number
+  unestimated_files: number
+  print_time_seconds: number
+  filament_grams: number
+  filament_mm: number
+}
+
 export interface ProjectStats {
   total_files: number
   file_types: Record<FileType, number>
   total_size: number
+  print_totals: PrintTotals
+  print_profiles: PrintProfile[]
+}
+
+export interface LibraryStats {
+  projects: number
+  totals: PrintTotals
+  backlog_projects: number
+  backlog: PrintTotals
+}
+
+export type ScanRunStatus = 'pending' | 'running' | 'completed' | 'failed' | 'cancelled'
+
+export interface ScanRun {
+  id: number
+  trigger: 'manual' | 'watcher'
+  status: ScanRunStatus
+  roots: string[]
+  started_at: string
+  finished_at: string | null
+  projects_added: number
+  projects_updated: number
+  projects_removed: number
+  errors: string[]
+  dirs_visited: number
+  projects_found: number
+  files_hashed: number
+  cancel_requested: boolean
+}
+
+export interface ScanJobResponse {
+  message: string
+  job_id: number
+  scan_run: ScanRun
 }
 
 export interface ScanResponse {
   message: string
   project_count: number
+  scan_run: ScanRun
 }
 
 export interface ProjectsResponse {
@@ -46,14 +152,588 @@ export interface ProjectSearchResponse extends ProjectsResponse {
   query: string
 }
 
+export interface ReadinessCheck {
+  key: string
+  label: string
+  passed: boolean
+}
+
+export interface PrintProfile extends GCodeMetadata {
+  file_id: number
+  filename: string
+  plates?: Plate[]
+  display?: Display
+}
+
+export interface Plate extends GCodeMetadata {
+  index: number
+  objects: string[]
+  skipped_objects?: string[]
+  filaments?: SlicedFilament[]
+  gcode?: string
+}
+
+export interface SlicedFilament {
+  tool: number
+  material?: string
+  color?: string
+  name?: string
+  grams?: number
+  mm?: number
+}
+
+export interface FileDetail extends ProjectFile {
+  filaments?: SlicedFilament[]
+  text?: string
+  last_downloaded_at?: string
+  last_printed_at?: string
+}
+
+export interface UnusedModel {
+  id: number
+  project_id: number
+  project_name: string
+  filename: string
+  file_type: FileType
+  size: number
+  last_downloaded_at: string | null
+  last_printed_at: string | null
+  last_used_at: string | null
+}
+
+export interface UnusedModelsReport {
+  models: UnusedModel[]
+  count: number
+  total: number
+  reclaimable_bytes: number
+  months: number
+  since: string
+}
+
+export type LinkIssueKind = 'source_unreachable' | 'missing_file'
+
+export interface LinkIssue {
+  id: number
+  project_id: number
+  kind: LinkIssueKind
+  target: string
+  referrer?: string
+  detail: string
+  first_seen_at: string
+  last_checked_at: string
+}
+
+export interface LinkIssueReport extends LinkIssue {
+  project_name: string
+}
+
+export interface LinkCheckStatus {
+  checked_at?: string
+  projects: number
+  issues: number
+  error?: string
+}
+
+export interface LinkIssuesReport {
+  issues: LinkIssueReport[]
+  count: number
+  projects: number
+  last_check: LinkCheckStatus | null
+}
+
+export interface LinkCheckResult {
+  message: string
+  projects: number
+  issues: number
+  new: LinkIssue[]
+}
+
+// One project's row of the bulk metadata spreadsheet; omitted fields are left unchanged on import
+export interface MetadataRow {
+  id: number
+  name?: string
+  tags?: string[]
+  license?: string
+  designer?: string
+  rating?: number
+}
+
+export type MetadataRowStatus = 'updated' | 'unchanged' | 'failed'
+
+export interface MetadataRowResult {
+  row: number
+  id: number
+  status: MetadataRowStatus
+  changed?: string[]
+  error?: string
+}
+
+export interface MetadataImportResponse {
+  message: string
+  dry_run: boolean
+  counts: Record<MetadataRowStatus, number>
+  rows: MetadataRowResult[]
+}
+
+// Rules selecting the G-code a retention run deletes
+export interface RetentionRules {
+  max_age_days: number
+  keep_latest?: boolean
+  exclude_tags?: string[]
+}
+
+export type RetentionSpareReason = 'excluded_tag' | 'printed' | 'latest_for_model' | 'canonical_copy'
+
+export interface RetentionCandidate {
+  file_id: number
+  project_id: number
+  project_name: string
+  filename: string
+  filepath: string
+  size: number
+  modified_at: string
+  age_days: number
+  last_printed_at?: string
+}
+
+export interface RetentionPlan {
+  rules: RetentionRules
+  cutoff: string
+  files: RetentionCandidate[]
+  count: number
+  reclaimable_bytes: number
+  spared: Partial<Record<RetentionSpareReason, number>>
+  token: string
+}
+
+export interface RetentionRun {
+  job_id: number
+  rules: RetentionRules
+  started_at: string
+  finished_at?: string
+  result?: {
+    deleted: number
+    reclaimed_bytes: number
+    errors?: string[]
+  }
+  error?: string
+}
+
+export interface SimilarFile {
+  file: ProjectFile
+  project_name: string
+  score: number
+  identical: boolean
+}
+
+export interface ProjectSummary {
+  project: Project
+  file_counts: Record<string, number>
+  total_files: number
+  total_size: number
+  cover_image: ProjectFile | null
+  tags: string[]
+  readiness: ReadinessCheck[]
+  ready: boolean
+  print_profiles: PrintProfile[]
+  largest_model: ProjectFile | null
+  gallery: GalleryItem[]
+  cover: ProjectCover | null
+  cover_thumbnail_url?: string
+  latest_prints: PrintJob[]
+}
+
+export interface SourceSnapshotImage {
+  url: string
+  filename: string
+  local_url: string
+}
+
+export interface SourceSnapshot {
+  url: string
+  title: string
+  description: string
+  license: string
+  images: SourceSnapshotImage[]
+  fetched_at: string
+}
+
+export type CoverSource = 'image' | 'embedded' | 'model'
+
+export type RenderView = 'iso' | 'front' | 'back' | 'left' | 'right' | 'top' | 'bottom'
+
+export interface ProjectCover {
+  file_id: number
+  filename: string
+  source: CoverSource
+  chosen: boolean
+  url: string
+  thumbnail_url?: string
+}
+
+export interface ProjectImage {
+  file_id: number
+  filename: string
+  size: number
+  url: string
+  thumbnail_url: string
+  cover: boolean
+}
+
+export interface ProjectImagesResponse {
+  images: ProjectImage[]
+  count: number
+  cover: ProjectCover | null
+}
+
+export type PrintOutcome = 'success' | 'failed' | 'cancelled' | 'printing'
+
+export type FailureReason =
+  | 'warping'
+  | 'adhesion'
+  | 'clog'
+  | 'power_loss'
+  | 'layer_shift'
+  | 'under_extrusion'
+  | 'stringing'
+  | 'supports'
+  | 'filament_runout'
+  | 'other'
+
+export interface FailureCount {
+  reason: FailureReason
+  count: number
+}
+
+export interface FailureGroup {
+  name: string
+  prints: number
+  failed: number
+  failure_rate: number
+  reasons: FailureCount[]
+}
+
+export interface FailureAnalysis {
+  prints: number
+  failed: number
+  failure_rate: number
+  untagged: number
+  reasons: FailureCount[]
+  settings: { setting: string; count: number }[]
+  by_printer: FailureGroup[]
+  by_material: FailureGroup[]
+}
+
+export type PrintMediaKind = 'photo' | 'video'
+
+export interface GalleryItem {
+  filename: string
+  kind: PrintMediaKind
+  url: string
+  thumbnail_url?: string
+  print_job_id?: number
+  print_outcome?: PrintOutcome
+}
+
+export interface PrintMedia {
+  id: number
+  print_job_id: number
+  project_id: number
+  filename: string
+  kind: PrintMediaKind
+  source: 'upload' | 'octoprint'
+  size: number
+  created_at: string
+}
+
+export interface PrintJob {
+  id: number
+  project_id: number
+  file_id: number | null
+  printer: string
+  material: string
+  filament_id?: number
+  order_id?: number
+  external_id?: string
+  filament_grams: number
+  duration_seconds: number
+  cost: number
+  outcome: PrintOutcome
+  failure_reasons: FailureReason[] | null
+  failure_settings: Record<string, string> | null
+  notes: string
+  started_at: string
+  finished_at: string | null
+  created_at: string
+  updated_at: string
+  media?: PrintMedia[]
+  display?: Display
+}
+
+export interface PrintedPart {
+  id: number
+  name: string
+  project_id: number | null
+  file_id: number | null
+  quantity: number
+  location: string
+  bin: string
+  low_stock_threshold: number
+  low_stock: boolean
+  notes: string
+  created_at: string
+  updated_at: string
+}
+
+export type BOMItemKind = 'printed' | 'hardware'
+
+export interface BOMItem {
+  id: number
+  project_id: number
+  kind: BOMItemKind
+  file_id: number | null
+  name: string
+  quantity: number
+  notes: string
+  created_at: string
+  updated_at: string
+}
+
+export interface BOMAvailability extends BOMItem {
+  on_hand: number
+  shortfall: number
+}
+
+export interface BuildCheck {
+  buildable: boolean
+  kits: number
+  printed: BOMAvailability[]
+  hardware: BOMItem[]
+}
+
+export interface ShoppingListItem {
+  name: string
+  quantity: number
+  notes?: string[]
+  projects: { project_id: number; project_name: string; quantity: number }[]
+}
+
+export interface AssemblyPart {
+  filename: string
+  quantity: number
+  file_id?: number
+}
+
+export interface Assembly {
+  id: number
+  project_id: number
+  name: string
+  parts: AssemblyPart[]
+  notes: string
+  created_at: string
+  updated_at: string
+}
+
+export interface AssembliesResponse {
+  assemblies: Assembly[]
+  suggested: Assembly[]
+  count: number
+}
+
+export interface Filament {
+  id: number
+  name: string
+  brand: string
+  material: string
+  color: string
+  diameter_mm: number
+  nozzle_temp_c: number
+  bed_temp_c: number
+  weight_grams: number
+  remaining_grams: number
+  notes: string
+  created_at: string
+  updated_at: string
+  display?: Display
+}
+
+export type LabelFormat = 'png' | 'pdf'
+
+export interface Calibration {
+  id: number
+  printer: string
+  filament_id: number | null
+  material: string
+  flow: number | null
+  pressure_advance: number | null
+  nozzle_temp_c: number | null
+  notes: string
+  calibrated_at: string
+  created_at: string
+  updated_at: string
+  display?: Display
+}
+
+export interface CalibratedSetting {
+  value: number
+  calibration_id: number
+  calibrated_at: string
+}
+
+export interface KnownGoodSettings {
+  printer: string
+  filament_id: number | null
+  material: string
+  flow: CalibratedSetting | null
+  pressure_advance: CalibratedSetting | null
+  nozzle_temp_c: CalibratedSetting | null
+  display?: Display
+}
+
+export interface Collection {
+  id: number
+  name: string
+  source_url: string
+  project_count: number
+  created_at: string
+  updated_at: string
+  projects?: Project[]
+}
+
+export type ImportJobStatus = 'pending' | 'running' | 'rate_limited' | 'completed' | 'failed'
+
+export interface ImportItem {
+  id: number
+  job_id: number
+  remote_id: string
+  name: string
+  url: string
+  status: 'pending' | 'imported' | 'failed'
+  project_id: number | null
+  error?: string
+}
+
+export interface ImportJob {
+  id: number
+  source_url: string
+  provider: string
+  status: ImportJobStatus
+  collection_id: number | null
+  total: number
+  imported: number
+  failed: number
+  error?: string
+  retry_after?: string
+  finished_at: string | null
+  created_at: string
+  updated_at: string
+  items?: ImportItem[]
+}
+
+export type JobStatus = 'pending' | 'running' | 'completed' | 'dead' | 'cancelled'
+
+export interface Job {
+  id: number
+  type: string
+  key?: string
+  payload: unknown
+  status: JobStatus
+  attempts: number
+  max_attempts: number
+  error?: string
+  locked_by?: string
+  locked_until?: string
+  run_at: string
+  started_at: string | null
+  finished_at: string | null
+  created_at: string
+  updated_at: string
+}
+
+export interface JobsResponse {
+  jobs: Job[]
+  count: number
+}
+
+export type SectionSort = 'newest' | 'updated' | 'name' | 'last_scanned'
+
+export interface SectionFilter {
+  query?: string
+  tags?: string[]
+  designer?: string
+  status?: ProjectStatus
+  has_file_types?: FileType[]
+  missing_file_types?: FileType[]
+  added_within_days?: number
+}
+
+export interface Section {
+  id: number
+  name: string
+  position: number
+  filter: SectionFilter
+  sort: SectionSort
+  limit: number
+  cover: string
+  created_at: string
+  updated_at: string
+}
+
+export interface SectionPreview extends Section {
+  projects: Project[]
+  total: number
+}
+
+export interface SectionsResponse {
+  sections: SectionPreview[]
+  count: number
+}
+
+export type SuggestionType = 'name' | 'tag' | 'designer'
+
+export interface SearchSuggestion {
+  text: string
+  type: SuggestionType
+  count: number
+  project_id?: number
+}
+
+export interface SearchSuggestResponse {
+  suggestions: SearchSuggestion[]
+  count: number
+  query: string
+}
+
+export interface READMEMetadata {
+  tags: string[]
+  license: string
+  designer: string
+  source: string
+}
+
 export interface READMEResponse {
   html: string
   raw: string
+  metadata?: READMEMetadata
+  template?: string
+}
+
+export interface UpdateREADMERequest {
+  content: string
+  metadata?: READMEMetadata
+  // The project updated_at the edit is based on; rejected with 409 if the project changed since
+  updated_at?: string
 }
 
 // File upload conflict handling
 export type ConflictResolution = 'overwrite' | 'skip' | 'rename'
 
+// The resolutions part of an upload: by filename, and for every other conflicting file
+export interface UploadResolutions {
+  files?: Record<string, ConflictResolution>
+  all?: ConflictResolution
+}
+
 export interface FileConflict {
   filename: string
   existing_file?: ProjectFile
@@ -64,6 +744,8 @@ export interface FileConflict {
 export interface UploadCheckResponse {
   conflicts: FileConflict[]
   safe: string[]
+  session_token: string
+  expires_at: string
 }
 
 export interface UploadTask {
@@ -77,12 +759,306 @@ export interface UploadTask {
   resolution?: ConflictResolution
 }
 
+export type UploadCommitMode = 'atomic' | 'per_file'
+
+export type UploadStatus = 'uploaded' | 'renamed' | 'overwritten' | 'skipped' | 'failed'
+
+export type UploadReason =
+  | 'unresolved_conflict'
+  | 'skip_requested'
+  | 'already_applied'
+  | 'unsupported_type'
+  | 'invalid_path'
+  | 'invalid_resolution'
+  | 'stale'
+  | 'staging_failed'
+  | 'commit_failed'
+  | 'aborted'
+
+export interface UploadFileResult {
+  filename: string
+  status: UploadStatus
+  reason?: UploadReason
+  error?: string
+  // The resulting record of an uploaded, renamed or overwritten file
+  file?: ProjectFile
+}
+
+export type UploadState = 'receiving' | 'processing' | 'completed' | 'failed'
+
+export interface UploadProgress {
+  upload_id: string
+  project_id: number
+  state: UploadState
+  received_bytes: number
+  // -1 when the upload was sent without a Content-Length
+  total_bytes: number
+  percent?: number
+  bytes_per_second: number
+  status_code?: number
+  started_at: string
+  finished_at?: string
+}
+
 export interface UploadResponse {
   message: string
-  uploaded_files: ProjectFile[]
-  uploaded_count: number
-  skipped_files?: string[]
-  skipped_count?: number
-  errors?: string[]
-  error_count?: number
-}
\ No newline at end of file
+  error?: string
+  commit_mode: UploadCommitMode
+  results: UploadFileResult[]
+  counts: Record<UploadStatus, number>
+  warnings?: string[]
+}
+
+export interface CreateProjectUploadResponse extends UploadResponse {
+  project?: Project
+}
+
+// Short-lived token letting a phone add photos to a project through its QR code
+export interface UploadToken {
+  token: string
+  project_id: number
+  expires_at: string
+  revoked_at?: string
+  uploaded: number
+  created_at: string
+  upload_url: string
+  qr_url: string
+}
+export type FeatureFlag = 'fts' | 'watcher' | 'integrations'
+
+export interface Capabilities {
+  features: Record<FeatureFlag, boolean>
+  integrations: Record<string, boolean>
+}
+
+export interface FileTypeInfo {
+  type: FileType
+  extensions: string[]
+}
+
+export interface ServerInfo {
+  version: string
+  commit: string
+  build_date?: string
+  go_version: string
+  features: Record<FeatureFlag, boolean>
+  file_types: FileTypeInfo[]
+  storage: {
+    backend: string
+    database: string
+  }
+  limits: {
+    max_upload_bytes: number
+    max_header_bytes: number
+  }
+  maintenance: MaintenanceState
+  // Only reported when the server's opt-in update check is on
+  update?: UpdateStatus
+}
+
+// What the server's check of GitHub releases found
+export interface UpdateStatus {
+  current: string
+  latest?: string
+  available: boolean
+  url?: string
+  published_at?: string
+  highlights?: string[]
+  checked_at?: string
+  error?: string
+}
+
+// Read-only maintenance mode; changes are refused with 503 while enabled
+export interface MaintenanceState {
+  enabled: boolean
+  message?: string
+  since?: string
+  retry_after_seconds?: number
+}
+
+export interface Printer {
+  id: number
+  name: string
+  build_x_mm: number
+  build_y_mm: number
+  build_z_mm: number
+  notes: string
+  created_at: string
+  updated_at: string
+}
+
+export interface ModelFit {
+  file_id: number
+  project_id: number
+  filename: string
+  size?: [number, number, number]
+  fits: boolean | null
+  error?: string
+}
+
+export interface ModelDimensions {
+  size: [number, number, number]
+  volume_cm3: number
+}
+
+export interface ScaleResult {
+  file_id: number
+  factor: number
+  original: ModelDimensions
+  scaled: ModelDimensions & { weight_grams: number }
+  material?: string
+  density_g_cm3: number
+  density_known: boolean
+  printers: { printer_id: number; name: string; fits: boolean }[]
+}
+
+export interface BatchPart {
+  file_id: number
+  project_id: number
+  filename: string
+  quantity: number
+  size: [number, number, number]
+  print_time_seconds: number
+  time_source?: 'gcode' | 'request'
+  gcode_file_id?: number
+}
+
+export interface BatchPlan {
+  parts: BatchPart[]
+  printers: {
+    printer_id: number
+    name: string
+    plates: { items: { part_id: number; copies: number }[]; print_time_seconds: number }[]
+    print_time_seconds: number
+  }[]
+  makespan_seconds: number
+  unplaced: { part_id: number; quantity: number; reason: string }[]
+}
+
+export type OrderStatus = 'pending' | 'printing' | 'ready' | 'shipped' | 'cancelled'
+
+export interface OrderItem {
+  project_id: number
+  file_id?: number
+  quantity: number
+  description?: string
+}
+
+export interface Order {
+  id: number
+  customer: string
+  contact: string
+  reference: string
+  items: OrderItem[]
+  due_date: string | null
+  status: OrderStatus
+  price: number
+  notes: string
+  created_at: string
+  updated_at: string
+  progress: {
+    requested: number
+    printed: number
+    items_printed: number[]
+    print_ids: number[]
+  }
+  overdue: boolean
+}
+
+export interface OrderDashboard {
+  by_status: Record<OrderStatus, number>
+  open: Order[]
+  overdue: number
+  due_soon: number
+  due_soon_days: number
+  open_value: number
+  shipped_value: number
+}
+
+export interface QuoteLine {
+  name: string
+  file_id?: number
+  quantity: number
+  material: string
+  filament_grams: number
+  print_time_seconds: number
+  source: 'gcode' | 'estimate'
+  material_cost: number
+  machine_cost: number
+  labor_cost: number
+  unit_cost: number
+  cost: number
+}
+
+export interface Quote {
+  customer?: string
+  notes?: string
+  quoted_at: string
+  currency: string
+  lines: QuoteLine[]
+  cost: number
+  setup_fee: number
+  margin_percent: number
+  margin: number
+  total: number
+  minimum_applied?: boolean
+}
+
+export interface StorefrontTheme {
+  title: string
+  tagline?: string
+  logo_url?: string
+  accent_color?: string
+  quote_url?: string
+}
+
+export interface PublicModel {
+  filename: string
+  file_type: FileType
+  size: number
+}
+
+export interface PublicProject {
+  slug: string
+  name: string
+  description: string
+  tags: string[]
+  license?: string
+  designer?: string
+  price?: number
+  currency: string
+  quote_url?: string
+  cover_url?: string
+  model_count: number
+  images?: string[]
+  models?: PublicModel[]
+  updated_at: string
+}
+
+export type DistributionKind = 'ipfs' | 'torrent'
+
+export interface Distribution {
+  id: number
+  project_id: number
+  kind: DistributionKind
+  content_id: string
+  uri: string
+  file_count: number
+  size: number
+  created_at: string
+}
+
+// How mirroring another instance stands; counts other than projects are of the last run
+export interface ReplicationStatus {
+  source: string
+  cursor?: string
+  last_run_at?: string
+  last_success_at?: string
+  last_error?: string
+  projects: number
+  updated: number
+  deleted: number
+  failed: number
+  files_fetched: number
+  bytes_fetched: number
+}